@@ -0,0 +1,34 @@
+package totext
+
+import (
+	"fmt"
+
+	"github.com/pilinux/totext/internal/charset"
+)
+
+// DetectCharset returns the charset of HTML or text content, e.g. utf-8,
+// shift_jis or windows-1252. It is read from the byte order mark, then
+// from the meta elements of HTML content, then from the HTTP Content-Type
+// header, which may be empty, and it is guessed from the bytes otherwise.
+func DetectCharset(content []byte, isHTML bool, contentType string) string {
+	name, _ := charset.Detect(content, isHTML, contentType)
+	return name
+}
+
+// decodeCharset transcodes the content to UTF-8 from the charset given
+// with WithCharset or else detected, it returns the name of the charset
+func decodeCharset(content []byte, isHTML bool, o *Options) ([]byte, string, error) {
+	name := charset.Name(o.Charset)
+	switch {
+	case name == "" && o.Charset != "":
+		return nil, "", fmt.Errorf("unknown charset %q", o.Charset)
+	case name == "":
+		name = DetectCharset(content, isHTML, o.ContentType)
+	}
+
+	decoded, err := charset.Decode(content, name)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %s: %w", ErrCorrupt, name, err)
+	}
+	return decoded, name, nil
+}
@@ -12,47 +12,28 @@ import (
 
 // ConvertDocToText receives MS word doc filepath as an argument
 // writes its text content and metadata into two separate files
-func ConvertDocToText(filepath string) error {
+//
+// out selects the format and the destination of the output.
+func ConvertDocToText(filepath string, out Output) error {
 	filepath = strings.TrimSpace(filepath)
 
+	// Check the output
+	if err := out.check(); err != nil {
+		return err
+	}
+
 	// Get file extension from filepath
 	fileExt := totext.GetFileExtension(filepath)
 	if fileExt != totext.DOC {
-		return fmt.Errorf("file type not supported")
-	}
-
-	// Convert doc to text
-	content, metadata, err := totext.ConvertDocToText(filepath)
-	if err != nil {
-		return err
+		return totext.ErrUnsupportedFormat
 	}
 
 	// Get filename from filepath
 	filename := totext.GetFilename(filepath)
 	filenameWithoutExtension := strings.TrimSuffix(filename, ".doc")
 
-	// Set current working directory
-	err = totext.SetCwd(filepath)
-	if err != nil {
-		return err
-	}
-
-	// Write content to a txt file
-	err = totext.WriteText(filenameWithoutExtension+".txt", content)
-	if err != nil {
-		return err
-	}
-
-	// Write metadata to a txt file
-	err = totext.WriteText(
-		filenameWithoutExtension+"_metadata.txt",
-		fmt.Sprintf("%v", metadata),
-	)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	// Convert doc to text and write it to a txt file
+	return convertFile(filepath, fileExt, filenameWithoutExtension, out)
 }
 
 // DocCmd defines the "doc" command
@@ -62,16 +43,25 @@ func DocCmd(appName string) *cobra.Command {
 		Short: "Extract text from a MS word doc file and write it to a txt file",
 		Args:  cobra.ExactArgs(1), // doc filepath
 		Run: func(cmd *cobra.Command, args []string) {
-			// Convert doc to text
-			err := ConvertDocToText(args[0])
+			// Get the values of the output flags
+			out, err := outputFlags(cmd)
 			if err != nil {
-				fmt.Println(err)
+				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
+
+			// Convert doc to text
+			err = ConvertDocToText(args[0], out)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(ExitCode(err))
+			}
 		},
 	}
+	// Add the output flags as optional arguments
+	addOutputFlags(docCmd)
 	docCmd.SetUsageFunc(func(cmd *cobra.Command) error {
-		fmt.Println("Usage:", appName, docCmd.Use, "[file.doc or /path/to/file.doc]")
+		fmt.Println("Usage:", appName, docCmd.Use, "[file.doc or /path/to/file.doc] [--output-format or -o text|json] [--stdout]")
 		return nil
 	})
 
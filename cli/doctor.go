@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pilinux/totext"
+)
+
+// Doctor checks the external tools used by the converters and
+// writes the report as a table or as JSON. It returns the number
+// of missing tools.
+func Doctor(w io.Writer, asJSON bool) (missing int, err error) {
+	deps := totext.CheckDependencies()
+	missing = len(totext.MissingDependencies(deps))
+
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return missing, encoder.Encode(deps)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, err = fmt.Fprintln(tw, "TOOL\tPACKAGE\tSTATUS\tVERSION\tFORMATS\tPATH")
+	if err != nil {
+		return missing, err
+	}
+	for _, dep := range deps {
+		status := "ok"
+		switch {
+		case !dep.Installed && dep.Optional:
+			status = "optional"
+		case !dep.Installed:
+			status = "missing"
+		}
+		_, err = fmt.Fprintf(
+			tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			dep.Name, dep.Package, status, dep.Version,
+			strings.Join(dep.Formats, ","), dep.Path,
+		)
+		if err != nil {
+			return missing, err
+		}
+	}
+	if err = tw.Flush(); err != nil {
+		return missing, err
+	}
+
+	// Print how to install the missing tools
+	for _, dep := range totext.MissingDependencies(deps) {
+		_, err = fmt.Fprintf(w, "\n%s: %s", dep.Name, dep.Note)
+		if err != nil {
+			return missing, err
+		}
+	}
+	if missing > 0 {
+		_, err = fmt.Fprintln(w)
+	}
+
+	return missing, err
+}
+
+// DoctorCmd defines the "doctor" command
+func DoctorCmd(appName string) *cobra.Command {
+	var doctorCmd = &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the external tools required to convert documents",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Get the value of the json flag
+			asJSON, err := cmd.Flags().GetBool("json")
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			// Check the dependencies
+			missing, err := Doctor(os.Stdout, asJSON)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if missing > 0 {
+				os.Exit(ExitMissingDependency)
+			}
+		},
+	}
+	// Add the json flag as an optional argument
+	doctorCmd.Flags().BoolP(
+		"json",
+		"j",
+		false,
+		"print the report as JSON",
+	)
+	doctorCmd.SetUsageFunc(func(cmd *cobra.Command) error {
+		fmt.Println("Usage:", appName, doctorCmd.Use, "[--json or -j]")
+		return nil
+	})
+
+	return doctorCmd
+}
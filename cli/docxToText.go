@@ -12,66 +12,58 @@ import (
 
 // ConvertDocxToText receives MS word docx filepath as an argument
 // writes its text content and metadata into two separate files
-func ConvertDocxToText(filepath string) error {
+//
+// out selects the format of the text content, the format and the
+// destination of the output.
+func ConvertDocxToText(filepath string, out Output) error {
 	filepath = strings.TrimSpace(filepath)
 
+	// Check the output
+	if err := out.check(); err != nil {
+		return err
+	}
+
 	// Get file extension from filepath
 	fileExt := totext.GetFileExtension(filepath)
 	if fileExt != totext.DOCX {
-		return fmt.Errorf("file type not supported")
-	}
-
-	// Convert docx to text
-	content, metadata, err := totext.ConvertDocxToText(filepath)
-	if err != nil {
-		return err
+		return totext.ErrUnsupportedFormat
 	}
 
 	// Get filename from filepath
 	filename := totext.GetFilename(filepath)
 	filenameWithoutExtension := strings.TrimSuffix(filename, ".docx")
 
-	// Set current working directory
-	err = totext.SetCwd(filepath)
-	if err != nil {
-		return err
-	}
-
-	// Write content to a txt file
-	err = totext.WriteText(filenameWithoutExtension+".txt", content)
-	if err != nil {
-		return err
-	}
-
-	// Write metadata to a txt file
-	err = totext.WriteText(
-		filenameWithoutExtension+"_metadata.txt",
-		fmt.Sprintf("%v", metadata),
-	)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	// Convert docx to text and write it to a txt or md file
+	return convertFile(filepath, fileExt, filenameWithoutExtension, out)
 }
 
 // DocxCmd defines the "docx" command
 func DocxCmd(appName string) *cobra.Command {
 	var docxCmd = &cobra.Command{
 		Use:   "docx",
-		Short: "Extract text from a MS word docx file and write it to a txt file",
+		Short: "Extract text from a MS word docx file and write it to a txt or md file",
 		Args:  cobra.ExactArgs(1), // docx filepath
 		Run: func(cmd *cobra.Command, args []string) {
-			// Convert docx to text
-			err := ConvertDocxToText(args[0])
+			// Get the values of the format and output flags
+			out, err := outputFlags(cmd)
 			if err != nil {
-				fmt.Println(err)
+				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
+
+			// Convert docx to text
+			err = ConvertDocxToText(args[0], out)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(ExitCode(err))
+			}
 		},
 	}
+	// Add the format and output flags as optional arguments
+	addFormatFlag(docxCmd)
+	addOutputFlags(docxCmd)
 	docxCmd.SetUsageFunc(func(cmd *cobra.Command) error {
-		fmt.Println("Usage:", appName, docxCmd.Use, "[file.docx or /path/to/file.docx]")
+		fmt.Println("Usage:", appName, docxCmd.Use, "[file.docx or /path/to/file.docx] [--format or -f txt|md] [--output-format or -o text|json] [--stdout]")
 		return nil
 	})
 
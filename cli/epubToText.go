@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pilinux/totext"
+)
+
+// ConvertEPUBToText receives epub filepath as an argument
+// and writes its text content and metadata into two separate files
+//
+// out selects the format of the text content, the format and the
+// destination of the output.
+func ConvertEPUBToText(filepath string, out Output) error {
+	filepath = strings.TrimSpace(filepath)
+
+	// Check the output
+	if err := out.check(); err != nil {
+		return err
+	}
+
+	// Get file extension from filepath
+	fileExt := totext.GetFileExtension(filepath)
+	if fileExt != totext.EPUB {
+		return totext.ErrUnsupportedFormat
+	}
+
+	// Get filename from filepath
+	filename := totext.GetFilename(filepath)
+	filenameWithoutExtension := strings.TrimSuffix(filename, path.Ext(filename))
+
+	// Convert epub to text and write it to a txt or md file
+	return convertFile(filepath, fileExt, filenameWithoutExtension, out)
+}
+
+// EPUBCmd defines the "epub" command
+func EPUBCmd(appName string) *cobra.Command {
+	var epubCmd = &cobra.Command{
+		Use:   "epub",
+		Short: "Extract text from an epub file and write it to a txt or md file",
+		Args:  cobra.ExactArgs(1), // epub filepath
+		Run: func(cmd *cobra.Command, args []string) {
+			// Get the values of the format and output flags
+			out, err := outputFlags(cmd)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			// Convert epub to text
+			err = ConvertEPUBToText(args[0], out)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(ExitCode(err))
+			}
+		},
+	}
+	// Add the format and output flags as optional arguments
+	addFormatFlag(epubCmd)
+	addOutputFlags(epubCmd)
+	epubCmd.SetUsageFunc(func(cmd *cobra.Command) error {
+		fmt.Println("Usage:", appName, epubCmd.Use, "[file.epub or /path/to/file.epub] [--format or -f txt|md] [--output-format or -o text|json] [--stdout]")
+		return nil
+	})
+
+	return epubCmd
+}
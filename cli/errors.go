@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"context"
+	"errors"
+
+	"github.com/pilinux/totext"
+)
+
+// Exit codes of the command line tool
+const (
+	ExitOK                = 0
+	ExitFailure           = 1
+	ExitUnsupportedFormat = 3
+	ExitMissingDependency = 4
+	ExitEncrypted         = 5
+	ExitCorrupt           = 6
+	ExitTimeout           = 7
+	ExitHTTPStatus        = 8
+	ExitInvalidURL        = 9
+)
+
+// ExitCode returns the exit code for the error returned by a command
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, totext.ErrUnsupportedFormat):
+		return ExitUnsupportedFormat
+	case errors.Is(err, totext.ErrMissingDependency{}):
+		return ExitMissingDependency
+	case errors.Is(err, totext.ErrEncrypted):
+		return ExitEncrypted
+	case errors.Is(err, totext.ErrCorrupt):
+		return ExitCorrupt
+	case errors.Is(err, totext.ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return ExitTimeout
+	case errors.Is(err, totext.ErrHTTPStatus{}):
+		return ExitHTTPStatus
+	case errors.Is(err, totext.ErrInvalidURL):
+		return ExitInvalidURL
+	default:
+		return ExitFailure
+	}
+}
@@ -3,6 +3,7 @@ package cli
 import (
 	"fmt"
 	"os"
+	"path"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -12,87 +13,154 @@ import (
 
 // ConvertFileToText receives filepath as an argument and writes
 // its text content and metadata into two separate files
-func ConvertFileToText(filepath string) error {
+//
+// out selects the format of the text content, the format and the
+// destination of the output, opts are passed to the converter.
+func ConvertFileToText(filepath string, out Output, opts ...totext.Option) error {
 	filepath = strings.TrimSpace(filepath)
 
-	// Get file extension from filepath
-	fileExt := totext.GetFileExtension(filepath)
-
-	var content string
-	var metadata map[string]string
-	var err error
-
-	switch fileExt {
-	case totext.DOC:
-		// Convert doc to text
-		content, metadata, err = totext.ConvertDocToText(filepath)
-	case totext.DOCX:
-		// Convert docx to text
-		content, metadata, err = totext.ConvertDocxToText(filepath)
-	case totext.HTML:
-		// Convert HTML to text
-		content, metadata, err = totext.ConvertHTMLToText(filepath, false)
-	case totext.ODT:
-		// Convert odt to text
-		content, metadata, err = totext.ConvertOdtToText(filepath)
-	case totext.PDF:
-		// Convert PDF to text
-		content, metadata, err = totext.ConvertPDFToText(filepath)
-	case totext.RTF:
-		// Convert rtf to text
-		content, metadata, err = totext.ConvertRTFToText(filepath)
-	default:
-		return fmt.Errorf("file type not supported")
-	}
-	if err != nil {
+	// Check the output
+	if err := out.check(); err != nil {
 		return err
 	}
 
-	// Get filename from filepath
-	filename := totext.GetFilename(filepath)
-	filenameWithoutExtension := strings.TrimSuffix(filename, "."+string(fileExt))
-
-	// Set current working directory
-	err = totext.SetCwd(filepath)
+	// Get the format registered for the file, which is detected
+	// from the content when the extension is missing or does not
+	// match the content
+	fileExt, _, err := totext.DetectFileFormat(filepath)
 	if err != nil {
 		return err
 	}
 
-	// Write content to a txt file
-	err = totext.WriteText(filenameWithoutExtension+".txt", content)
-	if err != nil {
-		return err
-	}
+	// Get filename from filepath
+	filename := totext.GetFilename(filepath)
+	filenameWithoutExtension := strings.TrimSuffix(filename, path.Ext(filename))
 
-	// Write metadata to a txt file
-	err = totext.WriteText(
-		filenameWithoutExtension+"_metadata.txt",
-		fmt.Sprintf("%v", metadata),
-	)
-	if err != nil {
-		return err
+	// Keep the extension of txt, md and json files,
+	// the output must not overwrite them
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".txt", ".md", ".json":
+		filenameWithoutExtension = filename
 	}
 
-	return nil
+	// Convert file to text and write it to a txt or md file
+	return convertFile(filepath, fileExt, filenameWithoutExtension, out, opts...)
 }
 
 // FileCmd defines the "file" command
 func FileCmd(appName string) *cobra.Command {
 	var fileCmd = &cobra.Command{
 		Use:   "file",
-		Short: "Extract text from a file and write it to a txt file",
+		Short: "Extract text from a file and write it to a txt or md file",
 		Args:  cobra.ExactArgs(1), // filepath
 		Run: func(cmd *cobra.Command, args []string) {
-			// Convert file to text
-			err := ConvertFileToText(args[0])
+			// Get the values of the format and output flags
+			out, err := outputFlags(cmd)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			// Get the values of the json flags
+			jsonKeys, err := cmd.Flags().GetStringSlice("json-keys")
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			jsonKeyValues, err := cmd.Flags().GetBool("json-key-values")
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			// Get the values of the sheet flags
+			sheetFormat, err := cmd.Flags().GetString("sheet-format")
 			if err != nil {
-				fmt.Println(err)
+				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
+			hiddenSheets, err := cmd.Flags().GetBool("hidden-sheets")
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			formulas, err := cmd.Flags().GetBool("formulas")
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			// Get the value of the notes flag
+			notes, err := cmd.Flags().GetBool("notes")
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			// Get the value of the prettify flag
+			prettify, err := cmd.Flags().GetBool("prettify")
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			// Convert file to text
+			err = ConvertFileToText(args[0], out,
+				totext.WithPrettify(prettify),
+				totext.WithJSONKeys(jsonKeys...), totext.WithJSONKeyValues(jsonKeyValues),
+				totext.WithSheetFormat(totext.SheetFormat(sheetFormat)),
+				totext.WithHiddenSheets(hiddenSheets), totext.WithFormulas(formulas),
+				totext.WithSpeakerNotes(notes))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(ExitCode(err))
+			}
 		},
 	}
+	// Add the format and output flags as optional arguments
+	addFormatFlag(fileCmd)
+	addOutputFlags(fileCmd)
+	// Add the json flags as optional arguments
+	fileCmd.Flags().StringSlice(
+		"json-keys",
+		nil,
+		"key paths of the string values of json files, e.g. title,items.*.name",
+	)
+	fileCmd.Flags().Bool(
+		"json-key-values",
+		false,
+		"write the string values of json files as key.path: value lines",
+	)
+	// Add the sheet flags as optional arguments
+	fileCmd.Flags().String(
+		"sheet-format",
+		string(totext.SheetFormatText),
+		"layout of the sheets of xlsx and ods files: text, tsv or csv",
+	)
+	fileCmd.Flags().Bool(
+		"hidden-sheets",
+		false,
+		"include the hidden sheets of xlsx and ods files",
+	)
+	fileCmd.Flags().Bool(
+		"formulas",
+		false,
+		"write the formulas of the cells of xlsx and ods files instead of their values",
+	)
+	// Add the notes flag as an optional argument
+	fileCmd.Flags().Bool(
+		"notes",
+		false,
+		"include the speaker notes of the slides of pptx and odp files",
+	)
+	// Add the prettify flag as an optional argument
+	fileCmd.Flags().Bool(
+		"prettify",
+		true,
+		"prettify html files with prettier (npx) before extracting their text",
+	)
 	fileCmd.SetUsageFunc(func(cmd *cobra.Command) error {
-		fmt.Println("Usage:", appName, fileCmd.Use, "[file.extension or /path/to/file.extension]")
+		fmt.Println("Usage:", appName, fileCmd.Use, "[file.extension or /path/to/file.extension] [--format or -f txt|md] [--output-format or -o text|json] [--stdout] [--json-keys key.path,...] [--json-key-values] [--sheet-format text|tsv|csv] [--hidden-sheets] [--formulas] [--notes] [--prettify=false]")
 		return nil
 	})
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -12,20 +13,32 @@ import (
 
 // ConvertHTMLToText receives HTML filepath as an argument and
 // writes its text content and metadata into two separate files
-func ConvertHTMLToText(filepath string, skipPrettifyError bool) error {
+//
+// out selects the format of the text content, the format and the
+// destination of the output.
+func ConvertHTMLToText(filepath string, skipPrettifyError bool, out Output) error {
 	filepath = strings.TrimSpace(filepath)
 
+	// Check the output
+	if err := out.check(); err != nil {
+		return err
+	}
+
 	// Get file extension from filepath
 	fileExt := totext.GetFileExtension(filepath)
 	if fileExt != totext.HTML {
-		return fmt.Errorf("file type not supported")
+		return totext.ErrUnsupportedFormat
 	}
+	res := newResult(filepath, fileExt, out, time.Now())
 
 	// Convert HTML to text
-	content, metadata, err := totext.ConvertHTMLToText(filepath, skipPrettifyError)
+	var warnings []string
+	content, metadata, err := totext.ConvertHTMLToText(filepath, skipPrettifyError,
+		totext.WithTextFormat(out.TextFormat), totext.WithWarnings(&warnings))
 	if err != nil {
 		return err
 	}
+	res.finish(content, metadata, warnings)
 
 	// Get filename from filepath
 	filename := totext.GetFilename(filepath)
@@ -37,47 +50,40 @@ func ConvertHTMLToText(filepath string, skipPrettifyError bool) error {
 		return err
 	}
 
-	// Write content to a txt file
-	err = totext.WriteText(filenameWithoutExtension+".txt", content)
-	if err != nil {
-		return err
-	}
-
-	// Write metadata to a txt file
-	err = totext.WriteText(
-		filenameWithoutExtension+"_metadata.txt",
-		fmt.Sprintf("%v", metadata),
-	)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	// Write content and metadata
+	return writeResult(out, filenameWithoutExtension, res)
 }
 
 // HTMLCmd defines the "html" command
 func HTMLCmd(appName string) *cobra.Command {
 	var htmlCmd = &cobra.Command{
 		Use:   "html",
-		Short: "Extract text content from an HTML file and write it to a txt file",
+		Short: "Extract text content from an HTML file and write it to a txt or md file",
 		Args:  cobra.MinimumNArgs(1), // html filepath
 		Run: func(cmd *cobra.Command, args []string) {
 			// Get the value of the skipPrettifyError flag
 			skipPrettifyError, err := cmd.Flags().GetBool("skipPrettifyError")
 			if err != nil {
-				fmt.Println(err)
+				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
 			if skipPrettifyError {
-				fmt.Println("Skipping prettify error")
+				fmt.Fprintln(os.Stderr, "Skipping prettify error")
 			}
 
-			// Convert HTML to text
-			err = ConvertHTMLToText(args[0], skipPrettifyError)
+			// Get the values of the format and output flags
+			out, err := outputFlags(cmd)
 			if err != nil {
-				fmt.Println(err)
+				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
+
+			// Convert HTML to text
+			err = ConvertHTMLToText(args[0], skipPrettifyError, out)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(ExitCode(err))
+			}
 		},
 	}
 	// Add the skipPrettifyError flag as an optional argument
@@ -87,8 +93,11 @@ func HTMLCmd(appName string) *cobra.Command {
 		false,
 		"skip prettify error",
 	)
+	// Add the format and output flags as optional arguments
+	addFormatFlag(htmlCmd)
+	addOutputFlags(htmlCmd)
 	htmlCmd.SetUsageFunc(func(cmd *cobra.Command) error {
-		fmt.Println("Usage:", appName, htmlCmd.Use, "[file.html or /path/to/file.html] [--skipPrettifyError or -s]")
+		fmt.Println("Usage:", appName, htmlCmd.Use, "[file.html or /path/to/file.html] [--skipPrettifyError or -s] [--format or -f txt|md] [--output-format or -o text|json] [--stdout]")
 		return nil
 	})
 
@@ -3,6 +3,7 @@ package cli
 import (
 	"fmt"
 	"os"
+	"path"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -10,68 +11,60 @@ import (
 	"github.com/pilinux/totext"
 )
 
-// ConvertOdtToText receives odt filepath as an argument and writes
-// its text content and metadata into two separate files
-func ConvertOdtToText(filepath string) error {
+// ConvertOdtToText receives odt, fodt or ott filepath as an argument
+// and writes its text content and metadata into two separate files
+//
+// out selects the format of the text content, the format and the
+// destination of the output.
+func ConvertOdtToText(filepath string, out Output) error {
 	filepath = strings.TrimSpace(filepath)
 
-	// Get file extension from filepath
-	fileExt := totext.GetFileExtension(filepath)
-	if fileExt != totext.ODT {
-		return fmt.Errorf("file type not supported")
+	// Check the output
+	if err := out.check(); err != nil {
+		return err
 	}
 
-	// Convert odt to text
-	content, metadata, err := totext.ConvertOdtToText(filepath)
-	if err != nil {
-		return err
+	// Get file extension from filepath
+	fileExt := totext.GetFileExtension(filepath)
+	if fileExt != totext.ODT && fileExt != totext.FODT && fileExt != totext.OTT {
+		return totext.ErrUnsupportedFormat
 	}
 
 	// Get filename from filepath
 	filename := totext.GetFilename(filepath)
-	filenameWithoutExtension := strings.TrimSuffix(filename, ".odt")
-
-	// Set current working directory
-	err = totext.SetCwd(filepath)
-	if err != nil {
-		return err
-	}
-
-	// Write content to a txt file
-	err = totext.WriteText(filenameWithoutExtension+".txt", content)
-	if err != nil {
-		return err
-	}
-
-	// Write metadata to a txt file
-	err = totext.WriteText(
-		filenameWithoutExtension+"_metadata.txt",
-		fmt.Sprintf("%v", metadata),
-	)
-	if err != nil {
-		return err
-	}
+	filenameWithoutExtension := strings.TrimSuffix(filename, path.Ext(filename))
 
-	return nil
+	// Convert odt to text and write it to a txt or md file
+	return convertFile(filepath, fileExt, filenameWithoutExtension, out)
 }
 
 // OdtCmd defines the "odt" command
 func OdtCmd(appName string) *cobra.Command {
 	var odtCmd = &cobra.Command{
 		Use:   "odt",
-		Short: "Extract text from an odt file and write it to a txt file",
+		Short: "Extract text from an odt, fodt or ott file and write it to a txt or md file",
 		Args:  cobra.ExactArgs(1), // odt filepath
 		Run: func(cmd *cobra.Command, args []string) {
-			// Convert odt to text
-			err := ConvertOdtToText(args[0])
+			// Get the values of the format and output flags
+			out, err := outputFlags(cmd)
 			if err != nil {
-				fmt.Println(err)
+				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
+
+			// Convert odt to text
+			err = ConvertOdtToText(args[0], out)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(ExitCode(err))
+			}
 		},
 	}
+	// Add the format and output flags as optional arguments
+	addFormatFlag(odtCmd)
+	addOutputFlags(odtCmd)
 	odtCmd.SetUsageFunc(func(cmd *cobra.Command) error {
-		fmt.Println("Usage:", appName, odtCmd.Use, "[file.odt or /path/to/file.odt]")
+		fmt.Println("Usage:", appName, odtCmd.Use, "[file.odt or /path/to/file.odt] [--format or -f txt|md] [--output-format or -o text|json] [--stdout]")
 		return nil
 	})
 
@@ -0,0 +1,219 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pilinux/totext"
+)
+
+// OutputFormat is the format of the output of the commands
+type OutputFormat string
+
+const (
+	// OutputFormatText writes the text content and the metadata
+	// into two separate files, it is the default
+	OutputFormatText OutputFormat = "text"
+	// OutputFormatJSON writes a single JSON document
+	OutputFormatJSON OutputFormat = "json"
+)
+
+// Output selects how and where the commands write the converted documents
+type Output struct {
+	// TextFormat is the format of the text content, plain text by default
+	TextFormat totext.TextFormat
+	// Format is the format of the output
+	Format OutputFormat
+	// Stdout writes the output to the standard output instead of files.
+	// Only the text content is written with OutputFormatText.
+	Stdout bool
+}
+
+// Result is the JSON document of a converted document
+type Result struct {
+	// Text is the text content of the document
+	Text string `json:"text"`
+	// Metadata is the metadata of the document, the metadata
+	// returned by the converter is kept in its raw field
+	Metadata totext.Metadata `json:"metadata"`
+	// Source is the path or the URL of the document
+	Source string `json:"source"`
+	// Format is the detected format of the document
+	Format totext.FileExtension `json:"format"`
+	// TextFormat is the format of the text content
+	TextFormat totext.TextFormat `json:"textFormat"`
+	// Page is the number of the page when the pages are written separately
+	Page int `json:"page,omitempty"`
+	// Timings are the start, the end and the duration of the conversion
+	Timings Timings `json:"timings"`
+	// Warnings are the non-fatal problems found during the conversion
+	Warnings []string `json:"warnings"`
+}
+
+// Timings are the start, the end and the duration of a conversion
+type Timings struct {
+	Started    time.Time `json:"started"`
+	Finished   time.Time `json:"finished"`
+	DurationMs int64     `json:"durationMs"`
+}
+
+// stdout is where the output is written with Output.Stdout
+var stdout io.Writer = os.Stdout
+
+// check validates the output and sets the defaults of its empty fields
+func (out *Output) check() error {
+	out.TextFormat = totext.TextFormat(strings.ToLower(strings.TrimSpace(string(out.TextFormat))))
+	switch out.TextFormat {
+	case "":
+		out.TextFormat = totext.TextFormatPlain
+	case totext.TextFormatPlain, totext.TextFormatMarkdown:
+	default:
+		return fmt.Errorf("unknown format %q, use txt or md", out.TextFormat)
+	}
+
+	out.Format = OutputFormat(strings.ToLower(strings.TrimSpace(string(out.Format))))
+	switch out.Format {
+	case "":
+		out.Format = OutputFormatText
+	case OutputFormatText, OutputFormatJSON:
+	default:
+		return fmt.Errorf("unknown output format %q, use text or json", out.Format)
+	}
+
+	return nil
+}
+
+// newResult returns the result of the conversion of the source
+// in the format which started at the given time
+func newResult(source string, format totext.FileExtension, out Output, started time.Time) *Result {
+	// The working directory is changed before writing the files
+	if abs, err := filepath.Abs(source); err == nil && !strings.Contains(source, "://") {
+		source = abs
+	}
+
+	return &Result{
+		Source:     source,
+		Format:     format,
+		TextFormat: out.TextFormat,
+		Timings:    Timings{Started: started},
+		Warnings:   []string{},
+	}
+}
+
+// finish records the end of the conversion with its text content,
+// metadata and warnings
+func (res *Result) finish(content string, metadata map[string]string, warnings []string) {
+	var counter totext.TextCounter
+	_, _ = counter.WriteString(content)
+	res.Text, res.Metadata = content, totext.NewMetadata(metadata, &counter)
+	res.Warnings = append(res.Warnings, warnings...)
+
+	res.Timings.Finished = time.Now()
+	res.Timings.DurationMs = res.Timings.Finished.Sub(res.Timings.Started).Milliseconds()
+}
+
+// writeResult writes the result to the standard output or into files
+// named after filenameWithoutExtension, as selected by out
+func writeResult(out Output, filenameWithoutExtension string, res *Result) error {
+	switch {
+	case out.Format == OutputFormatJSON && out.Stdout:
+		// A JSON document per line
+		enc := json.NewEncoder(stdout)
+		enc.SetEscapeHTML(false)
+		return enc.Encode(res)
+	case out.Format == OutputFormatJSON:
+		return writeJSON(filenameWithoutExtension+".json", res)
+	case out.Stdout:
+		_, err := io.WriteString(stdout, res.Text)
+		return err
+	}
+
+	// Write content to a txt or md file
+	err := totext.WriteText(filenameWithoutExtension+"."+string(res.TextFormat), res.Text)
+	if err != nil {
+		return err
+	}
+
+	// Write metadata to a txt file
+	return writeMetadata(filenameWithoutExtension, res.Metadata)
+}
+
+// writeMetadata writes the metadata into the metadata file of the
+// converted document, a field per line
+func writeMetadata(filenameWithoutExtension string, metadata totext.Metadata) error {
+	return totext.WriteText(filenameWithoutExtension+"_metadata.txt", metadata.String())
+}
+
+// writeJSON writes the result into an indented JSON file
+func writeJSON(name string, res *Result) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := f.Close(); e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	enc := json.NewEncoder(f)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+// addFormatFlag adds the format flag of the text content to the command
+func addFormatFlag(cmd *cobra.Command) {
+	cmd.Flags().StringP(
+		"format",
+		"f",
+		string(totext.TextFormatPlain),
+		"format of the text content: txt or md",
+	)
+}
+
+// addOutputFlags adds the output-format and stdout flags to the command
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP(
+		"output-format",
+		"o",
+		string(OutputFormatText),
+		"output format: text writes the text content and the metadata into two files, json a single JSON document",
+	)
+	cmd.Flags().Bool(
+		"stdout",
+		false,
+		"write to the standard output instead of files",
+	)
+}
+
+// outputFlags returns the output selected by the flags of the command
+func outputFlags(cmd *cobra.Command) (out Output, err error) {
+	if cmd.Flags().Lookup("format") != nil {
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			return out, err
+		}
+		out.TextFormat = totext.TextFormat(format)
+	}
+
+	format, err := cmd.Flags().GetString("output-format")
+	if err != nil {
+		return out, err
+	}
+	out.Format = OutputFormat(format)
+
+	out.Stdout, err = cmd.Flags().GetBool("stdout")
+	if err != nil {
+		return out, err
+	}
+
+	return out, out.check()
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pilinux/totext"
+)
+
+// ConvertPagesToText receives Apple Pages filepath as an argument
+// and writes its text content and metadata into two separate files
+//
+// out selects the format of the text content, the format and the
+// destination of the output.
+func ConvertPagesToText(filepath string, out Output) error {
+	filepath = strings.TrimSpace(filepath)
+
+	// Check the output
+	if err := out.check(); err != nil {
+		return err
+	}
+
+	// Get file extension from filepath
+	fileExt := totext.GetFileExtension(filepath)
+	if fileExt != totext.PAGES {
+		return totext.ErrUnsupportedFormat
+	}
+
+	// Get filename from filepath
+	filename := totext.GetFilename(filepath)
+	filenameWithoutExtension := strings.TrimSuffix(filename, ".pages")
+
+	// Convert pages to text and write it to a txt or md file
+	return convertFile(filepath, fileExt, filenameWithoutExtension, out)
+}
+
+// PagesCmd defines the "pages" command
+func PagesCmd(appName string) *cobra.Command {
+	var pagesCmd = &cobra.Command{
+		Use:   "pages",
+		Short: "Extract text from an Apple Pages file and write it to a txt or md file",
+		Args:  cobra.ExactArgs(1), // pages filepath
+		Run: func(cmd *cobra.Command, args []string) {
+			// Get the values of the format and output flags
+			out, err := outputFlags(cmd)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			// Convert pages to text
+			err = ConvertPagesToText(args[0], out)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(ExitCode(err))
+			}
+		},
+	}
+	// Add the format and output flags as optional arguments
+	addFormatFlag(pagesCmd)
+	addOutputFlags(pagesCmd)
+	pagesCmd.SetUsageFunc(func(cmd *cobra.Command) error {
+		fmt.Println("Usage:", appName, pagesCmd.Use, "[file.pages or /path/to/file.pages] [--format or -f txt|md] [--output-format or -o text|json] [--stdout]")
+		return nil
+	})
+
+	return pagesCmd
+}
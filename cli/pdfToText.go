@@ -1,9 +1,12 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -12,47 +15,122 @@ import (
 
 // ConvertPDFToText receives pdf filepath as an argument and writes
 // its text content and metadata into two separate files
-func ConvertPDFToText(filepath string) error {
+//
+// pages selects the pages to convert, e.g. "3-10,15". If splitPages is
+// set, the text content of every page is written into its own file.
+// backend selects the text extractor: "poppler", "native" or "" to use
+// poppler if it is installed. out selects the format and the destination
+// of the output.
+func ConvertPDFToText(filepath string, pages string, splitPages bool, backend string, out Output) error {
 	filepath = strings.TrimSpace(filepath)
 
+	// Check the output
+	if err := out.check(); err != nil {
+		return err
+	}
+
 	// Get file extension from filepath
 	fileExt := totext.GetFileExtension(filepath)
 	if fileExt != totext.PDF {
-		return fmt.Errorf("file type not supported")
-	}
-
-	// Convert PDF to text
-	content, metadata, err := totext.ConvertPDFToText(filepath)
-	if err != nil {
-		return err
+		return totext.ErrUnsupportedFormat
 	}
 
 	// Get filename from filepath
 	filename := totext.GetFilename(filepath)
 	filenameWithoutExtension := strings.TrimSuffix(filename, ".pdf")
 
-	// Set current working directory
-	err = totext.SetCwd(filepath)
+	// Select the text extractor and the pages
+	opts := []totext.Option{totext.WithPDFBackend(totext.PDFBackend(backend))}
+	if pages != "" {
+		ranges, err := totext.ParsePageRanges(pages)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, totext.WithPages(ranges...))
+	}
+
+	// Convert PDF to text and write every page to a txt file
+	if splitPages {
+		return convertPDFPages(filepath, filenameWithoutExtension, out, opts...)
+	}
+
+	// Convert PDF to text and write it to a txt file
+	return convertFile(filepath, fileExt, filenameWithoutExtension, out, opts...)
+}
+
+// convertPDFPages writes the text content of every page of the pdf file
+// into a separate file and its metadata into another file. With
+// OutputFormatJSON every page is a JSON document, and the pages written
+// to the standard output are separated with form feeds.
+func convertPDFPages(filepath string, filenameWithoutExtension string, out Output, opts ...totext.Option) error {
+	base := newResult(filepath, totext.PDF, out, time.Now())
+
+	// Open the file before changing the working directory
+	file, err := openFile(filepath)
 	if err != nil {
 		return err
 	}
+	defer func() {
+		_ = file.Close()
+	}()
 
-	// Write content to a txt file
-	err = totext.WriteText(filenameWithoutExtension+".txt", content)
+	// Set current working directory
+	err = totext.SetCwd(filepath)
 	if err != nil {
 		return err
 	}
 
-	// Write metadata to a txt file
-	err = totext.WriteText(
-		filenameWithoutExtension+"_metadata.txt",
-		fmt.Sprintf("%v", metadata),
-	)
+	var warnings []string
+	opts = append(opts, totext.WithWarnings(&warnings))
+
+	// Write the content of every page to a txt file or to the standard
+	// output, the JSON documents are written when the metadata is known
+	var results []*Result
+	var counter totext.TextCounter
+	written := false
+	metadata, err := totext.ConvertPDFReaderToPagesFunc(context.Background(), file, func(page totext.Page) error {
+		switch {
+		case out.Format == OutputFormatJSON:
+			res := *base
+			res.Page, res.Text = page.Number, page.Text
+			results = append(results, &res)
+			return nil
+		case out.Stdout:
+			if written {
+				if _, err := io.WriteString(stdout, "\f"); err != nil {
+					return err
+				}
+			}
+			written = true
+			_, err := io.WriteString(stdout, page.Text)
+			return err
+		}
+		_, _ = counter.WriteString(page.Text)
+		return totext.WriteText(
+			fmt.Sprintf("%s_page%d.txt", filenameWithoutExtension, page.Number),
+			page.Text,
+		)
+	}, opts...)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if out.Format == OutputFormatJSON {
+		for _, res := range results {
+			res.finish(res.Text, metadata, warnings)
+			err = writeResult(out, fmt.Sprintf("%s_page%d", filenameWithoutExtension, res.Page), res)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if out.Stdout {
+		return nil
+	}
+
+	// Write metadata to a txt file
+	return writeMetadata(filenameWithoutExtension, totext.NewMetadata(metadata, &counter))
 }
 
 // PdfCmd defines the "pdf" command
@@ -62,16 +140,67 @@ func PdfCmd(appName string) *cobra.Command {
 		Short: "Extract text from a PDF file and write it to a txt file",
 		Args:  cobra.ExactArgs(1), // pdf filepath
 		Run: func(cmd *cobra.Command, args []string) {
-			// Convert PDF to text
-			err := ConvertPDFToText(args[0])
+			// Get the value of the pages flag
+			pages, err := cmd.Flags().GetString("pages")
 			if err != nil {
-				fmt.Println(err)
+				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
+
+			// Get the value of the split-pages flag
+			splitPages, err := cmd.Flags().GetBool("split-pages")
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			// Get the value of the backend flag
+			backend, err := cmd.Flags().GetString("backend")
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			// Get the values of the output flags
+			out, err := outputFlags(cmd)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			// Convert PDF to text
+			err = ConvertPDFToText(args[0], pages, splitPages, backend, out)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(ExitCode(err))
+			}
 		},
 	}
+	// Add the pages flag as an optional argument
+	pdfCmd.Flags().StringP(
+		"pages",
+		"p",
+		"",
+		"pages to convert, e.g. 3-10,15",
+	)
+	// Add the split-pages flag as an optional argument
+	pdfCmd.Flags().BoolP(
+		"split-pages",
+		"s",
+		false,
+		"write every page to a separate txt file",
+	)
+	// Add the backend flag as an optional argument
+	pdfCmd.Flags().StringP(
+		"backend",
+		"b",
+		"",
+		"text extractor: poppler or native, poppler is used if it is installed",
+	)
+	// Add the output flags as optional arguments
+	addOutputFlags(pdfCmd)
 	pdfCmd.SetUsageFunc(func(cmd *cobra.Command) error {
-		fmt.Println("Usage:", appName, pdfCmd.Use, "[file.pdf or /path/to/file.pdf]")
+		fmt.Println("Usage:", appName, pdfCmd.Use, "[file.pdf or /path/to/file.pdf] [--pages or -p 3-10,15] [--split-pages or -s] [--backend or -b poppler|native] [--output-format or -o text|json] [--stdout]")
 		return nil
 	})
 
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakePoppler puts pdfinfo and pdftotext scripts on the PATH, which
+// fail unless the PDF file is found by the path they are given
+func fakePoppler(t *testing.T) {
+	t.Helper()
+
+	dir := t.TempDir()
+	scripts := map[string]string{
+		"pdfinfo": "#!/bin/sh\ntest -f \"$1\" || exit 1\necho 'Pages: 2'\n",
+		"pdftotext": "#!/bin/sh\nfor a; do path=$file; file=$a; done\n" +
+			"test -f \"$path\" || exit 1\nprintf 'Page one\\fPage two\\f'\n",
+	}
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// TestConvertPDFPagesSubdirectory tests splitting the pages of a pdf
+// file given by a relative path in a subdirectory
+func TestConvertPDFPagesSubdirectory(t *testing.T) {
+	fakePoppler(t)
+	path := testFile(t, "docs", "a.pdf", "%PDF-1.7\n")
+	dir, err := filepath.Abs("docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ConvertPDFToText(path, "1-2", true, "poppler", Output{}); err != nil {
+		t.Fatalf("Error converting file: %s", err)
+	}
+
+	// The pages are written next to the pdf file
+	for i, expected := range []string{"Page one", "Page two"} {
+		b, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("a_page%d.txt", i+1)))
+		if err != nil {
+			t.Fatalf("Error reading page %d: %s", i+1, err)
+		}
+		if string(b) != expected {
+			t.Errorf("Expected page %d %q, got %q", i+1, expected, string(b))
+		}
+	}
+}
@@ -12,66 +12,58 @@ import (
 
 // ConvertRTFToText receives rtf filepath as an argument and writes
 // its text content and metadata into two separate files
-func ConvertRTFToText(filepath string) error {
+//
+// out selects the format of the text content, the format and the
+// destination of the output.
+func ConvertRTFToText(filepath string, out Output) error {
 	filepath = strings.TrimSpace(filepath)
 
+	// Check the output
+	if err := out.check(); err != nil {
+		return err
+	}
+
 	// Get file extension from filepath
 	fileExt := totext.GetFileExtension(filepath)
 	if fileExt != totext.RTF {
-		return fmt.Errorf("file type not supported")
-	}
-
-	// Convert rtf to text
-	content, metadata, err := totext.ConvertRTFToText(filepath)
-	if err != nil {
-		return err
+		return totext.ErrUnsupportedFormat
 	}
 
 	// Get filename from filepath
 	filename := totext.GetFilename(filepath)
 	filenameWithoutExtension := strings.TrimSuffix(filename, ".rtf")
 
-	// Set current working directory
-	err = totext.SetCwd(filepath)
-	if err != nil {
-		return err
-	}
-
-	// Write content to a txt file
-	err = totext.WriteText(filenameWithoutExtension+".txt", content)
-	if err != nil {
-		return err
-	}
-
-	// Write metadata to a txt file
-	err = totext.WriteText(
-		filenameWithoutExtension+"_metadata.txt",
-		fmt.Sprintf("%v", metadata),
-	)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	// Convert rtf to text and write it to a txt or md file
+	return convertFile(filepath, fileExt, filenameWithoutExtension, out)
 }
 
 // RtfCmd defines the "rtf" command
 func RtfCmd(appName string) *cobra.Command {
 	var rtfCmd = &cobra.Command{
 		Use:   "rtf",
-		Short: "Extract text from an RTF file and write it to a txt file",
+		Short: "Extract text from an RTF file and write it to a txt or md file",
 		Args:  cobra.ExactArgs(1), // rtf filepath
 		Run: func(cmd *cobra.Command, args []string) {
-			// Convert rtf to text
-			err := ConvertRTFToText(args[0])
+			// Get the values of the format and output flags
+			out, err := outputFlags(cmd)
 			if err != nil {
-				fmt.Println(err)
+				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
+
+			// Convert rtf to text
+			err = ConvertRTFToText(args[0], out)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(ExitCode(err))
+			}
 		},
 	}
+	// Add the format and output flags as optional arguments
+	addFormatFlag(rtfCmd)
+	addOutputFlags(rtfCmd)
 	rtfCmd.SetUsageFunc(func(cmd *cobra.Command) error {
-		fmt.Println("Usage:", appName, rtfCmd.Use, "[file.rtf or /path/to/file.rtf]")
+		fmt.Println("Usage:", appName, rtfCmd.Use, "[file.rtf or /path/to/file.rtf] [--format or -f txt|md] [--output-format or -o text|json] [--stdout]")
 		return nil
 	})
 
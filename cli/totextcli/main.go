@@ -21,10 +21,13 @@ func main() {
 
 	// Define the subcommands
 	var docCmd = cli.DocCmd(appName)
+	var doctorCmd = cli.DoctorCmd(appName)
 	var docxCmd = cli.DocxCmd(appName)
+	var epubCmd = cli.EPUBCmd(appName)
 	var fileCmd = cli.FileCmd(appName)
 	var htmlCmd = cli.HTMLCmd(appName)
 	var odtCmd = cli.OdtCmd(appName)
+	var pagesCmd = cli.PagesCmd(appName)
 	var pdfCmd = cli.PdfCmd(appName)
 	var rtfCmd = cli.RtfCmd(appName)
 	var urlCmd = cli.URLCmd(appName)
@@ -33,10 +36,13 @@ func main() {
 	// Add the commands to the root command
 	rootCmd.AddCommand(
 		docCmd,
+		doctorCmd,
 		docxCmd,
+		epubCmd,
 		fileCmd,
 		htmlCmd,
 		odtCmd,
+		pagesCmd,
 		pdfCmd,
 		rtfCmd,
 		urlCmd,
@@ -44,7 +50,7 @@ func main() {
 	)
 
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/go-rod/rod"
 	"github.com/spf13/cobra"
@@ -13,9 +14,18 @@ import (
 
 // ConvertURLToText receives url as an argument and writes
 // its text content and metadata into two separate files
-func ConvertURLToText(inputURL string, skipPrettifyError bool, delayInSec int) (err error) {
+//
+// out selects the format of the text content, the format and the
+// destination of the output.
+func ConvertURLToText(inputURL string, skipPrettifyError bool, delayInSec int, out Output) (err error) {
 	inputURL = strings.TrimSpace(inputURL)
 
+	// Check the output
+	if err = out.check(); err != nil {
+		return err
+	}
+	res := newResult(inputURL, totext.HTML, out, time.Now())
+
 	// Create a new browser instance
 	browser := rod.New().MustConnect()
 	defer func() {
@@ -25,62 +35,65 @@ func ConvertURLToText(inputURL string, skipPrettifyError bool, delayInSec int) (
 	}()
 
 	// Fetch the HTML page and convert to text
-	htmlFilename, content, metadata, err := totext.ConvertURLToText(browser, inputURL, skipPrettifyError, delayInSec)
+	var warnings []string
+	htmlFilename, content, metadata, err := totext.ConvertURLToText(browser, inputURL, skipPrettifyError, delayInSec,
+		totext.WithTextFormat(out.TextFormat), totext.WithWarnings(&warnings))
 	if err != nil {
 		return err
 	}
+	res.finish(content, metadata, warnings)
 
-	// Get filename without extension from htmlFile
-	filenameWithoutExtension := strings.TrimSuffix(htmlFilename, ".html")
-
-	// Write content to a txt file
-	err = totext.WriteText(filenameWithoutExtension+".txt", content)
-	if err != nil {
-		return err
+	// The fetched page is not kept when writing to the standard output
+	if out.Stdout {
+		if err = totext.DeleteFile(htmlFilename); err != nil {
+			return err
+		}
 	}
 
-	// Write metadata to a txt file
-	err = totext.WriteText(
-		filenameWithoutExtension+"_metadata.txt",
-		fmt.Sprintf("%v", metadata),
-	)
-	if err != nil {
-		return err
-	}
+	// Get filename without extension from htmlFile
+	filenameWithoutExtension := strings.TrimSuffix(htmlFilename, ".html")
 
-	return nil
+	// Write content and metadata
+	return writeResult(out, filenameWithoutExtension, res)
 }
 
 // URLCmd defines the "url" command
 func URLCmd(appName string) *cobra.Command {
 	var urlCmd = &cobra.Command{
 		Use:   "url",
-		Short: "Fetch HTML page from the URL and write the extracted text to a txt file",
+		Short: "Fetch HTML page from the URL and write the extracted text to a txt or md file",
 		Args:  cobra.MinimumNArgs(1), // full URL
 		Run: func(cmd *cobra.Command, args []string) {
 			// Get the value of the skipPrettifyError flag
 			skipPrettifyError, err := cmd.Flags().GetBool("skipPrettifyError")
 			if err != nil {
-				fmt.Println(err)
+				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
 			if skipPrettifyError {
-				fmt.Println("Skipping prettify error")
+				fmt.Fprintln(os.Stderr, "Skipping prettify error")
 			}
 
 			// Get the value of the delayInSec flag
 			delayInSec, err := cmd.Flags().GetInt("delayInSec")
 			if err != nil {
-				fmt.Println(err)
+				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
 
-			// Convert HTML page from the given URL to text
-			err = ConvertURLToText(args[0], skipPrettifyError, delayInSec)
+			// Get the values of the format and output flags
+			out, err := outputFlags(cmd)
 			if err != nil {
-				fmt.Println(err)
+				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
+
+			// Convert HTML page from the given URL to text
+			err = ConvertURLToText(args[0], skipPrettifyError, delayInSec, out)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(ExitCode(err))
+			}
 		},
 	}
 	// Add the skipPrettifyError flag as an optional argument
@@ -97,8 +110,11 @@ func URLCmd(appName string) *cobra.Command {
 		0,
 		"additional delay in seconds for the web page to load",
 	)
+	// Add the format and output flags as optional arguments
+	addFormatFlag(urlCmd)
+	addOutputFlags(urlCmd)
 	urlCmd.SetUsageFunc(func(cmd *cobra.Command) error {
-		fmt.Println("Usage:", appName, urlCmd.Use, "['https://example.com/path/to/webpage'] [--skipPrettifyError or -s] [--delayInSec=<seconds> or -d <seconds>]")
+		fmt.Println("Usage:", appName, urlCmd.Use, "['https://example.com/path/to/webpage'] [--skipPrettifyError or -s] [--delayInSec=<seconds> or -d <seconds>] [--format or -f txt|md] [--output-format or -o text|json] [--stdout]")
 		return nil
 	})
 
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pilinux/totext"
+)
+
+// convertFile converts the file with the converter registered for
+// fileExt and writes its text content and metadata as selected by out.
+// The text content is written to the file or to the standard output
+// while it is being converted, instead of being held in memory.
+func convertFile(filepath string, fileExt totext.FileExtension, filenameWithoutExtension string, out Output, opts ...totext.Option) (err error) {
+	res := newResult(filepath, fileExt, out, time.Now())
+
+	// Open the file before changing the working directory
+	file, err := openFile(filepath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	// Set current working directory
+	err = totext.SetCwd(filepath)
+	if err != nil {
+		return err
+	}
+
+	var warnings []string
+	opts = append(opts, totext.WithTextFormat(out.TextFormat), totext.WithWarnings(&warnings))
+
+	// The JSON document holds the whole text content
+	if out.Format == OutputFormatJSON {
+		var content strings.Builder
+		metadata, err := totext.ConvertReaderToWriter(file, fileExt, &content, opts...)
+		if err != nil {
+			return err
+		}
+		res.finish(content.String(), metadata, warnings)
+		return writeResult(out, filenameWithoutExtension, res)
+	}
+
+	// Write content to the standard output
+	if out.Stdout {
+		w := bufio.NewWriter(stdout)
+		if _, err = totext.ConvertReaderToWriter(file, fileExt, w, opts...); err != nil {
+			return err
+		}
+		return w.Flush()
+	}
+
+	// Write content to a txt or md file
+	output, err := os.Create(filenameWithoutExtension + "." + string(out.TextFormat))
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(output)
+	var counter totext.TextCounter
+	metadata, err := totext.ConvertReaderToWriter(file, fileExt, io.MultiWriter(w, &counter), opts...)
+	if err == nil {
+		err = w.Flush()
+	}
+	if e := output.Close(); e != nil && err == nil {
+		err = e
+	}
+	if err != nil {
+		// Do not leave a partial file behind
+		_ = totext.DeleteFile(output.Name())
+		return err
+	}
+
+	// Write metadata to a txt file
+	return writeMetadata(filenameWithoutExtension, totext.NewMetadata(metadata, &counter))
+}
+
+// openFile opens the file by its absolute path. The converters pass
+// the name of the file to the external tools, which run after the
+// working directory is changed.
+func openFile(name string) (*os.File, error) {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(abs)
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pilinux/totext"
+)
+
+// nameFormat is converted by reading the file by its name, like
+// the converters running external tools
+const nameFormat totext.FileExtension = "name"
+
+func init() {
+	totext.RegisterConverter(nameFormat, "", totext.ConverterFunc(
+		func(ctx context.Context, r io.Reader, opts ...totext.Option) (string, map[string]string, error) {
+			f, ok := r.(*os.File)
+			if !ok {
+				return "", nil, totext.ErrUnsupportedFormat
+			}
+			b, err := os.ReadFile(f.Name())
+			if err != nil {
+				return "", nil, err
+			}
+			return string(b), nil, nil
+		},
+	))
+}
+
+// testFile writes the content into dir/name under a temporary
+// working directory and returns the relative path of the file
+func testFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	t.Chdir(t.TempDir())
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestConvertFileSubdirectory tests that the files given by
+// a relative path in a subdirectory are found by the converters
+// after the working directory is changed
+func TestConvertFileSubdirectory(t *testing.T) {
+	path := testFile(t, "docs", "a.name", "Hello")
+
+	var buf bytes.Buffer
+	stdout = &buf
+	defer func() {
+		stdout = os.Stdout
+	}()
+
+	if err := ConvertFileToText(path, Output{Stdout: true}); err != nil {
+		t.Fatalf("Error converting file: %s", err)
+	}
+	if buf.String() != "Hello" {
+		t.Errorf("Expected content %q, got %q", "Hello", buf.String())
+	}
+}
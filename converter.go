@@ -0,0 +1,258 @@
+package totext
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Converter converts a document of a specific format to text
+type Converter interface {
+	// ConvertReader receives the document content as an io.Reader
+	// and returns its text content and metadata. The conversion
+	// must stop when ctx is done.
+	ConvertReader(ctx context.Context, r io.Reader, opts ...Option) (content string, metadata map[string]string, err error)
+}
+
+// ConverterFunc is an adapter to allow the use of an ordinary
+// function as a Converter
+type ConverterFunc func(ctx context.Context, r io.Reader, opts ...Option) (content string, metadata map[string]string, err error)
+
+// ConvertReader calls f(ctx, r, opts...)
+func (f ConverterFunc) ConvertReader(ctx context.Context, r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	return f(ctx, r, opts...)
+}
+
+// StreamConverter is a Converter which writes the text content to an
+// io.Writer while it is being produced, instead of holding it in memory
+type StreamConverter interface {
+	Converter
+
+	// ConvertReaderTo receives the document content as an io.Reader,
+	// writes its text content to w and returns its metadata. The
+	// conversion must stop when ctx is done.
+	ConvertReaderTo(ctx context.Context, r io.Reader, w io.Writer, opts ...Option) (metadata map[string]string, err error)
+}
+
+// StreamConverterFunc is an adapter to allow the use of an ordinary
+// function as a StreamConverter
+type StreamConverterFunc func(ctx context.Context, r io.Reader, w io.Writer, opts ...Option) (metadata map[string]string, err error)
+
+// ConvertReaderTo calls f(ctx, r, w, opts...)
+func (f StreamConverterFunc) ConvertReaderTo(ctx context.Context, r io.Reader, w io.Writer, opts ...Option) (metadata map[string]string, err error) {
+	return f(ctx, r, w, opts...)
+}
+
+// ConvertReader calls f and collects the text content into a string
+func (f StreamConverterFunc) ConvertReader(ctx context.Context, r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	var sb strings.Builder
+	metadata, err = f(ctx, r, &sb, opts...)
+	if err != nil {
+		return "", nil, err
+	}
+	return sb.String(), metadata, nil
+}
+
+// registry holds all registered converters
+var registry = struct {
+	sync.RWMutex
+	converters map[FileExtension]Converter
+	mimeTypes  map[FileExtension]MIME
+	formats    map[MIME]FileExtension
+}{
+	converters: make(map[FileExtension]Converter),
+	mimeTypes:  make(map[FileExtension]MIME),
+	formats:    make(map[MIME]FileExtension),
+}
+
+// RegisterConverter registers a converter for the given file extension
+// and MIME type. If a converter is already registered for the file
+// extension, it is replaced.
+func RegisterConverter(fileExt FileExtension, mime MIME, converter Converter) {
+	fileExt = normalizeFileExtension(fileExt)
+	if fileExt == "" || converter == nil {
+		panic("totext: RegisterConverter requires a file extension and a converter")
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	// Drop the MIME type of the replaced converter
+	if oldMIME, ok := registry.mimeTypes[fileExt]; ok && registry.formats[NormalizeMIME(oldMIME)] == fileExt {
+		delete(registry.formats, NormalizeMIME(oldMIME))
+	}
+
+	registry.converters[fileExt] = converter
+	registry.mimeTypes[fileExt] = mime
+	if mime != "" {
+		registry.formats[NormalizeMIME(mime)] = fileExt
+	}
+}
+
+// GetConverter returns the converter registered for the file extension,
+// which is case-insensitive and may start with a dot, e.g. ".PDF"
+func GetConverter(fileExt FileExtension) (Converter, bool) {
+	fileExt = normalizeFileExtension(fileExt)
+
+	registry.RLock()
+	defer registry.RUnlock()
+
+	converter, ok := registry.converters[fileExt]
+	return converter, ok
+}
+
+// GetConverterByMIME returns the converter registered for the MIME type,
+// e.g. "text/markdown; charset=utf-8", its parameters and case are
+// ignored and the aliases of the built-in formats are accepted
+func GetConverterByMIME(mime MIME) (Converter, bool) {
+	fileExt := FormatForMIME(mime)
+	if fileExt == "" {
+		return nil, false
+	}
+	return GetConverter(fileExt)
+}
+
+// RegisteredFormats returns the sorted list of file extensions
+// which have a registered converter
+func RegisteredFormats() []FileExtension {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	formats := make([]FileExtension, 0, len(registry.converters))
+	for fileExt := range registry.converters {
+		formats = append(formats, fileExt)
+	}
+	sort.Slice(formats, func(i, j int) bool {
+		return formats[i] < formats[j]
+	})
+
+	return formats
+}
+
+// normalizeFileExtension returns the file extension in lower case
+// without surrounding spaces and leading dot
+func normalizeFileExtension(fileExt FileExtension) FileExtension {
+	ext := strings.TrimSpace(string(fileExt))
+	ext = strings.TrimPrefix(ext, ".")
+	return FileExtension(strings.ToLower(ext))
+}
+
+// isRegistered checks if a converter is registered for the file extension
+func isRegistered(fileExt FileExtension) bool {
+	_, ok := GetConverter(fileExt)
+	return ok
+}
+
+// Convert receives a filepath as an argument, picks the registered
+// converter for its format and returns its text content and metadata.
+// The format is detected from the content when the file extension
+// is missing or contradicts the content.
+func Convert(filepath string, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertContext(context.Background(), filepath, opts...)
+}
+
+// ConvertContext is like Convert but stops the conversion when ctx is done
+func ConvertContext(ctx context.Context, filepath string, opts ...Option) (content string, metadata map[string]string, err error) {
+	filepath = strings.TrimSpace(filepath)
+
+	// Get the file
+	file, err := os.Open(filepath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	// Get the format from the file extension and the content
+	fileExt := resolveFormat(file, GetFileExtension(filepath))
+
+	return ConvertReaderContext(ctx, file, fileExt, opts...)
+}
+
+// ConvertReader receives the document content as an io.Reader, picks
+// the registered converter for the given format and returns its
+// text content and metadata
+func ConvertReader(r io.Reader, format FileExtension, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertReaderContext(context.Background(), r, format, opts...)
+}
+
+// ConvertReaderContext is like ConvertReader but stops the conversion
+// when ctx is done
+func ConvertReaderContext(ctx context.Context, r io.Reader, format FileExtension, opts ...Option) (content string, metadata map[string]string, err error) {
+	// Get the converter for the format
+	converter, ok := GetConverter(format)
+	if !ok {
+		return "", nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+
+	return converter.ConvertReader(ctx, r, opts...)
+}
+
+// ConvertToWriter receives a filepath as an argument, picks the registered
+// converter for its format, writes its text content to w and returns
+// its metadata. The format is detected like in Convert.
+//
+// Converters which implement StreamConverter write the text while it is
+// being produced, so w may have received part of the text when an
+// error is returned.
+func ConvertToWriter(filepath string, w io.Writer, opts ...Option) (metadata map[string]string, err error) {
+	return ConvertToWriterContext(context.Background(), filepath, w, opts...)
+}
+
+// ConvertToWriterContext is like ConvertToWriter but stops the conversion
+// when ctx is done
+func ConvertToWriterContext(ctx context.Context, filepath string, w io.Writer, opts ...Option) (metadata map[string]string, err error) {
+	filepath = strings.TrimSpace(filepath)
+
+	// Get the file
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	// Get the format from the file extension and the content
+	fileExt := resolveFormat(file, GetFileExtension(filepath))
+
+	return ConvertReaderToWriterContext(ctx, file, fileExt, w, opts...)
+}
+
+// ConvertReaderToWriter receives the document content as an io.Reader,
+// picks the registered converter for the given format, writes its
+// text content to w and returns its metadata
+func ConvertReaderToWriter(r io.Reader, format FileExtension, w io.Writer, opts ...Option) (metadata map[string]string, err error) {
+	return ConvertReaderToWriterContext(context.Background(), r, format, w, opts...)
+}
+
+// ConvertReaderToWriterContext is like ConvertReaderToWriter but stops
+// the conversion when ctx is done
+func ConvertReaderToWriterContext(ctx context.Context, r io.Reader, format FileExtension, w io.Writer, opts ...Option) (metadata map[string]string, err error) {
+	// Get the converter for the format
+	converter, ok := GetConverter(format)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+
+	// Stream the text content
+	if sc, ok := converter.(StreamConverter); ok {
+		return sc.ConvertReaderTo(ctx, r, w, opts...)
+	}
+
+	// Other converters return the whole text content
+	content, metadata, err := converter.ConvertReader(ctx, r, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = io.WriteString(w, content); err != nil {
+		return nil, err
+	}
+
+	return metadata, nil
+}
@@ -0,0 +1,178 @@
+package totext
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// TestRegisterConverter tests RegisterConverter function
+func TestRegisterConverter(t *testing.T) {
+	const csv FileExtension = "csv"
+	const mimeCSV MIME = "text/csv"
+
+	RegisterConverter(csv, mimeCSV, ConverterFunc(
+		func(ctx context.Context, r io.Reader, opts ...Option) (string, map[string]string, error) {
+			b, err := io.ReadAll(r)
+			if err != nil {
+				return "", nil, err
+			}
+			return "converted " + string(b), map[string]string{"format": "csv"}, nil
+		},
+	))
+
+	// Registered formats must be recognized by GetFileExtension
+	if fileExt := GetFileExtension("/path/to/report.CSV"); fileExt != csv {
+		t.Errorf("Expected file extension %s, got %s", csv, fileExt)
+	}
+
+	// ConvertReader must dispatch to the registered converter
+	content, metadata, err := ConvertReader(strings.NewReader("a,b"), csv)
+	if err != nil {
+		t.Fatalf("Error converting content: %s", err)
+	}
+	if content != "converted a,b" || metadata["format"] != "csv" {
+		t.Errorf("Unexpected result %q %v", content, metadata)
+	}
+
+	// The converter must be found by its MIME type
+	if _, ok := GetConverterByMIME(mimeCSV); !ok {
+		t.Errorf("Expected converter for MIME type %s", mimeCSV)
+	}
+	if _, ok := GetConverterByMIME("Text/CSV; header=present"); !ok {
+		t.Errorf("Expected converter for MIME type %s with parameters", mimeCSV)
+	}
+}
+
+// TestGetConverterByMIME tests that GetConverterByMIME ignores the
+// parameters and the case of the MIME type
+func TestGetConverterByMIME(t *testing.T) {
+	// Test data
+	testData := []struct {
+		mime     MIME
+		expected bool
+	}{
+		{"application/pdf", true},
+		{"text/markdown; charset=utf-8", true},
+		{"Text/HTML", true},
+		{"APPLICATION/JSON; charset=UTF-8", true},
+		{"application/x-pdf", true},
+		{"application/octet-stream", false},
+		{"", false},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		if _, ok := GetConverterByMIME(td.mime); ok != td.expected {
+			t.Errorf("Expected %t for %q, got %t", td.expected, td.mime, ok)
+		}
+	}
+}
+
+// TestRegisteredFormats tests RegisteredFormats function
+func TestRegisteredFormats(t *testing.T) {
+	// Test data
+	testData := []FileExtension{DOC, DOCX, EPUB, FODT, HTML, JSON, MD, ODP, ODS, ODT, OTT, PAGES, PDF, PPTX, RTF, TXT, XLSX}
+
+	formats := fmt.Sprint(RegisteredFormats())
+
+	// Iterate over test data
+	for _, fileExt := range testData {
+		if !isRegistered(fileExt) {
+			t.Errorf("Expected built-in converter for %s in %s", fileExt, formats)
+		}
+	}
+}
+
+// TestGetConverter tests that GetConverter ignores the case
+// and the leading dot of the file extension
+func TestGetConverter(t *testing.T) {
+	// Test data
+	testData := []struct {
+		fileExt  FileExtension
+		expected bool
+	}{
+		{"pdf", true},
+		{"PDF", true},
+		{".Docx", true},
+		{" txt ", true},
+		{".", false},
+		{"unknown", false},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		if _, ok := GetConverter(td.fileExt); ok != td.expected {
+			t.Errorf("Expected %t for %q, got %t", td.expected, td.fileExt, ok)
+		}
+	}
+
+	// ConvertReader must accept the upper case format
+	content, _, err := ConvertReader(strings.NewReader("Hello"), "TXT")
+	if err != nil || content != "Hello\n" {
+		t.Errorf("Unexpected result %q %v", content, err)
+	}
+}
+
+// TestConvertUnsupported tests Convert function with an unsupported file type
+func TestConvertUnsupported(t *testing.T) {
+	_, _, err := Convert("test.unknown")
+	if err == nil {
+		t.Errorf("Expected error for unsupported file type")
+	}
+}
+
+// TestConvertReaderToWriter tests ConvertReaderToWriter function
+// with stream converters and with the other converters
+func TestConvertReaderToWriter(t *testing.T) {
+	const tsv FileExtension = "tsv"
+	const upper FileExtension = "upper"
+
+	RegisterConverter(tsv, "text/tab-separated-values", StreamConverterFunc(
+		func(ctx context.Context, r io.Reader, w io.Writer, opts ...Option) (map[string]string, error) {
+			_, err := io.Copy(NewFilterWriter(w), r)
+			return map[string]string{"format": "tsv"}, err
+		},
+	))
+	RegisterConverter(upper, "", ConverterFunc(
+		func(ctx context.Context, r io.Reader, opts ...Option) (string, map[string]string, error) {
+			b, err := io.ReadAll(r)
+			return strings.ToUpper(string(b)), nil, err
+		},
+	))
+
+	// Stream converters write to w
+	var sb strings.Builder
+	metadata, err := ConvertReaderToWriter(strings.NewReader("a\tb\n\n\nc"), tsv, &sb)
+	if err != nil {
+		t.Fatalf("Error converting content: %s", err)
+	}
+	if sb.String() != "ab\nc" {
+		t.Errorf("Unexpected content %q", sb.String())
+	}
+	if metadata["format"] != "tsv" {
+		t.Errorf("Unexpected metadata %v", metadata)
+	}
+
+	// Stream converters still return a string
+	content, _, err := ConvertReader(strings.NewReader("a\n\nb"), tsv)
+	if err != nil || content != "a\nb" {
+		t.Errorf("Unexpected result %q %v", content, err)
+	}
+
+	// The content of the other converters is written to w
+	sb.Reset()
+	if _, err = ConvertReaderToWriter(strings.NewReader("abc"), upper, &sb); err != nil {
+		t.Fatalf("Error converting content: %s", err)
+	}
+	if sb.String() != "ABC" {
+		t.Errorf("Unexpected content %q", sb.String())
+	}
+
+	// Unsupported formats
+	if _, err = ConvertReaderToWriter(strings.NewReader(""), "unknown", &sb); err == nil {
+		t.Errorf("Expected error for unsupported file type")
+	}
+}
@@ -0,0 +1,240 @@
+package totext
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod/lib/launcher"
+)
+
+// probeTimeout is the time given to a tool to print its version
+const probeTimeout = 10 * time.Second
+
+// Dependency describes an external tool used by the converters
+type Dependency struct {
+	// Name is the name of the tool
+	Name string `json:"name"`
+	// Package is the package which provides the tool
+	Package string `json:"package"`
+	// Installed reports whether the tool was found
+	Installed bool `json:"installed"`
+	// Path is the resolved path of the tool
+	Path string `json:"path,omitempty"`
+	// Version is the version reported by the tool
+	Version string `json:"version,omitempty"`
+	// Formats are the formats which need the tool
+	Formats []string `json:"formats"`
+	// Note is a hint on how to install the tool
+	Note string `json:"note,omitempty"`
+	// Optional reports whether the formats are converted
+	// without the tool by a built-in converter
+	Optional bool `json:"optional,omitempty"`
+}
+
+// dependency describes how to find an external tool and its version
+type dependency struct {
+	Dependency
+
+	// versionCmd is the tool which prints the version, if it
+	// differs from the tool itself
+	versionCmd string
+	// versionArgs are the arguments which print the version
+	versionArgs []string
+}
+
+// dependencies are the external tools used by the converters
+var dependencies = []dependency{
+	{
+		Dependency: Dependency{
+			Name:     "wvText",
+			Package:  "wv",
+			Formats:  []string{string(DOC)},
+			Note:     "sudo apt install wv | brew install wv",
+			Optional: true,
+		},
+		versionCmd:  "wvWare",
+		versionArgs: []string{"--version"},
+	},
+	{
+		Dependency: Dependency{
+			Name:     "pdftotext",
+			Package:  "poppler",
+			Formats:  []string{string(PDF)},
+			Note:     "sudo apt install poppler-utils | brew install poppler",
+			Optional: true,
+		},
+		versionArgs: []string{"-v"},
+	},
+	{
+		Dependency: Dependency{
+			Name:     "pdfinfo",
+			Package:  "poppler",
+			Formats:  []string{string(PDF)},
+			Note:     "sudo apt install poppler-utils | brew install poppler",
+			Optional: true,
+		},
+		versionArgs: []string{"-v"},
+	},
+	{
+		Dependency: Dependency{
+			Name:     "unrtf",
+			Package:  "unrtf",
+			Formats:  []string{string(RTF)},
+			Note:     "sudo apt install unrtf | brew install unrtf",
+			Optional: true,
+		},
+		versionArgs: []string{"--version"},
+	},
+	{
+		Dependency: Dependency{
+			Name:     "tesseract",
+			Package:  "tesseract",
+			Formats:  []string{string(PAGES)},
+			Note:     "sudo apt install tesseract-ocr | brew install tesseract",
+			Optional: true,
+		},
+		versionArgs: []string{"--version"},
+	},
+	{
+		Dependency: Dependency{
+			Name:    "npx",
+			Package: "node",
+			Formats: []string{string(HTML), "url"},
+			Note:    "install Node.js",
+		},
+		versionArgs: []string{"--version"},
+	},
+}
+
+// CheckDependencies reports the presence, path and version
+// of the external tools used by the converters
+func CheckDependencies() []Dependency {
+	return CheckDependenciesContext(context.Background())
+}
+
+// CheckDependenciesContext is like CheckDependencies but stops
+// probing the tools when ctx is done
+func CheckDependenciesContext(ctx context.Context) []Dependency {
+	deps := make([]Dependency, 0, len(dependencies)+2)
+
+	for _, d := range dependencies {
+		dep := d.Dependency
+		dep.Path, dep.Installed = lookPath(dep.Name)
+		if dep.Installed && d.versionArgs != nil {
+			versionPath, ok := dep.Path, true
+			if d.versionCmd != "" {
+				versionPath, ok = lookPath(d.versionCmd)
+			}
+			if ok {
+				// Some tools exit with an error after printing their version
+				dep.Version, _ = probeVersion(ctx, versionPath, d.versionArgs...)
+			}
+		}
+		deps = append(deps, dep)
+	}
+
+	deps = append(deps, checkPrettier(ctx), checkChromium(ctx))
+
+	return deps
+}
+
+// MissingDependencies returns the dependencies which are not installed,
+// except the optional ones
+func MissingDependencies(deps []Dependency) []Dependency {
+	var missing []Dependency
+	for _, dep := range deps {
+		if !dep.Installed && !dep.Optional {
+			missing = append(missing, dep)
+		}
+	}
+	return missing
+}
+
+// checkPrettier checks if prettier can be run with npx
+// without installing it
+func checkPrettier(ctx context.Context) Dependency {
+	dep := Dependency{
+		Name:    "prettier",
+		Package: "prettier",
+		Formats: []string{string(HTML), "url"},
+		Note:    "npm install --save-dev --save-exact prettier",
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	// The shell profile may print to the standard error
+	output, err := newCommand(ctx, shellCommand(), shellArgs("npx --no -- prettier --version")...).Output()
+	if version := firstLine(string(output)); err == nil && version != "" {
+		dep.Installed = true
+		dep.Version = version
+	}
+
+	return dep
+}
+
+// checkChromium checks if the browser used to fetch web pages
+// has been downloaded or is installed on the system
+func checkChromium(ctx context.Context) Dependency {
+	dep := Dependency{
+		Name:    "chromium",
+		Package: "chromium",
+		Formats: []string{"url"},
+		Note:    "downloaded automatically on first use",
+	}
+
+	// The browser downloaded by rod
+	if bin := launcher.NewBrowser().BinPath(); fileExists(bin) {
+		dep.Path = bin
+	} else if bin, ok := launcher.LookPath(); ok {
+		// The browser installed on the system
+		dep.Path = bin
+	}
+
+	if dep.Path != "" {
+		dep.Installed = true
+		dep.Version, _ = probeVersion(ctx, dep.Path, "--version")
+	}
+
+	return dep
+}
+
+// lookPath returns the resolved path of the tool
+func lookPath(name string) (string, bool) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// probeVersion runs the tool with args and returns the
+// first line of its output, tools print their version
+// either to the standard output or to the standard error
+func probeVersion(ctx context.Context, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	output, err := newCommand(ctx, name, args...).CombinedOutput()
+
+	return firstLine(string(output)), err
+}
+
+// firstLine returns the first non-empty line of s
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// fileExists checks if a regular file exists at path
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
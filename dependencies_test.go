@@ -0,0 +1,38 @@
+package totext
+
+import "testing"
+
+// TestMissingDependencies tests MissingDependencies function
+func TestMissingDependencies(t *testing.T) {
+	deps := []Dependency{
+		{Name: "pdftotext", Installed: true},
+		{Name: "unrtf", Installed: false},
+		{Name: "wvText", Installed: false},
+		{Name: "pdfinfo", Installed: false, Optional: true},
+	}
+
+	missing := MissingDependencies(deps)
+	if len(missing) != 2 || missing[0].Name != "unrtf" || missing[1].Name != "wvText" {
+		t.Errorf("Expected unrtf and wvText to be missing, got %v", missing)
+	}
+}
+
+// TestFirstLine tests firstLine function
+func TestFirstLine(t *testing.T) {
+	// Test data
+	testData := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"pdftotext version 22.02.0\nCopyright", "pdftotext version 22.02.0"},
+		{"\n\n  0.21.10  \n", "0.21.10"},
+	}
+
+	// Iterate over test data
+	for _, data := range testData {
+		if line := firstLine(data.input); line != data.expected {
+			t.Errorf("Expected line %q, got %q", data.expected, line)
+		}
+	}
+}
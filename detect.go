@@ -0,0 +1,385 @@
+package totext
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pilinux/totext/internal/cfb"
+)
+
+// sniffLen is the number of bytes read to detect the format
+const sniffLen = 4096
+
+// Magic numbers
+var (
+	bomUTF8      = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE   = []byte{0xFF, 0xFE}
+	bomUTF16BE   = []byte{0xFE, 0xFF}
+	pdfSignature = []byte("%PDF-")
+	rtfSignature = []byte(`{\rtf`)
+	zipSignature = []byte("PK\x03\x04")
+)
+
+// DetectFormat detects the format of the content from its magic numbers.
+// The reader is moved back to its original position.
+func DetectFormat(r io.ReadSeeker) (FileExtension, MIME, error) {
+	fileExt, _, err := detectFormat(r)
+	if err != nil {
+		return "", "", err
+	}
+
+	return fileExt, mimeTypeOf(fileExt), nil
+}
+
+// DetectFileFormat returns the format of the file from its extension
+// and its content. The content is used when the extension is missing,
+// unknown or contradicts the magic numbers of a binary format.
+func DetectFileFormat(filepath string) (FileExtension, MIME, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return "", "", err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	fileExt := resolveFormat(file, GetFileExtension(filepath))
+	if fileExt == "" {
+		return "", "", ErrUnsupportedFormat
+	}
+
+	return fileExt, mimeTypeOf(fileExt), nil
+}
+
+// resolveFormat returns the format of the content given the
+// format claimed by the file extension
+func resolveFormat(r io.ReadSeeker, fileExt FileExtension) FileExtension {
+	detected, signature, err := detectFormat(r)
+	if err != nil {
+		return fileExt
+	}
+
+	switch {
+	case fileExt == "" || !isRegistered(fileExt):
+		// The extension is missing or unknown
+		return detected
+	case signature && detected != fileExt:
+		// The extension contradicts the content
+		return detected
+	default:
+		return fileExt
+	}
+}
+
+// detectFormat detects the format of the content. signature reports
+// whether the format was identified by the magic numbers of a binary
+// format rather than guessed from text.
+func detectFormat(r io.ReadSeeker) (fileExt FileExtension, signature bool, err error) {
+	// Remember the position of the reader and move it back when done
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", false, err
+	}
+	defer func() {
+		if _, e := r.Seek(start, io.SeekStart); e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	// Read the beginning of the content
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", false, err
+	}
+	head = head[:n]
+
+	switch {
+	case bytes.HasPrefix(head, cfb.Signature):
+		fileExt, err = detectOLE(r, start)
+		return fileExt, true, err
+	case bytes.HasPrefix(head, zipSignature):
+		fileExt, err = detectZIP(r, start)
+		return fileExt, true, err
+	case bytes.HasPrefix(head, pdfSignature):
+		return PDF, true, nil
+	case bytes.HasPrefix(head, rtfSignature):
+		return RTF, true, nil
+	}
+
+	fileExt = detectText(head, n == sniffLen)
+	if fileExt == "" && bytes.Contains(head[:min(len(head), 1024)], pdfSignature) {
+		// Some writers put garbage before the header, which is only
+		// looked for in binary content so that it does not override
+		// the extension of text mentioning it
+		return PDF, false, nil
+	}
+	if fileExt == "" {
+		return "", false, ErrUnsupportedFormat
+	}
+
+	return fileExt, false, nil
+}
+
+// detectOLE detects the format stored in an OLE2 compound file
+func detectOLE(r io.ReadSeeker, start int64) (FileExtension, error) {
+	ra, err := readerAt(r, start)
+	if err != nil {
+		return "", err
+	}
+
+	doc, err := cfb.Open(ra, ra.Size())
+	if err != nil {
+		return "", ErrUnsupportedFormat
+	}
+
+	for entry, err := doc.Next(); err == nil; entry, err = doc.Next() {
+		if entry.Name == "WordDocument" {
+			return DOC, nil
+		}
+	}
+
+	return "", ErrUnsupportedFormat
+}
+
+// detectZIP detects the format stored in a ZIP container
+func detectZIP(r io.ReadSeeker, start int64) (FileExtension, error) {
+	ra, err := readerAt(r, start)
+	if err != nil {
+		return "", err
+	}
+
+	zr, err := zip.NewReader(ra, ra.Size())
+	if err != nil {
+		return "", ErrUnsupportedFormat
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	// OpenDocument files and EPUBs store their MIME type in the first entry
+	if f, ok := files["mimetype"]; ok {
+		mimetype, err := readZipFile(f, 256)
+		if err == nil {
+			if fileExt := formatOfZipMIME(strings.TrimSpace(string(mimetype))); fileExt != "" {
+				return fileExt, nil
+			}
+		}
+	}
+
+	// Office Open XML files list their parts in [Content_Types].xml
+	if f, ok := files["[Content_Types].xml"]; ok {
+		contentTypes, err := readZipFile(f, 1<<20)
+		switch {
+		case err != nil:
+		case bytes.Contains(contentTypes, []byte("wordprocessingml.document.main+xml")):
+			return DOCX, nil
+		case bytes.Contains(contentTypes, []byte("spreadsheetml.sheet.main+xml")):
+			return XLSX, nil
+		case bytes.Contains(contentTypes, []byte("presentationml.presentation.main+xml")):
+			return PPTX, nil
+		}
+	}
+
+	// EPUBs point to their package document in the container
+	if _, ok := files["META-INF/container.xml"]; ok {
+		return EPUB, nil
+	}
+
+	// Pages bundles store an IWA archive, or an XML index in older versions
+	for _, name := range []string{"Index/Document.iwa", "index.xml", "index.xml.gz"} {
+		if _, ok := files[name]; ok {
+			return PAGES, nil
+		}
+	}
+
+	return "", ErrUnsupportedFormat
+}
+
+// formatOfZipMIME returns the format for the MIME type stored
+// in the mimetype entry of a ZIP container
+func formatOfZipMIME(mimetype string) FileExtension {
+	switch MIME(mimetype) {
+	case MimeODT:
+		return ODT
+	case MimeOTT:
+		return OTT
+	case MimeODS:
+		return ODS
+	case MimeODP:
+		return ODP
+	case MimeEPUB:
+		return EPUB
+	}
+	return ""
+}
+
+// detectText detects the text based formats. truncated reports
+// whether head is only the beginning of the content.
+func detectText(head []byte, truncated bool) FileExtension {
+	switch {
+	case bytes.HasPrefix(head, bomUTF8):
+		head = head[len(bomUTF8):]
+		if fileExt := detectMarkup(head, truncated); fileExt != "" {
+			return fileExt
+		}
+		return TXT
+	case bytes.HasPrefix(head, bomUTF16LE), bytes.HasPrefix(head, bomUTF16BE):
+		return TXT
+	}
+
+	if fileExt := detectMarkup(head, truncated); fileExt != "" {
+		return fileExt
+	}
+
+	if isText(head, truncated) {
+		return TXT
+	}
+
+	return ""
+}
+
+// detectMarkup detects HTML, JSON and flat OpenDocument text content
+func detectMarkup(head []byte, truncated bool) FileExtension {
+	trimmed := bytes.TrimLeft(head, " \t\r\n")
+	if len(trimmed) == 0 {
+		return ""
+	}
+
+	// JSON values which are objects or arrays
+	if trimmed[0] == '{' || trimmed[0] == '[' {
+		if isJSON(trimmed, truncated) {
+			return JSON
+		}
+		return ""
+	}
+
+	// Flat OpenDocument files declare their MIME type in the root element
+	if bytes.HasPrefix(trimmed, []byte("<?xml")) || bytes.HasPrefix(trimmed, []byte("<office:document")) {
+		if bytes.Contains(trimmed, []byte("<office:document")) &&
+			bytes.Contains(trimmed, []byte(`office:mimetype="`+MimeODT+`"`)) {
+			return FODT
+		}
+	}
+
+	// HTML documents and fragments
+	lower := bytes.ToLower(trimmed)
+	for _, prefix := range []string{"<!doctype html", "<html", "<head", "<body"} {
+		if bytes.HasPrefix(lower, []byte(prefix)) {
+			return HTML
+		}
+	}
+	if bytes.HasPrefix(lower, []byte("<")) {
+		for _, tag := range []string{"<html", "<head", "<body"} {
+			if bytes.Contains(lower, []byte(tag)) {
+				return HTML
+			}
+		}
+	}
+
+	return ""
+}
+
+// isJSON checks if head is valid JSON, a truncated head
+// is valid if it has no syntax error
+func isJSON(head []byte, truncated bool) bool {
+	if !truncated {
+		return json.Valid(head)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(head))
+	for {
+		_, err := decoder.Token()
+		if err == nil {
+			continue
+		}
+		var syntaxErr *json.SyntaxError
+		return !errors.As(err, &syntaxErr)
+	}
+}
+
+// isText checks if head is UTF-8 text without control characters
+func isText(head []byte, truncated bool) bool {
+	if len(head) == 0 {
+		return false
+	}
+
+	// The last rune may be cut by the sniff length
+	if truncated {
+		for i := 0; i < utf8.UTFMax && len(head) > 0 && !utf8.Valid(head); i++ {
+			head = head[:len(head)-1]
+		}
+	}
+	if !utf8.Valid(head) {
+		return false
+	}
+
+	for _, b := range head {
+		if b < 0x20 && b != '\t' && b != '\n' && b != '\r' && b != '\f' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// readerAt returns the content starting at start as a sized io.ReaderAt,
+// readers which are not an io.ReaderAt are read into memory
+func readerAt(r io.ReadSeeker, start int64) (*io.SectionReader, error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	if ra, ok := r.(io.ReaderAt); ok {
+		return io.NewSectionReader(ra, start, end-start), nil
+	}
+
+	if _, err = r.Seek(start, io.SeekStart); err != nil {
+		return nil, err
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NewSectionReader(bytes.NewReader(b), 0, int64(len(b))), nil
+}
+
+// sectionReader returns the remaining content of r as a sized
+// io.ReaderAt. The content is read into memory unless r can seek.
+func sectionReader(r io.Reader) (*io.SectionReader, error) {
+	if rs, ok := r.(io.ReadSeeker); ok {
+		// Pipes are files which cannot seek
+		if start, err := rs.Seek(0, io.SeekCurrent); err == nil {
+			return readerAt(rs, start)
+		}
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return io.NewSectionReader(bytes.NewReader(data), 0, int64(len(data))), nil
+}
+
+// readZipFile reads at most limit bytes of the ZIP entry
+func readZipFile(f *zip.File, limit int64) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	return io.ReadAll(io.LimitReader(rc, limit))
+}
@@ -0,0 +1,161 @@
+package totext
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pilinux/totext/internal/fixture"
+)
+
+// TestDetectFormat tests DetectFormat function
+func TestDetectFormat(t *testing.T) {
+	// Test data
+	testData := []struct {
+		name     string
+		content  []byte
+		expected FileExtension
+	}{
+		{"pdf", []byte("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n1 0 obj"), PDF},
+		{"pdf after garbage", []byte("\x00\x00\x1b%PDF-1.7\n%\xe2\xe3\xcf\xd3\n1 0 obj"), PDF},
+		{"text mentioning pdf", []byte("The header is %PDF-1.7.\n"), TXT},
+		{"rtf", []byte(`{\rtf1\ansi Hello}`), RTF},
+		{"html", []byte("<!DOCTYPE html><html><body>Hi</body></html>"), HTML},
+		{"html fragment", []byte("  <div><body>Hi</body></div>"), HTML},
+		{"json", []byte(`{"a": [1, 2, "b"]}`), JSON},
+		{"utf-8 bom", []byte("\xEF\xBB\xBFHello"), TXT},
+		{"utf-16 bom", []byte("\xFF\xFEH\x00i\x00"), TXT},
+		{"text", []byte("Hello\nWorld\n"), TXT},
+		{"docx", fixture.Zip(t, map[string]string{
+			"[Content_Types].xml": `<Types><Override ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/></Types>`,
+		}), DOCX},
+		{"odt", fixture.Zip(t, map[string]string{
+			"mimetype": string(MimeODT),
+		}), ODT},
+		{"ott", fixture.Zip(t, map[string]string{
+			"mimetype": string(MimeOTT),
+		}), OTT},
+		{"fodt", []byte(`<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+			`<office:document xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" ` +
+			`office:mimetype="application/vnd.oasis.opendocument.text"><office:body/></office:document>`), FODT},
+		{"epub", fixture.Zip(t, map[string]string{
+			"mimetype": string(MimeEPUB),
+		}), EPUB},
+		{"epub without mimetype", fixture.Zip(t, map[string]string{
+			"META-INF/container.xml": "<container/>",
+		}), EPUB},
+		{"xlsx", fixture.Zip(t, map[string]string{
+			"[Content_Types].xml": `<Types><Override ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/></Types>`,
+		}), XLSX},
+		{"ods", fixture.Zip(t, map[string]string{
+			"mimetype": string(MimeODS),
+		}), ODS},
+		{"pptx", fixture.Zip(t, map[string]string{
+			"[Content_Types].xml": `<Types><Override ContentType="application/vnd.openxmlformats-officedocument.presentationml.presentation.main+xml"/></Types>`,
+		}), PPTX},
+		{"odp", fixture.Zip(t, map[string]string{
+			"mimetype": string(MimeODP),
+		}), ODP},
+		{"pages", fixture.Zip(t, map[string]string{
+			"Index/Document.iwa": "",
+		}), PAGES},
+		{"doc", fixture.Doc("Hello"), DOC},
+	}
+
+	// Iterate over test data
+	for _, data := range testData {
+		fileExt, mime, err := DetectFormat(bytes.NewReader(data.content))
+		if err != nil {
+			t.Errorf("%s: error detecting format: %s", data.name, err)
+			continue
+		}
+		if fileExt != data.expected {
+			t.Errorf("%s: expected format %s, got %s", data.name, data.expected, fileExt)
+		}
+		if mime != mimeTypeOf(data.expected) {
+			t.Errorf("%s: expected MIME type %s, got %s", data.name, mimeTypeOf(data.expected), mime)
+		}
+	}
+}
+
+// TestDetectFormatUnknown tests DetectFormat function with binary content
+func TestDetectFormatUnknown(t *testing.T) {
+	r := bytes.NewReader([]byte{0x00, 0x01, 0x02, 0x03, 0xff})
+
+	_, _, err := DetectFormat(r)
+	if !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Expected ErrUnsupportedFormat, got %v", err)
+	}
+
+	// The reader must be moved back to its original position
+	if r.Len() != 5 {
+		t.Errorf("Expected the reader to be rewound, %d bytes left", r.Len())
+	}
+}
+
+// TestDetectFormatCorruptOLE tests DetectFormat function with a compound
+// file whose header counts more directory sectors than it holds
+func TestDetectFormatCorruptOLE(t *testing.T) {
+	content := fixture.Doc("Hello")
+	binary.LittleEndian.PutUint32(content[40:], 0x69000000)
+
+	_, _, err := DetectFormat(bytes.NewReader(content))
+	if !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Expected ErrUnsupportedFormat, got %v", err)
+	}
+}
+
+// TestResolveFormat tests resolveFormat function
+func TestResolveFormat(t *testing.T) {
+	// Test data
+	testData := []struct {
+		content  string
+		fileExt  FileExtension
+		expected FileExtension
+	}{
+		{"%PDF-1.4", DOC, PDF},
+		{"%PDF-1.4", "", PDF},
+		{"%PDF-1.4", PDF, PDF},
+		{"plain text", HTML, HTML},
+		{"<html><body>Hi</body></html>", "", HTML},
+		{"Notes\n\nFiles start with %PDF-1.7\n", TXT, TXT},
+		{"# Notes\n\nFiles start with `%PDF-1.7`\n", MD, MD},
+		{"\x00\x1b%PDF-1.4", "", PDF},
+		{"\x00\x1b%PDF-1.4", PDF, PDF},
+	}
+
+	// Iterate over test data
+	for _, data := range testData {
+		fileExt := resolveFormat(bytes.NewReader([]byte(data.content)), data.fileExt)
+		if fileExt != data.expected {
+			t.Errorf("Expected format %s for %q with extension %q, got %s", data.expected, data.content, data.fileExt, fileExt)
+		}
+	}
+}
+
+// TestConvertTextMentioningPDF tests that text files which mention
+// the PDF header are converted as text
+func TestConvertTextMentioningPDF(t *testing.T) {
+	// Test data
+	testData := []string{"notes.txt", "notes.md"}
+
+	// Iterate over test data
+	for _, name := range testData {
+		path := filepath.Join(t.TempDir(), name)
+		if err := os.WriteFile(path, []byte("PDF files start with %PDF-1.7\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		content, _, err := Convert(path)
+		if err != nil {
+			t.Errorf("%s: error converting: %s", name, err)
+			continue
+		}
+		if !strings.Contains(content, "PDF files start with") {
+			t.Errorf("%s: unexpected content %q", name, content)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package totext
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/pilinux/totext/internal/doc"
+)
+
+// docNative reports whether the built-in MS word doc extractor is used
+func (o *Options) docNative() (bool, error) {
+	switch o.DocBackend {
+	case DocBackendNative:
+		return true, nil
+	case DocBackendWv:
+		return false, nil
+	case DocBackendAuto:
+		_, wv := lookPath("wvText")
+		return !wv, nil
+	}
+
+	return false, fmt.Errorf("unknown doc backend %q", o.DocBackend)
+}
+
+// docNativeText writes the text of the MS word doc content to w
+// with the built-in extractor and returns the metadata
+func docNativeText(ctx context.Context, r io.Reader, w io.Writer, opts ...Option) (map[string]string, error) {
+	ra, err := sectionReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := doc.Text(ra, ra.Size())
+	if ctx.Err() != nil {
+		return nil, contextError(ctx)
+	}
+
+	// Some .doc files are docx files in disguise
+	if errors.Is(err, doc.ErrNotDoc) {
+		content, metadata, e := ConvertDocxReaderToTextContext(ctx, io.NewSectionReader(ra, 0, ra.Size()), opts...)
+		if e != nil {
+			return nil, corruptError(err)
+		}
+		if _, e = io.WriteString(w, content); e != nil {
+			return nil, e
+		}
+		return metadata, nil
+	}
+
+	switch {
+	case errors.Is(err, doc.ErrEncrypted):
+		return nil, fmt.Errorf("%w: %w", ErrEncrypted, err)
+	case errors.Is(err, doc.ErrUnsupported):
+		return nil, fmt.Errorf("%w: %w", ErrUnsupportedFormat, err)
+	case err != nil:
+		return nil, corruptError(err)
+	}
+
+	// Read metadata from the OLE2 container
+	metadata := docInfo(ra, ra.Size())
+
+	// Filter out non-readable characters
+	if _, err = io.WriteString(w, FilterNonReadableCharacter(text)); err != nil {
+		return nil, err
+	}
+
+	return metadata, nil
+}
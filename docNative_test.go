@@ -0,0 +1,50 @@
+package totext
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pilinux/totext/internal/fixture"
+)
+
+// TestDocNativeBackend tests the built-in MS word doc extractor
+func TestDocNativeBackend(t *testing.T) {
+	// A docx file in disguise
+	docx := fixture.Zip(t, map[string]string{
+		"[Content_Types].xml": `<?xml version="1.0"?><Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/></Types>`,
+		"word/document.xml": `<?xml version="1.0"?><w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` +
+			`<w:body><w:p><w:r><w:t>Disguised</w:t></w:r></w:p></w:body></w:document>`,
+	})
+	content, _, err := ConvertDocReaderToText(bytes.NewReader(docx), WithDocBackend(DocBackendNative))
+	if err != nil || strings.TrimSpace(content) != "Disguised" {
+		t.Errorf("expected %q, got %q (%v)", "Disguised", content, err)
+	}
+
+	// Tabs and table cells
+	content, _, err = ConvertDocReaderToText(bytes.NewReader(fixture.Doc("Name\tValue\rA\x07B\x07\x07C\x07D\x07\x07")), WithDocBackend(DocBackendNative))
+	if expected := "Name Value\nA | B\nC | D\n"; err != nil || content != expected {
+		t.Errorf("expected %q, got %q (%v)", expected, content, err)
+	}
+
+	_, _, err = ConvertDocReaderToText(strings.NewReader("not a doc"), WithDocBackend(DocBackendNative))
+	if !errors.Is(err, ErrCorrupt) {
+		t.Errorf("expected ErrCorrupt, got %v", err)
+	}
+
+	// A header counting more directory sectors than the file holds
+	corrupt := fixture.Doc("Hello")
+	binary.LittleEndian.PutUint32(corrupt[40:], 0x69000000)
+	_, _, err = ConvertDocReaderToText(bytes.NewReader(corrupt), WithDocBackend(DocBackendNative))
+	if !errors.Is(err, ErrCorrupt) {
+		t.Errorf("expected ErrCorrupt for a corrupted header, got %v", err)
+	}
+
+	_, _, err = ConvertDocReaderToText(strings.NewReader("not a doc"), WithDocBackend("other"))
+	if err == nil {
+		t.Error("expected an error for an unknown backend")
+	}
+}
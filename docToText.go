@@ -1,11 +1,36 @@
 package totext
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"os"
 
-	"code.sajari.com/docconv"
+	"github.com/richardlehane/msoleps"
+
+	"github.com/pilinux/totext/internal/cfb"
+)
+
+func init() {
+	RegisterConverter(DOC, MimeDOC, StreamConverterFunc(ConvertDocReaderToWriterContext))
+}
+
+// DocBackend is an MS word doc text extractor
+type DocBackend string
+
+const (
+	// DocBackendAuto uses wv if wvText is installed,
+	// the built-in extractor otherwise
+	DocBackendAuto DocBackend = ""
+	// DocBackendWv uses wvText
+	DocBackendWv DocBackend = "wv"
+	// DocBackendNative uses the built-in extractor written in Go
+	DocBackendNative DocBackend = "native"
 )
 
+// docTimeLayout is the layout of the dates in the summary information
+const docTimeLayout = "2006-01-02 15:04:05.999999999 -0700 MST"
+
 // ConvertDocToText receives MS word doc filepath as an argument
 // and returns its text content and metadata
 //
@@ -14,7 +39,15 @@ import (
 // Debian/Ubuntu: sudo apt install wv
 //
 // MacOS: brew install wv
+//
+// Without wv the built-in extractor is used, see WithDocBackend.
 func ConvertDocToText(filepath string) (content string, metadata map[string]string, err error) {
+	return ConvertDocToTextContext(context.Background(), filepath)
+}
+
+// ConvertDocToTextContext is like ConvertDocToText but stops
+// the conversion when ctx is done
+func ConvertDocToTextContext(ctx context.Context, filepath string, opts ...Option) (content string, metadata map[string]string, err error) {
 	// Get the doc file
 	docFile, err := os.Open(filepath)
 	if err != nil {
@@ -25,13 +58,188 @@ func ConvertDocToText(filepath string) (content string, metadata map[string]stri
 	}()
 
 	// Convert doc to text
-	content, metadata, err = docconv.ConvertDoc(docFile)
+	return ConvertDocReaderToTextContext(ctx, docFile, opts...)
+}
+
+// ConvertDocReaderToText receives MS word doc content as an io.Reader
+// and returns its text content and metadata
+//
+// The external tool reads from a file, so r is copied to a
+// temporary file unless it is an *os.File. The built-in extractor
+// reads r directly if it can seek.
+//
+// Dependencies:
+//
+// Debian/Ubuntu: sudo apt install wv
+//
+// MacOS: brew install wv
+//
+// Without wv the built-in extractor is used, see WithDocBackend.
+func ConvertDocReaderToText(r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertDocReaderToTextContext(context.Background(), r, opts...)
+}
+
+// ConvertDocReaderToTextContext is like ConvertDocReaderToText but
+// kills wvText when ctx is done
+func ConvertDocReaderToTextContext(ctx context.Context, r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	return StreamConverterFunc(ConvertDocReaderToWriterContext).ConvertReader(ctx, r, opts...)
+}
+
+// ConvertDocReaderToWriter receives MS word doc content as an io.Reader,
+// writes its text content to w and returns its metadata
+//
+// Dependencies:
+//
+// Debian/Ubuntu: sudo apt install wv
+//
+// MacOS: brew install wv
+//
+// Without wv the built-in extractor is used, see WithDocBackend.
+func ConvertDocReaderToWriter(r io.Reader, w io.Writer, opts ...Option) (metadata map[string]string, err error) {
+	return ConvertDocReaderToWriterContext(context.Background(), r, w, opts...)
+}
+
+// ConvertDocReaderToWriterContext is like ConvertDocReaderToWriter but
+// kills wvText when ctx is done
+func ConvertDocReaderToWriterContext(ctx context.Context, r io.Reader, w io.Writer, opts ...Option) (metadata map[string]string, err error) {
+	if ctx.Err() != nil {
+		return nil, contextError(ctx)
+	}
+
+	native, err := newOptions(opts...).docNative()
 	if err != nil {
-		return "", nil, err
+		return nil, err
+	}
+	if native {
+		return docNativeText(ctx, r, w, opts...)
+	}
+
+	// wvText reads from a file
+	path, done, err := localFile(r)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	// Read metadata from the OLE2 container
+	metadata = docFileInfo(path)
+
+	// Convert doc to text, filtering out non-readable characters
+	fw := NewFilterWriter(w)
+	n, err := wvText(ctx, path, fw)
+	if ctx.Err() != nil {
+		return nil, contextError(ctx)
 	}
 
-	// Filter out non-readable characters
-	content = FilterNonReadableCharacter(content)
+	// The text was partly written
+	if err != nil && n > 0 {
+		return nil, err
+	}
+
+	// Some .doc files are docx files in disguise
+	if err != nil || n == 0 {
+		content, metadata, e := ConvertDocxToTextContext(ctx, path, opts...)
+		if e != nil {
+			if err != nil {
+				return nil, corruptError(err)
+			}
+			return nil, e
+		}
+		if _, e = io.WriteString(w, content); e != nil {
+			return nil, e
+		}
+		return metadata, nil
+	}
+
+	if err = fw.Close(); err != nil {
+		return nil, err
+	}
+
+	return metadata, nil
+}
+
+// wvText converts the doc file to text with wvText, writes the
+// text to w and returns the number of bytes written
+func wvText(ctx context.Context, path string, w io.Writer) (int64, error) {
+	// wvText writes its output to a file
+	outputFile, err := os.CreateTemp("", "totext-")
+	if err != nil {
+		return 0, err
+	}
+	_ = outputFile.Close()
+	defer func() {
+		_ = os.Remove(outputFile.Name())
+	}()
+
+	if _, err = runCommand(ctx, "wvText", nil, "wvText", path, outputFile.Name()); err != nil {
+		return 0, err
+	}
+
+	body, err := os.Open(outputFile.Name())
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = body.Close()
+	}()
+
+	return io.Copy(w, body)
+}
+
+// docFileInfo returns the properties stored in the summary
+// information streams of the doc file
+func docFileInfo(path string) map[string]string {
+	docFile, err := os.Open(path)
+	if err != nil {
+		return make(map[string]string)
+	}
+	defer func() {
+		_ = docFile.Close()
+	}()
+
+	stat, err := docFile.Stat()
+	if err != nil {
+		return make(map[string]string)
+	}
+
+	return docInfo(docFile, stat.Size())
+}
+
+// docInfo returns the properties stored in the summary information
+// streams of the doc content of the given size
+func docInfo(ra io.ReaderAt, size int64) (metadata map[string]string) {
+	metadata = make(map[string]string)
+
+	// The property parsers panic on malformed streams
+	defer func() {
+		_ = recover()
+	}()
+
+	doc, err := cfb.Open(ra, size)
+	if err != nil {
+		return
+	}
+
+	props := msoleps.New()
+	for entry, err := doc.Next(); err == nil; entry, err = doc.Next() {
+		if !msoleps.IsMSOLEPS(entry.Initial) {
+			continue
+		}
+		if err := props.Reset(doc); err != nil {
+			break
+		}
+		for _, prop := range props.Property {
+			metadata[prop.Name] = prop.String()
+		}
+	}
+
+	// Convert dates to unix timestamps
+	if t, ok := parseTime(metadata["LastSaveTime"], docTimeLayout); ok {
+		metadata["ModifiedDate"] = fmt.Sprintf("%d", t.Unix())
+	}
+	if t, ok := parseTime(metadata["CreateTime"], docTimeLayout); ok {
+		metadata["CreatedDate"] = fmt.Sprintf("%d", t.Unix())
+	}
 
 	return
 }
@@ -0,0 +1,145 @@
+package totext
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+)
+
+// Document is the result of a conversion with typed metadata
+type Document struct {
+	// Text is the text content of the document
+	Text string
+
+	// Metadata is the metadata of the document, the metadata
+	// as returned by the converter is kept in Raw
+	Metadata
+
+	// Format is the format of the source document
+	Format FileExtension
+	// Size is the size of the source document in bytes
+	Size int64
+	// SHA256 is the hex encoded SHA-256 checksum of the source document
+	SHA256 string
+
+	// Warnings are the non-fatal problems found during the conversion
+	Warnings []string
+}
+
+// ConvertDocument receives a filepath as an argument, picks the
+// registered converter for its format and returns the converted document.
+// The format is detected like in Convert.
+func ConvertDocument(ctx context.Context, filepath string, opts ...Option) (*Document, error) {
+	filepath = strings.TrimSpace(filepath)
+
+	// Get the file
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	// Get the format from the file extension and the content
+	fileExt := resolveFormat(file, GetFileExtension(filepath))
+
+	// Checksum the file first, so that the converters
+	// still receive the *os.File
+	h := sha256.New()
+	size, err := io.Copy(h, file)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	opts = append(opts, WithWarnings(&warnings))
+
+	content, metadata, err := ConvertReaderContext(ctx, file, fileExt, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := newDocument(fileExt, content, metadata, warnings)
+	doc.Size = size
+	doc.SHA256 = hex.EncodeToString(h.Sum(nil))
+
+	return doc, nil
+}
+
+// ConvertReaderDocument receives the document content as an io.Reader,
+// picks the registered converter for the given format and returns
+// the converted document
+func ConvertReaderDocument(ctx context.Context, r io.Reader, format FileExtension, opts ...Option) (*Document, error) {
+	// Checksum the content while it is being converted
+	hr := &hashReader{r: r, h: sha256.New()}
+
+	var warnings []string
+	opts = append(opts, WithWarnings(&warnings))
+
+	content, metadata, err := ConvertReaderContext(ctx, hr, format, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read the rest of the content which the converter did not need
+	if _, err = io.Copy(io.Discard, hr); err != nil {
+		return nil, err
+	}
+
+	doc := newDocument(format, content, metadata, warnings)
+	doc.Size = hr.n
+	doc.SHA256 = hex.EncodeToString(hr.h.Sum(nil))
+
+	return doc, nil
+}
+
+// hashReader counts and checksums the bytes read from r
+type hashReader struct {
+	r io.Reader
+	h hash.Hash
+	n int64
+}
+
+// Read reads from the underlying reader and updates the checksum
+func (hr *hashReader) Read(p []byte) (int, error) {
+	n, err := hr.r.Read(p)
+	if n > 0 {
+		_, _ = hr.h.Write(p[:n])
+		hr.n += int64(n)
+	}
+	return n, err
+}
+
+// newDocument creates a document and fills its typed
+// metadata from the metadata returned by the converter
+func newDocument(format FileExtension, content string, metadata map[string]string, warnings []string) *Document {
+	doc := &Document{
+		Text:     content,
+		Format:   format,
+		Warnings: warnings,
+	}
+
+	var counter TextCounter
+	_, _ = counter.WriteString(content)
+	doc.Metadata = newMetadata(metadata, &counter, doc.warn)
+
+	if strings.TrimSpace(content) == "" {
+		doc.warn("no text extracted")
+	}
+
+	return doc
+}
+
+// warn adds a warning to the document
+func (doc *Document) warn(format string, args ...any) {
+	doc.Warnings = append(doc.Warnings, fmt.Sprintf(format, args...))
+}
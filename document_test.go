@@ -0,0 +1,79 @@
+package totext
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestConvertReaderDocument tests ConvertReaderDocument function
+func TestConvertReaderDocument(t *testing.T) {
+	htmlContent := "<html><head><title>Test Page</title></head><body><p>Hello</p></body></html>"
+
+	doc, err := ConvertReaderDocument(
+		context.Background(),
+		strings.NewReader(htmlContent),
+		HTML,
+		WithPrettify(false),
+	)
+	if err != nil {
+		t.Fatalf("Error converting HTML: %s", err)
+	}
+
+	sum := sha256.Sum256([]byte(htmlContent))
+
+	// Compare document fields
+	if doc.Title != "Test Page" {
+		t.Errorf("Expected title %q, got %q", "Test Page", doc.Title)
+	}
+	if doc.Format != HTML {
+		t.Errorf("Expected format %s, got %s", HTML, doc.Format)
+	}
+	if doc.Size != int64(len(htmlContent)) {
+		t.Errorf("Expected size %d, got %d", len(htmlContent), doc.Size)
+	}
+	if doc.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("Expected checksum %x, got %s", sum, doc.SHA256)
+	}
+	if !strings.Contains(doc.Text, "Hello") {
+		t.Errorf("Expected text to contain %q, got %q", "Hello", doc.Text)
+	}
+}
+
+// TestNewDocument tests newDocument function
+func TestNewDocument(t *testing.T) {
+	metadata := map[string]string{
+		"Title":        "Report",
+		"Author":       "Alice; Bob",
+		"CreatedDate":  "1700000000",
+		"ModifiedDate": "yesterday",
+		"Pages":        "12",
+	}
+
+	doc := newDocument(PDF, "text", metadata, nil)
+
+	if doc.Title != "Report" {
+		t.Errorf("Expected title %q, got %q", "Report", doc.Title)
+	}
+	if len(doc.Authors) != 2 || doc.Authors[0] != "Alice" || doc.Authors[1] != "Bob" {
+		t.Errorf("Expected authors [Alice Bob], got %v", doc.Authors)
+	}
+	if !doc.Created.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("Expected created time %s, got %s", time.Unix(1700000000, 0), doc.Created)
+	}
+	if !doc.Modified.IsZero() {
+		t.Errorf("Expected zero modified time, got %s", doc.Modified)
+	}
+	if doc.PageCount != 12 {
+		t.Errorf("Expected page count 12, got %d", doc.PageCount)
+	}
+	if doc.WordCount != 1 || doc.CharacterCount != 4 || doc.Raw["Title"] != "Report" {
+		t.Errorf("Expected the counts of the text and the raw metadata, got %+v", doc.Metadata)
+	}
+	if len(doc.Warnings) != 1 {
+		t.Errorf("Expected one warning for the invalid date, got %v", doc.Warnings)
+	}
+}
@@ -1,14 +1,34 @@
 package totext
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"time"
 
-	"code.sajari.com/docconv"
+	"github.com/pilinux/totext/internal/docx"
 )
 
+func init() {
+	RegisterConverter(DOCX, MimeDOCX, ConverterFunc(ConvertDocxReaderToTextContext))
+}
+
 // ConvertDocxToText receives MS word docx filepath as an argument
 // and returns its text content and metadata
+//
+// Headings are prefixed with # for every level, list items with their
+// number or bullet and table cells are separated with " | ". The headers,
+// footers, footnotes, endnotes and comments follow the body, each
+// introduced by a label line, e.g. [Footnote 1].
 func ConvertDocxToText(filepath string) (content string, metadata map[string]string, err error) {
+	return ConvertDocxToTextContext(context.Background(), filepath)
+}
+
+// ConvertDocxToTextContext is like ConvertDocxToText but stops
+// the conversion when ctx is done
+func ConvertDocxToTextContext(ctx context.Context, filepath string, opts ...Option) (content string, metadata map[string]string, err error) {
 	// Get the docx file
 	docxFile, err := os.Open(filepath)
 	if err != nil {
@@ -19,13 +39,113 @@ func ConvertDocxToText(filepath string) (content string, metadata map[string]str
 	}()
 
 	// Convert docx to text
-	content, metadata, err = docconv.ConvertDocx(docxFile)
+	return ConvertDocxReaderToTextContext(ctx, docxFile, opts...)
+}
+
+// ConvertDocxReaderToText receives MS word docx content as an io.Reader
+// and returns its text content and metadata
+//
+// The text content is laid out as with ConvertDocxToText.
+func ConvertDocxReaderToText(r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertDocxReaderToTextContext(context.Background(), r, opts...)
+}
+
+// ConvertDocxReaderToTextContext is like ConvertDocxReaderToText
+// but stops the conversion when ctx is done
+func ConvertDocxReaderToTextContext(ctx context.Context, r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	markdown, err := newOptions(opts...).markdown()
+	if err != nil {
+		return "", nil, err
+	}
+
+	sections, metadata, err := ConvertDocxReaderToSectionsContext(ctx, r, opts...)
 	if err != nil {
 		return "", nil, err
 	}
 
-	// Filter out non-readable characters
-	content = FilterNonReadableCharacter(content)
+	return sectionsText(sections, markdown), metadata, nil
+}
+
+// ConvertDocxToSections receives MS word docx filepath as an argument
+// and returns the text content of its body, headers, footers,
+// footnotes, endnotes and comments as separate sections, and its metadata
+func ConvertDocxToSections(filepath string, opts ...Option) (sections []Section, metadata map[string]string, err error) {
+	return ConvertDocxToSectionsContext(context.Background(), filepath, opts...)
+}
+
+// ConvertDocxToSectionsContext is like ConvertDocxToSections but stops
+// the conversion when ctx is done
+func ConvertDocxToSectionsContext(ctx context.Context, filepath string, opts ...Option) (sections []Section, metadata map[string]string, err error) {
+	// Get the docx file
+	docxFile, err := os.Open(filepath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_ = docxFile.Close()
+	}()
+
+	// Convert docx to sections
+	return ConvertDocxReaderToSectionsContext(ctx, docxFile, opts...)
+}
+
+// ConvertDocxReaderToSections receives MS word docx content as an
+// io.Reader and returns the text content of its body, headers, footers,
+// footnotes, endnotes and comments as separate sections, and its metadata
+func ConvertDocxReaderToSections(r io.Reader, opts ...Option) (sections []Section, metadata map[string]string, err error) {
+	return ConvertDocxReaderToSectionsContext(context.Background(), r, opts...)
+}
+
+// ConvertDocxReaderToSectionsContext is like ConvertDocxReaderToSections
+// but stops the conversion when ctx is done
+func ConvertDocxReaderToSectionsContext(ctx context.Context, r io.Reader, opts ...Option) (sections []Section, metadata map[string]string, err error) {
+	if ctx.Err() != nil {
+		return nil, nil, contextError(ctx)
+	}
+
+	o := newOptions(opts...)
+	reject, err := o.rejectChanges()
+	if err != nil {
+		return nil, nil, err
+	}
+	markdown, err := o.markdown()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ra, err := sectionReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	doc, err := docx.Read(ctx, ra, ra.Size(), reject)
+	if ctx.Err() != nil {
+		return nil, nil, contextError(ctx)
+	}
+	if errors.Is(err, docx.ErrEncrypted) {
+		return nil, nil, fmt.Errorf("%w: %w", ErrEncrypted, err)
+	}
+	if err != nil {
+		return nil, nil, corruptError(err)
+	}
+
+	return documentSections(doc, markdown), docxMetadata(doc.Properties), nil
+}
+
+// docxMetadata returns the metadata of the document properties
+func docxMetadata(properties map[string]string) map[string]string {
+	metadata := make(map[string]string, len(properties)+2)
+	for key, value := range properties {
+		metadata[key] = value
+	}
+
+	// Convert dates to unix timestamps
+	if t, err := time.Parse(time.RFC3339, metadata["modified"]); err == nil {
+		metadata["ModifiedDate"] = fmt.Sprintf("%d", t.Unix())
+	}
+	if t, err := time.Parse(time.RFC3339, metadata["created"]); err == nil {
+		metadata["CreatedDate"] = fmt.Sprintf("%d", t.Unix())
+	}
 
-	return
+	return metadata
 }
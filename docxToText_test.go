@@ -0,0 +1,142 @@
+package totext
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pilinux/totext/internal/fixture"
+)
+
+// testDocx returns a docx document with a heading, a list, a table,
+// a tracked change, a footnote, a comment and a header
+func testDocx(t *testing.T) []byte {
+	t.Helper()
+
+	const ns = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"`
+	const rel = `<Relationship Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/`
+
+	return fixture.Zip(t, map[string]string{
+		"word/_rels/document.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			rel + `styles" Id="rId1" Target="styles.xml"/>` +
+			rel + `numbering" Id="rId2" Target="numbering.xml"/>` +
+			rel + `footnotes" Id="rId3" Target="footnotes.xml"/>` +
+			rel + `comments" Id="rId4" Target="comments.xml"/>` +
+			rel + `header" Id="rId5" Target="header1.xml"/>` +
+			`</Relationships>`,
+		"word/document.xml": `<w:document ` + ns + `><w:body>` +
+			`<w:p><w:pPr><w:pStyle w:val="Heading2"/></w:pPr><w:r><w:t>Results</w:t></w:r></w:p>` +
+			`<w:p><w:r><w:t>See note</w:t></w:r><w:r><w:footnoteReference w:id="5"/></w:r></w:p>` +
+			`<w:p><w:pPr><w:numPr><w:ilvl w:val="0"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>First</w:t></w:r></w:p>` +
+			`<w:p><w:pPr><w:numPr><w:ilvl w:val="1"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>Nested</w:t></w:r></w:p>` +
+			`<w:tbl><w:tr><w:tc><w:p><w:r><w:t>Name</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>Score</w:t></w:r></w:p></w:tc></w:tr>` +
+			`<w:tr><w:tc><w:p><w:r><w:t>Ann</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>9</w:t></w:r></w:p></w:tc></w:tr></w:tbl>` +
+			`<w:p><w:r><w:t xml:space="preserve">Total </w:t></w:r><w:del w:id="1"><w:r><w:delText>8</w:delText></w:r></w:del>` +
+			`<w:ins w:id="2"><w:r><w:t>9</w:t></w:r></w:ins></w:p>` +
+			`<w:sectPr><w:headerReference r:id="rId5"/></w:sectPr></w:body></w:document>`,
+		"word/styles.xml": `<w:styles ` + ns + `><w:style w:type="paragraph" w:styleId="Heading2">` +
+			`<w:name w:val="heading 2"/></w:style></w:styles>`,
+		"word/numbering.xml": `<w:numbering ` + ns + `><w:abstractNum w:abstractNumId="0">` +
+			`<w:lvl w:ilvl="0"><w:numFmt w:val="decimal"/><w:lvlText w:val="%1."/></w:lvl>` +
+			`<w:lvl w:ilvl="1"><w:numFmt w:val="bullet"/><w:lvlText w:val="-"/></w:lvl></w:abstractNum>` +
+			`<w:num w:numId="1"><w:abstractNumId w:val="0"/></w:num></w:numbering>`,
+		"word/footnotes.xml": `<w:footnotes ` + ns + `><w:footnote w:id="5"><w:p><w:r><w:footnoteRef/></w:r>` +
+			`<w:r><w:t xml:space="preserve"> Source: survey</w:t></w:r></w:p></w:footnote></w:footnotes>`,
+		"word/comments.xml": `<w:comments ` + ns + `><w:comment w:id="0" w:author="Jane Doe">` +
+			`<w:p><w:r><w:t>Verify</w:t></w:r></w:p></w:comment></w:comments>`,
+		"word/header1.xml": `<w:hdr ` + ns + `><w:p><w:r><w:t>Draft</w:t></w:r></w:p></w:hdr>`,
+		"docProps/core.xml": `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" ` +
+			`xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/">` +
+			`<dc:title>Survey</dc:title><dcterms:modified>2022-01-02T03:04:05Z</dcterms:modified></cp:coreProperties>`,
+		"_rels/.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			rel + `officeDocument" Id="rId1" Target="word/document.xml"/>` +
+			`<Relationship Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" ` +
+			`Id="rId2" Target="docProps/core.xml"/></Relationships>`,
+	})
+}
+
+// TestConvertDocxReaderToText tests the structure of the docx text content
+func TestConvertDocxReaderToText(t *testing.T) {
+	docx := testDocx(t)
+
+	// Test data
+	testData := []struct {
+		name     string
+		opts     []Option
+		expected string
+	}{
+		{
+			"accepted changes",
+			nil,
+			"## Results\nSee note[1]\n1. First\n  - Nested\nName | Score\nAnn | 9\nTotal 9\n" +
+				"[Header]\nDraft\n[Footnote 1]\nSource: survey\n[Comment 0 by Jane Doe]\nVerify\n",
+		},
+		{
+			"rejected changes",
+			[]Option{WithTrackedChanges(TrackedChangesReject)},
+			"## Results\nSee note[1]\n1. First\n  - Nested\nName | Score\nAnn | 9\nTotal 8\n" +
+				"[Header]\nDraft\n[Footnote 1]\nSource: survey\n[Comment 0 by Jane Doe]\nVerify\n",
+		},
+		{
+			"markdown",
+			[]Option{WithTextFormat(TextFormatMarkdown)},
+			"## Results\n\nSee note\\[1\\]\n\n1. First\n    - Nested\n\n| Name | Score |\n| --- | --- |\n| Ann | 9 |\n\nTotal 9\n\n" +
+				"\\[Header\\]\n\nDraft\n\n\\[Footnote 1\\]\n\nSource: survey\n\n\\[Comment 0 by Jane Doe\\]\n\nVerify\n",
+		},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		content, metadata, err := ConvertDocxReaderToText(bytes.NewReader(docx), td.opts...)
+		if err != nil {
+			t.Fatalf("%s: %v", td.name, err)
+		}
+		if content != td.expected {
+			t.Errorf("%s: expected %q, got %q", td.name, td.expected, content)
+		}
+		if metadata["title"] != "Survey" || metadata["ModifiedDate"] != "1641092645" {
+			t.Errorf("%s: unexpected metadata %v", td.name, metadata)
+		}
+	}
+}
+
+// TestConvertDocxReaderToSections tests the sections of a docx document
+func TestConvertDocxReaderToSections(t *testing.T) {
+	sections, _, err := ConvertDocxReaderToSections(bytes.NewReader(testDocx(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Section{
+		{Kind: SectionBody},
+		{Kind: SectionHeader, Text: "Draft\n"},
+		{Kind: SectionFootnote, ID: "1", Text: "Source: survey\n"},
+		{Kind: SectionComment, ID: "0", Author: "Jane Doe", Text: "Verify\n"},
+	}
+	if len(sections) != len(expected) {
+		t.Fatalf("expected %d sections, got %d", len(expected), len(sections))
+	}
+	for i, s := range sections {
+		if i == 0 {
+			if s.Kind != SectionBody || !strings.HasPrefix(s.Text, "## Results\n") {
+				t.Errorf("unexpected body %+v", s)
+			}
+			continue
+		}
+		if s != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], s)
+		}
+	}
+
+	_, _, err = ConvertDocxReaderToSections(strings.NewReader("not a docx"))
+	if !errors.Is(err, ErrCorrupt) {
+		t.Errorf("expected ErrCorrupt, got %v", err)
+	}
+
+	_, _, err = ConvertDocxReaderToSections(bytes.NewReader(testDocx(t)), WithTrackedChanges("other"))
+	if err == nil {
+		t.Error("expected an error for an unknown tracked changes mode")
+	}
+}
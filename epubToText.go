@@ -0,0 +1,244 @@
+package totext
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+
+	"github.com/pilinux/totext/internal/epub"
+	"github.com/pilinux/totext/internal/structure"
+)
+
+func init() {
+	RegisterConverter(EPUB, MimeEPUB, ConverterFunc(ConvertEPUBReaderToTextContext))
+}
+
+// epubDateLayouts are the layouts of the dates of the metadata
+var epubDateLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02", "2006-01", "2006"}
+
+// Chapter is the text content of a chapter of an e-book
+type Chapter struct {
+	// Number is the number of the chapter in reading order, starting at 1
+	Number int
+	// Title is the title of the chapter in the table of contents,
+	// empty if it is not listed
+	Title string
+	// Path is the path of the chapter in the archive
+	Path string
+	// Text is the text content of the chapter
+	Text string
+}
+
+// ConvertEPUBToText receives epub filepath as an argument and returns its text content and metadata
+//
+// The chapters are converted in the reading order of the spine and laid
+// out as with ConvertDocxToText. A chapter which does not start with a
+// heading is introduced by its title in the table of contents. The
+// metadata holds the Dublin Core elements of the package document, e.g.
+// title, creator, language, publisher, identifier and date, and the
+// number of chapters.
+func ConvertEPUBToText(filepath string, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertEPUBToTextContext(context.Background(), filepath, opts...)
+}
+
+// ConvertEPUBToTextContext is like ConvertEPUBToText but stops
+// the conversion when ctx is done
+func ConvertEPUBToTextContext(ctx context.Context, filepath string, opts ...Option) (content string, metadata map[string]string, err error) {
+	// Get the epub file
+	epubFile, err := os.Open(filepath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer func() {
+		_ = epubFile.Close()
+	}()
+
+	// Convert epub to text
+	return ConvertEPUBReaderToTextContext(ctx, epubFile, opts...)
+}
+
+// ConvertEPUBReaderToText receives epub content as an io.Reader
+// and returns its text content and metadata
+//
+// The text content and the metadata are laid out as with ConvertEPUBToText.
+func ConvertEPUBReaderToText(r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertEPUBReaderToTextContext(context.Background(), r, opts...)
+}
+
+// ConvertEPUBReaderToTextContext is like ConvertEPUBReaderToText
+// but stops the conversion when ctx is done
+func ConvertEPUBReaderToTextContext(ctx context.Context, r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	markdown, err := newOptions(opts...).markdown()
+	if err != nil {
+		return "", nil, err
+	}
+
+	chapters, metadata, err := ConvertEPUBReaderToChaptersContext(ctx, r, opts...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// The chapters of Markdown content are separated with a blank line
+	texts := make([]string, len(chapters))
+	for i, chapter := range chapters {
+		texts[i] = chapter.Text
+	}
+	sep := ""
+	if markdown {
+		sep = "\n"
+	}
+
+	return strings.Join(texts, sep), metadata, nil
+}
+
+// ConvertEPUBToChapters receives epub filepath as an argument and
+// returns the text content of its chapters in reading order, and its
+// metadata. The chapters without text, e.g. the cover, are left out.
+func ConvertEPUBToChapters(filepath string, opts ...Option) (chapters []Chapter, metadata map[string]string, err error) {
+	return ConvertEPUBToChaptersContext(context.Background(), filepath, opts...)
+}
+
+// ConvertEPUBToChaptersContext is like ConvertEPUBToChapters but stops
+// the conversion when ctx is done
+func ConvertEPUBToChaptersContext(ctx context.Context, filepath string, opts ...Option) (chapters []Chapter, metadata map[string]string, err error) {
+	// Get the epub file
+	epubFile, err := os.Open(filepath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_ = epubFile.Close()
+	}()
+
+	// Convert epub to chapters
+	return ConvertEPUBReaderToChaptersContext(ctx, epubFile, opts...)
+}
+
+// ConvertEPUBReaderToChapters receives epub content as an io.Reader
+// and returns the text content of its chapters in reading order,
+// and its metadata
+func ConvertEPUBReaderToChapters(r io.Reader, opts ...Option) (chapters []Chapter, metadata map[string]string, err error) {
+	return ConvertEPUBReaderToChaptersContext(context.Background(), r, opts...)
+}
+
+// ConvertEPUBReaderToChaptersContext is like ConvertEPUBReaderToChapters
+// but stops the conversion when ctx is done
+func ConvertEPUBReaderToChaptersContext(ctx context.Context, r io.Reader, opts ...Option) (chapters []Chapter, metadata map[string]string, err error) {
+	if ctx.Err() != nil {
+		return nil, nil, contextError(ctx)
+	}
+
+	o := newOptions(opts...)
+	markdown, err := o.markdown()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ra, err := sectionReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	book, err := epub.Read(ctx, ra, ra.Size())
+	if ctx.Err() != nil {
+		return nil, nil, contextError(ctx)
+	}
+	if errors.Is(err, epub.ErrEncrypted) {
+		return nil, nil, fmt.Errorf("%w: %w", ErrEncrypted, err)
+	}
+	if err != nil {
+		return nil, nil, corruptError(err)
+	}
+
+	for _, c := range book.Chapters {
+		if ctx.Err() != nil {
+			return nil, nil, contextError(ctx)
+		}
+		text, err := epubChapterText(c, markdown)
+		if err != nil {
+			o.warn("epub: %s: %v", c.Path, err)
+			continue
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		chapters = append(chapters, Chapter{
+			Number: len(chapters) + 1,
+			Title:  c.Title,
+			Path:   c.Path,
+			Text:   text,
+		})
+	}
+
+	return chapters, epubMetadata(book, len(chapters)), nil
+}
+
+// epubChapterText returns the text content of the XHTML document of the
+// chapter, its title is the first heading unless it starts with one
+func epubChapterText(c epub.Chapter, markdown bool) (string, error) {
+	content, _, err := decodeCharset(c.Content, true, newOptions())
+	if err != nil {
+		return "", err
+	}
+	doc, err := html.Parse(bytes.NewReader(content))
+	if err != nil {
+		return "", err
+	}
+
+	blocks := htmlBlocks(doc)
+	if len(blocks) == 0 {
+		return "", nil
+	}
+	if c.Title != "" && blocks[0].Kind != structure.Heading {
+		title := structure.Block{Kind: structure.Heading, Level: 1, Text: c.Title}
+		blocks = append([]structure.Block{title}, blocks...)
+	}
+
+	if markdown {
+		return filterMarkdown(blocksMarkdown(blocks)), nil
+	}
+	return FilterNonReadableCharacter(blocksText(blocks)), nil
+}
+
+// epubMetadata returns the metadata of the Dublin Core elements of
+// the book. The creators and the contributors are separated with
+// semicolons, the subjects are the keywords and the dates are stored
+// as unix timestamps under CreatedDate and ModifiedDate.
+func epubMetadata(book *epub.Book, chapters int) map[string]string {
+	metadata := make(map[string]string, len(book.Metadata)+4)
+	for key, values := range book.Metadata {
+		switch key {
+		case "creator", "contributor":
+			metadata[key] = strings.Join(values, "; ")
+		case "subject":
+			metadata[key] = strings.Join(values, ", ")
+			metadata["keywords"] = metadata[key]
+		default:
+			metadata[key] = values[0]
+		}
+	}
+	if book.Identifier != "" {
+		metadata["identifier"] = book.Identifier
+	}
+	metadata["chapters"] = strconv.Itoa(chapters)
+
+	// Convert dates to unix timestamps
+	for key, field := range map[string]string{
+		"CreatedDate":  "date",
+		"ModifiedDate": "modified",
+	} {
+		if t, ok := parseTime(metadata[field], epubDateLayouts...); ok {
+			metadata[key] = fmt.Sprintf("%d", t.Unix())
+		}
+	}
+
+	return metadata
+}
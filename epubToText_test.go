@@ -0,0 +1,107 @@
+package totext
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pilinux/totext/internal/fixture"
+)
+
+// epubXHTML returns an XHTML document with the body
+func epubXHTML(body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?><html xmlns="http://www.w3.org/1999/xhtml">` +
+		`<head><title>Book</title></head><body>` + body + `</body></html>`
+}
+
+// epubEntries are the entries of an EPUB with a cover, two chapters
+// in reverse order of their names and a navigation document
+var epubEntries = map[string]string{
+	"mimetype": string(MimeEPUB),
+	"META-INF/container.xml": `<container xmlns="urn:oasis:names:tc:opendocument:xmlns:container"><rootfiles>` +
+		`<rootfile full-path="OPS/package.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`,
+	"OPS/package.opf": `<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id">` +
+		`<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">` +
+		`<dc:identifier id="id">urn:isbn:9780000000001</dc:identifier><dc:title>A Tale</dc:title>` +
+		`<dc:creator>Jane Doe</dc:creator><dc:creator>John Roe</dc:creator><dc:language>fr</dc:language>` +
+		`<dc:publisher>Press</dc:publisher><dc:date>2020-05-01</dc:date>` +
+		`<dc:subject>Fiction</dc:subject><dc:subject>Sea</dc:subject>` +
+		`<meta property="dcterms:modified">2021-01-02T03:04:05Z</meta></metadata>` +
+		`<manifest><item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>` +
+		`<item id="cover" href="cover.xhtml" media-type="application/xhtml+xml"/>` +
+		`<item id="a" href="a.xhtml" media-type="application/xhtml+xml"/>` +
+		`<item id="b" href="b.xhtml" media-type="application/xhtml+xml"/></manifest>` +
+		`<spine><itemref idref="cover"/><itemref idref="b"/><itemref idref="a"/></spine></package>`,
+	"OPS/nav.xhtml": epubXHTML(`<nav epub:type="toc"><ol><li><a href="cover.xhtml">Cover</a></li>` +
+		`<li><a href="b.xhtml">The Harbour</a></li><li><a href="a.xhtml">The Storm</a></li></ol></nav>`),
+	"OPS/cover.xhtml": epubXHTML(`<img src="cover.jpg" alt="Cover"/>`),
+	"OPS/b.xhtml":     epubXHTML(`<p>The ship <em>left</em> at dawn.</p><ul><li>Sails</li></ul>`),
+	"OPS/a.xhtml":     epubXHTML(`<h2>Chapter Two</h2><p>Waves rose.</p>`),
+}
+
+// TestConvertEPUBReaderToText tests the text content and the metadata of an EPUB
+func TestConvertEPUBReaderToText(t *testing.T) {
+	data := fixture.Zip(t, epubEntries)
+
+	// Test data
+	testData := []struct {
+		format   TextFormat
+		expected string
+	}{
+		{TextFormatPlain, "# The Harbour\nThe ship left at dawn.\nSails\n## Chapter Two\nWaves rose.\n"},
+		{TextFormatMarkdown, "# The Harbour\n\nThe ship *left* at dawn.\n\n- Sails\n\n## Chapter Two\n\nWaves rose.\n"},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		content, _, err := ConvertReader(bytes.NewReader(data), EPUB, WithTextFormat(td.format))
+		if err != nil {
+			t.Fatalf("Error converting EPUB: %s", err)
+		}
+		if content != td.expected {
+			t.Errorf("Expected %s content %q, got %q", td.format, td.expected, content)
+		}
+	}
+
+	// Compare metadata
+	_, metadata, err := ConvertEPUBReaderToText(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Error converting EPUB: %s", err)
+	}
+	m := NewMetadata(metadata, nil)
+	if m.Title != "A Tale" || m.Language != "fr" || metadata["publisher"] != "Press" ||
+		metadata["identifier"] != "urn:isbn:9780000000001" || metadata["chapters"] != "2" {
+		t.Errorf("Unexpected metadata %v", metadata)
+	}
+	if strings.Join(m.Authors, "|") != "Jane Doe|John Roe" || strings.Join(m.Keywords, "|") != "Fiction|Sea" {
+		t.Errorf("Unexpected authors %q and keywords %q", m.Authors, m.Keywords)
+	}
+	if m.Created.Format("2006-01-02") != "2020-05-01" || m.Modified.Format("2006-01-02T15") != "2021-01-02T03" {
+		t.Errorf("Unexpected dates %s and %s", m.Created, m.Modified)
+	}
+}
+
+// TestConvertEPUBReaderToChapters tests the chapters of an EPUB
+func TestConvertEPUBReaderToChapters(t *testing.T) {
+	chapters, _, err := ConvertEPUBReaderToChapters(bytes.NewReader(fixture.Zip(t, epubEntries)))
+	if err != nil {
+		t.Fatalf("Error converting EPUB: %s", err)
+	}
+
+	// Test data
+	testData := []Chapter{
+		{1, "The Harbour", "OPS/b.xhtml", "# The Harbour\nThe ship left at dawn.\nSails\n"},
+		{2, "The Storm", "OPS/a.xhtml", "## Chapter Two\nWaves rose.\n"},
+	}
+
+	if len(chapters) != len(testData) {
+		t.Fatalf("Expected %d chapters, got %d", len(testData), len(chapters))
+	}
+
+	// Iterate over test data
+	for i, expected := range testData {
+		if chapters[i] != expected {
+			t.Errorf("Expected chapter %+v, got %+v", expected, chapters[i])
+		}
+	}
+}
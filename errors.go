@@ -0,0 +1,115 @@
+package totext
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Errors returned by the converters, they can be checked with errors.Is
+var (
+	// ErrUnsupportedFormat is returned when no converter is
+	// registered for the format
+	ErrUnsupportedFormat = errors.New("file type not supported")
+
+	// ErrEncrypted is returned when the document is protected by a password
+	ErrEncrypted = errors.New("document is encrypted")
+
+	// ErrCorrupt is returned when the document can not be parsed
+	ErrCorrupt = errors.New("document is corrupt")
+
+	// ErrTimeout is returned when a conversion exceeds the deadline of its context
+	ErrTimeout = errors.New("conversion timed out")
+
+	// ErrInvalidURL is returned when the URL can not be fetched
+	ErrInvalidURL = errors.New("invalid URL")
+)
+
+// ErrMissingDependency is returned when an external tool
+// required by the converter is not installed
+type ErrMissingDependency struct {
+	// Tool is the name of the missing tool
+	Tool string
+}
+
+// Error implements the error interface
+func (e ErrMissingDependency) Error() string {
+	return fmt.Sprintf("missing dependency: %s is not installed", e.Tool)
+}
+
+// Is reports whether target is an ErrMissingDependency for the same tool.
+// An ErrMissingDependency without a tool matches every missing tool.
+func (e ErrMissingDependency) Is(target error) bool {
+	t, ok := target.(ErrMissingDependency)
+	return ok && (t.Tool == "" || t.Tool == e.Tool)
+}
+
+// ErrHTTPStatus is returned when a web server responds
+// with an error status code
+type ErrHTTPStatus struct {
+	// Code is the HTTP status code
+	Code int
+}
+
+// Error implements the error interface
+func (e ErrHTTPStatus) Error() string {
+	return fmt.Sprintf("HTTP status code: %d", e.Code)
+}
+
+// Is reports whether target is an ErrHTTPStatus with the same code.
+// An ErrHTTPStatus without a code matches every status code.
+func (e ErrHTTPStatus) Is(target error) bool {
+	t, ok := target.(ErrHTTPStatus)
+	return ok && (t.Code == 0 || t.Code == e.Code)
+}
+
+// contextError returns the error of a done context. A deadline is
+// reported as ErrTimeout, which still matches context.DeadlineExceeded.
+func contextError(ctx context.Context) error {
+	err := ctx.Err()
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+
+	return err
+}
+
+// commandError classifies the error of a failed external tool
+func commandError(tool string, err error, stderr string) error {
+	// The tool is not installed or the shell did not find it
+	var exitErr *exec.ExitError
+	if errors.Is(err, exec.ErrNotFound) ||
+		(errors.As(err, &exitErr) && exitErr.ExitCode() == 127) {
+		return ErrMissingDependency{Tool: tool}
+	}
+
+	stderr = strings.TrimSpace(stderr)
+	if stderr == "" {
+		return fmt.Errorf("%s: %w", tool, err)
+	}
+
+	// The tool asked for a password
+	lower := strings.ToLower(stderr)
+	if strings.Contains(lower, "password") || strings.Contains(lower, "encrypt") {
+		return fmt.Errorf("%s: %w: %s", tool, ErrEncrypted, stderr)
+	}
+
+	return fmt.Errorf("%s: %w: %s", tool, err, stderr)
+}
+
+// corruptError marks a conversion error as ErrCorrupt
+// unless it is already classified
+func corruptError(err error) error {
+	if err == nil ||
+		errors.Is(err, ErrEncrypted) ||
+		errors.Is(err, ErrCorrupt) ||
+		errors.Is(err, ErrMissingDependency{}) ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	return fmt.Errorf("%w: %w", ErrCorrupt, err)
+}
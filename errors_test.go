@@ -0,0 +1,67 @@
+package totext
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+)
+
+// TestErrorsIs tests the errors.Is support of the typed errors
+func TestErrorsIs(t *testing.T) {
+	// Test data
+	testData := []struct {
+		err      error
+		target   error
+		expected bool
+	}{
+		{ErrMissingDependency{Tool: "pdftotext"}, ErrMissingDependency{}, true},
+		{ErrMissingDependency{Tool: "pdftotext"}, ErrMissingDependency{Tool: "pdftotext"}, true},
+		{ErrMissingDependency{Tool: "pdftotext"}, ErrMissingDependency{Tool: "unrtf"}, false},
+		{fmt.Errorf("wrapped: %w", ErrHTTPStatus{Code: 404}), ErrHTTPStatus{}, true},
+		{ErrHTTPStatus{Code: 404}, ErrHTTPStatus{Code: 500}, false},
+		{corruptError(errors.New("zip: not a valid zip file")), ErrCorrupt, true},
+		{corruptError(ErrEncrypted), ErrCorrupt, false},
+		{corruptError(context.Canceled), context.Canceled, true},
+	}
+
+	// Iterate over test data
+	for _, data := range testData {
+		if errors.Is(data.err, data.target) != data.expected {
+			t.Errorf("Expected errors.Is(%v, %v) to be %t", data.err, data.target, data.expected)
+		}
+	}
+}
+
+// TestCommandError tests commandError function
+func TestCommandError(t *testing.T) {
+	// A tool which is not installed
+	_, err := exec.Command("totext-missing-tool").Output()
+	err = commandError("totext-missing-tool", err, "")
+
+	var missing ErrMissingDependency
+	if !errors.As(err, &missing) || missing.Tool != "totext-missing-tool" {
+		t.Errorf("Expected ErrMissingDependency, got %v", err)
+	}
+
+	// A tool which asks for a password
+	err = commandError("pdfinfo", errors.New("exit status 1"), "Command Line Error: Incorrect password")
+	if !errors.Is(err, ErrEncrypted) {
+		t.Errorf("Expected ErrEncrypted, got %v", err)
+	}
+
+	// Any other failure
+	err = commandError("pdfinfo", errors.New("exit status 1"), "Syntax Error: Couldn't find trailer dictionary")
+	if errors.Is(err, ErrEncrypted) || errors.Is(err, ErrMissingDependency{}) {
+		t.Errorf("Expected unclassified error, got %v", err)
+	}
+}
+
+// TestConvertReaderUnsupported tests ConvertReader function with an unknown format
+func TestConvertReaderUnsupported(t *testing.T) {
+	_, _, err := ConvertReader(nil, "unknown")
+	if !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Expected ErrUnsupportedFormat, got %v", err)
+	}
+}
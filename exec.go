@@ -0,0 +1,108 @@
+package totext
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// waitDelay is the time given to a killed command to release its pipes
+const waitDelay = 5 * time.Second
+
+// newCommand returns a command bound to ctx. When ctx is done,
+// the command is killed together with its child processes.
+func newCommand(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = waitDelay
+	setProcessGroup(cmd)
+
+	return cmd
+}
+
+// runCommand runs the command bound to ctx and returns its standard output.
+// tool is the name of the external tool reported in the errors.
+func runCommand(ctx context.Context, tool string, stdin io.Reader, name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := newCommand(ctx, name, args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		// Report the context error instead of the kill signal
+		if ctx.Err() != nil {
+			return nil, contextError(ctx)
+		}
+		return nil, commandError(tool, err, stderr.String())
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// streamCommand runs the command bound to ctx and writes its standard
+// output to w while it is running.
+// tool is the name of the external tool reported in the errors.
+func streamCommand(ctx context.Context, tool string, stdin io.Reader, w io.Writer, name string, args ...string) error {
+	var stderr bytes.Buffer
+
+	cmd := newCommand(ctx, name, args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		// Report the context error instead of the kill signal
+		if ctx.Err() != nil {
+			return contextError(ctx)
+		}
+		return commandError(tool, err, stderr.String())
+	}
+
+	return nil
+}
+
+// localFile returns the path of a file which contains the data of r.
+// If r is an *os.File, its path is used, otherwise the data is copied
+// into a temporary file. done must be called to clean up the resources.
+func localFile(r io.Reader) (path string, done func(), err error) {
+	if f, ok := r.(*os.File); ok {
+		return f.Name(), func() {}, nil
+	}
+
+	f, err := os.CreateTemp("", "totext-")
+	if err != nil {
+		return "", nil, err
+	}
+	done = func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}
+
+	if _, err = io.Copy(f, r); err != nil {
+		done()
+		return "", nil, err
+	}
+
+	return f.Name(), done, nil
+}
+
+// sleepContext pauses the current goroutine for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return contextError(ctx)
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,51 @@
+package totext
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRunCommandTimeout tests runCommand function with a deadline
+func TestRunCommandTimeout(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep is not available")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := runCommand(ctx, "sleep", nil, "sleep", "10")
+
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("Expected ErrTimeout, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the command to be killed, took %s", elapsed)
+	}
+}
+
+// TestConvertReaderContextCancelled tests ConvertReaderContext function
+// with a cancelled context
+func TestConvertReaderContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Test data
+	testData := []FileExtension{DOC, DOCX, FODT, ODT, OTT, PAGES, PDF, RTF}
+
+	// Iterate over test data
+	for _, format := range testData {
+		_, _, err := ConvertReaderContext(ctx, strings.NewReader(""), format)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled for %s, got %v", format, err)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package totext
+
+import "os/exec"
+
+// setProcessGroup is a no-op on Windows, the command is killed
+// by exec.CommandContext when its context is done
+func setProcessGroup(cmd *exec.Cmd) {}
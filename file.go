@@ -15,28 +15,42 @@ type MIME string
 const (
 	DOC   FileExtension = "doc"
 	DOCX  FileExtension = "docx"
+	EPUB  FileExtension = "epub"
+	FODT  FileExtension = "fodt"
 	HTML  FileExtension = "html"
 	JSON  FileExtension = "json"
 	MD    FileExtension = "md"
+	ODP   FileExtension = "odp"
+	ODS   FileExtension = "ods"
 	ODT   FileExtension = "odt"
+	OTT   FileExtension = "ott"
 	PAGES FileExtension = "pages"
 	PDF   FileExtension = "pdf"
+	PPTX  FileExtension = "pptx"
 	RTF   FileExtension = "rtf"
 	TXT   FileExtension = "txt"
+	XLSX  FileExtension = "xlsx"
 )
 
 // MIME types
 const (
 	MimeDOC   MIME = "application/msword"
 	MimeDOCX  MIME = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	MimeEPUB  MIME = "application/epub+zip"
+	MimeFODT  MIME = "application/vnd.oasis.opendocument.text-flat-xml"
 	MimeHTML  MIME = "text/html"
 	MimeJSON  MIME = "application/json"
 	MimeMD    MIME = "text/markdown"
+	MimeODP   MIME = "application/vnd.oasis.opendocument.presentation"
+	MimeODS   MIME = "application/vnd.oasis.opendocument.spreadsheet"
 	MimeODT   MIME = "application/vnd.oasis.opendocument.text"
+	MimeOTT   MIME = "application/vnd.oasis.opendocument.text-template"
 	MimePAGES MIME = "application/vnd.apple.pages"
 	MimePDF   MIME = "application/pdf"
+	MimePPTX  MIME = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
 	MimeRTF   MIME = "application/rtf"
 	MimeTXT   MIME = "text/plain"
+	MimeXLSX  MIME = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
 )
 
 // GetFileExtension returns the file extension of a file
@@ -51,55 +65,77 @@ func GetFileExtension(filepath string) FileExtension {
 		return DOC
 	case string(DOCX):
 		return DOCX
+	case string(EPUB):
+		return EPUB
+	case string(FODT):
+		return FODT
 	case string(HTML):
 		return HTML
 	case string(JSON):
 		return JSON
 	case string(MD):
 		return MD
+	case string(ODP):
+		return ODP
+	case string(ODS):
+		return ODS
 	case string(ODT):
 		return ODT
+	case string(OTT):
+		return OTT
 	case string(PAGES):
 		return PAGES
 	case string(PDF):
 		return PDF
+	case string(PPTX):
+		return PPTX
 	case string(RTF):
 		return RTF
 	case string(TXT):
 		return TXT
+	case string(XLSX):
+		return XLSX
 
 	default:
+		// Check for formats registered by the user
+		if isRegistered(FileExtension(fileExt)) {
+			return FileExtension(fileExt)
+		}
 		return ""
 	}
 }
 
 // IsMIMETypeMatched compares *multipart.FileHeader MIME type with file extension
-func IsMIMETypeMatched(fileExt FileExtension, mime MIME) bool {
-	switch fileExt {
-	case DOC:
-		return mime == MimeDOC
-	case DOCX:
-		return mime == MimeDOCX
-	case HTML:
-		return mime == MimeHTML
-	case JSON:
-		return mime == MimeJSON
-	case MD:
-		return mime == MimeMD
-	case ODT:
-		return mime == MimeODT
-	case PAGES:
-		return mime == MimePAGES
-	case PDF:
-		return mime == MimePDF
-	case RTF:
-		return mime == MimeRTF
-	case TXT:
-		return mime == MimeTXT
+//
+// The parameters of the MIME type are ignored and the known aliases
+// of the format are accepted, e.g. "text/rtf" for RTF and "text/plain"
+// for MD and JSON. When the MIME type is generic, e.g.
+// application/octet-stream, the format is detected from the content
+// given by WithContentSniffing.
+func IsMIMETypeMatched(fileExt FileExtension, mime MIME, opts ...MatchOption) bool {
+	o := &matchOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
 
-	default:
+	if fileExt == "" {
 		return false
 	}
+
+	// Compare the MIME type without its parameters
+	if format := FormatForMIME(mime); format != "" {
+		return format == fileExt || format == TXT && plainTextFormats[fileExt]
+	}
+
+	// Detect the format from the content
+	if IsGenericMIME(mime) && o.content != nil {
+		detected, _, err := DetectFormat(o.content)
+		return err == nil && detected == fileExt
+	}
+
+	return false
 }
 
 // GetFilename returns the filename of a file
@@ -134,13 +170,20 @@ func WriteText(filepath string, content string) (err error) {
 	return nil
 }
 
-// ReadText reads text content from a text file
-func ReadText(filepath string) (string, error) {
+// ReadText reads text content from a text file, the content is
+// transcoded to UTF-8 from its charset given with WithCharset or
+// else detected from its byte order mark and its bytes
+func ReadText(filepath string, opts ...Option) (string, error) {
 	// Read the entire file into a string
 	content, err := os.ReadFile(filepath)
 	if err != nil {
 		return "", err
 	}
+
+	content, _, err = decodeCharset(content, false, newOptions(opts...))
+	if err != nil {
+		return "", err
+	}
 	return string(content), nil
 }
 
@@ -1,6 +1,10 @@
 package totext
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
 
 // TestGetFileExtension tests GetFileExtension function
 func TestGetFileExtension(t *testing.T) {
@@ -17,10 +21,16 @@ func TestGetFileExtension(t *testing.T) {
 
 		{"test.docx", DOCX},
 		{"test.odt", ODT},
+		{"test.fodt", FODT},
+		{"test.OTT", OTT},
 		{"test.txt", TXT},
 		{"test.md", MD},
 		{"test.rtf", RTF},
 		{"test.json", JSON},
+		{"test.xlsx", XLSX},
+		{"test.ods", ODS},
+		{"test.pptx", PPTX},
+		{"test.ODP", ODP},
 
 		{"test", ""},
 	}
@@ -51,6 +61,9 @@ func TestIsMIMETypeMatched(t *testing.T) {
 		{JSON, MimeJSON, true},
 		{MD, MimeMD, true},
 		{ODT, MimeODT, true},
+		{FODT, MimeFODT, true},
+		{OTT, MimeOTT, true},
+		{ODT, MimeOTT, false},
 		{PAGES, MimePAGES, true},
 		{PDF, MimePDF, true},
 		{RTF, MimeRTF, true},
@@ -152,6 +165,27 @@ func TestReadText(t *testing.T) {
 			t.Errorf("Expected content %s, got %s", data.expected, content)
 		}
 	}
+
+	// Text in other charsets is transcoded to UTF-8
+	encoded := []struct {
+		content  []byte
+		opts     []Option
+		expected string
+	}{
+		{[]byte("caf\xe9 cr\xe8me br\xfbl\xe9e"), nil, "café crème brûlée"},
+		{[]byte("\xff\xfeH\x00\xe9\x00"), nil, "Hé"},
+		{[]byte("\x93\xfa\x96\x7b"), []Option{WithCharset("Shift_JIS")}, "日本"},
+	}
+	for i, data := range encoded {
+		name := filepath.Join(t.TempDir(), "encoded.txt")
+		if err := os.WriteFile(name, data.content, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		content, err := ReadText(name, data.opts...)
+		if err != nil || content != data.expected {
+			t.Errorf("%d: expected %q, got %q, %v", i, data.expected, content, err)
+		}
+	}
 }
 
 // TestDeleteFile tests DeleteFile function
@@ -1,22 +1,129 @@
 package totext
 
-import "unicode"
+import (
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
 
 // FilterNonReadableCharacter - filter out non-readable characters
 func FilterNonReadableCharacter(input string) string {
-	var cleanedContent []rune
-	var lastChar rune
+	return filterString(input, filter{})
+}
+
+// filterMarkdown filters out non-readable characters like
+// FilterNonReadableCharacter but keeps the blank lines which
+// separate the blocks of Markdown
+func filterMarkdown(input string) string {
+	return filterString(input, filter{blankLines: true})
+}
+
+// filterString returns the characters of input kept by f
+func filterString(input string, f filter) string {
+	var cleanedContent strings.Builder
+	cleanedContent.Grow(len(input))
 
 	for _, char := range input {
-		if unicode.IsPrint(char) || char == '\n' {
-			if char == '\n' && lastChar == '\n' {
-				// skip consecutive newlines
-				continue
-			}
-			cleanedContent = append(cleanedContent, char)
-			lastChar = char
+		if f.keep(char) {
+			cleanedContent.WriteRune(char)
 		}
 	}
 
-	return string(cleanedContent)
+	return cleanedContent.String()
+}
+
+// filter holds the state of the non-readable character filter
+type filter struct {
+	// newlines is the number of consecutive newlines
+	newlines int
+	// blankLines keeps single blank lines
+	blankLines bool
+}
+
+// keep reports whether char is kept in the filtered text
+func (f *filter) keep(char rune) bool {
+	if !unicode.IsPrint(char) && char != '\n' {
+		return false
+	}
+	if char != '\n' {
+		f.newlines = 0
+		return true
+	}
+	f.newlines++
+	// skip consecutive newlines
+	return f.newlines == 1 || f.blankLines && f.newlines == 2
+}
+
+// FilterWriter filters out non-readable characters like
+// FilterNonReadableCharacter while the text is written to it
+type FilterWriter struct {
+	w       io.Writer
+	f       filter
+	pending []byte
+	buf     []byte
+}
+
+// NewFilterWriter returns a FilterWriter which writes the
+// filtered text to w
+func NewFilterWriter(w io.Writer) *FilterWriter {
+	return &FilterWriter{w: w}
+}
+
+// Write filters p and writes the result to the underlying writer.
+// A rune split between two writes is kept until the next write.
+func (fw *FilterWriter) Write(p []byte) (int, error) {
+	n := len(p)
+
+	data := p
+	if len(fw.pending) > 0 {
+		data = append(fw.pending, p...)
+		fw.pending = nil
+	}
+
+	fw.buf = fw.buf[:0]
+	for len(data) > 0 {
+		char, size := utf8.DecodeRune(data)
+		if char == utf8.RuneError && size == 1 && !utf8.FullRune(data) {
+			// Wait for the rest of the rune
+			fw.pending = append([]byte(nil), data...)
+			break
+		}
+		if fw.f.keep(char) {
+			fw.buf = utf8.AppendRune(fw.buf, char)
+		}
+		data = data[size:]
+	}
+
+	if len(fw.buf) > 0 {
+		if _, err := fw.w.Write(fw.buf); err != nil {
+			return 0, err
+		}
+	}
+
+	return n, nil
+}
+
+// Close writes the incomplete rune left by the last write.
+// It does not close the underlying writer.
+func (fw *FilterWriter) Close() error {
+	if len(fw.pending) == 0 {
+		return nil
+	}
+
+	pending := string(fw.pending)
+	fw.pending = nil
+
+	fw.buf = fw.buf[:0]
+	for _, char := range pending {
+		if fw.f.keep(char) {
+			fw.buf = utf8.AppendRune(fw.buf, char)
+		}
+	}
+	if len(fw.buf) > 0 {
+		_, err := fw.w.Write(fw.buf)
+		return err
+	}
+
+	return nil
 }
@@ -1,6 +1,7 @@
 package totext
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -30,3 +31,28 @@ func TestFilterNonReadableCharacter(t *testing.T) {
 		}
 	}
 }
+
+// TestFilterWriter tests that FilterWriter filters like
+// FilterNonReadableCharacter when the text is written in chunks
+func TestFilterWriter(t *testing.T) {
+	input := "Héllo\x00 wörld\n\n\n\tæ€😀\r\nend\xff"
+	expected := FilterNonReadableCharacter(input)
+
+	// Split the input at every size, including inside runes
+	for size := 1; size <= len(input); size++ {
+		var sb strings.Builder
+		fw := NewFilterWriter(&sb)
+		for i := 0; i < len(input); i += size {
+			chunk := input[i:min(i+size, len(input))]
+			if n, err := fw.Write([]byte(chunk)); err != nil || n != len(chunk) {
+				t.Fatalf("Write(%q): %d, %v", chunk, n, err)
+			}
+		}
+		if err := fw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if sb.String() != expected {
+			t.Errorf("chunk size %d: expected %q, got %q", size, expected, sb.String())
+		}
+	}
+}
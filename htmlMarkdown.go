@@ -0,0 +1,309 @@
+package totext
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/pilinux/totext/internal/structure"
+)
+
+// htmlSkipped are the elements whose content is not part of the text
+var htmlSkipped = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Style:    true,
+	atom.Script:   true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Footer:   true,
+	atom.Svg:      true,
+	atom.Iframe:   true,
+	atom.Select:   true,
+	atom.Button:   true,
+}
+
+// htmlBlockElements are the elements which end the current paragraph
+var htmlBlockElements = map[atom.Atom]bool{
+	atom.P:          true,
+	atom.Div:        true,
+	atom.Section:    true,
+	atom.Article:    true,
+	atom.Header:     true,
+	atom.Main:       true,
+	atom.Nav:        true,
+	atom.Aside:      true,
+	atom.Blockquote: true,
+	atom.Figure:     true,
+	atom.Figcaption: true,
+	atom.Dl:         true,
+	atom.Dt:         true,
+	atom.Dd:         true,
+	atom.Address:    true,
+	atom.Details:    true,
+	atom.Summary:    true,
+	atom.Form:       true,
+	atom.Fieldset:   true,
+	atom.Center:     true,
+	atom.Hr:         true,
+	atom.Caption:    true,
+}
+
+// htmlHeadings maps the heading elements to their levels
+var htmlHeadings = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+// htmlList is an open list of an HTML document
+type htmlList struct {
+	ordered bool
+	// next is the number of the next item of an ordered list
+	next int
+}
+
+// htmlReader reads the blocks of an HTML document
+type htmlReader struct {
+	blocks []structure.Block
+	// text is the text of the current paragraph, block
+	// holds its kind, level and label
+	text  structure.Builder
+	block structure.Block
+	// space reports whether the text ends with white space,
+	// following white space is collapsed
+	space bool
+	lists []htmlList
+	// quote is the depth of the open block quotes
+	quote int
+}
+
+// htmlBlocks returns the headings, paragraphs, lists, tables and
+// preformatted text of the HTML document with the format of the text
+// and the hyperlinks
+func htmlBlocks(n *html.Node) []structure.Block {
+	r := &htmlReader{space: true}
+	r.children(n)
+	r.flush()
+	return r.blocks
+}
+
+// children reads the child nodes of n
+func (r *htmlReader) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.node(c)
+	}
+}
+
+// node reads the node n and its children
+func (r *htmlReader) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		r.write(n.Data)
+		return
+	case html.ElementNode:
+	case html.DocumentNode:
+		r.children(n)
+		return
+	default:
+		return
+	}
+	if htmlSkipped[n.DataAtom] {
+		return
+	}
+
+	if level, ok := htmlHeadings[n.DataAtom]; ok {
+		r.flush()
+		r.block = structure.Block{Kind: structure.Heading, Level: level}
+		r.children(n)
+		r.flush()
+		return
+	}
+	if htmlBlockElements[n.DataAtom] {
+		r.flush()
+		if n.DataAtom == atom.Blockquote {
+			r.quote++
+			defer func() { r.quote-- }()
+		}
+		r.children(n)
+		r.flush()
+		return
+	}
+
+	format := r.text.Format
+	defer func() {
+		r.text.Format = format
+	}()
+
+	switch n.DataAtom {
+	case atom.Br:
+		r.text.Format = structure.Format{}
+		_, _ = r.text.WriteString("\n")
+		r.space = true
+	case atom.Ul, atom.Ol, atom.Menu:
+		r.flush()
+		list := htmlList{ordered: n.DataAtom == atom.Ol, next: 1}
+		if start, err := strconv.Atoi(htmlAttr(n, "start")); err == nil {
+			list.next = start
+		}
+		r.lists = append(r.lists, list)
+		r.children(n)
+		r.lists = r.lists[:len(r.lists)-1]
+		r.flush()
+	case atom.Li:
+		r.flush()
+		r.block = structure.Block{Kind: structure.ListItem}
+		if depth := len(r.lists); depth > 0 {
+			list := &r.lists[depth-1]
+			r.block.Level = depth - 1
+			if list.ordered {
+				if value, err := strconv.Atoi(htmlAttr(n, "value")); err == nil {
+					list.next = value
+				}
+				r.block.Label = strconv.Itoa(list.next) + "."
+				list.next++
+			}
+		}
+		r.children(n)
+		r.flush()
+	case atom.Pre:
+		r.flush()
+		code := strings.TrimPrefix(htmlText(n), "\n")
+		if strings.TrimSpace(code) != "" {
+			r.blocks = append(r.blocks, structure.Block{
+				Kind: structure.Code, Label: htmlLanguage(n), Text: strings.TrimRight(code, "\n"), Quote: r.quote,
+			})
+		}
+	case atom.Table:
+		r.flush()
+		if table := htmlTable(n); len(table.Rows) > 0 {
+			table.Quote = r.quote
+			r.blocks = append(r.blocks, table)
+		}
+	case atom.Strong, atom.B:
+		r.text.Format.Bold = true
+		r.children(n)
+	case atom.Em, atom.I, atom.Cite, atom.Dfn:
+		r.text.Format.Italic = true
+		r.children(n)
+	case atom.S, atom.Strike, atom.Del:
+		r.text.Format.Strike = true
+		r.children(n)
+	case atom.Code, atom.Kbd, atom.Samp, atom.Tt, atom.Var:
+		r.text.Format.Code = true
+		r.children(n)
+	case atom.A:
+		if href := strings.TrimSpace(htmlAttr(n, "href")); href != "" && !strings.HasPrefix(strings.ToLower(href), "javascript:") {
+			r.text.Format.Link = href
+		}
+		r.children(n)
+	default:
+		r.children(n)
+	}
+}
+
+// write writes the text of a text node, the white space is collapsed
+func (r *htmlReader) write(text string) {
+	for _, c := range text {
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' {
+			if !r.space {
+				_, _ = r.text.WriteString(" ")
+				r.space = true
+			}
+			continue
+		}
+		_, _ = r.text.WriteRune(c)
+		r.space = false
+	}
+}
+
+// flush ends the current paragraph
+func (r *htmlReader) flush() {
+	b := r.block
+	b.Text, b.Spans = r.text.String(), r.text.Spans()
+	b.Quote = r.quote
+	if strings.TrimSpace(b.Text) != "" {
+		r.blocks = append(r.blocks, b)
+	}
+
+	r.text.Reset()
+	r.block = structure.Block{}
+	r.space = true
+}
+
+// htmlTable returns the table block of the table element n,
+// the rows of nested tables are not part of it
+func htmlTable(n *html.Node) structure.Block {
+	table := structure.Block{Kind: structure.Table}
+
+	var rows func(n *html.Node)
+	rows = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch c.DataAtom {
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				rows(c)
+			case atom.Tr:
+				var row []structure.Cell
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.DataAtom == atom.Td || cell.DataAtom == atom.Th {
+						row = append(row, htmlBlocks(cell))
+					}
+				}
+				if len(row) > 0 {
+					table.Rows = append(table.Rows, row)
+				}
+			}
+		}
+	}
+	rows(n)
+
+	return table
+}
+
+// htmlText returns the text of the node and its children as is
+func htmlText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+
+	var text strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.DataAtom == atom.Br {
+			text.WriteString("\n")
+			continue
+		}
+		text.WriteString(htmlText(c))
+	}
+	return text.String()
+}
+
+// htmlLanguage returns the language of the preformatted element n
+// from the language- or lang- class of n or of its code element
+func htmlLanguage(n *html.Node) string {
+	nodes := []*html.Node{n}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.DataAtom == atom.Code {
+			nodes = append(nodes, c)
+		}
+	}
+
+	for _, node := range nodes {
+		for _, class := range strings.Fields(htmlAttr(node, "class")) {
+			for _, prefix := range []string{"language-", "lang-"} {
+				if lang, ok := strings.CutPrefix(class, prefix); ok && lang != "" {
+					return lang
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// htmlAttr returns the value of the attribute of the element n
+func htmlAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -3,22 +3,59 @@ package totext
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"regexp"
 	"runtime"
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
 	"golang.org/x/net/html"
+
+	"github.com/pilinux/totext/internal/charset"
 )
 
+func init() {
+	RegisterConverter(HTML, MimeHTML, ConverterFunc(ConvertHTMLReaderToTextContext))
+}
+
 // ConvertHTMLToText receives HTML filepath as an argument and returns its text content and metadata
-func ConvertHTMLToText(filepath string, skipPrettifyError bool) (content string, metadata map[string]string, err error) {
+//
+// The content is transcoded to UTF-8 from its charset, which is recorded
+// in the metadata, see WithCharset.
+//
+// WithTextFormat(TextFormatMarkdown) renders the headings, lists,
+// tables, links, emphasis and preformatted text in Markdown
+func ConvertHTMLToText(filepath string, skipPrettifyError bool, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertHTMLToTextContext(context.Background(), filepath, skipPrettifyError, opts...)
+}
+
+// ConvertHTMLToTextContext is like ConvertHTMLToText but kills
+// prettier when ctx is done
+func ConvertHTMLToTextContext(ctx context.Context, filepath string, skipPrettifyError bool, opts ...Option) (content string, metadata map[string]string, err error) {
+	htmlContent, err := os.ReadFile(filepath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Prettier reads UTF-8, the files in other charsets
+	// are prettified once they are transcoded
+	o := newOptions(opts...)
+	name := charset.Name(o.Charset)
+	if name == "" {
+		name = DetectCharset(htmlContent, true, o.ContentType)
+	}
+	if name != charset.UTF8 {
+		return ConvertHTMLReaderToTextContext(ctx, bytes.NewReader(htmlContent), append(opts, WithSkipPrettifyError(skipPrettifyError))...)
+	}
+
 	// Prettify the HTML file
-	err = PrettifyHTML(filepath)
+	err = PrettifyHTMLContext(ctx, filepath)
+	if ctx.Err() != nil {
+		return "", nil, contextError(ctx)
+	}
 	if !skipPrettifyError && err != nil {
 		return "", nil, err
 	}
@@ -34,18 +71,75 @@ func ConvertHTMLToText(filepath string, skipPrettifyError bool) (content string,
 		}
 	}()
 
+	// Convert HTML to text, the file is already prettified
+	return ConvertHTMLReaderToTextContext(ctx, htmlFile, append(opts, WithPrettify(false))...)
+}
+
+// ConvertHTMLBytesToText receives HTML content as a byte slice
+// and returns its text content and metadata
+func ConvertHTMLBytesToText(htmlContent []byte, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertHTMLReaderToText(bytes.NewReader(htmlContent), opts...)
+}
+
+// ConvertHTMLStringToText receives HTML content as a string
+// and returns its text content and metadata
+func ConvertHTMLStringToText(htmlContent string, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertHTMLReaderToText(strings.NewReader(htmlContent), opts...)
+}
+
+// ConvertHTMLReaderToText receives HTML content as an io.Reader
+// and returns its text content and metadata
+//
+// The content is prettified through the standard input of prettier
+// unless WithPrettify(false) is given
+func ConvertHTMLReaderToText(r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertHTMLReaderToTextContext(context.Background(), r, opts...)
+}
+
+// ConvertHTMLReaderToTextContext is like ConvertHTMLReaderToText but
+// kills prettier when ctx is done
+func ConvertHTMLReaderToTextContext(ctx context.Context, r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	o := newOptions(opts...)
+	markdown, err := o.markdown()
+	if err != nil {
+		return "", nil, err
+	}
+
 	// Copy the HTML content into a buffer
-	var htmlBuffer bytes.Buffer
-	_, err = io.Copy(&htmlBuffer, htmlFile)
+	htmlContent, err := io.ReadAll(r)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Transcode the HTML content to UTF-8
+	htmlContent, charsetName, err := decodeCharset(htmlContent, true, o)
 	if err != nil {
 		return "", nil, err
 	}
 
+	// Prettify the HTML content, prettier runs with npx
+	// and is skipped when Node.js is not installed
+	_, npx := lookPath("npx")
+	if o.Prettify && !npx {
+		o.warn("prettify: npx not found, skipped")
+	}
+	if o.Prettify && npx {
+		prettified, err := PrettifyHTMLContentContext(ctx, htmlContent)
+		if err != nil {
+			if !o.SkipPrettifyError || ctx.Err() != nil {
+				return "", nil, err
+			}
+			o.warn("prettify: %v", err)
+		} else {
+			htmlContent = prettified
+		}
+	}
+
 	// Initialize metadata map
-	metadata = make(map[string]string)
+	metadata = map[string]string{"charset": charsetName}
 
 	// Parse the HTML document
-	doc, err := goquery.NewDocumentFromReader(&htmlBuffer)
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(htmlContent))
 	if err != nil {
 		return "", nil, err
 	}
@@ -67,19 +161,32 @@ func ConvertHTMLToText(filepath string, skipPrettifyError bool) (content string,
 				metadata["description"] = content
 			}
 		}
+
+		// The language of the content-language pragma is the first one
+		httpEquiv, _ := s.Attr("http-equiv")
+		if strings.EqualFold(strings.TrimSpace(httpEquiv), "content-language") {
+			if language := strings.TrimSpace(strings.Split(content, ",")[0]); language != "" {
+				metadata["language"] = language
+			}
+		}
 	})
 
-	// Reset the file reader to the beginning of the file
-	_, err = htmlFile.Seek(0, io.SeekStart)
-	if err != nil {
-		return "", nil, err
+	// The lang attribute of the html element takes precedence
+	// over the content-language pragma
+	if lang := strings.TrimSpace(doc.Find("html").AttrOr("lang", "")); lang != "" {
+		metadata["language"] = lang
+	}
+
+	// Render the structure of the document in Markdown
+	if markdown {
+		return filterMarkdown(blocksMarkdown(htmlBlocks(doc.Nodes[0]))), metadata, nil
 	}
 
 	// Initialize a buffer to collect text content
 	var textBuffer bytes.Buffer
 
 	// Parse the HTML content
-	tokenizer := html.NewTokenizer(htmlFile)
+	tokenizer := html.NewTokenizer(bytes.NewReader(htmlContent))
 
 	// Keep track of whether we're inside a <style> or <script> or <footer> element
 	insideStyle := false
@@ -104,7 +211,7 @@ func ConvertHTMLToText(filepath string, skipPrettifyError bool) (content string,
 				return
 			}
 			// Error occurred while parsing the HTML
-			err = fmt.Errorf("error parsing HTML: %v", tokenizer.Err())
+			err = fmt.Errorf("%w: error parsing HTML: %w", ErrCorrupt, tokenizer.Err())
 			return
 
 		case html.StartTagToken, html.SelfClosingTagToken:
@@ -157,33 +264,63 @@ func ConvertHTMLToText(filepath string, skipPrettifyError bool) (content string,
 //
 // npm install --save-dev --save-exact prettier
 func PrettifyHTML(filepath string) (err error) {
+	return PrettifyHTMLContext(context.Background(), filepath)
+}
+
+// PrettifyHTMLContext is like PrettifyHTML but kills prettier when ctx is done
+func PrettifyHTMLContext(ctx context.Context, filepath string) (err error) {
 	// Check if the file exists
 	if _, err = os.Stat(filepath); os.IsNotExist(err) {
-		return fmt.Errorf("file does not exist")
+		return fmt.Errorf("file does not exist: %w", err)
 	}
 
-	var cmd *exec.Cmd
+	// Prettify the HTML file using prettier command
+	_, err = runCommand(ctx, "prettier", nil, shellCommand(), prettierArgs("--write "+filepath)...)
 
-	// run cmd based on OS
-	switch os := runtime.GOOS; os {
-	case "windows":
-		// Command to execute in PowerShell
-		command := "npx prettier --write " + filepath
+	return
+}
 
-		// Create a new PowerShell session
-		// and execute the command
-		cmd = exec.Command("powershell.exe", "-Command", command)
+// PrettifyHTMLContent prettifies the HTML content using the prettier
+// library without writing it to a file
+//
+// Dependencies:
+//
+// npm init
+//
+// npm install --save-dev --save-exact prettier
+func PrettifyHTMLContent(htmlContent []byte) ([]byte, error) {
+	return PrettifyHTMLContentContext(context.Background(), htmlContent)
+}
+
+// PrettifyHTMLContentContext is like PrettifyHTMLContent but kills
+// prettier when ctx is done
+func PrettifyHTMLContentContext(ctx context.Context, htmlContent []byte) ([]byte, error) {
+	// Pass the HTML content through the standard input of prettier
+	return runCommand(ctx, "prettier", bytes.NewReader(htmlContent), shellCommand(), prettierArgs("--parser html")...)
+}
 
-	default:
-		// Command to execute in Bash
-		command := "source $HOME/.bashrc && npx prettier --write " + filepath
-		cmd = exec.Command("/bin/bash", "-c", command)
+// shellCommand returns the shell based on OS
+func shellCommand() string {
+	if runtime.GOOS == "windows" {
+		// Create a new PowerShell session
+		return "powershell.exe"
 	}
+	return "/bin/bash"
+}
 
-	// Prettify the HTML file using prettier command
-	err = cmd.Run()
+// prettierArgs returns the shell arguments to run prettier with args
+func prettierArgs(args string) []string {
+	return shellArgs("npx prettier " + args)
+}
 
-	return
+// shellArgs returns the arguments to run command in the shell based on OS
+func shellArgs(command string) []string {
+	if runtime.GOOS == "windows" {
+		// Command to execute in PowerShell
+		return []string{"-Command", command}
+	}
+	// Command to execute in Bash
+	return []string{"-c", "source $HOME/.bashrc && " + command}
 }
 
 // CleanUpHTML cleans up the HTML content and extracts the text content
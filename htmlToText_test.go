@@ -0,0 +1,129 @@
+package totext
+
+import "testing"
+
+// TestConvertHTMLStringToText tests ConvertHTMLStringToText function
+func TestConvertHTMLStringToText(t *testing.T) {
+	htmlContent := `<html lang="en-GB">
+<head>
+<title>Test Page</title>
+<meta name="description" content="A test page">
+<meta http-equiv="Content-Language" content="fr">
+<style>body { color: red; }</style>
+<script>var x = 1;</script>
+</head>
+<body>
+<h1>Hello</h1>
+<p>World</p>
+<footer>Copyright</footer>
+</body>
+</html>`
+
+	content, metadata, err := ConvertHTMLStringToText(htmlContent, WithPrettify(false))
+	if err != nil {
+		t.Fatalf("Error converting HTML: %s", err)
+	}
+
+	// Compare content
+	expected := "Test Page\nHello\nWorld\n"
+	if content != expected {
+		t.Errorf("Expected content %q, got %q", expected, content)
+	}
+
+	// Compare metadata
+	if metadata["title"] != "Test Page" {
+		t.Errorf("Expected title %q, got %q", "Test Page", metadata["title"])
+	}
+	if metadata["description"] != "A test page" {
+		t.Errorf("Expected description %q, got %q", "A test page", metadata["description"])
+	}
+	if metadata["language"] != "en-GB" {
+		t.Errorf("Expected language %q, got %q", "en-GB", metadata["language"])
+	}
+
+	// The content-language pragma without the lang attribute
+	_, metadata, err = ConvertHTMLStringToText(`<html><head><meta http-equiv="content-language" content="de, en"></head></html>`,
+		WithPrettify(false))
+	if err != nil {
+		t.Fatalf("Error converting HTML: %s", err)
+	}
+	if metadata["language"] != "de" {
+		t.Errorf("Expected language %q, got %q", "de", metadata["language"])
+	}
+}
+
+// TestConvertHTMLCharset tests transcoding HTML content to UTF-8
+func TestConvertHTMLCharset(t *testing.T) {
+	// Test data
+	testData := []struct {
+		content  string
+		opts     []Option
+		expected string
+		charset  string
+	}{
+		{"<html><head><meta charset=\"Shift_JIS\"></head><body><p>\x93\xfa\x96\x7b</p></body></html>", nil, "日本\n", "shift_jis"},
+		{"<p>caf\xe9</p>", []Option{WithContentType("text/html; charset=ISO-8859-1")}, "café\n", "windows-1252"},
+		{"<p>\xc4\xe3\xba\xc3\xa3\xac\xca\xc0\xbd\xe7\xa1\xa3\xce\xd2\xc3\xc7\xb5\xc4\xb9\xfa\xbc\xd2\xa1\xa3</p>", nil, "你好，世界。我们的国家。\n", "gbk"},
+		{"<p>caf\xc3\xa9</p>", nil, "café\n", "utf-8"},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		content, metadata, err := ConvertHTMLStringToText(td.content, append(td.opts, WithPrettify(false))...)
+		if err != nil {
+			t.Errorf("Error converting HTML %q: %s", td.content, err)
+			continue
+		}
+		if content != td.expected || metadata["charset"] != td.charset {
+			t.Errorf("Expected %q in %s, got %q in %s", td.expected, td.charset, content, metadata["charset"])
+		}
+	}
+
+	if _, _, err := ConvertHTMLStringToText("<p>text</p>", WithPrettify(false), WithCharset("unknown")); err == nil {
+		t.Error("Expected an error for an unknown charset")
+	}
+}
+
+// TestConvertHTMLStringToMarkdown tests the Markdown of an HTML document
+func TestConvertHTMLStringToMarkdown(t *testing.T) {
+	htmlContent := `<html><head><title>Test Page</title></head><body>
+<h2>Hello</h2>
+<p>Some <strong>bold</strong>, <em>italic</em> and <code>code</code> with a <a href="https://example.com">link</a>.</p>
+<ul><li>Two</li></ul>
+<ol start="3"><li>Three</li><li>Four<ul><li>Nested</li></ul></li></ol>
+<table><tr><th>Name</th><th>Score</th></tr><tr><td>Ann</td><td>9</td></tr></table>
+<blockquote><p>Quoted</p><blockquote>Nested</blockquote></blockquote>
+<pre><code class="language-go">x := 1
+y := 2</code></pre>
+<footer>Copyright</footer>
+</body></html>`
+
+	content, _, err := ConvertHTMLStringToText(htmlContent, WithPrettify(false), WithTextFormat(TextFormatMarkdown))
+	if err != nil {
+		t.Fatalf("Error converting HTML: %s", err)
+	}
+
+	expected := "## Hello\n\nSome **bold**, *italic* and `code` with a [link](https://example.com).\n\n" +
+		"- Two\n\n3. Three\n4. Four\n    - Nested\n\n| Name | Score |\n| --- | --- |\n| Ann | 9 |\n\n" +
+		"> Quoted\n>\n> > Nested\n\n```go\nx := 1\ny := 2\n```\n"
+	if content != expected {
+		t.Errorf("Expected content %q, got %q", expected, content)
+	}
+}
+
+// TestConvertHTMLWithoutNpx tests skipping prettify when npx is missing
+func TestConvertHTMLWithoutNpx(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	var warnings []string
+	content, _, err := ConvertHTMLStringToText("<p>Hello</p>", WithWarnings(&warnings))
+	if err != nil {
+		t.Fatalf("Error converting HTML: %s", err)
+	}
+	if content != "Hello\n" {
+		t.Errorf("Expected content %q, got %q", "Hello\n", content)
+	}
+	if len(warnings) != 1 || warnings[0] != "prettify: npx not found, skipped" {
+		t.Errorf("Expected the npx warning, got %q", warnings)
+	}
+}
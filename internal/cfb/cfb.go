@@ -0,0 +1,94 @@
+// Package cfb opens the compound files of the binary Office documents,
+// e.g. doc files, with mscfb.
+//
+// mscfb sizes its slices with the sector counts of the header without
+// checking them, so a corrupted header can make it allocate more memory
+// than is available. The counts are checked against the size of the
+// file before the file is handed to mscfb.
+package cfb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/richardlehane/mscfb"
+)
+
+var (
+	// ErrNotCompound is returned when the content is not a compound file
+	ErrNotCompound = errors.New("not a compound file")
+
+	// ErrMalformed is returned when the compound file cannot be read
+	ErrMalformed = errors.New("malformed compound file")
+)
+
+// Signature starts the compound files
+var Signature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// headerSize is the size of the header of the compound files
+const headerSize = 512
+
+// Open opens the compound file of the given size
+func Open(ra io.ReaderAt, size int64) (*mscfb.Reader, error) {
+	if err := checkHeader(ra, size); err != nil {
+		return nil, err
+	}
+
+	r, err := mscfb.New(ra)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return r, nil
+}
+
+// checkHeader checks the sector counts of the header against the size
+// of the compound file
+func checkHeader(ra io.ReaderAt, size int64) error {
+	if size < headerSize {
+		return fmt.Errorf("%w: %d bytes", ErrNotCompound, size)
+	}
+	h := make([]byte, headerSize)
+	if _, err := ra.ReadAt(h, 0); err != nil {
+		return fmt.Errorf("%w: %v", ErrNotCompound, err)
+	}
+	if !bytes.HasPrefix(h, Signature) {
+		return ErrNotCompound
+	}
+
+	// Version 3 files have 512-byte sectors, version 4 files 4096-byte
+	// sectors
+	var sectorSize int64
+	switch shift := binary.LittleEndian.Uint16(h[30:]); shift {
+	case 9, 12:
+		sectorSize = 1 << shift
+	default:
+		return fmt.Errorf("%w: sector shift %d", ErrMalformed, shift)
+	}
+
+	// Version 3 files do not count their directory sectors
+	numDirectory := binary.LittleEndian.Uint32(h[40:])
+	if binary.LittleEndian.Uint16(h[26:]) == 3 && numDirectory != 0 {
+		return fmt.Errorf("%w: %d directory sectors", ErrMalformed, numDirectory)
+	}
+
+	// Every sector counted must fit in the file after the header
+	counts := []struct {
+		name  string
+		count uint32
+	}{
+		{"directory", numDirectory},
+		{"FAT", binary.LittleEndian.Uint32(h[44:])},
+		{"mini FAT", binary.LittleEndian.Uint32(h[64:])},
+		{"DIFAT", binary.LittleEndian.Uint32(h[72:])},
+	}
+	for _, c := range counts {
+		if int64(c.count)*sectorSize > size-headerSize {
+			return fmt.Errorf("%w: %d %s sectors", ErrMalformed, c.count, c.name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package cfb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/pilinux/totext/internal/fixture"
+)
+
+// header returns the header of a version 3 compound file followed by
+// sectors sectors of 512 bytes, with the uint32 values set at the offsets
+func header(sectors int, values map[int]uint32) []byte {
+	h := make([]byte, headerSize+sectors*512)
+	copy(h, Signature)
+	binary.LittleEndian.PutUint16(h[24:], 0x3E)
+	binary.LittleEndian.PutUint16(h[26:], 3)
+	binary.LittleEndian.PutUint16(h[28:], 0xFFFE)
+	binary.LittleEndian.PutUint16(h[30:], 9)
+	binary.LittleEndian.PutUint16(h[32:], 6)
+	for off, v := range values {
+		binary.LittleEndian.PutUint32(h[off:], v)
+	}
+	return h
+}
+
+// TestCheckHeader tests the checks of the header
+func TestCheckHeader(t *testing.T) {
+	// Test data
+	testData := []struct {
+		name     string
+		content  []byte
+		expected error
+	}{
+		{"valid", header(2, map[int]uint32{44: 1}), nil},
+		{"short", header(0, nil)[:100], ErrNotCompound},
+		{"bad signature", append([]byte("PK\x03\x04"), header(1, nil)[4:]...), ErrNotCompound},
+		{"bad sector shift", header(1, map[int]uint32{30: 7}), ErrMalformed},
+		{"version 3 directory sectors", header(1, map[int]uint32{40: 0x69000000}), ErrMalformed},
+		{"too many fat sectors", header(1, map[int]uint32{44: 2}), ErrMalformed},
+		{"too many mini fat sectors", header(1, map[int]uint32{64: 0xFFFFFFFF}), ErrMalformed},
+		{"too many difat sectors", header(1, map[int]uint32{72: 0x10000}), ErrMalformed},
+	}
+
+	// Iterate over test data
+	for _, data := range testData {
+		err := checkHeader(bytes.NewReader(data.content), int64(len(data.content)))
+		if data.expected == nil && err != nil {
+			t.Errorf("%s: unexpected error: %v", data.name, err)
+		}
+		if data.expected != nil && !errors.Is(err, data.expected) {
+			t.Errorf("%s: expected %v, got %v", data.name, data.expected, err)
+		}
+	}
+}
+
+// TestOpen tests opening a compound file and rejecting a corrupted
+// header before mscfb allocates memory for it
+func TestOpen(t *testing.T) {
+	content := fixture.Compound(fixture.Stream{Name: "Contents", Data: []byte("Hello")})
+
+	r, err := Open(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if entry, err := r.Next(); err != nil || entry.Name != "Contents" {
+		t.Errorf("expected the Contents stream, got %v (%v)", entry, err)
+	}
+
+	binary.LittleEndian.PutUint32(content[40:], 0x69000000)
+	if _, err = Open(bytes.NewReader(content), int64(len(content))); !errors.Is(err, ErrMalformed) {
+		t.Errorf("expected ErrMalformed, got %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+// Package charset detects the character encoding of HTML and text
+// content and transcodes it to UTF-8.
+//
+// The charset is read from the byte order mark, then from the meta
+// elements of HTML content, then from the HTTP Content-Type header and
+// it is guessed from the bytes of the content otherwise. The charsets
+// are named as in the WHATWG Encoding Standard, e.g. windows-1252.
+package charset
+
+import (
+	"bytes"
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// The sources of the detected charsets
+const (
+	// SourceBOM is the byte order mark at the start of the content
+	SourceBOM = "bom"
+	// SourceMeta is a meta element of HTML content
+	SourceMeta = "meta"
+	// SourceHeader is the HTTP Content-Type header
+	SourceHeader = "header"
+	// SourceGuess is the statistical guess from the bytes
+	SourceGuess = "guess"
+)
+
+// UTF8 is the name of the UTF-8 charset
+const UTF8 = "utf-8"
+
+// maxPrescan is the number of bytes of HTML content
+// in which the meta elements are looked for
+const maxPrescan = 4096
+
+// boms are the byte order marks of the charsets
+var boms = []struct {
+	name string
+	bom  []byte
+}{
+	{UTF8, []byte{0xef, 0xbb, 0xbf}},
+	{"utf-16le", []byte{0xff, 0xfe}},
+	{"utf-16be", []byte{0xfe, 0xff}},
+}
+
+// Detect returns the name of the charset of the content and the source
+// it was found in. The meta elements are read if isHTML is set and
+// contentType is the HTTP Content-Type header of the content, if any.
+func Detect(data []byte, isHTML bool, contentType string) (name, source string) {
+	for _, b := range boms {
+		if bytes.HasPrefix(data, b.bom) {
+			return b.name, SourceBOM
+		}
+	}
+
+	if isHTML {
+		if name = prescan(data[:min(len(data), maxPrescan)]); name != "" {
+			return name, SourceMeta
+		}
+	}
+
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		if name = Name(params["charset"]); name != "" {
+			return name, SourceHeader
+		}
+	}
+
+	return Guess(data), SourceGuess
+}
+
+// Name returns the WHATWG name of the charset label,
+// an empty string if it is unknown
+func Name(label string) string {
+	enc, err := htmlindex.Get(strings.TrimSpace(label))
+	if err != nil {
+		return ""
+	}
+	name, err := htmlindex.Name(enc)
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
+// Decode transcodes the content from the charset to UTF-8,
+// the byte order mark of the charset is removed
+func Decode(data []byte, name string) ([]byte, error) {
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range boms {
+		if b.name == name {
+			data = bytes.TrimPrefix(data, b.bom)
+		}
+	}
+	if name == UTF8 {
+		return data, nil
+	}
+
+	return enc.NewDecoder().Bytes(data)
+}
+
+// prescan returns the charset of the first meta element of the HTML
+// content which declares one, an empty string if there is none
+func prescan(data []byte) string {
+	z := html.NewTokenizer(bytes.NewReader(data))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if string(name) != "meta" || !hasAttr {
+				continue
+			}
+
+			var charset, httpEquiv, content string
+			for {
+				key, val, more := z.TagAttr()
+				switch string(key) {
+				case "charset":
+					charset = string(val)
+				case "http-equiv":
+					httpEquiv = string(val)
+				case "content":
+					content = string(val)
+				}
+				if !more {
+					break
+				}
+			}
+			if charset == "" && strings.EqualFold(strings.TrimSpace(httpEquiv), "content-type") {
+				if _, params, err := mime.ParseMediaType(content); err == nil {
+					charset = params["charset"]
+				}
+			}
+
+			if name := Name(charset); name != "" {
+				// The meta element could not be read from UTF-16 content
+				if strings.HasPrefix(name, "utf-16") {
+					return UTF8
+				}
+				return name
+			}
+		}
+	}
+}
+
+// validUTF8 reports whether the content is valid UTF-8, an incomplete
+// sequence at its end is allowed
+func validUTF8(data []byte) bool {
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size == 1 {
+			return !utf8.FullRune(data[i:])
+		}
+		i += size
+	}
+	return true
+}
+
+// decodeString transcodes the content with enc, invalid sequences
+// are replaced with utf8.RuneError
+func decodeString(data []byte, enc encoding.Encoding) string {
+	s, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return ""
+	}
+	return string(s)
+}
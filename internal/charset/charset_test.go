@@ -0,0 +1,114 @@
+package charset
+
+import (
+	"testing"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// encode encodes the UTF-8 text in the charset
+func encode(t *testing.T, text, name string) []byte {
+	t.Helper()
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := enc.NewEncoder().Bytes([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// TestGuess tests guessing the charset of text without a declaration
+func TestGuess(t *testing.T) {
+	// Test data
+	testData := []struct {
+		name string
+		text string
+	}{
+		{"utf-8", "Tous les êtres humains naissent libres et égaux en dignité et en droits."},
+		{"shift_jis", "すべての人間は、生まれながらにして自由であり、かつ、尊厳と権利とについて平等である。"},
+		{"euc-jp", "すべての人間は、生まれながらにして自由であり、かつ、尊厳と権利とについて平等である。"},
+		{"gbk", "人人生而自由，在尊严和权利上一律平等。他们赋有理性和良心，并应以兄弟关系的精神相对待。"},
+		{"big5", "人人生而自由，在尊嚴和權利上一律平等。他們賦有理性和良心，並應以兄弟關係的精神相對待。"},
+		{"euc-kr", "모든 인간은 태어날 때부터 자유로우며 그 존엄과 권리에 있어 동등하다."},
+		{"windows-1252", "Tous les êtres humains naissent libres et égaux en dignité et en droits."},
+		{"windows-1252", "Alle Menschen sind frei und gleich an Würde und Rechten geboren."},
+		{"windows-1250", "Wszyscy ludzie rodzą się wolni i równi pod względem swej godności i swych praw."},
+		{"windows-1251", "Все люди рождаются свободными и равными в своем достоинстве и правах."},
+		{"koi8-r", "Все люди рождаются свободными и равными в своем достоинстве и правах."},
+		{"windows-1253", "Όλοι οι άνθρωποι γεννιούνται ελεύθεροι και ίσοι στην αξιοπρέπεια."},
+		{"utf-16le", "All human beings are born free and equal in dignity and rights."},
+		{"utf-16be", "All human beings are born free and equal in dignity and rights."},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		if got := Guess(encode(t, td.text, td.name)); got != td.name {
+			t.Errorf("expected %s, got %s for %q", td.name, got, td.text)
+		}
+	}
+}
+
+// TestDetect tests the order of the sources of the charset
+func TestDetect(t *testing.T) {
+	latin1 := encode(t, "<p>café</p>", "windows-1252")
+
+	// Test data
+	testData := []struct {
+		data        []byte
+		isHTML      bool
+		contentType string
+		name        string
+		source      string
+	}{
+		{append([]byte{0xef, 0xbb, 0xbf}, `<meta charset="gbk">`...), true, "", "utf-8", SourceBOM},
+		{[]byte{0xff, 0xfe, 'a', 0}, false, "", "utf-16le", SourceBOM},
+		{[]byte(`<meta charset="Shift_JIS">`), true, "text/html; charset=utf-8", "shift_jis", SourceMeta},
+		{[]byte(`<meta http-equiv="Content-Type" content="text/html; charset=iso-8859-1">`), true, "", "windows-1252", SourceMeta},
+		{[]byte(`<meta charset="utf-16">`), true, "", "utf-8", SourceMeta},
+		{[]byte(`<meta charset="gbk">`), false, "", "utf-8", SourceGuess},
+		{[]byte(`<meta charset="unknown"><p>text</p>`), true, "text/html; charset=GB2312", "gbk", SourceHeader},
+		{latin1, true, "text/html", "windows-1252", SourceGuess},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		name, source := Detect(td.data, td.isHTML, td.contentType)
+		if name != td.name || source != td.source {
+			t.Errorf("expected %s from %s, got %s from %s for %q", td.name, td.source, name, source, td.data)
+		}
+	}
+}
+
+// TestDecode tests transcoding to UTF-8
+func TestDecode(t *testing.T) {
+	// Test data
+	testData := []struct {
+		data     []byte
+		name     string
+		expected string
+	}{
+		{[]byte{0xef, 0xbb, 0xbf, 'a'}, "utf-8", "a"},
+		{[]byte{0xff, 0xfe, 'a', 0, 0xe9, 0}, "utf-16le", "aé"},
+		{[]byte{'c', 'a', 'f', 0xe9}, "windows-1252", "café"},
+		{[]byte{0x93, 0xfa, 0x96, 0x7b}, "shift_jis", "日本"},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		got, err := Decode(td.data, td.name)
+		if err != nil {
+			t.Errorf("%s: %v", td.name, err)
+			continue
+		}
+		if string(got) != td.expected {
+			t.Errorf("%s: expected %q, got %q", td.name, td.expected, got)
+		}
+	}
+
+	if _, err := Decode([]byte("a"), "unknown"); err == nil {
+		t.Error("expected an error for an unknown charset")
+	}
+}
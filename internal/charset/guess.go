@@ -0,0 +1,139 @@
+package charset
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+
+	"github.com/pilinux/totext/internal/lang"
+)
+
+// maxGuess is the number of bytes from which the charset is guessed
+const maxGuess = 64 << 10
+
+// minFrequent is the share of the frequent characters from which
+// the content is read in a multi-byte charset
+const minFrequent = 0.2
+
+// The most frequent characters of the languages of the multi-byte charsets
+const (
+	frequentJapanese    = "のにはをたがでてとしいるれかなもっすあまりこうだよられつくけどんからおさ日本人年大中出一会国行事者分生上時見月自"
+	frequentSimplified  = "的一是不了在人有我他这个们中来上大为和国地到以说时要就出会可也你对生能而子那得于着下自之年过发后作里用道行所然家种事成方多经么去法学如都同现当没动面起看定天分还进好小部其些主样理心"
+	frequentTraditional = "的一是不了在人有我他這個們中來上大為和國地到以說時要就出會可也你對生能而子那得於著下自之年過發後作裡用道行所然家種事成方多經麼去法學如都同現當沒動面起看定天分還進好小部其些主樣理心"
+	frequentKorean      = "이다는의에하고을를가지기서한로으사도리인대어나들해게수있시것자정적니요습일우전주상보부국세"
+)
+
+// candidate is a charset which the content is guessed to be in
+type candidate struct {
+	name string
+	enc  encoding.Encoding
+	// frequent are the most frequent characters of the
+	// languages of a multi-byte charset
+	frequent string
+}
+
+// multiByte are the multi-byte charsets which are guessed, they are
+// told apart by the share of the frequent characters of their languages
+var multiByte = []candidate{
+	{"shift_jis", japanese.ShiftJIS, frequentJapanese},
+	{"euc-jp", japanese.EUCJP, frequentJapanese},
+	{"gbk", simplifiedchinese.GBK, frequentSimplified},
+	{"big5", traditionalchinese.Big5, frequentTraditional},
+	{"euc-kr", korean.EUCKR, frequentKorean},
+}
+
+// singleByte are the single-byte charsets which are guessed in order of
+// preference, they are told apart by the trigrams of the known languages
+var singleByte = []candidate{
+	{name: "windows-1252", enc: charmap.Windows1252},
+	{name: "windows-1250", enc: charmap.Windows1250},
+	{name: "windows-1251", enc: charmap.Windows1251},
+	{name: "koi8-r", enc: charmap.KOI8R},
+	{name: "windows-1253", enc: charmap.Windows1253},
+}
+
+// Guess returns the most likely charset of the content from its bytes.
+// Valid UTF-8 is UTF-8, the content is windows-1252 if no other charset
+// is more likely.
+func Guess(data []byte) string {
+	data = data[:min(len(data), maxGuess)]
+	// ASCII characters in UTF-16 are valid UTF-8
+	if name := guessUTF16(data); name != "" {
+		return name
+	}
+	if validUTF8(data) {
+		return UTF8
+	}
+
+	best, score := "", minFrequent
+	for _, c := range multiByte {
+		if s := frequentShare(decodeString(data, c.enc), c.frequent); s > score {
+			best, score = c.name, s
+		}
+	}
+	if best != "" {
+		return best
+	}
+
+	best, score = singleByte[0].name, 0
+	for _, c := range singleByte {
+		if s := lang.Match(decodeString(data, c.enc)); s > score {
+			best, score = c.name, s
+		}
+	}
+	return best
+}
+
+// guessUTF16 returns the UTF-16 charset of content without a byte order
+// mark from the zero bytes of its ASCII characters, an empty string if
+// it is not UTF-16
+func guessUTF16(data []byte) string {
+	var even, odd int
+	for i := 0; i+1 < len(data); i += 2 {
+		if data[i] == 0 {
+			even++
+		}
+		if data[i+1] == 0 {
+			odd++
+		}
+	}
+
+	pairs := len(data) / 2
+	switch {
+	case pairs == 0:
+		return ""
+	case odd*3 > pairs && even*10 < odd:
+		return "utf-16le"
+	case even*3 > pairs && odd*10 < even:
+		return "utf-16be"
+	}
+	return ""
+}
+
+// frequentShare returns the share of the frequent characters among the
+// characters of the text which are not ASCII, 0 if the text has too
+// many invalid sequences
+func frequentShare(text, frequent string) float64 {
+	var n, matches, invalid int
+	for _, r := range text {
+		switch {
+		case r < utf8.RuneSelf:
+			continue
+		case r == utf8.RuneError:
+			invalid++
+		case strings.ContainsRune(frequent, r):
+			matches++
+		}
+		n++
+	}
+	if n == 0 || invalid*50 > n {
+		return 0
+	}
+	return float64(matches) / float64(n)
+}
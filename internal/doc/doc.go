@@ -0,0 +1,389 @@
+// Package doc extracts the text of Word 97-2003 binary documents.
+//
+// The document is a compound file. Its text is stored in pieces in the
+// WordDocument stream, either compressed as 8-bit characters or as
+// UTF-16, and the piece table of the Table stream locates the pieces.
+package doc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/richardlehane/mscfb"
+	"golang.org/x/text/encoding/charmap"
+
+	"github.com/pilinux/totext/internal/cfb"
+)
+
+var (
+	// ErrNotDoc is returned when the content is not a Word document
+	ErrNotDoc = errors.New("not a Word document")
+
+	// ErrEncrypted is returned when the document is encrypted
+	// or obfuscated
+	ErrEncrypted = errors.New("encrypted Word document")
+
+	// ErrUnsupported is returned for documents of Word 95 and earlier
+	ErrUnsupported = errors.New("unsupported Word version")
+
+	// ErrMalformed is returned when the document cannot be parsed
+	ErrMalformed = errors.New("malformed Word document")
+)
+
+// maxStreamSize limits the size of the streams read into memory
+const maxStreamSize = 512 << 20
+
+// wordIdent identifies the Word 97 and later file format
+const wordIdent = 0xA5EC
+
+// Flags of the file information block
+const (
+	flagEncrypted   = 0x0100
+	flagWhichTblStm = 0x0200
+	flagObfuscated  = 0x8000
+)
+
+// Special characters of the text
+const (
+	chrPicture     = 0x01
+	chrFootnoteRef = 0x02
+	chrAnnotation  = 0x05
+	chrCell        = 0x07
+	chrDrawn       = 0x08
+	chrLineBreak   = 0x0B
+	chrPageBreak   = 0x0C
+	chrParagraph   = 0x0D
+	chrFieldBegin  = 0x13
+	chrFieldSep    = 0x14
+	chrFieldEnd    = 0x15
+	chrHyphen      = 0x1E
+	chrSoftHyphen  = 0x1F
+)
+
+// fib is the part of the file information block used to find the text
+type fib struct {
+	flags uint16
+	// ccpText is the number of characters of the main document
+	ccpText int
+	// fcClx and lcbClx locate the piece table in the Table stream
+	fcClx, lcbClx int
+}
+
+// piece is a run of text in the WordDocument stream
+type piece struct {
+	// cpStart and cpEnd are the character positions of the piece
+	cpStart, cpEnd int
+	// fc is the offset of the text in the WordDocument stream
+	fc int
+	// compressed pieces store 8-bit characters, the others UTF-16
+	compressed bool
+}
+
+// Text returns the text of the main document of the Word document.
+// Paragraphs and table rows end with a line feed, tabs are written as
+// spaces, table cells are separated with " | " and the codes of the
+// fields are left out.
+func Text(ra io.ReaderAt, size int64) (text string, err error) {
+	// The parsers do not check every index
+	defer func() {
+		if e := recover(); e != nil {
+			text, err = "", fmt.Errorf("%w: %v", ErrMalformed, e)
+		}
+	}()
+
+	file, err := cfb.Open(ra, size)
+	if errors.Is(err, cfb.ErrNotCompound) {
+		return "", fmt.Errorf("%w: %v", ErrNotDoc, err)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+
+	streams, err := readStreams(file, "WordDocument", "0Table", "1Table")
+	if err != nil {
+		return "", err
+	}
+	word, ok := streams["WordDocument"]
+	if !ok {
+		return "", ErrNotDoc
+	}
+
+	f, err := parseFib(word)
+	if err != nil {
+		return "", err
+	}
+	if f.flags&(flagEncrypted|flagObfuscated) != 0 {
+		return "", ErrEncrypted
+	}
+
+	tableName := "0Table"
+	if f.flags&flagWhichTblStm != 0 {
+		tableName = "1Table"
+	}
+	table, ok := streams[tableName]
+	if !ok {
+		return "", fmt.Errorf("%w: missing %s stream", ErrMalformed, tableName)
+	}
+	if f.fcClx < 0 || f.lcbClx <= 0 || f.fcClx+f.lcbClx > len(table) {
+		return "", fmt.Errorf("%w: invalid piece table location", ErrMalformed)
+	}
+
+	pieces, err := parseClx(table[f.fcClx : f.fcClx+f.lcbClx])
+	if err != nil {
+		return "", err
+	}
+
+	return extract(word, pieces, f.ccpText), nil
+}
+
+// readStreams reads the streams of the root storage with the names
+func readStreams(file *mscfb.Reader, names ...string) (map[string][]byte, error) {
+	streams := make(map[string][]byte)
+	for entry, err := file.Next(); err == nil; entry, err = file.Next() {
+		// Embedded documents are in sub-storages
+		if len(entry.Path) > 0 {
+			continue
+		}
+		for _, name := range names {
+			if entry.Name != name {
+				continue
+			}
+			if entry.Size > maxStreamSize {
+				return nil, fmt.Errorf("%w: %s stream too large", ErrMalformed, name)
+			}
+			data, err := io.ReadAll(entry)
+			if err != nil && len(data) < int(entry.Size) {
+				return nil, fmt.Errorf("%w: %s stream: %v", ErrMalformed, name, err)
+			}
+			streams[name] = data
+		}
+	}
+	return streams, nil
+}
+
+// parseFib reads the file information block at the start
+// of the WordDocument stream
+func parseFib(word []byte) (fib, error) {
+	var f fib
+	if len(word) < 34 {
+		return f, ErrNotDoc
+	}
+
+	if binary.LittleEndian.Uint16(word) != wordIdent {
+		return f, ErrUnsupported
+	}
+	f.flags = binary.LittleEndian.Uint16(word[10:])
+
+	// FibBase is followed by the arrays of 16-bit values, 32-bit values
+	// and offset and length pairs, each preceded by its count
+	pos := 32
+	csw := int(binary.LittleEndian.Uint16(word[pos:]))
+	pos += 2 + 2*csw
+
+	if pos+2 > len(word) {
+		return f, fmt.Errorf("%w: truncated file information block", ErrMalformed)
+	}
+	cslw := int(binary.LittleEndian.Uint16(word[pos:]))
+	rgLw := pos + 2
+	pos = rgLw + 4*cslw
+	if cslw > 3 && rgLw+16 <= len(word) {
+		f.ccpText = int(int32(binary.LittleEndian.Uint32(word[rgLw+12:])))
+	}
+
+	if pos+2 > len(word) {
+		return f, fmt.Errorf("%w: truncated file information block", ErrMalformed)
+	}
+	cbRgFcLcb := int(binary.LittleEndian.Uint16(word[pos:]))
+	rgFcLcb := pos + 2
+
+	// fcClx is the 34th offset and length pair
+	const clx = 33
+	if cbRgFcLcb <= clx || rgFcLcb+8*clx+8 > len(word) {
+		return f, fmt.Errorf("%w: missing piece table", ErrMalformed)
+	}
+	f.fcClx = int(binary.LittleEndian.Uint32(word[rgFcLcb+8*clx:]))
+	f.lcbClx = int(binary.LittleEndian.Uint32(word[rgFcLcb+8*clx+4:]))
+
+	return f, nil
+}
+
+// parseClx reads the pieces of the piece table, which follows the
+// property modifiers in the CLX structure
+func parseClx(clx []byte) ([]piece, error) {
+	pos := 0
+	for pos < len(clx) {
+		switch clx[pos] {
+		case 0x01:
+			// Prc, skipped
+			if pos+3 > len(clx) {
+				return nil, fmt.Errorf("%w: truncated CLX", ErrMalformed)
+			}
+			size := int(int16(binary.LittleEndian.Uint16(clx[pos+1:])))
+			if size < 0 {
+				return nil, fmt.Errorf("%w: invalid CLX", ErrMalformed)
+			}
+			pos += 3 + size
+		case 0x02:
+			// Pcdt
+			if pos+5 > len(clx) {
+				return nil, fmt.Errorf("%w: truncated CLX", ErrMalformed)
+			}
+			size := int(binary.LittleEndian.Uint32(clx[pos+1:]))
+			if size < 0 || pos+5+size > len(clx) {
+				return nil, fmt.Errorf("%w: truncated piece table", ErrMalformed)
+			}
+			return parsePlcPcd(clx[pos+5 : pos+5+size])
+		default:
+			return nil, fmt.Errorf("%w: invalid CLX", ErrMalformed)
+		}
+	}
+
+	return nil, fmt.Errorf("%w: missing piece table", ErrMalformed)
+}
+
+// parsePlcPcd reads the character positions and the piece
+// descriptors of the piece table
+func parsePlcPcd(plc []byte) ([]piece, error) {
+	// n+1 character positions of 4 bytes and n descriptors of 8 bytes
+	n := (len(plc) - 4) / 12
+	if n <= 0 {
+		return nil, fmt.Errorf("%w: empty piece table", ErrMalformed)
+	}
+
+	pieces := make([]piece, 0, n)
+	descriptors := 4 * (n + 1)
+	for i := 0; i < n; i++ {
+		pcd := plc[descriptors+8*i:]
+		fc := binary.LittleEndian.Uint32(pcd[2:])
+		p := piece{
+			cpStart:    int(binary.LittleEndian.Uint32(plc[4*i:])),
+			cpEnd:      int(binary.LittleEndian.Uint32(plc[4*i+4:])),
+			fc:         int(fc & 0x3FFFFFFF),
+			compressed: fc&0x40000000 != 0,
+		}
+		if p.compressed {
+			p.fc /= 2
+		}
+		pieces = append(pieces, p)
+	}
+
+	return pieces, nil
+}
+
+// extract returns the text of the pieces up to the character position limit
+func extract(word []byte, pieces []piece, limit int) string {
+	if limit <= 0 {
+		limit = int(^uint(0) >> 1)
+	}
+
+	w := &writer{}
+	for _, p := range pieces {
+		if p.cpStart >= limit || p.cpEnd <= p.cpStart {
+			continue
+		}
+		count := min(p.cpEnd, limit) - p.cpStart
+
+		if p.compressed {
+			end := min(p.fc+count, len(word))
+			for _, b := range word[min(p.fc, end):end] {
+				w.char(charmap.Windows1252.DecodeByte(b))
+			}
+			continue
+		}
+
+		end := min(p.fc+2*count, len(word)&^1)
+		if p.fc >= end {
+			continue
+		}
+		units := make([]uint16, 0, (end-p.fc)/2)
+		for i := p.fc; i+1 < end; i += 2 {
+			units = append(units, binary.LittleEndian.Uint16(word[i:]))
+		}
+		for _, r := range utf16.Decode(units) {
+			w.char(r)
+		}
+	}
+
+	return w.String()
+}
+
+// writer writes the characters of the document as plain text
+type writer struct {
+	strings.Builder
+
+	// fields holds the nested fields, true while the code of
+	// the field is read
+	fields []bool
+	// cell delays the separator of a table cell until its next text
+	cell bool
+	// last is the previous character
+	last rune
+}
+
+// char writes a character of the document
+func (w *writer) char(r rune) {
+	last := w.last
+	w.last = r
+
+	// Fields are made of a code and an optional result
+	switch r {
+	case chrFieldBegin:
+		w.fields = append(w.fields, true)
+		return
+	case chrFieldSep:
+		if n := len(w.fields); n > 0 {
+			w.fields[n-1] = false
+		}
+		return
+	case chrFieldEnd:
+		if n := len(w.fields); n > 0 {
+			w.fields = w.fields[:n-1]
+		}
+		return
+	}
+	for _, code := range w.fields {
+		if code {
+			return
+		}
+	}
+
+	switch r {
+	case chrParagraph, chrLineBreak, chrPageBreak:
+		w.cell = false
+		w.WriteByte('\n')
+	case chrCell:
+		// The end of a row follows the end of its last cell
+		if last == chrCell {
+			w.cell = false
+			w.WriteByte('\n')
+			w.last = 0
+			return
+		}
+		w.cell = true
+	case chrHyphen:
+		w.text('-')
+	case 0xA0:
+		w.text(' ')
+	case '\t':
+		w.text(' ')
+	case chrPicture, chrFootnoteRef, chrAnnotation, chrDrawn, chrSoftHyphen:
+	default:
+		if r >= 0x20 {
+			w.text(r)
+		}
+	}
+}
+
+// text writes a character of text
+func (w *writer) text(r rune) {
+	if w.cell {
+		// Separate the table cells as the other native backends do
+		w.cell = false
+		w.WriteString(" | ")
+	}
+	w.WriteRune(r)
+}
@@ -0,0 +1,88 @@
+package doc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pilinux/totext/internal/fixture"
+)
+
+// TestExtract tests the text of the piece table
+func TestExtract(t *testing.T) {
+	// Test data
+	testData := []struct {
+		texts    []string
+		ccpText  int
+		expected string
+	}{
+		{[]string{"Hello \x93world\x94\r", "Zweite Zeile: ü€\r"}, 0, "Hello “world”\nZweite Zeile: ü€\n"},
+		{[]string{"A\x07B\x07\x07C\x07D\x07\x07"}, 0, "A | B\nC | D\n"},
+		{[]string{"Name\tValue\r"}, 0, "Name Value\n"},
+		{[]string{"See \x13 HYPERLINK \"x\" \x14the link\x15 and \x13 PAGE \x15.\r"}, 0, "See the link and .\n"},
+		{[]string{"\x13 IF \x13 A \x14nested\x15 \x14outer\x15\r"}, 0, "outer\n"},
+		{[]string{"non\x1Ebreaking soft\x1Fhyphen\x01\x08\x0Bline\x0Cpage\r"}, 0, "non-breaking softhyphen\nline\npage\n"},
+		{[]string{"main\r", "footnote\r"}, 5, "main\n"},
+	}
+
+	// Iterate over test data
+	for _, data := range testData {
+		word, table := fixture.WordStreams(0, data.ccpText, data.texts...)
+		f, err := parseFib(word)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pieces, err := parseClx(table[f.fcClx : f.fcClx+f.lcbClx])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text := extract(word, pieces, f.ccpText); text != data.expected {
+			t.Errorf("%q: expected %q, got %q", data.texts, data.expected, text)
+		}
+	}
+}
+
+// TestParseFib tests the flags of the file information block
+func TestParseFib(t *testing.T) {
+	word, _ := fixture.WordStreams(flagEncrypted|flagWhichTblStm, 0, "text")
+	f, err := parseFib(word)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.flags&flagEncrypted == 0 || f.flags&flagWhichTblStm == 0 {
+		t.Errorf("unexpected flags %x", f.flags)
+	}
+
+	// Word 95
+	word[0] = 0xDC
+	if _, err = parseFib(word); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+
+	if _, err = parseFib(word[:40]); err == nil {
+		t.Error("expected an error for a truncated block")
+	}
+}
+
+// TestText tests the text of Word documents and content which
+// is not a Word document
+func TestText(t *testing.T) {
+	content := fixture.Doc("Hello \x93world\x94\r", "ü€\r")
+	text, err := Text(bytes.NewReader(content), int64(len(content)))
+	if expected := "Hello “world”\nü€\n"; err != nil || text != expected {
+		t.Errorf("expected %q, got %q (%v)", expected, text, err)
+	}
+
+	// A header counting more directory sectors than the file holds
+	binary.LittleEndian.PutUint32(content[40:], 0x69000000)
+	if _, err = Text(bytes.NewReader(content), int64(len(content))); !errors.Is(err, ErrMalformed) {
+		t.Errorf("expected ErrMalformed, got %v", err)
+	}
+
+	_, err = Text(strings.NewReader("not a compound file"), 19)
+	if !errors.Is(err, ErrNotDoc) {
+		t.Errorf("expected ErrNotDoc, got %v", err)
+	}
+}
@@ -0,0 +1,228 @@
+// Package docx reads the text and the structure of Office Open XML
+// word processing documents.
+//
+// The document is a zip package. The main document part refers to the
+// styles, the numbering definitions, the notes, the comments and the
+// headers and footers through its relationships.
+package docx
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/pilinux/totext/internal/opc"
+	"github.com/pilinux/totext/internal/structure"
+	"github.com/pilinux/totext/internal/xmltree"
+)
+
+var (
+	// ErrNotDocx is returned when the content is not a docx document
+	ErrNotDocx = errors.New("not a docx document")
+
+	// ErrEncrypted is returned when the document is encrypted
+	ErrEncrypted = errors.New("encrypted docx document")
+
+	// ErrMalformed is returned when the document cannot be parsed
+	ErrMalformed = errors.New("malformed docx document")
+)
+
+// Read reads the docx document of the given size. The tracked changes
+// are accepted, or rejected if reject is set.
+func Read(ctx context.Context, ra io.ReaderAt, size int64, reject bool) (*structure.Document, error) {
+	pk, err := opc.Open(ra, size)
+	if err != nil {
+		return nil, packageError(err)
+	}
+
+	pkgRels, err := pk.Rels("")
+	if err != nil {
+		return nil, packageError(err)
+	}
+	main := pkgRels.First("officeDocument")
+	if main == "" {
+		main = "word/document.xml"
+	}
+	mainFile := pk.File(main)
+	if mainFile == nil {
+		return nil, fmt.Errorf("%w: missing main document part", ErrNotDocx)
+	}
+	docRels, err := pk.Rels(main)
+	if err != nil {
+		return nil, packageError(err)
+	}
+
+	p := &parser{
+		reject:     reject,
+		styles:     make(map[string]*style),
+		charStyles: make(map[string]*style),
+		numbering:  newNumbering(),
+		footnotes:  make(map[string]int),
+		endnotes:   make(map[string]int),
+	}
+	doc := &structure.Document{}
+
+	// Read the styles and the numbering definitions before the text
+	if f := pk.File(docRels.First("styles")); f != nil {
+		if err = p.part(f, p.readStyles); err != nil {
+			return nil, err
+		}
+	}
+	if f := pk.File(docRels.First("numbering")); f != nil {
+		if err = p.part(f, p.numbering.read(p)); err != nil {
+			return nil, err
+		}
+	}
+
+	p.links = docRels.Links
+	err = p.part(mainFile, func() (err error) {
+		doc.Body, err = p.blocks()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Headers and footers are referenced by the sections
+	for _, refs := range []struct {
+		ids   []string
+		parts *[][]structure.Block
+	}{
+		{p.headers, &doc.Headers},
+		{p.footers, &doc.Footers},
+	} {
+		for _, id := range refs.ids {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			f := pk.File(docRels.ByID[id])
+			if f == nil {
+				continue
+			}
+			if err = p.partLinks(pk, docRels.ByID[id]); err != nil {
+				return nil, err
+			}
+			var blocks []structure.Block
+			err = p.part(f, func() (err error) {
+				blocks, err = p.blocks()
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+			*refs.parts = append(*refs.parts, blocks)
+		}
+	}
+
+	// Notes are numbered in the order of their references
+	for _, notes := range []struct {
+		typ     string
+		numbers map[string]int
+		notes   *[]structure.Note
+	}{
+		{"footnotes", p.footnotes, &doc.Footnotes},
+		{"endnotes", p.endnotes, &doc.Endnotes},
+		{"comments", nil, &doc.Comments},
+	} {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		f := pk.File(docRels.First(notes.typ))
+		if f == nil {
+			continue
+		}
+		if err = p.partLinks(pk, docRels.First(notes.typ)); err != nil {
+			return nil, err
+		}
+		err = p.part(f, func() (err error) {
+			*notes.notes, err = p.notes(notes.numbers)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Read the core and the extended properties
+	if doc.Properties, err = pk.Properties(); err != nil {
+		return nil, packageError(err)
+	}
+
+	return doc, nil
+}
+
+// packageError returns the error of the package as a document error
+func packageError(err error) error {
+	switch {
+	case errors.Is(err, opc.ErrEncrypted):
+		return ErrEncrypted
+	case errors.Is(err, opc.ErrNotPackage):
+		return fmt.Errorf("%w: %v", ErrNotDocx, err)
+	}
+	return fmt.Errorf("%w: %v", ErrMalformed, err)
+}
+
+// partLinks reads the targets of the hyperlinks of the part with the name
+func (p *parser) partLinks(pk *opc.Package, name string) error {
+	r, err := pk.Rels(name)
+	if err != nil {
+		return packageError(err)
+	}
+	p.links = r.Links
+	return nil
+}
+
+// notes reads the footnotes, the endnotes or the comments of the
+// current part. numbers holds the numbers of the referenced notes,
+// it is nil for the comments.
+func (p *parser) notes(numbers map[string]int) ([]structure.Note, error) {
+	var notes []structure.Note
+	err := xmltree.Children(p.d, func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "footnote", "endnote", "comment":
+		default:
+			return p.d.Skip()
+		}
+
+		// Separators are not part of the text
+		if typ := xmltree.Attr(t, "type"); typ != "" && typ != "normal" {
+			return p.d.Skip()
+		}
+
+		blocks, err := p.blocks()
+		if err != nil {
+			return err
+		}
+		note := structure.Note{ID: xmltree.Attr(t, "id"), Author: xmltree.Attr(t, "author"), Blocks: blocks}
+		if numbers != nil {
+			note.ID = strconv.Itoa(noteNumber(numbers, note.ID))
+		}
+		notes = append(notes, note)
+		return nil
+	})
+	if err != nil || numbers == nil {
+		return notes, err
+	}
+
+	sort.SliceStable(notes, func(i, j int) bool {
+		a, _ := strconv.Atoi(notes[i].ID)
+		b, _ := strconv.Atoi(notes[j].ID)
+		return a < b
+	})
+	return notes, nil
+}
+
+// noteNumber returns the number of the note with the id,
+// new notes are numbered in order
+func noteNumber(numbers map[string]int, id string) int {
+	n, ok := numbers[id]
+	if !ok {
+		n = len(numbers) + 1
+		numbers[id] = n
+	}
+	return n
+}
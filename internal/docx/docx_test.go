@@ -0,0 +1,331 @@
+package docx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pilinux/totext/internal/cfb"
+	"github.com/pilinux/totext/internal/fixture"
+	"github.com/pilinux/totext/internal/structure"
+)
+
+const (
+	wordNS = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" ` +
+		`xmlns:mc="http://schemas.openxmlformats.org/markup-compatibility/2006"`
+	relType = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/"
+)
+
+// buildDocx returns a docx package with the body of the main document
+// and the other parts
+func buildDocx(t *testing.T, body string, parts map[string]string) []byte {
+	t.Helper()
+
+	entries := map[string]string{
+		"_rels/.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="` + relType + `officeDocument" Target="word/document.xml"/>` +
+			`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>` +
+			`</Relationships>`,
+		"word/_rels/document.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="` + relType + `styles" Target="styles.xml"/>` +
+			`<Relationship Id="rId2" Type="` + relType + `numbering" Target="numbering.xml"/>` +
+			`<Relationship Id="rId3" Type="` + relType + `footnotes" Target="footnotes.xml"/>` +
+			`<Relationship Id="rId4" Type="` + relType + `comments" Target="/word/comments.xml"/>` +
+			`<Relationship Id="rId10" Type="` + relType + `header" Target="header1.xml"/>` +
+			`<Relationship Id="rId11" Type="` + relType + `footer" Target="footer1.xml"/>` +
+			`<Relationship Id="rId12" Type="` + relType + `hyperlink" Target="https://example.com" TargetMode="External"/>` +
+			`</Relationships>`,
+		"word/document.xml": `<w:document ` + wordNS + `><w:body>` + body + `</w:body></w:document>`,
+	}
+
+	return fixture.Zip(t, entries, parts)
+}
+
+// emptyZip returns a zip archive without a document
+func emptyZip(t *testing.T) []byte {
+	return fixture.Zip(t, map[string]string{"readme.txt": ""})
+}
+
+// render returns the blocks as lines of kind, level, label and text
+func render(blocks []structure.Block) string {
+	var lines []string
+	for _, b := range blocks {
+		switch b.Kind {
+		case structure.Heading:
+			lines = append(lines, fmt.Sprintf("H%d %s|%s", b.Level, b.Label, b.Text))
+		case structure.ListItem:
+			lines = append(lines, fmt.Sprintf("L%d %s|%s", b.Level, b.Label, b.Text))
+		case structure.Table:
+			var rows []string
+			for _, row := range b.Rows {
+				var cells []string
+				for _, cell := range row {
+					cells = append(cells, render(cell))
+				}
+				rows = append(rows, strings.Join(cells, ","))
+			}
+			lines = append(lines, "T "+strings.Join(rows, ";"))
+		default:
+			lines = append(lines, "P "+b.Text)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// paragraph returns a paragraph with the properties and the runs
+func paragraph(props string, runs ...string) string {
+	p := "<w:p>"
+	if props != "" {
+		p += "<w:pPr>" + props + "</w:pPr>"
+	}
+	for _, run := range runs {
+		if strings.HasPrefix(run, "<") {
+			p += run
+			continue
+		}
+		p += `<w:r><w:t xml:space="preserve">` + run + `</w:t></w:r>`
+	}
+	return p + "</w:p>"
+}
+
+// numPr returns the numbering properties of a paragraph
+func numPr(numID, ilvl int) string {
+	return fmt.Sprintf(`<w:numPr><w:ilvl w:val="%d"/><w:numId w:val="%d"/></w:numPr>`, ilvl, numID)
+}
+
+var testParts = map[string]string{
+	"word/styles.xml": `<w:styles ` + wordNS + `>` +
+		`<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/></w:style>` +
+		`<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/>` +
+		`<w:pPr><w:outlineLvl w:val="0"/></w:pPr></w:style>` +
+		`<w:style w:type="paragraph" w:styleId="berschrift2"><w:name w:val="heading 2"/><w:basedOn w:val="Normal"/></w:style>` +
+		`<w:style w:type="paragraph" w:styleId="Chapter"><w:name w:val="Chapter"/><w:basedOn w:val="Heading1"/>` +
+		`<w:pPr><w:numPr><w:numId w:val="4"/></w:numPr></w:pPr></w:style>` +
+		`<w:style w:type="character" w:styleId="Strong"><w:name w:val="heading 3"/></w:style>` +
+		`</w:styles>`,
+	"word/numbering.xml": `<w:numbering ` + wordNS + `>` +
+		`<w:abstractNum w:abstractNumId="0">` +
+		`<w:lvl w:ilvl="0"><w:start w:val="1"/><w:numFmt w:val="decimal"/><w:lvlText w:val="%1."/></w:lvl>` +
+		`<w:lvl w:ilvl="1"><w:start w:val="1"/><w:numFmt w:val="lowerLetter"/><w:lvlText w:val="%1.%2)"/></w:lvl>` +
+		`</w:abstractNum>` +
+		`<w:abstractNum w:abstractNumId="1">` +
+		`<w:lvl w:ilvl="0"><w:numFmt w:val="bullet"/><w:lvlText w:val="` + "" + `"/></w:lvl>` +
+		`<w:lvl w:ilvl="1"><w:numFmt w:val="bullet"/><w:lvlText w:val="o"/></w:lvl>` +
+		`</w:abstractNum>` +
+		`<w:abstractNum w:abstractNumId="2">` +
+		`<w:lvl w:ilvl="0"><w:start w:val="1"/><w:numFmt w:val="upperRoman"/><w:lvlText w:val="Chapter %1"/></w:lvl>` +
+		`</w:abstractNum>` +
+		`<w:num w:numId="1"><w:abstractNumId w:val="0"/></w:num>` +
+		`<w:num w:numId="2"><w:abstractNumId w:val="1"/></w:num>` +
+		`<w:num w:numId="3"><w:abstractNumId w:val="0"/>` +
+		`<w:lvlOverride w:ilvl="0"><w:startOverride w:val="5"/></w:lvlOverride></w:num>` +
+		`<w:num w:numId="4"><w:abstractNumId w:val="2"/></w:num>` +
+		`</w:numbering>`,
+	"word/footnotes.xml": `<w:footnotes ` + wordNS + `>` +
+		`<w:footnote w:type="separator" w:id="-1"><w:p><w:r><w:separator/></w:r></w:p></w:footnote>` +
+		`<w:footnote w:id="7">` + paragraph("", `<w:r><w:footnoteRef/></w:r>`, " Second note") + `</w:footnote>` +
+		`<w:footnote w:id="3">` + paragraph("", " First note") + `</w:footnote>` +
+		`</w:footnotes>`,
+	"word/comments.xml": `<w:comments ` + wordNS + `>` +
+		`<w:comment w:id="0" w:author="Jane Doe">` + paragraph("", "Check this") + `</w:comment>` +
+		`</w:comments>`,
+	"word/header1.xml": `<w:hdr ` + wordNS + `>` + paragraph("", "Confidential") + `</w:hdr>`,
+	"word/footer1.xml": `<w:ftr ` + wordNS + `>` + paragraph("", "Page ", `<w:fldSimple w:instr="PAGE"><w:r><w:t>1</w:t></w:r></w:fldSimple>`) + `</w:ftr>`,
+	"docProps/core.xml": `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" ` +
+		`xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/">` +
+		`<dc:title>Report</dc:title><dc:creator>Jane Doe</dc:creator>` +
+		`<dcterms:created>2021-03-04T05:06:07Z</dcterms:created><cp:keywords></cp:keywords></cp:coreProperties>`,
+}
+
+// TestRead tests the structure of a document
+func TestRead(t *testing.T) {
+	body := paragraph(`<w:pStyle w:val="Heading1"/>`, "Intro") +
+		paragraph(`<w:pStyle w:val="berschrift2"/>`, "Scope") +
+		paragraph("", "Text", `<w:r><w:footnoteReference w:id="3"/></w:r>`, " and", `<w:r><w:footnoteReference w:id="7"/></w:r>`) +
+		paragraph(numPr(1, 0), "One") +
+		paragraph(numPr(1, 1), "Sub") +
+		paragraph(numPr(1, 1), "Sub") +
+		paragraph(numPr(1, 0), "Two") +
+		paragraph(numPr(2, 0), "Dot") +
+		paragraph(numPr(2, 1), "Circle") +
+		paragraph(numPr(3, 0), "Five") +
+		paragraph(numPr(1, 0), "Three") +
+		paragraph(`<w:pStyle w:val="Chapter"/>`, "Start") +
+		paragraph(`<w:pStyle w:val="Chapter"/>`+numPr(0, 0), "Plain") +
+		paragraph(`<w:outlineLvl w:val="2"/>`, "Direct") +
+		paragraph("") +
+		`<w:tbl><w:tblPr/><w:tr><w:tc><w:tcPr/>` + paragraph("", "A") + `</w:tc><w:tc>` + paragraph("", "B") + `</w:tc></w:tr>` +
+		`<w:tr><w:tc>` + paragraph("", "C") + paragraph("", "D") + `</w:tc><w:tc>` +
+		`<w:tbl><w:tr><w:tc>` + paragraph("", "E") + `</w:tc></w:tr></w:tbl></w:tc></w:tr></w:tbl>` +
+		paragraph("", `<w:r><w:t>a</w:t><w:tab/><w:t>b</w:t><w:br/><w:t>c</w:t><w:noBreakHyphen/><w:sym w:font="Symbol" w:char="F0B7"/><w:sym w:char="03A9"/></w:r>`) +
+		paragraph("", `<w:r><w:fldChar w:fldCharType="begin"/></w:r><w:r><w:instrText>HYPERLINK "x"</w:instrText></w:r>`+
+			`<w:r><w:fldChar w:fldCharType="separate"/></w:r><w:hyperlink r:id="rId12"><w:r><w:t>link</w:t></w:r></w:hyperlink>`+
+			`<w:r><w:fldChar w:fldCharType="end"/></w:r>`) +
+		paragraph("", `<w:r><mc:AlternateContent><mc:Choice Requires="wps"><w:drawing><w:txbxContent>`+paragraph("", "Box")+
+			`</w:txbxContent></w:drawing></mc:Choice><mc:Fallback><w:pict><w:txbxContent>`+paragraph("", "Box")+
+			`</w:txbxContent></w:pict></mc:Fallback></mc:AlternateContent></w:r>`, "Anchor") +
+		`<w:sdt><w:sdtPr><w:alias w:val="x"/></w:sdtPr><w:sdtContent>` + paragraph("", "Control") + `</w:sdtContent></w:sdt>` +
+		`<w:sectPr><w:headerReference w:type="default" r:id="rId10"/><w:headerReference w:type="first" r:id="rId10"/>` +
+		`<w:footerReference w:type="default" r:id="rId11"/><w:headerReference w:type="even" r:id="rId99"/></w:sectPr>`
+
+	data := buildDocx(t, body, testParts)
+	doc, err := Read(context.Background(), bytes.NewReader(data), int64(len(data)), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.Join([]string{
+		"H1 |Intro",
+		"H2 |Scope",
+		"P Text[1] and[2]",
+		"L0 1.|One",
+		"L1 1.a)|Sub",
+		"L1 1.b)|Sub",
+		"L0 2.|Two",
+		"L0 •|Dot",
+		"L1 o|Circle",
+		"L0 5.|Five",
+		"L0 3.|Three",
+		"H1 Chapter I|Start",
+		"H1 |Plain",
+		"H3 |Direct",
+		"T P A,P B;P C\nP D,T P E",
+		"P a\tb\nc-Ω",
+		"P link",
+		"P Anchor",
+		"P Box",
+		"P Control",
+	}, "\n")
+	if got := render(doc.Body); got != expected {
+		t.Errorf("unexpected body\n%s\nexpected\n%s", got, expected)
+	}
+
+	if len(doc.Headers) != 1 || render(doc.Headers[0]) != "P Confidential" {
+		t.Errorf("unexpected headers %v", doc.Headers)
+	}
+	if len(doc.Footers) != 1 || render(doc.Footers[0]) != "P Page 1" {
+		t.Errorf("unexpected footers %v", doc.Footers)
+	}
+	if len(doc.Footnotes) != 2 ||
+		doc.Footnotes[0].ID != "1" || render(doc.Footnotes[0].Blocks) != "P  First note" ||
+		doc.Footnotes[1].ID != "2" || render(doc.Footnotes[1].Blocks) != "P  Second note" {
+		t.Errorf("unexpected footnotes %v", doc.Footnotes)
+	}
+	if len(doc.Comments) != 1 || doc.Comments[0].ID != "0" || doc.Comments[0].Author != "Jane Doe" ||
+		render(doc.Comments[0].Blocks) != "P Check this" {
+		t.Errorf("unexpected comments %v", doc.Comments)
+	}
+	if doc.Properties["title"] != "Report" || doc.Properties["creator"] != "Jane Doe" ||
+		doc.Properties["created"] != "2021-03-04T05:06:07Z" || len(doc.Properties) != 3 {
+		t.Errorf("unexpected properties %v", doc.Properties)
+	}
+}
+
+// TestTrackedChanges tests accepting and rejecting the tracked changes
+func TestTrackedChanges(t *testing.T) {
+	body := paragraph("", "Keep ", `<w:ins w:id="1" w:author="A"><w:r><w:t>new</w:t></w:r></w:ins>`,
+		`<w:del w:id="2" w:author="A"><w:r><w:delText>old</w:delText></w:r></w:del>`) +
+		paragraph(`<w:rPr><w:del w:id="3" w:author="A"/></w:rPr>`, "Joined ") +
+		paragraph("", "line") +
+		paragraph(`<w:rPr><w:ins w:id="4" w:author="A"/></w:rPr>`, `<w:ins w:id="5" w:author="A"><w:r><w:t>Added</w:t></w:r></w:ins>`) +
+		paragraph("", `<w:moveFrom w:id="6"><w:r><w:t>from</w:t></w:r></w:moveFrom>`, `<w:moveTo w:id="7"><w:r><w:t>to</w:t></w:r></w:moveTo>`) +
+		`<w:tbl><w:tr><w:tc>` + paragraph("", "Row") + `</w:tc></w:tr>` +
+		`<w:tr><w:trPr><w:ins w:id="8" w:author="A"/></w:trPr><w:tc>` + paragraph("", "Inserted") + `</w:tc></w:tr>` +
+		`<w:tr><w:trPr><w:del w:id="9" w:author="A"/></w:trPr><w:tc>` + paragraph("", "Deleted") + `</w:tc></w:tr></w:tbl>`
+
+	// Test data
+	testData := []struct {
+		reject   bool
+		expected string
+	}{
+		{false, "P Keep new\nP Joined line\nP Added\nP to\nT P Row;P Inserted"},
+		{true, "P Keep old\nP Joined \nP line\nP from\nT P Row;P Deleted"},
+	}
+
+	// Iterate over test data
+	data := buildDocx(t, body, nil)
+	for _, td := range testData {
+		doc, err := Read(context.Background(), bytes.NewReader(data), int64(len(data)), td.reject)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := render(doc.Body); got != td.expected {
+			t.Errorf("reject %v: unexpected body\n%s\nexpected\n%s", td.reject, got, td.expected)
+		}
+	}
+}
+
+// TestReadErrors tests the errors of invalid documents
+func TestReadErrors(t *testing.T) {
+	// Test data
+	testData := []struct {
+		name     string
+		data     []byte
+		expected error
+	}{
+		{"not a zip", []byte("plain text"), ErrNotDocx},
+		{"encrypted", append(append([]byte{}, cfb.Signature...), make([]byte, 512)...), ErrEncrypted},
+		{"no document", emptyZip(t), ErrNotDocx},
+		{"malformed", buildDocx(t, "<w:p><w:r>", nil), ErrMalformed},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		_, err := Read(context.Background(), bytes.NewReader(td.data), int64(len(td.data)), false)
+		if !errors.Is(err, td.expected) {
+			t.Errorf("%s: expected %v, got %v", td.name, td.expected, err)
+		}
+	}
+}
+
+// TestFormat tests the format of the runs, the hyperlinks and the code paragraphs
+func TestFormat(t *testing.T) {
+	body := paragraph("", "Plain ", `<w:r><w:rPr><w:b/></w:rPr><w:t>bold</w:t></w:r>`,
+		`<w:r><w:rPr><w:b/><w:i w:val="0"/></w:rPr><w:t xml:space="preserve"> too</w:t></w:r>`,
+		`<w:r><w:rPr><w:rStyle w:val="Emphasis"/></w:rPr><w:t xml:space="preserve"> em</w:t></w:r>`,
+		`<w:r><w:rPr><w:rFonts w:ascii="Courier New"/></w:rPr><w:t xml:space="preserve"> x</w:t></w:r>`) +
+		paragraph("", `<w:hyperlink r:id="rId12"><w:r><w:t>site</w:t></w:r></w:hyperlink>`,
+			`<w:hyperlink w:anchor="top"><w:r><w:t>top</w:t></w:r></w:hyperlink>`) +
+		paragraph(`<w:pStyle w:val="Code"/>`, "x := 1")
+
+	parts := map[string]string{
+		"word/styles.xml": `<w:styles ` + wordNS + `>` +
+			`<w:style w:type="character" w:styleId="Emphasis"><w:name w:val="Emphasis"/><w:rPr><w:i/></w:rPr></w:style>` +
+			`<w:style w:type="paragraph" w:styleId="Code"><w:name w:val="HTML Preformatted"/></w:style>` +
+			`</w:styles>`,
+	}
+	data := buildDocx(t, body, parts)
+	doc, err := Read(context.Background(), bytes.NewReader(data), int64(len(data)), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Body) != 3 {
+		t.Fatalf("unexpected body %v", doc.Body)
+	}
+
+	expected := []structure.Span{
+		{Text: "Plain "},
+		{Text: "bold too", Format: structure.Format{Bold: true}},
+		{Text: " em", Format: structure.Format{Italic: true}},
+		{Text: " x", Format: structure.Format{Code: true}},
+	}
+	if fmt.Sprint(doc.Body[0].Spans) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, doc.Body[0].Spans)
+	}
+
+	expected = []structure.Span{
+		{Text: "site", Format: structure.Format{Link: "https://example.com"}},
+		{Text: "top", Format: structure.Format{Link: "#top"}},
+	}
+	if fmt.Sprint(doc.Body[1].Spans) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, doc.Body[1].Spans)
+	}
+
+	if doc.Body[2].Kind != structure.Code || doc.Body[2].Text != "x := 1" {
+		t.Errorf("unexpected code block %+v", doc.Body[2])
+	}
+}
@@ -0,0 +1,421 @@
+package docx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pilinux/totext/internal/structure"
+	"github.com/pilinux/totext/internal/xmltree"
+)
+
+// parser reads the parts of the document
+type parser struct {
+	// d decodes the current part
+	d *xml.Decoder
+	// reject rejects the tracked changes instead of accepting them
+	reject bool
+
+	styles map[string]*style
+	// charStyles are the character styles by id
+	charStyles map[string]*style
+	numbering  *numbering
+	// defaultStyle is the id of the default paragraph style
+	defaultStyle string
+
+	// links maps the relationship ids of the current part to the
+	// targets of its hyperlinks
+	links map[string]string
+
+	// footnotes and endnotes map the note ids to their numbers
+	footnotes, endnotes map[string]int
+	// headers and footers are the relationship ids of the
+	// headers and footers referenced by the sections
+	headers, footers []string
+}
+
+// paragraphProps are the properties of a paragraph used for its structure
+type paragraphProps struct {
+	style string
+	// numID and ilvl select the list level of a numbered paragraph
+	numID, ilvl       string
+	hasNum, hasIlvl   bool
+	outline           int
+	hasOutline        bool
+	inserted, deleted bool
+}
+
+// content is the content of a paragraph
+type content struct {
+	props paragraphProps
+	text  structure.Builder
+	// extra holds the blocks of the text boxes of the paragraph
+	extra []structure.Block
+}
+
+// part parses the part of the file, fn reads the content of its root element
+func (p *parser) part(f *zip.File, fn func() error) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrMalformed, f.Name, err)
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	p.d = xml.NewDecoder(rc)
+	for {
+		tok, err := p.d.Token()
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrMalformed, f.Name, err)
+		}
+		if _, ok := tok.(xml.StartElement); ok {
+			break
+		}
+	}
+	if err = fn(); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrMalformed, f.Name, err)
+	}
+
+	return nil
+}
+
+// blocks reads the paragraphs and the tables up to the end of the
+// current element
+func (p *parser) blocks() ([]structure.Block, error) {
+	var blocks []structure.Block
+	// merged holds the text of the paragraphs whose marks are removed
+	// by the tracked changes, they are merged with the next paragraph
+	var merged structure.Builder
+
+	err := xmltree.Children(p.d, func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "p":
+			var c content
+			err := xmltree.Children(p.d, func(t xml.StartElement) error {
+				return p.inline(&c, t)
+			})
+			if err != nil {
+				return err
+			}
+
+			merged.Append(&c.text)
+			if p.removed(c.props.inserted, c.props.deleted) {
+				blocks = append(blocks, c.extra...)
+				return nil
+			}
+			if b, ok := p.block(c.props, &merged); ok {
+				blocks = append(blocks, b)
+			}
+			merged.Reset()
+			blocks = append(blocks, c.extra...)
+		case "tbl":
+			b := structure.Block{Kind: structure.Table}
+			if err := p.rows(&b); err != nil {
+				return err
+			}
+			if len(b.Rows) > 0 {
+				blocks = append(blocks, b)
+			}
+		case "body", "sdt", "sdtContent", "customXml":
+			children, err := p.blocks()
+			if err != nil {
+				return err
+			}
+			blocks = append(blocks, children...)
+		case "sectPr":
+			return p.section()
+		default:
+			return p.d.Skip()
+		}
+		return nil
+	})
+
+	return blocks, err
+}
+
+// removed reports whether the tracked change removes the content
+func (p *parser) removed(inserted, deleted bool) bool {
+	if p.reject {
+		return inserted
+	}
+	return deleted
+}
+
+// block returns the block of the paragraph, false if it has no text
+func (p *parser) block(props paragraphProps, text *structure.Builder) (structure.Block, bool) {
+	b := structure.Block{Kind: structure.Paragraph, Text: text.String(), Spans: text.Spans()}
+
+	// Direct properties override those of the style
+	id := props.style
+	if id == "" {
+		id = p.defaultStyle
+	}
+	s := p.resolveStyle(id)
+	if props.hasOutline {
+		s.outline = props.outline
+	}
+	if props.hasNum {
+		s.numID = props.numID
+	}
+	if props.hasIlvl {
+		s.ilvl = props.ilvl
+	}
+
+	// Numbered paragraphs without text still take a number
+	ilvl, _ := strconv.Atoi(s.ilvl)
+	ilvl = min(max(ilvl, 0), maxLevels-1)
+	label, numbered := p.numbering.next(s.numID, ilvl)
+
+	switch {
+	case s.outline >= 0 && s.outline < maxLevels:
+		b.Kind = structure.Heading
+		b.Level = s.outline + 1
+		b.Label = label
+	case numbered:
+		b.Kind = structure.ListItem
+		b.Level = ilvl
+		b.Label = label
+	case s.code:
+		b.Kind = structure.Code
+	}
+
+	return b, strings.TrimSpace(b.Text) != ""
+}
+
+// rows reads the rows of a table into b
+func (p *parser) rows(b *structure.Block) error {
+	return xmltree.Children(p.d, func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "tr":
+			var row []structure.Cell
+			var inserted, deleted bool
+			err := p.cells(&row, &inserted, &deleted)
+			if err != nil {
+				return err
+			}
+			if !p.removed(inserted, deleted) {
+				b.Rows = append(b.Rows, row)
+			}
+			return nil
+		case "sdt", "sdtContent", "customXml":
+			return p.rows(b)
+		default:
+			return p.d.Skip()
+		}
+	})
+}
+
+// cells reads the cells of a table row into row
+func (p *parser) cells(row *[]structure.Cell, inserted, deleted *bool) error {
+	return xmltree.Children(p.d, func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "tc":
+			blocks, err := p.blocks()
+			if err != nil {
+				return err
+			}
+			*row = append(*row, blocks)
+			return nil
+		case "trPr":
+			return p.changes(inserted, deleted)
+		case "sdt", "sdtContent", "customXml":
+			return p.cells(row, inserted, deleted)
+		default:
+			return p.d.Skip()
+		}
+	})
+}
+
+// changes reads the tracked insertion and deletion marks
+// of the current properties element
+func (p *parser) changes(inserted, deleted *bool) error {
+	return xmltree.Children(p.d, func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "ins":
+			*inserted = true
+		case "del":
+			*deleted = true
+		}
+		return p.d.Skip()
+	})
+}
+
+// paragraphProps reads the properties of a paragraph into props
+func (p *parser) paragraphProps(props *paragraphProps) error {
+	return xmltree.Children(p.d, func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "pStyle":
+			props.style = xmltree.Attr(t, "val")
+		case "numPr":
+			return p.numPr(&props.numID, &props.ilvl, &props.hasNum, &props.hasIlvl)
+		case "outlineLvl":
+			props.outline, _ = strconv.Atoi(xmltree.Attr(t, "val"))
+			props.hasOutline = true
+		case "rPr":
+			// The paragraph mark may be inserted or deleted
+			return p.changes(&props.inserted, &props.deleted)
+		case "sectPr":
+			return p.section()
+		}
+		return p.d.Skip()
+	})
+}
+
+// numPr reads the numbering properties of a paragraph or of a style
+func (p *parser) numPr(numID, ilvl *string, hasNum, hasIlvl *bool) error {
+	return xmltree.Children(p.d, func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "numId":
+			*numID, *hasNum = xmltree.Attr(t, "val"), true
+		case "ilvl":
+			*ilvl, *hasIlvl = xmltree.Attr(t, "val"), true
+		}
+		return p.d.Skip()
+	})
+}
+
+// section reads the header and footer references of a section
+func (p *parser) section() error {
+	return xmltree.Children(p.d, func(t xml.StartElement) error {
+		var refs *[]string
+		switch t.Name.Local {
+		case "headerReference":
+			refs = &p.headers
+		case "footerReference":
+			refs = &p.footers
+		default:
+			return p.d.Skip()
+		}
+
+		id := xmltree.Attr(t, "id")
+		for _, ref := range *refs {
+			if ref == id {
+				return p.d.Skip()
+			}
+		}
+		*refs = append(*refs, id)
+		return p.d.Skip()
+	})
+}
+
+// inline reads the element of a paragraph into c
+func (p *parser) inline(c *content, t xml.StartElement) error {
+	switch t.Name.Local {
+	case "pPr":
+		return p.paragraphProps(&c.props)
+	case "t":
+		return p.text(c)
+	case "delText":
+		if p.reject {
+			return p.text(c)
+		}
+	case "tab", "ptab":
+		_ = c.text.WriteByte('\t')
+	case "br", "cr":
+		_ = c.text.WriteByte('\n')
+	case "noBreakHyphen":
+		_ = c.text.WriteByte('-')
+	case "sym":
+		if r, err := strconv.ParseUint(xmltree.Attr(t, "char"), 16, 32); err == nil && !structure.IsPrivate(rune(r)) {
+			_, _ = c.text.WriteRune(rune(r))
+		}
+	case "r":
+		// The properties of the run apply to its content
+		format := c.text.Format
+		defer func() {
+			c.text.Format = format
+		}()
+		return xmltree.Children(p.d, func(t xml.StartElement) error {
+			if t.Name.Local == "rPr" {
+				return p.runProps(&c.text.Format)
+			}
+			return p.inline(c, t)
+		})
+	case "hyperlink":
+		format := c.text.Format
+		defer func() {
+			c.text.Format = format
+		}()
+		if link := p.links[xmltree.Attr(t, "id")]; link != "" {
+			c.text.Format.Link = link
+		} else if anchor := xmltree.Attr(t, "anchor"); anchor != "" {
+			c.text.Format.Link = "#" + anchor
+		}
+		return xmltree.Children(p.d, func(t xml.StartElement) error {
+			return p.inline(c, t)
+		})
+	case "footnoteReference":
+		_, _ = fmt.Fprintf(&c.text, "[%d]", noteNumber(p.footnotes, xmltree.Attr(t, "id")))
+	case "endnoteReference":
+		_, _ = fmt.Fprintf(&c.text, "[%d]", noteNumber(p.endnotes, xmltree.Attr(t, "id")))
+	case "ins", "moveTo":
+		if !p.reject {
+			return xmltree.Children(p.d, func(t xml.StartElement) error {
+				return p.inline(c, t)
+			})
+		}
+	case "del", "moveFrom":
+		if p.reject {
+			return xmltree.Children(p.d, func(t xml.StartElement) error {
+				return p.inline(c, t)
+			})
+		}
+	case "txbxContent":
+		blocks, err := p.blocks()
+		c.extra = append(c.extra, blocks...)
+		return err
+	case "rPr", "instrText", "delInstrText", "fldData", "Fallback":
+		// Field codes are not part of the text and the fallback
+		// of alternate content repeats the chosen content
+	default:
+		// Runs, hyperlinks, fields, content controls, drawings, ...
+		return xmltree.Children(p.d, func(t xml.StartElement) error {
+			return p.inline(c, t)
+		})
+	}
+
+	return p.d.Skip()
+}
+
+// text reads the character data of the current text element into c
+func (p *parser) text(c *content) error {
+	return xmltree.Walk(p.d, func(xml.StartElement) error {
+		return p.d.Skip()
+	}, func(data xml.CharData) {
+		_, _ = c.text.Write(data)
+	})
+}
+
+// runProps reads the properties of a run which make its format
+func (p *parser) runProps(format *structure.Format) error {
+	return xmltree.Children(p.d, func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "rStyle":
+			p.charFormat(xmltree.Attr(t, "val"), format)
+		case "b":
+			format.Bold = toggle(t)
+		case "i":
+			format.Italic = toggle(t)
+		case "strike", "dstrike":
+			format.Strike = toggle(t)
+		case "rFonts":
+			if font := xmltree.Attr(t, "ascii"); font != "" {
+				format.Code = structure.Monospace(font)
+			}
+		}
+		return p.d.Skip()
+	})
+}
+
+// toggle returns the value of a toggle property, which is on unless
+// its value is false
+func toggle(t xml.StartElement) bool {
+	switch xmltree.Attr(t, "val") {
+	case "0", "false", "off":
+		return false
+	}
+	return true
+}
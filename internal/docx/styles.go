@@ -0,0 +1,328 @@
+package docx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pilinux/totext/internal/structure"
+	"github.com/pilinux/totext/internal/xmltree"
+)
+
+// maxLevels is the number of outline and list levels
+const maxLevels = 9
+
+// style holds the properties of a paragraph style used for the
+// structure of the document, or of a character style used for
+// the format of the runs
+type style struct {
+	name    string
+	basedOn string
+	props   paragraphProps
+	format  structure.Format
+}
+
+// codeStyles are the names of the styles of preformatted text or code
+var codeStyles = map[string]bool{
+	"html preformatted": true,
+	"html code":         true,
+	"html typewriter":   true,
+	"preformatted text": true,
+	"source code":       true,
+	"source text":       true,
+	"verbatim char":     true,
+	"code":              true,
+}
+
+// resolved are the properties of a paragraph style
+// after following the styles it is based on
+type resolved struct {
+	// outline is the outline level, -1 for body text
+	outline int
+	// numID and ilvl select the list level of the style
+	numID, ilvl string
+	// code is set for the styles of preformatted text
+	code bool
+}
+
+// readStyles reads the paragraph and the character styles of the styles part
+func (p *parser) readStyles() error {
+	return xmltree.Children(p.d, func(t xml.StartElement) error {
+		if t.Name.Local != "style" {
+			return p.d.Skip()
+		}
+
+		s := &style{}
+		id := xmltree.Attr(t, "styleId")
+		switch xmltree.Attr(t, "type") {
+		case "paragraph":
+			if xmltree.Attr(t, "default") == "1" || xmltree.Attr(t, "default") == "true" {
+				p.defaultStyle = id
+			}
+			p.styles[id] = s
+		case "character":
+			p.charStyles[id] = s
+		default:
+			return p.d.Skip()
+		}
+
+		return xmltree.Children(p.d, func(t xml.StartElement) error {
+			switch t.Name.Local {
+			case "name":
+				s.name = xmltree.Attr(t, "val")
+			case "basedOn":
+				s.basedOn = xmltree.Attr(t, "val")
+			case "pPr":
+				return p.paragraphProps(&s.props)
+			case "rPr":
+				return p.runProps(&s.format)
+			}
+			return p.d.Skip()
+		})
+	})
+}
+
+// charFormat applies the format of the character style with the id
+// and of the styles it is based on to format
+func (p *parser) charFormat(id string, format *structure.Format) {
+	// The chain of styles is limited in case it loops
+	for depth := 0; depth < 16; depth++ {
+		s, ok := p.charStyles[id]
+		if !ok {
+			break
+		}
+		format.Bold = format.Bold || s.format.Bold
+		format.Italic = format.Italic || s.format.Italic
+		format.Strike = format.Strike || s.format.Strike
+		format.Code = format.Code || s.format.Code || codeStyles[strings.ToLower(s.name)]
+		id = s.basedOn
+	}
+}
+
+// resolveStyle returns the properties of the paragraph style with the id
+func (p *parser) resolveStyle(id string) resolved {
+	r := resolved{outline: -1}
+	var hasOutline, hasNum, hasIlvl bool
+
+	// The chain of styles is limited in case it loops
+	for depth := 0; depth < 16; depth++ {
+		s, ok := p.styles[id]
+		if !ok {
+			break
+		}
+		if !hasOutline && s.props.hasOutline {
+			r.outline, hasOutline = s.props.outline, true
+		}
+		if !hasOutline {
+			// Built-in heading styles are named in English in every language
+			name := strings.ToLower(s.name)
+			var level int
+			if _, err := fmt.Sscanf(name, "heading %d", &level); err == nil && level > 0 {
+				r.outline, hasOutline = level-1, true
+			} else if name == "title" {
+				r.outline, hasOutline = 0, true
+			}
+		}
+		if !hasNum && s.props.hasNum {
+			r.numID, hasNum = s.props.numID, true
+		}
+		r.code = r.code || codeStyles[strings.ToLower(s.name)]
+		if !hasIlvl && s.props.hasIlvl {
+			r.ilvl, hasIlvl = s.props.ilvl, true
+		}
+		id = s.basedOn
+	}
+
+	return r
+}
+
+// level is a level of a list definition
+type level struct {
+	start  int
+	format string
+	// text is the template of the label, e.g. "%1.%2."
+	text string
+}
+
+// num is a list instance, it refers to an abstract list definition
+type num struct {
+	abstractID string
+	// starts are the overridden start values of the levels
+	starts map[int]int
+}
+
+// counter holds the current numbers of the levels of a list
+type counter struct {
+	values  [maxLevels]int
+	started [maxLevels]bool
+}
+
+// numbering holds the list definitions of the numbering part
+// and the counters of the lists
+type numbering struct {
+	abstracts map[string]*[maxLevels]*level
+	nums      map[string]*num
+	counters  map[string]*counter
+}
+
+// newNumbering returns empty list definitions
+func newNumbering() *numbering {
+	return &numbering{
+		abstracts: make(map[string]*[maxLevels]*level),
+		nums:      make(map[string]*num),
+		counters:  make(map[string]*counter),
+	}
+}
+
+// read returns a function which reads the list definitions
+// of the numbering part
+func (n *numbering) read(p *parser) func() error {
+	return func() error {
+		return xmltree.Children(p.d, func(t xml.StartElement) error {
+			switch t.Name.Local {
+			case "abstractNum":
+				levels := new([maxLevels]*level)
+				n.abstracts[xmltree.Attr(t, "abstractNumId")] = levels
+				return xmltree.Children(p.d, func(t xml.StartElement) error {
+					if t.Name.Local != "lvl" {
+						return p.d.Skip()
+					}
+					ilvl, err := strconv.Atoi(xmltree.Attr(t, "ilvl"))
+					if err != nil || ilvl < 0 || ilvl >= maxLevels {
+						return p.d.Skip()
+					}
+					levels[ilvl] = &level{start: 1, format: "decimal"}
+					return n.readLevel(p, levels[ilvl])
+				})
+			case "num":
+				nm := &num{starts: make(map[int]int)}
+				n.nums[xmltree.Attr(t, "numId")] = nm
+				return xmltree.Children(p.d, func(t xml.StartElement) error {
+					switch t.Name.Local {
+					case "abstractNumId":
+						nm.abstractID = xmltree.Attr(t, "val")
+					case "lvlOverride":
+						ilvl, _ := strconv.Atoi(xmltree.Attr(t, "ilvl"))
+						return xmltree.Children(p.d, func(t xml.StartElement) error {
+							if t.Name.Local == "startOverride" {
+								nm.starts[ilvl], _ = strconv.Atoi(xmltree.Attr(t, "val"))
+							}
+							return p.d.Skip()
+						})
+					}
+					return p.d.Skip()
+				})
+			}
+			return p.d.Skip()
+		})
+	}
+}
+
+// readLevel reads the level definition of a list
+func (n *numbering) readLevel(p *parser, lv *level) error {
+	return xmltree.Children(p.d, func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "start":
+			lv.start, _ = strconv.Atoi(xmltree.Attr(t, "val"))
+		case "numFmt":
+			lv.format = xmltree.Attr(t, "val")
+		case "lvlText":
+			lv.text = xmltree.Attr(t, "val")
+		}
+		return p.d.Skip()
+	})
+}
+
+// next advances the counter of the list level and returns the label of
+// the list item, false if the paragraph is not numbered
+func (n *numbering) next(numID string, ilvl int) (string, bool) {
+	nm, ok := n.nums[numID]
+	if !ok || numID == "0" {
+		return "", false
+	}
+	levels := n.abstracts[nm.abstractID]
+	if levels == nil || levels[ilvl] == nil {
+		return "", true
+	}
+
+	// Lists without overrides continue the lists of the same definition
+	key := "abstract:" + nm.abstractID
+	if len(nm.starts) > 0 {
+		key = "num:" + numID
+	}
+	c, ok := n.counters[key]
+	if !ok {
+		c = &counter{}
+		n.counters[key] = c
+	}
+
+	start := func(i int) int {
+		if s, ok := nm.starts[i]; ok {
+			return s
+		}
+		if levels[i] != nil {
+			return levels[i].start
+		}
+		return 1
+	}
+
+	if c.started[ilvl] {
+		c.values[ilvl]++
+	} else {
+		c.values[ilvl], c.started[ilvl] = start(ilvl), true
+	}
+	// The deeper levels start again
+	for i := ilvl + 1; i < maxLevels; i++ {
+		c.started[i] = false
+	}
+
+	lv := levels[ilvl]
+	if lv.format == "bullet" {
+		return structure.Bullet(lv.text), true
+	}
+
+	// Replace the placeholders of the levels, e.g. %1
+	var label strings.Builder
+	for i := 0; i < len(lv.text); i++ {
+		if lv.text[i] != '%' || i+1 >= len(lv.text) || lv.text[i+1] < '1' || lv.text[i+1] > '9' {
+			label.WriteByte(lv.text[i])
+			continue
+		}
+		j := int(lv.text[i+1] - '1')
+		i++
+
+		value := start(j)
+		if c.started[j] {
+			value = c.values[j]
+		}
+		format := "decimal"
+		if levels[j] != nil {
+			format = levels[j].format
+		}
+		label.WriteString(formatNumber(value, format))
+	}
+
+	return label.String(), true
+}
+
+// formatNumber formats the number of a list level
+func formatNumber(n int, format string) string {
+	switch format {
+	case "none":
+		return ""
+	case "decimalZero":
+		return fmt.Sprintf("%02d", n)
+	case "lowerLetter":
+		return structure.Letters(n, 'a')
+	case "upperLetter":
+		return structure.Letters(n, 'A')
+	case "lowerRoman":
+		return strings.ToLower(structure.Roman(n))
+	case "upperRoman":
+		return structure.Roman(n)
+	case "ordinal":
+		return structure.Ordinal(n)
+	}
+	return strconv.Itoa(n)
+}
@@ -0,0 +1,466 @@
+// Package epub reads the chapters and the metadata of EPUB e-books.
+//
+// An EPUB is a zip archive. META-INF/container.xml points to the OPF
+// package document, whose manifest lists the files of the book, whose
+// spine orders the XHTML chapters and whose metadata holds the Dublin
+// Core elements. The titles of the chapters are read from the table of
+// contents, the navigation document of EPUB 3 or the NCX of EPUB 2.
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/pilinux/totext/internal/charset"
+)
+
+var (
+	// ErrNotEPUB is returned when the content is not an EPUB
+	ErrNotEPUB = errors.New("not an EPUB")
+
+	// ErrEncrypted is returned when the chapters are encrypted,
+	// e.g. with DRM
+	ErrEncrypted = errors.New("encrypted EPUB")
+
+	// ErrMalformed is returned when the EPUB cannot be parsed
+	ErrMalformed = errors.New("malformed EPUB")
+)
+
+const (
+	// maxPartSize limits the size of the files read into memory
+	maxPartSize = 64 << 20
+
+	// dcNamespace is the namespace of the Dublin Core elements
+	dcNamespace = "http://purl.org/dc/elements/1.1/"
+
+	// fontObfuscation are the algorithms which obfuscate the fonts,
+	// the other encrypted files cannot be read
+	fontObfuscation = "http://www.idpf.org/2008/embedding http://ns.adobe.com/pdf/enc#RC"
+)
+
+// Book is an EPUB e-book
+type Book struct {
+	// Metadata holds the Dublin Core elements by their local name, e.g.
+	// title or creator, in document order. The modification date is
+	// stored as modified and the meta elements with a name, e.g. the
+	// generator, by their name.
+	Metadata map[string][]string
+	// Identifier is the unique identifier of the book
+	Identifier string
+	// Chapters are the XHTML documents of the spine in reading order
+	Chapters []Chapter
+}
+
+// Chapter is an XHTML document of the spine
+type Chapter struct {
+	// Path is the path of the document in the archive
+	Path string
+	// Title is the first title of the document in the table
+	// of contents, empty if it is not listed
+	Title string
+	// Content is the XHTML document
+	Content []byte
+}
+
+// container is META-INF/container.xml
+type container struct {
+	Rootfiles []struct {
+		FullPath  string `xml:"full-path,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+// opfPackage is the OPF package document
+type opfPackage struct {
+	UniqueIdentifier string `xml:"unique-identifier,attr"`
+	Metadata         struct {
+		Elements []opfElement `xml:",any"`
+	} `xml:"metadata"`
+	Manifest []opfItem `xml:"manifest>item"`
+	Spine    struct {
+		Toc      string `xml:"toc,attr"`
+		Itemrefs []struct {
+			IDRef string `xml:"idref,attr"`
+		} `xml:"itemref"`
+	} `xml:"spine"`
+}
+
+// opfElement is an element of the metadata of the package
+type opfElement struct {
+	XMLName  xml.Name
+	ID       string `xml:"id,attr"`
+	Event    string `xml:"event,attr"`
+	Property string `xml:"property,attr"`
+	Name     string `xml:"name,attr"`
+	Content  string `xml:"content,attr"`
+	Value    string `xml:",chardata"`
+}
+
+// opfItem is an item of the manifest
+type opfItem struct {
+	ID         string `xml:"id,attr"`
+	Href       string `xml:"href,attr"`
+	MediaType  string `xml:"media-type,attr"`
+	Properties string `xml:"properties,attr"`
+}
+
+// ncxPoint is a navigation point of the NCX
+type ncxPoint struct {
+	Label   string `xml:"navLabel>text"`
+	Content struct {
+		Src string `xml:"src,attr"`
+	} `xml:"content"`
+	Points []ncxPoint `xml:"navPoint"`
+}
+
+// encryption is META-INF/encryption.xml
+type encryption struct {
+	Data []struct {
+		Method struct {
+			Algorithm string `xml:"Algorithm,attr"`
+		} `xml:"EncryptionMethod"`
+		Reference struct {
+			URI string `xml:"URI,attr"`
+		} `xml:"CipherData>CipherReference"`
+	} `xml:"EncryptedData"`
+}
+
+// Read reads the EPUB of the given size
+func Read(ctx context.Context, ra io.ReaderAt, size int64) (*Book, error) {
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotEPUB, err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	// The container points to the package document
+	var c container
+	if files["META-INF/container.xml"] == nil {
+		return nil, fmt.Errorf("%w: missing META-INF/container.xml", ErrNotEPUB)
+	}
+	if err := readXML(files, "META-INF/container.xml", &c); err != nil {
+		return nil, err
+	}
+	// The first package document, or else the first rootfile
+	opfPath := ""
+	for i, rootfile := range c.Rootfiles {
+		if i == 0 || rootfile.MediaType == "application/oebps-package+xml" {
+			opfPath = rootfile.FullPath
+		}
+		if rootfile.MediaType == "application/oebps-package+xml" {
+			break
+		}
+	}
+	if opfPath = resolve(".", opfPath); opfPath == "" {
+		return nil, fmt.Errorf("%w: missing package document", ErrMalformed)
+	}
+
+	var p opfPackage
+	if err := readXML(files, opfPath, &p); err != nil {
+		return nil, err
+	}
+	dir := path.Dir(opfPath)
+
+	book := &Book{Metadata: make(map[string][]string)}
+	book.metadata(&p)
+
+	encrypted, err := encryptedFiles(files)
+	if err != nil {
+		return nil, err
+	}
+
+	// The chapters in the order of the spine
+	items := make(map[string]opfItem, len(p.Manifest))
+	for _, item := range p.Manifest {
+		items[item.ID] = item
+	}
+	for _, itemref := range p.Spine.Itemrefs {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		item, ok := items[itemref.IDRef]
+		if !ok || !isXHTML(item.MediaType) {
+			continue
+		}
+		// The files missing from the archive are skipped
+		name := resolve(dir, item.Href)
+		if files[name] == nil {
+			continue
+		}
+		if encrypted[name] {
+			return nil, fmt.Errorf("%w: %s", ErrEncrypted, name)
+		}
+		content, err := readFile(files, name)
+		if err != nil {
+			return nil, err
+		}
+		book.Chapters = append(book.Chapters, Chapter{Path: name, Content: content})
+	}
+
+	// The titles of the table of contents, the navigation
+	// document takes precedence over the NCX
+	titles := make(map[string]string)
+	for _, item := range p.Manifest {
+		if hasProperty(item.Properties, "nav") {
+			name := resolve(dir, item.Href)
+			if content, err := readFile(files, name); err == nil {
+				navTitles(content, path.Dir(name), titles)
+			}
+			break
+		}
+	}
+	if len(titles) == 0 {
+		for _, item := range p.Manifest {
+			if item.ID == p.Spine.Toc || p.Spine.Toc == "" && item.MediaType == "application/x-dtbncx+xml" {
+				name := resolve(dir, item.Href)
+				var ncx struct {
+					Points []ncxPoint `xml:"navMap>navPoint"`
+				}
+				if err := readXML(files, name, &ncx); err == nil {
+					ncxTitles(ncx.Points, path.Dir(name), titles)
+				}
+				break
+			}
+		}
+	}
+	for i := range book.Chapters {
+		book.Chapters[i].Title = titles[book.Chapters[i].Path]
+	}
+
+	return book, nil
+}
+
+// metadata reads the metadata of the package
+func (b *Book) metadata(p *opfPackage) {
+	for _, e := range p.Metadata.Elements {
+		value := strings.Join(strings.Fields(e.Value), " ")
+		switch {
+		case e.XMLName.Space == dcNamespace:
+			if value == "" {
+				continue
+			}
+			key := e.XMLName.Local
+			// The dates of EPUB 2 have an event
+			if key == "date" && strings.EqualFold(e.Event, "modification") {
+				key = "modified"
+			}
+			b.Metadata[key] = append(b.Metadata[key], value)
+			if key == "identifier" && e.ID != "" && e.ID == p.UniqueIdentifier {
+				b.Identifier = value
+			}
+		case e.XMLName.Local == "meta" && e.Property == "dcterms:modified" && value != "":
+			b.Metadata["modified"] = append(b.Metadata["modified"], value)
+		case e.XMLName.Local == "meta" && e.Name != "" && strings.TrimSpace(e.Content) != "":
+			b.Metadata[e.Name] = append(b.Metadata[e.Name], strings.TrimSpace(e.Content))
+		}
+	}
+
+	if b.Identifier == "" && len(b.Metadata["identifier"]) > 0 {
+		b.Identifier = b.Metadata["identifier"][0]
+	}
+}
+
+// encryptedFiles returns the files listed in META-INF/encryption.xml
+// which are encrypted with an algorithm other than font obfuscation
+func encryptedFiles(files map[string]*zip.File) (map[string]bool, error) {
+	encrypted := make(map[string]bool)
+	if files["META-INF/encryption.xml"] == nil {
+		return encrypted, nil
+	}
+
+	var e encryption
+	if err := readXML(files, "META-INF/encryption.xml", &e); err != nil {
+		return nil, err
+	}
+	for _, data := range e.Data {
+		if data.Method.Algorithm != "" && strings.Contains(fontObfuscation, data.Method.Algorithm) {
+			continue
+		}
+		encrypted[resolve(".", data.Reference.URI)] = true
+	}
+	return encrypted, nil
+}
+
+// navTitles reads the titles of the table of contents of the navigation
+// document, the hrefs are relative to dir
+func navTitles(content []byte, dir string, titles map[string]string) {
+	doc, err := html.Parse(bytes.NewReader(content))
+	if err != nil {
+		return
+	}
+
+	// The nav element of the table of contents, or else the first one
+	var toc, first *html.Node
+	var find func(n *html.Node)
+	find = func(n *html.Node) {
+		if n.DataAtom == atom.Nav {
+			if first == nil {
+				first = n
+			}
+			if toc == nil && hasProperty(attr(n, "epub:type"), "toc") {
+				toc = n
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			find(c)
+		}
+	}
+	find(doc)
+	if toc == nil {
+		toc = first
+	}
+	if toc == nil {
+		return
+	}
+
+	var links func(n *html.Node)
+	links = func(n *html.Node) {
+		if n.DataAtom == atom.A {
+			addTitle(titles, resolve(dir, attr(n, "href")), text(n))
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			links(c)
+		}
+	}
+	links(toc)
+}
+
+// ncxTitles reads the titles of the navigation points of the NCX
+// in document order, the sources are relative to dir
+func ncxTitles(points []ncxPoint, dir string, titles map[string]string) {
+	for _, point := range points {
+		addTitle(titles, resolve(dir, point.Content.Src), point.Label)
+		ncxTitles(point.Points, dir, titles)
+	}
+}
+
+// addTitle records the title of the file unless it has one
+func addTitle(titles map[string]string, name, title string) {
+	title = strings.Join(strings.Fields(title), " ")
+	if name == "" || title == "" || titles[name] != "" {
+		return
+	}
+	titles[name] = title
+}
+
+// readXML decodes the XML file of the archive into v
+func readXML(files map[string]*zip.File, name string, v any) error {
+	content, err := readFile(files, name)
+	if err != nil {
+		return err
+	}
+
+	d := xml.NewDecoder(bytes.NewReader(content))
+	d.Strict = false
+	d.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		data, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		decoded, err := charset.Decode(data, charset.Name(label))
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(decoded), nil
+	}
+	if err := d.Decode(v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrMalformed, name, err)
+	}
+	return nil
+}
+
+// readFile returns the content of the file of the archive
+func readFile(files map[string]*zip.File, name string) ([]byte, error) {
+	f := files[name]
+	if f == nil {
+		return nil, fmt.Errorf("%w: missing %s", ErrMalformed, name)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrMalformed, name, err)
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	content, err := io.ReadAll(io.LimitReader(rc, maxPartSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrMalformed, name, err)
+	}
+	if len(content) > maxPartSize {
+		return nil, fmt.Errorf("%w: %s is too large", ErrMalformed, name)
+	}
+	return content, nil
+}
+
+// resolve returns the path in the archive of the href relative to dir,
+// without its fragment
+func resolve(dir, href string) string {
+	href, _, _ = strings.Cut(href, "#")
+	if unescaped, err := url.PathUnescape(href); err == nil {
+		href = unescaped
+	}
+	if strings.TrimSpace(href) == "" {
+		return ""
+	}
+	return strings.TrimPrefix(path.Join(dir, href), "/")
+}
+
+// isXHTML reports whether the media type is the one of XHTML
+// or HTML documents
+func isXHTML(mediaType string) bool {
+	switch strings.ToLower(strings.TrimSpace(mediaType)) {
+	case "application/xhtml+xml", "text/html":
+		return true
+	}
+	return false
+}
+
+// hasProperty reports whether the space separated list has the property
+func hasProperty(list, property string) bool {
+	for _, p := range strings.Fields(list) {
+		if p == property {
+			return true
+		}
+	}
+	return false
+}
+
+// attr returns the value of the attribute of the element n
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// text returns the text of the node and its children
+func text(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(text(c))
+	}
+	return sb.String()
+}
@@ -0,0 +1,198 @@
+package epub
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/pilinux/totext/internal/fixture"
+)
+
+// testContainer points to the package document OEBPS/content.opf
+const testContainer = `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`
+
+// chapter returns an XHTML document with the body
+func chapter(body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?><html xmlns="http://www.w3.org/1999/xhtml"><head><title>Ignored</title></head>` +
+		`<body>` + body + `</body></html>`
+}
+
+// buildEPUB3 returns an EPUB 3 with a navigation document and the chapters
+// in the reverse order of their names
+func buildEPUB3(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+
+	entries := map[string]string{
+		"mimetype":               "application/epub+zip",
+		"META-INF/container.xml": testContainer,
+		"OEBPS/content.opf": `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="uid">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <dc:identifier id="isbn">urn:isbn:9780000000001</dc:identifier>
+  <dc:identifier id="uid">urn:uuid:1234</dc:identifier>
+  <dc:title>The  Book</dc:title>
+  <dc:creator id="a1">Jane Doe</dc:creator>
+  <dc:creator>John Roe</dc:creator>
+  <dc:language>en</dc:language>
+  <dc:publisher>Press</dc:publisher>
+  <dc:date>2020-05-01</dc:date>
+  <dc:subject>Fiction</dc:subject>
+  <meta property="dcterms:modified">2021-01-02T03:04:05Z</meta>
+  <meta refines="#a1" property="role">aut</meta>
+  <meta name="generator" content="Writer 1.0"/>
+</metadata>
+<manifest>
+  <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+  <item id="c1" href="text/chapter%201.xhtml" media-type="application/xhtml+xml"/>
+  <item id="c2" href="text/b.xhtml" media-type="application/xhtml+xml"/>
+  <item id="c3" href="text/gone.xhtml" media-type="application/xhtml+xml"/>
+  <item id="img" href="cover.jpg" media-type="image/jpeg"/>
+</manifest>
+<spine><itemref idref="c2"/><itemref idref="img"/><itemref idref="c1"/><itemref idref="c3"/><itemref idref="missing"/></spine>
+</package>`,
+		"OEBPS/nav.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops"><body>` +
+			`<nav epub:type="landmarks"><ol><li><a href="text/b.xhtml">Start</a></li></ol></nav>` +
+			`<nav epub:type="toc"><ol><li><a href="text/b.xhtml#top">Opening   <em>words</em></a>` +
+			`<ol><li><a href="text/b.xhtml#s2">Section</a></li></ol></li>` +
+			`<li><a href="text/chapter%201.xhtml">Closing</a></li></ol></nav></body></html>`,
+		"OEBPS/text/b.xhtml":         chapter("<p>First</p>"),
+		"OEBPS/text/chapter 1.xhtml": chapter("<p>Last</p>"),
+	}
+	for name, content := range parts {
+		entries[name] = content
+	}
+
+	return fixture.Zip(t, entries)
+}
+
+// TestRead tests the chapters and the metadata of an EPUB 3
+func TestRead(t *testing.T) {
+	data := buildEPUB3(t, nil)
+	book, err := Read(context.Background(), bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Error reading EPUB: %s", err)
+	}
+
+	// Test data
+	testData := []struct {
+		path  string
+		title string
+		body  string
+	}{
+		{"OEBPS/text/b.xhtml", "Opening words", "First"},
+		{"OEBPS/text/chapter 1.xhtml", "Closing", "Last"},
+	}
+
+	if len(book.Chapters) != len(testData) {
+		t.Fatalf("Expected %d chapters, got %d", len(testData), len(book.Chapters))
+	}
+
+	// Iterate over test data
+	for i, td := range testData {
+		c := book.Chapters[i]
+		if c.Path != td.path || c.Title != td.title || !bytes.Contains(c.Content, []byte(td.body)) {
+			t.Errorf("Expected chapter %s %q with %s, got %s %q with %s", td.path, td.title, td.body, c.Path, c.Title, c.Content)
+		}
+	}
+
+	expected := map[string][]string{
+		"identifier": {"urn:isbn:9780000000001", "urn:uuid:1234"},
+		"title":      {"The Book"},
+		"creator":    {"Jane Doe", "John Roe"},
+		"language":   {"en"},
+		"publisher":  {"Press"},
+		"date":       {"2020-05-01"},
+		"subject":    {"Fiction"},
+		"modified":   {"2021-01-02T03:04:05Z"},
+		"generator":  {"Writer 1.0"},
+	}
+	if fmt.Sprint(book.Metadata) != fmt.Sprint(expected) {
+		t.Errorf("Expected metadata %q, got %q", expected, book.Metadata)
+	}
+	if book.Identifier != "urn:uuid:1234" {
+		t.Errorf("Expected identifier urn:uuid:1234, got %q", book.Identifier)
+	}
+}
+
+// TestReadNCX tests the titles of the NCX and the metadata of an EPUB 2
+func TestReadNCX(t *testing.T) {
+	data := fixture.Zip(t, map[string]string{
+		"META-INF/container.xml": `<container><rootfiles><rootfile full-path="book.opf"/></rootfiles></container>`,
+		"book.opf": `<package xmlns="http://www.idpf.org/2007/opf" xmlns:opf="http://www.idpf.org/2007/opf" version="2.0">
+<metadata><dc-metadata xmlns:dc="http://purl.org/dc/elements/1.1/"></dc-metadata>
+  <dc:title xmlns:dc="http://purl.org/dc/elements/1.1/">Old Book</dc:title>
+  <dc:date xmlns:dc="http://purl.org/dc/elements/1.1/" opf:event="modification">2010-02-03</dc:date>
+</metadata>
+<manifest>
+  <item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+  <item id="a" href="a.html" media-type="text/html"/>
+  <item id="b" href="b.html" media-type="text/html"/>
+</manifest>
+<spine toc="ncx"><itemref idref="a"/><itemref idref="b"/></spine>
+</package>`,
+		"toc.ncx": `<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/"><navMap>` +
+			`<navPoint><navLabel><text>Part One</text></navLabel><content src="a.html"/>` +
+			`<navPoint><navLabel><text>Chapter B</text></navLabel><content src="b.html#c"/></navPoint></navPoint>` +
+			`</navMap></ncx>`,
+		"a.html": chapter("<p>A</p>"),
+		"b.html": chapter("<p>B</p>"),
+	})
+
+	book, err := Read(context.Background(), bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Error reading EPUB: %s", err)
+	}
+	if len(book.Chapters) != 2 || book.Chapters[0].Title != "Part One" || book.Chapters[1].Title != "Chapter B" {
+		t.Errorf("Unexpected chapters %+v", book.Chapters)
+	}
+	if book.Metadata["title"][0] != "Old Book" || book.Metadata["modified"][0] != "2010-02-03" {
+		t.Errorf("Unexpected metadata %q", book.Metadata)
+	}
+}
+
+// TestReadErrors tests the errors of invalid EPUBs
+func TestReadErrors(t *testing.T) {
+	// Test data
+	testData := []struct {
+		name     string
+		data     []byte
+		expected error
+	}{
+		{"plain text", []byte("plain text"), ErrNotEPUB},
+		{"no container", fixture.Zip(t, map[string]string{"mimetype": "application/epub+zip"}), ErrNotEPUB},
+		{"no package", fixture.Zip(t, map[string]string{"META-INF/container.xml": testContainer}), ErrMalformed},
+		{"malformed package", buildEPUB3(t, map[string]string{"OEBPS/content.opf": "<package"}), ErrMalformed},
+		{"encrypted", buildEPUB3(t, map[string]string{
+			"META-INF/encryption.xml": `<encryption xmlns="urn:oasis:names:tc:opendocument:xmlns:container" ` +
+				`xmlns:enc="http://www.w3.org/2001/04/xmlenc#"><enc:EncryptedData>` +
+				`<enc:EncryptionMethod Algorithm="http://www.w3.org/2001/04/xmlenc#aes128-cbc"/>` +
+				`<enc:CipherData><enc:CipherReference URI="OEBPS/text/b.xhtml"/></enc:CipherData>` +
+				`</enc:EncryptedData></encryption>`,
+		}), ErrEncrypted},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		_, err := Read(context.Background(), bytes.NewReader(td.data), int64(len(td.data)))
+		if !errors.Is(err, td.expected) {
+			t.Errorf("%s: expected %v, got %v", td.name, td.expected, err)
+		}
+	}
+
+	// Obfuscated fonts are not encrypted chapters
+	data := buildEPUB3(t, map[string]string{
+		"META-INF/encryption.xml": `<encryption xmlns="urn:oasis:names:tc:opendocument:xmlns:container" ` +
+			`xmlns:enc="http://www.w3.org/2001/04/xmlenc#"><enc:EncryptedData>` +
+			`<enc:EncryptionMethod Algorithm="http://www.idpf.org/2008/embedding"/>` +
+			`<enc:CipherData><enc:CipherReference URI="OEBPS/font.otf"/></enc:CipherData>` +
+			`</enc:EncryptedData></encryption>`,
+	})
+	if _, err := Read(context.Background(), bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Errorf("Error reading EPUB with obfuscated fonts: %s", err)
+	}
+}
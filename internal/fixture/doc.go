@@ -0,0 +1,185 @@
+package fixture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"unicode/utf16"
+)
+
+// Stream is a stream of a compound file
+type Stream struct {
+	Name string
+	Data []byte
+}
+
+// Special sector numbers of the compound files
+const (
+	freeSector   = 0xFFFFFFFF
+	endOfChain   = 0xFFFFFFFE
+	fatSector    = 0xFFFFFFFD
+	noStream     = 0xFFFFFFFF
+	sectorSize   = 512
+	miniCutoff   = 4096
+	entrySize    = 128
+	fatEntries   = sectorSize / 4
+	headerDifats = 109
+)
+
+// Compound returns a version 3 compound file with the streams in its
+// root storage. The streams are padded with zeros to 4096 bytes, so
+// that none of them is stored in the mini stream.
+func Compound(streams ...Stream) []byte {
+	le := binary.LittleEndian
+
+	// The sectors of the streams
+	var data [][]byte
+	streamSectors := 0
+	for _, s := range streams {
+		b := make([]byte, roundUp(max(len(s.Data), miniCutoff), sectorSize))
+		copy(b, s.Data)
+		data = append(data, b)
+		streamSectors += len(b) / sectorSize
+	}
+
+	// The FAT sectors, the directory sectors and the streams follow
+	// the header
+	dirSectors := roundUp((len(streams)+1)*entrySize, sectorSize) / sectorSize
+	fatSectors := 1
+	for fatSectors*fatEntries < fatSectors+dirSectors+streamSectors {
+		fatSectors++
+	}
+
+	header := make([]byte, sectorSize)
+	copy(header, "\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1")
+	le.PutUint16(header[24:], 0x3E)
+	le.PutUint16(header[26:], 3)
+	le.PutUint16(header[28:], 0xFFFE)
+	le.PutUint16(header[30:], 9)
+	le.PutUint16(header[32:], 6)
+	le.PutUint32(header[44:], uint32(fatSectors))
+	le.PutUint32(header[48:], uint32(fatSectors))
+	le.PutUint32(header[56:], miniCutoff)
+	le.PutUint32(header[60:], endOfChain)
+	le.PutUint32(header[68:], endOfChain)
+	for i := 0; i < headerDifats; i++ {
+		sector := uint32(freeSector)
+		if i < fatSectors {
+			sector = uint32(i)
+		}
+		le.PutUint32(header[76+4*i:], sector)
+	}
+
+	// Every chain is a run of consecutive sectors
+	fat := make([]byte, fatSectors*sectorSize)
+	for i := 0; i < len(fat); i += 4 {
+		le.PutUint32(fat[i:], freeSector)
+	}
+	for i := 0; i < fatSectors; i++ {
+		le.PutUint32(fat[4*i:], fatSector)
+	}
+	chain := func(start, n int) {
+		for i := start; i < start+n-1; i++ {
+			le.PutUint32(fat[4*i:], uint32(i+1))
+		}
+		le.PutUint32(fat[4*(start+n-1):], endOfChain)
+	}
+	chain(fatSectors, dirSectors)
+
+	// The root entry holds the streams as a chain of left siblings
+	dir := make([]byte, dirSectors*sectorSize)
+	entry := func(i int, name string, kind byte, child, left uint32, start, size int) {
+		e := dir[i*entrySize:]
+		units := utf16.Encode([]rune(name))
+		for j, u := range units {
+			le.PutUint16(e[2*j:], u)
+		}
+		le.PutUint16(e[64:], uint16(2*len(units)+2))
+		e[66], e[67] = kind, 1
+		le.PutUint32(e[68:], left)
+		le.PutUint32(e[72:], noStream)
+		le.PutUint32(e[76:], child)
+		le.PutUint32(e[116:], uint32(start))
+		le.PutUint32(e[120:], uint32(size))
+	}
+	root := uint32(noStream)
+	if len(streams) > 0 {
+		root = 1
+	}
+	entry(0, "Root Entry", 5, root, noStream, endOfChain, 0)
+	start := fatSectors + dirSectors
+	for i, s := range streams {
+		left := uint32(noStream)
+		if i+1 < len(streams) {
+			left = uint32(i + 2)
+		}
+		entry(i+1, s.Name, 2, noStream, left, start, len(data[i]))
+		chain(start, len(data[i])/sectorSize)
+		start += len(data[i]) / sectorSize
+	}
+	for i := len(streams) + 1; i < dirSectors*sectorSize/entrySize; i++ {
+		entry(i, "", 0, noStream, noStream, 0, 0)
+	}
+
+	return bytes.Join(append([][]byte{header, fat, dir}, data...), nil)
+}
+
+// WordStreams returns a WordDocument stream with the texts and the
+// Table stream with their piece table. Texts with non-ASCII runes are
+// stored as UTF-16, the others compressed.
+func WordStreams(flags uint16, ccpText int, texts ...string) (word, table []byte) {
+	le := binary.LittleEndian
+
+	// FibBase, 14 16-bit values, 22 32-bit values, 93 pairs
+	const rgFcLcb = 32 + 2 + 28 + 2 + 88 + 2
+	fib := make([]byte, rgFcLcb+93*8)
+	le.PutUint16(fib, 0xA5EC)
+	le.PutUint16(fib[10:], flags)
+	le.PutUint16(fib[32:], 14)
+	le.PutUint16(fib[62:], 22)
+	le.PutUint32(fib[64+12:], uint32(ccpText))
+	le.PutUint16(fib[152:], 93)
+
+	var stream bytes.Buffer
+	stream.Write(fib)
+
+	var cps, pcds bytes.Buffer
+	cp := 0
+	for _, text := range texts {
+		_ = binary.Write(&cps, le, uint32(cp))
+		units := utf16.Encode([]rune(text))
+		fc := uint32(stream.Len())
+		if len(units) == len(text) {
+			// 8-bit characters
+			stream.WriteString(text)
+			fc = fc*2 | 0x40000000
+		} else {
+			_ = binary.Write(&stream, le, units)
+		}
+		pcds.Write([]byte{0, 0})
+		_ = binary.Write(&pcds, le, fc)
+		pcds.Write([]byte{0, 0})
+		cp += len(units)
+	}
+	_ = binary.Write(&cps, le, uint32(cp))
+
+	// A property modifier precedes the piece table
+	table = []byte{0xFF, 0x01, 0x02, 0x00, 0xAA, 0xBB, 0x02}
+	table = le.AppendUint32(table, uint32(cps.Len()+pcds.Len()))
+	table = append(append(table, cps.Bytes()...), pcds.Bytes()...)
+
+	word = stream.Bytes()
+	le.PutUint32(word[rgFcLcb+33*8:], 1)
+	le.PutUint32(word[rgFcLcb+33*8+4:], uint32(len(table)-1))
+	return word, table
+}
+
+// Doc returns a Word 97 document with the texts, see WordStreams
+func Doc(texts ...string) []byte {
+	word, table := WordStreams(0, 0, texts...)
+	return Compound(Stream{"WordDocument", word}, Stream{"0Table", table})
+}
+
+// roundUp rounds n up to a multiple of m
+func roundUp(n, m int) int {
+	return (n + m - 1) / m * m
+}
@@ -0,0 +1,3 @@
+// Package fixture builds the documents used by the tests of the
+// converters, so that the tests do not depend on binary files.
+package fixture
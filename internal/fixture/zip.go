@@ -0,0 +1,45 @@
+package fixture
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"sort"
+	"testing"
+)
+
+// Zip returns a zip archive with the entries, the entries of the later
+// maps replace those of the earlier ones with the same name. The entries
+// are written in the order of their names.
+func Zip[T string | []byte](t testing.TB, entries ...map[string]T) []byte {
+	t.Helper()
+
+	merged := make(map[string]T)
+	for _, e := range entries {
+		for name, content := range e {
+			merged[name] = content
+		}
+	}
+	names := make([]string, 0, len(merged))
+	for name := range merged {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = io.WriteString(w, string(merged[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
@@ -0,0 +1,88 @@
+//go:build ignore
+
+// gen builds the trigram profiles of profiles.txt from a corpus of
+// general text, a directory with a <code>.txt file of sentences for
+// every language, e.g. the sentences of the Leipzig Corpora Collection:
+//
+//	go run gen.go -corpus /path/to/sentences
+//
+// or go generate with the directory in $CORPUS.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pilinux/totext/internal/lang"
+)
+
+// languages are the languages of the trigram profiles, those sharing
+// a script with other languages and Greek for the charset detection
+var languages = []string{
+	"ar", "bg", "ca", "cs", "da", "de", "el", "en", "es", "et", "fa",
+	"fi", "fr", "hi", "hr", "hu", "id", "it", "lt", "lv", "mr", "nb",
+	"nl", "pl", "pt", "ro", "ru", "sk", "sl", "sr", "sv", "sw", "tl",
+	"tr", "uk", "ur", "vi",
+}
+
+// maxText is the size of the text read for a language
+const maxText = 4 << 20
+
+func main() {
+	corpus := flag.String("corpus", "", "directory of the <code>.txt files")
+	out := flag.String("o", "profiles.txt", "output file")
+	flag.Parse()
+	if *corpus == "" {
+		log.Fatal("missing -corpus directory")
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	w := bufio.NewWriter(f)
+	fmt.Fprintln(w, "# Trigram profiles built by gen.go from a corpus of general text, do not edit.")
+	fmt.Fprintln(w, "# A line holds the language code and its most frequent trigrams in order,")
+	fmt.Fprintln(w, "# _ marks the start and the end of the words.")
+
+	for _, code := range languages {
+		text, err := readText(filepath.Join(*corpus, code+".txt"))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		trigrams := lang.Profile(text, lang.ProfileSize)
+		for i, t := range trigrams {
+			trigrams[i] = strings.ReplaceAll(t, " ", "_")
+		}
+		fmt.Fprintln(w, code, strings.Join(trigrams, " "))
+		log.Printf("%s: %d bytes of text", code, len(text))
+	}
+
+	if err = w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+	if err = f.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// readText reads the start of the text of the file
+func readText(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxText))
+	return string(data), err
+}
@@ -0,0 +1,287 @@
+// Package lang detects the language of a text from its script and
+// the trigrams of its words.
+//
+// The languages written in a script of their own are detected from the
+// script alone. The languages sharing a script are told apart with the
+// trigram profiles of profiles.txt, built by gen.go from a corpus of
+// general text, and the out-of-place distance of Cavnar and Trenkle.
+package lang
+
+import (
+	_ "embed"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+//go:generate go run gen.go -corpus $CORPUS
+
+// ProfileSize is the number of trigrams of the profiles
+const ProfileSize = 1000
+
+// minLetters is the number of letters from which the confidence
+// is not lowered for the length of the text
+const minLetters = 100
+
+//go:embed profiles.txt
+var profilesText string
+
+// profile is the trigram profile of a language
+type profile struct {
+	code   string
+	script *unicode.RangeTable
+	// ranks maps the trigrams to their ranks
+	ranks map[string]int
+}
+
+var (
+	profilesOnce sync.Once
+	profiles     []profile
+)
+
+// scripts are the scripts of the languages, in the order of preference
+// for the letters belonging to several of them
+var scripts = []*unicode.RangeTable{
+	unicode.Latin, unicode.Cyrillic, unicode.Arabic, unicode.Devanagari,
+	unicode.Greek, unicode.Hebrew, unicode.Thai, unicode.Hangul,
+	unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Georgian,
+	unicode.Armenian, unicode.Bengali, unicode.Tamil, unicode.Telugu,
+	unicode.Gujarati, unicode.Gurmukhi, unicode.Kannada, unicode.Malayalam,
+}
+
+// scriptLanguages maps the scripts written in a single language to it
+var scriptLanguages = map[*unicode.RangeTable]string{
+	unicode.Greek:     "el",
+	unicode.Hebrew:    "he",
+	unicode.Thai:      "th",
+	unicode.Hangul:    "ko",
+	unicode.Georgian:  "ka",
+	unicode.Armenian:  "hy",
+	unicode.Bengali:   "bn",
+	unicode.Tamil:     "ta",
+	unicode.Telugu:    "te",
+	unicode.Gujarati:  "gu",
+	unicode.Gurmukhi:  "pa",
+	unicode.Kannada:   "kn",
+	unicode.Malayalam: "ml",
+}
+
+// Languages returns the codes of the languages which can be detected
+func Languages() []string {
+	codes := []string{"ja", "zh"}
+	for _, code := range scriptLanguages {
+		codes = append(codes, code)
+	}
+	for _, p := range loadProfiles() {
+		codes = append(codes, p.code)
+	}
+	slices.Sort(codes)
+	return slices.Compact(codes)
+}
+
+// Detect returns the ISO 639-1 code of the language of the text and
+// the confidence of the detection between 0 and 1. The code is empty
+// if the text has no letters of a known language.
+func Detect(text string) (code string, confidence float64) {
+	// Count the letters of every script
+	counts := make(map[*unicode.RangeTable]int)
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if s := scriptOf(r); s != nil {
+			counts[s]++
+		}
+	}
+
+	// Japanese is written with the kana and the Han characters
+	kana := counts[unicode.Hiragana] + counts[unicode.Katakana]
+	cjk := kana + counts[unicode.Han]
+	var script *unicode.RangeTable
+	n := 0
+	for _, s := range scripts {
+		if s == unicode.Han || s == unicode.Hiragana || s == unicode.Katakana {
+			if cjk > n {
+				script, n = unicode.Han, cjk
+			}
+			continue
+		}
+		if counts[s] > n {
+			script, n = s, counts[s]
+		}
+	}
+	if script == nil {
+		return "", 0
+	}
+
+	// Share of the letters in the script and length of the text,
+	// the Han characters and the Hangul syllables count for several letters
+	length := float64(n)
+	if script == unicode.Han || script == unicode.Hangul {
+		length *= 3
+	}
+	confidence = float64(n) / float64(letters) * min(length/minLetters, 1)
+
+	switch {
+	case script == unicode.Han && kana*10 >= cjk:
+		return "ja", confidence
+	case script == unicode.Han:
+		return "zh", confidence
+	case scriptLanguages[script] != "":
+		return scriptLanguages[script], confidence
+	}
+
+	code, distance := closest(text, script)
+	return code, confidence * distance
+}
+
+// closest returns the language of the script whose profile is the
+// closest to the profile of the text, with a score between 0 and 1
+// of how much closer it is than the other languages
+func closest(text string, script *unicode.RangeTable) (string, float64) {
+	trigrams := Profile(text, ProfileSize)
+	if len(trigrams) == 0 {
+		return "", 0
+	}
+
+	profiles := loadProfiles()
+	best, second := -1, -1
+	distances := make([]int, len(profiles))
+	for i, p := range profiles {
+		if p.script != script {
+			continue
+		}
+		for rank, t := range trigrams {
+			if r, ok := p.ranks[t]; ok {
+				distances[i] += abs(rank - r)
+			} else {
+				distances[i] += ProfileSize
+			}
+		}
+		switch {
+		case best < 0 || distances[i] < distances[best]:
+			best, second = i, best
+		case second < 0 || distances[i] < distances[second]:
+			second = i
+		}
+	}
+	if best < 0 {
+		return "", 0
+	}
+	if second < 0 {
+		return profiles[best].code, 1
+	}
+
+	// The margin is relative to the distance of the second language
+	d1, d2 := float64(distances[best]), float64(distances[second])
+	return profiles[best].code, min((d2-d1)/d2*20, 1)
+}
+
+// Match returns the share of the trigrams of the text which are in the
+// profile of the language closest to it, between 0 and 1. It tells how
+// much the text looks like a known language.
+func Match(text string) float64 {
+	trigrams := Profile(text, ProfileSize)
+	if len(trigrams) == 0 {
+		return 0
+	}
+
+	best := 0
+	for _, p := range loadProfiles() {
+		n := 0
+		for _, t := range trigrams {
+			if _, ok := p.ranks[t]; ok {
+				n++
+			}
+		}
+		best = max(best, n)
+	}
+	return float64(best) / float64(len(trigrams))
+}
+
+// Profile returns the n most frequent trigrams of the words of the
+// text in order, the words start and end with a space
+func Profile(text string, n int) []string {
+	counts := make(map[string]int)
+	var word []rune
+	add := func() {
+		if len(word) == 0 {
+			return
+		}
+		w := append(append([]rune{' '}, word...), ' ')
+		for i := 0; i+3 <= len(w); i++ {
+			counts[string(w[i:i+3])]++
+		}
+		word = word[:0]
+	}
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.In(r, unicode.Mn, unicode.Mc) {
+			word = append(word, unicode.ToLower(r))
+			continue
+		}
+		add()
+	}
+	add()
+
+	trigrams := make([]string, 0, len(counts))
+	for t := range counts {
+		trigrams = append(trigrams, t)
+	}
+	// Ties are ordered to be deterministic
+	slices.SortFunc(trigrams, func(a, b string) int {
+		if counts[a] != counts[b] {
+			return counts[b] - counts[a]
+		}
+		return strings.Compare(a, b)
+	})
+
+	return trigrams[:min(n, len(trigrams))]
+}
+
+// loadProfiles parses the embedded profiles once
+func loadProfiles() []profile {
+	profilesOnce.Do(func() {
+		for line := range strings.Lines(profilesText) {
+			fields := strings.Fields(line)
+			if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+				continue
+			}
+			p := profile{code: fields[0], ranks: make(map[string]int, len(fields)-1)}
+			for rank, t := range fields[1:] {
+				t = strings.ReplaceAll(t, "_", " ")
+				p.ranks[t] = rank
+				if p.script == nil {
+					for _, r := range t {
+						if s := scriptOf(r); s != nil {
+							p.script = s
+							break
+						}
+					}
+				}
+			}
+			profiles = append(profiles, p)
+		}
+	})
+	return profiles
+}
+
+// scriptOf returns the script of the letter r, nil if it is unknown
+func scriptOf(r rune) *unicode.RangeTable {
+	for _, s := range scripts {
+		if unicode.Is(s, r) {
+			return s
+		}
+	}
+	return nil
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,181 @@
+package lang
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestDetect tests the detection of the first sentences of the
+// Universal Declaration of Human Rights
+func TestDetect(t *testing.T) {
+	// Test data
+	testData := []struct {
+		expected string
+		text     string
+	}{
+		{"en", "All human beings are born free and equal in dignity and rights. They are endowed with reason and conscience and should act towards one another in a spirit of brotherhood."},
+		{"de", "Alle Menschen sind frei und gleich an Würde und Rechten geboren. Sie sind mit Vernunft und Gewissen begabt und sollen einander im Geist der Brüderlichkeit begegnen."},
+		{"fr", "Tous les êtres humains naissent libres et égaux en dignité et en droits. Ils sont doués de raison et de conscience et doivent agir les uns envers les autres dans un esprit de fraternité."},
+		{"es", "Todos los seres humanos nacen libres e iguales en dignidad y derechos y, dotados como están de razón y conciencia, deben comportarse fraternalmente los unos con los otros."},
+		{"pt", "Todos os seres humanos nascem livres e iguais em dignidade e em direitos. Dotados de razão e de consciência, devem agir uns para com os outros em espírito de fraternidade."},
+		{"it", "Tutti gli esseri umani nascono liberi ed eguali in dignità e diritti. Essi sono dotati di ragione e di coscienza e devono agire gli uni verso gli altri in spirito di fratellanza."},
+		{"nl", "Alle mensen worden vrij en gelijk in waardigheid en rechten geboren. Zij zijn begiftigd met verstand en geweten, en behoren zich jegens elkander in een geest van broederschap te gedragen."},
+		{"sv", "Alla människor är födda fria och lika i värde och rättigheter. De har utrustats med förnuft och samvete och bör handla gentemot varandra i en anda av broderskap."},
+		{"da", "Alle mennesker er født frie og lige i værdighed og rettigheder. De er udstyret med fornuft og samvittighed, og de bør handle mod hverandre i en broderskabets ånd."},
+		{"nb", "Alle mennesker er født frie og med samme menneskeverd og menneskerettigheter. De er utstyrt med fornuft og samvittighet og bør handle mot hverandre i brorskapets ånd."},
+		{"fi", "Kaikki ihmiset syntyvät vapaina ja tasavertaisina arvoltaan ja oikeuksiltaan. Heille on annettu järki ja omatunto, ja heidän on toimittava toisiaan kohtaan veljeyden hengessä."},
+		{"pl", "Wszyscy ludzie rodzą się wolni i równi pod względem swej godności i swych praw. Są oni obdarzeni rozumem i sumieniem i powinni postępować wobec innych w duchu braterstwa."},
+		{"cs", "Všichni lidé rodí se svobodní a sobě rovní co do důstojnosti a práv. Jsou nadáni rozumem a svědomím a mají spolu jednat v duchu bratrství."},
+		{"sk", "Všetci ľudia sa rodia slobodní a sebe rovní, čo sa týka ich dôstojnosti a práv. Sú obdarení rozumom a majú navzájom jednať v bratskom duchu."},
+		{"hu", "Minden emberi lény szabadon születik és egyenlő méltósága és joga van. Az emberek, ésszel és lelkiismerettel bírván, egymással szemben testvéri szellemben kell hogy viseltessenek."},
+		{"ro", "Toate ființele umane se nasc libere și egale în demnitate și în drepturi. Ele sunt înzestrate cu rațiune și conștiință și trebuie să se comporte unele față de altele în spiritul fraternității."},
+		{"tr", "Bütün insanlar hür, haysiyet ve haklar bakımından eşit doğarlar. Akıl ve vicdana sahiptirler ve birbirlerine karşı kardeşlik zihniyeti ile hareket etmelidirler."},
+		{"id", "Semua orang dilahirkan merdeka dan mempunyai martabat dan hak-hak yang sama. Mereka dikaruniai akal dan hati nurani dan hendaknya bergaul satu sama lain dalam semangat persaudaraan."},
+		{"vi", "Tất cả mọi người sinh ra đều được tự do và bình đẳng về nhân phẩm và quyền lợi. Mọi con người đều được tạo hóa ban cho lý trí và lương tâm và cần phải đối xử với nhau trong tình bằng hữu."},
+		{"hr", "Sva ljudska bića rađaju se slobodna i jednaka u dostojanstvu i pravima. Ona su obdarena razumom i sviješću pa bi jedna prema drugima trebala postupati u duhu bratstva."},
+		{"sl", "Vsi ljudje se rodijo svobodni in imajo enako dostojanstvo in enake pravice. Obdarjeni so z razumom in vestjo in bi morali ravnati drug z drugim kakor bratje."},
+		{"et", "Kõik inimesed sünnivad vabadena ja võrdsetena oma väärikuselt ja õigustelt. Neile on antud mõistus ja südametunnistus ja nende suhtumist üksteisesse peab kandma vendluse vaim."},
+		{"lv", "Visi cilvēki piedzimst brīvi un vienlīdzīgi savā pašcieņā un tiesībās. Viņi ir apveltīti ar saprātu un sirdsapziņu, un viņiem jāizturas citam pret citu brālības garā."},
+		{"lt", "Visi žmonės gimsta laisvi ir lygūs savo orumu ir teisėmis. Jiems suteiktas protas ir sąžinė ir jie turi elgtis vienas kito atžvilgiu kaip broliai."},
+		{"ca", "Tots els éssers humans neixen lliures i iguals en dignitat i en drets. Són dotats de raó i de consciència, i han de comportar-se fraternalment els uns amb els altres."},
+		{"ru", "Все люди рождаются свободными и равными в своем достоинстве и правах. Они наделены разумом и совестью и должны поступать в отношении друг друга в духе братства."},
+		{"uk", "Всі люди народжуються вільними і рівними у своїй гідності та правах. Вони наділені розумом і совістю і повинні діяти у відношенні один до одного в дусі братерства."},
+		{"bg", "Всички хора се раждат свободни и равни по достойнство и права. Те са надарени с разум и съвест и следва да се отнасят помежду си в дух на братство."},
+		{"sr", "Сва људска бића рађају се слободна и једнака у достојанству и правима. Она су обдарена разумом и свешћу и треба једни према другима да поступају у духу братства."},
+		{"ar", "يولد جميع الناس أحرارًا متساوين في الكرامة والحقوق. وهم قد وهبوا العقل والوجدان وعليهم أن يعامل بعضهم بعضًا بروح الإخاء."},
+		{"fa", "تمام افراد بشر آزاد به دنیا می‌آیند و از لحاظ حیثیت و حقوق با هم برابرند. همه دارای عقل و وجدان هستند و باید نسبت به یکدیگر با روح برادری رفتار کنند."},
+		{"hi", "सभी मनुष्यों को गौरव और अधिकारों के मामले में जन्मजात स्वतन्त्रता और समानता प्राप्त है। उन्हें बुद्धि और अन्तरात्मा की देन प्राप्त है और परस्पर उन्हें भाईचारे के भाव से बर्ताव करना चाहिए।"},
+		{"mr", "सर्व मानवी व्यक्ति जन्मतःच स्वतंत्र आहेत व त्यांना समान प्रतिष्ठा व समान अधिकार आहेत. त्यांना विचारशक्ती व सदसद्विवेकबुद्धी लाभलेली आहे व त्यांनी एकमेकांशी बंधुत्वाच्या भावनेने आचरण करावे."},
+		{"el", "Όλοι οι άνθρωποι γεννιούνται ελεύθεροι και ίσοι στην αξιοπρέπεια και τα δικαιώματα."},
+		{"zh", "人人生而自由，在尊严和权利上一律平等。他们赋有理性和良心，并应以兄弟关系的精神相对待。"},
+		{"ja", "すべての人間は、生まれながらにして自由であり、かつ、尊厳と権利とについて平等である。"},
+		{"ko", "모든 인간은 태어날 때부터 자유로우며 그 존엄과 권리에 있어 동등하다."},
+		{"he", "כל בני אדם נולדו בני חורין ושווים בערכם ובזכויותיהם."},
+		{"th", "มนุษย์ทั้งหลายเกิดมามีอิสระและเสมอภาคกันในเกียรติศักดิ์และสิทธิ"},
+		{"", "1234 !?"},
+		{"", ""},
+	}
+
+	// Iterate over test data
+	for _, test := range testData {
+		code, confidence := Detect(test.text)
+		if code != test.expected {
+			t.Errorf("expected %q, got %q for %q", test.expected, code, test.text)
+		}
+		if confidence < 0 || confidence > 1 || (code == "") != (confidence == 0) {
+			t.Errorf("unexpected confidence %v of %q", confidence, test.text)
+		}
+	}
+}
+
+// TestDetectSentences tests the detection of a natural sentence
+// of every language
+func TestDetectSentences(t *testing.T) {
+	// Test data
+	testData := map[string]string{
+		"ar": "سأذهب غدا إلى السوق لشراء الخضار والفواكه مع أخي.",
+		"bg": "Утре сутринта ще отидем с приятели на планина, ако времето е хубаво.",
+		"bn": "কাল সকালে আমরা বন্ধুদের সাথে বাজারে যাব।",
+		"ca": "Demà al matí anirem a la platja amb els nens si fa bon temps.",
+		"cs": "Zítra ráno musím jít k doktorovi, takže přijdu do práce později.",
+		"da": "Vi skal have gæster i aften, så jeg må hellere gøre rent i køkkenet.",
+		"de": "Kannst du mir bitte sagen, wann der nächste Zug nach Hamburg fährt?",
+		"el": "Αύριο το πρωί θα πάμε στη θάλασσα με τους φίλους μας.",
+		"en": "I'm going to the supermarket after work, do you need anything?",
+		"es": "Mañana vamos a cenar en un restaurante nuevo cerca de la playa.",
+		"et": "Homme lähme sõpradega mere äärde, kui ilm on ilus.",
+		"fa": "فردا صبح با دوستانم به کوه می‌رویم اگر هوا خوب باشد.",
+		"fi": "Menemme huomenna mökille, jos sää on hyvä.",
+		"fr": "Nous avons passé un week-end très agréable chez mes grands-parents à la campagne.",
+		"gu": "કાલે સવારે અમે મિત્રો સાથે બજારમાં જઈશું.",
+		"he": "מחר בבוקר אנחנו נוסעים לים עם החברים שלנו.",
+		"hi": "कल सुबह हम अपने दोस्तों के साथ बाज़ार जाएंगे।",
+		"hr": "Sutra idemo na more s prijateljima ako bude lijepo vrijeme.",
+		"hu": "Holnap reggel korán kell kelnem, mert hosszú út vár rám.",
+		"hy": "Վաղը առավոտյան ընկերներիս հետ ծով ենք գնալու։",
+		"id": "Saya akan pergi ke pasar untuk membeli sayur dan buah besok pagi.",
+		"it": "Questa mattina ho perso l'autobus e sono arrivato in ufficio in ritardo.",
+		"ja": "明日の朝、友達と海に行きます。",
+		"ka": "ხვალ დილით მეგობრებთან ერთად ზღვაზე წავალთ.",
+		"kn": "ನಾಳೆ ಬೆಳಿಗ್ಗೆ ನಾವು ಸ್ನೇಹಿತರೊಂದಿಗೆ ಮಾರುಕಟ್ಟೆಗೆ ಹೋಗುತ್ತೇವೆ.",
+		"ko": "내일 아침에 친구들과 바다에 갈 거예요.",
+		"lt": "Rytoj su draugais važiuosime prie jūros, jei bus gražus oras.",
+		"lv": "Rīt mēs ar draugiem brauksim uz jūru, ja būs labs laiks.",
+		"ml": "നാളെ രാവിലെ ഞങ്ങൾ കൂട്ടുകാരോടൊപ്പം ചന്തയിലേക്ക് പോകും.",
+		"mr": "उद्या सकाळी आम्ही आमच्या मित्रांसोबत बाजारात जाणार आहोत.",
+		"nb": "Jeg har ikke hatt tid til å lese boka ennå, men jeg gleder meg til å begynne på den.",
+		"nl": "We gaan dit weekend met de kinderen naar het strand als het mooi weer is.",
+		"pa": "ਕੱਲ੍ਹ ਸਵੇਰੇ ਅਸੀਂ ਦੋਸਤਾਂ ਨਾਲ ਬਾਜ਼ਾਰ ਜਾਵਾਂਗੇ।",
+		"pl": "Wczoraj wieczorem oglądaliśmy z rodziną ciekawy film w telewizji.",
+		"pt": "Ontem à noite fomos ao cinema e depois jantamos com os nossos amigos.",
+		"ro": "Mâine dimineață plecăm la munte cu prietenii noștri.",
+		"ru": "Завтра утром мы поедем с друзьями на дачу, если будет хорошая погода.",
+		"sk": "Zajtra ráno musím ísť k lekárovi, takže prídem do práce neskôr.",
+		"sl": "Jutri gremo na morje s prijatelji, če bo lepo vreme.",
+		"sr": "Сутра ујутру идемо са пријатељима на море ако буде лепо време.",
+		"sv": "Jag brukar dricka kaffe på morgonen innan jag cyklar till jobbet.",
+		"sw": "Kesho asubuhi nitaenda sokoni kununua matunda na mboga.",
+		"ta": "நாளை காலை நாங்கள் நண்பர்களுடன் கடற்கரைக்குச் செல்வோம்.",
+		"te": "రేపు ఉదయం మేము స్నేహితులతో కలిసి బజారుకు వెళ్తాము.",
+		"th": "พรุ่งนี้เช้าเราจะไปทะเลกับเพื่อน",
+		"tl": "Pupunta kami sa palengke bukas ng umaga para bumili ng gulay.",
+		"tr": "Yarın sabah erkenden kalkıp işe gitmem gerekiyor.",
+		"uk": "Завтра вранці ми поїдемо з друзями на дачу, якщо буде гарна погода.",
+		"ur": "کل صبح ہم اپنے دوستوں کے ساتھ بازار جائیں گے۔",
+		"vi": "Hôm nay trời đẹp nên chúng tôi đi dạo trong công viên.",
+		"zh": "明天早上我们和朋友一起去海边。",
+	}
+
+	// Iterate over the languages
+	for _, code := range Languages() {
+		text, ok := testData[code]
+		if !ok {
+			t.Errorf("missing a sentence of %q", code)
+			continue
+		}
+		if got, _ := Detect(text); got != code {
+			t.Errorf("expected %q, got %q for %q", code, got, text)
+		}
+	}
+}
+
+// TestConfidence tests that longer texts are detected with more confidence
+func TestConfidence(t *testing.T) {
+	_, short := Detect("Das ist gut.")
+	_, long := Detect("Alle Menschen sind frei und gleich an Würde und Rechten geboren.")
+	if short >= long {
+		t.Errorf("expected less confidence for the short text, got %v and %v", short, long)
+	}
+}
+
+// TestLanguages tests that the most common languages can be detected
+func TestLanguages(t *testing.T) {
+	languages := Languages()
+	if len(languages) < 30 {
+		t.Errorf("expected at least 30 languages, got %d", len(languages))
+	}
+	for _, code := range []string{"en", "zh", "es", "hi", "ar", "pt", "ru", "ja", "de", "fr"} {
+		if !slices.Contains(languages, code) {
+			t.Errorf("missing language %q", code)
+		}
+	}
+}
+
+// TestMatch tests that text of a known language matches better than
+// the same text decoded with the wrong charset
+func TestMatch(t *testing.T) {
+	text := Match("Tous les êtres humains naissent libres et égaux en dignité et en droits.")
+	garbled := Match("Tous les Ãªtres humains naissent libres et Ã©gaux en dignitÃ© et en droits.")
+	if text <= garbled || text > 1 || garbled < 0 {
+		t.Errorf("expected a better match for the text, got %v and %v", text, garbled)
+	}
+}
+
+// TestProfile tests the order of the trigrams of a text
+func TestProfile(t *testing.T) {
+	expected := []string{" th", "he ", "the", " a ", " ca", "at ", "cat"}
+	got := Profile("The cat, the a.", 7)
+	if !slices.Equal(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
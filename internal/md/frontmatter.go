@@ -0,0 +1,162 @@
+package md
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// yamlField matches the key and the value of a YAML mapping entry
+var yamlField = regexp.MustCompile(`^([^\s:#'"][^:#]*?|"[^"]*"|'[^']*'):(?:[ \t]+(.*))?$`)
+
+// frontMatter reads the YAML front matter which starts the lines,
+// it returns its fields and its number of lines, 0 if there is none.
+//
+// The mappings of scalars, flow sequences, block sequences, block
+// scalars and nested mappings are read, the keys of the nested
+// mappings are joined with a dot, e.g. author.name.
+func frontMatter(lines []string) (map[string][]string, int) {
+	if len(lines) == 0 || strings.TrimRight(lines[0], " \t") != "---" {
+		return nil, 0
+	}
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if line := strings.TrimRight(lines[i], " \t"); line == "---" || line == "..." {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return nil, 0
+	}
+
+	fields := make(map[string][]string)
+	// key is the key of the current collection or block scalar
+	var key, style string
+	var block []string
+	endBlock := func() {
+		if style == "" {
+			return
+		}
+		sep := " "
+		if strings.HasPrefix(style, "|") {
+			sep = "\n"
+		}
+		fields[key] = []string{strings.TrimSpace(strings.Join(block, sep))}
+		style, block = "", nil
+	}
+
+	for _, line := range lines[1:end] {
+		trimmed := strings.TrimSpace(line)
+		indented := line != "" && (line[0] == ' ' || line[0] == '\t')
+
+		if style != "" && (indented || trimmed == "") {
+			block = append(block, trimmed)
+			continue
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if !indented {
+			endBlock()
+			m := yamlField.FindStringSubmatch(strings.TrimRight(line, " \t"))
+			if m == nil {
+				key = ""
+				continue
+			}
+			key = unquote(m[1])
+			value := strings.TrimSpace(m[2])
+			switch {
+			case value == "" || strings.HasPrefix(value, "#"):
+				// A collection follows
+			case strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">"):
+				style = value
+			case strings.HasPrefix(value, "["):
+				fields[key] = flowSequence(value)
+			default:
+				fields[key] = []string{scalar(value)}
+			}
+			continue
+		}
+
+		// The entries of the collection
+		if key == "" {
+			continue
+		}
+		if item, ok := strings.CutPrefix(trimmed, "-"); ok && (item == "" || item[0] == ' ' || item[0] == '\t') {
+			if item = scalar(strings.TrimSpace(item)); item != "" {
+				fields[key] = append(fields[key], item)
+			}
+		} else if m := yamlField.FindStringSubmatch(trimmed); m != nil {
+			if value := scalar(strings.TrimSpace(m[2])); value != "" {
+				sub := key + "." + unquote(m[1])
+				fields[sub] = append(fields[sub], value)
+			}
+		}
+	}
+	endBlock()
+
+	return fields, end + 1
+}
+
+// flowSequence returns the items of a flow sequence, e.g. [a, "b"]
+func flowSequence(value string) []string {
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	var items []string
+	for _, item := range splitFlow(value) {
+		if item = scalar(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// splitFlow splits the items of a flow sequence on the commas
+// which are not quoted
+func splitFlow(value string) []string {
+	var items []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			items = append(items, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, value[start:])
+}
+
+// scalar returns the value of a scalar without its quotes
+// and the comment which follows a plain scalar
+func scalar(value string) string {
+	if value == "" || value[0] == '"' || value[0] == '\'' {
+		return unquote(value)
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = value[:i]
+	}
+	return strings.TrimSpace(value)
+}
+
+// unquote returns the value of a quoted scalar, other values are
+// returned as is
+func unquote(value string) string {
+	switch {
+	case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+		if s, err := strconv.Unquote(value); err == nil {
+			return s
+		}
+		return value[1 : len(value)-1]
+	case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	}
+	return value
+}
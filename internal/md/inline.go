@@ -0,0 +1,258 @@
+package md
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	// autolink matches the URI and the email autolinks
+	autolink = regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9+.\-]{1,31}:[^\s<>]*|[^\s@<>\\]+@[^\s@<>\\]+)>`)
+	// htmlTag matches the inline HTML tags and comments
+	htmlTag = regexp.MustCompile(`^(?:</?[A-Za-z][A-Za-z0-9\-]*(?:\s[^<>]*)?/?>|<!--.*?-->)`)
+	// lineBreakTag matches the HTML line breaks
+	lineBreakTag = regexp.MustCompile(`(?i)^<br\s*/?>`)
+	// entity matches the HTML entities and numeric character references
+	entity = regexp.MustCompile(`^&(?:#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[A-Za-z][A-Za-z0-9]{1,31});`)
+)
+
+// piece is a piece of the text of inline Markdown
+type piece struct {
+	text string
+	// delim is the character of a run of emphasis delimiters,
+	// which can open or close the emphasis
+	delim       byte
+	open, close bool
+	// removed is set for the delimiters of an emphasis
+	removed bool
+}
+
+// inline returns the text of inline Markdown without its syntax,
+// refs are the labels of the link reference definitions
+func inline(s string, refs map[string]bool) string {
+	var pieces []piece
+	literal := func(text string) {
+		pieces = append(pieces, piece{text: text})
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && isPunct(s[i+1]):
+			literal(s[i+1 : i+2])
+			i += 2
+		case c == '`':
+			n := run(s, i, '`')
+			end := closingTicks(s, i+n, n)
+			if end < 0 {
+				literal(s[i : i+n])
+				i += n
+				continue
+			}
+			code := strings.ReplaceAll(s[i+n:end], "\n", " ")
+			if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+				code = code[1 : len(code)-1]
+			}
+			literal(code)
+			i = end + n
+		case c == '!' && strings.HasPrefix(s[i+1:], "["):
+			if text, end, ok := link(s, i+1, refs); ok {
+				literal(inline(text, refs))
+				i = end
+				continue
+			}
+			literal("!")
+			i++
+		case c == '[':
+			if text, end, ok := link(s, i, refs); ok {
+				literal(inline(text, refs))
+				i = end
+				continue
+			}
+			literal("[")
+			i++
+		case c == '<':
+			if m := autolink.FindStringSubmatch(s[i:]); m != nil {
+				literal(m[1])
+				i += len(m[0])
+			} else if m := htmlTag.FindString(s[i:]); m != "" {
+				if lineBreakTag.MatchString(m) {
+					literal("\n")
+				}
+				i += len(m)
+			} else {
+				literal("<")
+				i++
+			}
+		case c == '&':
+			if m := entity.FindString(s[i:]); m != "" {
+				literal(html.UnescapeString(m))
+				i += len(m)
+				continue
+			}
+			literal("&")
+			i++
+		case c == '*' || c == '_' || c == '~':
+			n := run(s, i, c)
+			prev, _ := utf8.DecodeLastRuneInString(s[:i])
+			next, _ := utf8.DecodeRuneInString(s[i+n:])
+			p := piece{text: s[i : i+n], delim: c, open: !isSpace(next), close: !isSpace(prev)}
+			switch c {
+			case '_':
+				// Underscores within words are not emphasis
+				p.open = p.open && !isWord(prev)
+				p.close = p.close && !isWord(next)
+			case '~':
+				// Strikethrough has one or two tildes
+				if n > 2 {
+					p.delim = 0
+				}
+			}
+			pieces = append(pieces, p)
+			i += n
+		default:
+			_, size := utf8.DecodeRuneInString(s[i:])
+			literal(s[i : i+size])
+			i += size
+		}
+	}
+
+	emphasis(pieces)
+
+	var sb strings.Builder
+	for _, p := range pieces {
+		if !p.removed {
+			sb.WriteString(p.text)
+		}
+	}
+	return sb.String()
+}
+
+// emphasis marks the delimiters of the emphasis and the strikethrough
+// as removed, an opening run is matched by the next closing run of the
+// same character
+func emphasis(pieces []piece) {
+	var openers []int
+	for i := range pieces {
+		p := &pieces[i]
+		if p.delim == 0 {
+			continue
+		}
+
+		if p.close {
+			matched := false
+			for k := len(openers) - 1; k >= 0; k-- {
+				o := &pieces[openers[k]]
+				if o.delim == p.delim && (p.delim != '~' || len(o.text) == len(p.text)) {
+					o.removed, p.removed = true, true
+					openers = openers[:k]
+					matched = true
+					break
+				}
+			}
+			if matched {
+				continue
+			}
+		}
+		if p.open {
+			openers = append(openers, i)
+		}
+	}
+}
+
+// link reads the link, the image or the footnote reference whose text
+// starts with the bracket at s[i], it returns its text and the end of
+// the link. Shortcut references are links if their label is defined.
+func link(s string, i int, refs map[string]bool) (text string, end int, ok bool) {
+	close := matching(s, i, '[', ']')
+	if close < 0 {
+		return "", 0, false
+	}
+	text, end = s[i+1:close], close+1
+
+	// Footnote references are kept as [label]
+	if strings.HasPrefix(text, "^") && !strings.ContainsAny(text, " \t") {
+		return "\\[" + text[1:] + "\\]", end, true
+	}
+
+	switch {
+	case strings.HasPrefix(s[end:], "("):
+		if dest := matching(s, end, '(', ')'); dest >= 0 {
+			return text, dest + 1, true
+		}
+	case strings.HasPrefix(s[end:], "["):
+		if label := matching(s, end, '[', ']'); label >= 0 {
+			return text, label + 1, true
+		}
+	}
+	if refs[strings.ToLower(strings.TrimSpace(text))] {
+		return text, end, true
+	}
+	return "", 0, false
+}
+
+// matching returns the index of the bracket which closes the bracket
+// at s[i], brackets escaped with a backslash are skipped
+func matching(s string, i int, open, close byte) int {
+	depth := 0
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case open:
+			depth++
+		case close:
+			if depth--; depth == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+// closingTicks returns the index of the run of n backticks from
+// the index i, -1 if there is none
+func closingTicks(s string, i, n int) int {
+	for i < len(s) {
+		j := strings.IndexByte(s[i:], '`')
+		if j < 0 {
+			return -1
+		}
+		i += j
+		m := run(s, i, '`')
+		if m == n {
+			return i
+		}
+		i += m
+	}
+	return -1
+}
+
+// run returns the length of the run of c at s[i]
+func run(s string, i int, c byte) int {
+	n := 0
+	for i+n < len(s) && s[i+n] == c {
+		n++
+	}
+	return n
+}
+
+// isPunct reports whether c is ASCII punctuation,
+// which can be escaped with a backslash
+func isPunct(c byte) bool {
+	return c < utf8.RuneSelf && unicode.IsPunct(rune(c)) || strings.IndexByte("$+<=>^`|~", c) >= 0
+}
+
+// isSpace reports whether r is white space, the start and the end
+// of the text count as white space
+func isSpace(r rune) bool {
+	return r == utf8.RuneError || unicode.IsSpace(r)
+}
+
+// isWord reports whether r is a letter or a digit
+func isWord(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
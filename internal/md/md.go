@@ -0,0 +1,261 @@
+// Package md reads the text of Markdown documents without their syntax.
+//
+// The headings, paragraphs, list items, block quotes, code and the cells
+// of GFM tables are read a line each. The link text, the image alt text
+// and the content of the code spans and blocks are kept, the emphasis,
+// the HTML tags, the link destinations and the reference definitions are
+// removed. The YAML front matter is read into fields.
+package md
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Document is a Markdown document
+type Document struct {
+	// Text is the text of the document without the Markdown syntax,
+	// a line per block
+	Text string
+	// Body is the Markdown of the document without the front matter
+	Body string
+	// FrontMatter holds the fields of the YAML front matter, the
+	// values of the lists are kept in order
+	FrontMatter map[string][]string
+}
+
+var (
+	// atxHeading matches the start of the ATX headings, e.g. ## Title
+	atxHeading = regexp.MustCompile(`^ {0,3}#{1,6}(?:[ \t]|$)`)
+	// closingHashes matches the optional closing sequence of ATX headings
+	closingHashes = regexp.MustCompile(`(?:^|[ \t]+)#+[ \t]*$`)
+	// thematicBreak matches the thematic breaks and the underlines of
+	// the setext headings
+	thematicBreak = regexp.MustCompile(`^ {0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,}|=+[ \t]*)$`)
+	// fence matches the opening fences of code blocks
+	fence = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})")
+	// listItem matches the markers of list items and task list items
+	listItem = regexp.MustCompile(`^[ \t]*(?:[-*+]|[0-9]{1,9}[.)])(?:[ \t]+\[[ xX]\])?(?:[ \t]+|$)`)
+	// definition matches the link reference definitions
+	definition = regexp.MustCompile(`^ {0,3}\[([^\]^][^\]]*)\]:[ \t]*\S`)
+	// footnote matches the footnote definitions
+	footnote = regexp.MustCompile(`^ {0,3}\[\^([^\]]+)\]:[ \t]*`)
+	// delimiterRow matches the delimiter rows of GFM tables
+	delimiterRow = regexp.MustCompile(`^[ \t]*\|?(?:[ \t]*:?-+:?[ \t]*\|)*[ \t]*:?-+:?[ \t]*\|?[ \t]*$`)
+)
+
+// reader holds the state of the blocks being read
+type reader struct {
+	refs  map[string]bool
+	lines []string
+	// paragraph holds the lines of the current paragraph
+	paragraph []string
+	// list is set within a list, where indented lines are not code
+	list bool
+}
+
+// Read reads the Markdown document
+func Read(src string) *Document {
+	src = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(src)
+	src = strings.TrimPrefix(src, "\uFEFF")
+
+	doc := &Document{Body: src}
+	lines := strings.Split(src, "\n")
+	if fields, n := frontMatter(lines); n > 0 {
+		doc.FrontMatter = fields
+		lines = lines[n:]
+		doc.Body = strings.Join(lines, "\n")
+	}
+
+	r := &reader{refs: references(lines)}
+	r.read(lines)
+	doc.Text = strings.Join(r.lines, "\n")
+	if doc.Text != "" {
+		doc.Text += "\n"
+	}
+
+	return doc
+}
+
+// read reads the blocks of the lines
+func (r *reader) read(lines []string) {
+	for i := 0; i < len(lines); i++ {
+		line := quoted(lines[i])
+
+		// Blank lines end the paragraphs
+		if strings.TrimSpace(line) == "" {
+			r.flush()
+			continue
+		}
+
+		// Fenced code blocks are kept as is
+		if m := fence.FindStringSubmatch(line); m != nil && (m[2][0] == '~' || !strings.Contains(line[len(m[0]):], "`")) {
+			r.flush()
+			i = r.code(lines, i+1, len(m[1]), m[2])
+			continue
+		}
+
+		indented := strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")
+		switch {
+		case indented && len(r.paragraph) == 0 && !r.list:
+			// Indented code blocks
+			r.add(strings.TrimPrefix(strings.TrimPrefix(line, "    "), "\t"))
+		case thematicBreak.MatchString(line):
+			// Thematic breaks and setext heading underlines
+			r.flush()
+		case atxHeading.MatchString(line):
+			r.flush()
+			text := strings.TrimLeft(strings.TrimSpace(line), "#")
+			r.add(strings.TrimSpace(inline(closingHashes.ReplaceAllString(text, ""), r.refs)))
+		case strings.HasPrefix(strings.TrimSpace(line), "<!--"):
+			r.flush()
+			i = comment(lines, i)
+		case footnote.MatchString(line):
+			r.flush()
+			m := footnote.FindStringSubmatch(line)
+			r.paragraph = append(r.paragraph, "["+m[1]+"] "+line[len(m[0]):])
+		case definition.MatchString(line) && len(r.paragraph) == 0:
+			// Link reference definitions
+		case strings.Contains(line, "|") && i+1 < len(lines) && delimiterRow.MatchString(quoted(lines[i+1])) &&
+			strings.Contains(lines[i+1], "|") && len(r.paragraph) == 0:
+			r.flush()
+			i = r.table(lines, i)
+		case listItem.MatchString(line):
+			r.flush()
+			r.list = true
+			r.paragraph = append(r.paragraph, line[len(listItem.FindString(line)):])
+		default:
+			if !indented && len(r.paragraph) == 0 {
+				r.list = false
+			}
+			r.paragraph = append(r.paragraph, line)
+		}
+	}
+	r.flush()
+}
+
+// add adds a line of text, empty lines are left out
+func (r *reader) add(line string) {
+	if line = strings.TrimRight(line, " \t"); strings.TrimSpace(line) != "" {
+		r.lines = append(r.lines, line)
+	}
+}
+
+// flush ends the current paragraph, its lines are joined with
+// spaces except at the hard line breaks
+func (r *reader) flush() {
+	if len(r.paragraph) == 0 {
+		return
+	}
+
+	var sb strings.Builder
+	for i, line := range r.paragraph {
+		hard := strings.HasSuffix(line, "  ") || strings.HasSuffix(line, "\\")
+		line = strings.TrimSpace(line)
+		if hard && strings.HasSuffix(line, "\\") {
+			line = strings.TrimSuffix(line, "\\")
+		}
+		sb.WriteString(line)
+		switch {
+		case i == len(r.paragraph)-1:
+		case hard:
+			sb.WriteString("\n")
+		default:
+			sb.WriteString(" ")
+		}
+	}
+	r.paragraph = r.paragraph[:0]
+
+	for line := range strings.SplitSeq(inline(sb.String(), r.refs), "\n") {
+		r.add(strings.TrimSpace(line))
+	}
+}
+
+// code adds the lines of the fenced code block which starts at line i
+// and returns the index of its closing fence. The indent of the opening
+// fence is removed from the lines.
+func (r *reader) code(lines []string, i, indent int, open string) int {
+	for ; i < len(lines); i++ {
+		line := quoted(lines[i])
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, open) && strings.Trim(trimmed, open[:1]) == "" {
+			return i
+		}
+		for j := 0; j < indent && strings.HasPrefix(line, " "); j++ {
+			line = line[1:]
+		}
+		r.add(line)
+	}
+	return i
+}
+
+// table adds the rows of the table whose header is at line i and
+// returns the index of its last row, the cells are separated with |
+func (r *reader) table(lines []string, i int) int {
+	r.add(strings.Join(cells(quoted(lines[i]), r.refs), " | "))
+	i += 2
+	for ; i < len(lines); i++ {
+		line := quoted(lines[i])
+		if strings.TrimSpace(line) == "" || !strings.Contains(line, "|") {
+			break
+		}
+		r.add(strings.Join(cells(line, r.refs), " | "))
+	}
+	return i - 1
+}
+
+// cells returns the text of the cells of a table row,
+// pipes escaped with a backslash are part of the cells
+func cells(row string, refs map[string]bool) []string {
+	row = strings.TrimSpace(row)
+	row = strings.TrimPrefix(row, "|")
+	if strings.HasSuffix(row, "|") && !strings.HasSuffix(row, "\\|") {
+		row = row[:len(row)-1]
+	}
+
+	var cells []string
+	start := 0
+	for i := 0; i <= len(row); i++ {
+		if i < len(row) && (row[i] != '|' || i > 0 && row[i-1] == '\\') {
+			continue
+		}
+		cell := strings.ReplaceAll(row[start:i], "\\|", "|")
+		cells = append(cells, strings.TrimSpace(inline(cell, refs)))
+		start = i + 1
+	}
+	return cells
+}
+
+// comment returns the index of the line which ends
+// the HTML comment starting at line i
+func comment(lines []string, i int) int {
+	for j := i; j < len(lines); j++ {
+		if strings.Contains(lines[j], "-->") {
+			return j
+		}
+	}
+	return len(lines) - 1
+}
+
+// quoted returns the line without the markers of block quotes
+func quoted(line string) string {
+	for {
+		trimmed := strings.TrimLeft(line, " ")
+		if len(line)-len(trimmed) > 3 || !strings.HasPrefix(trimmed, ">") {
+			return line
+		}
+		line = strings.TrimPrefix(trimmed[1:], " ")
+	}
+}
+
+// references returns the labels of the link reference definitions,
+// in lower case
+func references(lines []string) map[string]bool {
+	refs := make(map[string]bool)
+	for _, line := range lines {
+		if m := definition.FindStringSubmatch(quoted(line)); m != nil {
+			refs[strings.ToLower(strings.TrimSpace(m[1]))] = true
+		}
+	}
+	return refs
+}
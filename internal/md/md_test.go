@@ -0,0 +1,109 @@
+package md
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestRead tests the text of the blocks of Markdown documents
+func TestRead(t *testing.T) {
+	// Test data
+	testData := []struct {
+		name     string
+		src      string
+		expected string
+	}{
+		{"headings", "# Title #\n\nSetext\n======\n\n## Sub\n---\n", "Title\nSetext\nSub\n"},
+		{"paragraph", "Soft\nline  \nhard\\\nbreak\r\n", "Soft line\nhard\nbreak\n"},
+		{"lists", "- one\n- [x] done\n  continued\n    1. nested\n\n* * *\n", "one\ndone continued\nnested\n"},
+		{"quote", "> quoted **text**\n> > nested\n", "quoted text nested\n"},
+		{"fenced code", "```go\nfunc main() {\n\tprintln(\"*hi*\")\n}\n```\n~~~\n# not a heading\n~~~\n",
+			"func main() {\n\tprintln(\"*hi*\")\n}\n# not a heading\n"},
+		{"indented code", "text\n\n    code *kept*\n", "text\ncode *kept*\n"},
+		{"table", "| Name | Value |\n|------|------:|\n| a \\| b | **2** |\n| [x](y) | |\nafter\n", "Name | Value\na | b | 2\nx |\nafter\n"},
+		{"references", "See [ref] and [text][ref] and [unknown].\n\n[ref]: https://example.com \"title\"\n", "See ref and text and [unknown].\n"},
+		{"footnotes", "Note[^1].\n\n[^1]: The *note*.\n", "Note[1].\n[1] The note.\n"},
+		{"html", "<!-- comment\nlines -->\n<div>Text<br>break</div>\n", "Text\nbreak\n"},
+		{"empty", "", ""},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		doc := Read(td.src)
+		if doc.Text != td.expected {
+			t.Errorf("%s: expected %q, got %q", td.name, td.expected, doc.Text)
+		}
+		if doc.FrontMatter != nil {
+			t.Errorf("%s: unexpected front matter %v", td.name, doc.FrontMatter)
+		}
+	}
+}
+
+// TestInline tests removing the inline syntax
+func TestInline(t *testing.T) {
+	// Test data
+	testData := []struct {
+		src      string
+		expected string
+	}{
+		{"*em* _em_ **strong** __strong__ ***both***", "em em strong strong both"},
+		{"snake_case_name and 2 * 3 and a*b*c", "snake_case_name and 2 * 3 and abc"},
+		{"~~gone~~ ~one~ ~~~three", "gone one ~~~three"},
+		{"`code *span*` and `` a ` b `` and ` `` `", "code *span* and a ` b and ``"},
+		{"[link *text*](https://x.y (title)) ![alt](img.png)", "link text alt"},
+		{"<https://auto.link> <me@example.com> <span class=\"x\">tag</span>", "https://auto.link me@example.com tag"},
+		{"\\*not em\\* &amp; &copy; &#35; &unknown; AT&T", "*not em* & © # &unknown; AT&T"},
+		{"unclosed *em and [bracket", "unclosed *em and [bracket"},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		if got := inline(td.src, nil); got != td.expected {
+			t.Errorf("expected %q, got %q for %q", td.expected, got, td.src)
+		}
+	}
+}
+
+// TestFrontMatter tests reading the YAML front matter
+func TestFrontMatter(t *testing.T) {
+	src := `---
+title: "My: Notes"
+author:
+  - Jane Doe
+  - 'John O''Roe'
+tags: [go, "text, tools"]
+date: 2024-03-01 # published
+summary: >
+  A short
+  summary
+notes: |
+  line one
+  line two
+owner:
+  name: Ann
+---
+# Body
+`
+	doc := Read(src)
+
+	expected := map[string][]string{
+		"title":      {"My: Notes"},
+		"author":     {"Jane Doe", "John O'Roe"},
+		"tags":       {"go", "text, tools"},
+		"date":       {"2024-03-01"},
+		"summary":    {"A short summary"},
+		"notes":      {"line one\nline two"},
+		"owner.name": {"Ann"},
+	}
+	if fmt.Sprint(doc.FrontMatter) != fmt.Sprint(expected) {
+		t.Errorf("expected %q, got %q", expected, doc.FrontMatter)
+	}
+	if doc.Text != "Body\n" || doc.Body != "# Body\n" {
+		t.Errorf("unexpected text %q and body %q", doc.Text, doc.Body)
+	}
+
+	// A thematic break is not front matter without its end
+	if doc := Read("---\ntext\n"); doc.FrontMatter != nil || doc.Text != "text\n" {
+		t.Errorf("unexpected front matter %v of %q", doc.FrontMatter, doc.Text)
+	}
+}
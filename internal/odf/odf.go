@@ -0,0 +1,215 @@
+// Package odf reads the text and the structure of OpenDocument text
+// documents, e.g. odt files, odt templates and flat fodt files, and the
+// slides of OpenDocument presentations.
+//
+// A package is a zip archive with the metadata in meta.xml, the styles,
+// the page headers and footers in styles.xml and the text in content.xml.
+// A flat document holds the same elements in a single XML document.
+package odf
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pilinux/totext/internal/structure"
+	"github.com/pilinux/totext/internal/xmltree"
+)
+
+var (
+	// ErrNotODF is returned when the content is not an OpenDocument document
+	ErrNotODF = errors.New("not an OpenDocument document")
+
+	// ErrEncrypted is returned when the document is encrypted
+	ErrEncrypted = errors.New("encrypted OpenDocument document")
+
+	// ErrMalformed is returned when the document cannot be parsed
+	ErrMalformed = errors.New("malformed OpenDocument document")
+)
+
+// zipSignature starts the packages, the other documents are flat
+var zipSignature = []byte("PK\x03\x04")
+
+// maxManifestSize limits the size of the manifest read into memory
+const maxManifestSize = 16 << 20
+
+// Read reads the OpenDocument text document of the given size, either
+// a package or a flat XML document. The tracked changes are accepted,
+// or rejected if reject is set.
+func Read(ctx context.Context, ra io.ReaderAt, size int64, reject bool) (*structure.Document, error) {
+	p := newParser(reject)
+
+	signature := make([]byte, len(zipSignature))
+	if _, err := ra.ReadAt(signature, 0); err != nil || !bytes.Equal(signature, zipSignature) {
+		// Flat documents hold the metadata, the styles and the text
+		if err := p.part("flat document", io.NewSectionReader(ra, 0, size)); err != nil {
+			return nil, err
+		}
+		return p.doc, nil
+	}
+
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotODF, err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	if files["content.xml"] == nil {
+		return nil, fmt.Errorf("%w: missing content.xml", ErrNotODF)
+	}
+	if encrypted, err := IsEncrypted(files["META-INF/manifest.xml"]); err != nil || encrypted {
+		if err == nil {
+			err = ErrEncrypted
+		}
+		return nil, err
+	}
+
+	// The styles are needed by the content
+	for _, name := range []string{"meta.xml", "styles.xml", "content.xml"} {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		f := files[name]
+		if f == nil {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrMalformed, name, err)
+		}
+		err = p.part(name, rc)
+		_ = rc.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return p.doc, nil
+}
+
+// ReadMeta reads the metadata of the meta.xml part of a package,
+// e.g. of a spreadsheet or a presentation
+func ReadMeta(r io.Reader) (map[string]string, error) {
+	p := newParser(false)
+	if err := p.part("meta.xml", r); err != nil {
+		return nil, err
+	}
+	return p.doc.Properties, nil
+}
+
+// IsEncrypted reports whether the manifest file of a package lists
+// encrypted files, f may be nil when the package has no manifest
+func IsEncrypted(f *zip.File) (bool, error) {
+	if f == nil {
+		return false, nil
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return false, fmt.Errorf("%w: %s: %v", ErrMalformed, f.Name, err)
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+	manifest, err := io.ReadAll(io.LimitReader(rc, maxManifestSize))
+	if err != nil {
+		return false, fmt.Errorf("%w: %s: %v", ErrMalformed, f.Name, err)
+	}
+
+	return bytes.Contains(manifest, []byte("encryption-data")), nil
+}
+
+// part parses the XML document of a part, or of a flat document
+func (p *parser) part(name string, r io.Reader) error {
+	p.d = xml.NewDecoder(r)
+	for {
+		tok, err := p.d.Token()
+		if err != nil {
+			// Without a root element the content is not XML
+			return fmt.Errorf("%w: %s: %v", ErrNotODF, name, err)
+		}
+		if t, ok := tok.(xml.StartElement); ok {
+			if !strings.HasPrefix(t.Name.Local, "document") {
+				return fmt.Errorf("%w: %s: unknown root element %s", ErrNotODF, name, t.Name.Local)
+			}
+			break
+		}
+	}
+
+	if err := xmltree.Children(p.d, p.document); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrMalformed, name, err)
+	}
+
+	return nil
+}
+
+// document reads a child element of the root element of a part
+func (p *parser) document(t xml.StartElement) error {
+	switch t.Name.Local {
+	case "meta":
+		return p.meta()
+	case "styles", "automatic-styles":
+		return p.styles()
+	case "font-face-decls":
+		return p.fontFaces()
+	case "master-styles":
+		return p.masterStyles()
+	case "body":
+		blocks, err := p.blocks()
+		p.doc.Body = append(p.doc.Body, blocks...)
+		return err
+	}
+
+	return p.d.Skip()
+}
+
+// meta reads the metadata of the document
+func (p *parser) meta() error {
+	props := p.doc.Properties
+	return xmltree.Children(p.d, func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "document-statistic":
+			// The statistics are attributes, e.g. page-count
+			for _, a := range t.Attr {
+				props[a.Name.Local] = a.Value
+			}
+			return p.d.Skip()
+		case "template":
+			if title := xmltree.Attr(t, "title"); title != "" {
+				props["template"] = title
+			}
+			return p.d.Skip()
+		case "auto-reload", "hyperlink-behaviour":
+			return p.d.Skip()
+		}
+
+		text, err := p.text()
+		text = strings.TrimSpace(text)
+		if err != nil || text == "" {
+			return err
+		}
+		switch t.Name.Local {
+		case "keyword":
+			// Every keyword has its own element
+			if props["keywords"] != "" {
+				text = props["keywords"] + ", " + text
+			}
+			props["keywords"] = text
+		case "user-defined":
+			if name := xmltree.Attr(t, "name"); name != "" {
+				props[name] = text
+			}
+		default:
+			props[t.Name.Local] = text
+		}
+		return nil
+	})
+}
@@ -0,0 +1,426 @@
+package odf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pilinux/totext/internal/fixture"
+	"github.com/pilinux/totext/internal/structure"
+)
+
+const officeNS = `xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" ` +
+	`xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" ` +
+	`xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" ` +
+	`xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" ` +
+	`xmlns:draw="urn:oasis:names:tc:opendocument:xmlns:drawing:1.0" ` +
+	`xmlns:meta="urn:oasis:names:tc:opendocument:xmlns:meta:1.0" ` +
+	`xmlns:dc="http://purl.org/dc/elements/1.1/"`
+
+// buildODT returns an odt package with the text of the body and the parts
+func buildODT(t *testing.T, text string, parts map[string]string) []byte {
+	t.Helper()
+
+	entries := map[string]string{
+		"mimetype": "application/vnd.oasis.opendocument.text",
+		"content.xml": `<office:document-content ` + officeNS + `>` + testStyles +
+			`<office:body><office:text>` + text + `</office:text></office:body></office:document-content>`,
+	}
+	for name, content := range parts {
+		entries[name] = content
+	}
+
+	return fixture.Zip(t, entries)
+}
+
+// render returns the blocks as lines of kind, level, label and text
+func render(blocks []structure.Block) string {
+	var lines []string
+	for _, b := range blocks {
+		switch b.Kind {
+		case structure.Heading:
+			lines = append(lines, fmt.Sprintf("H%d %s|%s", b.Level, b.Label, b.Text))
+		case structure.ListItem:
+			lines = append(lines, fmt.Sprintf("L%d %s|%s", b.Level, b.Label, b.Text))
+		case structure.Table:
+			var rows []string
+			for _, row := range b.Rows {
+				var cells []string
+				for _, cell := range row {
+					cells = append(cells, render(cell))
+				}
+				rows = append(rows, strings.Join(cells, ","))
+			}
+			lines = append(lines, "T "+strings.Join(rows, ";"))
+		default:
+			lines = append(lines, "P "+b.Text)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+const testStyles = `<office:automatic-styles>` +
+	`<text:list-style style:name="L1">` +
+	`<text:list-level-style-number text:level="1" style:num-format="1" style:num-suffix="."/>` +
+	`<text:list-level-style-number text:level="2" style:num-format="a" style:num-suffix=")" text:display-levels="2"/>` +
+	`</text:list-style>` +
+	`<text:list-style style:name="L2">` +
+	`<text:list-level-style-bullet text:level="1" text:bullet-char="` + "\uf0b7" + `"/>` +
+	`<text:list-level-style-bullet text:level="2" text:bullet-char="-"/>` +
+	`</text:list-style>` +
+	`</office:automatic-styles>`
+
+const testStylesPart = `<office:document-styles ` + officeNS + `><office:styles>` +
+	`<style:style style:name="Heading" style:family="paragraph"/>` +
+	`<style:style style:name="Heading_20_2" style:family="paragraph" style:parent-style-name="Heading" style:default-outline-level="2"/>` +
+	`<style:style style:name="Sub" style:family="paragraph" style:parent-style-name="Heading_20_2"/>` +
+	`<text:outline-style style:name="Outline">` +
+	`<text:outline-level-style text:level="1" style:num-format="I" style:num-suffix="."/>` +
+	`<text:outline-level-style text:level="2" style:num-format="1" text:display-levels="2"/>` +
+	`</text:outline-style></office:styles>` +
+	`<office:master-styles><style:master-page style:name="Standard">` +
+	`<style:header><text:p>Confidential</text:p></style:header>` +
+	`<style:header-left style:display="false"><text:p>Hidden</text:p></style:header-left>` +
+	`<style:footer><text:p>Page <text:page-number>1</text:page-number></text:p></style:footer>` +
+	`</style:master-page></office:master-styles></office:document-styles>`
+
+const testMeta = `<office:document-meta ` + officeNS + `><office:meta>` +
+	`<meta:generator>Writer</meta:generator><dc:title>Report</dc:title>` +
+	`<meta:initial-creator>Jane Doe</meta:initial-creator><dc:creator>John Roe</dc:creator>` +
+	`<meta:creation-date>2021-03-04T05:06:07.123</meta:creation-date>` +
+	`<meta:keyword>alpha</meta:keyword><meta:keyword>beta</meta:keyword>` +
+	`<meta:user-defined meta:name="Project" meta:value-type="string">Apollo</meta:user-defined>` +
+	`<meta:template xlink:href="x.ott" xlink:title="Letter" xmlns:xlink="http://www.w3.org/1999/xlink"/>` +
+	`<meta:document-statistic meta:page-count="3" meta:word-count="120"/>` +
+	`<dc:description> </dc:description>` +
+	`</office:meta></office:document-meta>`
+
+// TestRead tests the structure of a document
+func TestRead(t *testing.T) {
+	text := `<text:sequence-decls/>` +
+		`<text:h text:outline-level="1">Intro</text:h>` +
+		`<text:h text:style-name="Sub">Scope</text:h>` +
+		`<text:h text:outline-level="1" text:is-list-header="true">Unnumbered</text:h>` +
+		`<text:p>Text<text:note text:id="n1" text:note-class="footnote"><text:note-citation>1</text:note-citation>` +
+		`<text:note-body><text:p>First note</text:p></text:note-body></text:note> and` +
+		`<text:note text:note-class="endnote"><text:note-citation text:label="*">i</text:note-citation>` +
+		`<text:note-body><text:p>End</text:p></text:note-body></text:note></text:p>` +
+		`<text:list xml:id="list1" text:style-name="L1">` +
+		`<text:list-item><text:p>One</text:p><text:list><text:list-item><text:p>Sub</text:p></text:list-item>` +
+		`<text:list-item><text:p>Sub</text:p></text:list-item></text:list></text:list-item>` +
+		`<text:list-item><text:p>Two</text:p><text:p>More</text:p></text:list-item>` +
+		`<text:list-header><text:p>Header</text:p></text:list-header>` +
+		`</text:list>` +
+		`<text:list text:style-name="L2"><text:list-item><text:p>Dot</text:p>` +
+		`<text:list><text:list-item><text:p>Dash</text:p></text:list-item></text:list></text:list-item></text:list>` +
+		`<text:list text:continue-list="list1" text:style-name="L1"><text:list-item><text:p>Three</text:p></text:list-item></text:list>` +
+		`<text:list text:style-name="L1"><text:list-item text:start-value="7"><text:p>Seven</text:p></text:list-item></text:list>` +
+		`<text:numbered-paragraph text:list-id="np" text:style-name="L1" text:level="1"><text:number>1.</text:number>` +
+		`<text:p>Numbered</text:p></text:numbered-paragraph>` +
+		`<table:table><table:table-column/><table:table-header-rows><table:table-row>` +
+		`<table:table-cell><text:p>A</text:p></table:table-cell><table:table-cell><text:p>B</text:p></table:table-cell>` +
+		`</table:table-row></table:table-header-rows><table:table-row>` +
+		`<table:table-cell table:number-columns-spanned="2"><text:p>C</text:p><text:p>D</text:p></table:table-cell>` +
+		`<table:covered-table-cell/></table:table-row></table:table>` +
+		`<text:p>a<text:tab/>b<text:line-break/>c<text:s text:c="2"/>d   e` + "\n" +
+		`<text:span>f</text:span><text:a xlink:href="x" xmlns:xlink="http://www.w3.org/1999/xlink">link</text:a></text:p>` +
+		`<text:p>Anchor<draw:frame><draw:text-box><text:p>Box</text:p></draw:text-box></draw:frame>` +
+		`<office:annotation><dc:creator>Jane Doe</dc:creator><dc:date>2021-01-01</dc:date>` +
+		`<text:p>Check this</text:p></office:annotation></text:p>` +
+		`<text:section><text:p>Section</text:p></text:section>` +
+		`<text:table-of-content><text:table-of-content-source/><text:index-body>` +
+		`<text:p>Index</text:p></text:index-body></text:table-of-content>` +
+		`<text:p> </text:p>` +
+		`<text:h text:outline-level="2">Next</text:h>`
+
+	data := buildODT(t, text, map[string]string{
+		"styles.xml": testStylesPart,
+		"meta.xml":   testMeta,
+	})
+	doc, err := Read(context.Background(), bytes.NewReader(data), int64(len(data)), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.Join([]string{
+		"H1 I.|Intro",
+		"H2 I.1|Scope",
+		"H1 |Unnumbered",
+		"P Text[1] and[*]",
+		"L0 1.|One",
+		"L1 1.a)|Sub",
+		"L1 1.b)|Sub",
+		"L0 2.|Two",
+		"L0 |More",
+		"L0 |Header",
+		"L0 •|Dot",
+		"L1 -|Dash",
+		"L0 3.|Three",
+		"L0 7.|Seven",
+		"L0 1.|Numbered",
+		"T P A,P B;P C\nP D",
+		"P a\tb\nc  d e flink",
+		"P Anchor",
+		"P Box",
+		"P Section",
+		"P Index",
+		"H2 I.2|Next",
+	}, "\n")
+	if got := render(doc.Body); got != expected {
+		t.Errorf("unexpected body\n%s\nexpected\n%s", got, expected)
+	}
+
+	if len(doc.Headers) != 1 || render(doc.Headers[0]) != "P Confidential" {
+		t.Errorf("unexpected headers %v", doc.Headers)
+	}
+	if len(doc.Footers) != 1 || render(doc.Footers[0]) != "P Page 1" {
+		t.Errorf("unexpected footers %v", doc.Footers)
+	}
+	if len(doc.Footnotes) != 1 || doc.Footnotes[0].ID != "1" || render(doc.Footnotes[0].Blocks) != "P First note" {
+		t.Errorf("unexpected footnotes %v", doc.Footnotes)
+	}
+	if len(doc.Endnotes) != 1 || doc.Endnotes[0].ID != "*" || render(doc.Endnotes[0].Blocks) != "P End" {
+		t.Errorf("unexpected endnotes %v", doc.Endnotes)
+	}
+	if len(doc.Comments) != 1 || doc.Comments[0].ID != "1" || doc.Comments[0].Author != "Jane Doe" ||
+		render(doc.Comments[0].Blocks) != "P Check this" {
+		t.Errorf("unexpected comments %v", doc.Comments)
+	}
+
+	expectedProps := map[string]string{
+		"generator":       "Writer",
+		"title":           "Report",
+		"initial-creator": "Jane Doe",
+		"creator":         "John Roe",
+		"creation-date":   "2021-03-04T05:06:07.123",
+		"keywords":        "alpha, beta",
+		"Project":         "Apollo",
+		"template":        "Letter",
+		"page-count":      "3",
+		"word-count":      "120",
+	}
+	if fmt.Sprint(doc.Properties) != fmt.Sprint(expectedProps) {
+		t.Errorf("unexpected properties %v", doc.Properties)
+	}
+}
+
+// TestReadFlat tests a flat document
+func TestReadFlat(t *testing.T) {
+	data := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+		`<office:document ` + officeNS + ` office:mimetype="application/vnd.oasis.opendocument.text">` +
+		`<office:meta><dc:title>Flat</dc:title></office:meta>` +
+		strings.TrimSuffix(strings.TrimPrefix(testStylesPart, `<office:document-styles `+officeNS+`>`), `</office:document-styles>`) +
+		testStyles +
+		`<office:body><office:text><text:h text:outline-level="1">Title</text:h>` +
+		`<text:list text:style-name="L2"><text:list-item><text:p>Item</text:p></text:list-item></text:list>` +
+		`</office:text></office:body></office:document>`
+
+	doc, err := Read(context.Background(), strings.NewReader(data), int64(len(data)), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := render(doc.Body); got != "H1 I.|Title\nL0 •|Item" {
+		t.Errorf("unexpected body\n%s", got)
+	}
+	if len(doc.Headers) != 1 || doc.Properties["title"] != "Flat" {
+		t.Errorf("unexpected headers %v or properties %v", doc.Headers, doc.Properties)
+	}
+}
+
+// TestTrackedChanges tests accepting and rejecting the tracked changes
+func TestTrackedChanges(t *testing.T) {
+	text := `<text:tracked-changes>` +
+		`<text:changed-region text:id="c1"><text:insertion><office:change-info/></text:insertion></text:changed-region>` +
+		`<text:changed-region text:id="c2"><text:deletion><office:change-info/><text:p>old</text:p></text:deletion></text:changed-region>` +
+		`<text:changed-region text:id="c3"><text:deletion><office:change-info/>` +
+		`<text:h text:outline-level="1">Removed</text:h></text:deletion></text:changed-region>` +
+		`<text:changed-region text:id="c4"><text:insertion><office:change-info/></text:insertion></text:changed-region>` +
+		`</text:tracked-changes>` +
+		`<text:p>Keep <text:change-start text:change-id="c1"/>new<text:change-end text:change-id="c1"/>` +
+		`<text:change text:change-id="c2"/></text:p>` +
+		`<text:change text:change-id="c3"/>` +
+		`<text:change-start text:change-id="c4"/><text:p>Added</text:p><text:change-end text:change-id="c4"/>` +
+		`<text:h text:outline-level="1">Kept</text:h>`
+
+	// Test data
+	testData := []struct {
+		reject   bool
+		expected string
+	}{
+		{false, "P Keep new\nP Added\nH1 I.|Kept"},
+		{true, "P Keep old\nH1 |Removed\nH1 I.|Kept"},
+	}
+
+	// Iterate over test data
+	data := buildODT(t, text, map[string]string{"styles.xml": testStylesPart})
+	for _, td := range testData {
+		doc, err := Read(context.Background(), bytes.NewReader(data), int64(len(data)), td.reject)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := render(doc.Body); got != td.expected {
+			t.Errorf("reject %v: unexpected body\n%s\nexpected\n%s", td.reject, got, td.expected)
+		}
+	}
+}
+
+// TestReadPresentation tests the slides of a presentation
+func TestReadPresentation(t *testing.T) {
+	ns := officeNS + ` xmlns:presentation="urn:oasis:names:tc:opendocument:xmlns:presentation:1.0"`
+	content := `<office:document-content ` + ns + `><office:automatic-styles>` +
+		`<style:style style:name="dp2" style:family="drawing-page"><style:drawing-page-properties presentation:visibility="hidden"/></style:style>` +
+		`</office:automatic-styles><office:body><office:presentation>` +
+		`<draw:page draw:name="page1" draw:style-name="dp1">` +
+		`<draw:frame presentation:class="title"><draw:text-box><text:p>Road</text:p><text:p>map</text:p></draw:text-box></draw:frame>` +
+		`<draw:frame presentation:class="outline"><draw:text-box><text:list text:style-name="L2"><text:list-item>` +
+		`<text:p>Ship</text:p></text:list-item></text:list></draw:text-box></draw:frame>` +
+		`<draw:frame presentation:class="page-number"><draw:text-box><text:p>1</text:p></draw:text-box></draw:frame>` +
+		`<draw:g><draw:custom-shape><text:p>Grouped</text:p></draw:custom-shape></draw:g>` +
+		`<presentation:notes><draw:page-thumbnail presentation:class="page"/>` +
+		`<draw:frame presentation:class="notes"><draw:text-box><text:p>Greet the audience</text:p></draw:text-box></draw:frame>` +
+		`</presentation:notes></draw:page>` +
+		`<draw:page draw:name="page2" draw:style-name="dp2">` +
+		`<draw:frame><table:table><table:table-row><table:table-cell><text:p>A</text:p></table:table-cell>` +
+		`<table:table-cell><text:p>B</text:p></table:table-cell></table:table-row></table:table></draw:frame>` +
+		`</draw:page></office:presentation></office:body></office:document-content>`
+	data := fixture.Zip(t, map[string]string{
+		"mimetype":    "application/vnd.oasis.opendocument.presentation",
+		"content.xml": content,
+		"styles.xml":  `<office:document-styles ` + officeNS + `>` + testStyles + `</office:document-styles>`,
+		"meta.xml":    testMeta,
+	})
+
+	doc, err := Read(context.Background(), bytes.NewReader(data), int64(len(data)), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Test data
+	testData := []struct {
+		title  string
+		hidden bool
+		body   string
+		notes  string
+	}{
+		{"Road map", false, "L0 \u2022|Ship\nP Grouped", "P Greet the audience"},
+		{"", true, "T P A,P B", ""},
+	}
+
+	if len(doc.Slides) != len(testData) {
+		t.Fatalf("expected %d slides, got %d", len(testData), len(doc.Slides))
+	}
+
+	// Iterate over test data
+	for i, td := range testData {
+		s := doc.Slides[i]
+		if s.Title != td.title || s.Hidden != td.hidden {
+			t.Errorf("expected slide %q hidden %t, got %q hidden %t", td.title, td.hidden, s.Title, s.Hidden)
+		}
+		if body := render(s.Body); body != td.body {
+			t.Errorf("slide %d: expected body %q, got %q", i+1, td.body, body)
+		}
+		if notes := render(s.Notes); notes != td.notes {
+			t.Errorf("slide %d: expected notes %q, got %q", i+1, td.notes, notes)
+		}
+	}
+
+	if len(doc.Body) != 0 || doc.Properties["title"] != "Report" {
+		t.Errorf("unexpected body %v or properties %v", doc.Body, doc.Properties)
+	}
+}
+
+// TestReadMeta tests the metadata of a meta.xml part
+func TestReadMeta(t *testing.T) {
+	data := `<office:document-meta ` + officeNS + `><office:meta><dc:title>Budget</dc:title>` +
+		`<meta:initial-creator>Jane Doe</meta:initial-creator>` +
+		`<meta:document-statistic meta:table-count="2" meta:cell-count="10"/></office:meta></office:document-meta>`
+
+	props, err := ReadMeta(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if props["title"] != "Budget" || props["initial-creator"] != "Jane Doe" || props["table-count"] != "2" {
+		t.Errorf("unexpected properties %v", props)
+	}
+
+	if _, err := ReadMeta(strings.NewReader("plain text")); !errors.Is(err, ErrNotODF) {
+		t.Errorf("expected %v, got %v", ErrNotODF, err)
+	}
+}
+
+// TestReadErrors tests the errors of invalid documents
+func TestReadErrors(t *testing.T) {
+	// Test data
+	testData := []struct {
+		name     string
+		data     []byte
+		expected error
+	}{
+		{"plain text", []byte("plain text"), ErrNotODF},
+		{"other XML", []byte(`<?xml version="1.0"?><html></html>`), ErrNotODF},
+		{"no content", fixture.Zip(t, map[string]string{"mimetype": "application/vnd.oasis.opendocument.text"}), ErrNotODF},
+		{"encrypted", buildODT(t, "", map[string]string{
+			"META-INF/manifest.xml": `<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0">` +
+				`<manifest:file-entry manifest:full-path="content.xml"><manifest:encryption-data/></manifest:file-entry>` +
+				`</manifest:manifest>`,
+		}), ErrEncrypted},
+		{"malformed", buildODT(t, "<text:p>", nil), ErrMalformed},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		_, err := Read(context.Background(), bytes.NewReader(td.data), int64(len(td.data)), false)
+		if !errors.Is(err, td.expected) {
+			t.Errorf("%s: expected %v, got %v", td.name, td.expected, err)
+		}
+	}
+}
+
+// TestFormat tests the format of the spans, the hyperlinks and the code paragraphs
+func TestFormat(t *testing.T) {
+	styles := `<office:document-styles ` + officeNS + ` xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0">` +
+		`<office:font-face-decls><style:font-face style:name="Mono" style:font-pitch="fixed"/></office:font-face-decls>` +
+		`<office:styles>` +
+		`<style:style style:name="Strong" style:family="text"><style:text-properties fo:font-weight="bold"/></style:style>` +
+		`<style:style style:name="T1" style:family="text" style:parent-style-name="Strong">` +
+		`<style:text-properties fo:font-style="italic"/></style:style>` +
+		`<style:style style:name="T2" style:family="text"><style:text-properties style:font-name="Mono"/></style:style>` +
+		`<style:style style:name="Preformatted_20_Text" style:family="paragraph"/>` +
+		`</office:styles></office:document-styles>`
+	text := `<text:p>Plain <text:span text:style-name="Strong">bold</text:span>` +
+		`<text:span text:style-name="T1"> both</text:span> <text:span text:style-name="T2">x</text:span></text:p>` +
+		`<text:p><text:a xlink:href="https://example.com" xmlns:xlink="http://www.w3.org/1999/xlink">site</text:a></text:p>` +
+		`<text:p text:style-name="Preformatted_20_Text">x := 1</text:p>`
+
+	data := buildODT(t, text, map[string]string{"styles.xml": styles})
+	doc, err := Read(context.Background(), bytes.NewReader(data), int64(len(data)), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Body) != 3 {
+		t.Fatalf("unexpected body %v", doc.Body)
+	}
+
+	expected := []structure.Span{
+		{Text: "Plain "},
+		{Text: "bold", Format: structure.Format{Bold: true}},
+		{Text: " both", Format: structure.Format{Bold: true, Italic: true}},
+		{Text: " "},
+		{Text: "x", Format: structure.Format{Code: true}},
+	}
+	if fmt.Sprint(doc.Body[0].Spans) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, doc.Body[0].Spans)
+	}
+
+	expected = []structure.Span{{Text: "site", Format: structure.Format{Link: "https://example.com"}}}
+	if fmt.Sprint(doc.Body[1].Spans) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, doc.Body[1].Spans)
+	}
+
+	if doc.Body[2].Kind != structure.Code || doc.Body[2].Text != "x := 1" {
+		t.Errorf("unexpected code block %+v", doc.Body[2])
+	}
+}
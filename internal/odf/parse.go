@@ -0,0 +1,503 @@
+package odf
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+
+	"github.com/pilinux/totext/internal/structure"
+	"github.com/pilinux/totext/internal/xmltree"
+)
+
+// parser reads the parts of the document
+type parser struct {
+	// d decodes the current part
+	d *xml.Decoder
+	// reject rejects the tracked changes instead of accepting them
+	reject bool
+	doc    *structure.Document
+
+	listStyles      map[string]*listStyle
+	paragraphStyles map[string]paragraphStyle
+	textStyles      map[string]textStyle
+	// fixedFonts holds the names of the fonts with a fixed pitch
+	fixedFonts map[string]bool
+	outline    listStyle
+	// headings numbers the headings with the outline style
+	headings counter
+	// lists maps the ids of the lists to their counters, and lastLists
+	// the list styles to the counters of their last lists, so that
+	// lists can continue the numbering of previous lists
+	lists, lastLists map[string]*counter
+
+	// changes are the tracked changes by id
+	changes map[string]change
+	// hidden holds the ids of the open insertions which are rejected
+	hidden map[string]bool
+	// hiddenPages holds the names of the styles of the hidden slides
+	hiddenPages map[string]bool
+}
+
+// change is a tracked change
+type change struct {
+	insertion bool
+	// deleted is the content of a deletion
+	deleted []structure.Block
+}
+
+// content is the content of a paragraph
+type content struct {
+	text structure.Builder
+	// space reports whether the text ends with a space or starts,
+	// following white space is collapsed
+	space bool
+	// collapsed reports whether the text ends with collapsed white space,
+	// which is removed at the end of the paragraph
+	collapsed bool
+	// extra holds the blocks of the text boxes of the paragraph
+	extra []structure.Block
+}
+
+// newParser returns a parser for a new document
+func newParser(reject bool) *parser {
+	return &parser{
+		reject:          reject,
+		doc:             &structure.Document{Properties: make(map[string]string)},
+		listStyles:      make(map[string]*listStyle),
+		paragraphStyles: make(map[string]paragraphStyle),
+		textStyles:      make(map[string]textStyle),
+		fixedFonts:      make(map[string]bool),
+		lists:           make(map[string]*counter),
+		lastLists:       make(map[string]*counter),
+		changes:         make(map[string]change),
+		hidden:          make(map[string]bool),
+		hiddenPages:     make(map[string]bool),
+	}
+}
+
+// text returns the text of the current element
+func (p *parser) text() (string, error) {
+	var text strings.Builder
+	err := xmltree.Walk(p.d, func(xml.StartElement) error {
+		return p.d.Skip()
+	}, func(data xml.CharData) {
+		text.Write(data)
+	})
+	return text.String(), err
+}
+
+// blocks reads the block-level content up to the end of the current element
+func (p *parser) blocks() ([]structure.Block, error) {
+	var blocks []structure.Block
+	err := xmltree.Children(p.d, func(t xml.StartElement) error {
+		children, err := p.block(t)
+		blocks = append(blocks, children...)
+		return err
+	})
+
+	return blocks, err
+}
+
+// block reads the block-level element t
+func (p *parser) block(t xml.StartElement) ([]structure.Block, error) {
+	switch t.Name.Local {
+	case "p":
+		kind := structure.Paragraph
+		if p.isCode(xmltree.Attr(t, "style-name")) {
+			kind = structure.Code
+		}
+		return p.paragraph(structure.Block{Kind: kind})
+	case "h":
+		return p.heading(t, -1, "")
+	case "list":
+		return p.list(t, 0, nil, nil)
+	case "numbered-paragraph":
+		return p.numberedParagraph(t)
+	case "table":
+		b := structure.Block{Kind: structure.Table}
+		if err := p.rows(&b); err != nil || len(b.Rows) == 0 {
+			return nil, err
+		}
+		return []structure.Block{b}, nil
+	case "presentation":
+		return nil, p.presentation()
+	case "tracked-changes":
+		return nil, p.trackedChanges()
+	case "change", "change-start", "change-end":
+		return p.change(t), p.d.Skip()
+	case "body", "text", "section", "frame", "text-box", "custom-shape",
+		"region-left", "region-center", "region-right",
+		"index-body", "index-title", "table-of-content", "alphabetical-index",
+		"illustration-index", "table-index", "object-index", "user-index", "bibliography":
+		// The sources of the indexes are skipped as they are not blocks
+		return p.blocks()
+	}
+
+	return nil, p.d.Skip()
+}
+
+// paragraph reads a paragraph or a heading with the kind, level
+// and label of b, followed by the blocks of its text boxes
+func (p *parser) paragraph(b structure.Block) ([]structure.Block, error) {
+	c := &content{space: true}
+	err := xmltree.Walk(p.d, func(t xml.StartElement) error {
+		return p.inline(c, t)
+	}, func(data xml.CharData) {
+		p.write(c, string(data), true)
+	})
+
+	var blocks []structure.Block
+	if c.collapsed {
+		c.text.TrimSuffix(" ")
+	}
+	b.Text, b.Spans = c.text.String(), c.text.Spans()
+	if strings.TrimSpace(b.Text) != "" {
+		blocks = append(blocks, b)
+	}
+	return append(blocks, c.extra...), err
+}
+
+// heading reads a heading. Outside of lists level is -1 and the
+// heading is numbered with the outline style.
+func (p *parser) heading(t xml.StartElement, level int, label string) ([]structure.Block, error) {
+	outline, _ := strconv.Atoi(xmltree.Attr(t, "outline-level"))
+	if outline <= 0 {
+		outline = p.outlineLevel(xmltree.Attr(t, "style-name"))
+	}
+	outline = min(max(outline, 1), maxLevels)
+
+	if level < 0 && xmltree.Attr(t, "is-list-header") != "true" {
+		start := -1
+		if xmltree.Attr(t, "restart-numbering") == "true" {
+			start, _ = strconv.Atoi(xmltree.Attr(t, "start-value"))
+		}
+		label = p.headings.next(&p.outline, outline-1, start)
+	}
+
+	return p.paragraph(structure.Block{Kind: structure.Heading, Level: outline, Label: label})
+}
+
+// list reads a list. Nested lists have the style and the counter of
+// the outermost list, which are nil for the outermost list.
+func (p *parser) list(t xml.StartElement, level int, style *listStyle, ctr *counter) ([]structure.Block, error) {
+	if ctr == nil {
+		name := xmltree.Attr(t, "style-name")
+		style = p.listStyles[name]
+
+		// Lists start again unless they continue a previous list
+		switch {
+		case p.lists[xmltree.Attr(t, "continue-list")] != nil:
+			ctr = p.lists[xmltree.Attr(t, "continue-list")]
+		case xmltree.Attr(t, "continue-numbering") == "true" && p.lastLists[name] != nil:
+			ctr = p.lastLists[name]
+		default:
+			ctr = &counter{}
+		}
+		if id := xmltree.Attr(t, "id"); id != "" {
+			p.lists[id] = ctr
+		}
+		p.lastLists[name] = ctr
+	}
+	level = min(level, maxLevels-1)
+
+	var blocks []structure.Block
+	err := xmltree.Children(p.d, func(t xml.StartElement) error {
+		if t.Name.Local != "list-item" && t.Name.Local != "list-header" {
+			return p.d.Skip()
+		}
+
+		// Items are numbered at their first paragraph, items which
+		// only hold a nested list are not numbered
+		numbered := t.Name.Local == "list-item"
+		start := -1
+		if s, err := strconv.Atoi(xmltree.Attr(t, "start-value")); err == nil {
+			start = s
+		}
+
+		return xmltree.Children(p.d, func(t xml.StartElement) error {
+			var label string
+			switch t.Name.Local {
+			case "p", "h":
+				if numbered {
+					label, numbered = ctr.next(style, level, start), false
+				}
+			}
+
+			var children []structure.Block
+			var err error
+			switch t.Name.Local {
+			case "p":
+				children, err = p.paragraph(structure.Block{Kind: structure.ListItem, Level: level, Label: label})
+			case "h":
+				children, err = p.heading(t, level, label)
+			case "list":
+				children, err = p.list(t, level+1, style, ctr)
+			default:
+				children, err = p.block(t)
+			}
+			blocks = append(blocks, children...)
+			return err
+		})
+	})
+
+	return blocks, err
+}
+
+// numberedParagraph reads a paragraph numbered as an item of a list
+// without being part of a list element
+func (p *parser) numberedParagraph(t xml.StartElement) ([]structure.Block, error) {
+	id := xmltree.Attr(t, "list-id")
+	ctr := p.lists[id]
+	if ctr == nil {
+		ctr = &counter{}
+		p.lists[id] = ctr
+	}
+	level, _ := strconv.Atoi(xmltree.Attr(t, "level"))
+	level = min(max(level, 1), maxLevels) - 1
+	start := -1
+	if s, err := strconv.Atoi(xmltree.Attr(t, "start-value")); err == nil {
+		start = s
+	}
+	label := ctr.next(p.listStyles[xmltree.Attr(t, "style-name")], level, start)
+
+	var blocks []structure.Block
+	err := xmltree.Children(p.d, func(t xml.StartElement) error {
+		var children []structure.Block
+		var err error
+		switch t.Name.Local {
+		case "p":
+			children, err = p.paragraph(structure.Block{Kind: structure.ListItem, Level: level, Label: label})
+		case "h":
+			children, err = p.heading(t, level, label)
+		default:
+			// The number element repeats the label
+			return p.d.Skip()
+		}
+		blocks = append(blocks, children...)
+		return err
+	})
+
+	return blocks, err
+}
+
+// rows reads the rows of a table into b
+func (p *parser) rows(b *structure.Block) error {
+	return xmltree.Children(p.d, func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "table-row":
+			var row []structure.Cell
+			err := xmltree.Children(p.d, func(t xml.StartElement) error {
+				// Covered cells are hidden by merged cells
+				if t.Name.Local != "table-cell" {
+					return p.d.Skip()
+				}
+				blocks, err := p.blocks()
+				row = append(row, blocks)
+				return err
+			})
+			b.Rows = append(b.Rows, row)
+			return err
+		case "table-header-rows", "table-rows", "table-row-group":
+			return p.rows(b)
+		default:
+			return p.d.Skip()
+		}
+	})
+}
+
+// inline reads the element of a paragraph into c
+func (p *parser) inline(c *content, t xml.StartElement) error {
+	switch t.Name.Local {
+	case "s":
+		n, err := strconv.Atoi(xmltree.Attr(t, "c"))
+		if err != nil || n < 1 {
+			n = 1
+		}
+		p.write(c, strings.Repeat(" ", min(n, 1024)), false)
+	case "tab":
+		p.write(c, "\t", false)
+	case "line-break":
+		p.write(c, "\n", false)
+		c.space = true
+	case "note":
+		return p.note(c, xmltree.Attr(t, "note-class"))
+	case "annotation":
+		return p.annotation()
+	case "change", "change-start", "change-end":
+		for _, b := range p.change(t) {
+			p.write(c, b.Text, true)
+		}
+	case "text-box", "custom-shape":
+		blocks, err := p.blocks()
+		c.extra = append(c.extra, blocks...)
+		return err
+	case "span", "a":
+		// The format of the span or the link applies to its content
+		format := c.text.Format
+		defer func() {
+			c.text.Format = format
+		}()
+		if t.Name.Local == "a" {
+			c.text.Format.Link = xmltree.Attr(t, "href")
+		} else {
+			p.textFormat(xmltree.Attr(t, "style-name"), &c.text.Format)
+		}
+		return xmltree.Walk(p.d, func(t xml.StartElement) error {
+			return p.inline(c, t)
+		}, func(data xml.CharData) {
+			p.write(c, string(data), true)
+		})
+	case "title", "desc", "image", "object", "object-ole", "tracked-changes":
+		// Descriptions of the drawings and embedded objects
+	default:
+		// Spans, links, fields, frames, ...
+		return xmltree.Walk(p.d, func(t xml.StartElement) error {
+			return p.inline(c, t)
+		}, func(data xml.CharData) {
+			p.write(c, string(data), true)
+		})
+	}
+
+	return p.d.Skip()
+}
+
+// write writes the text to the paragraph unless it is part of a
+// rejected insertion. The white space of the XML content is collapsed.
+func (p *parser) write(c *content, text string, collapse bool) {
+	if len(p.hidden) > 0 {
+		return
+	}
+	if !collapse {
+		c.text.WriteString(text)
+		c.space, c.collapsed = false, false
+		return
+	}
+
+	for _, r := range text {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' {
+			if !c.space {
+				c.text.WriteByte(' ')
+				c.space, c.collapsed = true, true
+			}
+			continue
+		}
+		c.text.WriteRune(r)
+		c.space, c.collapsed = false, false
+	}
+}
+
+// note reads a footnote or an endnote of the class, its citation is written to c
+func (p *parser) note(c *content, class string) error {
+	var citation string
+	var blocks []structure.Block
+	err := xmltree.Children(p.d, func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "note-citation":
+			citation = xmltree.Attr(t, "label")
+			text, err := p.text()
+			if citation == "" {
+				citation = strings.TrimSpace(text)
+			}
+			return err
+		case "note-body":
+			var err error
+			blocks, err = p.blocks()
+			return err
+		}
+		return p.d.Skip()
+	})
+	if err != nil || len(p.hidden) > 0 {
+		return err
+	}
+
+	p.write(c, "["+citation+"]", false)
+	note := structure.Note{ID: citation, Blocks: blocks}
+	if class == "endnote" {
+		p.doc.Endnotes = append(p.doc.Endnotes, note)
+	} else {
+		p.doc.Footnotes = append(p.doc.Footnotes, note)
+	}
+	return nil
+}
+
+// annotation reads a comment
+func (p *parser) annotation() error {
+	note := structure.Note{ID: strconv.Itoa(len(p.doc.Comments) + 1)}
+	err := xmltree.Children(p.d, func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "creator":
+			author, err := p.text()
+			note.Author = strings.TrimSpace(author)
+			return err
+		case "date", "date-string":
+			return p.d.Skip()
+		}
+		blocks, err := p.block(t)
+		note.Blocks = append(note.Blocks, blocks...)
+		return err
+	})
+	if err != nil || len(p.hidden) > 0 {
+		return err
+	}
+
+	p.doc.Comments = append(p.doc.Comments, note)
+	return nil
+}
+
+// trackedChanges reads the changed regions of the document
+func (p *parser) trackedChanges() error {
+	// The deleted content does not count for the numbering
+	headings := p.headings
+	defer func() {
+		p.headings = headings
+	}()
+
+	return xmltree.Children(p.d, func(t xml.StartElement) error {
+		if t.Name.Local != "changed-region" {
+			return p.d.Skip()
+		}
+
+		id := xmltree.Attr(t, "id")
+		return xmltree.Children(p.d, func(t xml.StartElement) error {
+			switch t.Name.Local {
+			case "insertion":
+				p.changes[id] = change{insertion: true}
+			case "deletion":
+				deleted, err := p.blocks()
+				// The numbers of the deleted headings are not known
+				for i := range deleted {
+					if deleted[i].Kind == structure.Heading {
+						deleted[i].Label = ""
+					}
+				}
+				p.changes[id] = change{deleted: deleted}
+				return err
+			}
+			return p.d.Skip()
+		})
+	})
+}
+
+// change handles a change mark and returns the deleted content
+// which is restored when the changes are rejected
+func (p *parser) change(t xml.StartElement) []structure.Block {
+	if !p.reject {
+		return nil
+	}
+
+	id := xmltree.Attr(t, "change-id")
+	switch t.Name.Local {
+	case "change":
+		if len(p.hidden) == 0 {
+			return p.changes[id].deleted
+		}
+	case "change-start":
+		if p.changes[id].insertion {
+			p.hidden[id] = true
+		}
+	case "change-end":
+		delete(p.hidden, id)
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package odf
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"github.com/pilinux/totext/internal/structure"
+	"github.com/pilinux/totext/internal/xmltree"
+)
+
+// presentation reads the pages of a presentation as slides
+func (p *parser) presentation() error {
+	return xmltree.Children(p.d, func(t xml.StartElement) error {
+		if t.Name.Local != "page" {
+			return p.d.Skip()
+		}
+
+		s := structure.Slide{Hidden: p.hiddenPages[xmltree.Attr(t, "style-name")]}
+		err := xmltree.Children(p.d, func(t xml.StartElement) error {
+			return p.shape(t, &s)
+		})
+		p.doc.Slides = append(p.doc.Slides, s)
+		return err
+	})
+}
+
+// shape reads the text of a shape of the slide, the shapes of a group
+// or the speaker notes. The page numbers, the headers, the footers and
+// the dates of the placeholders are left out.
+func (p *parser) shape(t xml.StartElement, s *structure.Slide) error {
+	switch t.Name.Local {
+	case "g":
+		return xmltree.Children(p.d, func(t xml.StartElement) error {
+			return p.shape(t, s)
+		})
+	case "notes":
+		return xmltree.Children(p.d, func(t xml.StartElement) error {
+			if t.Name.Local != "frame" || xmltree.Attr(t, "class") != "notes" {
+				return p.d.Skip()
+			}
+			blocks, err := p.blocks()
+			s.Notes = append(s.Notes, blocks...)
+			return err
+		})
+	case "frame", "custom-shape", "rect", "ellipse", "circle", "polygon", "polyline",
+		"path", "regular-polygon", "caption", "measure", "line", "connector":
+	default:
+		return p.d.Skip()
+	}
+
+	switch xmltree.Attr(t, "class") {
+	case "page-number", "header", "footer", "date-time", "page":
+		return p.d.Skip()
+	case "title":
+		blocks, err := p.blocks()
+		texts := make([]string, 0, len(blocks)+1)
+		if s.Title != "" {
+			texts = append(texts, s.Title)
+		}
+		for _, b := range blocks {
+			if text := strings.Join(strings.Fields(b.Text), " "); text != "" {
+				texts = append(texts, text)
+			}
+		}
+		s.Title = strings.Join(texts, " ")
+		return err
+	}
+
+	blocks, err := p.blocks()
+	s.Body = append(s.Body, blocks...)
+	return err
+}
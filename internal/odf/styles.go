@@ -0,0 +1,359 @@
+package odf
+
+import (
+	"cmp"
+	"encoding/xml"
+	"strconv"
+	"strings"
+
+	"github.com/pilinux/totext/internal/structure"
+	"github.com/pilinux/totext/internal/xmltree"
+)
+
+// maxLevels is the number of outline and list levels
+const maxLevels = 10
+
+// listLevel is the style of a level of a list or of the outline
+type listLevel struct {
+	bullet bool
+	// char is the bullet character
+	char string
+	// format is the number format, e.g. 1, a, A, i or I,
+	// no number is shown if it is empty
+	format         string
+	prefix, suffix string
+	// display is the number of levels shown in the label, e.g. 2 for 1.1
+	display int
+	start   int
+}
+
+// listStyle holds the styles of the levels of a list
+type listStyle [maxLevels]*listLevel
+
+// paragraphStyle holds the properties of a paragraph style
+// used for the structure of the document
+type paragraphStyle struct {
+	parent string
+	// outline is the default outline level of the headings, 0 if unset
+	outline int
+	// code is set for the styles of preformatted text
+	code bool
+}
+
+// textStyle holds the properties of a text style used for the format
+// of the spans, the properties which the style does not set are nil
+type textStyle struct {
+	parent                     string
+	bold, italic, strike, code *bool
+}
+
+// codeStyles are the names of the styles of preformatted text
+var codeStyles = map[string]bool{
+	"preformatted text": true,
+	"source text":       true,
+	"source code":       true,
+	"code":              true,
+}
+
+// counter holds the current numbers of the levels of a list
+type counter struct {
+	values  [maxLevels]int
+	started [maxLevels]bool
+}
+
+// styles reads the list styles, the outline style and the
+// paragraph styles of the styles element
+func (p *parser) styles() error {
+	return xmltree.Children(p.d, func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "list-style":
+			style := &listStyle{}
+			p.listStyles[xmltree.Attr(t, "name")] = style
+			return p.listStyle(style, "list-level-style-number", "list-level-style-bullet", "list-level-style-image")
+		case "outline-style":
+			return p.listStyle(&p.outline, "outline-level-style")
+		case "style":
+			switch xmltree.Attr(t, "family") {
+			case "paragraph":
+				level, _ := strconv.Atoi(xmltree.Attr(t, "default-outline-level"))
+				p.paragraphStyles[xmltree.Attr(t, "name")] = paragraphStyle{
+					parent:  xmltree.Attr(t, "parent-style-name"),
+					outline: level,
+					code:    isCodeStyle(t),
+				}
+			case "text":
+				return p.textStyle(t)
+			case "drawing-page":
+				return p.drawingPageStyle(t)
+			}
+		}
+		return p.d.Skip()
+	})
+}
+
+// isCodeStyle reports whether the style is a style of preformatted text.
+// Spaces are encoded as _20_ in the names of the styles.
+func isCodeStyle(t xml.StartElement) bool {
+	for _, name := range []string{xmltree.Attr(t, "name"), xmltree.Attr(t, "display-name")} {
+		if codeStyles[strings.ToLower(strings.ReplaceAll(name, "_20_", " "))] {
+			return true
+		}
+	}
+	return false
+}
+
+// textStyle reads the format of the text style t
+func (p *parser) textStyle(t xml.StartElement) error {
+	set := func(value bool) *bool {
+		return &value
+	}
+	s := textStyle{parent: xmltree.Attr(t, "parent-style-name")}
+	if isCodeStyle(t) {
+		s.code = set(true)
+	}
+
+	err := xmltree.Children(p.d, func(t xml.StartElement) error {
+		if t.Name.Local != "text-properties" {
+			return p.d.Skip()
+		}
+		for _, a := range t.Attr {
+			switch a.Name.Local {
+			case "font-weight":
+				weight, err := strconv.Atoi(a.Value)
+				s.bold = set(a.Value == "bold" || err == nil && weight >= 600)
+			case "font-style":
+				s.italic = set(a.Value == "italic" || a.Value == "oblique")
+			case "text-line-through-style":
+				s.strike = set(a.Value != "none")
+			case "font-name":
+				s.code = set(p.fixedFonts[a.Value] || structure.Monospace(a.Value))
+			case "font-family":
+				s.code = set(structure.Monospace(a.Value))
+			}
+		}
+		return p.d.Skip()
+	})
+	p.textStyles[xmltree.Attr(t, "name")] = s
+
+	return err
+}
+
+// drawingPageStyle reads whether the pages of the
+// drawing page style t are hidden
+func (p *parser) drawingPageStyle(t xml.StartElement) error {
+	name := xmltree.Attr(t, "name")
+	return xmltree.Children(p.d, func(t xml.StartElement) error {
+		if t.Name.Local == "drawing-page-properties" && xmltree.Attr(t, "visibility") == "hidden" {
+			p.hiddenPages[name] = true
+		}
+		return p.d.Skip()
+	})
+}
+
+// fontFaces reads the fonts with a fixed pitch
+func (p *parser) fontFaces() error {
+	return xmltree.Children(p.d, func(t xml.StartElement) error {
+		if t.Name.Local == "font-face" && xmltree.Attr(t, "font-pitch") == "fixed" {
+			p.fixedFonts[xmltree.Attr(t, "name")] = true
+		}
+		return p.d.Skip()
+	})
+}
+
+// textFormat applies the properties of the text style with the name
+// and of the styles it is based on to format
+func (p *parser) textFormat(name string, format *structure.Format) {
+	var bold, italic, strike, code *bool
+	// The chain of styles is limited in case it loops
+	for depth := 0; depth < 16; depth++ {
+		s, ok := p.textStyles[name]
+		if !ok {
+			break
+		}
+		bold, italic = cmp.Or(bold, s.bold), cmp.Or(italic, s.italic)
+		strike, code = cmp.Or(strike, s.strike), cmp.Or(code, s.code)
+		name = s.parent
+	}
+
+	for _, v := range []struct {
+		value *bool
+		field *bool
+	}{
+		{bold, &format.Bold},
+		{italic, &format.Italic},
+		{strike, &format.Strike},
+		{code, &format.Code},
+	} {
+		if v.value != nil {
+			*v.field = *v.value
+		}
+	}
+}
+
+// listStyle reads the level styles with the names into style
+func (p *parser) listStyle(style *listStyle, names ...string) error {
+	return xmltree.Children(p.d, func(t xml.StartElement) error {
+		level, err := strconv.Atoi(xmltree.Attr(t, "level"))
+		known := false
+		for _, name := range names {
+			known = known || t.Name.Local == name
+		}
+		if !known || err != nil || level < 1 || level > maxLevels {
+			return p.d.Skip()
+		}
+
+		lv := &listLevel{
+			format:  xmltree.Attr(t, "num-format"),
+			prefix:  xmltree.Attr(t, "num-prefix"),
+			suffix:  xmltree.Attr(t, "num-suffix"),
+			display: 1,
+			start:   1,
+		}
+		switch t.Name.Local {
+		case "list-level-style-bullet":
+			lv.bullet, lv.char = true, xmltree.Attr(t, "bullet-char")
+		case "list-level-style-image":
+			lv.bullet = true
+		}
+		if n, err := strconv.Atoi(xmltree.Attr(t, "display-levels")); err == nil && n > 0 {
+			lv.display = n
+		}
+		if n, err := strconv.Atoi(xmltree.Attr(t, "start-value")); err == nil {
+			lv.start = n
+		}
+		style[level-1] = lv
+
+		return p.d.Skip()
+	})
+}
+
+// outlineLevel returns the default outline level of the
+// paragraph style, 0 if it has none
+func (p *parser) outlineLevel(name string) int {
+	// The chain of styles is limited in case it loops
+	for depth := 0; depth < 16; depth++ {
+		s, ok := p.paragraphStyles[name]
+		if !ok {
+			break
+		}
+		if s.outline > 0 {
+			return s.outline
+		}
+		name = s.parent
+	}
+
+	return 0
+}
+
+// isCode reports whether the paragraph style with the name
+// or a style it is based on is a style of preformatted text
+func (p *parser) isCode(name string) bool {
+	for depth := 0; depth < 16; depth++ {
+		s, ok := p.paragraphStyles[name]
+		if !ok {
+			break
+		}
+		if s.code {
+			return true
+		}
+		name = s.parent
+	}
+
+	return false
+}
+
+// next advances the counter of the list level and returns the label of
+// the item. start is the start value of the item, -1 to continue.
+func (c *counter) next(style *listStyle, level, start int) string {
+	startOf := func(i int) int {
+		if style != nil && style[i] != nil {
+			return style[i].start
+		}
+		return 1
+	}
+
+	switch {
+	case start >= 0:
+		c.values[level], c.started[level] = start, true
+	case c.started[level]:
+		c.values[level]++
+	default:
+		c.values[level], c.started[level] = startOf(level), true
+	}
+	// The deeper levels start again
+	for i := level + 1; i < maxLevels; i++ {
+		c.started[i] = false
+	}
+
+	if style == nil || style[level] == nil {
+		return ""
+	}
+	lv := style[level]
+	if lv.bullet {
+		return lv.prefix + structure.Bullet(lv.char) + lv.suffix
+	}
+	if lv.format == "" {
+		return strings.TrimSpace(lv.prefix + lv.suffix)
+	}
+
+	// The label shows the numbers of the upper levels, e.g. 1.2
+	var numbers []string
+	for i := max(level-lv.display+1, 0); i <= level; i++ {
+		value := startOf(i)
+		if c.started[i] {
+			value = c.values[i]
+		}
+		format := lv.format
+		if style[i] != nil && style[i].format != "" {
+			format = style[i].format
+		}
+		numbers = append(numbers, formatNumber(value, format))
+	}
+
+	return lv.prefix + strings.Join(numbers, ".") + lv.suffix
+}
+
+// formatNumber formats the number of a list level
+func formatNumber(n int, format string) string {
+	switch format {
+	case "a":
+		return structure.Letters(n, 'a')
+	case "A":
+		return structure.Letters(n, 'A')
+	case "i":
+		return strings.ToLower(structure.Roman(n))
+	case "I":
+		return structure.Roman(n)
+	}
+	return strconv.Itoa(n)
+}
+
+// masterStyles reads the headers and footers of the master pages
+func (p *parser) masterStyles() error {
+	return xmltree.Children(p.d, func(t xml.StartElement) error {
+		if t.Name.Local != "master-page" {
+			return p.d.Skip()
+		}
+
+		return xmltree.Children(p.d, func(t xml.StartElement) error {
+			var parts *[][]structure.Block
+			switch t.Name.Local {
+			case "header", "header-left", "header-first":
+				parts = &p.doc.Headers
+			case "footer", "footer-left", "footer-first":
+				parts = &p.doc.Footers
+			default:
+				return p.d.Skip()
+			}
+			if xmltree.Attr(t, "display") == "false" {
+				return p.d.Skip()
+			}
+
+			blocks, err := p.blocks()
+			if len(blocks) > 0 {
+				*parts = append(*parts, blocks)
+			}
+			return err
+		})
+	})
+}
@@ -0,0 +1,226 @@
+// Package opc reads the zip packages of the Office Open XML documents,
+// e.g. docx, xlsx and pptx files, as defined by the Open Packaging Conventions.
+//
+// The parts of a package refer to each other through the relationships
+// stored in the _rels folder next to them. The package relationships
+// point to the main part and to the core and extended properties.
+package opc
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/pilinux/totext/internal/cfb"
+)
+
+var (
+	// ErrNotPackage is returned when the content is not a zip package
+	ErrNotPackage = errors.New("not an Office Open XML package")
+
+	// ErrEncrypted is returned when the package is encrypted
+	ErrEncrypted = errors.New("encrypted Office Open XML package")
+
+	// ErrMalformed is returned when a part cannot be read
+	ErrMalformed = errors.New("malformed Office Open XML package")
+)
+
+// MaxPartSize limits the size of the parts read into memory
+const MaxPartSize = 256 << 20
+
+// Package is a zip package
+type Package struct {
+	// files maps the lower case part names to the files
+	files map[string]*zip.File
+}
+
+// Rels holds the targets of the relationships of a part
+type Rels struct {
+	// ByID maps the relationship ids to the part names
+	ByID map[string]string
+	// ByType maps the last element of the relationship types,
+	// e.g. worksheet or slide, to the part names
+	ByType map[string][]string
+	// Links maps the ids of the external relationships,
+	// e.g. hyperlinks, to their targets
+	Links map[string]string
+}
+
+// First returns the first target of the relationship type
+func (r Rels) First(typ string) string {
+	if targets := r.ByType[typ]; len(targets) > 0 {
+		return targets[0]
+	}
+	return ""
+}
+
+// Open opens the package of the given size
+func Open(ra io.ReaderAt, size int64) (*Package, error) {
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		// Encrypted packages are stored in compound files
+		signature := make([]byte, len(cfb.Signature))
+		if _, e := ra.ReadAt(signature, 0); e == nil && bytes.Equal(signature, cfb.Signature) {
+			return nil, ErrEncrypted
+		}
+		return nil, fmt.Errorf("%w: %v", ErrNotPackage, err)
+	}
+
+	pk := &Package{files: make(map[string]*zip.File, len(zr.File))}
+	for _, f := range zr.File {
+		pk.files[strings.ToLower(strings.TrimPrefix(f.Name, "/"))] = f
+	}
+	return pk, nil
+}
+
+// File returns the part with the name, part names are case-insensitive
+func (pk *Package) File(name string) *zip.File {
+	return pk.files[strings.ToLower(strings.TrimPrefix(name, "/"))]
+}
+
+// Open opens the part with the name
+func (pk *Package) Open(name string) (io.ReadCloser, error) {
+	f := pk.File(name)
+	if f == nil {
+		return nil, fmt.Errorf("%w: missing %s", ErrMalformed, name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrMalformed, name, err)
+	}
+	return rc, nil
+}
+
+// Read returns the content of the part with the name
+func (pk *Package) Read(name string) ([]byte, error) {
+	rc, err := pk.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	data, err := io.ReadAll(io.LimitReader(rc, MaxPartSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrMalformed, name, err)
+	}
+	if len(data) > MaxPartSize {
+		return nil, fmt.Errorf("%w: %s is too large", ErrMalformed, name)
+	}
+	return data, nil
+}
+
+// Rels reads the relationships of the part with the name,
+// the package relationships if name is empty
+func (pk *Package) Rels(name string) (Rels, error) {
+	r := Rels{ByID: make(map[string]string), ByType: make(map[string][]string), Links: make(map[string]string)}
+	dir, base := path.Split(name)
+	relsName := path.Join(dir, "_rels", base+".rels")
+	if pk.File(relsName) == nil {
+		return r, nil
+	}
+
+	data, err := pk.Read(relsName)
+	if err != nil {
+		return r, err
+	}
+	var v struct {
+		Relationships []struct {
+			ID         string `xml:"Id,attr"`
+			Type       string `xml:"Type,attr"`
+			Target     string `xml:"Target,attr"`
+			TargetMode string `xml:"TargetMode,attr"`
+		} `xml:"Relationship"`
+	}
+	if err = xml.Unmarshal(data, &v); err != nil {
+		return r, fmt.Errorf("%w: %s: %v", ErrMalformed, relsName, err)
+	}
+
+	for _, rel := range v.Relationships {
+		if strings.EqualFold(rel.TargetMode, "External") {
+			r.Links[rel.ID] = rel.Target
+			continue
+		}
+		// Targets are relative to the folder of the source part
+		target := strings.TrimPrefix(rel.Target, "/")
+		if !strings.HasPrefix(rel.Target, "/") {
+			target = path.Join(dir, rel.Target)
+		}
+		r.ByID[rel.ID] = target
+		typ := path.Base(rel.Type)
+		r.ByType[typ] = append(r.ByType[typ], target)
+	}
+
+	return r, nil
+}
+
+// Properties reads the simple elements of the core and the extended
+// properties of the package by their local name, e.g. title, creator,
+// modified or Application
+func (pk *Package) Properties() (map[string]string, error) {
+	props := make(map[string]string)
+	rels, err := pk.Rels("")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, typ := range []string{"core-properties", "extended-properties"} {
+		name := rels.First(typ)
+		if name == "" || pk.File(name) == nil {
+			continue
+		}
+		data, err := pk.Read(name)
+		if err != nil {
+			return nil, err
+		}
+		if err = properties(data, props); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrMalformed, name, err)
+		}
+	}
+
+	return props, nil
+}
+
+// properties reads the elements of the properties part which hold text
+// into props, the elements with children are left out
+func properties(data []byte, props map[string]string) error {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	depth := 0
+	// leaf reports whether the current element has no children
+	leaf := false
+	var name string
+	var text strings.Builder
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			leaf = depth == 2
+			name = t.Name.Local
+			text.Reset()
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if leaf && depth == 2 {
+				if value := strings.TrimSpace(text.String()); value != "" {
+					props[name] = value
+				}
+			}
+			leaf = false
+			depth--
+		}
+	}
+}
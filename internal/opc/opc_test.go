@@ -0,0 +1,137 @@
+package opc
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/pilinux/totext/internal/cfb"
+	"github.com/pilinux/totext/internal/fixture"
+)
+
+// testPackage returns a package with a main part, a relative and
+// an external relationship of the main part and the properties
+func testPackage(t *testing.T) *Package {
+	t.Helper()
+
+	data := fixture.Zip(t, map[string]string{
+		"_rels/.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>` +
+			`<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/extended-properties" Target="/docProps/app.xml"/>` +
+			`</Relationships>`,
+		"xl/workbook.xml": `<workbook/>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="../xl/worksheets/Sheet2.xml"/>` +
+			`<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="https://example.com" TargetMode="External"/>` +
+			`</Relationships>`,
+		"xl/worksheets/sheet1.xml": `<worksheet/>`,
+		"docProps/core.xml": `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" ` +
+			`xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/">` +
+			`<dc:title>Budget</dc:title><dc:creator>Jane Doe</dc:creator>` +
+			`<dcterms:created>2021-03-04T05:06:07Z</dcterms:created></cp:coreProperties>`,
+		"docProps/app.xml": `<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties">` +
+			`<Application>Microsoft Excel</Application><TitlesOfParts><vector><lpstr>Sheet1</lpstr></vector></TitlesOfParts></Properties>`,
+	})
+
+	pk, err := Open(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return pk
+}
+
+// TestRels tests the targets of the relationships
+func TestRels(t *testing.T) {
+	pk := testPackage(t)
+
+	rels, err := pk.Rels("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rels.First("officeDocument") != "xl/workbook.xml" || rels.First("missing") != "" {
+		t.Errorf("unexpected package relationships %v", rels)
+	}
+
+	rels, err = pk.Rels("xl/workbook.xml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Test data
+	testData := []struct {
+		id       string
+		expected string
+	}{
+		{"rId1", "xl/worksheets/sheet1.xml"},
+		{"rId2", "xl/worksheets/Sheet2.xml"},
+		{"rId3", ""},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		if target := rels.ByID[td.id]; target != td.expected {
+			t.Errorf("%s: expected target %q, got %q", td.id, td.expected, target)
+		}
+	}
+	if len(rels.ByType["worksheet"]) != 2 {
+		t.Errorf("unexpected worksheets %v", rels.ByType["worksheet"])
+	}
+	if rels.Links["rId3"] != "https://example.com" {
+		t.Errorf("unexpected links %v", rels.Links)
+	}
+
+	// Part names are case-insensitive
+	if pk.File("XL/Worksheets/Sheet1.xml") == nil {
+		t.Error("expected the part of the worksheet")
+	}
+	if _, err = pk.Read("xl/worksheets/sheet2.xml"); !errors.Is(err, ErrMalformed) {
+		t.Errorf("expected %v, got %v", ErrMalformed, err)
+	}
+}
+
+// TestProperties tests the core and the extended properties
+func TestProperties(t *testing.T) {
+	props, err := testPackage(t).Properties()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Test data
+	testData := map[string]string{
+		"title":         "Budget",
+		"creator":       "Jane Doe",
+		"created":       "2021-03-04T05:06:07Z",
+		"Application":   "Microsoft Excel",
+		"TitlesOfParts": "",
+	}
+
+	// Iterate over test data
+	for name, expected := range testData {
+		if props[name] != expected {
+			t.Errorf("%s: expected %q, got %q", name, expected, props[name])
+		}
+	}
+}
+
+// TestOpenErrors tests the errors of the content which is not a package
+func TestOpenErrors(t *testing.T) {
+	// Test data
+	testData := []struct {
+		name     string
+		data     []byte
+		expected error
+	}{
+		{"plain text", []byte("plain text"), ErrNotPackage},
+		{"encrypted", append(append([]byte{}, cfb.Signature...), make([]byte, 504)...), ErrEncrypted},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		_, err := Open(bytes.NewReader(td.data), int64(len(td.data)))
+		if !errors.Is(err, td.expected) {
+			t.Errorf("%s: expected %v, got %v", td.name, td.expected, err)
+		}
+	}
+}
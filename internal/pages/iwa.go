@@ -0,0 +1,150 @@
+package pages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// maxArchiveSize limits the decompressed size of an IWA file
+const maxArchiveSize = 256 << 20
+
+// message is a protobuf message of an archived object
+type message struct {
+	// typ identifies the message type, e.g. 2001 for text storages
+	typ  uint64
+	data []byte
+}
+
+// object is an object archived in an IWA file
+type object struct {
+	id       uint64
+	messages []message
+}
+
+// decodeIWA decompresses the chunks of an IWA file. Every chunk starts
+// with a zero byte and the 24 bit little endian length of its snappy
+// compressed block, without the checksums of the snappy framing format.
+func decodeIWA(ctx context.Context, data []byte) ([]byte, error) {
+	var out []byte
+	for len(data) > 0 {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if len(data) < 4 || data[0] != 0 {
+			return nil, errors.New("invalid IWA chunk header")
+		}
+		length := int(data[1]) | int(data[2])<<8 | int(data[3])<<16
+		if len(data) < 4+length {
+			return nil, errors.New("truncated IWA chunk")
+		}
+
+		var err error
+		out, err = decodeSnappy(out, data[4:4+length], maxArchiveSize-len(out))
+		if err != nil {
+			return nil, err
+		}
+		data = data[4+length:]
+	}
+
+	return out, nil
+}
+
+// readObjects reads the objects of a decompressed IWA file. Every object
+// is an ArchiveInfo message, prefixed with its length, which lists the
+// types and the lengths of the messages following it.
+func readObjects(data []byte) ([]object, error) {
+	var objects []object
+	for len(data) > 0 {
+		length, n := protowire.ConsumeVarint(data)
+		if n < 0 || length > uint64(len(data)-n) {
+			return nil, errors.New("truncated archive info")
+		}
+		info := data[n : n+int(length)]
+		data = data[n+int(length):]
+
+		var obj object
+		var lengths []uint64
+		err := fields(info, func(num protowire.Number, v uint64, b []byte) error {
+			switch num {
+			case 1:
+				obj.id = v
+			case 2:
+				// MessageInfo with its type and the length of its payload
+				var typ, size uint64
+				err := fields(b, func(num protowire.Number, v uint64, _ []byte) error {
+					switch num {
+					case 1:
+						typ = v
+					case 3:
+						size = v
+					}
+					return nil
+				})
+				obj.messages = append(obj.messages, message{typ: typ})
+				lengths = append(lengths, size)
+				return err
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for i, size := range lengths {
+			if size > uint64(len(data)) {
+				return nil, fmt.Errorf("truncated message of object %d", obj.id)
+			}
+			obj.messages[i].data = data[:size]
+			data = data[size:]
+		}
+		objects = append(objects, obj)
+	}
+
+	return objects, nil
+}
+
+// fields calls fn with the number and the value of the fields of the
+// protobuf message, v holds the varint and fixed values and b the length
+// delimited values. Groups are skipped.
+func fields(data []byte, fn func(num protowire.Number, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		var v uint64
+		var b []byte
+		switch typ {
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(data)
+		case protowire.Fixed32Type:
+			var v32 uint32
+			v32, n = protowire.ConsumeFixed32(data)
+			v = uint64(v32)
+		case protowire.Fixed64Type:
+			v, n = protowire.ConsumeFixed64(data)
+		case protowire.BytesType:
+			b, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		if typ == protowire.StartGroupType {
+			continue
+		}
+		if err := fn(num, v, b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
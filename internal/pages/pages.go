@@ -0,0 +1,176 @@
+// Package pages reads the text of Apple Pages documents.
+//
+// Documents of Pages 5 and later are zip bundles of IWA files, which
+// hold snappy compressed protobuf messages, the text is in the storages
+// of Index/Document.iwa. Documents of earlier versions hold an XML index.
+// The bundles also hold a preview of the document, a PDF file in older
+// versions and a JPEG image in newer ones.
+package pages
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pilinux/totext/internal/structure"
+)
+
+var (
+	// ErrNotPages is returned when the content is not a Pages document
+	ErrNotPages = errors.New("not a Pages document")
+
+	// ErrEncrypted is returned when the document is protected by a password
+	ErrEncrypted = errors.New("encrypted Pages document")
+
+	// ErrMalformed is returned when the document cannot be decoded
+	ErrMalformed = errors.New("malformed Pages document")
+
+	// ErrNoText is returned when the document has no text which can be
+	// decoded, its preview may still be read
+	ErrNoText = errors.New("no text in Pages document")
+
+	// ErrNoPreview is returned when the document has no preview
+	ErrNoPreview = errors.New("no preview in Pages document")
+)
+
+// maxEntrySize limits the size of the entries read into memory
+const maxEntrySize = 256 << 20
+
+// Names of the entries of the bundles
+const (
+	documentIWA = "Index/Document.iwa"
+	indexZip    = "Index.zip"
+	indexXML    = "index.xml"
+	indexXMLGz  = "index.xml.gz"
+)
+
+// previews are the names of the previews, in order of preference
+var previews = []string{"QuickLook/Preview.pdf", "preview.jpg"}
+
+// passwordFiles are the entries of the documents protected by a password
+var passwordFiles = []string{".iwpv2", ".iwph"}
+
+// Read reads the Pages document of the given size
+func Read(ctx context.Context, ra io.ReaderAt, size int64) (*structure.Document, error) {
+	files, err := entries(ra, size)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range passwordFiles {
+		if files[name] != nil {
+			return nil, ErrEncrypted
+		}
+	}
+
+	// Bundles saved as packages keep the index in a nested archive
+	if files[documentIWA] == nil && files[indexZip] != nil {
+		data, err := readEntry(files[indexZip])
+		if err != nil {
+			return nil, err
+		}
+		nested, err := entries(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrMalformed, indexZip, err)
+		}
+		for name, f := range nested {
+			if files[name] == nil {
+				files[name] = f
+			}
+		}
+	}
+
+	var doc *structure.Document
+	switch {
+	case files[documentIWA] != nil:
+		doc, err = readIWA(ctx, files[documentIWA])
+	case files[indexXML] != nil:
+		doc, err = readXML(ctx, files[indexXML], false)
+	case files[indexXMLGz] != nil:
+		doc, err = readXML(ctx, files[indexXMLGz], true)
+	default:
+		if previewEntry(files) != nil {
+			return nil, ErrNoText
+		}
+		return nil, ErrNotPages
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(doc.Body) == 0 && len(doc.Headers) == 0 && len(doc.Footnotes) == 0 && len(doc.Comments) == 0 {
+		return doc, ErrNoText
+	}
+
+	return doc, nil
+}
+
+// Preview opens the preview of the Pages document of the given size
+// and returns its name, either QuickLook/Preview.pdf or preview.jpg
+func Preview(ra io.ReaderAt, size int64) (string, io.ReadCloser, error) {
+	files, err := entries(ra, size)
+	if err != nil {
+		return "", nil, err
+	}
+
+	f := previewEntry(files)
+	if f == nil {
+		return "", nil, ErrNoPreview
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %s: %v", ErrMalformed, f.Name, err)
+	}
+
+	return f.Name, rc, nil
+}
+
+// entries returns the entries of the bundle by name
+func entries(ra io.ReaderAt, size int64) (map[string]*zip.File, error) {
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotPages, err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[strings.TrimPrefix(f.Name, "/")] = f
+	}
+
+	return files, nil
+}
+
+// previewEntry returns the preferred preview of the bundle, nil if it has none
+func previewEntry(files map[string]*zip.File) *zip.File {
+	for _, name := range previews {
+		if f := files[name]; f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// readEntry reads the content of the entry
+func readEntry(f *zip.File) ([]byte, error) {
+	if f.UncompressedSize64 > maxEntrySize {
+		return nil, fmt.Errorf("%w: %s: too large", ErrMalformed, f.Name)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrMalformed, f.Name, err)
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxEntrySize))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrMalformed, f.Name, err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,265 @@
+package pages
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/pilinux/totext/internal/fixture"
+	"github.com/pilinux/totext/internal/structure"
+)
+
+// snappyLiterals compresses the data into a snappy block of literals
+func snappyLiterals(data []byte) []byte {
+	block := binary.AppendUvarint(nil, uint64(len(data)))
+	for len(data) > 0 {
+		n := min(len(data), 256)
+		if n <= 60 {
+			block = append(block, byte(n-1)<<2)
+		} else {
+			block = append(block, 60<<2, byte(n-1))
+		}
+		block = append(block, data[:n]...)
+		data = data[n:]
+	}
+	return block
+}
+
+// storageMessage returns a text storage message of the kind
+func storageMessage(kind int, text ...string) []byte {
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(kind))
+	for _, s := range text {
+		b = protowire.AppendTag(b, 3, protowire.BytesType)
+		b = protowire.AppendString(b, s)
+	}
+	return b
+}
+
+// buildIWA returns an IWA file with one object for each message,
+// split into chunks of the given size
+func buildIWA(typ uint64, messages [][]byte, chunk int) []byte {
+	var stream []byte
+	for i, m := range messages {
+		var info []byte
+		info = protowire.AppendTag(info, 3, protowire.BytesType)
+		info = protowire.AppendString(info, "ignored")
+		info = protowire.AppendTag(info, 3, protowire.StartGroupType)
+		info = protowire.AppendTag(info, 3, protowire.EndGroupType)
+		var mi []byte
+		mi = protowire.AppendTag(mi, 1, protowire.VarintType)
+		mi = protowire.AppendVarint(mi, typ)
+		mi = protowire.AppendTag(mi, 3, protowire.VarintType)
+		mi = protowire.AppendVarint(mi, uint64(len(m)))
+		info = protowire.AppendTag(info, 1, protowire.VarintType)
+		info = protowire.AppendVarint(info, uint64(i+1))
+		info = protowire.AppendTag(info, 2, protowire.BytesType)
+		info = protowire.AppendBytes(info, mi)
+
+		stream = protowire.AppendVarint(stream, uint64(len(info)))
+		stream = append(stream, info...)
+		stream = append(stream, m...)
+	}
+
+	var iwa []byte
+	for len(stream) > 0 {
+		n := min(len(stream), chunk)
+		block := snappyLiterals(stream[:n])
+		iwa = append(iwa, 0, byte(len(block)), byte(len(block)>>8), byte(len(block)>>16))
+		iwa = append(iwa, block...)
+		stream = stream[n:]
+	}
+	return iwa
+}
+
+// render returns the text of the paragraphs separated by |
+func render(blocks []structure.Block) string {
+	var texts []string
+	for _, b := range blocks {
+		texts = append(texts, b.Text)
+	}
+	return strings.Join(texts, "|")
+}
+
+// TestDecodeSnappy tests the decompression of literals and copies
+func TestDecodeSnappy(t *testing.T) {
+	// Test data
+	testData := []struct {
+		name     string
+		block    []byte
+		expected string
+		err      bool
+	}{
+		{"literal", snappyLiterals([]byte("hello")), "hello", false},
+		{"long literal", snappyLiterals(bytes.Repeat([]byte("x"), 300)), strings.Repeat("x", 300), false},
+		// "ab" followed by a copy of 6 bytes at offset 2, which overlaps its output
+		{"copy 1", []byte{8, 1 << 2, 'a', 'b', 0x01 | 2<<2, 2}, "abababab", false},
+		{"copy 2", []byte{5, 1 << 2, 'a', 'b', 0x02 | 2<<2, 2, 0}, "ababa", false},
+		{"copy 4", []byte{4, 0, 'a', 0x03 | 2<<2, 1, 0, 0, 0}, "aaaa", false},
+		{"bad offset", []byte{4, 0, 'a', 0x02 | 2<<2, 5, 0}, "", true},
+		{"bad length", []byte{9, 0, 'a'}, "", true},
+		{"truncated", []byte{5, 4 << 2, 'a'}, "", true},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		got, err := decodeSnappy(nil, td.block, 1<<20)
+		if (err != nil) != td.err || string(got) != td.expected {
+			t.Errorf("%s: expected %q (error %v), got %q (%v)", td.name, td.expected, td.err, got, err)
+		}
+	}
+}
+
+// TestReadIWA tests the text storages of an IWA document
+func TestReadIWA(t *testing.T) {
+	iwa := buildIWA(2001, [][]byte{
+		storageMessage(kindBody, "Title\nFirst", " paragraph\u2028line\ttab\ufffc\u2029", "Second\f"),
+		storageMessage(kindHeader, "Header"),
+		storageMessage(kindTextBox, "Box"),
+		storageMessage(kindFootnote, "Note"),
+		storageMessage(kindNote, "Comment"),
+		storageMessage(kindBody, "\n"),
+	}, 40)
+	data := fixture.Zip(t, map[string][]byte{
+		"Index/Document.iwa":  iwa,
+		"Index/Metadata.iwa":  nil,
+		"preview.jpg":         []byte("jpeg"),
+		"Metadata/Properties": nil,
+	})
+
+	doc, err := Read(context.Background(), bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := render(doc.Body); got != "Title|First paragraph\nline\ttab|Second|Box" {
+		t.Errorf("unexpected body %q", got)
+	}
+	if len(doc.Headers) != 1 || render(doc.Headers[0]) != "Header" {
+		t.Errorf("unexpected headers %v", doc.Headers)
+	}
+	if len(doc.Footnotes) != 1 || doc.Footnotes[0].ID != "1" || render(doc.Footnotes[0].Blocks) != "Note" {
+		t.Errorf("unexpected footnotes %v", doc.Footnotes)
+	}
+	if len(doc.Comments) != 1 || render(doc.Comments[0].Blocks) != "Comment" {
+		t.Errorf("unexpected comments %v", doc.Comments)
+	}
+
+	// Bundles saved as packages nest the index
+	nested := fixture.Zip(t, map[string][]byte{
+		"Index.zip": fixture.Zip(t, map[string][]byte{"Index/Document.iwa": iwa}),
+	})
+	doc, err = Read(context.Background(), bytes.NewReader(nested), int64(len(nested)))
+	if err != nil || !strings.HasPrefix(render(doc.Body), "Title|") {
+		t.Errorf("unexpected nested document %v (%v)", doc, err)
+	}
+}
+
+// TestReadXML tests the XML index of older documents
+func TestReadXML(t *testing.T) {
+	index := `<?xml version="1.0"?><sl:document xmlns:sl="http://developer.apple.com/namespaces/sl" ` +
+		`xmlns:sf="http://developer.apple.com/namespaces/sf" xmlns:sfa="http://developer.apple.com/namespaces/sfa">` +
+		`<sl:metadata><sf:title><sf:string sfa:string="Report"/></sf:title>` +
+		`<sf:authors><sf:string sfa:string="Jane Doe"/><sf:string sfa:string="John Roe"/></sf:authors></sl:metadata>` +
+		`<sl:stylesheet><sf:p>Style</sf:p></sl:stylesheet>` +
+		`<sf:text-storage><sf:text-body><sf:section><sf:layout><sf:p>First<sf:tab/>line<sf:br/>next</sf:p>` +
+		`<sf:p>Second</sf:p></sf:layout></sf:section></sf:text-body></sf:text-storage></sl:document>`
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	if _, err := io.WriteString(zw, index); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Test data
+	testData := []map[string][]byte{
+		{"index.xml": []byte(index)},
+		{"index.xml.gz": gz.Bytes()},
+	}
+
+	// Iterate over test data
+	for _, entries := range testData {
+		data := fixture.Zip(t, entries)
+		doc, err := Read(context.Background(), bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := render(doc.Body); got != "First\tline\nnext|Second" {
+			t.Errorf("unexpected body %q", got)
+		}
+		if doc.Properties["title"] != "Report" || doc.Properties["authors"] != "Jane Doe, John Roe" {
+			t.Errorf("unexpected properties %v", doc.Properties)
+		}
+	}
+}
+
+// TestReadErrors tests the errors of invalid documents
+func TestReadErrors(t *testing.T) {
+	// Test data
+	testData := []struct {
+		name     string
+		data     []byte
+		expected error
+	}{
+		{"not a zip", []byte("plain text"), ErrNotPages},
+		{"other zip", fixture.Zip(t, map[string][]byte{"readme.txt": nil}), ErrNotPages},
+		{"encrypted", fixture.Zip(t, map[string][]byte{".iwpv2": nil, "Index/Document.iwa": nil}), ErrEncrypted},
+		{"bad chunk", fixture.Zip(t, map[string][]byte{"Index/Document.iwa": []byte{1, 2, 3, 4}}), ErrMalformed},
+		{"bad archive", fixture.Zip(t, map[string][]byte{
+			"Index/Document.iwa": append([]byte{0, 3, 0, 0}, snappyLiterals([]byte{10})...)[:4+2],
+		}), ErrMalformed},
+		{"no text", fixture.Zip(t, map[string][]byte{"Index/Document.iwa": buildIWA(2001, nil, 10)}), ErrNoText},
+		{"only preview", fixture.Zip(t, map[string][]byte{"QuickLook/Preview.pdf": nil}), ErrNoText},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		_, err := Read(context.Background(), bytes.NewReader(td.data), int64(len(td.data)))
+		if !errors.Is(err, td.expected) {
+			t.Errorf("%s: expected %v, got %v", td.name, td.expected, err)
+		}
+	}
+}
+
+// TestPreview tests the choice of the preview
+func TestPreview(t *testing.T) {
+	// Test data
+	testData := []struct {
+		entries  map[string][]byte
+		expected string
+	}{
+		{map[string][]byte{"preview.jpg": []byte("jpg"), "QuickLook/Preview.pdf": []byte("pdf")}, "QuickLook/Preview.pdf"},
+		{map[string][]byte{"preview.jpg": []byte("jpg"), "preview-micro.jpg": nil}, "preview.jpg"},
+		{map[string][]byte{"QuickLook/Thumbnail.jpg": nil}, ""},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		data := fixture.Zip(t, td.entries)
+		name, rc, err := Preview(bytes.NewReader(data), int64(len(data)))
+		if td.expected == "" {
+			if !errors.Is(err, ErrNoPreview) {
+				t.Errorf("expected ErrNoPreview, got %v", err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if name != td.expected || err != nil || string(content) != string(td.entries[name]) {
+			t.Errorf("expected %s, got %s %q (%v)", td.expected, name, content, err)
+		}
+	}
+}
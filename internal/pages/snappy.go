@@ -0,0 +1,88 @@
+package pages
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// errSnappy is returned for invalid snappy compressed data
+var errSnappy = errors.New("invalid snappy data")
+
+// decodeSnappy decompresses a snappy block. The decompressed data
+// is appended to dst, its length may not exceed limit.
+func decodeSnappy(dst, src []byte, limit int) ([]byte, error) {
+	n, k := binary.Uvarint(src)
+	if k <= 0 || n > uint64(limit) {
+		return nil, errSnappy
+	}
+	start := len(dst)
+	end := start + int(n)
+	if cap(dst) < end {
+		grown := make([]byte, start, end)
+		copy(grown, dst)
+		dst = grown
+	}
+
+	for s := k; s < len(src); {
+		var length, offset int
+		switch src[s] & 0x03 {
+		case 0x00:
+			// Literal, long lengths follow the tag in 1 to 4 bytes
+			length = int(src[s] >> 2)
+			s++
+			if length >= 60 {
+				size := length - 59
+				if s+size > len(src) {
+					return nil, errSnappy
+				}
+				length = 0
+				for i := size - 1; i >= 0; i-- {
+					length = length<<8 | int(src[s+i])
+				}
+				s += size
+			}
+			length++
+			if length <= 0 || s+length > len(src) || len(dst)+length > end {
+				return nil, errSnappy
+			}
+			dst = append(dst, src[s:s+length]...)
+			s += length
+			continue
+		case 0x01:
+			if s+2 > len(src) {
+				return nil, errSnappy
+			}
+			length = 4 + int(src[s]>>2&0x07)
+			offset = int(src[s]&0xe0)<<3 | int(src[s+1])
+			s += 2
+		case 0x02:
+			if s+3 > len(src) {
+				return nil, errSnappy
+			}
+			length = 1 + int(src[s]>>2)
+			offset = int(binary.LittleEndian.Uint16(src[s+1:]))
+			s += 3
+		case 0x03:
+			if s+5 > len(src) {
+				return nil, errSnappy
+			}
+			length = 1 + int(src[s]>>2)
+			offset = int(binary.LittleEndian.Uint32(src[s+1:]))
+			s += 5
+		}
+
+		// Copies may overlap the bytes they produce
+		if offset <= 0 || offset > len(dst)-start || len(dst)+length > end {
+			return nil, errSnappy
+		}
+		for i := 0; i < length; i++ {
+			dst = append(dst, dst[len(dst)-offset])
+		}
+	}
+
+	if len(dst) != end {
+		return nil, errSnappy
+	}
+
+	return dst, nil
+}
@@ -0,0 +1,129 @@
+package pages
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/pilinux/totext/internal/structure"
+)
+
+// Types of the messages of the text storages, TSWP.StorageArchive
+var storageTypes = map[uint64]bool{2001: true, 2005: true}
+
+// Kinds of the text storages
+const (
+	kindBody     = 0
+	kindHeader   = 1
+	kindFootnote = 2
+	kindTextBox  = 3
+	kindNote     = 4
+)
+
+// readIWA reads the text storages of the document archive
+func readIWA(ctx context.Context, f *zip.File) (*structure.Document, error) {
+	data, err := readEntry(f)
+	if err != nil {
+		return nil, err
+	}
+	data, err = decodeIWA(ctx, data)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrMalformed, f.Name, err)
+	}
+	objects, err := readObjects(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrMalformed, f.Name, err)
+	}
+
+	doc := &structure.Document{Properties: make(map[string]string)}
+	// The text boxes and the other storages follow the body
+	var extra []structure.Block
+	for _, obj := range objects {
+		for _, m := range obj.messages {
+			if !storageTypes[m.typ] {
+				continue
+			}
+			kind, text, err := storage(m.data)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: object %d: %v", ErrMalformed, f.Name, obj.id, err)
+			}
+			blocks := paragraphs(text)
+			if len(blocks) == 0 {
+				continue
+			}
+
+			switch kind {
+			case kindBody:
+				doc.Body = append(doc.Body, blocks...)
+			case kindHeader:
+				doc.Headers = append(doc.Headers, blocks)
+			case kindFootnote:
+				id := strconv.Itoa(len(doc.Footnotes) + 1)
+				doc.Footnotes = append(doc.Footnotes, structure.Note{ID: id, Blocks: blocks})
+			case kindNote:
+				id := strconv.Itoa(len(doc.Comments) + 1)
+				doc.Comments = append(doc.Comments, structure.Note{ID: id, Blocks: blocks})
+			default:
+				extra = append(extra, blocks...)
+			}
+		}
+	}
+	doc.Body = append(doc.Body, extra...)
+
+	return doc, nil
+}
+
+// storage returns the kind and the text of a text storage message
+func storage(data []byte) (int, string, error) {
+	kind := kindTextBox
+	var text strings.Builder
+	err := fields(data, func(num protowire.Number, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			kind = int(v)
+		case 3:
+			text.Write(b)
+		}
+		return nil
+	})
+
+	return kind, text.String(), err
+}
+
+// paragraphs splits the text of a storage into paragraphs
+func paragraphs(text string) []structure.Block {
+	var blocks []structure.Block
+	var p strings.Builder
+	flush := func() {
+		if s := p.String(); strings.TrimSpace(s) != "" {
+			blocks = append(blocks, structure.Block{Kind: structure.Paragraph, Text: s})
+		}
+		p.Reset()
+	}
+
+	for _, r := range text {
+		switch {
+		case r == '\n' || r == '\r' || r == '\u2029' || r == '\f' || r == '\x04':
+			// Paragraph, page and section breaks
+			flush()
+		case r == '\u2028' || r == '\v':
+			p.WriteByte('\n')
+		case r == '\t':
+			p.WriteByte('\t')
+		case r < ' ' || r == '\ufffc':
+			// Anchors of the attachments, e.g. images and footnotes
+		default:
+			p.WriteRune(r)
+		}
+	}
+	flush()
+
+	return blocks
+}
@@ -0,0 +1,115 @@
+package pages
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pilinux/totext/internal/structure"
+)
+
+// readXML reads the XML index of the documents of Pages 4 and earlier,
+// compressed with gzip if gz is set. The text is in the text-body
+// elements and the metadata in the metadata element.
+func readXML(ctx context.Context, f *zip.File, gz bool) (*structure.Document, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrMalformed, f.Name, err)
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	var r io.Reader = io.LimitReader(rc, maxEntrySize)
+	if gz {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrMalformed, f.Name, err)
+		}
+		r = io.LimitReader(zr, maxEntrySize)
+	}
+
+	doc := &structure.Document{Properties: make(map[string]string)}
+	var p strings.Builder
+	flush := func() {
+		if s := p.String(); strings.TrimSpace(s) != "" {
+			doc.Body = append(doc.Body, structure.Block{Kind: structure.Paragraph, Text: s})
+		}
+		p.Reset()
+	}
+
+	// text counts the open text-body elements and field is the
+	// name of the open field of the metadata
+	var text int
+	var inMetadata bool
+	var field string
+
+	d := xml.NewDecoder(r)
+	for {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrMalformed, f.Name, err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case t.Name.Local == "text-body":
+				text++
+			case t.Name.Local == "metadata":
+				inMetadata = true
+			case inMetadata && field == "":
+				field = t.Name.Local
+			case inMetadata && t.Name.Local == "string":
+				// The values of the fields are attributes of string elements
+				for _, a := range t.Attr {
+					if a.Name.Local == "string" && strings.TrimSpace(a.Value) != "" {
+						if doc.Properties[field] != "" {
+							doc.Properties[field] += ", "
+						}
+						doc.Properties[field] += strings.TrimSpace(a.Value)
+					}
+				}
+			case text > 0:
+				switch t.Name.Local {
+				case "tab":
+					p.WriteByte('\t')
+				case "br", "lnbr":
+					p.WriteByte('\n')
+				}
+			}
+		case xml.EndElement:
+			switch {
+			case t.Name.Local == "text-body":
+				flush()
+				text--
+			case t.Name.Local == "metadata":
+				inMetadata = false
+			case inMetadata && t.Name.Local == field:
+				field = ""
+			case text > 0:
+				switch t.Name.Local {
+				case "p", "pgbr", "sectbr", "layoutbr", "contbr":
+					flush()
+				}
+			}
+		case xml.CharData:
+			if text > 0 {
+				p.Write(t)
+			}
+		}
+	}
+	flush()
+
+	return doc, nil
+}
@@ -0,0 +1,250 @@
+package pdf
+
+import (
+	"strings"
+	"unicode/utf16"
+)
+
+// codespace is a range of codes of the same length
+type codespace struct {
+	n      int
+	lo, hi []byte
+}
+
+// code is a character code with its length in bytes
+type code struct {
+	value uint32
+	n     int
+}
+
+// codeRange maps a range of codes to consecutive values
+type codeRange struct {
+	lo, hi code
+	// text is the text of lo, the last character is
+	// incremented for the next codes
+	text []uint16
+	// texts are the texts of the codes, if given as an array
+	texts []string
+	// cid is the CID of lo
+	cid int
+}
+
+// cmap maps character codes to Unicode text or to CIDs
+type cmap struct {
+	codespaces []codespace
+
+	// Unicode mappings of ToUnicode CMaps
+	chars  map[code]string
+	ranges []codeRange
+
+	// CID mappings of encoding CMaps
+	cids      map[code]int
+	cidRanges []codeRange
+
+	// identity maps two byte codes to the same CIDs
+	identity bool
+}
+
+// identityCMap is the Identity-H and Identity-V encoding
+var identityCMap = &cmap{
+	codespaces: []codespace{{n: 2, lo: []byte{0, 0}, hi: []byte{0xFF, 0xFF}}},
+	identity:   true,
+}
+
+// parseCMap parses a CMap program
+func parseCMap(data []byte) *cmap {
+	cm := &cmap{
+		chars: make(map[code]string),
+		cids:  make(map[code]int),
+	}
+
+	lx := newBytesLexer(data)
+	var operands []Object
+	for {
+		tok, err := lx.readObject()
+		if err != nil {
+			// io.EOF or a damaged CMap
+			break
+		}
+
+		kw, ok := tok.(Keyword)
+		if !ok {
+			operands = append(operands, tok)
+			continue
+		}
+
+		switch kw {
+		case "usecmap":
+			if len(operands) > 0 {
+				if name, ok := operands[len(operands)-1].(Name); ok && strings.HasPrefix(string(name), "Identity-") {
+					cm.identity = true
+					cm.codespaces = append(cm.codespaces, identityCMap.codespaces...)
+				}
+			}
+		case "endcodespacerange":
+			for i := 0; i+1 < len(operands); i += 2 {
+				lo, ok1 := operands[i].(String)
+				hi, ok2 := operands[i+1].(String)
+				if ok1 && ok2 && len(lo) == len(hi) && len(lo) > 0 && len(lo) <= 4 {
+					cm.codespaces = append(cm.codespaces, codespace{n: len(lo), lo: []byte(lo), hi: []byte(hi)})
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok := operands[i].(String)
+				if !ok || len(src) == 0 || len(src) > 4 {
+					continue
+				}
+				cm.chars[newCode(src)] = bfText(operands[i+1])
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(String)
+				hi, ok2 := operands[i+1].(String)
+				if !ok1 || !ok2 || len(lo) == 0 || len(lo) > 4 || len(lo) != len(hi) {
+					continue
+				}
+				r := codeRange{lo: newCode(lo), hi: newCode(hi)}
+				switch dst := operands[i+2].(type) {
+				case String:
+					r.text = utf16Units(dst)
+				case Array:
+					for _, obj := range dst {
+						r.texts = append(r.texts, bfText(obj))
+					}
+				default:
+					continue
+				}
+				cm.ranges = append(cm.ranges, r)
+			}
+		case "endcidchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].(String)
+				cid, ok2 := integer(operands[i+1])
+				if ok1 && ok2 && len(src) > 0 && len(src) <= 4 {
+					cm.cids[newCode(src)] = int(cid)
+				}
+			}
+		case "endcidrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(String)
+				hi, ok2 := operands[i+1].(String)
+				cid, ok3 := integer(operands[i+2])
+				if ok1 && ok2 && ok3 && len(lo) > 0 && len(lo) <= 4 && len(lo) == len(hi) {
+					cm.cidRanges = append(cm.cidRanges, codeRange{lo: newCode(lo), hi: newCode(hi), cid: int(cid)})
+				}
+			}
+		}
+
+		// The operands of an operator are consumed
+		operands = operands[:0]
+	}
+
+	return cm
+}
+
+// newCode returns the code of the bytes of s
+func newCode(s String) code {
+	var v uint32
+	for i := 0; i < len(s); i++ {
+		v = v<<8 | uint32(s[i])
+	}
+	return code{value: v, n: len(s)}
+}
+
+// utf16Units returns the UTF-16BE code units of s
+func utf16Units(s String) []uint16 {
+	units := make([]uint16, 0, len(s)/2)
+	for i := 0; i+1 < len(s); i += 2 {
+		units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+	}
+	// Some writers use single bytes
+	if len(s) == 1 {
+		units = append(units, uint16(s[0]))
+	}
+	return units
+}
+
+// bfText returns the text of a bfchar or bfrange destination
+func bfText(obj Object) string {
+	switch dst := obj.(type) {
+	case String:
+		return string(utf16.Decode(utf16Units(dst)))
+	case Name:
+		s, _ := glyphRune(string(dst))
+		return s
+	}
+	return ""
+}
+
+// next splits the next code from s and returns its length
+func (cm *cmap) next(s []byte, defaultLen int) (code, int) {
+	for n := 1; n <= 4 && n <= len(s); n++ {
+		for _, cs := range cm.codespaces {
+			if cs.n == n && inCodespace(s[:n], cs) {
+				return newCode(String(s[:n])), n
+			}
+		}
+	}
+
+	// Codes outside of the code space ranges
+	n := min(max(defaultLen, 1), len(s))
+	return newCode(String(s[:n])), n
+}
+
+// inCodespace reports whether b lies in the code space range
+func inCodespace(b []byte, cs codespace) bool {
+	for i := range b {
+		if b[i] < cs.lo[i] || b[i] > cs.hi[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// text returns the Unicode text of the code
+func (cm *cmap) text(c code) (string, bool) {
+	if s, ok := cm.chars[c]; ok {
+		return s, true
+	}
+
+	for _, r := range cm.ranges {
+		if c.n != r.lo.n || c.value < r.lo.value || c.value > r.hi.value {
+			continue
+		}
+		offset := int(c.value - r.lo.value)
+		if r.texts != nil {
+			if offset < len(r.texts) {
+				return r.texts[offset], true
+			}
+			continue
+		}
+		if len(r.text) == 0 {
+			continue
+		}
+		units := append([]uint16(nil), r.text...)
+		units[len(units)-1] += uint16(offset)
+		return string(utf16.Decode(units)), true
+	}
+
+	return "", false
+}
+
+// cid returns the CID of the code
+func (cm *cmap) cid(c code) int {
+	if cid, ok := cm.cids[c]; ok {
+		return cid
+	}
+
+	for _, r := range cm.cidRanges {
+		if c.n == r.lo.n && c.value >= r.lo.value && c.value <= r.hi.value {
+			return r.cid + int(c.value-r.lo.value)
+		}
+	}
+
+	if cm.identity {
+		return int(c.value)
+	}
+
+	return 0
+}
@@ -0,0 +1,272 @@
+package pdf
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// pdfDocEncoding maps the bytes of PDFDocEncoding which
+// differ from ISO Latin-1
+var pdfDocEncoding = [256]rune{
+	0x18: 0x02D8, 0x19: 0x02C7, 0x1A: 0x02C6, 0x1B: 0x02D9,
+	0x1C: 0x02DD, 0x1D: 0x02DB, 0x1E: 0x02DA, 0x1F: 0x02DC,
+	0x80: 0x2022, 0x81: 0x2020, 0x82: 0x2021, 0x83: 0x2026,
+	0x84: 0x2014, 0x85: 0x2013, 0x86: 0x0192, 0x87: 0x2044,
+	0x88: 0x2039, 0x89: 0x203A, 0x8A: 0x2212, 0x8B: 0x2030,
+	0x8C: 0x201E, 0x8D: 0x201C, 0x8E: 0x201D, 0x8F: 0x2018,
+	0x90: 0x2019, 0x91: 0x201A, 0x92: 0x2122, 0x93: 0xFB01,
+	0x94: 0xFB02, 0x95: 0x0141, 0x96: 0x0152, 0x97: 0x0160,
+	0x98: 0x0178, 0x99: 0x017D, 0x9A: 0x0131, 0x9B: 0x0142,
+	0x9C: 0x0153, 0x9D: 0x0161, 0x9E: 0x017E, 0xA0: 0x20AC,
+}
+
+// standardEncoding maps the codes of the Adobe standard encoding
+// which differ from ASCII to their glyph names
+var standardEncoding = map[byte]string{
+	0x27: "quoteright", 0x60: "quoteleft",
+	0xA1: "exclamdown", 0xA2: "cent", 0xA3: "sterling", 0xA4: "fraction",
+	0xA5: "yen", 0xA6: "florin", 0xA7: "section", 0xA8: "currency",
+	0xA9: "quotesingle", 0xAA: "quotedblleft", 0xAB: "guillemotleft",
+	0xAC: "guilsinglleft", 0xAD: "guilsinglright", 0xAE: "fi", 0xAF: "fl",
+	0xB1: "endash", 0xB2: "dagger", 0xB3: "daggerdbl", 0xB4: "periodcentered",
+	0xB6: "paragraph", 0xB7: "bullet", 0xB8: "quotesinglbase",
+	0xB9: "quotedblbase", 0xBA: "quotedblright", 0xBB: "guillemotright",
+	0xBC: "ellipsis", 0xBD: "perthousand", 0xBF: "questiondown",
+	0xC1: "grave", 0xC2: "acute", 0xC3: "circumflex", 0xC4: "tilde",
+	0xC5: "macron", 0xC6: "breve", 0xC7: "dotaccent", 0xC8: "dieresis",
+	0xCA: "ring", 0xCB: "cedilla", 0xCD: "hungarumlaut", 0xCE: "ogonek",
+	0xCF: "caron", 0xD0: "emdash", 0xE1: "AE", 0xE3: "ordfeminine",
+	0xE8: "Lslash", 0xE9: "Oslash", 0xEA: "OE", 0xEB: "ordmasculine",
+	0xF1: "ae", 0xF5: "dotlessi", 0xF8: "lslash", 0xF9: "oslash",
+	0xFA: "oe", 0xFB: "germandbls",
+}
+
+// glyphNames maps the common glyph names to Unicode, names of
+// the form uniXXXX and uXXXX are decoded by glyphRune
+var glyphNames = map[string]rune{
+	// ASCII
+	"space": ' ', "exclam": '!', "quotedbl": '"', "numbersign": '#',
+	"dollar": '$', "percent": '%', "ampersand": '&', "quotesingle": '\'',
+	"parenleft": '(', "parenright": ')', "asterisk": '*', "plus": '+',
+	"comma": ',', "hyphen": '-', "period": '.', "slash": '/',
+	"zero": '0', "one": '1', "two": '2', "three": '3', "four": '4',
+	"five": '5', "six": '6', "seven": '7', "eight": '8', "nine": '9',
+	"colon": ':', "semicolon": ';', "less": '<', "equal": '=',
+	"greater": '>', "question": '?', "at": '@', "bracketleft": '[',
+	"backslash": '\\', "bracketright": ']', "asciicircum": '^',
+	"underscore": '_', "grave": '`', "braceleft": '{', "bar": '|',
+	"braceright": '}', "asciitilde": '~',
+
+	// Punctuation and symbols
+	"nbspace": 0x00A0, "nonbreakingspace": 0x00A0, "exclamdown": 0x00A1,
+	"cent": 0x00A2, "sterling": 0x00A3, "currency": 0x00A4, "yen": 0x00A5,
+	"brokenbar": 0x00A6, "section": 0x00A7, "dieresis": 0x00A8,
+	"copyright": 0x00A9, "ordfeminine": 0x00AA, "guillemotleft": 0x00AB,
+	"logicalnot": 0x00AC, "sfthyphen": 0x00AD, "registered": 0x00AE,
+	"macron": 0x00AF, "degree": 0x00B0, "plusminus": 0x00B1,
+	"twosuperior": 0x00B2, "threesuperior": 0x00B3, "acute": 0x00B4,
+	"mu": 0x00B5, "paragraph": 0x00B6, "periodcentered": 0x00B7,
+	"cedilla": 0x00B8, "onesuperior": 0x00B9, "ordmasculine": 0x00BA,
+	"guillemotright": 0x00BB, "onequarter": 0x00BC, "onehalf": 0x00BD,
+	"threequarters": 0x00BE, "questiondown": 0x00BF, "multiply": 0x00D7,
+	"divide": 0x00F7, "dotlessi": 0x0131, "dotlessj": 0x0237,
+	"circumflex": 0x02C6, "caron": 0x02C7, "breve": 0x02D8,
+	"dotaccent": 0x02D9, "ring": 0x02DA, "ogonek": 0x02DB, "tilde": 0x02DC,
+	"hungarumlaut": 0x02DD, "endash": 0x2013, "emdash": 0x2014,
+	"figuredash": 0x2012, "quoteleft": 0x2018, "quoteright": 0x2019,
+	"quotesinglbase": 0x201A, "quotereversed": 0x201B,
+	"quotedblleft": 0x201C, "quotedblright": 0x201D, "quotedblbase": 0x201E,
+	"dagger": 0x2020, "daggerdbl": 0x2021, "bullet": 0x2022,
+	"ellipsis": 0x2026, "perthousand": 0x2030, "minute": 0x2032,
+	"second": 0x2033, "guilsinglleft": 0x2039, "guilsinglright": 0x203A,
+	"fraction": 0x2044, "Euro": 0x20AC, "euro": 0x20AC, "trademark": 0x2122,
+	"florin": 0x0192, "minus": 0x2212, "lozenge": 0x25CA,
+	"infinity": 0x221E, "notequal": 0x2260, "lessequal": 0x2264,
+	"greaterequal": 0x2265, "approxequal": 0x2248, "partialdiff": 0x2202,
+	"summation": 0x2211, "product": 0x220F, "radical": 0x221A,
+	"integral": 0x222B, "increment": 0x2206, "Delta": 0x0394,
+	"Omega": 0x03A9, "pi": 0x03C0, "arrowleft": 0x2190, "arrowup": 0x2191,
+	"arrowright": 0x2192, "arrowdown": 0x2193, "arrowboth": 0x2194,
+	"element": 0x2208, "logicaland": 0x2227, "logicalor": 0x2228,
+	"intersection": 0x2229, "union": 0x222A, "similar": 0x223C,
+	"equivalence": 0x2261, "proportional": 0x221D, "emptyset": 0x2205,
+	"universal": 0x2200, "existential": 0x2203, "nabla": 0x2207,
+	"angle": 0x2220, "therefore": 0x2234, "dotmath": 0x22C5,
+	"circlemultiply": 0x2297, "circleplus": 0x2295, "openbullet": 0x25E6,
+	"club": 0x2663, "diamond": 0x2666, "heart": 0x2665, "spade": 0x2660,
+	"checkmark": 0x2713, "dotlessinvert": 0x0131,
+
+	// Ligatures
+	"ff": 0xFB00, "fi": 0xFB01, "fl": 0xFB02, "ffi": 0xFB03, "ffl": 0xFB04,
+
+	// Latin-1 letters
+	"Agrave": 0x00C0, "Aacute": 0x00C1, "Acircumflex": 0x00C2,
+	"Atilde": 0x00C3, "Adieresis": 0x00C4, "Aring": 0x00C5, "AE": 0x00C6,
+	"Ccedilla": 0x00C7, "Egrave": 0x00C8, "Eacute": 0x00C9,
+	"Ecircumflex": 0x00CA, "Edieresis": 0x00CB, "Igrave": 0x00CC,
+	"Iacute": 0x00CD, "Icircumflex": 0x00CE, "Idieresis": 0x00CF,
+	"Eth": 0x00D0, "Ntilde": 0x00D1, "Ograve": 0x00D2, "Oacute": 0x00D3,
+	"Ocircumflex": 0x00D4, "Otilde": 0x00D5, "Odieresis": 0x00D6,
+	"Oslash": 0x00D8, "Ugrave": 0x00D9, "Uacute": 0x00DA,
+	"Ucircumflex": 0x00DB, "Udieresis": 0x00DC, "Yacute": 0x00DD,
+	"Thorn": 0x00DE, "germandbls": 0x00DF, "agrave": 0x00E0,
+	"aacute": 0x00E1, "acircumflex": 0x00E2, "atilde": 0x00E3,
+	"adieresis": 0x00E4, "aring": 0x00E5, "ae": 0x00E6, "ccedilla": 0x00E7,
+	"egrave": 0x00E8, "eacute": 0x00E9, "ecircumflex": 0x00EA,
+	"edieresis": 0x00EB, "igrave": 0x00EC, "iacute": 0x00ED,
+	"icircumflex": 0x00EE, "idieresis": 0x00EF, "eth": 0x00F0,
+	"ntilde": 0x00F1, "ograve": 0x00F2, "oacute": 0x00F3,
+	"ocircumflex": 0x00F4, "otilde": 0x00F5, "odieresis": 0x00F6,
+	"oslash": 0x00F8, "ugrave": 0x00F9, "uacute": 0x00FA,
+	"ucircumflex": 0x00FB, "udieresis": 0x00FC, "yacute": 0x00FD,
+	"thorn": 0x00FE, "ydieresis": 0x00FF,
+
+	// Latin Extended-A letters
+	"Amacron": 0x0100, "amacron": 0x0101, "Abreve": 0x0102, "abreve": 0x0103,
+	"Aogonek": 0x0104, "aogonek": 0x0105, "Cacute": 0x0106, "cacute": 0x0107,
+	"Ccircumflex": 0x0108, "ccircumflex": 0x0109, "Cdotaccent": 0x010A,
+	"cdotaccent": 0x010B, "Ccaron": 0x010C, "ccaron": 0x010D,
+	"Dcaron": 0x010E, "dcaron": 0x010F, "Dcroat": 0x0110, "dcroat": 0x0111,
+	"Emacron": 0x0112, "emacron": 0x0113, "Ebreve": 0x0114, "ebreve": 0x0115,
+	"Edotaccent": 0x0116, "edotaccent": 0x0117, "Eogonek": 0x0118,
+	"eogonek": 0x0119, "Ecaron": 0x011A, "ecaron": 0x011B,
+	"Gcircumflex": 0x011C, "gcircumflex": 0x011D, "Gbreve": 0x011E,
+	"gbreve": 0x011F, "Gdotaccent": 0x0120, "gdotaccent": 0x0121,
+	"Gcommaaccent": 0x0122, "gcommaaccent": 0x0123, "Hcircumflex": 0x0124,
+	"hcircumflex": 0x0125, "Hbar": 0x0126, "hbar": 0x0127, "Itilde": 0x0128,
+	"itilde": 0x0129, "Imacron": 0x012A, "imacron": 0x012B, "Ibreve": 0x012C,
+	"ibreve": 0x012D, "Iogonek": 0x012E, "iogonek": 0x012F,
+	"Idotaccent": 0x0130, "IJ": 0x0132, "ij": 0x0133, "Jcircumflex": 0x0134,
+	"jcircumflex": 0x0135, "Kcommaaccent": 0x0136, "kcommaaccent": 0x0137,
+	"kgreenlandic": 0x0138, "Lacute": 0x0139, "lacute": 0x013A,
+	"Lcommaaccent": 0x013B, "lcommaaccent": 0x013C, "Lcaron": 0x013D,
+	"lcaron": 0x013E, "Ldot": 0x013F, "ldot": 0x0140, "Lslash": 0x0141,
+	"lslash": 0x0142, "Nacute": 0x0143, "nacute": 0x0144,
+	"Ncommaaccent": 0x0145, "ncommaaccent": 0x0146, "Ncaron": 0x0147,
+	"ncaron": 0x0148, "napostrophe": 0x0149, "Eng": 0x014A, "eng": 0x014B,
+	"Omacron": 0x014C, "omacron": 0x014D, "Obreve": 0x014E, "obreve": 0x014F,
+	"Ohungarumlaut": 0x0150, "ohungarumlaut": 0x0151, "OE": 0x0152,
+	"oe": 0x0153, "Racute": 0x0154, "racute": 0x0155, "Rcommaaccent": 0x0156,
+	"rcommaaccent": 0x0157, "Rcaron": 0x0158, "rcaron": 0x0159,
+	"Sacute": 0x015A, "sacute": 0x015B, "Scircumflex": 0x015C,
+	"scircumflex": 0x015D, "Scedilla": 0x015E, "scedilla": 0x015F,
+	"Scaron": 0x0160, "scaron": 0x0161, "Tcommaaccent": 0x0162,
+	"tcommaaccent": 0x0163, "Tcaron": 0x0164, "tcaron": 0x0165,
+	"Tbar": 0x0166, "tbar": 0x0167, "Utilde": 0x0168, "utilde": 0x0169,
+	"Umacron": 0x016A, "umacron": 0x016B, "Ubreve": 0x016C, "ubreve": 0x016D,
+	"Uring": 0x016E, "uring": 0x016F, "Uhungarumlaut": 0x0170,
+	"uhungarumlaut": 0x0171, "Uogonek": 0x0172, "uogonek": 0x0173,
+	"Wcircumflex": 0x0174, "wcircumflex": 0x0175, "Ycircumflex": 0x0176,
+	"ycircumflex": 0x0177, "Ydieresis": 0x0178, "Zacute": 0x0179,
+	"zacute": 0x017A, "Zdotaccent": 0x017B, "zdotaccent": 0x017C,
+	"Zcaron": 0x017D, "zcaron": 0x017E, "longs": 0x017F,
+	"Scommaaccent": 0x0218, "scommaaccent": 0x0219,
+
+	// Greek letters
+	"Alpha": 0x0391, "Beta": 0x0392, "Gamma": 0x0393, "Epsilon": 0x0395,
+	"Zeta": 0x0396, "Eta": 0x0397, "Theta": 0x0398, "Iota": 0x0399,
+	"Kappa": 0x039A, "Lambda": 0x039B, "Mu": 0x039C, "Nu": 0x039D,
+	"Xi": 0x039E, "Omicron": 0x039F, "Pi": 0x03A0, "Rho": 0x03A1,
+	"Sigma": 0x03A3, "Tau": 0x03A4, "Upsilon": 0x03A5, "Phi": 0x03A6,
+	"Chi": 0x03A7, "Psi": 0x03A8, "alpha": 0x03B1, "beta": 0x03B2,
+	"gamma": 0x03B3, "delta": 0x03B4, "epsilon": 0x03B5, "zeta": 0x03B6,
+	"eta": 0x03B7, "theta": 0x03B8, "iota": 0x03B9, "kappa": 0x03BA,
+	"lambda": 0x03BB, "nu": 0x03BD, "xi": 0x03BE, "omicron": 0x03BF,
+	"rho": 0x03C1, "sigma1": 0x03C2, "sigma": 0x03C3, "tau": 0x03C4,
+	"upsilon": 0x03C5, "phi": 0x03C6, "chi": 0x03C7, "psi": 0x03C8,
+	"omega": 0x03C9, "theta1": 0x03D1, "phi1": 0x03D5, "omega1": 0x03D6,
+}
+
+// glyphRune returns the text of the glyph name
+func glyphRune(name string) (string, bool) {
+	if r, ok := glyphNames[name]; ok {
+		return string(r), true
+	}
+
+	// Single letters are named after themselves
+	if len(name) == 1 && (name[0] >= 'A' && name[0] <= 'Z' || name[0] >= 'a' && name[0] <= 'z') {
+		return name, true
+	}
+
+	// uniXXXX, possibly a sequence of code points
+	if strings.HasPrefix(name, "uni") && len(name) >= 7 && (len(name)-3)%4 == 0 {
+		var sb strings.Builder
+		for i := 3; i < len(name); i += 4 {
+			v, err := strconv.ParseUint(name[i:i+4], 16, 32)
+			if err != nil {
+				sb.Reset()
+				break
+			}
+			sb.WriteRune(rune(v))
+		}
+		if sb.Len() > 0 {
+			return sb.String(), true
+		}
+	}
+
+	// uXXXX to uXXXXXX
+	if strings.HasPrefix(name, "u") && len(name) >= 5 && len(name) <= 7 {
+		if v, err := strconv.ParseUint(name[1:], 16, 32); err == nil && v <= 0x10FFFF {
+			return string(rune(v)), true
+		}
+	}
+
+	// Variants, e.g. a.sc, and ligatures, e.g. f_f_i
+	if base, _, ok := strings.Cut(name, "."); ok && base != "" {
+		return glyphRune(base)
+	}
+	if strings.Contains(name, "_") {
+		var sb strings.Builder
+		for _, part := range strings.Split(name, "_") {
+			s, ok := glyphRune(part)
+			if !ok {
+				return "", false
+			}
+			sb.WriteString(s)
+		}
+		return sb.String(), true
+	}
+
+	return "", false
+}
+
+// baseEncoding returns the text of the 256 codes of a named encoding
+func baseEncoding(name Name) [256]string {
+	var enc [256]string
+
+	switch name {
+	case "WinAnsiEncoding":
+		fillCharmap(&enc, charmap.Windows1252)
+	case "MacRomanEncoding", "MacExpertEncoding":
+		fillCharmap(&enc, charmap.Macintosh)
+	case "PDFDocEncoding":
+		for i := range enc {
+			if r := pdfDocEncoding[i]; r != 0 {
+				enc[i] = string(r)
+			} else if i >= 0x20 {
+				enc[i] = string(rune(i))
+			}
+		}
+	default:
+		// StandardEncoding
+		for i := 0x20; i < 0x7F; i++ {
+			enc[i] = string(rune(i))
+		}
+		for code, glyph := range standardEncoding {
+			enc[code], _ = glyphRune(glyph)
+		}
+	}
+
+	return enc
+}
+
+// fillCharmap fills enc with the printable characters of a charmap
+func fillCharmap(enc *[256]string, cm *charmap.Charmap) {
+	for i := 0x20; i < 256; i++ {
+		if r := cm.DecodeByte(byte(i)); r != 0xFFFD && r != 0x7F {
+			enc[i] = string(r)
+		}
+	}
+}
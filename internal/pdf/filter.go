@@ -0,0 +1,364 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/ascii85"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxStreamSize limits the size of a decoded stream
+const maxStreamSize = 256 << 20
+
+// errUnsupportedFilter is returned for the filters of images,
+// which do not hold any text
+var errUnsupportedFilter = errors.New("pdf: unsupported filter")
+
+// decodeStream applies the filters of the stream dictionary to data
+func decodeStream(dict Dict, data []byte, resolve func(Object) Object) ([]byte, error) {
+	filters, params := filterList(dict, resolve)
+
+	var err error
+	for i, filter := range filters {
+		var param Dict
+		if i < len(params) {
+			param = params[i]
+		}
+
+		data, err = applyFilter(filter, param, data, resolve)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// filterList returns the filters of the stream and their parameters
+func filterList(dict Dict, resolve func(Object) Object) ([]Name, []Dict) {
+	var filters []Name
+	switch f := resolve(dict["Filter"]).(type) {
+	case Name:
+		filters = append(filters, f)
+	case Array:
+		for _, obj := range f {
+			if name, ok := resolve(obj).(Name); ok {
+				filters = append(filters, name)
+			}
+		}
+	}
+
+	var params []Dict
+	switch p := resolve(dict["DecodeParms"]).(type) {
+	case Dict:
+		params = append(params, p)
+	case Array:
+		for _, obj := range p {
+			param, _ := resolve(obj).(Dict)
+			params = append(params, param)
+		}
+	}
+
+	return filters, params
+}
+
+// applyFilter decodes data with a single filter
+func applyFilter(filter Name, param Dict, data []byte, resolve func(Object) Object) ([]byte, error) {
+	var out []byte
+	var err error
+
+	switch filter {
+	case "FlateDecode", "Fl":
+		out, err = flateDecode(data)
+	case "LZWDecode", "LZW":
+		earlyChange := true
+		if v, ok := integer(resolve(param["EarlyChange"])); ok && v == 0 {
+			earlyChange = false
+		}
+		out, err = lzwDecode(data, earlyChange)
+	case "ASCII85Decode", "A85":
+		out, err = ascii85Decode(data)
+	case "ASCIIHexDecode", "AHx":
+		out = asciiHexDecode(data)
+	case "RunLengthDecode", "RL":
+		out = runLengthDecode(data)
+	case "Crypt":
+		// Only the identity crypt filter is supported
+		if name, _ := resolve(param["Name"]).(Name); name != "" && name != "Identity" {
+			return nil, ErrEncrypted
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedFilter, filter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Flate and LZW data may be encoded with a predictor
+	if filter == "FlateDecode" || filter == "Fl" || filter == "LZWDecode" || filter == "LZW" {
+		return unpredict(out, param, resolve)
+	}
+
+	return out, nil
+}
+
+// flateDecode inflates zlib data. Damaged streams are decoded
+// as far as possible.
+func flateDecode(data []byte) ([]byte, error) {
+	var rc io.ReadCloser
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		// Some writers omit the zlib header
+		rc = flate.NewReader(bytes.NewReader(data))
+	} else {
+		rc = zr
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	out, err := io.ReadAll(io.LimitReader(rc, maxStreamSize))
+	if err != nil && len(out) == 0 {
+		return nil, malformed("flate: %v", err)
+	}
+
+	// Keep the data decoded before a checksum error or a truncation
+	return out, nil
+}
+
+// lzwDecode decodes LZW data with codes of 9 to 12 bits,
+// most significant bit first
+func lzwDecode(data []byte, earlyChange bool) ([]byte, error) {
+	const (
+		clearCode = 256
+		eodCode   = 257
+	)
+
+	var out bytes.Buffer
+	table := make([][]byte, 258, 4096)
+	for i := 0; i < 256; i++ {
+		table[i] = []byte{byte(i)}
+	}
+
+	early := 0
+	if earlyChange {
+		early = 1
+	}
+
+	var bits, nbits uint32
+	width := uint32(9)
+	var prev []byte
+
+	for _, b := range data {
+		bits = bits<<8 | uint32(b)
+		nbits += 8
+
+		for nbits >= width {
+			code := int(bits >> (nbits - width) & (1<<width - 1))
+			nbits -= width
+
+			switch {
+			case code == clearCode:
+				table = table[:258]
+				width = 9
+				prev = nil
+				continue
+			case code == eodCode:
+				return out.Bytes(), nil
+			}
+
+			var entry []byte
+			switch {
+			case code < len(table):
+				entry = table[code]
+			case code == len(table) && prev != nil:
+				entry = append(append([]byte(nil), prev...), prev[0])
+			default:
+				return out.Bytes(), malformed("lzw: invalid code %d", code)
+			}
+
+			out.Write(entry)
+			if out.Len() > maxStreamSize {
+				return out.Bytes(), nil
+			}
+
+			if prev != nil && len(table) < 4096 {
+				table = append(table, append(append([]byte(nil), prev...), entry[0]))
+			}
+			prev = entry
+
+			if n := len(table) + early; n >= 1<<width && width < 12 {
+				width++
+			}
+		}
+	}
+
+	return out.Bytes(), nil
+}
+
+// ascii85Decode decodes ASCII base-85 data ending with ~>
+func ascii85Decode(data []byte) ([]byte, error) {
+	data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("<~"))
+	if i := bytes.Index(data, []byte("~>")); i >= 0 {
+		data = data[:i]
+	}
+
+	out := make([]byte, 4*len(data)/5+4+4*bytes.Count(data, []byte("z")))
+	n, _, err := ascii85.Decode(out, data, true)
+	if err != nil {
+		return nil, malformed("ascii85: %v", err)
+	}
+
+	return out[:n], nil
+}
+
+// asciiHexDecode decodes hexadecimal data ending with >
+func asciiHexDecode(data []byte) []byte {
+	out := make([]byte, 0, len(data)/2)
+	var hi byte
+	odd := false
+	for _, c := range data {
+		if c == '>' {
+			break
+		}
+		if !isHex(c) {
+			continue
+		}
+		if odd {
+			out = append(out, hi<<4|unhex(c))
+		} else {
+			hi = unhex(c)
+		}
+		odd = !odd
+	}
+	if odd {
+		out = append(out, hi<<4)
+	}
+	return out
+}
+
+// runLengthDecode decodes run-length encoded data
+func runLengthDecode(data []byte) []byte {
+	var out []byte
+	for i := 0; i < len(data); {
+		n := int(data[i])
+		i++
+		switch {
+		case n < 128:
+			// Copy the next n+1 bytes
+			end := min(i+n+1, len(data))
+			out = append(out, data[i:end]...)
+			i = end
+		case n > 128:
+			// Repeat the next byte 257-n times
+			if i < len(data) {
+				out = append(out, bytes.Repeat(data[i:i+1], 257-n)...)
+				i++
+			}
+		default:
+			// End of data
+			return out
+		}
+	}
+	return out
+}
+
+// unpredict reverses the PNG and TIFF predictors
+func unpredict(data []byte, param Dict, resolve func(Object) Object) ([]byte, error) {
+	predictor, _ := integer(resolve(param["Predictor"]))
+	if predictor <= 1 {
+		return data, nil
+	}
+
+	colors := intParam(param, "Colors", 1, resolve)
+	bpc := intParam(param, "BitsPerComponent", 8, resolve)
+	columns := intParam(param, "Columns", 1, resolve)
+
+	bpp := max((colors*bpc+7)/8, 1)
+	rowLen := (colors*bpc*columns + 7) / 8
+	if rowLen <= 0 {
+		return nil, malformed("predictor: invalid row length")
+	}
+
+	if predictor == 2 {
+		// TIFF predictor, only 8 bits per component are supported
+		if bpc != 8 {
+			return data, nil
+		}
+		for row := 0; row+rowLen <= len(data); row += rowLen {
+			for i := row + bpp; i < row+rowLen; i++ {
+				data[i] += data[i-bpp]
+			}
+		}
+		return data, nil
+	}
+
+	// PNG predictors, every row starts with its filter type
+	out := make([]byte, 0, len(data))
+	prev := make([]byte, rowLen)
+	for row := 0; row < len(data); row += rowLen + 1 {
+		filter := data[row]
+		end := min(row+1+rowLen, len(data))
+		cur := make([]byte, rowLen)
+		copy(cur, data[row+1:end])
+
+		for i := 0; i < rowLen; i++ {
+			var left, upLeft byte
+			if i >= bpp {
+				left = cur[i-bpp]
+				upLeft = prev[i-bpp]
+			}
+			up := prev[i]
+
+			switch filter {
+			case 1:
+				cur[i] += left
+			case 2:
+				cur[i] += up
+			case 3:
+				cur[i] += byte((int(left) + int(up)) / 2)
+			case 4:
+				cur[i] += paeth(left, up, upLeft)
+			}
+		}
+
+		out = append(out, cur[:end-row-1]...)
+		prev = cur
+	}
+
+	return out, nil
+}
+
+// paeth returns the Paeth predictor of the PNG specification
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	default:
+		return c
+	}
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// intParam returns the integer parameter key or its default value
+func intParam(param Dict, key Name, def int, resolve func(Object) Object) int {
+	if v, ok := integer(resolve(param[key])); ok && v > 0 && v < 1<<20 {
+		return int(v)
+	}
+	return def
+}
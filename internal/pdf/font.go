@@ -0,0 +1,408 @@
+package pdf
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// glyph is a decoded character code
+type glyph struct {
+	text string
+	// width is the horizontal displacement in text space units
+	width float64
+	// space reports whether the code is the single byte code 32,
+	// which receives the word spacing
+	space bool
+}
+
+// font maps the character codes of a font to text and widths
+type font struct {
+	// composite reports whether the font is a Type0 font
+	composite bool
+
+	// encoding maps the codes to CIDs in composite fonts
+	encoding *cmap
+	// ucs2 reports whether the codes of the encoding are UTF-16
+	ucs2 bool
+	// toUnicode maps the codes to text
+	toUnicode *cmap
+
+	// simple maps the single byte codes to text in simple fonts
+	simple [256]string
+
+	// trueType maps the glyphs of an embedded TrueType font to Unicode
+	trueType *trueTypeCmap
+	// cidToGID maps the CIDs to glyphs, nil for the identity
+	cidToGID []byte
+
+	// widths are the widths by code in simple fonts and by CID in
+	// composite fonts, in glyph space units
+	widths       map[int]float64
+	defaultWidth float64
+	// scale converts glyph space to text space
+	scale float64
+}
+
+// loadFont reads a font dictionary
+func (rd *Reader) loadFont(dict Dict) *font {
+	f := &font{
+		widths: make(map[int]float64),
+		scale:  0.001,
+	}
+
+	subtype, _ := rd.Resolve(dict["Subtype"]).(Name)
+	if subtype == "Type0" {
+		rd.loadCompositeFont(f, dict)
+	} else {
+		rd.loadSimpleFont(f, dict, subtype)
+	}
+
+	if stm, ok := rd.Resolve(dict["ToUnicode"]).(*Stream); ok {
+		if data, err := rd.StreamData(stm); err == nil {
+			f.toUnicode = parseCMap(data)
+		}
+	}
+
+	return f
+}
+
+// loadSimpleFont reads the encoding and the widths of a simple font
+func (rd *Reader) loadSimpleFont(f *font, dict Dict, subtype Name) {
+	baseFont, _ := rd.Resolve(dict["BaseFont"]).(Name)
+	descriptor, _ := rd.Resolve(dict["FontDescriptor"]).(Dict)
+
+	// Type3 glyphs are defined in their own glyph space
+	if subtype == "Type3" {
+		if m, ok := rd.Resolve(dict["FontMatrix"]).(Array); ok && len(m) > 0 {
+			if a, ok := number(rd.Resolve(m[0])); ok && a != 0 {
+				f.scale = a
+			}
+		}
+	}
+
+	// Widths
+	first, _ := integer(rd.Resolve(dict["FirstChar"]))
+	if widths, ok := rd.Resolve(dict["Widths"]).(Array); ok {
+		for i, w := range widths {
+			if v, ok := number(rd.Resolve(w)); ok {
+				f.widths[int(first)+i] = v
+			}
+		}
+	}
+	if w, ok := number(rd.Resolve(descriptor["MissingWidth"])); ok && w > 0 {
+		f.defaultWidth = w
+	} else {
+		f.defaultWidth = 500
+	}
+	if len(f.widths) == 0 {
+		// The standard 14 fonts may omit their widths
+		for c := 0x20; c < 0x7F; c++ {
+			f.widths[c] = standardWidth(string(baseFont), rune(c))
+		}
+	}
+
+	// Embedded TrueType fonts
+	if stm, ok := rd.Resolve(descriptor["FontFile2"]).(*Stream); ok {
+		if data, err := rd.StreamData(stm); err == nil {
+			f.trueType = parseTrueTypeCmap(data)
+		}
+	}
+
+	// Encoding
+	var base Name
+	var differences Array
+	switch enc := rd.Resolve(dict["Encoding"]).(type) {
+	case Name:
+		base = enc
+	case Dict:
+		base, _ = rd.Resolve(enc["BaseEncoding"]).(Name)
+		differences, _ = rd.Resolve(enc["Differences"]).(Array)
+	}
+
+	symbolic := false
+	if flags, ok := integer(rd.Resolve(descriptor["Flags"])); ok {
+		symbolic = flags&4 != 0 && flags&32 == 0
+	}
+
+	switch {
+	case base != "":
+		f.simple = baseEncoding(base)
+	case subtype == "TrueType" && !symbolic:
+		f.simple = baseEncoding("WinAnsiEncoding")
+	case isSymbolFont(string(baseFont)):
+		// Only the digits and the punctuation of the Symbol font are
+		// ASCII, the other glyphs come from the differences
+		if strings.HasPrefix(stripSubset(string(baseFont)), "Symbol") {
+			for c := 0x20; c < 0x40; c++ {
+				f.simple[c] = string(rune(c))
+			}
+			f.simple['"'], f.simple['$'], f.simple['\''], f.simple['-'] = "∀", "∃", "∋", "−"
+		}
+	default:
+		f.simple = baseEncoding("StandardEncoding")
+		// Embedded Type1 fonts define their own encoding
+		if stm, ok := rd.Resolve(descriptor["FontFile"]).(*Stream); ok {
+			if data, err := rd.StreamData(stm); err == nil {
+				for c, name := range type1Encoding(data) {
+					if text, ok := glyphRune(name); ok {
+						f.simple[c] = text
+					}
+				}
+			}
+		}
+	}
+
+	// Differences override the base encoding
+	c := 0
+	for _, obj := range differences {
+		switch v := rd.Resolve(obj).(type) {
+		case int64:
+			c = int(v)
+		case float64:
+			c = int(v)
+		case Name:
+			if c >= 0 && c < 256 {
+				if text, ok := glyphRune(string(v)); ok {
+					f.simple[c] = text
+				} else {
+					// Unknown glyph names are resolved with the font program
+					f.simple[c] = ""
+				}
+			}
+			c++
+		}
+	}
+
+	// Symbolic TrueType fonts without encoding map the codes
+	// to glyphs with their own cmap
+	if subtype == "TrueType" && symbolic && base == "" && f.trueType != nil && f.trueType.unicode != nil {
+		for c := 0; c < 256; c++ {
+			if gid, ok := f.trueType.symbol[c]; ok {
+				if r, ok := f.trueType.unicode[gid]; ok {
+					f.simple[c] = string(r)
+				}
+			}
+		}
+	}
+}
+
+// loadCompositeFont reads the CMap and the widths of a Type0 font
+func (rd *Reader) loadCompositeFont(f *font, dict Dict) {
+	f.composite = true
+
+	switch enc := rd.Resolve(dict["Encoding"]).(type) {
+	case Name:
+		name := string(enc)
+		f.encoding = identityCMap
+		// The codes of the Unicode CMaps are UTF-16
+		f.ucs2 = strings.HasPrefix(name, "Uni") && (strings.Contains(name, "UCS2") || strings.Contains(name, "UTF16"))
+	case *Stream:
+		if data, err := rd.StreamData(enc); err == nil {
+			f.encoding = parseCMap(data)
+		}
+	}
+	if f.encoding == nil {
+		f.encoding = identityCMap
+	}
+
+	// The descendant font holds the widths and the font program
+	var descendant Dict
+	if arr, ok := rd.Resolve(dict["DescendantFonts"]).(Array); ok && len(arr) > 0 {
+		descendant, _ = rd.Resolve(arr[0]).(Dict)
+	}
+
+	f.defaultWidth = 1000
+	if dw, ok := number(rd.Resolve(descendant["DW"])); ok {
+		f.defaultWidth = dw
+	}
+
+	// W is a list of "c [w1 w2 ...]" and "cfirst clast w"
+	if w, ok := rd.Resolve(descendant["W"]).(Array); ok {
+		for i := 0; i < len(w); {
+			first, ok := integer(rd.Resolve(w[i]))
+			if !ok || i+1 >= len(w) {
+				break
+			}
+			if arr, ok := rd.Resolve(w[i+1]).(Array); ok {
+				for j, v := range arr {
+					if width, ok := number(rd.Resolve(v)); ok {
+						f.widths[int(first)+j] = width
+					}
+				}
+				i += 2
+				continue
+			}
+			last, ok1 := integer(rd.Resolve(w[i+1]))
+			if i+2 >= len(w) || !ok1 {
+				break
+			}
+			if width, ok := number(rd.Resolve(w[i+2])); ok && last >= first && last-first < 1<<16 {
+				for c := first; c <= last; c++ {
+					f.widths[int(c)] = width
+				}
+			}
+			i += 3
+		}
+	}
+
+	// Embedded TrueType fonts map the glyphs to Unicode
+	descriptor, _ := rd.Resolve(descendant["FontDescriptor"]).(Dict)
+	if stm, ok := rd.Resolve(descriptor["FontFile2"]).(*Stream); ok {
+		if data, err := rd.StreamData(stm); err == nil {
+			f.trueType = parseTrueTypeCmap(data)
+		}
+	}
+	if stm, ok := rd.Resolve(descendant["CIDToGIDMap"]).(*Stream); ok {
+		if data, err := rd.StreamData(stm); err == nil {
+			f.cidToGID = data
+		}
+	}
+}
+
+// decode splits s into codes and returns their glyphs
+func (f *font) decode(s []byte) []glyph {
+	glyphs := make([]glyph, 0, len(s))
+
+	for len(s) > 0 {
+		// Simple fonts have single byte codes
+		c, n := code{value: uint32(s[0]), n: 1}, 1
+		if f.composite {
+			c, n = f.encoding.next(s, 2)
+		}
+		s = s[n:]
+
+		g := glyph{space: n == 1 && c.value == 32}
+
+		// Width
+		key := int(c.value)
+		if f.composite {
+			key = f.encoding.cid(c)
+		}
+		w, ok := f.widths[key]
+		if !ok {
+			w = f.defaultWidth
+		}
+		g.width = w * f.scale
+
+		g.text = f.text(c)
+		glyphs = append(glyphs, g)
+	}
+
+	return glyphs
+}
+
+// text returns the Unicode text of the code
+func (f *font) text(c code) string {
+	if f.toUnicode != nil {
+		if text, ok := f.toUnicode.text(c); ok {
+			return text
+		}
+	}
+
+	if !f.composite {
+		return f.simple[c.value&0xFF]
+	}
+
+	if f.ucs2 {
+		units := make([]uint16, 0, 2)
+		if c.n == 4 {
+			units = append(units, uint16(c.value>>16))
+		}
+		units = append(units, uint16(c.value))
+		return string(utf16.Decode(units))
+	}
+
+	// The glyphs of embedded TrueType fonts
+	if f.trueType != nil && f.trueType.unicode != nil {
+		gid := f.encoding.cid(c)
+		if f.cidToGID != nil {
+			if 2*gid+1 >= len(f.cidToGID) {
+				return ""
+			}
+			gid = int(f.cidToGID[2*gid])<<8 | int(f.cidToGID[2*gid+1])
+		}
+		if r, ok := f.trueType.unicode[gid]; ok {
+			return string(r)
+		}
+	}
+
+	return ""
+}
+
+// isSymbolFont reports whether the font is one of the standard symbol fonts
+func isSymbolFont(baseFont string) bool {
+	baseFont = stripSubset(baseFont)
+	return strings.HasPrefix(baseFont, "Symbol") || strings.HasPrefix(baseFont, "ZapfDingbats")
+}
+
+// stripSubset removes the subset tag of a font name, e.g. ABCDEF+Times
+func stripSubset(baseFont string) string {
+	if i := strings.IndexByte(baseFont, '+'); i == 6 {
+		return baseFont[i+1:]
+	}
+	return baseFont
+}
+
+// type1EncodingEntry matches an entry of the encoding of a Type1 font
+var type1EncodingEntry = regexp.MustCompile(`dup\s+(\d+)\s*/([^\s/\[\]{}()<>%]+)\s+put`)
+
+// type1Encoding reads the built-in encoding of a Type1 font program
+func type1Encoding(data []byte) map[int]string {
+	// The encoding is in the cleartext part of the program
+	if i := bytes.Index(data, []byte("eexec")); i >= 0 {
+		data = data[:i]
+	}
+	if !bytes.Contains(data, []byte("/Encoding")) {
+		return nil
+	}
+
+	enc := make(map[int]string)
+	for _, m := range type1EncodingEntry.FindAllSubmatch(data, -1) {
+		if c, err := strconv.Atoi(string(m[1])); err == nil && c < 256 {
+			enc[c] = string(m[2])
+		}
+	}
+	return enc
+}
+
+// standardWidth returns the approximate width of a character
+// of the standard 14 fonts, in glyph space units
+func standardWidth(baseFont string, c rune) float64 {
+	baseFont = stripSubset(baseFont)
+	switch {
+	case strings.HasPrefix(baseFont, "Courier"):
+		return 600
+	case strings.HasPrefix(baseFont, "Times"):
+		if c >= 0x20 && c < 0x7F {
+			return float64(timesWidths[c-0x20])
+		}
+	default:
+		if c >= 0x20 && c < 0x7F {
+			return float64(helveticaWidths[c-0x20])
+		}
+	}
+	return 500
+}
+
+// helveticaWidths are the widths of the ASCII characters of Helvetica
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 222, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	222, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// timesWidths are the widths of the ASCII characters of Times-Roman
+var timesWidths = [95]int{
+	250, 333, 408, 500, 500, 833, 778, 333, 333, 333, 500, 564, 250, 333, 250, 278,
+	500, 500, 500, 500, 500, 500, 500, 500, 500, 500, 278, 278, 564, 564, 564, 444,
+	921, 722, 667, 667, 722, 611, 556, 722, 722, 333, 389, 722, 611, 889, 722, 722,
+	556, 722, 667, 556, 611, 722, 722, 944, 722, 722, 611, 333, 278, 333, 469, 500,
+	333, 444, 500, 444, 500, 444, 333, 500, 500, 278, 278, 500, 278, 778, 500, 500,
+	500, 500, 333, 389, 278, 500, 500, 722, 500, 500, 444, 480, 200, 480, 541,
+}
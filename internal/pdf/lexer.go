@@ -0,0 +1,509 @@
+package pdf
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+)
+
+// bufSize is the size of the chunks read by the lexer
+const bufSize = 4096
+
+// maxDepth limits the nesting of arrays and dictionaries
+const maxDepth = 100
+
+// lexer reads the tokens and objects of a PDF file or content stream
+type lexer struct {
+	r    io.ReaderAt
+	size int64
+
+	buf []byte // buffered data
+	off int64  // offset of buf[0]
+	i   int    // read position in buf
+
+	// refs enables the parsing of indirect references
+	refs bool
+}
+
+// newLexer returns a lexer reading r from offset
+func newLexer(r io.ReaderAt, size int64, offset int64) *lexer {
+	return &lexer{r: r, size: size, off: offset, refs: true}
+}
+
+// newBytesLexer returns a lexer reading the content of b
+func newBytesLexer(b []byte) *lexer {
+	return &lexer{r: bytes.NewReader(b), size: int64(len(b)), buf: b}
+}
+
+// pos returns the current offset
+func (lx *lexer) pos() int64 {
+	return lx.off + int64(lx.i)
+}
+
+// seek moves the lexer to offset
+func (lx *lexer) seek(offset int64) {
+	if offset >= lx.off && offset <= lx.off+int64(len(lx.buf)) {
+		lx.i = int(offset - lx.off)
+		return
+	}
+	lx.off = offset
+	lx.buf = lx.buf[:0]
+	lx.i = 0
+}
+
+// fill reads the next chunk, it reports false at the end of the data
+func (lx *lexer) fill() bool {
+	start := lx.pos()
+	if start >= lx.size {
+		return false
+	}
+
+	n := min(int64(bufSize), lx.size-start)
+	buf := make([]byte, n)
+	m, err := lx.r.ReadAt(buf, start)
+	if m == 0 && err != nil {
+		return false
+	}
+
+	lx.buf = buf[:m]
+	lx.off = start
+	lx.i = 0
+	return true
+}
+
+// peek returns the next byte without consuming it
+func (lx *lexer) peek() (byte, bool) {
+	if lx.i >= len(lx.buf) && !lx.fill() {
+		return 0, false
+	}
+	return lx.buf[lx.i], true
+}
+
+// next consumes the next byte
+func (lx *lexer) next() (byte, bool) {
+	c, ok := lx.peek()
+	if ok {
+		lx.i++
+	}
+	return c, ok
+}
+
+// isSpace reports whether c is a PDF white-space character
+func isSpace(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+// isDelimiter reports whether c is a PDF delimiter character
+func isDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+// isRegular reports whether c is a regular character
+func isRegular(c byte) bool {
+	return !isSpace(c) && !isDelimiter(c)
+}
+
+// skipSpace skips the white-space and the comments
+func (lx *lexer) skipSpace() {
+	for {
+		c, ok := lx.peek()
+		if !ok {
+			return
+		}
+		switch {
+		case isSpace(c):
+			lx.i++
+		case c == '%':
+			for {
+				c, ok = lx.next()
+				if !ok || c == '\r' || c == '\n' {
+					break
+				}
+			}
+		default:
+			return
+		}
+	}
+}
+
+// readToken reads the next token. Delimiters of arrays, dictionaries
+// and procedures are returned as keywords. It returns io.EOF at the
+// end of the data.
+func (lx *lexer) readToken() (Object, error) {
+	lx.skipSpace()
+
+	c, ok := lx.next()
+	if !ok {
+		return nil, io.EOF
+	}
+
+	switch c {
+	case '/':
+		return lx.readName(), nil
+	case '(':
+		return lx.readLiteralString(), nil
+	case '<':
+		if c, ok = lx.peek(); ok && c == '<' {
+			lx.i++
+			return Keyword("<<"), nil
+		}
+		return lx.readHexString(), nil
+	case '>':
+		if c, ok = lx.peek(); ok && c == '>' {
+			lx.i++
+			return Keyword(">>"), nil
+		}
+		// Stray delimiter
+		return Keyword(">"), nil
+	case '[', ']', '{', '}', ')':
+		return Keyword(string(c)), nil
+	}
+
+	// Numbers and keywords
+	var word []byte
+	word = append(word, c)
+	for {
+		c, ok = lx.peek()
+		if !ok || !isRegular(c) {
+			break
+		}
+		word = append(word, c)
+		lx.i++
+	}
+
+	return parseWord(word), nil
+}
+
+// parseWord returns the number or keyword spelled by word
+func parseWord(word []byte) Object {
+	switch string(word) {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+
+	c := word[0]
+	if c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9') {
+		s := string(word)
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+		// Malformed numbers, e.g. "--1" or "1.2.3", are read leniently
+		if f, ok := parseLenientFloat(s); ok {
+			return f
+		}
+	}
+
+	return Keyword(word)
+}
+
+// parseLenientFloat parses the leading valid part of a malformed number
+func parseLenientFloat(s string) (float64, bool) {
+	neg := false
+	for len(s) > 0 && (s[0] == '-' || s[0] == '+') {
+		neg = neg || s[0] == '-'
+		s = s[1:]
+	}
+
+	end, dot := 0, false
+	for end < len(s) && ((s[end] >= '0' && s[end] <= '9') || (s[end] == '.' && !dot)) {
+		dot = dot || s[end] == '.'
+		end++
+	}
+	if end == 0 || (end == 1 && dot) {
+		return 0, false
+	}
+
+	f, err := strconv.ParseFloat(s[:end], 64)
+	if err != nil {
+		return 0, false
+	}
+	if neg {
+		f = -f
+	}
+	return f, true
+}
+
+// readName reads a name after its slash
+func (lx *lexer) readName() Name {
+	var name []byte
+	for {
+		c, ok := lx.peek()
+		if !ok || !isRegular(c) {
+			break
+		}
+		lx.i++
+		// #xx escapes
+		if c == '#' {
+			h1, ok1 := lx.peek()
+			if ok1 && isHex(h1) {
+				lx.i++
+				h2, ok2 := lx.peek()
+				if ok2 && isHex(h2) {
+					lx.i++
+					name = append(name, unhex(h1)<<4|unhex(h2))
+					continue
+				}
+				name = append(name, c, h1)
+				continue
+			}
+		}
+		name = append(name, c)
+	}
+	return Name(name)
+}
+
+// readLiteralString reads a literal string after its opening parenthesis
+func (lx *lexer) readLiteralString() String {
+	var s []byte
+	depth := 1
+	for {
+		c, ok := lx.next()
+		if !ok {
+			return String(s)
+		}
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return String(s)
+			}
+		case '\r':
+			// End of lines are read as line feeds
+			if c, ok = lx.peek(); ok && c == '\n' {
+				lx.i++
+			}
+			s = append(s, '\n')
+			continue
+		case '\\':
+			c, ok = lx.next()
+			if !ok {
+				return String(s)
+			}
+			switch c {
+			case 'n':
+				s = append(s, '\n')
+			case 'r':
+				s = append(s, '\r')
+			case 't':
+				s = append(s, '\t')
+			case 'b':
+				s = append(s, '\b')
+			case 'f':
+				s = append(s, '\f')
+			case '\r':
+				// Line continuation
+				if c, ok = lx.peek(); ok && c == '\n' {
+					lx.i++
+				}
+			case '\n':
+				// Line continuation
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				v := int(c - '0')
+				for j := 0; j < 2; j++ {
+					c, ok = lx.peek()
+					if !ok || c < '0' || c > '7' {
+						break
+					}
+					lx.i++
+					v = v*8 + int(c-'0')
+				}
+				s = append(s, byte(v))
+			default:
+				s = append(s, c)
+			}
+			continue
+		}
+		s = append(s, c)
+	}
+}
+
+// readHexString reads a hexadecimal string after its opening bracket
+func (lx *lexer) readHexString() String {
+	var s []byte
+	var hi byte
+	odd := false
+	for {
+		c, ok := lx.next()
+		if !ok || c == '>' {
+			break
+		}
+		if !isHex(c) {
+			continue
+		}
+		if odd {
+			s = append(s, hi<<4|unhex(c))
+		} else {
+			hi = unhex(c)
+		}
+		odd = !odd
+	}
+	// A missing final digit is zero
+	if odd {
+		s = append(s, hi<<4)
+	}
+	return String(s)
+}
+
+// isHex reports whether c is a hexadecimal digit
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// unhex returns the value of the hexadecimal digit c
+func unhex(c byte) byte {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0'
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10
+	}
+	return 0
+}
+
+// readObject reads the next object. Arrays, dictionaries and,
+// if enabled, indirect references are assembled from their tokens.
+func (lx *lexer) readObject() (Object, error) {
+	return lx.readObjectDepth(0)
+}
+
+// readObjectDepth reads the next object nested at depth
+func (lx *lexer) readObjectDepth(depth int) (Object, error) {
+	if depth > maxDepth {
+		return nil, malformed("objects nested too deeply")
+	}
+
+	tok, err := lx.readToken()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case Keyword:
+		switch t {
+		case "[":
+			return lx.readArray(depth)
+		case "<<":
+			return lx.readDict(depth)
+		}
+		return t, nil
+	case int64:
+		if !lx.refs || t < 0 {
+			return t, nil
+		}
+		// An indirect reference is made of two integers and R
+		pos := lx.pos()
+		if gen, ok := lx.tryInteger(); ok {
+			if kw, err := lx.readToken(); err == nil && kw == Keyword("R") {
+				return Ref{Num: int(t), Gen: int(gen)}, nil
+			}
+		}
+		lx.seek(pos)
+		return t, nil
+	}
+
+	return tok, nil
+}
+
+// tryInteger reads a non-negative integer
+func (lx *lexer) tryInteger() (int64, bool) {
+	tok, err := lx.readToken()
+	if err != nil {
+		return 0, false
+	}
+	n, ok := tok.(int64)
+	return n, ok && n >= 0
+}
+
+// readArray reads the elements of an array after its opening bracket
+func (lx *lexer) readArray(depth int) (Array, error) {
+	var arr Array
+	for {
+		obj, err := lx.readObjectDepth(depth + 1)
+		if err != nil {
+			if err == io.EOF {
+				return arr, nil
+			}
+			return nil, err
+		}
+		if kw, ok := obj.(Keyword); ok {
+			switch kw {
+			case "]":
+				return arr, nil
+			case ">>", "endobj", "stream":
+				// Unterminated array
+				lx.unread(kw)
+				return arr, nil
+			}
+		}
+		arr = append(arr, obj)
+	}
+}
+
+// readDict reads the entries of a dictionary after its opening brackets
+func (lx *lexer) readDict(depth int) (Dict, error) {
+	dict := make(Dict)
+	for {
+		key, err := lx.readObjectDepth(depth + 1)
+		if err != nil {
+			if err == io.EOF {
+				return dict, nil
+			}
+			return nil, err
+		}
+
+		name, ok := key.(Name)
+		if !ok {
+			if kw, ok := key.(Keyword); ok {
+				switch kw {
+				case ">>":
+					return dict, nil
+				case "endobj", "stream":
+					// Unterminated dictionary
+					lx.unread(kw)
+					return dict, nil
+				}
+			}
+			// Skip the garbage
+			continue
+		}
+
+		value, err := lx.readObjectDepth(depth + 1)
+		if err != nil {
+			if err == io.EOF {
+				return dict, nil
+			}
+			return nil, err
+		}
+		if kw, ok := value.(Keyword); ok && kw == ">>" {
+			// Missing value
+			return dict, nil
+		}
+		if value != nil {
+			dict[name] = value
+		}
+	}
+}
+
+// unread moves the lexer back before the keyword just read
+func (lx *lexer) unread(kw Keyword) {
+	lx.seek(lx.pos() - int64(len(kw)))
+}
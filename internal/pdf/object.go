@@ -0,0 +1,81 @@
+// Package pdf extracts the text of PDF files without external tools.
+//
+// It reads the cross-reference tables and streams, decodes the common
+// stream filters, maps the glyphs of simple and composite fonts to
+// Unicode and interprets the text operators of the content streams.
+// Encrypted files are not supported.
+package pdf
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned by the reader
+var (
+	// ErrEncrypted is returned for encrypted files
+	ErrEncrypted = errors.New("pdf: encrypted file")
+	// ErrMalformed is returned when the file can not be parsed
+	ErrMalformed = errors.New("pdf: malformed file")
+)
+
+// malformed returns an ErrMalformed with a description
+func malformed(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrMalformed, fmt.Sprintf(format, args...))
+}
+
+// Object is a PDF object: nil, bool, int64, float64, Name, String,
+// Array, Dict, Ref, *Stream or Keyword
+type Object any
+
+// Name is a name object, e.g. /Type
+type Name string
+
+// String is a string object holding raw bytes
+type String string
+
+// Array is an array object
+type Array []Object
+
+// Dict is a dictionary object
+type Dict map[Name]Object
+
+// Ref is an indirect reference, e.g. 12 0 R
+type Ref struct {
+	Num int
+	Gen int
+}
+
+// Keyword is a bare keyword, e.g. an operator of a content stream
+type Keyword string
+
+// Stream is a stream object
+type Stream struct {
+	Dict Dict
+
+	// offset and length locate the raw data in the file
+	offset int64
+	length int64
+}
+
+// number returns the numeric value of obj
+func number(obj Object) (float64, bool) {
+	switch v := obj.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// integer returns the integer value of obj
+func integer(obj Object) (int64, bool) {
+	switch v := obj.(type) {
+	case int64:
+		return v, true
+	case float64:
+		return int64(v), true
+	}
+	return 0, false
+}
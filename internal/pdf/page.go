@@ -0,0 +1,220 @@
+package pdf
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// maxPageDepth limits the nesting of the page tree
+const maxPageDepth = 64
+
+// page is a leaf of the page tree with its inherited resources
+type page struct {
+	dict      Dict
+	resources Dict
+}
+
+// loadPages walks the page tree
+func (rd *Reader) loadPages() []page {
+	catalog := rd.catalog()
+	if catalog == nil {
+		return nil
+	}
+
+	var pages []page
+	visited := make(map[Ref]bool)
+
+	var walk func(obj Object, resources Dict, depth int)
+	walk = func(obj Object, resources Dict, depth int) {
+		if ref, ok := obj.(Ref); ok {
+			if visited[ref] {
+				return
+			}
+			visited[ref] = true
+		}
+		node, ok := rd.Resolve(obj).(Dict)
+		if !ok || depth > maxPageDepth {
+			return
+		}
+
+		// Resources are inherited from the ancestors
+		if res, ok := rd.Resolve(node["Resources"]).(Dict); ok {
+			resources = res
+		}
+
+		kids, isTree := rd.Resolve(node["Kids"]).(Array)
+		if !isTree || node["Type"] == Name("Page") {
+			pages = append(pages, page{dict: node, resources: resources})
+			return
+		}
+		for _, kid := range kids {
+			walk(kid, resources, depth+1)
+		}
+	}
+	walk(catalog["Pages"], nil, 0)
+
+	return pages
+}
+
+// NumPage returns the number of pages
+func (rd *Reader) NumPage() int {
+	return len(rd.pages)
+}
+
+// PageText returns the text of the page n, starting at 1
+func (rd *Reader) PageText(ctx context.Context, n int) (text string, err error) {
+	if n < 1 || n > len(rd.pages) {
+		return "", malformed("page %d out of range", n)
+	}
+
+	// The interpreter does not check every index
+	defer func() {
+		if e := recover(); e != nil {
+			text, err = "", malformed("page %d: %v", n, e)
+		}
+	}()
+
+	p := rd.pages[n-1]
+	data := rd.contents(p.dict["Contents"])
+
+	ex := newExtractor(ctx, rd)
+	if err = ex.run(data, p.resources, 0); err != nil {
+		return "", err
+	}
+
+	return ex.text(), nil
+}
+
+// contents returns the concatenated data of the content streams
+func (rd *Reader) contents(obj Object) []byte {
+	switch v := rd.Resolve(obj).(type) {
+	case *Stream:
+		data, _ := rd.StreamData(v)
+		return data
+	case Array:
+		var data []byte
+		for _, part := range v {
+			if stm, ok := rd.Resolve(part).(*Stream); ok {
+				if b, err := rd.StreamData(stm); err == nil {
+					// Tokens must not span the streams
+					data = append(append(data, b...), '\n')
+				}
+			}
+		}
+		return data
+	}
+	return nil
+}
+
+// Info returns the entries of the document information dictionary
+// which are text strings, e.g. Title and Author
+func (rd *Reader) Info() map[string]string {
+	info := make(map[string]string)
+
+	dict, ok := rd.Resolve(rd.trailer["Info"]).(Dict)
+	if !ok {
+		return info
+	}
+
+	for key, value := range dict {
+		if s, ok := rd.Resolve(value).(String); ok {
+			if text := strings.TrimSpace(TextString(s)); text != "" {
+				info[string(key)] = text
+			}
+		}
+	}
+
+	return info
+}
+
+// TextString decodes a text string, which is encoded in UTF-16BE
+// or UTF-8 with a byte order mark, or in PDFDocEncoding
+func TextString(s String) string {
+	switch {
+	case strings.HasPrefix(string(s), "\xFE\xFF"):
+		b := []byte(s[2:])
+		units := make([]uint16, 0, len(b)/2)
+		for i := 0; i+1 < len(b); i += 2 {
+			units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+		}
+		return string(utf16.Decode(units))
+	case strings.HasPrefix(string(s), "\xEF\xBB\xBF"):
+		return strings.ToValidUTF8(string(s[3:]), string(utf8.RuneError))
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if r := pdfDocEncoding[s[i]]; r != 0 {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteRune(rune(s[i]))
+		}
+	}
+	return sb.String()
+}
+
+// ParseDate parses a date string, e.g. D:20230102150405+01'00'
+func ParseDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(s), "D:"))
+	if len(s) < 4 {
+		return time.Time{}, false
+	}
+
+	// Read the digits of the date, missing fields have default values
+	fields := []int{0, 1, 1, 0, 0, 0}
+	sizes := []int{4, 2, 2, 2, 2, 2}
+	i := 0
+	for f, size := range sizes {
+		if i+size > len(s) || !allDigits(s[i:i+size]) {
+			if f == 0 {
+				return time.Time{}, false
+			}
+			break
+		}
+		fields[f] = atoi(s[i : i+size])
+		i += size
+	}
+
+	// Time zone: Z, +HH'mm' or -HH'mm'
+	loc := time.UTC
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		zone := strings.NewReplacer("'", "", ":", "").Replace(s[i+1:])
+		var hours, minutes int
+		if len(zone) >= 2 && allDigits(zone[:2]) {
+			hours = atoi(zone[:2])
+		}
+		if len(zone) >= 4 && allDigits(zone[2:4]) {
+			minutes = atoi(zone[2:4])
+		}
+		offset := hours*3600 + minutes*60
+		if s[i] == '-' {
+			offset = -offset
+		}
+		loc = time.FixedZone("", offset)
+	}
+
+	t := time.Date(fields[0], time.Month(fields[1]), fields[2], fields[3], fields[4], fields[5], 0, loc)
+	return t, true
+}
+
+// allDigits reports whether s only holds decimal digits
+func allDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// atoi converts the decimal digits of s
+func atoi(s string) int {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		n = n*10 + int(s[i]-'0')
+	}
+	return n
+}
@@ -0,0 +1,326 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// buildPDF writes a PDF file with the objects, numbered from 1, and a
+// cross-reference table. trailer is added to the trailer dictionary.
+func buildPDF(objects []string, trailer string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R %s >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, trailer, xref)
+
+	return b.Bytes()
+}
+
+// stream returns a stream object with the data
+func stream(dict, data string) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+// deflate compresses data with zlib
+func deflate(data string) string {
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	_, _ = w.Write([]byte(data))
+	_ = w.Close()
+	return b.String()
+}
+
+// simplePDF returns a document with a page per content stream
+func simplePDF(contents ...string) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Title (Test document) /Author <FEFF004A00FC007200670065006E> /CreationDate (D:20240102030405+01'00') >>",
+	}
+
+	var kids []string
+	for _, content := range contents {
+		n := len(objects) + 1
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents %d 0 R >>", n+1),
+			stream("/Filter /FlateDecode", deflate(content)))
+		kids = append(kids, fmt.Sprintf("%d 0 R", n))
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /Resources << /Font << /F1 3 0 R >> >> >>",
+		strings.Join(kids, " "), len(kids))
+
+	return buildPDF(objects, "/Info 4 0 R")
+}
+
+// TestPageText tests the text extraction of the pages
+func TestPageText(t *testing.T) {
+	data := simplePDF(
+		"BT /F1 12 Tf 72 720 Td (Hello, world!) Tj 0 -14 Td (Second line) Tj ET",
+		"BT /F1 12 Tf 14 TL 72 720 Td [(Kern)-20(ed)-600(words)] TJ T* (caf\\351) Tj ET",
+		"q 1 0 0 1 300 0 cm BT /F1 10 Tf 0 700 Td (right) Tj ET Q BT /F1 10 Tf 72 700 Td (left) Tj ET",
+	)
+
+	rd, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rd.NumPage() != 3 {
+		t.Fatalf("expected 3 pages, got %d", rd.NumPage())
+	}
+	if rd.Version() != "1.4" {
+		t.Errorf("expected version 1.4, got %q", rd.Version())
+	}
+
+	// Test data
+	testData := []struct {
+		page     int
+		expected string
+	}{
+		{1, "Hello, world!\nSecond line\n"},
+		{2, "Kerned words\ncafé\n"},
+		{3, "right\nleft\n"},
+	}
+
+	// Iterate over test data
+	for _, d := range testData {
+		text, err := rd.PageText(context.Background(), d.page)
+		if err != nil {
+			t.Errorf("page %d: %v", d.page, err)
+			continue
+		}
+		if text != d.expected {
+			t.Errorf("page %d: expected %q, got %q", d.page, d.expected, text)
+		}
+	}
+
+	if _, err := rd.PageText(context.Background(), 4); err == nil {
+		t.Error("expected an error for a page out of range")
+	}
+
+	info := rd.Info()
+	if info["Title"] != "Test document" || info["Author"] != "Jürgen" {
+		t.Errorf("unexpected info %v", info)
+	}
+	created, ok := ParseDate(info["CreationDate"])
+	if !ok || !created.Equal(time.Date(2024, 1, 2, 2, 4, 5, 0, time.UTC)) {
+		t.Errorf("unexpected creation date %v", created)
+	}
+}
+
+// TestToUnicode tests a composite font with a ToUnicode CMap
+func TestToUnicode(t *testing.T) {
+	cmap := `/CIDInit /ProcSet findresource begin
+12 dict begin
+begincmap
+1 begincodespacerange
+<0000> <FFFF>
+endcodespacerange
+2 beginbfchar
+<0001> <0048>
+<0002> <0069>
+endbfchar
+1 beginbfrange
+<0010> <0012> <03B1>
+endbfrange
+endcmap
+CMapName currentdict /CMap defineresource pop
+end
+end`
+
+	data := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 5 0 R >> >> /Contents 4 0 R >>",
+		stream("", "BT /F1 12 Tf 72 720 Td <00010002> Tj 0 -14 Td <001000110012> Tj ET"),
+		"<< /Type /Font /Subtype /Type0 /BaseFont /ABCDEF+Test /Encoding /Identity-H /DescendantFonts [6 0 R] /ToUnicode 7 0 R >>",
+		"<< /Type /Font /Subtype /CIDFontType2 /BaseFont /ABCDEF+Test /DW 500 >>",
+		stream("", cmap),
+	}, "")
+
+	rd, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := rd.PageText(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "Hi\nαβγ\n" {
+		t.Errorf("expected %q, got %q", "Hi\nαβγ\n", text)
+	}
+}
+
+// TestReconstruct tests a document with a damaged cross-reference table
+func TestReconstruct(t *testing.T) {
+	data := simplePDF("BT /F1 12 Tf 72 720 Td (Recovered) Tj ET")
+	// Point startxref to nowhere
+	i := bytes.LastIndex(data, []byte("startxref"))
+	data = append(data[:i:i], []byte("startxref\n999999\n%%EOF\n")...)
+
+	rd, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := rd.PageText(context.Background(), 1)
+	if err != nil || text != "Recovered\n" {
+		t.Errorf("expected %q, got %q (%v)", "Recovered\n", text, err)
+	}
+}
+
+// TestEncrypted tests an encrypted document
+func TestEncrypted(t *testing.T) {
+	data := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [] /Count 0 >>",
+		"<< /Filter /Standard /V 1 /R 2 /O (x) /U (y) /P -4 >>",
+	}, "/Encrypt 3 0 R")
+
+	_, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if !errors.Is(err, ErrEncrypted) {
+		t.Errorf("expected ErrEncrypted, got %v", err)
+	}
+}
+
+// TestMalformed tests data which is not a PDF document
+func TestMalformed(t *testing.T) {
+	// Test data
+	testData := []string{
+		"",
+		"not a pdf",
+		"%PDF-1.7\n",
+	}
+
+	// Iterate over test data
+	for _, data := range testData {
+		_, err := NewReader(strings.NewReader(data), int64(len(data)))
+		if !errors.Is(err, ErrMalformed) {
+			t.Errorf("%q: expected ErrMalformed, got %v", data, err)
+		}
+	}
+}
+
+// TestDecodeStream tests the stream filters
+func TestDecodeStream(t *testing.T) {
+	// Test data
+	testData := []struct {
+		dict     Dict
+		data     string
+		expected string
+	}{
+		{Dict{"Filter": Name("FlateDecode")}, deflate("flate"), "flate"},
+		{Dict{"Filter": Name("ASCIIHexDecode")}, "68 65 6C6C 6F>", "hello"},
+		{Dict{"Filter": Name("ASCII85Decode")}, "<~87cURD]i,\"Ebo80~>", "Hello World!"},
+		{Dict{"Filter": Name("RunLengthDecode")}, "\x02abc\xFEz\x80", "abczzz"},
+		{Dict{"Filter": Name("LZWDecode")}, "\x80\x0B\x60\x50\x22\x0C\x0C\x85\x01", "-----A---B"},
+		{Dict{"Filter": Array{Name("ASCIIHexDecode"), Name("RunLengthDecode")}}, "00 41 FD 42 80>", "ABBBB"},
+		{
+			Dict{"Filter": Name("FlateDecode"), "DecodeParms": Dict{"Predictor": int64(12), "Columns": int64(2)}},
+			deflate("\x02ab\x02\x01\x01"),
+			"abbc",
+		},
+	}
+
+	// Iterate over test data
+	for _, d := range testData {
+		out, err := decodeStream(d.dict, []byte(d.data), func(obj Object) Object { return obj })
+		if err != nil {
+			t.Errorf("%v: %v", d.dict, err)
+			continue
+		}
+		if string(out) != d.expected {
+			t.Errorf("%v: expected %q, got %q", d.dict, d.expected, out)
+		}
+	}
+}
+
+// TestTextString tests TextString function
+func TestTextString(t *testing.T) {
+	// Test data
+	testData := []struct {
+		input    String
+		expected string
+	}{
+		{String("plain"), "plain"},
+		{String("\xFE\xFF\x00A\x00\xE9"), "Aé"},
+		{String("\xEF\xBB\xBFcaf\xC3\xA9"), "café"},
+		{String("\x8Dquoted\x8E"), "“quoted”"},
+	}
+
+	// Iterate over test data
+	for _, d := range testData {
+		if got := TextString(d.input); got != d.expected {
+			t.Errorf("%q: expected %q, got %q", d.input, d.expected, got)
+		}
+	}
+}
+
+// TestXrefStream tests a document with a cross-reference stream
+// and compressed objects
+func TestXrefStream(t *testing.T) {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.5\n")
+
+	// Objects 1 to 3 are stored in the object stream 5
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 << /Type /Font /Subtype /Type1 /BaseFont /Times-Roman >> >> >> /Contents 4 0 R >>",
+	}
+	var header, body strings.Builder
+	for i, obj := range objs {
+		fmt.Fprintf(&header, "%d %d ", i+1, body.Len())
+		body.WriteString(obj + "\n")
+	}
+
+	offset4 := b.Len()
+	fmt.Fprintf(&b, "4 0 obj\n%s\nendobj\n", stream("", "BT /F1 11 Tf 50 50 Td (Compressed) Tj ET"))
+	offset5 := b.Len()
+	fmt.Fprintf(&b, "5 0 obj\n%s\nendobj\n", stream(
+		fmt.Sprintf("/Type /ObjStm /N 3 /First %d /Filter /FlateDecode", header.Len()),
+		deflate(header.String()+body.String())))
+
+	// Entries of 1, 2 and 2 bytes
+	offset6 := b.Len()
+	entries := []byte{
+		0, 0, 0, 0xFF, 0xFF,
+		2, 0, 5, 0, 0,
+		2, 0, 5, 0, 1,
+		2, 0, 5, 0, 2,
+		1, byte(offset4 >> 8), byte(offset4), 0, 0,
+		1, byte(offset5 >> 8), byte(offset5), 0, 0,
+		1, byte(offset6 >> 8), byte(offset6), 0, 0,
+	}
+	fmt.Fprintf(&b, "6 0 obj\n%s\nendobj\n", stream("/Type /XRef /Size 7 /W [1 2 2] /Root 1 0 R", string(entries)))
+	fmt.Fprintf(&b, "startxref\n%d\n%%%%EOF\n", offset6)
+
+	data := b.Bytes()
+	rd, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := rd.PageText(context.Background(), 1)
+	if err != nil || text != "Compressed\n" {
+		t.Errorf("expected %q, got %q (%v)", "Compressed\n", text, err)
+	}
+}
@@ -0,0 +1,662 @@
+package pdf
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// maxResolveDepth limits the chains of indirect references
+const maxResolveDepth = 32
+
+// xrefEntry locates an object in the file
+type xrefEntry struct {
+	// compressed reports whether the object is stored in an object stream
+	compressed bool
+	// offset is the offset of the object, or the number of its object stream
+	offset int64
+	// index is the index of the object in its object stream
+	index int
+}
+
+// objStm is a decoded object stream
+type objStm struct {
+	data    []byte
+	nums    []int
+	offsets []int64
+}
+
+// Reader reads the objects and the pages of a PDF file
+type Reader struct {
+	r    io.ReaderAt
+	size int64
+
+	version string
+	xref    map[int]xrefEntry
+	trailer Dict
+
+	mu      sync.Mutex
+	objects map[int]Object
+	objStms map[int]*objStm
+	pending map[int]bool
+	fonts   map[uintptr]*font
+
+	pages []page
+}
+
+// NewReader opens the PDF file read from r, size is the size of the file
+func NewReader(r io.ReaderAt, size int64) (rd *Reader, err error) {
+	rd = &Reader{
+		r:       r,
+		size:    size,
+		objects: make(map[int]Object),
+		objStms: make(map[int]*objStm),
+		pending: make(map[int]bool),
+		fonts:   make(map[uintptr]*font),
+	}
+
+	// The parsers do not check every index
+	defer func() {
+		if e := recover(); e != nil {
+			rd, err = nil, malformed("%v", e)
+		}
+	}()
+
+	if err = rd.readHeader(); err != nil {
+		return nil, err
+	}
+
+	// Files with a broken cross-reference table are reconstructed
+	if err = rd.readXref(); err != nil || rd.catalog() == nil {
+		if err = rd.reconstruct(); err != nil {
+			return nil, err
+		}
+	}
+
+	if rd.trailer["Encrypt"] != nil {
+		return nil, ErrEncrypted
+	}
+
+	rd.pages = rd.loadPages()
+
+	return rd, nil
+}
+
+// readHeader reads the version of the file
+func (rd *Reader) readHeader() error {
+	head := make([]byte, min(1024, rd.size))
+	n, _ := rd.r.ReadAt(head, 0)
+	head = head[:n]
+
+	i := bytes.Index(head, []byte("%PDF-"))
+	if i < 0 {
+		return malformed("missing header")
+	}
+
+	version := head[i+5:]
+	end := 0
+	for end < len(version) && end < 8 && (version[end] == '.' || (version[end] >= '0' && version[end] <= '9')) {
+		end++
+	}
+	rd.version = string(version[:end])
+
+	return nil
+}
+
+// Version returns the version of the file, e.g. "1.7"
+func (rd *Reader) Version() string {
+	// The catalog may override the version of the header
+	if catalog := rd.catalog(); catalog != nil {
+		if v, ok := rd.Resolve(catalog["Version"]).(Name); ok && v != "" {
+			return string(v)
+		}
+	}
+	return rd.version
+}
+
+// catalog returns the document catalog
+func (rd *Reader) catalog() Dict {
+	catalog, _ := rd.Resolve(rd.trailer["Root"]).(Dict)
+	return catalog
+}
+
+// readXref reads the chain of cross-reference sections
+// starting at the last startxref
+func (rd *Reader) readXref() error {
+	tailLen := min(rd.size, 4096)
+	tail := make([]byte, tailLen)
+	n, _ := rd.r.ReadAt(tail, rd.size-tailLen)
+	tail = tail[:n]
+
+	i := bytes.LastIndex(tail, []byte("startxref"))
+	if i < 0 {
+		return malformed("missing startxref")
+	}
+	lx := newBytesLexer(tail[i+len("startxref"):])
+	offset, ok := lx.tryInteger()
+	if !ok {
+		return malformed("invalid startxref")
+	}
+
+	rd.xref = make(map[int]xrefEntry)
+	rd.trailer = nil
+	visited := make(map[int64]bool)
+
+	for offset > 0 && !visited[offset] {
+		visited[offset] = true
+		if offset >= rd.size {
+			return malformed("startxref beyond the end of the file")
+		}
+
+		trailer, err := rd.readXrefSection(offset)
+		if err != nil {
+			return err
+		}
+
+		// Hybrid files store the compressed objects in an xref stream
+		if stm, ok := integer(trailer["XRefStm"]); ok && !visited[stm] {
+			visited[stm] = true
+			if _, err = rd.readXrefSection(stm); err != nil {
+				return err
+			}
+		}
+
+		// The newest trailer wins
+		if rd.trailer == nil {
+			rd.trailer = trailer
+		} else {
+			for key, value := range trailer {
+				if _, ok := rd.trailer[key]; !ok {
+					rd.trailer[key] = value
+				}
+			}
+		}
+
+		offset, _ = integer(trailer["Prev"])
+	}
+
+	if rd.trailer == nil {
+		return malformed("missing trailer")
+	}
+
+	return nil
+}
+
+// readXrefSection reads a cross-reference table or stream at offset
+// and returns its trailer. Entries already read are newer and kept.
+func (rd *Reader) readXrefSection(offset int64) (Dict, error) {
+	lx := newLexer(rd.r, rd.size, offset)
+	tok, err := lx.readToken()
+	if err != nil {
+		return nil, malformed("invalid xref offset")
+	}
+
+	if tok == Keyword("xref") {
+		return rd.readXrefTable(lx)
+	}
+
+	// Cross-reference stream
+	lx.seek(offset)
+	_, obj, err := rd.readIndirect(lx)
+	if err != nil {
+		return nil, err
+	}
+	stm, ok := obj.(*Stream)
+	if !ok || stm.Dict["Type"] != Name("XRef") {
+		return nil, malformed("invalid xref stream")
+	}
+	if err = rd.readXrefStream(stm); err != nil {
+		return nil, err
+	}
+
+	return stm.Dict, nil
+}
+
+// readXrefTable reads the subsections of a cross-reference table
+func (rd *Reader) readXrefTable(lx *lexer) (Dict, error) {
+	for {
+		tok, err := lx.readToken()
+		if err != nil {
+			return nil, malformed("unterminated xref table")
+		}
+		if tok == Keyword("trailer") {
+			break
+		}
+
+		start, ok1 := tok.(int64)
+		count, ok2 := lx.tryInteger()
+		if !ok1 || !ok2 || start < 0 {
+			return nil, malformed("invalid xref subsection")
+		}
+
+		for i := int64(0); i < count; i++ {
+			offset, ok1 := lx.tryInteger()
+			_, ok2 := lx.tryInteger()
+			kind, err := lx.readToken()
+			if !ok1 || !ok2 || err != nil {
+				return nil, malformed("invalid xref entry")
+			}
+
+			num := int(start + i)
+			if _, ok := rd.xref[num]; ok {
+				continue
+			}
+			switch kind {
+			case Keyword("n"):
+				rd.xref[num] = xrefEntry{offset: offset}
+			case Keyword("f"):
+				rd.xref[num] = xrefEntry{offset: -1}
+			default:
+				return nil, malformed("invalid xref entry type")
+			}
+		}
+	}
+
+	lx.refs = true
+	obj, err := lx.readObject()
+	if err != nil {
+		return nil, malformed("invalid trailer")
+	}
+	trailer, ok := obj.(Dict)
+	if !ok {
+		return nil, malformed("invalid trailer")
+	}
+
+	return trailer, nil
+}
+
+// readXrefStream reads the entries of a cross-reference stream
+func (rd *Reader) readXrefStream(stm *Stream) error {
+	data, err := rd.StreamData(stm)
+	if err != nil {
+		return err
+	}
+
+	w, ok := stm.Dict["W"].(Array)
+	if !ok || len(w) < 3 {
+		return malformed("invalid xref stream widths")
+	}
+	var widths [3]int
+	rowLen := 0
+	for i := range widths {
+		n, _ := integer(w[i])
+		if n < 0 || n > 8 {
+			return malformed("invalid xref stream widths")
+		}
+		widths[i] = int(n)
+		rowLen += widths[i]
+	}
+	if rowLen == 0 {
+		return malformed("invalid xref stream widths")
+	}
+
+	size, _ := integer(stm.Dict["Size"])
+	index := Array{int64(0), size}
+	if arr, ok := stm.Dict["Index"].(Array); ok {
+		index = arr
+	}
+
+	field := func(row []byte, i int) int64 {
+		start := 0
+		for j := 0; j < i; j++ {
+			start += widths[j]
+		}
+		var v int64
+		for _, b := range row[start : start+widths[i]] {
+			v = v<<8 | int64(b)
+		}
+		return v
+	}
+
+	pos := 0
+	for i := 0; i+1 < len(index); i += 2 {
+		start, _ := integer(index[i])
+		count, _ := integer(index[i+1])
+		for j := int64(0); j < count && pos+rowLen <= len(data); j++ {
+			row := data[pos : pos+rowLen]
+			pos += rowLen
+
+			num := int(start + j)
+			if _, ok := rd.xref[num]; ok {
+				continue
+			}
+
+			// The type defaults to 1 when its width is zero
+			typ := int64(1)
+			if widths[0] > 0 {
+				typ = field(row, 0)
+			}
+			switch typ {
+			case 0:
+				rd.xref[num] = xrefEntry{offset: -1}
+			case 1:
+				rd.xref[num] = xrefEntry{offset: field(row, 1)}
+			case 2:
+				rd.xref[num] = xrefEntry{compressed: true, offset: field(row, 1), index: int(field(row, 2))}
+			}
+		}
+	}
+
+	return nil
+}
+
+// objHeader matches the header of an indirect object
+var objHeader = regexp.MustCompile(`(\d+)[ \t\r\n\f\x00]+(\d+)[ \t\r\n\f\x00]+obj\b`)
+
+// reconstruct rebuilds the cross-reference table by scanning the file
+func (rd *Reader) reconstruct() error {
+	if rd.size > 1<<31 {
+		return malformed("file too large to reconstruct")
+	}
+	data := make([]byte, rd.size)
+	n, _ := rd.r.ReadAt(data, 0)
+	data = data[:n]
+
+	rd.xref = make(map[int]xrefEntry)
+	rd.trailer = make(Dict)
+	rd.objects = make(map[int]Object)
+	rd.objStms = make(map[int]*objStm)
+
+	// Later definitions of an object win
+	for _, m := range objHeader.FindAllSubmatchIndex(data, -1) {
+		// The number must not be the end of a longer token
+		if m[0] > 0 && !isSpace(data[m[0]-1]) && !isDelimiter(data[m[0]-1]) {
+			continue
+		}
+		num, err := strconv.Atoi(string(data[m[2]:m[3]]))
+		if err != nil {
+			continue
+		}
+		rd.xref[num] = xrefEntry{offset: int64(m[0])}
+	}
+
+	// Objects of object streams
+	nums := make([]int, 0, len(rd.xref))
+	for num := range rd.xref {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+	for _, num := range nums {
+		stm, ok := rd.Resolve(Ref{Num: num}).(*Stream)
+		if !ok || stm.Dict["Type"] != Name("ObjStm") {
+			continue
+		}
+		objs, err := rd.objStm(num)
+		if err != nil {
+			continue
+		}
+		for i, objNum := range objs.nums {
+			if _, ok := rd.xref[objNum]; !ok {
+				rd.xref[objNum] = xrefEntry{compressed: true, offset: int64(num), index: i}
+			}
+		}
+	}
+
+	// Trailers and cross-reference streams hold the root
+	for _, i := range indexAll(data, []byte("trailer")) {
+		lx := newBytesLexer(data[i+len("trailer"):])
+		lx.refs = true
+		if trailer, err := lx.readObject(); err == nil {
+			if dict, ok := trailer.(Dict); ok {
+				for key, value := range dict {
+					rd.trailer[key] = value
+				}
+			}
+		}
+	}
+	if rd.catalog() == nil {
+		for _, num := range nums {
+			obj := rd.Resolve(Ref{Num: num})
+			if stm, ok := obj.(*Stream); ok && stm.Dict["Type"] == Name("XRef") {
+				for key, value := range stm.Dict {
+					if key == "Root" || key == "Info" || key == "Encrypt" {
+						rd.trailer[key] = value
+					}
+				}
+			}
+			if dict, ok := obj.(Dict); ok && dict["Type"] == Name("Catalog") && rd.catalog() == nil {
+				rd.trailer["Root"] = Ref{Num: num}
+			}
+		}
+	}
+
+	if rd.catalog() == nil {
+		return malformed("missing catalog")
+	}
+
+	return nil
+}
+
+// indexAll returns the offsets of all the occurrences of sep in s
+func indexAll(s, sep []byte) []int {
+	var offsets []int
+	for i := 0; ; {
+		j := bytes.Index(s[i:], sep)
+		if j < 0 {
+			return offsets
+		}
+		offsets = append(offsets, i+j)
+		i += j + len(sep)
+	}
+}
+
+// readIndirect reads the indirect object at the position of lx
+func (rd *Reader) readIndirect(lx *lexer) (int, Object, error) {
+	num, ok1 := lx.tryInteger()
+	_, ok2 := lx.tryInteger()
+	kw, err := lx.readToken()
+	if !ok1 || !ok2 || err != nil || kw != Keyword("obj") {
+		return 0, nil, malformed("invalid object header")
+	}
+
+	obj, err := lx.readObject()
+	if err != nil {
+		return 0, nil, malformed("invalid object %d", num)
+	}
+
+	dict, ok := obj.(Dict)
+	if !ok {
+		return int(num), obj, nil
+	}
+
+	// A stream follows its dictionary
+	pos := lx.pos()
+	if tok, err := lx.readToken(); err != nil || tok != Keyword("stream") {
+		lx.seek(pos)
+		return int(num), obj, nil
+	}
+
+	// The data starts after the end of line
+	if c, ok := lx.peek(); ok && c == '\r' {
+		lx.i++
+	}
+	if c, ok := lx.peek(); ok && c == '\n' {
+		lx.i++
+	}
+
+	stm := &Stream{Dict: dict, offset: lx.pos()}
+	stm.length = rd.streamLength(stm)
+
+	return int(num), stm, nil
+}
+
+// streamLength returns the length of the raw stream data. The length
+// of the dictionary is checked against the endstream keyword.
+func (rd *Reader) streamLength(stm *Stream) int64 {
+	if length, ok := integer(rd.Resolve(stm.Dict["Length"])); ok && length >= 0 && stm.offset+length <= rd.size {
+		lx := newLexer(rd.r, rd.size, stm.offset+length)
+		if tok, err := lx.readToken(); err == nil && tok == Keyword("endstream") {
+			return length
+		}
+	}
+
+	// Search the endstream keyword
+	const chunk = 64 << 10
+	keyword := []byte("endstream")
+	buf := make([]byte, chunk+len(keyword))
+	for offset := stm.offset; offset < rd.size; offset += chunk {
+		n, _ := rd.r.ReadAt(buf, offset)
+		if n == 0 {
+			break
+		}
+		if i := bytes.Index(buf[:n], keyword); i >= 0 {
+			// The end of line before endstream is not part of the data
+			end := offset + int64(i)
+			data := buf[:i]
+			if bytes.HasSuffix(data, []byte("\r\n")) {
+				end -= 2
+			} else if bytes.HasSuffix(data, []byte("\n")) || bytes.HasSuffix(data, []byte("\r")) {
+				end--
+			}
+			return max(end-stm.offset, 0)
+		}
+	}
+
+	return rd.size - stm.offset
+}
+
+// Resolve follows indirect references and returns the referenced object.
+// It returns nil for missing and damaged objects.
+func (rd *Reader) Resolve(obj Object) Object {
+	for depth := 0; depth < maxResolveDepth; depth++ {
+		ref, ok := obj.(Ref)
+		if !ok {
+			return obj
+		}
+		obj = rd.object(ref.Num)
+	}
+	return nil
+}
+
+// object returns the indirect object num
+func (rd *Reader) object(num int) Object {
+	rd.mu.Lock()
+	if obj, ok := rd.objects[num]; ok {
+		rd.mu.Unlock()
+		return obj
+	}
+	// Break reference cycles, e.g. a stream length referring to itself
+	if rd.pending[num] {
+		rd.mu.Unlock()
+		return nil
+	}
+	rd.pending[num] = true
+	rd.mu.Unlock()
+
+	obj := rd.loadObject(num)
+
+	rd.mu.Lock()
+	delete(rd.pending, num)
+	rd.objects[num] = obj
+	rd.mu.Unlock()
+
+	return obj
+}
+
+// loadObject reads the indirect object num from the file
+func (rd *Reader) loadObject(num int) Object {
+	entry, ok := rd.xref[num]
+	if !ok || entry.offset < 0 {
+		return nil
+	}
+
+	if entry.compressed {
+		return rd.compressedObject(int(entry.offset), entry.index)
+	}
+
+	if entry.offset >= rd.size {
+		return nil
+	}
+	lx := newLexer(rd.r, rd.size, entry.offset)
+	_, obj, err := rd.readIndirect(lx)
+	if err != nil {
+		return nil
+	}
+
+	return obj
+}
+
+// compressedObject returns the object stored at index in an object stream
+func (rd *Reader) compressedObject(stmNum, index int) Object {
+	objs, err := rd.objStm(stmNum)
+	if err != nil || index < 0 || index >= len(objs.offsets) {
+		return nil
+	}
+
+	offset := objs.offsets[index]
+	if offset < 0 || offset >= int64(len(objs.data)) {
+		return nil
+	}
+
+	lx := newBytesLexer(objs.data)
+	lx.refs = true
+	lx.seek(offset)
+	obj, err := lx.readObject()
+	if err != nil {
+		return nil
+	}
+
+	return obj
+}
+
+// objStm returns the decoded object stream num
+func (rd *Reader) objStm(num int) (*objStm, error) {
+	rd.mu.Lock()
+	objs, ok := rd.objStms[num]
+	rd.mu.Unlock()
+	if ok {
+		return objs, nil
+	}
+
+	stm, ok := rd.Resolve(Ref{Num: num}).(*Stream)
+	if !ok {
+		return nil, malformed("invalid object stream %d", num)
+	}
+	data, err := rd.StreamData(stm)
+	if err != nil {
+		return nil, err
+	}
+
+	n, _ := integer(rd.Resolve(stm.Dict["N"]))
+	first, _ := integer(rd.Resolve(stm.Dict["First"]))
+	if n < 0 || first < 0 || first > int64(len(data)) {
+		return nil, malformed("invalid object stream %d", num)
+	}
+
+	// The header holds pairs of object numbers and offsets
+	objs = &objStm{data: data}
+	lx := newBytesLexer(data[:first])
+	for i := int64(0); i < n; i++ {
+		objNum, ok1 := lx.tryInteger()
+		offset, ok2 := lx.tryInteger()
+		if !ok1 || !ok2 {
+			break
+		}
+		objs.nums = append(objs.nums, int(objNum))
+		objs.offsets = append(objs.offsets, first+offset)
+	}
+
+	rd.mu.Lock()
+	rd.objStms[num] = objs
+	rd.mu.Unlock()
+
+	return objs, nil
+}
+
+// StreamData returns the decoded data of the stream
+func (rd *Reader) StreamData(stm *Stream) ([]byte, error) {
+	if stm.length > maxStreamSize {
+		return nil, malformed("stream too large")
+	}
+
+	raw := make([]byte, stm.length)
+	n, err := rd.r.ReadAt(raw, stm.offset)
+	if n < len(raw) && err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return decodeStream(stm.Dict, raw[:n], rd.Resolve)
+}
@@ -0,0 +1,400 @@
+package pdf
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// maxFormDepth limits the nesting of form XObjects
+const maxFormDepth = 8
+
+// maxOperands limits the operands kept for an operator
+const maxOperands = 64
+
+// matrix is a transformation matrix [a b c d e f]
+type matrix [6]float64
+
+// identity is the identity matrix
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+// mul returns the product m × n
+func (m matrix) mul(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+// translate returns the translation matrix by (x, y)
+func translate(x, y float64) matrix {
+	return matrix{1, 0, 0, 1, x, y}
+}
+
+// gstate is the part of the graphics state used to place the text
+type gstate struct {
+	ctm       matrix
+	font      *font
+	fontSize  float64
+	charSpace float64
+	wordSpace float64
+	hScale    float64
+	leading   float64
+	rise      float64
+}
+
+// extractor interprets the content streams of a page and
+// writes its text in the order of the content
+type extractor struct {
+	ctx context.Context
+	rd  *Reader
+
+	gs    gstate
+	stack []gstate
+	// tm and tlm are the text matrix and the text line matrix
+	tm, tlm matrix
+
+	forms []*Stream
+	ops   int
+
+	sb strings.Builder
+	// The end of the last glyph in device space
+	started      bool
+	lastX, lastY float64
+	lastSize     float64
+	lastDir      [2]float64
+}
+
+// newExtractor returns an extractor for a page of rd
+func newExtractor(ctx context.Context, rd *Reader) *extractor {
+	return &extractor{
+		ctx: ctx,
+		rd:  rd,
+		gs:  gstate{ctm: identity, hScale: 1},
+		tm:  identity,
+		tlm: identity,
+	}
+}
+
+// text returns the extracted text ending with a line feed
+func (ex *extractor) text() string {
+	s := ex.sb.String()
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		s += "\n"
+	}
+	return s
+}
+
+// run interprets a content stream with its resources
+func (ex *extractor) run(data []byte, resources Dict, depth int) error {
+	lx := newBytesLexer(data)
+	var operands []Object
+
+	for {
+		obj, err := lx.readObject()
+		if err != nil {
+			// io.EOF or damaged content, the text read so far is kept
+			return nil
+		}
+
+		op, ok := obj.(Keyword)
+		if !ok {
+			if len(operands) < maxOperands {
+				operands = append(operands, obj)
+			}
+			continue
+		}
+
+		// Check the context from time to time
+		ex.ops++
+		if ex.ops%4096 == 0 && ex.ctx.Err() != nil {
+			return ex.ctx.Err()
+		}
+
+		if err = ex.operator(op, operands, resources, depth, lx); err != nil {
+			return err
+		}
+		operands = operands[:0]
+	}
+}
+
+// operator runs a content stream operator
+func (ex *extractor) operator(op Keyword, args []Object, resources Dict, depth int, lx *lexer) error {
+	num := func(i int) float64 {
+		if i < len(args) {
+			v, _ := number(args[i])
+			return v
+		}
+		return 0
+	}
+
+	switch op {
+	case "q":
+		ex.stack = append(ex.stack, ex.gs)
+	case "Q":
+		if n := len(ex.stack); n > 0 {
+			ex.gs = ex.stack[n-1]
+			ex.stack = ex.stack[:n-1]
+		}
+	case "cm":
+		if len(args) == 6 {
+			ex.gs.ctm = matrix{num(0), num(1), num(2), num(3), num(4), num(5)}.mul(ex.gs.ctm)
+		}
+	case "BT":
+		ex.tm, ex.tlm = identity, identity
+	case "Tf":
+		if len(args) == 2 {
+			name, _ := args[0].(Name)
+			ex.gs.font = ex.font(resources, name)
+			ex.gs.fontSize = num(1)
+		}
+	case "Tc":
+		ex.gs.charSpace = num(0)
+	case "Tw":
+		ex.gs.wordSpace = num(0)
+	case "Tz":
+		ex.gs.hScale = num(0) / 100
+	case "TL":
+		ex.gs.leading = num(0)
+	case "Ts":
+		ex.gs.rise = num(0)
+	case "Td":
+		ex.moveText(num(0), num(1))
+	case "TD":
+		ex.gs.leading = -num(1)
+		ex.moveText(num(0), num(1))
+	case "Tm":
+		if len(args) == 6 {
+			ex.tm = matrix{num(0), num(1), num(2), num(3), num(4), num(5)}
+			ex.tlm = ex.tm
+		}
+	case "T*":
+		ex.moveText(0, -ex.gs.leading)
+	case "Tj":
+		if len(args) > 0 {
+			ex.show(args[0])
+		}
+	case "'":
+		ex.moveText(0, -ex.gs.leading)
+		if len(args) > 0 {
+			ex.show(args[len(args)-1])
+		}
+	case "\"":
+		if len(args) == 3 {
+			ex.gs.wordSpace = num(0)
+			ex.gs.charSpace = num(1)
+			ex.moveText(0, -ex.gs.leading)
+			ex.show(args[2])
+		}
+	case "TJ":
+		if len(args) > 0 {
+			arr, _ := args[0].(Array)
+			for _, item := range arr {
+				if adj, ok := number(item); ok {
+					// Adjustments are in thousandths of the font size
+					tx := -adj / 1000 * ex.gs.fontSize * ex.gs.hScale
+					ex.tm = translate(tx, 0).mul(ex.tm)
+					continue
+				}
+				ex.show(item)
+			}
+		}
+	case "Do":
+		if len(args) > 0 {
+			name, _ := args[0].(Name)
+			return ex.form(resources, name, depth)
+		}
+	case "BI":
+		skipInlineImage(lx)
+	}
+
+	return nil
+}
+
+// moveText starts a new line offset by (tx, ty)
+func (ex *extractor) moveText(tx, ty float64) {
+	ex.tlm = translate(tx, ty).mul(ex.tlm)
+	ex.tm = ex.tlm
+}
+
+// font returns the font of the resources
+func (ex *extractor) font(resources Dict, name Name) *font {
+	fonts, _ := ex.rd.Resolve(resources["Font"]).(Dict)
+	dict, ok := ex.rd.Resolve(fonts[name]).(Dict)
+	if !ok {
+		return nil
+	}
+
+	// Fonts are shared by the pages
+	key := reflect.ValueOf(dict).Pointer()
+	ex.rd.mu.Lock()
+	f, ok := ex.rd.fonts[key]
+	ex.rd.mu.Unlock()
+	if ok {
+		return f
+	}
+
+	f = ex.rd.loadFont(dict)
+	ex.rd.mu.Lock()
+	ex.rd.fonts[key] = f
+	ex.rd.mu.Unlock()
+	return f
+}
+
+// form interprets a form XObject
+func (ex *extractor) form(resources Dict, name Name, depth int) error {
+	xobjects, _ := ex.rd.Resolve(resources["XObject"]).(Dict)
+	stm, ok := ex.rd.Resolve(xobjects[name]).(*Stream)
+	if !ok || stm.Dict["Subtype"] != Name("Form") || depth >= maxFormDepth {
+		return nil
+	}
+
+	// Forms must not draw themselves
+	for _, f := range ex.forms {
+		if f == stm {
+			return nil
+		}
+	}
+
+	data, err := ex.rd.StreamData(stm)
+	if err != nil {
+		return nil
+	}
+
+	formResources, ok := ex.rd.Resolve(stm.Dict["Resources"]).(Dict)
+	if !ok {
+		formResources = resources
+	}
+
+	// The form has its own graphics and text state
+	saved, tm, tlm := ex.gs, ex.tm, ex.tlm
+	if m, ok := ex.rd.Resolve(stm.Dict["Matrix"]).(Array); ok && len(m) == 6 {
+		var fm matrix
+		for i := range fm {
+			fm[i], _ = number(ex.rd.Resolve(m[i]))
+		}
+		ex.gs.ctm = fm.mul(ex.gs.ctm)
+	}
+
+	ex.forms = append(ex.forms, stm)
+	err = ex.run(data, formResources, depth+1)
+	ex.forms = ex.forms[:len(ex.forms)-1]
+
+	ex.gs, ex.tm, ex.tlm = saved, tm, tlm
+	return err
+}
+
+// show places the glyphs of a string
+func (ex *extractor) show(obj Object) {
+	s, ok := obj.(String)
+	if !ok || ex.gs.font == nil {
+		return
+	}
+
+	gs := &ex.gs
+	for _, g := range gs.font.decode([]byte(s)) {
+		// The rendering matrix places the glyph on the page
+		trm := matrix{gs.fontSize * gs.hScale, 0, 0, gs.fontSize, 0, gs.rise}.mul(ex.tm).mul(gs.ctm)
+
+		tx := g.width*gs.fontSize + gs.charSpace
+		if g.space {
+			tx += gs.wordSpace
+		}
+		tx *= gs.hScale
+		ex.tm = translate(tx, 0).mul(ex.tm)
+
+		end := matrix{1, 0, 0, 1, 0, gs.rise}.mul(ex.tm).mul(gs.ctm)
+		ex.emit(g.text, trm, end[4], end[5])
+	}
+}
+
+// emit writes the text of a glyph, preceded by a space or a line
+// feed when it is away from the previous glyph
+func (ex *extractor) emit(text string, trm matrix, endX, endY float64) {
+	x, y := trm[4], trm[5]
+	size := math.Hypot(trm[2], trm[3])
+
+	dir := [2]float64{trm[0], trm[1]}
+	if l := math.Hypot(dir[0], dir[1]); l > 0 {
+		dir[0], dir[1] = dir[0]/l, dir[1]/l
+	} else {
+		dir = [2]float64{1, 0}
+	}
+
+	if ex.started {
+		dx, dy := x-ex.lastX, y-ex.lastY
+		along := dx*ex.lastDir[0] + dy*ex.lastDir[1]
+		across := dy*ex.lastDir[0] - dx*ex.lastDir[1]
+		ref := max(size, ex.lastSize)
+		turned := dir[0]*ex.lastDir[0]+dir[1]*ex.lastDir[1] < 0.9
+
+		switch {
+		case turned || math.Abs(across) > 0.5*ref || along < -3*ref:
+			ex.newline()
+		case along > 0.15*ref && !strings.HasPrefix(text, " "):
+			ex.space()
+		}
+	}
+
+	if text != "" {
+		ex.sb.WriteString(text)
+	}
+
+	ex.started = true
+	ex.lastX, ex.lastY = endX, endY
+	ex.lastSize = size
+	ex.lastDir = dir
+}
+
+// newline ends the current line
+func (ex *extractor) newline() {
+	if last := ex.last(); last != "" && last != "\n" {
+		ex.sb.WriteByte('\n')
+	}
+}
+
+// space separates two words
+func (ex *extractor) space() {
+	if last := ex.last(); last != "" && last != " " && last != "\n" {
+		ex.sb.WriteByte(' ')
+	}
+}
+
+// last returns the last byte written
+func (ex *extractor) last() string {
+	s := ex.sb.String()
+	if s == "" {
+		return ""
+	}
+	return s[len(s)-1:]
+}
+
+// skipInlineImage skips the data of an inline image after BI
+func skipInlineImage(lx *lexer) {
+	// The image dictionary ends with ID
+	for {
+		obj, err := lx.readToken()
+		if err != nil {
+			return
+		}
+		if obj == Keyword("ID") {
+			break
+		}
+	}
+
+	// The data ends with EI between white-space
+	data := lx.buf[lx.i:]
+	for i := 1; i+1 < len(data); i++ {
+		if data[i] == 'E' && data[i+1] == 'I' && isSpace(data[i-1]) &&
+			(i+2 == len(data) || isSpace(data[i+2]) || isDelimiter(data[i+2])) {
+			lx.seek(lx.pos() + int64(i+2))
+			return
+		}
+	}
+	lx.seek(lx.size)
+}
@@ -0,0 +1,167 @@
+package pdf
+
+import "encoding/binary"
+
+// trueTypeCmap holds the character maps of an embedded TrueType font
+type trueTypeCmap struct {
+	// unicode maps the glyphs to Unicode, from a Unicode subtable
+	unicode map[int]rune
+	// symbol maps the single byte codes to glyphs, from a symbol
+	// or a Macintosh subtable
+	symbol map[int]int
+}
+
+// parseTrueTypeCmap reads the cmap table of a TrueType font program
+func parseTrueTypeCmap(font []byte) *trueTypeCmap {
+	table := trueTypeTable(font, "cmap")
+	if len(table) < 4 {
+		return nil
+	}
+
+	tt := &trueTypeCmap{}
+	count := int(binary.BigEndian.Uint16(table[2:]))
+	for i := 0; i < count; i++ {
+		rec := 4 + 8*i
+		if rec+8 > len(table) {
+			break
+		}
+		platform := binary.BigEndian.Uint16(table[rec:])
+		encoding := binary.BigEndian.Uint16(table[rec+2:])
+		offset := int(binary.BigEndian.Uint32(table[rec+4:]))
+		if offset >= len(table) {
+			continue
+		}
+
+		switch {
+		case platform == 3 && (encoding == 1 || encoding == 10) && tt.unicode == nil:
+			// Unicode subtable, inverted to map the glyphs
+			codes := cmapSubtable(table[offset:])
+			if len(codes) > 0 {
+				tt.unicode = make(map[int]rune, len(codes))
+				for c, gid := range codes {
+					if old, ok := tt.unicode[gid]; !ok || rune(c) < old {
+						tt.unicode[gid] = rune(c)
+					}
+				}
+			}
+		case platform == 3 && encoding == 0, platform == 1 && encoding == 0:
+			// Symbol subtable, which maps 0xF000+code, or Macintosh subtable
+			if tt.symbol != nil && platform == 1 {
+				continue
+			}
+			codes := cmapSubtable(table[offset:])
+			if len(codes) > 0 {
+				tt.symbol = make(map[int]int)
+				for c, gid := range codes {
+					tt.symbol[c&0xFF] = gid
+				}
+			}
+		}
+	}
+
+	if tt.unicode == nil && tt.symbol == nil {
+		return nil
+	}
+	return tt
+}
+
+// trueTypeTable returns the table of a TrueType font program
+func trueTypeTable(font []byte, tag string) []byte {
+	if len(font) < 12 {
+		return nil
+	}
+	count := int(binary.BigEndian.Uint16(font[4:]))
+	for i := 0; i < count; i++ {
+		rec := 12 + 16*i
+		if rec+16 > len(font) {
+			return nil
+		}
+		if string(font[rec:rec+4]) != tag {
+			continue
+		}
+		offset := int(binary.BigEndian.Uint32(font[rec+8:]))
+		length := int(binary.BigEndian.Uint32(font[rec+12:]))
+		if offset < 0 || length < 0 || offset+length > len(font) || offset+length < offset {
+			return nil
+		}
+		return font[offset : offset+length]
+	}
+	return nil
+}
+
+// cmapSubtable maps the character codes of a cmap subtable to glyphs.
+// The formats 0, 4, 6 and 12 are supported.
+func cmapSubtable(sub []byte) map[int]int {
+	if len(sub) < 4 {
+		return nil
+	}
+	u16 := func(i int) int {
+		if i+2 > len(sub) {
+			return 0
+		}
+		return int(binary.BigEndian.Uint16(sub[i:]))
+	}
+	u32 := func(i int) int {
+		if i+4 > len(sub) {
+			return 0
+		}
+		return int(binary.BigEndian.Uint32(sub[i:]))
+	}
+
+	codes := make(map[int]int)
+	switch u16(0) {
+	case 0:
+		for c := 0; c < 256 && 6+c < len(sub); c++ {
+			if gid := int(sub[6+c]); gid != 0 {
+				codes[c] = gid
+			}
+		}
+	case 4:
+		segCount := u16(6) / 2
+		endCodes := 14
+		startCodes := endCodes + 2*segCount + 2
+		deltas := startCodes + 2*segCount
+		rangeOffsets := deltas + 2*segCount
+		for s := 0; s < segCount; s++ {
+			end, start := u16(endCodes+2*s), u16(startCodes+2*s)
+			delta, rangeOffset := u16(deltas+2*s), u16(rangeOffsets+2*s)
+			if start > end || end-start > 0xFFFF {
+				continue
+			}
+			for c := start; c <= end && c != 0xFFFF; c++ {
+				gid := 0
+				if rangeOffset == 0 {
+					gid = (c + delta) & 0xFFFF
+				} else {
+					i := rangeOffsets + 2*s + rangeOffset + 2*(c-start)
+					if gid = u16(i); gid != 0 {
+						gid = (gid + delta) & 0xFFFF
+					}
+				}
+				if gid != 0 {
+					codes[c] = gid
+				}
+			}
+		}
+	case 6:
+		first, count := u16(6), u16(8)
+		for i := 0; i < count; i++ {
+			if gid := u16(10 + 2*i); gid != 0 {
+				codes[first+i] = gid
+			}
+		}
+	case 12:
+		groups := u32(12)
+		for g := 0; g < groups && 16+12*g+12 <= len(sub); g++ {
+			start, end, gid := u32(16+12*g), u32(20+12*g), u32(24+12*g)
+			if start > end || end-start > 0xFFFF {
+				continue
+			}
+			for c := start; c <= end; c++ {
+				codes[c] = gid + c - start
+			}
+		}
+	}
+
+	return codes
+}
@@ -0,0 +1,415 @@
+// Package pptx reads the slides of Office Open XML presentations.
+//
+// The presentation part lists the slides in their order. Each slide
+// holds a tree of shapes, text boxes, tables and groups of shapes, and
+// refers to its speaker notes through its relationships.
+package pptx
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pilinux/totext/internal/opc"
+	"github.com/pilinux/totext/internal/structure"
+	"github.com/pilinux/totext/internal/xmltree"
+)
+
+var (
+	// ErrNotPPTX is returned when the content is not a pptx presentation
+	ErrNotPPTX = errors.New("not a pptx presentation")
+
+	// ErrEncrypted is returned when the presentation is encrypted
+	ErrEncrypted = errors.New("encrypted pptx presentation")
+
+	// ErrMalformed is returned when the presentation cannot be parsed
+	ErrMalformed = errors.New("malformed pptx presentation")
+)
+
+// maxLevels is the number of levels of the paragraphs
+const maxLevels = 9
+
+// parser reads a slide or a notes slide
+type parser struct {
+	d     *xml.Decoder
+	slide *structure.Slide
+	// notes is set for the notes slides, their paragraphs
+	// have no bullets and only the body placeholders are read
+	notes bool
+}
+
+// Read reads the slides of the pptx presentation of the given size
+// in their order, with their speaker notes
+func Read(ctx context.Context, ra io.ReaderAt, size int64) (*structure.Document, error) {
+	pk, err := opc.Open(ra, size)
+	if err != nil {
+		return nil, packageError(err)
+	}
+	rels, err := pk.Rels("")
+	if err != nil {
+		return nil, packageError(err)
+	}
+	name := rels.First("officeDocument")
+	if name == "" || pk.File(name) == nil {
+		return nil, fmt.Errorf("%w: missing presentation", ErrNotPPTX)
+	}
+
+	presRels, err := pk.Rels(name)
+	if err != nil {
+		return nil, packageError(err)
+	}
+	data, err := pk.Read(name)
+	if err != nil {
+		return nil, packageError(err)
+	}
+	var v struct {
+		Slides []struct {
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sldIdLst>sldId"`
+	}
+	if err = xml.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrMalformed, name, err)
+	}
+
+	doc := &structure.Document{}
+	for _, id := range v.Slides {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		target := presRels.ByID[id.ID]
+		if target == "" || pk.File(target) == nil {
+			continue
+		}
+
+		slide, err := readPart(pk, target, false)
+		if err != nil {
+			return nil, err
+		}
+		slideRels, err := pk.Rels(target)
+		if err != nil {
+			return nil, packageError(err)
+		}
+		if name := slideRels.First("notesSlide"); name != "" && pk.File(name) != nil {
+			notesSlide, err := readPart(pk, name, true)
+			if err != nil {
+				return nil, err
+			}
+			slide.Notes = notesSlide.Body
+		}
+		doc.Slides = append(doc.Slides, slide)
+	}
+
+	if doc.Properties, err = pk.Properties(); err != nil {
+		return nil, packageError(err)
+	}
+
+	return doc, nil
+}
+
+// packageError returns the error of the package as a presentation error
+func packageError(err error) error {
+	switch {
+	case errors.Is(err, opc.ErrEncrypted):
+		return ErrEncrypted
+	case errors.Is(err, opc.ErrNotPackage):
+		return fmt.Errorf("%w: %v", ErrNotPPTX, err)
+	}
+	return fmt.Errorf("%w: %v", ErrMalformed, err)
+}
+
+// readPart reads the shape tree of a slide or a notes slide
+func readPart(pk *opc.Package, name string, notes bool) (structure.Slide, error) {
+	var slide structure.Slide
+	rc, err := pk.Open(name)
+	if err != nil {
+		return slide, packageError(err)
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	p := &parser{d: xml.NewDecoder(rc), slide: &slide, notes: notes}
+	for {
+		tok, err := p.d.Token()
+		if errors.Is(err, io.EOF) {
+			return slide, nil
+		}
+		if err == nil {
+			if t, ok := tok.(xml.StartElement); ok {
+				switch t.Name.Local {
+				case "sld":
+					show := xmltree.Attr(t, "show")
+					slide.Hidden = show != "" && !isTrue(show)
+				case "spTree":
+					err = xmltree.Children(p.d, p.shape)
+				}
+			}
+		}
+		if err != nil {
+			return slide, fmt.Errorf("%w: %s: %v", ErrMalformed, name, err)
+		}
+	}
+}
+
+// shape reads a shape of the shape tree, the groups of shapes are read
+// recursively. The pictures and the connectors have no text.
+func (p *parser) shape(t xml.StartElement) error {
+	switch t.Name.Local {
+	case "grpSp":
+		return xmltree.Children(p.d, p.shape)
+	case "AlternateContent":
+		// The first choice is read, the fallback repeats it
+		read := false
+		return xmltree.Children(p.d, func(t xml.StartElement) error {
+			if t.Name.Local != "Choice" || read {
+				return p.d.Skip()
+			}
+			read = true
+			return xmltree.Children(p.d, p.shape)
+		})
+	case "sp":
+		return p.sp()
+	case "graphicFrame":
+		blocks, err := p.graphicFrame()
+		p.slide.Body = append(p.slide.Body, blocks...)
+		return err
+	}
+
+	return p.d.Skip()
+}
+
+// sp reads the text of a shape. The title placeholders hold the title
+// of the slide, the paragraphs of the body placeholders have bullets.
+// The slide numbers, the dates, the headers and the footers are left out.
+func (p *parser) sp() error {
+	placeholder, typ := false, ""
+	return xmltree.Children(p.d, func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "nvSpPr":
+			return xmltree.Children(p.d, func(t xml.StartElement) error {
+				if t.Name.Local != "nvPr" {
+					return p.d.Skip()
+				}
+				return xmltree.Children(p.d, func(t xml.StartElement) error {
+					if t.Name.Local == "ph" {
+						placeholder, typ = true, xmltree.Attr(t, "type")
+					}
+					return p.d.Skip()
+				})
+			})
+		case "txBody":
+		default:
+			return p.d.Skip()
+		}
+
+		switch {
+		case typ == "sldNum" || typ == "dt" || typ == "hdr" || typ == "ftr" || typ == "sldImg":
+			return p.d.Skip()
+		case p.notes && (!placeholder || typ != "body"):
+			return p.d.Skip()
+		case typ == "title" || typ == "ctrTitle":
+			blocks, err := p.txBody(false)
+			texts := make([]string, 0, len(blocks)+1)
+			if p.slide.Title != "" {
+				texts = append(texts, p.slide.Title)
+			}
+			for _, b := range blocks {
+				texts = append(texts, strings.Join(strings.Fields(b.Text), " "))
+			}
+			p.slide.Title = strings.Join(texts, " ")
+			return err
+		}
+
+		bulleted := placeholder && !p.notes && (typ == "" || typ == "body" || typ == "obj")
+		blocks, err := p.txBody(bulleted)
+		p.slide.Body = append(p.slide.Body, blocks...)
+		return err
+	})
+}
+
+// txBody reads the paragraphs of a text body. The paragraphs have
+// bullets if bulleted is set, unless they have none.
+func (p *parser) txBody(bulleted bool) ([]structure.Block, error) {
+	var blocks []structure.Block
+	var numbers [maxLevels]int
+	err := xmltree.Children(p.d, func(t xml.StartElement) error {
+		if t.Name.Local != "p" {
+			return p.d.Skip()
+		}
+		b, err := p.paragraph(bulleted, &numbers)
+		if strings.TrimSpace(b.Text) != "" {
+			blocks = append(blocks, b)
+		}
+		return err
+	})
+
+	return blocks, err
+}
+
+// paragraph reads a paragraph, numbers counts the numbered
+// paragraphs of the levels of the text body
+func (p *parser) paragraph(bulleted bool, numbers *[maxLevels]int) (structure.Block, error) {
+	var text strings.Builder
+	level := 0
+	bullet, autoNum := "", ""
+	start := 1
+	err := xmltree.Children(p.d, func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "pPr":
+			level, _ = strconv.Atoi(xmltree.Attr(t, "lvl"))
+			level = min(max(level, 0), maxLevels-1)
+			return xmltree.Children(p.d, func(t xml.StartElement) error {
+				switch t.Name.Local {
+				case "buNone":
+					bulleted = false
+				case "buChar":
+					bullet = structure.Bullet(xmltree.Attr(t, "char"))
+				case "buAutoNum":
+					autoNum = xmltree.Attr(t, "type")
+					if n, err := strconv.Atoi(xmltree.Attr(t, "startAt")); err == nil && n > 0 {
+						start = n
+					}
+				}
+				return p.d.Skip()
+			})
+		case "r", "fld":
+			return xmltree.Children(p.d, func(t xml.StartElement) error {
+				if t.Name.Local != "t" {
+					return p.d.Skip()
+				}
+				return xmltree.Walk(p.d, func(xml.StartElement) error {
+					return p.d.Skip()
+				}, func(data xml.CharData) {
+					text.Write(data)
+				})
+			})
+		case "br":
+			text.WriteString("\n")
+		}
+		return p.d.Skip()
+	})
+
+	b := structure.Block{Kind: structure.Paragraph, Level: level, Text: text.String()}
+	switch {
+	case autoNum != "":
+		if numbers[level] == 0 {
+			numbers[level] = start - 1
+		}
+		numbers[level]++
+		b.Kind, b.Label = structure.ListItem, autoNumLabel(autoNum, numbers[level])
+	case bullet != "":
+		b.Kind, b.Label = structure.ListItem, bullet
+	case bulleted:
+		b.Kind, b.Label = structure.ListItem, "•"
+	default:
+		b.Level = 0
+	}
+
+	// The numbering of the deeper levels restarts
+	first := level + 1
+	if autoNum == "" {
+		first = level
+	}
+	for i := first; i < maxLevels; i++ {
+		numbers[i] = 0
+	}
+
+	return b, err
+}
+
+// autoNumLabel returns the label of the number of an automatically
+// numbered paragraph with the scheme, e.g. arabicPeriod or romanLcParenR
+func autoNumLabel(scheme string, n int) string {
+	var number string
+	switch {
+	case strings.HasPrefix(scheme, "alphaLc"):
+		number = structure.Letters(n, 'a')
+	case strings.HasPrefix(scheme, "alphaUc"):
+		number = structure.Letters(n, 'A')
+	case strings.HasPrefix(scheme, "romanLc"):
+		number = strings.ToLower(structure.Roman(n))
+	case strings.HasPrefix(scheme, "romanUc"):
+		number = structure.Roman(n)
+	default:
+		number = strconv.Itoa(n)
+	}
+
+	switch {
+	case strings.HasSuffix(scheme, "ParenBoth"):
+		return "(" + number + ")"
+	case strings.HasSuffix(scheme, "ParenR"):
+		return number + ")"
+	case strings.HasSuffix(scheme, "Plain"):
+		return number
+	}
+	return number + "."
+}
+
+// isTrue reports whether the value of a boolean attribute is true
+func isTrue(value string) bool {
+	return value == "1" || value == "true"
+}
+
+// graphicFrame reads the table of a graphic frame,
+// the charts and the diagrams are left out
+func (p *parser) graphicFrame() ([]structure.Block, error) {
+	var blocks []structure.Block
+	var frame func(t xml.StartElement) error
+	frame = func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "graphic", "graphicData":
+			return xmltree.Children(p.d, frame)
+		case "tbl":
+			b, err := p.table()
+			if len(b.Rows) > 0 {
+				blocks = append(blocks, b)
+			}
+			return err
+		}
+		return p.d.Skip()
+	}
+
+	return blocks, xmltree.Children(p.d, frame)
+}
+
+// table reads the rows of a table, the cells covered
+// by a merged cell are empty
+func (p *parser) table() (structure.Block, error) {
+	b := structure.Block{Kind: structure.Table}
+	err := xmltree.Children(p.d, func(t xml.StartElement) error {
+		if t.Name.Local != "tr" {
+			return p.d.Skip()
+		}
+
+		var row []structure.Cell
+		err := xmltree.Children(p.d, func(t xml.StartElement) error {
+			if t.Name.Local != "tc" {
+				return p.d.Skip()
+			}
+			if isTrue(xmltree.Attr(t, "hMerge")) || isTrue(xmltree.Attr(t, "vMerge")) {
+				row = append(row, nil)
+				return p.d.Skip()
+			}
+
+			var cell structure.Cell
+			err := xmltree.Children(p.d, func(t xml.StartElement) error {
+				if t.Name.Local != "txBody" {
+					return p.d.Skip()
+				}
+				blocks, err := p.txBody(false)
+				cell = append(cell, blocks...)
+				return err
+			})
+			row = append(row, cell)
+			return err
+		})
+		b.Rows = append(b.Rows, row)
+		return err
+	})
+
+	return b, err
+}
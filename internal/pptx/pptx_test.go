@@ -0,0 +1,185 @@
+package pptx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pilinux/totext/internal/fixture"
+	"github.com/pilinux/totext/internal/structure"
+)
+
+const pmlNS = `xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main" ` +
+	`xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" ` +
+	`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"`
+
+// rels returns a relationships part with the targets by type
+func rels(targets ...string) string {
+	var sb strings.Builder
+	sb.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := 0; i+1 < len(targets); i += 2 {
+		fmt.Fprintf(&sb, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/%s" Target="%s"/>`,
+			i/2+1, targets[i], targets[i+1])
+	}
+	sb.WriteString(`</Relationships>`)
+	return sb.String()
+}
+
+// sp returns a shape with the placeholder type and the paragraphs
+func sp(placeholder, paragraphs string) string {
+	ph := ""
+	if placeholder != "-" {
+		ph = `<p:ph type="` + placeholder + `"/>`
+	}
+	return `<p:sp><p:nvSpPr><p:cNvPr id="1" name="Shape"/><p:cNvSpPr/><p:nvPr>` + ph + `</p:nvPr></p:nvSpPr>` +
+		`<p:spPr/><p:txBody><a:bodyPr/>` + paragraphs + `</p:txBody></p:sp>`
+}
+
+// para returns a paragraph with the properties and the runs
+func para(pPr string, runs ...string) string {
+	var sb strings.Builder
+	sb.WriteString(`<a:p>` + pPr)
+	for _, r := range runs {
+		sb.WriteString(`<a:r><a:rPr lang="en-US"/><a:t>` + r + `</a:t></a:r>`)
+	}
+	sb.WriteString(`<a:endParaRPr/></a:p>`)
+	return sb.String()
+}
+
+// buildPPTX returns a presentation with two slides listed in the
+// reverse order of their relationships, the first one has speaker notes
+func buildPPTX(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+
+	slide := func(attrs, shapes string) string {
+		return `<p:sld ` + pmlNS + attrs + `><p:cSld><p:spTree><p:nvGrpSpPr/><p:grpSpPr/>` + shapes +
+			`</p:spTree></p:cSld></p:sld>`
+	}
+	entries := map[string]string{
+		"[Content_Types].xml": `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"/>`,
+		"_rels/.rels": rels("officeDocument/2006/relationships/officeDocument", "ppt/presentation.xml",
+			"package/2006/relationships/metadata/core-properties", "docProps/core.xml"),
+		"docProps/core.xml": `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" ` +
+			`xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>Roadmap</dc:title></cp:coreProperties>`,
+		"ppt/presentation.xml": `<p:presentation ` + pmlNS + `><p:sldIdLst>` +
+			`<p:sldId id="256" r:id="rId2"/><p:sldId id="257" r:id="rId1"/><p:sldId id="258" r:id="rId9"/>` +
+			`</p:sldIdLst></p:presentation>`,
+		"ppt/_rels/presentation.xml.rels": rels("officeDocument/2006/relationships/slide", "slides/slide2.xml",
+			"officeDocument/2006/relationships/slide", "slides/slide1.xml"),
+		"ppt/slides/slide1.xml": slide(``, sp("ctrTitle", para(``, "Road", "map"))+
+			sp("subTitle", para(``, "Second quarter"))+
+			sp("sldNum", para(``, "1"))+
+			`<p:grpSp><p:nvGrpSpPr/><p:grpSpPr/>`+sp("-", para(`<a:pPr><a:buChar char="-"/></a:pPr>`, "Grouped"))+
+			`<p:pic><p:nvPicPr/></p:pic></p:grpSp>`),
+		"ppt/slides/_rels/slide1.xml.rels": rels("officeDocument/2006/relationships/notesSlide", "../notesSlides/notesSlide1.xml"),
+		"ppt/notesSlides/notesSlide1.xml": `<p:notes ` + pmlNS + `><p:cSld><p:spTree>` +
+			sp("sldImg", "") + sp("body", para(``, "Greet the audience")) + sp("sldNum", para(``, "1")) +
+			`</p:spTree></p:cSld></p:notes>`,
+		"ppt/slides/slide2.xml": slide(` show="0"`, sp("title", para(``, "Goals"))+
+			sp("body", para(``, "Ship")+para(`<a:pPr lvl="1"/>`, "On time")+para(`<a:pPr><a:buNone/></a:pPr>`, "Plain"))+
+			sp("-", para(`<a:pPr><a:buAutoNum type="alphaLcParenR"/></a:pPr>`, "First")+
+				para(`<a:pPr><a:buAutoNum type="alphaLcParenR"/></a:pPr>`, "Second"))+
+			`<p:graphicFrame><p:nvGraphicFramePr/><a:graphic><a:graphicData><a:tbl><a:tblGrid/>`+
+			`<a:tr><a:tc gridSpan="2"><a:txBody>`+para(``, "Wide")+`</a:txBody></a:tc><a:tc hMerge="1"><a:txBody>`+
+			para(``, "Covered")+`</a:txBody></a:tc></a:tr>`+
+			`<a:tr><a:tc><a:txBody>`+para(``, "A")+`</a:txBody></a:tc><a:tc><a:txBody>`+para(``, "B")+`</a:txBody></a:tc></a:tr>`+
+			`</a:tbl></a:graphicData></a:graphic></p:graphicFrame>`),
+	}
+	for name, content := range parts {
+		entries[name] = content
+	}
+
+	return fixture.Zip(t, entries)
+}
+
+// render returns a line for each block with its kind, level, label and
+// text, the cells of the tables are separated with |
+func render(blocks []structure.Block) string {
+	var sb strings.Builder
+	for _, b := range blocks {
+		switch b.Kind {
+		case structure.ListItem:
+			fmt.Fprintf(&sb, "L%d %s %s\n", b.Level, b.Label, b.Text)
+		case structure.Table:
+			for _, row := range b.Rows {
+				cells := make([]string, len(row))
+				for i, cell := range row {
+					cells[i] = strings.TrimSpace(render(cell))
+				}
+				sb.WriteString("T " + strings.Join(cells, "|") + "\n")
+			}
+		default:
+			sb.WriteString("P " + b.Text + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// TestRead tests the slides of a presentation
+func TestRead(t *testing.T) {
+	data := buildPPTX(t, nil)
+	doc, err := Read(context.Background(), bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Test data
+	testData := []struct {
+		title  string
+		hidden bool
+		body   string
+		notes  string
+	}{
+		{"Roadmap", false, "P Second quarter\nL0 - Grouped\n", "P Greet the audience\n"},
+		{"Goals", true,
+			"L0 • Ship\nL1 • On time\nP Plain\nL0 a) First\nL0 b) Second\nT P Wide|\nT P A|P B\n", ""},
+	}
+
+	if len(doc.Slides) != len(testData) {
+		t.Fatalf("expected %d slides, got %d", len(testData), len(doc.Slides))
+	}
+
+	// Iterate over test data
+	for i, td := range testData {
+		s := doc.Slides[i]
+		if s.Title != td.title || s.Hidden != td.hidden {
+			t.Errorf("expected slide %q hidden %t, got %q hidden %t", td.title, td.hidden, s.Title, s.Hidden)
+		}
+		if body := render(s.Body); body != td.body {
+			t.Errorf("%s: expected body %q, got %q", td.title, td.body, body)
+		}
+		if notes := render(s.Notes); notes != td.notes {
+			t.Errorf("%s: expected notes %q, got %q", td.title, td.notes, notes)
+		}
+	}
+
+	if doc.Properties["title"] != "Roadmap" {
+		t.Errorf("unexpected properties %v", doc.Properties)
+	}
+}
+
+// TestReadErrors tests the errors of invalid presentations
+func TestReadErrors(t *testing.T) {
+	// Test data
+	testData := []struct {
+		name     string
+		data     []byte
+		expected error
+	}{
+		{"plain text", []byte("plain text"), ErrNotPPTX},
+		{"no presentation", fixture.Zip(t, map[string]string{"[Content_Types].xml": "<Types/>"}), ErrNotPPTX},
+		{"encrypted", append([]byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}, make([]byte, 504)...), ErrEncrypted},
+		{"malformed", buildPPTX(t, map[string]string{"ppt/slides/slide1.xml": "<p:sld><p:cSld><p:spTree>"}), ErrMalformed},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		_, err := Read(context.Background(), bytes.NewReader(td.data), int64(len(td.data)))
+		if !errors.Is(err, td.expected) {
+			t.Errorf("%s: expected %v, got %v", td.name, td.expected, err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package rtf
+
+import (
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// charsets maps the \fcharset values of the fonts to code pages
+var charsets = map[int]int{
+	0:   0, // ANSI, the code page of the document
+	1:   0, // default
+	2:   1252,
+	77:  10000,
+	128: 932,
+	129: 949,
+	134: 936,
+	136: 950,
+	161: 1253,
+	162: 1254,
+	163: 1258,
+	177: 1255,
+	178: 1256,
+	186: 1257,
+	204: 1251,
+	222: 874,
+	238: 1250,
+	254: 437,
+	255: 850,
+}
+
+// codepages are the supported code pages
+var codepages = map[int]encoding.Encoding{
+	437:   charmap.CodePage437,
+	850:   charmap.CodePage850,
+	852:   charmap.CodePage852,
+	855:   charmap.CodePage855,
+	858:   charmap.CodePage858,
+	860:   charmap.CodePage860,
+	862:   charmap.CodePage862,
+	863:   charmap.CodePage863,
+	865:   charmap.CodePage865,
+	866:   charmap.CodePage866,
+	874:   charmap.Windows874,
+	932:   japanese.ShiftJIS,
+	936:   simplifiedchinese.GBK,
+	949:   korean.EUCKR,
+	950:   traditionalchinese.Big5,
+	1250:  charmap.Windows1250,
+	1251:  charmap.Windows1251,
+	1252:  charmap.Windows1252,
+	1253:  charmap.Windows1253,
+	1254:  charmap.Windows1254,
+	1255:  charmap.Windows1255,
+	1256:  charmap.Windows1256,
+	1257:  charmap.Windows1257,
+	1258:  charmap.Windows1258,
+	10000: charmap.Macintosh,
+	10007: charmap.MacintoshCyrillic,
+	20866: charmap.KOI8R,
+	21866: charmap.KOI8U,
+	28591: charmap.ISO8859_1,
+	28592: charmap.ISO8859_2,
+	28595: charmap.ISO8859_5,
+	28597: charmap.ISO8859_7,
+	28605: charmap.ISO8859_15,
+	65001: unicode.UTF8,
+}
+
+// decode returns the text of the bytes in the code page,
+// unknown code pages are read as Windows-1252
+func decode(cp int, b []byte) string {
+	enc, ok := codepages[cp]
+	if !ok {
+		enc = charmap.Windows1252
+	}
+
+	text, err := enc.NewDecoder().Bytes(b)
+	if err != nil {
+		text, _ = charmap.Windows1252.NewDecoder().Bytes(b)
+	}
+	return string(text)
+}
+
+// isLeadByte reports whether b starts a double byte character
+// in the code page
+func isLeadByte(cp int, b byte) bool {
+	switch cp {
+	case 932:
+		return b >= 0x81 && b <= 0x9F || b >= 0xE0 && b <= 0xFC
+	case 936, 949, 950:
+		return b >= 0x81 && b <= 0xFE
+	}
+	return false
+}
@@ -0,0 +1,350 @@
+package rtf
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pilinux/totext/internal/structure"
+)
+
+// maxLevels is the number of outline and list levels
+const maxLevels = 9
+
+// format holds the character properties of the text
+type format struct {
+	bold, italic, strike bool
+	// link is the target of the hyperlink field of the text
+	link string
+}
+
+// paragraph holds the paragraph properties used for the structure
+type paragraph struct {
+	// outline is the outline level of a heading, -1 for body text
+	outline int
+	// list is set for the items of a list at level
+	list  bool
+	level int
+	// table is set for the paragraphs of table cells
+	table bool
+}
+
+// document builds the structure of the document read by Read
+type document struct {
+	doc *structure.Document
+
+	// text is the text of the current paragraph and label
+	// the label of the list item
+	text  structure.Builder
+	label strings.Builder
+
+	// cell, row and rows are the content of the current table
+	cell []structure.Block
+	row  []structure.Cell
+	rows [][]structure.Cell
+
+	// instruction is the instruction of the current field
+	// and link the target of its hyperlink
+	instruction strings.Builder
+	link        string
+
+	// note holds the paragraphs of the current footnote or
+	// endnote and noteText the text of its current paragraph
+	note     []structure.Block
+	noteText structure.Builder
+	endnote  bool
+}
+
+// Read reads the RTF document from r and returns its structure and
+// its information. The headings, list items, tables, footnotes and
+// endnotes are kept with the format of the text and the hyperlinks.
+// ErrNotRTF is returned as with Convert.
+func Read(ctx context.Context, r io.Reader) (*structure.Document, *Info, error) {
+	p, err := newParser(ctx, r, io.Discard)
+	if err != nil {
+		return nil, nil, err
+	}
+	p.doc = &document{doc: &structure.Document{}}
+	if err = p.run(); err != nil {
+		return nil, nil, err
+	}
+	p.doc.endParagraph(p.st)
+	p.doc.endTable()
+	p.doc.doc.Properties = p.info.Fields
+
+	return p.doc.doc, p.info, nil
+}
+
+// docDestination starts the destinations and the groups of the
+// structure, it reports whether the control word is one of them
+func (p *parser) docDestination(word string) bool {
+	switch word {
+	case "fldinst":
+		// The ignorable destination is skipped until the field is known
+		if n := len(p.stack); n > 0 && p.stack[n-1].dest == destText {
+			p.st.dest = destFieldInst
+			p.doc.instruction.Reset()
+			return true
+		}
+		return false
+	}
+
+	if p.st.dest != destText {
+		return false
+	}
+	switch word {
+	case "listtext", "pntext":
+		p.st.dest = destLabel
+		p.doc.label.Reset()
+	case "field":
+		p.doc.link = ""
+	case "fldrslt":
+		p.st.format.link = p.doc.link
+	case "footnote":
+		p.st.note = true
+		p.doc.note, p.doc.endnote = nil, false
+		p.doc.noteText.Reset()
+	default:
+		return false
+	}
+	return true
+}
+
+// docWord interprets the control words of the structure and of the
+// format of the text, it reports whether the control word is one of them
+func (p *parser) docWord(word string, param int, hasParam bool) bool {
+	// Toggles are turned off by a zero parameter
+	on := !hasParam || param != 0
+
+	switch word {
+	case "par", "sect", "page":
+		p.doc.endParagraph(p.st)
+	case "line":
+		p.text("\n")
+	case "cell", "nestcell":
+		p.doc.endCell(p.st)
+	case "row":
+		p.doc.endRow()
+	case "nestrow":
+		// Nested tables are read as cells of the outer table
+	case "pard":
+		p.st.para = paragraph{outline: -1}
+	case "outlinelevel":
+		p.st.para.outline = param
+	case "ls":
+		p.st.para.list = true
+	case "ilvl":
+		p.st.para.level = param
+	case "intbl":
+		p.st.para.table = true
+	case "b":
+		p.st.format.bold = on
+	case "i":
+		p.st.format.italic = on
+	case "strike", "striked":
+		p.st.format.strike = on
+	case "ftnalt":
+		p.doc.endnote = true
+	case "plain":
+		// The hidden text is reset by the common control words
+		p.st.format = format{link: p.st.format.link}
+		return false
+	default:
+		return false
+	}
+	return true
+}
+
+// endGroup ends the field instructions and the notes when their
+// groups end, st is the state of the enclosing group
+func (d *document) endGroup(ended, st state) {
+	if ended.dest == destFieldInst && st.dest != destFieldInst {
+		d.link = hyperlink(d.instruction.String())
+	}
+	if ended.note && !st.note {
+		d.endNote(ended)
+	}
+}
+
+// write writes the text in the format of st, fixed reports
+// whether the font has a fixed pitch
+func (d *document) write(s string, st state, fixed bool) {
+	text := &d.text
+	if st.note {
+		text = &d.noteText
+	}
+	text.Format = structure.Format{
+		Bold:   st.format.bold,
+		Italic: st.format.italic,
+		Strike: st.format.strike,
+		Code:   fixed,
+		Link:   st.format.link,
+	}
+	_, _ = text.WriteString(s)
+}
+
+// block returns the block of the paragraph text with the
+// properties, false if it has no text
+func block(text *structure.Builder, para paragraph, label string) (structure.Block, bool) {
+	b := structure.Block{Kind: structure.Paragraph, Text: text.String(), Spans: text.Spans()}
+	text.Reset()
+
+	switch {
+	case para.list || label != "":
+		b.Kind = structure.ListItem
+		b.Level = min(max(para.level, 0), maxLevels-1)
+		if strings.IndexFunc(label, structure.IsPrivate) >= 0 {
+			label = structure.Bullet(label)
+		}
+		b.Label = label
+	case para.outline >= 0 && para.outline < maxLevels:
+		b.Kind = structure.Heading
+		b.Level = para.outline + 1
+	case isCode(b.Spans):
+		b.Kind, b.Spans = structure.Code, nil
+	}
+
+	return b, strings.TrimSpace(b.Text) != ""
+}
+
+// isCode reports whether all the text of the spans is code
+func isCode(spans []structure.Span) bool {
+	code := false
+	for _, s := range spans {
+		if strings.TrimSpace(s.Text) == "" {
+			continue
+		}
+		if !s.Code || s.Link != "" {
+			return false
+		}
+		code = true
+	}
+	return code
+}
+
+// endParagraph ends the current paragraph with the properties of st
+func (d *document) endParagraph(st state) {
+	if st.note {
+		if b, ok := block(&d.noteText, paragraph{outline: -1}, ""); ok {
+			d.note = append(d.note, b)
+		}
+		return
+	}
+
+	label := strings.TrimSpace(d.label.String())
+	d.label.Reset()
+	b, ok := block(&d.text, st.para, label)
+	if st.para.table {
+		if ok {
+			d.cell = append(d.cell, b)
+		}
+		return
+	}
+
+	d.endTable()
+	if ok {
+		d.doc.Body = append(d.doc.Body, b)
+	}
+}
+
+// endCell ends the current paragraph and the cell of the table
+func (d *document) endCell(st state) {
+	if st.note {
+		d.endParagraph(st)
+		return
+	}
+
+	st.para.table = true
+	d.endParagraph(st)
+	d.row = append(d.row, d.cell)
+	d.cell = nil
+}
+
+// endRow ends the current row of the table
+func (d *document) endRow() {
+	if len(d.row) > 0 {
+		d.rows = append(d.rows, d.row)
+	}
+	d.row, d.cell = nil, nil
+}
+
+// endTable adds the current table to the body
+func (d *document) endTable() {
+	d.endRow()
+	if len(d.rows) > 0 {
+		d.doc.Body = append(d.doc.Body, structure.Block{Kind: structure.Table, Rows: d.rows})
+	}
+	d.rows = nil
+}
+
+// endNote adds the current note and writes its number to the
+// paragraph, st is the state of the note group
+func (d *document) endNote(st state) {
+	d.endParagraph(st)
+
+	notes := &d.doc.Footnotes
+	if d.endnote {
+		notes = &d.doc.Endnotes
+	}
+	id := strconv.Itoa(len(*notes) + 1)
+	*notes = append(*notes, structure.Note{ID: id, Blocks: d.note})
+	d.note = nil
+
+	d.text.Format = structure.Format{}
+	_, _ = d.text.WriteString("[" + id + "]")
+}
+
+// hyperlink returns the target of a HYPERLINK field instruction,
+// e.g. HYPERLINK "https://example.com" or HYPERLINK \l "bookmark"
+func hyperlink(instruction string) string {
+	args := fieldArgs(instruction)
+	if len(args) < 2 || !strings.EqualFold(args[0], "HYPERLINK") {
+		return ""
+	}
+
+	var target, anchor string
+	for i := 1; i < len(args); i++ {
+		switch {
+		case args[i] == `\l` && i+1 < len(args):
+			i++
+			anchor = args[i]
+		case strings.HasPrefix(args[i], `\`):
+			// Switches with an argument, e.g. \o "tooltip"
+			if args[i] == `\o` || args[i] == `\t` || args[i] == `\m` {
+				i++
+			}
+		case target == "":
+			target = args[i]
+		}
+	}
+
+	if anchor != "" {
+		return target + "#" + anchor
+	}
+	return target
+}
+
+// fieldArgs splits a field instruction into its arguments,
+// quoted arguments may contain spaces
+func fieldArgs(instruction string) []string {
+	var args []string
+	for s := strings.TrimSpace(instruction); s != ""; s = strings.TrimSpace(s) {
+		if s[0] == '"' {
+			end := strings.IndexByte(s[1:], '"')
+			if end < 0 {
+				end = len(s) - 1
+			}
+			args = append(args, s[1:end+1])
+			s = s[min(end+2, len(s)):]
+			continue
+		}
+		end := strings.IndexAny(s, " \t\r\n")
+		if end < 0 {
+			end = len(s)
+		}
+		args = append(args, s[:end])
+		s = s[end:]
+	}
+	return args
+}
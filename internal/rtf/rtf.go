@@ -0,0 +1,750 @@
+// Package rtf extracts the text and the document information of
+// Rich Text Format documents.
+//
+// The parser reads the control words, control symbols and groups of
+// the document and writes the text of the body in order. Destinations
+// which do not hold body text, like the font table, the style sheet,
+// pictures and the ignorable destinations marked with \*, are skipped.
+// Read also keeps the structure and the format of the text.
+package rtf
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// ErrNotRTF is returned when the content is not an RTF document
+var ErrNotRTF = errors.New("not an RTF document")
+
+// ErrMalformed is returned when the document cannot be parsed
+var ErrMalformed = errors.New("malformed RTF document")
+
+// maxDepth limits the nesting of the groups
+const maxDepth = 1024
+
+// maxWord is the maximum length of a control word
+const maxWord = 32
+
+// Info is the document information stored in the \info group
+type Info struct {
+	// Fields are the text fields keyed by their control word,
+	// e.g. title, subject, author, keywords
+	Fields map[string]string
+
+	// Created, Revised and Printed are the dates of the document
+	Created, Revised, Printed time.Time
+}
+
+// destination is where the text of a group goes
+type destination int
+
+const (
+	// destText is the body of the document
+	destText destination = iota
+	// destSkip is a destination whose text is ignored
+	destSkip
+	// destFontTable is the font table
+	destFontTable
+	// destInfo is the information group
+	destInfo
+	// destField is a text field of the information group
+	destField
+	// destDate is a date of the information group
+	destDate
+	// destLabel is the label of a list item
+	destLabel
+	// destFieldInst is the instruction of a field
+	destFieldInst
+)
+
+// skipped are the destinations which do not hold body text
+var skipped = map[string]bool{
+	"colortbl":           true,
+	"stylesheet":         true,
+	"listtable":          true,
+	"listoverridetable":  true,
+	"revtbl":             true,
+	"rsidtbl":            true,
+	"generator":          true,
+	"filetbl":            true,
+	"pgdsctbl":           true,
+	"xmlnstbl":           true,
+	"themedata":          true,
+	"colorschememapping": true,
+	"datastore":          true,
+	"latentstyles":       true,
+	"pict":               true,
+	"nonshppict":         true,
+	"objdata":            true,
+	"objclass":           true,
+	"fldinst":            true,
+	"datafield":          true,
+	"private":            true,
+	"sp":                 true,
+	"xe":                 true,
+	"tc":                 true,
+	"header":             true,
+	"headerl":            true,
+	"headerr":            true,
+	"headerf":            true,
+	"footer":             true,
+	"footerl":            true,
+	"footerr":            true,
+	"footerf":            true,
+}
+
+// infoFields are the text fields of the information group
+var infoFields = map[string]bool{
+	"title":     true,
+	"subject":   true,
+	"author":    true,
+	"manager":   true,
+	"company":   true,
+	"operator":  true,
+	"category":  true,
+	"keywords":  true,
+	"comment":   true,
+	"doccomm":   true,
+	"hlinkbase": true,
+}
+
+// infoNumbers are the statistics of the information group
+var infoNumbers = map[string]bool{
+	"version":    true,
+	"edmins":     true,
+	"nofpages":   true,
+	"nofwords":   true,
+	"nofchars":   true,
+	"nofcharsws": true,
+}
+
+// infoDates are the dates of the information group
+var infoDates = map[string]bool{
+	"creatim": true,
+	"revtim":  true,
+	"printim": true,
+	"buptim":  true,
+}
+
+// symbols are the control words which stand for a character
+var symbols = map[string]string{
+	"tab":       "\t",
+	"emdash":    "—",
+	"endash":    "–",
+	"bullet":    "•",
+	"lquote":    "‘",
+	"rquote":    "’",
+	"ldblquote": "“",
+	"rdblquote": "”",
+	"emspace":   " ",
+	"enspace":   " ",
+	"qmspace":   " ",
+}
+
+// state is the state of a group, restored when the group ends
+type state struct {
+	dest destination
+	// uc is the number of fallback characters after \u
+	uc int
+	// font is the current font, -1 for the default font
+	font   int
+	hidden bool
+	// field is the control word of the information field or date
+	field string
+
+	// format and para are the character and the paragraph
+	// properties read by Read
+	format format
+	para   paragraph
+	// note is set in a footnote or an endnote read by Read
+	note bool
+}
+
+// parser interprets an RTF document
+type parser struct {
+	ctx context.Context
+	r   *bufio.Reader
+	w   *bufio.Writer
+
+	st    state
+	stack []state
+	done  bool
+
+	// skip is the number of fallback characters left to skip after \u
+	skip int
+	// high is a pending high surrogate of \u
+	high rune
+	// cell delays the separator of a table cell until its next text
+	cell bool
+
+	// codepage is the code page of the document
+	codepage int
+	// deff is the default font
+	deff int
+	// fonts maps the fonts to their code page
+	fonts map[int]int
+	// fontNum is the font being defined in the font table
+	fontNum int
+
+	// pending are code page bytes waiting to be decoded,
+	// lead reports a pending lead byte of a double byte code page
+	pending []byte
+	lead    bool
+
+	// fixed holds the fonts with a fixed pitch
+	fixed map[int]bool
+
+	info  *Info
+	field strings.Builder
+	date  [6]int
+
+	// doc builds the structure of the document read by Read,
+	// it is nil for Convert
+	doc *document
+
+	word  []byte
+	count int
+}
+
+// Convert writes the text of the RTF document read from r to w and
+// returns its information. The tabs are written as spaces and the
+// cells of the table rows are separated with " | ". If r is a *bufio.Reader and the content is
+// not an RTF document, ErrNotRTF is returned before r is read.
+func Convert(ctx context.Context, r io.Reader, w io.Writer) (*Info, error) {
+	p, err := newParser(ctx, r, w)
+	if err != nil {
+		return nil, err
+	}
+	if err = p.run(); err != nil {
+		return nil, err
+	}
+
+	return p.info, p.w.Flush()
+}
+
+// newParser returns a parser which writes the text of the document
+// read from r to w
+func newParser(ctx context.Context, r io.Reader, w io.Writer) (*parser, error) {
+	br := bufio.NewReader(r)
+
+	// A byte order mark and white-space may precede the document
+	head, _ := br.Peek(64)
+	start := bytes.TrimLeft(bytes.TrimPrefix(head, []byte("\xEF\xBB\xBF")), " \t\r\n")
+	if !bytes.HasPrefix(start, []byte(`{\rtf`)) {
+		return nil, ErrNotRTF
+	}
+	if _, err := br.Discard(len(head) - len(start)); err != nil {
+		return nil, err
+	}
+
+	return &parser{
+		ctx:      ctx,
+		r:        br,
+		w:        bufio.NewWriter(w),
+		st:       state{uc: 1, font: -1, para: paragraph{outline: -1}},
+		codepage: 1252,
+		fonts:    make(map[int]int),
+		fixed:    make(map[int]bool),
+		info:     &Info{Fields: make(map[string]string)},
+	}, nil
+}
+
+// run reads the tokens until the document ends
+func (p *parser) run() error {
+	for !p.done {
+		c, err := p.r.ReadByte()
+		if err == io.EOF {
+			// Unterminated groups are tolerated
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		// Check the context from time to time
+		p.count++
+		if p.count%65536 == 0 && p.ctx.Err() != nil {
+			return p.ctx.Err()
+		}
+
+		switch c {
+		case '{':
+			p.flush()
+			if len(p.stack) >= maxDepth {
+				return fmt.Errorf("%w: groups nested too deeply", ErrMalformed)
+			}
+			p.stack = append(p.stack, p.st)
+			p.skip = 0
+		case '}':
+			p.flush()
+			p.endGroup()
+			p.skip = 0
+		case '\\':
+			if err = p.control(); err != nil {
+				return err
+			}
+		case '\r', '\n', 0:
+			// Line breaks of the file are not part of the text
+		case '\t':
+			p.char("\t")
+		default:
+			p.textByte(c)
+		}
+	}
+
+	p.flush()
+	return nil
+}
+
+// endGroup restores the state of the enclosing group
+func (p *parser) endGroup() {
+	n := len(p.stack)
+	if n == 0 {
+		p.done = true
+		return
+	}
+
+	ended := p.st
+	p.st = p.stack[n-1]
+	p.stack = p.stack[:n-1]
+	if n == 1 {
+		// The document group ended
+		p.done = true
+	}
+
+	if p.doc != nil {
+		p.doc.endGroup(ended, p.st)
+	}
+
+	// Store the information field or date
+	if ended.field != "" && ended.field != p.st.field {
+		switch ended.dest {
+		case destField:
+			if text := strings.TrimSpace(p.field.String()); text != "" {
+				p.info.Fields[ended.field] = text
+			}
+		case destDate:
+			p.setDate(ended.field)
+		}
+	}
+}
+
+// setDate stores the date read from the information group
+func (p *parser) setDate(field string) {
+	yr, mo, dy, hr, mi, sec := p.date[0], p.date[1], p.date[2], p.date[3], p.date[4], p.date[5]
+	if yr <= 0 || mo < 1 || mo > 12 || dy < 1 || dy > 31 {
+		return
+	}
+
+	t := time.Date(yr, time.Month(mo), dy, hr, mi, sec, 0, time.UTC)
+	switch field {
+	case "creatim":
+		p.info.Created = t
+	case "revtim":
+		p.info.Revised = t
+	case "printim":
+		p.info.Printed = t
+	}
+}
+
+// control reads a control word or a control symbol after a backslash
+func (p *parser) control() error {
+	c, err := p.r.ReadByte()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !isLetter(c) {
+		return p.symbol(c)
+	}
+
+	// The control word is a sequence of letters
+	p.word = append(p.word[:0], c)
+	for {
+		c, err = p.r.ReadByte()
+		if err != nil {
+			break
+		}
+		if !isLetter(c) || len(p.word) >= maxWord {
+			_ = p.r.UnreadByte()
+			break
+		}
+		p.word = append(p.word, c)
+	}
+
+	// It may be followed by a signed parameter, a space
+	// delimiter is part of the control word
+	param, hasParam, neg, digits := 0, false, false, 0
+	for {
+		c, err = p.r.ReadByte()
+		if err != nil {
+			break
+		}
+		if c == '-' && !neg && digits == 0 {
+			neg = true
+			continue
+		}
+		if c >= '0' && c <= '9' {
+			if digits < 10 {
+				param = param*10 + int(c-'0')
+			}
+			digits++
+			hasParam = true
+			continue
+		}
+		if c != ' ' {
+			_ = p.r.UnreadByte()
+		}
+		break
+	}
+	if neg {
+		param = -param
+	}
+
+	return p.controlWord(string(p.word), param, hasParam)
+}
+
+// controlWord interprets a control word
+func (p *parser) controlWord(word string, param int, hasParam bool) error {
+	// The binary data counts as a single fallback character
+	if word == "bin" {
+		if param > 0 {
+			if _, err := p.r.Discard(param); err != nil && err != io.EOF {
+				return err
+			}
+		}
+		if p.skip > 0 {
+			p.skip--
+		}
+		return nil
+	}
+
+	// Fallback characters of \u may be control words
+	if p.skip > 0 && word != "u" {
+		p.skip--
+		return nil
+	}
+
+	switch word {
+	case "u":
+		if hasParam {
+			p.unicode(param)
+		}
+		return nil
+	case "uc":
+		p.st.uc = max(param, 0)
+		return nil
+	}
+
+	p.flush()
+
+	// Destinations
+	switch {
+	case word == "fonttbl":
+		p.st.dest = destFontTable
+		return nil
+	case word == "info":
+		p.st.dest = destInfo
+		return nil
+	case p.doc != nil && p.docDestination(word):
+		return nil
+	case skipped[word]:
+		p.st.dest = destSkip
+		return nil
+	case p.st.dest == destInfo && infoDates[word]:
+		p.st.dest, p.st.field = destDate, word
+		p.date = [6]int{}
+		return nil
+	case p.st.dest == destInfo && infoFields[word]:
+		p.st.dest, p.st.field = destField, word
+		p.field.Reset()
+		return nil
+	case p.st.dest == destInfo:
+		if infoNumbers[word] && hasParam {
+			p.info.Fields[word] = strconv.Itoa(param)
+		}
+		return nil
+	}
+
+	// Font table
+	if p.st.dest == destFontTable {
+		switch word {
+		case "f":
+			p.fontNum = param
+		case "fcharset":
+			if cp, ok := charsets[param]; ok {
+				p.fonts[p.fontNum] = cp
+			}
+		case "cpg":
+			p.fonts[p.fontNum] = param
+		case "fmodern":
+			p.fixed[p.fontNum] = true
+		case "fprq":
+			p.fixed[p.fontNum] = p.fixed[p.fontNum] || param == 1
+		}
+		return nil
+	}
+
+	// Dates of the information group
+	if p.st.dest == destDate {
+		for i, unit := range [...]string{"yr", "mo", "dy", "hr", "min", "sec"} {
+			if word == unit {
+				p.date[i] = param
+			}
+		}
+		return nil
+	}
+
+	if p.doc != nil && p.st.dest == destText && p.docWord(word, param, hasParam) {
+		return nil
+	}
+
+	switch word {
+	case "ansi":
+		p.codepage = 1252
+	case "mac":
+		p.codepage = 10000
+	case "pc":
+		p.codepage = 437
+	case "pca":
+		p.codepage = 850
+	case "ansicpg":
+		if param > 0 {
+			p.codepage = param
+		}
+	case "deff":
+		p.deff = param
+	case "f":
+		p.st.font = param
+	case "plain":
+		p.st.hidden = false
+	case "v":
+		p.st.hidden = !hasParam || param != 0
+	case "par", "line", "sect", "page", "row", "nestrow":
+		p.newline()
+	case "cell", "nestcell":
+		if p.st.dest == destText && !p.st.hidden {
+			p.cell = true
+		}
+	default:
+		if s, ok := symbols[word]; ok {
+			p.char(s)
+		}
+	}
+
+	return nil
+}
+
+// symbol interprets a control symbol
+func (p *parser) symbol(c byte) error {
+	if c == '\'' {
+		// A byte in the code page of the current font
+		hex := make([]byte, 2)
+		if _, err := io.ReadFull(p.r, hex); err != nil {
+			return nil
+		}
+		b, ok := unhex(hex)
+		if !ok {
+			return nil
+		}
+		if p.skip > 0 {
+			p.skip--
+			return nil
+		}
+		p.addByte(b)
+		return nil
+	}
+
+	if p.skip > 0 {
+		p.skip--
+		return nil
+	}
+
+	switch c {
+	case '\\', '{', '}':
+		p.char(string(c))
+	case '~':
+		// Non-breaking space
+		p.char(" ")
+	case '_':
+		// Non-breaking hyphen
+		p.char("-")
+	case '\r', '\n':
+		p.flush()
+		if p.doc != nil && p.st.dest == destText {
+			p.docWord("par", 0, false)
+			return nil
+		}
+		p.newline()
+	case '*':
+		// Ignorable destination
+		p.flush()
+		p.st.dest = destSkip
+	}
+	// \- (optional hyphen), \: and \| are ignored
+
+	return nil
+}
+
+// unicode writes the character of \u and skips its fallback characters
+func (p *parser) unicode(param int) {
+	p.flush()
+	p.skip = p.st.uc
+
+	// The parameter is a signed 16-bit number
+	r := rune(param)
+	if r < 0 {
+		r += 0x10000
+	}
+
+	switch {
+	case utf16.IsSurrogate(r) && r < 0xDC00:
+		p.high = r
+		return
+	case utf16.IsSurrogate(r) && p.high != 0:
+		r = utf16.DecodeRune(p.high, r)
+	case utf16.IsSurrogate(r):
+		r = '�'
+	}
+	p.high = 0
+
+	p.text(string(r))
+}
+
+// textByte interprets a byte of text
+func (p *parser) textByte(c byte) {
+	if p.skip > 0 {
+		p.skip--
+		return
+	}
+
+	// The second byte of a double byte character may be ASCII
+	if c >= 0x80 || p.lead {
+		p.addByte(c)
+		return
+	}
+
+	p.char(string(c))
+}
+
+// addByte appends a byte in the code page of the current font
+func (p *parser) addByte(b byte) {
+	cp := p.fontCodepage()
+	switch {
+	case p.lead:
+		p.lead = false
+	case isLeadByte(cp, b):
+		p.lead = true
+	}
+	p.pending = append(p.pending, b)
+}
+
+// flush decodes the pending code page bytes
+func (p *parser) flush() {
+	if len(p.pending) == 0 {
+		return
+	}
+
+	text := decode(p.fontCodepage(), p.pending)
+	p.pending = p.pending[:0]
+	p.lead = false
+	p.text(text)
+}
+
+// char writes a character which is not in a code page
+func (p *parser) char(s string) {
+	p.flush()
+	p.text(s)
+}
+
+// text writes text to the current destination
+func (p *parser) text(s string) {
+	p.high = 0
+
+	switch p.st.dest {
+	case destText:
+		if p.st.hidden {
+			return
+		}
+		if p.doc != nil {
+			p.doc.write(s, p.st, p.fixed[p.currentFont()])
+			return
+		}
+		if p.cell {
+			// Separate the table cells as the text of the tables
+			// of structured documents
+			p.cell = false
+			_, _ = p.w.WriteString(" | ")
+		}
+		// Tabs are not kept by the filter of non-readable characters
+		_, _ = p.w.WriteString(strings.ReplaceAll(s, "\t", " "))
+	case destField:
+		p.field.WriteString(s)
+	case destLabel:
+		p.doc.label.WriteString(s)
+	case destFieldInst:
+		p.doc.instruction.WriteString(s)
+	}
+}
+
+// newline ends a paragraph or a table row
+func (p *parser) newline() {
+	switch p.st.dest {
+	case destText:
+		if !p.st.hidden {
+			p.cell = false
+			_ = p.w.WriteByte('\n')
+		}
+	case destField:
+		p.field.WriteByte(' ')
+	}
+}
+
+// currentFont returns the current font
+func (p *parser) currentFont() int {
+	if p.st.font < 0 {
+		return p.deff
+	}
+	return p.st.font
+}
+
+// fontCodepage returns the code page of the current font
+func (p *parser) fontCodepage() int {
+	if cp := p.fonts[p.currentFont()]; cp > 0 {
+		return cp
+	}
+	return p.codepage
+}
+
+// isLetter reports whether c is a letter of a control word
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// unhex returns the byte of two hexadecimal digits
+func unhex(hex []byte) (byte, bool) {
+	var b byte
+	for _, c := range hex {
+		switch {
+		case c >= '0' && c <= '9':
+			b = b<<4 | (c - '0')
+		case c >= 'a' && c <= 'f':
+			b = b<<4 | (c - 'a' + 10)
+		case c >= 'A' && c <= 'F':
+			b = b<<4 | (c - 'A' + 10)
+		default:
+			return 0, false
+		}
+	}
+	return b, true
+}
@@ -0,0 +1,181 @@
+package rtf
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pilinux/totext/internal/structure"
+)
+
+// convert returns the text of the RTF document
+func convert(t *testing.T, doc string) (string, *Info) {
+	t.Helper()
+	var sb strings.Builder
+	info, err := Convert(context.Background(), strings.NewReader(doc), &sb)
+	if err != nil {
+		t.Fatalf("%q: %v", doc, err)
+	}
+	return sb.String(), info
+}
+
+// TestConvert tests the text of RTF documents
+func TestConvert(t *testing.T) {
+	// Test data
+	testData := []struct {
+		input    string
+		expected string
+	}{
+		{`{\rtf1\ansi Hello {\b bold} world!\par Second line}`, "Hello bold world!\nSecond line"},
+		{`{\rtf1{\fonttbl{\f0\fswiss Arial;}}{\colortbl;\red0\green0\blue0;}{\stylesheet{\s0 Normal;}}Text}`, "Text"},
+		{`{\rtf1 a{\*\generator Writer;}b{\*\unknown c}d}`, "abd"},
+		{`{\rtf1 {\pict\pngblip 89504e47}picture}`, "picture"},
+		{`{\rtf1\ansi\ansicpg1252 caf\'e9 \'93quoted\'94}`, "café “quoted”"},
+		{`{\rtf1\ansi\ansicpg1251 \'cf\'f0\'e8\'e2\'e5\'f2}`, "Привет"},
+		{`{\rtf1\uc1\u8364?x}`, "€x"},
+		{`{\rtf1\uc2\u26085\'93\'fa\u26412\'96\'7b}`, "日本"},
+		{`{\rtf1\uc0 \u233 t\u233}`, "été"},
+		{`{\rtf1 \u-10179?\u-8704?}`, "😀"},
+		{`{\rtf1 {\uc1\u233\'e9}\'41}`, "éA"},
+		{`{\rtf1 a\tab b\line c\emdash d\~e\_f\-g\\\{\}}`, "a b\nc—d e-fg\\{}"},
+		{`{\rtf1 \trowd\cellx1000\cellx2000 A\cell B\cell\row C\cell D\cell\row}`, "A | B\nC | D\n"},
+		{`{\rtf1 visible{\v hidden}\v0 shown}`, "visibleshown"},
+		{`{\rtf1 {\field{\*\fldinst HYPERLINK "http://x"}{\fldrslt link}}}`, "link"},
+		{`{\rtf1 {\header Page header}body}`, "body"},
+		{`{\rtf1 a\bin3 {}}b}`, "ab"},
+		{"\xEF\xBB\xBF {\\rtf1 bom}", "bom"},
+		{`{\rtf1 unterminated {\b group`, "unterminated group"},
+		{`{\rtf1 end}trailing`, "end"},
+		{`{\rtf1\ansi\deff0{\fonttbl{\f0 Arial;}{\f1\fcharset204 Arial Cyr;}}A{\f1 \'c0}\'c0}`, "AАÀ"},
+		{`{\rtf1\ansi\ansicpg932{\fonttbl{\f0\fcharset128 MS Mincho;}}\f0 \'82\'a0\'82a}`, "あＢ"},
+	}
+
+	// Iterate over test data
+	for _, data := range testData {
+		text, _ := convert(t, data.input)
+		if text != data.expected {
+			t.Errorf("%q: expected %q, got %q", data.input, data.expected, text)
+		}
+	}
+}
+
+// TestInfo tests the document information
+func TestInfo(t *testing.T) {
+	doc := `{\rtf1\ansi{\info{\title The \'93title\'94}{\author Jane Doe}{\keywords a, b}` +
+		`{\creatim\yr2019\mo6\dy5\hr10\min33}{\revtim\yr2020\mo1\dy2\hr3\min4\sec5}\nofpages3\version2}` +
+		`Body}`
+
+	text, info := convert(t, doc)
+	if text != "Body" {
+		t.Errorf("expected %q, got %q", "Body", text)
+	}
+
+	expected := map[string]string{
+		"title":    "The “title”",
+		"author":   "Jane Doe",
+		"keywords": "a, b",
+		"nofpages": "3",
+		"version":  "2",
+	}
+	for key, value := range expected {
+		if info.Fields[key] != value {
+			t.Errorf("%s: expected %q, got %q", key, value, info.Fields[key])
+		}
+	}
+
+	if !info.Created.Equal(time.Date(2019, 6, 5, 10, 33, 0, 0, time.UTC)) {
+		t.Errorf("unexpected creation date %v", info.Created)
+	}
+	if !info.Revised.Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("unexpected revision date %v", info.Revised)
+	}
+}
+
+// TestMalformed tests content which is not an RTF document
+func TestMalformed(t *testing.T) {
+	// The reader is not consumed
+	br := bufio.NewReader(strings.NewReader("plain text"))
+	_, err := Convert(context.Background(), br, &strings.Builder{})
+	if !errors.Is(err, ErrNotRTF) {
+		t.Errorf("expected ErrNotRTF, got %v", err)
+	}
+	if rest, _ := br.ReadString(0); rest != "plain text" {
+		t.Errorf("expected the reader to be unread, got %q", rest)
+	}
+
+	// Too many nested groups
+	doc := `{\rtf1 ` + strings.Repeat("{", maxDepth+1)
+	_, err = Convert(context.Background(), strings.NewReader(doc), &strings.Builder{})
+	if !errors.Is(err, ErrMalformed) {
+		t.Errorf("expected ErrMalformed, got %v", err)
+	}
+}
+
+// TestRead tests the structure of RTF documents
+func TestRead(t *testing.T) {
+	doc := `{\rtf1\ansi{\fonttbl{\f0\fswiss Arial;}{\f1\fmodern Courier New;}}` +
+		`{\pard\outlinelevel1 Scope\par}` +
+		`\pard Plain {\b bold} {\i\b0 it}{\field{\*\fldinst HYPERLINK "https://example.com"}{\fldrslt site}}` +
+		`{\footnote Note}\par` +
+		`{\listtext 1.\tab}\pard\ls1\ilvl0 One\par` +
+		`{\listtext \'b7\tab}\pard\ls1\ilvl1 Sub\par` +
+		`\pard\intbl A\cell B\cell\row` +
+		`\pard\f1 x := 1\par}`
+
+	d, _, err := Read(context.Background(), strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []structure.Block{
+		{Kind: structure.Heading, Level: 2, Text: "Scope"},
+		{Kind: structure.Paragraph, Text: "Plain bold itsite[1]", Spans: []structure.Span{
+			{Text: "Plain "},
+			{Text: "bold", Format: structure.Format{Bold: true}},
+			{Text: " "},
+			{Text: "it", Format: structure.Format{Italic: true}},
+			{Text: "site", Format: structure.Format{Link: "https://example.com"}},
+			{Text: "[1]"},
+		}},
+		{Kind: structure.ListItem, Level: 0, Label: "1.", Text: "One"},
+		{Kind: structure.ListItem, Level: 1, Label: "·", Text: "Sub"},
+		{Kind: structure.Table, Rows: [][]structure.Cell{{
+			{{Kind: structure.Paragraph, Text: "A"}},
+			{{Kind: structure.Paragraph, Text: "B"}},
+		}}},
+		{Kind: structure.Code, Text: "x := 1"},
+	}
+	if fmt.Sprintf("%+v", d.Body) != fmt.Sprintf("%+v", expected) {
+		t.Errorf("expected\n%+v\ngot\n%+v", expected, d.Body)
+	}
+	if len(d.Footnotes) != 1 || d.Footnotes[0].ID != "1" || len(d.Footnotes[0].Blocks) != 1 ||
+		d.Footnotes[0].Blocks[0].Text != "Note" {
+		t.Errorf("unexpected footnotes %+v", d.Footnotes)
+	}
+}
+
+// TestHyperlink tests the targets of hyperlink field instructions
+func TestHyperlink(t *testing.T) {
+	// Test data
+	testData := []struct {
+		input    string
+		expected string
+	}{
+		{`HYPERLINK "https://example.com"`, "https://example.com"},
+		{`HYPERLINK https://example.com \o "tip"`, "https://example.com"},
+		{`HYPERLINK \l "top"`, "#top"},
+		{`HYPERLINK "a.html" \l "b"`, "a.html#b"},
+		{`PAGE`, ""},
+	}
+
+	// Iterate over test data
+	for _, data := range testData {
+		if link := hyperlink(data.input); link != data.expected {
+			t.Errorf("%q: expected %q, got %q", data.input, data.expected, link)
+		}
+	}
+}
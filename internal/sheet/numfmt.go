@@ -0,0 +1,476 @@
+package sheet
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// builtinFormats are the number formats of the built-in format ids
+// of xlsx workbooks. The short date format, which depends on the locale,
+// is rendered as yyyy-mm-dd.
+var builtinFormats = map[int]string{
+	0:  "General",
+	1:  "0",
+	2:  "0.00",
+	3:  "#,##0",
+	4:  "#,##0.00",
+	9:  "0%",
+	10: "0.00%",
+	11: "0.00E+00",
+	12: "# ?/?",
+	13: "# ??/??",
+	14: "yyyy-mm-dd",
+	15: "d-mmm-yy",
+	16: "d-mmm",
+	17: "mmm-yy",
+	18: "h:mm AM/PM",
+	19: "h:mm:ss AM/PM",
+	20: "h:mm",
+	21: "h:mm:ss",
+	22: "yyyy-mm-dd h:mm",
+	37: "#,##0 ;(#,##0)",
+	38: "#,##0 ;[Red](#,##0)",
+	39: "#,##0.00;(#,##0.00)",
+	40: "#,##0.00;[Red](#,##0.00)",
+	45: "mm:ss",
+	46: "[h]:mm:ss",
+	47: "mm:ss.0",
+	48: "##0.0E+0",
+	49: "@",
+}
+
+// fmtToken is a token of a section of a number format
+type fmtToken struct {
+	// kind is literal, number or a date part, e.g. yyyy, mm or [h]
+	kind string
+	text string
+}
+
+// formatNumber renders the number with the number format code,
+// date1904 selects the 1904 date system of the serial dates
+func formatNumber(v float64, code string, date1904 bool) string {
+	sections := splitSections(code)
+	section := sections[0]
+	negative := v < 0
+	switch {
+	case len(sections) >= 3 && v == 0:
+		section = sections[2]
+	case len(sections) >= 2 && negative:
+		// The section of the negative numbers has its own sign
+		section, v, negative = sections[1], -v, false
+	}
+
+	tokens := tokenize(section)
+	if isDateFormat(tokens) {
+		return formatDate(v, tokens, date1904)
+	}
+
+	var sb strings.Builder
+	done := false
+	var pattern strings.Builder
+	for _, t := range tokens {
+		if t.kind == "number" {
+			pattern.WriteString(t.text)
+		}
+	}
+	for _, t := range tokens {
+		switch t.kind {
+		case "literal":
+			sb.WriteString(t.text)
+		case "general", "number":
+			if done {
+				continue
+			}
+			done = true
+			if t.kind == "general" {
+				sb.WriteString(general(math.Abs(v)))
+			} else {
+				sb.WriteString(formatDigits(math.Abs(v)*percentScale(tokens), pattern.String()))
+			}
+		}
+	}
+	if !done && sb.Len() == 0 {
+		return general(v)
+	}
+
+	if negative && strings.ContainsAny(sb.String(), "123456789") {
+		return "-" + sb.String()
+	}
+	return sb.String()
+}
+
+// general renders the number with the General format,
+// with up to 15 significant digits
+func general(v float64) string {
+	if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	s := strconv.FormatFloat(v, 'g', 15, 64)
+	if mantissa, exp, ok := strings.Cut(s, "e"); ok {
+		return mantissa + "E" + exp
+	}
+	return s
+}
+
+// percentScale returns the scale of the percent signs of the format
+func percentScale(tokens []fmtToken) float64 {
+	scale := 1.0
+	for _, t := range tokens {
+		if t.kind == "literal" {
+			scale *= math.Pow(100, float64(strings.Count(t.text, "%")))
+		}
+	}
+	return scale
+}
+
+// formatDigits renders the non-negative number with the digit
+// placeholders of the pattern, e.g. #,##0.00 or 0.00E+00
+func formatDigits(v float64, pattern string) string {
+	mantissa, exponent, scientific := strings.Cut(strings.ToUpper(pattern), "E")
+	intPart, decPart, _ := strings.Cut(mantissa, ".")
+
+	// The commas after the digits scale the number by thousands
+	for strings.HasSuffix(intPart, ",") {
+		intPart = intPart[:len(intPart)-1]
+		v /= 1000
+	}
+	grouping := strings.Contains(intPart, ",")
+	minInt := strings.Count(intPart, "0")
+	decimals := strings.Count(decPart, "0") + strings.Count(decPart, "#") + strings.Count(decPart, "?")
+	minDec := strings.Count(decPart, "0")
+
+	exp := 0
+	if scientific && v != 0 {
+		// The exponent is a multiple of the number of integer digits
+		step := max(len(strings.Trim(intPart, ",")), 1)
+		exp = int(math.Floor(math.Log10(v)))
+		exp -= ((exp % step) + step) % step
+		v /= math.Pow(10, float64(exp))
+	}
+
+	s := strconv.FormatFloat(v, 'f', decimals, 64)
+	whole, frac, _ := strings.Cut(s, ".")
+	for len(frac) > minDec && strings.HasSuffix(frac, "0") {
+		frac = frac[:len(frac)-1]
+	}
+	if whole == "0" && minInt == 0 {
+		whole = ""
+	}
+	for len(whole) < minInt {
+		whole = "0" + whole
+	}
+	if grouping {
+		whole = groupThousands(whole)
+	}
+
+	out := whole
+	if frac != "" {
+		out += "." + frac
+	}
+	if scientific {
+		sign := "+"
+		if exp < 0 {
+			sign, exp = "-", -exp
+		}
+		digits := strconv.Itoa(exp)
+		for len(digits) < strings.Count(exponent, "0") {
+			digits = "0" + digits
+		}
+		out += "E" + sign + digits
+	}
+	return out
+}
+
+// groupThousands separates the thousands of the digits with commas
+func groupThousands(digits string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+	var sb strings.Builder
+	head := len(digits) % 3
+	if head > 0 {
+		sb.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if sb.Len() > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(digits[i : i+3])
+	}
+	return sb.String()
+}
+
+// splitSections splits the format code on the semicolons
+// which are not quoted, escaped or within brackets
+func splitSections(code string) []string {
+	var sections []string
+	start := 0
+	inQuote, inBracket := false, false
+	for i := 0; i < len(code); i++ {
+		switch c := code[i]; {
+		case inQuote:
+			inQuote = c != '"'
+		case inBracket:
+			inBracket = c != ']'
+		case c == '"':
+			inQuote = true
+		case c == '[':
+			inBracket = true
+		case c == '\\':
+			i++
+		case c == ';':
+			sections = append(sections, code[start:i])
+			start = i + 1
+		}
+	}
+	return append(sections, code[start:])
+}
+
+// tokenize splits a section of a number format into literals, digit
+// placeholders, the General keyword and the parts of the dates
+func tokenize(section string) []fmtToken {
+	var tokens []fmtToken
+	add := func(kind, text string) {
+		if kind == "literal" && len(tokens) > 0 && tokens[len(tokens)-1].kind == "literal" {
+			tokens[len(tokens)-1].text += text
+			return
+		}
+		tokens = append(tokens, fmtToken{kind, text})
+	}
+
+	runes := []rune(section)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		lower := unicode.ToLower(c)
+		switch {
+		case c == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			add("literal", string(runes[i+1:min(end, len(runes))]))
+			i = end
+		case c == '\\' && i+1 < len(runes):
+			add("literal", string(runes[i+1]))
+			i++
+		case c == '_' && i+1 < len(runes):
+			// Padding with the width of the next character
+			add("literal", " ")
+			i++
+		case c == '*' && i+1 < len(runes):
+			// Filling with the next character
+			i++
+		case c == '[':
+			end := i + 1
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+			content := string(runes[i+1 : min(end, len(runes))])
+			i = end
+			switch elapsed := strings.ToLower(content); {
+			case strings.HasPrefix(content, "$"):
+				// Currency symbol with its locale, e.g. [$€-407]
+				symbol, _, _ := strings.Cut(content[1:], "-")
+				add("literal", symbol)
+			case elapsed == "":
+			case strings.Trim(elapsed, "h") == "" || strings.Trim(elapsed, "m") == "" || strings.Trim(elapsed, "s") == "":
+				add("["+elapsed[:1]+"]", "")
+			}
+			// Colors and conditions are left out
+		case strings.EqualFold(string(runes[i:min(i+7, len(runes))]), "general"):
+			add("general", "")
+			i += 6
+		case strings.EqualFold(string(runes[i:min(i+5, len(runes))]), "am/pm"):
+			add("am/pm", "")
+			i += 4
+		case strings.EqualFold(string(runes[i:min(i+3, len(runes))]), "a/p"):
+			add("a/p", "")
+			i += 2
+		case c == '.' && len(tokens) > 0 && tokens[len(tokens)-1].kind[0] == 's' &&
+			i+1 < len(runes) && runes[i+1] == '0':
+			// Fractions of seconds, e.g. ss.00
+			end := i + 1
+			for end < len(runes) && runes[end] == '0' {
+				end++
+			}
+			add("frac", string(runes[i+1:end]))
+			i = end - 1
+		case strings.ContainsRune("0#?", c) || c == ',' && isDigitPlaceholder(runes, i) ||
+			c == '.' && !isDateTokens(tokens):
+			add("number", string(c))
+			if len(tokens) > 1 && tokens[len(tokens)-2].kind == "number" {
+				tokens[len(tokens)-2].text += string(c)
+				tokens = tokens[:len(tokens)-1]
+			}
+		case (c == 'E' || c == 'e') && len(tokens) > 0 && tokens[len(tokens)-1].kind == "number" &&
+			i+1 < len(runes) && (runes[i+1] == '+' || runes[i+1] == '-'):
+			tokens[len(tokens)-1].text += "E" + string(runes[i+1])
+			i++
+		case strings.ContainsRune("ymdhs", lower):
+			end := i
+			for end < len(runes) && unicode.ToLower(runes[end]) == lower {
+				end++
+			}
+			add(strings.Repeat(string(lower), end-i), "")
+			i = end - 1
+		case c == '@':
+			// Text placeholder, the numbers are displayed as they are
+			add("general", "")
+		default:
+			add("literal", string(c))
+		}
+	}
+	return tokens
+}
+
+// isDigitPlaceholder reports whether the comma at runes[i] follows
+// a digit placeholder, e.g. in #,##0
+func isDigitPlaceholder(runes []rune, i int) bool {
+	return i > 0 && strings.ContainsRune("0#?,", runes[i-1])
+}
+
+// isDateTokens reports whether the tokens have a part of a date
+func isDateTokens(tokens []fmtToken) bool {
+	for _, t := range tokens {
+		switch t.kind[0] {
+		case 'y', 'm', 'd', 'h', 's', '[':
+			return true
+		}
+	}
+	return false
+}
+
+// isDateFormat reports whether the section of a number format
+// renders dates or times
+func isDateFormat(tokens []fmtToken) bool {
+	for _, t := range tokens {
+		if t.kind == "general" {
+			return false
+		}
+	}
+	return isDateTokens(tokens)
+}
+
+// serialTime returns the time of the serial date, the number of days
+// since the epoch of the date system of the workbook
+func serialTime(v float64, date1904 bool) time.Time {
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	switch {
+	case date1904:
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	case v < 61:
+		// The 1900 date system counts February 29, 1900
+		epoch = epoch.AddDate(0, 0, 1)
+	}
+	days := math.Floor(v)
+	ms := math.Round((v - days) * 86400000)
+	return epoch.AddDate(0, 0, int(days)).Add(time.Duration(ms) * time.Millisecond)
+}
+
+// formatDate renders the serial date with the tokens of a date format
+func formatDate(v float64, tokens []fmtToken, date1904 bool) string {
+	t := serialTime(v, date1904)
+	twelveHour := false
+	for _, tok := range tokens {
+		if tok.kind == "am/pm" || tok.kind == "a/p" {
+			twelveHour = true
+		}
+	}
+
+	// The months next to hours or seconds are minutes
+	minute := make([]bool, len(tokens))
+	for i, tok := range tokens {
+		if tok.kind != "m" && tok.kind != "mm" {
+			continue
+		}
+		for j := i - 1; j >= 0; j-- {
+			if tokens[j].kind == "literal" {
+				continue
+			}
+			minute[i] = tokens[j].kind[0] == 'h' || tokens[j].kind == "[h]"
+			break
+		}
+		for j := i + 1; j < len(tokens) && !minute[i]; j++ {
+			if tokens[j].kind == "literal" {
+				continue
+			}
+			minute[i] = tokens[j].kind[0] == 's' || tokens[j].kind == "[s]"
+			break
+		}
+	}
+
+	pad := func(n, width int) string {
+		s := strconv.Itoa(n)
+		for len(s) < width {
+			s = "0" + s
+		}
+		return s
+	}
+
+	var sb strings.Builder
+	for i, tok := range tokens {
+		switch tok.kind {
+		case "literal":
+			sb.WriteString(tok.text)
+		case "frac":
+			n := min(len(tok.text), 3)
+			sb.WriteString("." + pad(t.Nanosecond()/int(math.Pow10(9-n)), n))
+		case "y", "yy":
+			sb.WriteString(pad(t.Year()%100, 2))
+		case "d":
+			sb.WriteString(strconv.Itoa(t.Day()))
+		case "dd":
+			sb.WriteString(pad(t.Day(), 2))
+		case "ddd":
+			sb.WriteString(t.Weekday().String()[:3])
+		case "m", "mm":
+			switch {
+			case minute[i]:
+				sb.WriteString(pad(t.Minute(), len(tok.kind)))
+			default:
+				sb.WriteString(pad(int(t.Month()), len(tok.kind)))
+			}
+		case "mmm":
+			sb.WriteString(t.Month().String()[:3])
+		case "mmmmm":
+			sb.WriteString(t.Month().String()[:1])
+		case "h", "hh":
+			hour := t.Hour()
+			if twelveHour {
+				hour = (hour+11)%12 + 1
+			}
+			sb.WriteString(pad(hour, len(tok.kind)))
+		case "s", "ss":
+			sb.WriteString(pad(t.Second(), len(tok.kind)))
+		case "am/pm", "a/p":
+			marker := "AM"
+			if t.Hour() >= 12 {
+				marker = "PM"
+			}
+			sb.WriteString(marker[:len(tok.kind)/2])
+		case "[h]":
+			sb.WriteString(strconv.Itoa(int(math.Floor(v * 24))))
+		case "[m]":
+			sb.WriteString(strconv.Itoa(int(math.Floor(v * 24 * 60))))
+		case "[s]":
+			sb.WriteString(strconv.Itoa(int(math.Floor(v * 24 * 3600))))
+		default:
+			switch {
+			case tok.kind[0] == 'y':
+				sb.WriteString(pad(t.Year(), 4))
+			case tok.kind[0] == 'd':
+				sb.WriteString(t.Weekday().String())
+			case tok.kind[0] == 'm':
+				sb.WriteString(t.Month().String())
+			case tok.kind[0] == 'h':
+				sb.WriteString(pad(t.Hour(), 2))
+			case tok.kind[0] == 's':
+				sb.WriteString(pad(t.Second(), 2))
+			}
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,334 @@
+package sheet
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pilinux/totext/internal/odf"
+	"github.com/pilinux/totext/internal/xmltree"
+)
+
+// odsCell is a cell of a row with its number of repetitions
+type odsCell struct {
+	cell   Cell
+	repeat int
+}
+
+// odsReader reads the content of an ods document
+type odsReader struct {
+	ctx context.Context
+	d   *xml.Decoder
+	// hidden holds the names of the table styles of the hidden sheets
+	hidden map[string]bool
+	wb     *Workbook
+	cells  int
+}
+
+// ReadODS reads the ods document of the given size
+func ReadODS(ctx context.Context, ra io.ReaderAt, size int64) (*Workbook, error) {
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotSpreadsheet, err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	if files["content.xml"] == nil {
+		return nil, fmt.Errorf("%w: missing content.xml", ErrNotSpreadsheet)
+	}
+
+	encrypted, err := odf.IsEncrypted(files["META-INF/manifest.xml"])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	if encrypted {
+		return nil, ErrEncrypted
+	}
+
+	r := &odsReader{ctx: ctx, hidden: make(map[string]bool), wb: &Workbook{}}
+	rc, err := files["content.xml"].Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: content.xml: %v", ErrMalformed, err)
+	}
+	err = r.content(rc)
+	_ = rc.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	r.wb.Properties = make(map[string]string)
+	if f := files["meta.xml"]; f != nil {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%w: meta.xml: %v", ErrMalformed, err)
+		}
+		props, err := odf.ReadMeta(rc)
+		_ = rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+		}
+		r.wb.Properties = props
+	}
+
+	return r.wb, nil
+}
+
+// content reads the styles and the tables of the content part
+func (r *odsReader) content(rc io.Reader) error {
+	r.d = xml.NewDecoder(rc)
+	for {
+		tok, err := r.d.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: content.xml: %v", ErrMalformed, err)
+		}
+		t, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch t.Name.Local {
+		case "style":
+			if xmltree.Attr(t, "family") == "table" {
+				r.tableStyle(xmltree.Attr(t, "name"))
+			}
+		case "table":
+			if err = r.table(t); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// tableStyle marks the table style as hidden if it is not displayed
+func (r *odsReader) tableStyle(name string) {
+	for {
+		tok, err := r.d.Token()
+		if err != nil {
+			return
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "table-properties" && xmltree.Attr(t, "display") == "false" {
+				r.hidden[name] = true
+			}
+		case xml.EndElement:
+			if t.Name.Local == "style" {
+				return
+			}
+		}
+	}
+}
+
+// table reads the rows of a table into a sheet
+func (r *odsReader) table(start xml.StartElement) error {
+	sh := Sheet{Name: xmltree.Attr(start, "name"), Hidden: r.hidden[xmltree.Attr(start, "style-name")]}
+	b := &builder{cells: &r.cells}
+	row := 0
+	err := r.children(func(t xml.StartElement) error {
+		return r.rows(t, b, &row)
+	})
+	if err != nil {
+		return err
+	}
+
+	sh.Rows = b.sheetRows()
+	r.wb.Sheets = append(r.wb.Sheets, sh)
+	return nil
+}
+
+// rows reads a row of a table, or the rows of a group of rows
+func (r *odsReader) rows(t xml.StartElement, b *builder, row *int) error {
+	switch t.Name.Local {
+	case "table-header-rows", "table-row-group", "table-rows":
+		return r.children(func(t xml.StartElement) error {
+			return r.rows(t, b, row)
+		})
+	case "table-row":
+	default:
+		return r.d.Skip()
+	}
+
+	if err := r.ctx.Err(); err != nil {
+		return err
+	}
+	repeat := repeated(t, "number-rows-repeated")
+	var cells []odsCell
+	empty := true
+	err := r.children(func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "table-cell", "covered-table-cell":
+			c, err := r.cell(t)
+			if err != nil {
+				return err
+			}
+			empty = empty && c.IsEmpty()
+			cells = append(cells, odsCell{c, repeated(t, "number-columns-repeated")})
+			return nil
+		}
+		return r.d.Skip()
+	})
+	if err != nil {
+		return err
+	}
+
+	// The empty rows are repeated to the end of the sheet
+	if empty {
+		*row = min(*row+repeat, maxRows)
+		return nil
+	}
+	for n := 0; n < repeat && *row < maxRows; n++ {
+		col := 0
+		for _, c := range cells {
+			if c.cell.IsEmpty() {
+				col = min(col+c.repeat, maxColumns)
+				continue
+			}
+			for i := 0; i < c.repeat && col < maxColumns; i++ {
+				if err := b.set(*row, col, c.cell); err != nil {
+					return err
+				}
+				col++
+			}
+		}
+		*row++
+	}
+	return nil
+}
+
+// cell reads the displayed value and the formula of a cell,
+// the cells covered by a merged cell are empty
+func (r *odsReader) cell(t xml.StartElement) (Cell, error) {
+	var lines []string
+	err := r.children(func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "p", "h":
+			var sb strings.Builder
+			if err := r.text(&sb); err != nil {
+				return err
+			}
+			lines = append(lines, sb.String())
+			return nil
+		}
+		// Annotations, shapes and the other elements
+		return r.d.Skip()
+	})
+	if err != nil || t.Name.Local == "covered-table-cell" {
+		return Cell{}, err
+	}
+
+	c := Cell{Value: strings.Join(lines, "\n")}
+	if c.Value == "" {
+		switch xmltree.Attr(t, "value-type") {
+		case "float", "percentage", "currency":
+			if v, err := strconv.ParseFloat(xmltree.Attr(t, "value"), 64); err == nil {
+				c.Value = general(v)
+			}
+		case "date":
+			c.Value = xmltree.Attr(t, "date-value")
+		case "time":
+			c.Value = xmltree.Attr(t, "time-value")
+		case "boolean":
+			c.Value = strings.ToUpper(xmltree.Attr(t, "boolean-value"))
+		case "string":
+			c.Value = xmltree.Attr(t, "string-value")
+		}
+	}
+
+	if formula := xmltree.Attr(t, "formula"); formula != "" {
+		// The formulas start with their namespace, e.g. of:=SUM([.A1:.A2])
+		if prefix, rest, ok := strings.Cut(formula, ":"); ok && !strings.ContainsAny(prefix, "=[(") {
+			formula = rest
+		}
+		if !strings.HasPrefix(formula, "=") {
+			formula = "=" + formula
+		}
+		c.Formula = formula
+	}
+
+	return c, nil
+}
+
+// text reads the text of a paragraph until its end element
+func (r *odsReader) text(sb *strings.Builder) error {
+	for {
+		tok, err := r.d.Token()
+		if err != nil {
+			return fmt.Errorf("%w: content.xml: %v", ErrMalformed, err)
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			sb.Write(t)
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "s":
+				n, err := strconv.Atoi(xmltree.Attr(t, "c"))
+				if err != nil || n < 1 {
+					n = 1
+				}
+				sb.WriteString(strings.Repeat(" ", min(n, 1024)))
+				err = r.d.Skip()
+				if err != nil {
+					return err
+				}
+			case "tab":
+				sb.WriteString("\t")
+				if err = r.d.Skip(); err != nil {
+					return err
+				}
+			case "line-break":
+				sb.WriteString("\n")
+				if err = r.d.Skip(); err != nil {
+					return err
+				}
+			case "annotation", "note":
+				if err = r.d.Skip(); err != nil {
+					return err
+				}
+			default:
+				// Spans, hyperlinks and the other inline elements
+				if err = r.text(sb); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// children calls fn for each child element until the end element
+func (r *odsReader) children(fn func(t xml.StartElement) error) error {
+	for {
+		tok, err := r.d.Token()
+		if err != nil {
+			return fmt.Errorf("%w: content.xml: %v", ErrMalformed, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if err = fn(t); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// repeated returns the number of repetitions of the attribute, at least 1
+func repeated(t xml.StartElement, name string) int {
+	n, err := strconv.Atoi(xmltree.Attr(t, name))
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
@@ -0,0 +1,126 @@
+// Package sheet reads the cells of spreadsheets, the xlsx workbooks of
+// Office Open XML and the ods documents of OpenDocument.
+//
+// The cells hold the value displayed by the spreadsheet application,
+// e.g. the numbers and the dates rendered with their number format, and
+// the text of their formula. The cells covered by a merged cell are empty.
+package sheet
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotSpreadsheet is returned when the content is not a spreadsheet
+	ErrNotSpreadsheet = errors.New("not a spreadsheet")
+
+	// ErrEncrypted is returned when the spreadsheet is encrypted
+	ErrEncrypted = errors.New("encrypted spreadsheet")
+
+	// ErrMalformed is returned when the spreadsheet cannot be parsed
+	ErrMalformed = errors.New("malformed spreadsheet")
+)
+
+const (
+	// maxColumns is the number of columns of the largest sheets
+	maxColumns = 1 << 14
+	// maxRows is the number of rows of the largest sheets
+	maxRows = 1 << 20
+	// maxCells limits the number of cells of a workbook, the empty
+	// cells before the last cell of the rows included
+	maxCells = 1 << 25
+)
+
+// Workbook is a spreadsheet document
+type Workbook struct {
+	// Sheets are the sheets in the order of the workbook
+	Sheets []Sheet
+	// Properties holds the metadata of the document, e.g. the core
+	// properties of xlsx workbooks or the meta.xml fields of ods documents
+	Properties map[string]string
+}
+
+// Sheet is a sheet of a workbook
+type Sheet struct {
+	Name string
+	// Hidden is set for the sheets which are not displayed
+	Hidden bool
+	// Rows are the rows of the sheet from the first one, the rows end
+	// with their last cell which is not empty and the empty rows at the
+	// end of the sheet are left out
+	Rows [][]Cell
+}
+
+// Cell is a cell of a sheet
+type Cell struct {
+	// Value is the value displayed by the spreadsheet application,
+	// the cached value of a formula
+	Value string
+	// Formula is the formula of the cell with its leading =,
+	// empty if the cell holds a constant
+	Formula string
+}
+
+// IsEmpty reports whether the cell has neither a value nor a formula
+func (c Cell) IsEmpty() bool {
+	return c.Value == "" && c.Formula == ""
+}
+
+// builder builds the rows of a sheet from its cells
+type builder struct {
+	rows [][]Cell
+	// cells counts the cells of the workbook
+	cells *int
+}
+
+// set sets the cell at the zero-based row and column
+func (b *builder) set(row, col int, c Cell) error {
+	if c.IsEmpty() || row < 0 || col < 0 || row >= maxRows || col >= maxColumns {
+		return nil
+	}
+
+	for len(b.rows) <= row {
+		b.rows = append(b.rows, nil)
+	}
+	r := b.rows[row]
+	if col >= len(r) {
+		*b.cells += col + 1 - len(r)
+		if *b.cells > maxCells {
+			return fmt.Errorf("%w: more than %d cells", ErrMalformed, maxCells)
+		}
+		r = append(r, make([]Cell, col+1-len(r))...)
+	}
+	r[col] = c
+	b.rows[row] = r
+	return nil
+}
+
+// merge clears the cells covered by the merged cell of the range,
+// the top left cell keeps its value
+func (b *builder) merge(first, last cellRef) {
+	for row := first.row; row <= last.row && row < len(b.rows); row++ {
+		r := b.rows[row]
+		for col := first.col; col <= last.col && col < len(r); col++ {
+			if row != first.row || col != first.col {
+				r[col] = Cell{}
+			}
+		}
+	}
+}
+
+// sheetRows returns the rows without the empty cells
+// at their end and without the empty rows at the end
+func (b *builder) sheetRows() [][]Cell {
+	rows := b.rows
+	for i, r := range rows {
+		for len(r) > 0 && r[len(r)-1].IsEmpty() {
+			r = r[:len(r)-1]
+		}
+		rows[i] = r
+	}
+	for len(rows) > 0 && len(rows[len(rows)-1]) == 0 {
+		rows = rows[:len(rows)-1]
+	}
+	return rows
+}
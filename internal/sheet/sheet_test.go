@@ -0,0 +1,320 @@
+package sheet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pilinux/totext/internal/fixture"
+)
+
+const relsNS = `xmlns="http://schemas.openxmlformats.org/package/2006/relationships"`
+
+// buildXLSX returns an xlsx workbook with a visible and a hidden sheet
+func buildXLSX(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+
+	entries := map[string]string{
+		"[Content_Types].xml": `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"/>`,
+		"_rels/.rels": `<Relationships ` + relsNS + `>` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>` +
+			`</Relationships>`,
+		"docProps/core.xml": `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" ` +
+			`xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>Budget</dc:title><dc:creator>Jane Doe</dc:creator></cp:coreProperties>`,
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+			`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><workbookPr/><sheets>` +
+			`<sheet name="Plan" sheetId="1" r:id="rId1"/><sheet name="Secret" sheetId="2" state="hidden" r:id="rId2"/>` +
+			`</sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships ` + relsNS + `>` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet2.xml"/>` +
+			`<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/sharedStrings" Target="sharedStrings.xml"/>` +
+			`<Relationship Id="rId4" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+			`</Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<si><t>Item</t></si><si><r><t>Pri</t></r><r><t>ce</t></r><rPh><t>ignored</t></rPh></si><si><t>Total</t></si></sst>`,
+		"xl/styles.xml": `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<numFmts><numFmt numFmtId="164" formatCode="#,##0.00 [$€-407]"/></numFmts>` +
+			`<cellXfs><xf numFmtId="0"/><xf numFmtId="164"/><xf numFmtId="14"/><xf numFmtId="10"/></cellXfs></styleSheet>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="inlineStr"><is><t>Date</t></is></c></row>` +
+			`<row r="2"><c r="A2" t="str"><v>Rent</v></c><c r="B2" s="1"><v>1234.5</v></c><c r="C2" s="2"><v>44197</v></c>` +
+			`<c r="D2" t="b"><v>1</v></c></row>` +
+			`<row r="3"><c r="A3" t="s"><v>2</v></c><c r="B3" s="1"><f>SUM(B2:B2)</f><v>1234.5</v></c>` +
+			`<c r="C3" s="3"><v>0.125</v></c></row>` +
+			`<row r="5"><c r="A5"><f t="shared" ref="A5:A6" si="0">B5*2</f><v>4</v></c><c r="B5"><v>2</v></c>` +
+			`<c r="C5"><v>Merged</v></c><c r="D5"><v>hidden</v></c></row>` +
+			`<row r="6"><c r="A6"><f t="shared" si="0"/><v>6</v></c><c r="B6"><v>3</v></c></row>` +
+			`</sheetData><mergeCells count="1"><mergeCell ref="C5:D5"/></mergeCells></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			`<row><c t="inlineStr"><is><t>Key</t></is></c><c><v>42</v></c></row></sheetData></worksheet>`,
+	}
+	for name, content := range parts {
+		entries[name] = content
+	}
+
+	return fixture.Zip(t, entries)
+}
+
+// render returns the values and the formulas of the rows
+func render(rows [][]Cell) string {
+	s := ""
+	for _, row := range rows {
+		for i, c := range row {
+			if i > 0 {
+				s += "|"
+			}
+			s += c.Value
+			if c.Formula != "" {
+				s += "{" + c.Formula + "}"
+			}
+		}
+		s += "\n"
+	}
+	return s
+}
+
+// TestReadXLSX tests the sheets, the cells and the properties of a workbook
+func TestReadXLSX(t *testing.T) {
+	data := buildXLSX(t, nil)
+	wb, err := ReadXLSX(context.Background(), bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Test data
+	testData := []struct {
+		name   string
+		hidden bool
+		rows   string
+	}{
+		{"Plan", false, "Item|Price|Date\n" +
+			"Rent|1,234.50 €|2021-01-01|TRUE\n" +
+			"Total|1,234.50 €{=SUM(B2:B2)}|12.50%\n" +
+			"\n" +
+			"4{=B5*2}|2|Merged\n" +
+			"6{=B6*2}|3\n"},
+		{"Secret", true, "Key|42\n"},
+	}
+
+	if len(wb.Sheets) != len(testData) {
+		t.Fatalf("expected %d sheets, got %d", len(testData), len(wb.Sheets))
+	}
+
+	// Iterate over test data
+	for i, td := range testData {
+		sh := wb.Sheets[i]
+		if sh.Name != td.name || sh.Hidden != td.hidden {
+			t.Errorf("expected sheet %s hidden %t, got %s hidden %t", td.name, td.hidden, sh.Name, sh.Hidden)
+		}
+		if rows := render(sh.Rows); rows != td.rows {
+			t.Errorf("%s: expected rows %q, got %q", td.name, td.rows, rows)
+		}
+	}
+
+	if wb.Properties["title"] != "Budget" || wb.Properties["creator"] != "Jane Doe" {
+		t.Errorf("unexpected properties %v", wb.Properties)
+	}
+}
+
+// TestReadXLSXErrors tests the errors of invalid workbooks
+func TestReadXLSXErrors(t *testing.T) {
+	// Test data
+	testData := []struct {
+		name     string
+		data     []byte
+		expected error
+	}{
+		{"plain text", []byte("plain text"), ErrNotSpreadsheet},
+		{"no workbook", fixture.Zip(t, map[string]string{"[Content_Types].xml": "<Types/>"}), ErrNotSpreadsheet},
+		{"encrypted", append([]byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}, make([]byte, 504)...), ErrEncrypted},
+		{"malformed", buildXLSX(t, map[string]string{"xl/worksheets/sheet1.xml": "<worksheet><sheetData><row>"}), ErrMalformed},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		_, err := ReadXLSX(context.Background(), bytes.NewReader(td.data), int64(len(td.data)))
+		if !errors.Is(err, td.expected) {
+			t.Errorf("%s: expected %v, got %v", td.name, td.expected, err)
+		}
+	}
+}
+
+const odsNS = `xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" ` +
+	`xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" ` +
+	`xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" ` +
+	`xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" ` +
+	`xmlns:meta="urn:oasis:names:tc:opendocument:xmlns:meta:1.0" ` +
+	`xmlns:dc="http://purl.org/dc/elements/1.1/"`
+
+// buildODS returns an ods document with the tables and the parts
+func buildODS(t *testing.T, tables string, parts map[string]string) []byte {
+	t.Helper()
+
+	entries := map[string]string{
+		"mimetype": "application/vnd.oasis.opendocument.spreadsheet",
+		"content.xml": `<office:document-content ` + odsNS + `><office:automatic-styles>` +
+			`<style:style style:name="ta1" style:family="table"><style:table-properties table:display="true"/></style:style>` +
+			`<style:style style:name="ta2" style:family="table"><style:table-properties table:display="false"/></style:style>` +
+			`</office:automatic-styles><office:body><office:spreadsheet>` + tables +
+			`</office:spreadsheet></office:body></office:document-content>`,
+		"meta.xml": `<office:document-meta ` + odsNS + `><office:meta><dc:title>Inventory</dc:title>` +
+			`<meta:initial-creator>Jane Doe</meta:initial-creator></office:meta></office:document-meta>`,
+	}
+	for name, content := range parts {
+		entries[name] = content
+	}
+
+	return fixture.Zip(t, entries)
+}
+
+// TestReadODS tests the sheets, the cells and the properties of an ods document
+func TestReadODS(t *testing.T) {
+	data := buildODS(t, `<table:table table:name="Stock" table:style-name="ta1">`+
+		`<table:table-column table:number-columns-repeated="3"/>`+
+		`<table:table-header-rows><table:table-row><table:table-cell office:value-type="string"><text:p>Item</text:p></table:table-cell>`+
+		`<table:table-cell office:value-type="string"><text:p>Count</text:p></table:table-cell></table:table-row></table:table-header-rows>`+
+		`<table:table-row><table:table-cell table:number-columns-spanned="2" office:value-type="string">`+
+		`<text:p>Two<text:s text:c="2"/><text:span>words</text:span></text:p><text:p>lines</text:p></table:table-cell>`+
+		`<table:covered-table-cell><text:p>covered</text:p></table:covered-table-cell>`+
+		`<table:table-cell office:value-type="boolean" office:boolean-value="true"/></table:table-row>`+
+		`<table:table-row table:number-rows-repeated="2"><table:table-cell/></table:table-row>`+
+		`<table:table-row table:number-rows-repeated="2"><table:table-cell table:number-columns-repeated="2"/>`+
+		`<table:table-cell table:formula="of:=[.A5]*2" office:value-type="float" office:value="8">`+
+		`<text:p>8.00</text:p><office:annotation><text:p>note</text:p></office:annotation></table:table-cell></table:table-row>`+
+		`<table:table-row table:number-rows-repeated="1048570"><table:table-cell table:number-columns-repeated="1024"/></table:table-row>`+
+		`</table:table>`+
+		`<table:table table:name="Hidden" table:style-name="ta2"><table:table-row>`+
+		`<table:table-cell office:value-type="date" office:date-value="2021-01-02"/></table:table-row></table:table>`, nil)
+
+	wb, err := ReadODS(context.Background(), bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Test data
+	testData := []struct {
+		name   string
+		hidden bool
+		rows   string
+	}{
+		{"Stock", false, "Item|Count\nTwo  words\nlines||TRUE\n\n\n||8.00{=[.A5]*2}\n||8.00{=[.A5]*2}\n"},
+		{"Hidden", true, "2021-01-02\n"},
+	}
+
+	if len(wb.Sheets) != len(testData) {
+		t.Fatalf("expected %d sheets, got %d", len(testData), len(wb.Sheets))
+	}
+
+	// Iterate over test data
+	for i, td := range testData {
+		sh := wb.Sheets[i]
+		if sh.Name != td.name || sh.Hidden != td.hidden {
+			t.Errorf("expected sheet %s hidden %t, got %s hidden %t", td.name, td.hidden, sh.Name, sh.Hidden)
+		}
+		if rows := render(sh.Rows); rows != td.rows {
+			t.Errorf("%s: expected rows %q, got %q", td.name, td.rows, rows)
+		}
+	}
+
+	if wb.Properties["title"] != "Inventory" || wb.Properties["initial-creator"] != "Jane Doe" {
+		t.Errorf("unexpected properties %v", wb.Properties)
+	}
+}
+
+// TestReadODSErrors tests the errors of invalid ods documents
+func TestReadODSErrors(t *testing.T) {
+	// Test data
+	testData := []struct {
+		name     string
+		data     []byte
+		expected error
+	}{
+		{"plain text", []byte("plain text"), ErrNotSpreadsheet},
+		{"no content", fixture.Zip(t, map[string]string{"mimetype": "application/vnd.oasis.opendocument.spreadsheet"}), ErrNotSpreadsheet},
+		{"encrypted", buildODS(t, "", map[string]string{
+			"META-INF/manifest.xml": `<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0">` +
+				`<manifest:file-entry manifest:full-path="content.xml"><manifest:encryption-data/></manifest:file-entry>` +
+				`</manifest:manifest>`,
+		}), ErrEncrypted},
+		{"malformed", buildODS(t, "<table:table><table:table-row>", nil), ErrMalformed},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		_, err := ReadODS(context.Background(), bytes.NewReader(td.data), int64(len(td.data)))
+		if !errors.Is(err, td.expected) {
+			t.Errorf("%s: expected %v, got %v", td.name, td.expected, err)
+		}
+	}
+}
+
+// TestFormatNumber tests the number formats
+func TestFormatNumber(t *testing.T) {
+	// Test data
+	testData := []struct {
+		value    float64
+		code     string
+		date1904 bool
+		expected string
+	}{
+		{1234.5, "General", false, "1234.5"},
+		{0.1 + 0.2, "General", false, "0.3"},
+		{1e20, "General", false, "1E+20"},
+		{-3, "0", false, "-3"},
+		{1234.567, "#,##0.00", false, "1,234.57"},
+		{1234567, "#,##0,", false, "1,235"},
+		{0.5, "#.##", false, ".5"},
+		{-1234, "#,##0 ;(#,##0)", false, "(1,234)"},
+		{0, "0.00;-0.00;\"zero\"", false, "zero"},
+		{0.256, "0.0%", false, "25.6%"},
+		{12345, "0.00E+00", false, "1.23E+04"},
+		{12345, "##0.0E+0", false, "12.3E+3"},
+		{9.5, "[Red]0.0 \"kg\"", false, "9.5 kg"},
+		{42, "@", false, "42"},
+		{44197, "yyyy-mm-dd", false, "2021-01-01"},
+		{44197.75, "d-mmm-yy h:mm AM/PM", false, "1-Jan-21 6:00 PM"},
+		{44197, "dddd, mmmm d", false, "Friday, January 1"},
+		{0.52083912, "hh:mm:ss.00", false, "12:30:00.50"},
+		{1.5, "[h]:mm", false, "36:00"},
+		{0, "yyyy-mm-dd", true, "1904-01-01"},
+		{59, "yyyy-mm-dd", false, "1900-02-28"},
+		{61, "yyyy-mm-dd", false, "1900-03-01"},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		if s := formatNumber(td.value, td.code, td.date1904); s != td.expected {
+			t.Errorf("%v %q: expected %q, got %q", td.value, td.code, td.expected, s)
+		}
+	}
+}
+
+// TestShiftFormula tests the references of the shared formulas
+func TestShiftFormula(t *testing.T) {
+	// Test data
+	testData := []struct {
+		formula    string
+		rows, cols int
+		expected   string
+	}{
+		{"SUM(A1:B2)*$C$1", 1, 1, "SUM(B2:C3)*$C$1"},
+		{"$A1+A$1+LOG10(2)", 2, 1, "$A3+B$1+LOG10(2)"},
+		{`"A1"&'Sheet 1'!A1`, 1, 0, `"A1"&'Sheet 1'!A2`},
+		{"A1+1.5E3", -1, 0, "#REF!+1.5E3"},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		if s := shiftFormula(td.formula, td.rows, td.cols); s != td.expected {
+			t.Errorf("%s: expected %q, got %q", td.formula, td.expected, s)
+		}
+	}
+
+	if strings.Join([]string{columnName(0), columnName(25), columnName(26), columnName(701)}, " ") != "A Z AA ZZ" {
+		t.Errorf("unexpected column names")
+	}
+}
@@ -0,0 +1,414 @@
+package sheet
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pilinux/totext/internal/opc"
+	"github.com/pilinux/totext/internal/xmltree"
+)
+
+// cellRef is the zero-based row and column of a cell
+type cellRef struct {
+	row, col int
+}
+
+// refPattern matches the A1 references of the formulas
+var refPattern = regexp.MustCompile(`^(\$?)([A-Za-z]{1,3})(\$?)([0-9]{1,7})$`)
+
+// parseCellRef parses an A1 reference, e.g. B12 or $B$12
+func parseCellRef(s string) (cellRef, bool) {
+	m := refPattern.FindStringSubmatch(s)
+	if m == nil {
+		return cellRef{}, false
+	}
+	col := 0
+	for _, c := range strings.ToUpper(m[2]) {
+		col = col*26 + int(c-'A') + 1
+	}
+	row, _ := strconv.Atoi(m[4])
+	if row < 1 || row > maxRows || col > maxColumns {
+		return cellRef{}, false
+	}
+	return cellRef{row - 1, col - 1}, true
+}
+
+// columnName returns the letters of the zero-based column, e.g. AB
+func columnName(col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name
+}
+
+// xlsxCell is a cell of a worksheet
+type xlsxCell struct {
+	Ref   string `xml:"r,attr"`
+	Type  string `xml:"t,attr"`
+	Style int    `xml:"s,attr"`
+	Value string `xml:"v"`
+	// Formula is nil if the cell holds a constant
+	Formula *struct {
+		Text string `xml:",chardata"`
+		Type string `xml:"t,attr"`
+		SI   string `xml:"si,attr"`
+	} `xml:"f"`
+	Inline *richText `xml:"is"`
+}
+
+// richText is a string of the shared strings or of an inline string,
+// either a single text or runs of formatted text
+type richText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+// String returns the text without the phonetic runs
+func (rt richText) String() string {
+	var sb strings.Builder
+	sb.WriteString(rt.Text)
+	for _, r := range rt.Runs {
+		sb.WriteString(r.Text)
+	}
+	return sb.String()
+}
+
+// sharedFormula is the formula shared by a range of cells
+type sharedFormula struct {
+	text string
+	at   cellRef
+}
+
+// xlsxReader reads the parts of an xlsx workbook
+type xlsxReader struct {
+	pk       *opc.Package
+	strings  []string
+	formats  []string
+	date1904 bool
+	cells    int
+}
+
+// ReadXLSX reads the xlsx workbook of the given size
+func ReadXLSX(ctx context.Context, ra io.ReaderAt, size int64) (*Workbook, error) {
+	pk, err := opc.Open(ra, size)
+	if err != nil {
+		return nil, packageError(err)
+	}
+	rels, err := pk.Rels("")
+	if err != nil {
+		return nil, packageError(err)
+	}
+	name := rels.First("officeDocument")
+	if name == "" || pk.File(name) == nil {
+		return nil, fmt.Errorf("%w: missing workbook", ErrNotSpreadsheet)
+	}
+
+	r := &xlsxReader{pk: pk}
+	wbRels, err := pk.Rels(name)
+	if err != nil {
+		return nil, packageError(err)
+	}
+	data, err := pk.Read(name)
+	if err != nil {
+		return nil, packageError(err)
+	}
+	var v struct {
+		Properties struct {
+			Date1904 string `xml:"date1904,attr"`
+		} `xml:"workbookPr"`
+		Sheets []struct {
+			Name  string `xml:"name,attr"`
+			State string `xml:"state,attr"`
+			ID    string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err = xml.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrMalformed, name, err)
+	}
+	r.date1904 = v.Properties.Date1904 == "1" || v.Properties.Date1904 == "true"
+
+	if err = r.sharedStrings(wbRels.First("sharedStrings")); err != nil {
+		return nil, err
+	}
+	if err = r.styles(wbRels.First("styles")); err != nil {
+		return nil, err
+	}
+
+	wb := &Workbook{}
+	for _, s := range v.Sheets {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		sh := Sheet{Name: s.Name, Hidden: s.State == "hidden" || s.State == "veryHidden"}
+		// The chart sheets have no cells
+		if target := wbRels.ByID[s.ID]; target != "" && pk.File(target) != nil {
+			if sh.Rows, err = r.worksheet(ctx, target); err != nil {
+				return nil, err
+			}
+		}
+		wb.Sheets = append(wb.Sheets, sh)
+	}
+
+	if wb.Properties, err = pk.Properties(); err != nil {
+		return nil, packageError(err)
+	}
+
+	return wb, nil
+}
+
+// packageError returns the error of the package as a spreadsheet error
+func packageError(err error) error {
+	switch {
+	case errors.Is(err, opc.ErrEncrypted):
+		return ErrEncrypted
+	case errors.Is(err, opc.ErrNotPackage):
+		return fmt.Errorf("%w: %v", ErrNotSpreadsheet, err)
+	}
+	return fmt.Errorf("%w: %v", ErrMalformed, err)
+}
+
+// sharedStrings reads the shared strings part
+func (r *xlsxReader) sharedStrings(name string) error {
+	if name == "" || r.pk.File(name) == nil {
+		return nil
+	}
+	data, err := r.pk.Read(name)
+	if err != nil {
+		return packageError(err)
+	}
+	var v struct {
+		Items []richText `xml:"si"`
+	}
+	if err = xml.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrMalformed, name, err)
+	}
+	r.strings = make([]string, len(v.Items))
+	for i, item := range v.Items {
+		r.strings[i] = item.String()
+	}
+	return nil
+}
+
+// styles reads the number formats of the cell styles
+func (r *xlsxReader) styles(name string) error {
+	if name == "" || r.pk.File(name) == nil {
+		return nil
+	}
+	data, err := r.pk.Read(name)
+	if err != nil {
+		return packageError(err)
+	}
+	var v struct {
+		NumFmts []struct {
+			ID   int    `xml:"numFmtId,attr"`
+			Code string `xml:"formatCode,attr"`
+		} `xml:"numFmts>numFmt"`
+		CellXfs []struct {
+			NumFmtID int `xml:"numFmtId,attr"`
+		} `xml:"cellXfs>xf"`
+	}
+	if err = xml.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrMalformed, name, err)
+	}
+
+	custom := make(map[int]string, len(v.NumFmts))
+	for _, f := range v.NumFmts {
+		custom[f.ID] = f.Code
+	}
+	r.formats = make([]string, len(v.CellXfs))
+	for i, xf := range v.CellXfs {
+		code, ok := custom[xf.NumFmtID]
+		if !ok {
+			code = builtinFormats[xf.NumFmtID]
+		}
+		r.formats[i] = code
+	}
+	return nil
+}
+
+// worksheet reads the rows of the worksheet part
+func (r *xlsxReader) worksheet(ctx context.Context, name string) ([][]Cell, error) {
+	rc, err := r.pk.Open(name)
+	if err != nil {
+		return nil, packageError(err)
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	b := &builder{cells: &r.cells}
+	shared := make(map[string]sharedFormula)
+	var merges [][2]cellRef
+	at := cellRef{row: -1}
+	d := xml.NewDecoder(rc)
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrMalformed, name, err)
+		}
+		t, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch t.Name.Local {
+		case "row":
+			if err = ctx.Err(); err != nil {
+				return nil, err
+			}
+			at = cellRef{row: at.row + 1, col: -1}
+			if n, err := strconv.Atoi(xmltree.Attr(t, "r")); err == nil && n > 0 {
+				at.row = n - 1
+			}
+		case "c":
+			var c xlsxCell
+			if err = d.DecodeElement(&c, &t); err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrMalformed, name, err)
+			}
+			at.col++
+			if ref, ok := parseCellRef(c.Ref); ok {
+				at = ref
+			}
+			if err = b.set(at.row, at.col, r.cell(c, at, shared)); err != nil {
+				return nil, err
+			}
+		case "mergeCell":
+			first, last, ok := strings.Cut(xmltree.Attr(t, "ref"), ":")
+			from, ok1 := parseCellRef(first)
+			to, ok2 := parseCellRef(last)
+			if ok && ok1 && ok2 {
+				merges = append(merges, [2]cellRef{from, to})
+			}
+		}
+	}
+
+	for _, m := range merges {
+		b.merge(m[0], m[1])
+	}
+	return b.sheetRows(), nil
+}
+
+// cell returns the displayed value and the formula of the cell at
+// the reference, shared holds the shared formulas of the worksheet
+func (r *xlsxReader) cell(c xlsxCell, at cellRef, shared map[string]sharedFormula) Cell {
+	var cell Cell
+	switch c.Type {
+	case "s":
+		if i, err := strconv.Atoi(strings.TrimSpace(c.Value)); err == nil && i >= 0 && i < len(r.strings) {
+			cell.Value = r.strings[i]
+		}
+	case "inlineStr":
+		if c.Inline != nil {
+			cell.Value = c.Inline.String()
+		}
+	case "b":
+		cell.Value = "FALSE"
+		if strings.TrimSpace(c.Value) == "1" {
+			cell.Value = "TRUE"
+		}
+	case "str", "e", "d":
+		cell.Value = c.Value
+	default:
+		cell.Value = c.Value
+		if v, err := strconv.ParseFloat(strings.TrimSpace(c.Value), 64); err == nil {
+			code := "General"
+			if c.Style >= 0 && c.Style < len(r.formats) && r.formats[c.Style] != "" {
+				code = r.formats[c.Style]
+			}
+			cell.Value = formatNumber(v, code, r.date1904)
+		}
+	}
+
+	if f := c.Formula; f != nil {
+		text := f.Text
+		if f.Type == "shared" {
+			// The first cell of the range holds the formula
+			if sf, ok := shared[f.SI]; ok && text == "" {
+				text = shiftFormula(sf.text, at.row-sf.at.row, at.col-sf.at.col)
+			} else if text != "" {
+				shared[f.SI] = sharedFormula{text, at}
+			}
+		}
+		if text != "" {
+			cell.Formula = "=" + text
+		}
+	}
+
+	return cell
+}
+
+// shiftFormula moves the relative references of the formula
+// by the rows and the columns
+func shiftFormula(formula string, rows, cols int) string {
+	var sb strings.Builder
+	quote := byte(0)
+	for i := 0; i < len(formula); {
+		c := formula[i]
+		switch {
+		case quote != 0:
+			// Strings and quoted sheet names
+			if c == quote {
+				quote = 0
+			}
+			sb.WriteByte(c)
+			i++
+		case c == '"' || c == '\'':
+			quote = c
+			sb.WriteByte(c)
+			i++
+		case isWordChar(c):
+			j := i
+			for j < len(formula) && isWordChar(formula[j]) {
+				j++
+			}
+			word := formula[i:j]
+			m := refPattern.FindStringSubmatch(word)
+			if m == nil || j < len(formula) && (formula[j] == '(' || formula[j] == '!') {
+				sb.WriteString(word)
+				i = j
+				continue
+			}
+			ref, ok := parseCellRef(word)
+			if !ok {
+				sb.WriteString(word)
+				i = j
+				continue
+			}
+			if m[1] == "" {
+				ref.col += cols
+			}
+			if m[3] == "" {
+				ref.row += rows
+			}
+			if ref.row < 0 || ref.col < 0 {
+				sb.WriteString("#REF!")
+			} else {
+				sb.WriteString(m[1] + columnName(ref.col) + m[3] + strconv.Itoa(ref.row+1))
+			}
+			i = j
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	return sb.String()
+}
+
+// isWordChar reports whether the character is part of a name,
+// a number or a reference of a formula
+func isWordChar(c byte) bool {
+	return c == '$' || c == '_' || c == '.' || c >= '0' && c <= '9' ||
+		c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= 0x80
+}
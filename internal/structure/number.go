@@ -0,0 +1,78 @@
+package structure
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Letters formats the number as the letters of the lists, a to z
+// followed by aa to zz and so on, starting at the letter first
+func Letters(n int, first byte) string {
+	if n <= 0 {
+		return strconv.Itoa(n)
+	}
+	return strings.Repeat(string(rune(first)+rune((n-1)%26)), (n-1)/26+1)
+}
+
+// Roman formats the number as an upper case roman numeral
+func Roman(n int) string {
+	if n <= 0 || n >= 4000 {
+		return strconv.Itoa(n)
+	}
+
+	numerals := []struct {
+		value  int
+		symbol string
+	}{
+		{1000, "M"}, {900, "CM"}, {500, "D"}, {400, "CD"},
+		{100, "C"}, {90, "XC"}, {50, "L"}, {40, "XL"},
+		{10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"},
+	}
+	var b strings.Builder
+	for _, numeral := range numerals {
+		for ; n >= numeral.value; n -= numeral.value {
+			b.WriteString(numeral.symbol)
+		}
+	}
+	return b.String()
+}
+
+// Ordinal formats the number as an English ordinal, e.g. 1st
+func Ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// Bullet returns the bullet of a list label. The characters of the
+// symbol fonts, which are in the private use area, are replaced with
+// a bullet.
+func Bullet(text string) string {
+	if text == "" {
+		return "•"
+	}
+
+	var b strings.Builder
+	for _, r := range text {
+		if IsPrivate(r) {
+			r = '•'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// IsPrivate reports whether the rune is in the private use area, where
+// the symbol fonts have their glyphs
+func IsPrivate(r rune) bool {
+	return unicode.In(r, unicode.Co)
+}
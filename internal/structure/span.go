@@ -0,0 +1,135 @@
+package structure
+
+import "strings"
+
+// Format is the format of a run of text
+type Format struct {
+	Bold   bool
+	Italic bool
+	Strike bool
+	// Code is set for text in a monospaced font or a code style
+	Code bool
+	// Link is the target of a hyperlink, e.g. a URL or #bookmark
+	Link string
+}
+
+// Span is a run of text with the same format
+type Span struct {
+	Text string
+	Format
+}
+
+// Builder builds the text of a paragraph and its spans
+type Builder struct {
+	// Format is the format of the text written next
+	Format Format
+
+	text  strings.Builder
+	spans []Span
+	// formatted reports whether a span has a format
+	formatted bool
+}
+
+// WriteString appends s in the current format
+func (b *Builder) WriteString(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	b.text.WriteString(s)
+
+	if n := len(b.spans); n > 0 && b.spans[n-1].Format == b.Format {
+		b.spans[n-1].Text += s
+	} else {
+		b.spans = append(b.spans, Span{Text: s, Format: b.Format})
+	}
+	if b.Format != (Format{}) {
+		b.formatted = true
+	}
+
+	return len(s), nil
+}
+
+// Write appends p in the current format
+func (b *Builder) Write(p []byte) (int, error) {
+	return b.WriteString(string(p))
+}
+
+// WriteByte appends c in the current format
+func (b *Builder) WriteByte(c byte) error {
+	_, err := b.WriteString(string(c))
+	return err
+}
+
+// WriteRune appends r in the current format
+func (b *Builder) WriteRune(r rune) (int, error) {
+	return b.WriteString(string(r))
+}
+
+// Append appends the text and the spans of other
+func (b *Builder) Append(other *Builder) {
+	format := b.Format
+	for _, s := range other.spans {
+		b.Format = s.Format
+		_, _ = b.WriteString(s.Text)
+	}
+	b.Format = format
+}
+
+// String returns the text
+func (b *Builder) String() string {
+	return b.text.String()
+}
+
+// Len returns the length of the text in bytes
+func (b *Builder) Len() int {
+	return b.text.Len()
+}
+
+// Spans returns the spans of the text, nil if none has a format
+func (b *Builder) Spans() []Span {
+	if !b.formatted {
+		return nil
+	}
+	return append([]Span(nil), b.spans...)
+}
+
+// Reset empties the text, the current format is kept
+func (b *Builder) Reset() {
+	b.text.Reset()
+	b.spans = nil
+	b.formatted = false
+}
+
+// TrimSuffix removes the trailing suffix of the text if present
+func (b *Builder) TrimSuffix(suffix string) {
+	text := b.text.String()
+	if !strings.HasSuffix(text, suffix) {
+		return
+	}
+	b.text.Reset()
+	b.text.WriteString(text[:len(text)-len(suffix)])
+
+	for n := len(suffix); n > 0 && len(b.spans) > 0; {
+		last := &b.spans[len(b.spans)-1]
+		cut := min(n, len(last.Text))
+		last.Text = last.Text[:len(last.Text)-cut]
+		n -= cut
+		if last.Text == "" {
+			b.spans = b.spans[:len(b.spans)-1]
+		}
+	}
+}
+
+// monospaced are parts of the names of common monospaced fonts
+var monospaced = []string{"mono", "courier", "consolas", "menlo", "monaco", "lucida console", "fixedsys", "terminal"}
+
+// Monospace reports whether the font is a common monospaced font
+func Monospace(font string) bool {
+	font = strings.ToLower(font)
+	for _, name := range monospaced {
+		if strings.Contains(font, name) {
+			return true
+		}
+	}
+	return false
+}
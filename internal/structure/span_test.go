@@ -0,0 +1,66 @@
+package structure
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestBuilder tests the spans of the text written to a Builder
+func TestBuilder(t *testing.T) {
+	var b Builder
+	_, _ = b.WriteString("plain ")
+	b.Format.Bold = true
+	_, _ = b.WriteString("bo")
+	_, _ = b.WriteRune('l')
+	_ = b.WriteByte('d')
+	b.Format = Format{}
+	_, _ = b.WriteString("  ")
+	b.TrimSuffix(" ")
+
+	var other Builder
+	other.Format.Link = "https://example.com"
+	_, _ = other.WriteString("link")
+	b.Append(&other)
+
+	expected := []Span{
+		{Text: "plain "},
+		{Text: "bold", Format: Format{Bold: true}},
+		{Text: " "},
+		{Text: "link", Format: Format{Link: "https://example.com"}},
+	}
+	if b.String() != "plain bold link" || b.Len() != len("plain bold link") {
+		t.Errorf("unexpected text %q", b.String())
+	}
+	if fmt.Sprint(b.Spans()) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, b.Spans())
+	}
+
+	// Text without format has no spans
+	b.Reset()
+	_, _ = b.WriteString("plain")
+	if b.Spans() != nil || b.String() != "plain" {
+		t.Errorf("unexpected spans %v of %q", b.Spans(), b.String())
+	}
+}
+
+// TestMonospace tests the names of monospaced fonts
+func TestMonospace(t *testing.T) {
+	// Test data
+	testData := []struct {
+		font     string
+		expected bool
+	}{
+		{"Courier New", true},
+		{"DejaVu Sans Mono", true},
+		{"Consolas", true},
+		{"Arial", false},
+		{"", false},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		if got := Monospace(td.font); got != td.expected {
+			t.Errorf("%q: expected %v, got %v", td.font, td.expected, got)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Package structure describes the structure of text documents: the
+// paragraphs, headings, list items and tables of their body and the
+// page headers and footers, notes and comments around it, or the
+// slides of presentations.
+package structure
+
+// BlockKind is the kind of a block of a document
+type BlockKind int
+
+const (
+	// Paragraph is a paragraph of text
+	Paragraph BlockKind = iota
+	// Heading is a paragraph with an outline level
+	Heading
+	// ListItem is a numbered or bulleted paragraph
+	ListItem
+	// Table is a table, its content is in Rows
+	Table
+	// Code is a paragraph of preformatted text, e.g. source code
+	Code
+)
+
+// Block is a paragraph, a heading, a list item, a table or code
+type Block struct {
+	Kind BlockKind
+	// Level is the level of a heading, starting at 1,
+	// or of a list item, starting at 0
+	Level int
+	// Label is the number or the bullet of a list item
+	// or of a numbered heading, or the language of code
+	Label string
+	// Text is the text of the paragraph
+	Text string
+	// Spans are the runs of Text with their format, nil when
+	// the text has no format
+	Spans []Span
+	// Rows are the cells of a table, row by row
+	Rows [][]Cell
+	// Quote is the depth of the block quotes around the block
+	Quote int
+}
+
+// Cell is the content of a table cell
+type Cell []Block
+
+// Note is a footnote, an endnote or a comment
+type Note struct {
+	// ID is the number of a note or the id of a comment
+	ID string
+	// Author is the author of a comment
+	Author string
+	// Blocks is the content of the note
+	Blocks []Block
+}
+
+// Slide is a slide of a presentation
+type Slide struct {
+	// Title is the text of the title of the slide
+	Title string
+	// Body is the content of the other shapes of the slide
+	Body []Block
+	// Notes are the speaker notes of the slide
+	Notes []Block
+	// Hidden is set for the slides which are not shown
+	Hidden bool
+}
+
+// Document is the content of a document
+type Document struct {
+	// Body is the main document
+	Body []Block
+	// Headers and Footers are the distinct header and footer
+	// parts in the order of the sections
+	Headers [][]Block
+	Footers [][]Block
+	// Footnotes and Endnotes are ordered by number
+	Footnotes []Note
+	Endnotes  []Note
+	// Comments are in the order of the document
+	Comments []Note
+	// Slides are the slides of a presentation in their order
+	Slides []Slide
+	// Properties are the metadata of the document
+	// keyed by their element names, e.g. title or creator
+	Properties map[string]string
+}
@@ -0,0 +1,46 @@
+// Package xmltree walks the element trees of the XML parts of the
+// documents, e.g. of docx, odt and pptx files, which are read token by
+// token with an xml.Decoder.
+package xmltree
+
+import "encoding/xml"
+
+// Walk calls fn with the child elements and text with the character
+// data of the current element of the decoder up to its end. fn must
+// read the child element up to its end, text may be nil.
+func Walk(d *xml.Decoder, fn func(xml.StartElement) error, text func(xml.CharData)) error {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if err = fn(t); err != nil {
+				return err
+			}
+		case xml.CharData:
+			if text != nil {
+				text(t)
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// Children calls fn with the child elements of the current element of
+// the decoder up to its end. fn must read the child element up to its end.
+func Children(d *xml.Decoder, fn func(xml.StartElement) error) error {
+	return Walk(d, fn, nil)
+}
+
+// Attr returns the value of the attribute of the element with the local name
+func Attr(t xml.StartElement, name string) string {
+	for _, a := range t.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,44 @@
+package xmltree
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+// TestWalk tests walking the children and the text of an element
+func TestWalk(t *testing.T) {
+	d := xml.NewDecoder(strings.NewReader(`<root><p id="1">a<b>b</b>c</p><p id="2"/></root><after/>`))
+	if _, err := d.Token(); err != nil {
+		t.Fatal(err)
+	}
+
+	var ids, texts []string
+	err := Children(d, func(p xml.StartElement) error {
+		ids = append(ids, Attr(p, "id"))
+		var text strings.Builder
+		err := Walk(d, func(xml.StartElement) error {
+			return d.Skip()
+		}, func(data xml.CharData) {
+			text.Write(data)
+		})
+		texts = append(texts, text.String())
+		return err
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(ids, ",") != "1,2" || strings.Join(texts, ",") != "ac," {
+		t.Errorf("unexpected ids %q and texts %q", ids, texts)
+	}
+
+	// The walk stops at the end of the element
+	tok, err := d.Token()
+	if se, ok := tok.(xml.StartElement); err != nil || !ok || se.Name.Local != "after" {
+		t.Errorf("expected the next element, got %v (%v)", tok, err)
+	}
+
+	if Attr(xml.StartElement{}, "id") != "" {
+		t.Error("expected an empty attribute")
+	}
+}
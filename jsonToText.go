@@ -0,0 +1,172 @@
+package totext
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+func init() {
+	RegisterConverter(JSON, MimeJSON, ConverterFunc(ConvertJSONReaderToTextContext))
+}
+
+// ConvertJSONToText receives json filepath as an argument and returns its text content and metadata
+//
+// The string values are returned in document order, a line each. The
+// path of a value is made of the keys of the objects which hold it joined
+// with dots, e.g. author.name, the elements of an array have the path of
+// the array. WithJSONKeys selects the values by path and WithJSONKeyValues
+// prefixes the values with their path.
+func ConvertJSONToText(filepath string, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertJSONToTextContext(context.Background(), filepath, opts...)
+}
+
+// ConvertJSONToTextContext is like ConvertJSONToText but stops
+// the conversion when ctx is done
+func ConvertJSONToTextContext(ctx context.Context, filepath string, opts ...Option) (content string, metadata map[string]string, err error) {
+	// Get the json file
+	jsonFile, err := os.Open(filepath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer func() {
+		_ = jsonFile.Close()
+	}()
+
+	// Convert json to text
+	return ConvertJSONReaderToTextContext(ctx, jsonFile, opts...)
+}
+
+// ConvertJSONReaderToText receives json content as an io.Reader
+// and returns its text content and metadata
+func ConvertJSONReaderToText(r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertJSONReaderToTextContext(context.Background(), r, opts...)
+}
+
+// ConvertJSONReaderToTextContext is like ConvertJSONReaderToText
+// but stops the conversion when ctx is done
+func ConvertJSONReaderToTextContext(ctx context.Context, r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	o := newOptions(opts...)
+	if _, err = o.markdown(); err != nil {
+		return "", nil, err
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", nil, err
+	}
+
+	data, charsetName, err := decodeCharset(data, false, o)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var sb strings.Builder
+	err = jsonStrings(ctx, data, func(path []string, value string) {
+		if !jsonKeySelected(path, o.JSONKeys) {
+			return
+		}
+		if o.JSONKeyValues && len(path) > 0 {
+			sb.WriteString(strings.Join(path, "."))
+			sb.WriteString(": ")
+		}
+		sb.WriteString(value)
+		sb.WriteString("\n")
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return cleanUpText(sb.String()), map[string]string{"charset": charsetName}, nil
+}
+
+// jsonStrings calls fn with the path and the value of the string values
+// of the JSON content, in document order
+func jsonStrings(ctx context.Context, data []byte, fn func(path []string, value string)) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	// path holds the keys of the objects, containers marks for each open
+	// object or array whether it is an object
+	var path []string
+	var containers []bool
+	// key is set when the next token is the key of an object member
+	key := false
+
+	for i := 0; ; i++ {
+		if i%1024 == 0 && ctx.Err() != nil {
+			return contextError(ctx)
+		}
+
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			if len(containers) > 0 {
+				return corruptError(io.ErrUnexpectedEOF)
+			}
+			return nil
+		}
+		if err != nil {
+			return corruptError(err)
+		}
+
+		switch t := tok.(type) {
+		case json.Delim:
+			switch t {
+			case '{':
+				containers = append(containers, true)
+				key = true
+				continue
+			case '[':
+				containers = append(containers, false)
+				continue
+			case '}', ']':
+				containers = containers[:len(containers)-1]
+				key = false
+			}
+		case string:
+			if key {
+				path = append(path, t)
+				key = false
+				continue
+			}
+			fn(path, t)
+		}
+
+		// The value of an object member ends with its key
+		if len(containers) > 0 && containers[len(containers)-1] {
+			path = path[:len(path)-1]
+			key = true
+		}
+	}
+}
+
+// jsonKeySelected reports whether the value at path is selected by the
+// key paths, a key path selects the values at its path and below it and
+// a * matches any key
+func jsonKeySelected(path []string, keys []string) bool {
+	if len(keys) == 0 {
+		return true
+	}
+
+	for _, key := range keys {
+		parts := strings.Split(key, ".")
+		if len(parts) > len(path) {
+			continue
+		}
+		matched := true
+		for i, part := range parts {
+			if part != "*" && part != path[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package totext
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestConvertJSONReaderToText tests ConvertJSONReaderToText function
+func TestConvertJSONReaderToText(t *testing.T) {
+	src := `{
+  "title": "Notes",
+  "count": 2,
+  "draft": false,
+  "items": [
+    {"name": "first", "tags": ["a", "b"], "meta": {}},
+    {"name": "second", "note": null}
+  ],
+  "author": {"name": "Jane", "email": "jane@example.com"},
+  "body": "line one\r\nline two"
+}`
+
+	// Test data
+	testData := []struct {
+		opts     []Option
+		expected string
+	}{
+		{nil, "Notes\nfirst\na\nb\nsecond\nJane\njane@example.com\nline one\nline two\n"},
+		{[]Option{WithJSONKeys("title", "author")}, "Notes\nJane\njane@example.com\n"},
+		{[]Option{WithJSONKeys("items.name", "*.email")}, "first\nsecond\njane@example.com\n"},
+		{[]Option{WithJSONKeys("items.tags", "title"), WithJSONKeyValues(true)}, "title: Notes\nitems.tags: a\nitems.tags: b\n"},
+		{[]Option{WithJSONKeys("missing")}, ""},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		content, metadata, err := ConvertJSONReaderToText(strings.NewReader(src), td.opts...)
+		if err != nil {
+			t.Errorf("Error converting JSON: %s", err)
+			continue
+		}
+		if content != td.expected {
+			t.Errorf("Expected %q, got %q", td.expected, content)
+		}
+		if metadata["charset"] != "utf-8" {
+			t.Errorf("Expected charset utf-8, got %q", metadata["charset"])
+		}
+	}
+
+	// Top-level arrays and values have no key path
+	content, _, err := ConvertJSONReaderToText(strings.NewReader(`["x", {"k": "y"}] "z"`), WithJSONKeyValues(true))
+	if err != nil {
+		t.Fatalf("Error converting JSON: %s", err)
+	}
+	if content != "x\nk: y\nz\n" {
+		t.Errorf("Unexpected content %q", content)
+	}
+
+	// Invalid JSON is corrupt
+	for _, invalid := range []string{`{"a": }`, `{"a": "b"`} {
+		if _, _, err := ConvertJSONReaderToText(strings.NewReader(invalid)); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("Expected ErrCorrupt for %q, got %v", invalid, err)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package totext
+
+import "github.com/pilinux/totext/internal/lang"
+
+// DetectLanguage returns the ISO 639-1 code of the language of the text
+// and the confidence of the detection between 0 and 1, an empty code if
+// the language is unknown.
+//
+// The languages written in a script of their own, e.g. Greek, Korean or
+// Thai, are detected from the script. The other languages are detected
+// with an offline model of the trigrams of their words, e.g. English,
+// Spanish, Russian, Arabic or Hindi. The confidence is lower for short
+// texts and for languages which are close to each other.
+func DetectLanguage(text string) (code string, confidence float64) {
+	return lang.Detect(text)
+}
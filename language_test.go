@@ -0,0 +1,30 @@
+package totext
+
+import "testing"
+
+// TestDetectLanguage tests DetectLanguage function
+func TestDetectLanguage(t *testing.T) {
+	// Test data
+	testData := []struct {
+		text     string
+		expected string
+	}{
+		{"The report describes the results of the survey and the methods used to collect the data.", "en"},
+		{"Der Bericht beschreibt die Ergebnisse der Umfrage und die Methoden der Datenerhebung.", "de"},
+		{"El informe describe los resultados de la encuesta y los métodos utilizados para recoger los datos.", "es"},
+		{"Отчёт описывает результаты опроса и методы, которые использовались для сбора данных.", "ru"},
+		{"この報告書は、調査の結果とデータの収集方法について説明します。", "ja"},
+		{"12345", ""},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		code, confidence := DetectLanguage(td.text)
+		if code != td.expected {
+			t.Errorf("Expected language %q, got %q for %q", td.expected, code, td.text)
+		}
+		if code != "" && (confidence <= 0 || confidence > 1) {
+			t.Errorf("Expected a confidence between 0 and 1, got %v for %q", confidence, td.text)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package totext
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pilinux/totext/internal/structure"
+)
+
+// orderedLabel matches the labels of list items which are
+// Markdown ordered list markers, e.g. 1. or 2)
+var orderedLabel = regexp.MustCompile(`^[0-9]{1,9}[.)]$`)
+
+// lineStart matches the start of a line which Markdown would read as
+// a heading, a block quote, a list item or a thematic break
+var lineStart = regexp.MustCompile(`^(?:[#>]|[-+=](?:\s|$)|[0-9]{1,9}[.)](?:\s|$))`)
+
+// blocksMarkdown returns the blocks in Markdown. Headings are prefixed
+// with # for every level, list items with - or their number, tables are
+// GFM pipe tables, consecutive code blocks are fenced together and the
+// blocks in block quotes are prefixed with >.
+func blocksMarkdown(blocks []structure.Block) string {
+	var md strings.Builder
+	// kinds are the kinds of the list markers of the previous list item
+	// and its parents, nil after other blocks
+	var kinds []string
+	quote := 0
+
+	for i := 0; i < len(blocks); i++ {
+		b := blocks[i]
+
+		// Blocks are separated with blank lines, except the items of a
+		// list. Lists of another kind at the same level are new lists.
+		level, marker := 0, ""
+		if b.Kind == structure.ListItem {
+			// Nested lists can only be one level deeper
+			level = min(max(b.Level, 0), len(kinds))
+			marker = listMarker(b.Label)
+		}
+		if md.Len() > 0 && (b.Kind != structure.ListItem || kinds == nil ||
+			b.Quote != quote || level < len(kinds) && kinds[level] != listKind(marker)) {
+			md.WriteString(strings.TrimSpace(strings.Repeat("> ", min(b.Quote, quote))) + "\n")
+		}
+		quote = b.Quote
+		if b.Kind == structure.ListItem {
+			kinds = append(kinds[:level], listKind(marker))
+		} else {
+			kinds = nil
+		}
+
+		var block string
+		switch b.Kind {
+		case structure.Heading:
+			block = strings.Repeat("#", min(max(b.Level, 1), 6)) + " "
+			if b.Label != "" {
+				block += escapeMarkdown(b.Label, false) + " "
+			}
+			block += strings.TrimSpace(inlineMarkdown(b, " ")) + "\n"
+		case structure.ListItem:
+			indent := strings.Repeat("    ", level)
+			block = indent + marker + " " +
+				strings.TrimSpace(inlineMarkdown(b, "\\\n"+indent+strings.Repeat(" ", len(marker)+1))) + "\n"
+		case structure.Table:
+			block = tableMarkdown(b.Rows)
+		case structure.Code:
+			// The code blocks are fenced with their language
+			var lines []string
+			for ; i < len(blocks) && blocks[i].Kind == structure.Code &&
+				blocks[i].Label == b.Label && blocks[i].Quote == b.Quote; i++ {
+				// Tabs are not kept by the filter of non-readable characters
+				lines = append(lines, strings.ReplaceAll(blocks[i].Text, "\t", "    "))
+			}
+			i--
+			code := strings.Join(lines, "\n")
+			fence := strings.Repeat("`", max(longestRun(code, '`')+1, 3))
+			block = fence + strings.Join(strings.Fields(b.Label), "") + "\n" + code + "\n" + fence + "\n"
+		default:
+			block = strings.TrimSpace(inlineMarkdown(b, "\\\n")) + "\n"
+		}
+
+		if b.Quote > 0 {
+			block = quoteMarkdown(block, b.Quote)
+		}
+		md.WriteString(block)
+	}
+
+	return md.String()
+}
+
+// quoteMarkdown prefixes the lines of the Markdown with > for every
+// level of the block quotes
+func quoteMarkdown(md string, depth int) string {
+	prefix := strings.Repeat("> ", depth)
+	lines := strings.Split(strings.TrimSuffix(md, "\n"), "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = strings.TrimSpace(prefix)
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// listKind returns the kind of a Markdown list marker: - for bullets,
+// or the delimiter of the numbers, . or )
+func listKind(marker string) string {
+	if orderedLabel.MatchString(marker) {
+		return marker[len(marker)-1:]
+	}
+	return "-"
+}
+
+// listMarker returns the Markdown list marker of the label of a list
+// item. Labels which are not numbers or bullets follow a - marker.
+func listMarker(label string) string {
+	if orderedLabel.MatchString(label) {
+		return label
+	}
+	if strings.IndexFunc(label, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}) < 0 {
+		return "-"
+	}
+	return "- " + escapeMarkdown(label, false)
+}
+
+// inlineMarkdown returns the text of the block in Markdown with its
+// links, emphasis and code. The line breaks are replaced with newline.
+func inlineMarkdown(b structure.Block, newline string) string {
+	spans := b.Spans
+	if spans == nil {
+		spans = []structure.Span{{Text: b.Text}}
+	}
+
+	var md strings.Builder
+	for i := 0; i < len(spans); {
+		// Consecutive spans with the same target are a single link
+		j := i + 1
+		for j < len(spans) && spans[j].Link == spans[i].Link {
+			j++
+		}
+
+		var text strings.Builder
+		for k, s := range spans[i:j] {
+			start := i+k == 0 || strings.HasSuffix(spans[i+k-1].Text, "\n")
+			text.WriteString(spanMarkdown(s, start))
+		}
+		if link := spans[i].Link; link != "" && strings.TrimSpace(text.String()) != "" {
+			md.WriteString("[" + text.String() + "](" + linkDestination(link) + ")")
+		} else {
+			md.WriteString(text.String())
+		}
+		i = j
+	}
+
+	// Tabs are not kept by the filter of non-readable characters
+	text := strings.ReplaceAll(md.String(), "\t", " ")
+	return strings.ReplaceAll(text, "\n", newline)
+}
+
+// spanMarkdown returns the text of the span with the markers of its
+// format. start reports whether the span starts a line. The white
+// space around the text is kept outside of the markers.
+func spanMarkdown(s structure.Span, start bool) string {
+	trimmed := strings.TrimSpace(s.Text)
+	if trimmed == "" {
+		return s.Text
+	}
+	lead := s.Text[:strings.Index(s.Text, trimmed)]
+	trail := s.Text[len(lead)+len(trimmed):]
+
+	if s.Code {
+		trimmed = strings.ReplaceAll(trimmed, "\n", " ")
+		ticks := strings.Repeat("`", longestRun(trimmed, '`')+1)
+		if strings.HasPrefix(trimmed, "`") || strings.HasSuffix(trimmed, "`") {
+			trimmed = " " + trimmed + " "
+		}
+		return lead + ticks + trimmed + ticks + trail
+	}
+
+	text := escapeMarkdown(trimmed, start)
+	var marker string
+	switch {
+	case s.Bold && s.Italic:
+		marker = "***"
+	case s.Bold:
+		marker = "**"
+	case s.Italic:
+		marker = "*"
+	}
+	if s.Strike {
+		marker += "~~"
+	}
+	if marker == "" {
+		return lead + text + trail
+	}
+
+	return lead + marker + text + reverse(marker) + trail
+}
+
+// escapeMarkdown escapes the characters of the text which Markdown
+// would read as markup. start reports whether the text starts a line.
+func escapeMarkdown(text string, start bool) string {
+	var md strings.Builder
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			md.WriteString("\n")
+		}
+
+		var escaped strings.Builder
+		var prev rune
+		for j, r := range line {
+			switch r {
+			case '\\', '`', '*', '[', ']', '<':
+				escaped.WriteByte('\\')
+			case '_':
+				// Underscores within words are not emphasis
+				next, _ := utf8.DecodeRuneInString(line[j+1:])
+				if !isWordRune(prev) || !isWordRune(next) {
+					escaped.WriteByte('\\')
+				}
+			case '~':
+				if prev == '~' || strings.HasPrefix(line[j+1:], "~") {
+					escaped.WriteByte('\\')
+				}
+			}
+			escaped.WriteRune(r)
+			prev = r
+		}
+		line = escaped.String()
+
+		// The markers at the start of the lines
+		if (i > 0 || start) && lineStart.MatchString(strings.TrimLeft(line, " ")) {
+			indent := len(line) - len(strings.TrimLeft(line, " "))
+			if pos := strings.IndexAny(line[indent:], "#>-+=.)"); pos >= 0 {
+				pos += indent
+				line = line[:pos] + "\\" + line[pos:]
+			}
+		}
+		md.WriteString(line)
+	}
+
+	return md.String()
+}
+
+// isWordRune reports whether r is a letter or a digit
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// tableMarkdown returns the rows of a table as a GFM pipe table,
+// the first row is the header
+func tableMarkdown(rows [][]structure.Cell) string {
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	if width == 0 {
+		return ""
+	}
+
+	var md strings.Builder
+	for i, row := range rows {
+		cells := make([]string, width)
+		for j, cell := range row {
+			var texts []string
+			for _, b := range cell {
+				var text string
+				if b.Kind == structure.Table {
+					text = escapeMarkdown(strings.Join(strings.Fields(blocksText([]structure.Block{b})), " "), false)
+				} else {
+					text = strings.Join(strings.Fields(inlineMarkdown(b, " ")), " ")
+				}
+				if text != "" {
+					texts = append(texts, text)
+				}
+			}
+			// Pipes are escaped even in code spans
+			cells[j] = strings.ReplaceAll(strings.Join(texts, " "), "|", "\\|")
+		}
+		md.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		if i == 0 {
+			md.WriteString("|" + strings.Repeat(" --- |", width) + "\n")
+		}
+	}
+
+	return md.String()
+}
+
+// linkDestination returns the destination of a Markdown link
+func linkDestination(link string) string {
+	if !strings.ContainsAny(link, " ()<>") {
+		return link
+	}
+	return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(link) + ">"
+}
+
+// longestRun returns the length of the longest run of c in s
+func longestRun(s string, c byte) int {
+	longest, n := 0, 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == c {
+			n++
+			longest = max(longest, n)
+		} else {
+			n = 0
+		}
+	}
+	return longest
+}
+
+// reverse returns the characters of s in reverse order
+func reverse(s string) string {
+	b := []byte(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
@@ -0,0 +1,129 @@
+package totext
+
+import (
+	"testing"
+
+	"github.com/pilinux/totext/internal/structure"
+)
+
+// TestBlocksMarkdown tests the Markdown of the blocks
+func TestBlocksMarkdown(t *testing.T) {
+	paragraph := func(text string) structure.Cell {
+		return structure.Cell{{Kind: structure.Paragraph, Text: text}}
+	}
+
+	// Test data
+	testData := []struct {
+		name     string
+		blocks   []structure.Block
+		expected string
+	}{
+		{
+			"heading",
+			[]structure.Block{{Kind: structure.Heading, Level: 8, Label: "1.", Text: "Intro"}},
+			"###### 1. Intro\n",
+		},
+		{
+			"lists",
+			[]structure.Block{
+				{Kind: structure.ListItem, Label: "1.", Text: "One"},
+				{Kind: structure.ListItem, Level: 3, Label: "•", Text: "Deep"},
+				{Kind: structure.ListItem, Label: "a)", Text: "Letter\nnext"},
+				{Kind: structure.Paragraph, Text: "After"},
+			},
+			"1. One\n    - Deep\n\n- a) Letter\\\n     next\n\nAfter\n",
+		},
+		{
+			"adjacent lists",
+			[]structure.Block{
+				{Kind: structure.ListItem, Label: "•", Text: "One"},
+				{Kind: structure.ListItem, Label: "•", Text: "Two"},
+				{Kind: structure.ListItem, Label: "3.", Text: "Three"},
+				{Kind: structure.ListItem, Label: "4)", Text: "Four"},
+			},
+			"- One\n- Two\n\n3. Three\n\n4) Four\n",
+		},
+		{
+			"table",
+			[]structure.Block{{Kind: structure.Table, Rows: [][]structure.Cell{
+				{paragraph("Name"), paragraph("a|b")},
+				{paragraph("Ann")},
+			}}},
+			"| Name | a\\|b |\n| --- | --- |\n| Ann |  |\n",
+		},
+		{
+			"code",
+			[]structure.Block{
+				{Kind: structure.Code, Text: "a := \"```\""},
+				{Kind: structure.Code, Text: "\tb()"},
+			},
+			"````\na := \"```\"\n    b()\n````\n",
+		},
+		{
+			"code language",
+			[]structure.Block{
+				{Kind: structure.Code, Label: "go", Text: "a()"},
+				{Kind: structure.Code, Label: "go", Text: "b()"},
+				{Kind: structure.Code, Text: "$ c"},
+			},
+			"```go\na()\nb()\n```\n\n```\n$ c\n```\n",
+		},
+		{
+			"quotes",
+			[]structure.Block{
+				{Kind: structure.Paragraph, Text: "Said:"},
+				{Kind: structure.Paragraph, Quote: 1, Text: "First\nline"},
+				{Kind: structure.ListItem, Quote: 1, Label: "•", Text: "Item"},
+				{Kind: structure.Paragraph, Quote: 2, Text: "Nested"},
+				{Kind: structure.Paragraph, Text: "After"},
+			},
+			"Said:\n\n> First\\\n> line\n>\n> - Item\n>\n> > Nested\n\nAfter\n",
+		},
+		{
+			"spans",
+			[]structure.Block{{Kind: structure.Paragraph, Text: "See bold it x site", Spans: []structure.Span{
+				{Text: "See "},
+				{Text: "bold ", Format: structure.Format{Bold: true}},
+				{Text: "it", Format: structure.Format{Italic: true, Strike: true}},
+				{Text: " "},
+				{Text: "x", Format: structure.Format{Code: true}},
+				{Text: " "},
+				{Text: "si", Format: structure.Format{Link: "https://example.com/a b"}},
+				{Text: "te", Format: structure.Format{Bold: true, Link: "https://example.com/a b"}},
+			}}},
+			"See **bold** *~~it~~* `x` [si**te**](<https://example.com/a b>)\n",
+		},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		if got := blocksMarkdown(td.blocks); got != td.expected {
+			t.Errorf("%s: expected %q, got %q", td.name, td.expected, got)
+		}
+	}
+}
+
+// TestEscapeMarkdown tests escaping the markup characters of the text
+func TestEscapeMarkdown(t *testing.T) {
+	// Test data
+	testData := []struct {
+		text     string
+		start    bool
+		expected string
+	}{
+		{"a*b* [c] `d` <e>", false, "a\\*b\\* \\[c\\] \\`d\\` \\<e>"},
+		{"snake_case _x_", false, "snake_case \\_x\\_"},
+		{"~a~ ~~b~~", false, "~a~ \\~\\~b\\~\\~"},
+		{"# title", true, "\\# title"},
+		{"# title", false, "# title"},
+		{"1. one\n- two\n> three", true, "1\\. one\n\\- two\n\\> three"},
+		{"-1 and 2.5", true, "-1 and 2.5"},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		if got := escapeMarkdown(td.text, td.start); got != td.expected {
+			t.Errorf("%q: expected %q, got %q", td.text, td.expected, got)
+		}
+	}
+}
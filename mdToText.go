@@ -0,0 +1,129 @@
+package totext
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pilinux/totext/internal/md"
+)
+
+func init() {
+	RegisterConverter(MD, MimeMD, ConverterFunc(ConvertMarkdownReaderToTextContext))
+}
+
+// frontMatterDateLayouts are the layouts of the dates of the YAML front matter
+var frontMatterDateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"}
+
+// ConvertMarkdownToText receives md filepath as an argument and returns its text content and metadata
+//
+// The Markdown syntax is removed, the text, the link text, the content of
+// the code and the cells of the tables are kept. The fields of the YAML
+// front matter are returned in the metadata, the values of the lists are
+// separated with commas. In Markdown format the document is returned
+// without its front matter.
+func ConvertMarkdownToText(filepath string, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertMarkdownToTextContext(context.Background(), filepath, opts...)
+}
+
+// ConvertMarkdownToTextContext is like ConvertMarkdownToText but stops
+// the conversion when ctx is done
+func ConvertMarkdownToTextContext(ctx context.Context, filepath string, opts ...Option) (content string, metadata map[string]string, err error) {
+	// Get the md file
+	mdFile, err := os.Open(filepath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer func() {
+		_ = mdFile.Close()
+	}()
+
+	// Convert md to text
+	return ConvertMarkdownReaderToTextContext(ctx, mdFile, opts...)
+}
+
+// ConvertMarkdownReaderToText receives md content as an io.Reader
+// and returns its text content and metadata
+func ConvertMarkdownReaderToText(r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertMarkdownReaderToTextContext(context.Background(), r, opts...)
+}
+
+// ConvertMarkdownReaderToTextContext is like ConvertMarkdownReaderToText
+// but stops the conversion when ctx is done
+func ConvertMarkdownReaderToTextContext(ctx context.Context, r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	o := newOptions(opts...)
+	markdown, err := o.markdown()
+	if err != nil {
+		return "", nil, err
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", nil, err
+	}
+	if ctx.Err() != nil {
+		return "", nil, contextError(ctx)
+	}
+
+	data, charsetName, err := decodeCharset(data, false, o)
+	if err != nil {
+		return "", nil, err
+	}
+
+	doc := md.Read(string(data))
+	metadata = frontMatterMetadata(doc.FrontMatter, o)
+	metadata["charset"] = charsetName
+
+	if markdown {
+		body := strings.TrimLeft(doc.Body, "\n")
+		if body != "" && !strings.HasSuffix(body, "\n") {
+			body += "\n"
+		}
+		return body, metadata, nil
+	}
+
+	return doc.Text, metadata, nil
+}
+
+// frontMatterMetadata returns the metadata of the fields of the YAML
+// front matter. The authors are separated with semicolons, the tags
+// are the keywords unless the front matter has keywords and the dates
+// are stored as unix timestamps under CreatedDate and ModifiedDate.
+func frontMatterMetadata(fields map[string][]string, o *Options) map[string]string {
+	metadata := make(map[string]string, len(fields)+1)
+	for key, values := range fields {
+		metadata[key] = strings.Join(values, ", ")
+	}
+
+	if authors, ok := fields["author"]; ok {
+		metadata["author"] = strings.Join(authors, "; ")
+	}
+	if tags, ok := metadata["tags"]; ok && metadata["keywords"] == "" {
+		metadata["keywords"] = tags
+	}
+
+	// Dates
+	dates := []struct {
+		metadataKey string
+		keys        []string
+	}{
+		{"CreatedDate", []string{"date", "created"}},
+		{"ModifiedDate", []string{"lastmod", "modified", "updated"}},
+	}
+	for _, date := range dates {
+		value := firstValue(metadata, date.keys...)
+		if value == "" {
+			continue
+		}
+		if t, ok := parseTime(value, frontMatterDateLayouts...); ok {
+			metadata[date.metadataKey] = fmt.Sprintf("%d", t.Unix())
+		} else {
+			o.warn("invalid front matter date %q", value)
+		}
+	}
+
+	return metadata
+}
@@ -0,0 +1,69 @@
+package totext
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestConvertMarkdownReaderToText tests ConvertMarkdownReaderToText function
+func TestConvertMarkdownReaderToText(t *testing.T) {
+	src := `---
+title: Release notes
+author: [Jane Doe, John Roe]
+tags: [go, text]
+date: 2024-03-01
+lastmod: 2024-03-02T10:00:00Z
+lang: en
+---
+# Release *1.2*
+
+See the [changelog](https://example.com/changelog) and ![logo](logo.png).
+
+| Format | Converter |
+|--------|-----------|
+| md     | ` + "`mdToText.go`" + ` |
+
+` + "```sh\ntotextcli file notes.md\n```\n"
+
+	content, metadata, err := ConvertMarkdownReaderToText(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Error converting Markdown: %s", err)
+	}
+
+	// Compare content
+	expected := "Release 1.2\nSee the changelog and logo.\nFormat | Converter\nmd | mdToText.go\ntotextcli file notes.md\n"
+	if content != expected {
+		t.Errorf("Expected content %q, got %q", expected, content)
+	}
+
+	// Compare metadata
+	m := NewMetadata(metadata, nil)
+	if m.Title != "Release notes" || m.Language != "en" || m.Charset != "utf-8" {
+		t.Errorf("Unexpected metadata %v", metadata)
+	}
+	if strings.Join(m.Authors, "|") != "Jane Doe|John Roe" || strings.Join(m.Keywords, "|") != "go|text" {
+		t.Errorf("Unexpected authors %q and keywords %q", m.Authors, m.Keywords)
+	}
+	if m.Created.Format("2006-01-02") != "2024-03-01" || m.Modified.Format("2006-01-02T15") != "2024-03-02T10" {
+		t.Errorf("Unexpected dates %s and %s", m.Created, m.Modified)
+	}
+
+	// The Markdown format keeps the syntax without the front matter
+	content, _, err = ConvertMarkdownReaderToText(strings.NewReader(src), WithTextFormat(TextFormatMarkdown))
+	if err != nil {
+		t.Fatalf("Error converting Markdown: %s", err)
+	}
+	if !strings.HasPrefix(content, "# Release *1.2*\n") {
+		t.Errorf("Unexpected Markdown %q", content)
+	}
+
+	// Invalid dates are reported
+	var warnings []string
+	_, metadata, err = ConvertMarkdownReaderToText(strings.NewReader("---\ndate: soon\n---\ntext\n"), WithWarnings(&warnings))
+	if err != nil {
+		t.Fatalf("Error converting Markdown: %s", err)
+	}
+	if metadata["date"] != "soon" || metadata["CreatedDate"] != "" || len(warnings) != 1 {
+		t.Errorf("Unexpected metadata %v and warnings %q", metadata, warnings)
+	}
+}
@@ -0,0 +1,331 @@
+package totext
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Metadata is the metadata of a document in the schema shared by
+// all the formats
+type Metadata struct {
+	// Title is the title of the document
+	Title string `json:"title,omitempty"`
+	// Subject is the subject or the description of the document
+	Subject string `json:"subject,omitempty"`
+	// Authors are the authors of the document
+	Authors []string `json:"authors,omitempty"`
+	// Keywords are the keywords of the document
+	Keywords []string `json:"keywords,omitempty"`
+	// Language is the language declared by the document, or else the
+	// ISO 639-1 code of the language detected from the text content
+	Language string `json:"language,omitempty"`
+	// LanguageConfidence is the confidence of the language between 0
+	// and 1, it is 1 for a language declared by the document
+	LanguageConfidence float64 `json:"languageConfidence,omitempty"`
+	// Created is the creation time of the document, it is
+	// encoded in RFC 3339 format
+	Created time.Time `json:"created,omitzero"`
+	// Modified is the last modification time of the document,
+	// it is encoded in RFC 3339 format
+	Modified time.Time `json:"modified,omitzero"`
+	// PageCount is the number of pages of the document,
+	// or the number of slides of a presentation
+	PageCount int `json:"pageCount,omitempty"`
+	// WordCount is the number of words of the text content
+	WordCount int `json:"wordCount,omitempty"`
+	// CharacterCount is the number of characters of the text
+	// content, line breaks excluded
+	CharacterCount int `json:"characterCount,omitempty"`
+	// Producer is the application which produced the document
+	Producer string `json:"producer,omitempty"`
+	// Charset is the charset which the text of HTML and text
+	// documents was transcoded from, e.g. shift_jis
+	Charset string `json:"charset,omitempty"`
+
+	// Raw is the metadata as returned by the converter. Besides the
+	// native keys of the format, the converters store the dates as unix
+	// timestamps under CreatedDate and ModifiedDate, as docconv did.
+	Raw map[string]string `json:"raw"`
+}
+
+// metadataKey is a key of the metadata returned by the converters
+// with the separator of its values, if it has several
+type metadataKey struct {
+	key string
+	sep string
+}
+
+// metadataDate is a native key of the metadata returned by the
+// converters for a date with the layouts of its values
+type metadataDate struct {
+	key     string
+	layouts []string
+}
+
+// isoDateLayouts are the layouts of the ISO 8601 dates of
+// the XML based formats and the front matter
+var isoDateLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02 15:04:05", "2006-01-02"}
+
+// The keys of the metadata returned by the converters for the fields
+// of the schema, in order of preference
+var (
+	titleKeys    = []string{"title", "Title", "TITLE"}
+	subjectKeys  = []string{"subject", "Subject", "description", "Description"}
+	languageKeys = []string{"language", "Language", "lang"}
+	producerKeys = []string{"Producer", "producer", "generator", "Generator", "AppName", "Application", "Creator"}
+	pageKeys     = []string{"Pages", "PageCount", "page-count", "slides"}
+	authorKeys   = []metadataKey{
+		{"Author", ";"},
+		{"author", ";"},
+		{"creator", ";"},
+		{"authors", ","},
+		{"AUTHOR", ";"},
+	}
+	keywordKeys = []metadataKey{
+		{"keywords", ",;"},
+		{"Keywords", ",;"},
+	}
+	createdKeys = []metadataDate{
+		{"created", isoDateLayouts},
+		{"creation-date", isoDateLayouts},
+		{"CreationDate", pdfTimeLayouts},
+		{"CreateTime", []string{docTimeLayout}},
+		{"### creation date", []string{unrtfTimeLayout}},
+	}
+	modifiedKeys = []metadataDate{
+		{"modified", isoDateLayouts},
+		{"lastmod", isoDateLayouts},
+		{"updated", isoDateLayouts},
+		{"ModDate", pdfTimeLayouts},
+		{"LastSaveTime", []string{docTimeLayout}},
+		{"### revision date", []string{unrtfTimeLayout}},
+	}
+)
+
+// NewMetadata maps the metadata returned by a converter into the schema.
+// The dates are read from the native keys of the formats, e.g. created
+// of DOCX or CreationDate of PDF, or else from the unix timestamps stored
+// under CreatedDate and ModifiedDate. The word and character counts are those of counter,
+// which may be nil. The language is detected from the start of the text
+// written to counter unless the metadata declares it.
+func NewMetadata(raw map[string]string, counter *TextCounter) Metadata {
+	return newMetadata(raw, counter, func(string, ...any) {})
+}
+
+// newMetadata is like NewMetadata, the invalid values are reported to warn
+func newMetadata(raw map[string]string, counter *TextCounter, warn func(format string, args ...any)) Metadata {
+	if raw == nil {
+		raw = make(map[string]string)
+	}
+
+	m := Metadata{
+		Title:    firstValue(raw, titleKeys...),
+		Subject:  firstValue(raw, subjectKeys...),
+		Authors:  splitValue(raw, authorKeys),
+		Keywords: splitValue(raw, keywordKeys),
+		Language: firstValue(raw, languageKeys...),
+		Producer: firstValue(raw, producerKeys...),
+		Charset:  firstValue(raw, "charset"),
+		Raw:      raw,
+	}
+
+	// Dates
+	m.Created = parseDate(raw, createdKeys, "CreatedDate", warn)
+	m.Modified = parseDate(raw, modifiedKeys, "ModifiedDate", warn)
+
+	// Page count
+	if pages := firstValue(raw, pageKeys...); pages != "" {
+		n, err := strconv.Atoi(pages)
+		if err != nil {
+			warn("invalid page count %q", pages)
+		} else {
+			m.PageCount = n
+		}
+	}
+
+	if counter != nil {
+		m.WordCount, m.CharacterCount = counter.Words, counter.Characters
+	}
+
+	// Language
+	switch {
+	case m.Language != "":
+		m.LanguageConfidence = 1
+	case counter != nil:
+		m.Language, m.LanguageConfidence = DetectLanguage(string(counter.sample))
+	}
+
+	return m
+}
+
+// parseDate parses the first native date of keys which has a valid
+// value, or else the unix timestamp stored under unixKey
+func parseDate(metadata map[string]string, keys []metadataDate, unixKey string, warn func(format string, args ...any)) time.Time {
+	for _, k := range keys {
+		if t, ok := parseTime(metadata[k.key], k.layouts...); ok {
+			return t.UTC()
+		}
+	}
+
+	return parseUnix(metadata, unixKey, warn)
+}
+
+// parseUnix parses the unix timestamp stored under key
+func parseUnix(metadata map[string]string, key string, warn func(format string, args ...any)) time.Time {
+	value := strings.TrimSpace(metadata[key])
+	if value == "" {
+		return time.Time{}
+	}
+
+	sec, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		warn("invalid %s %q", key, value)
+		return time.Time{}
+	}
+
+	return time.Unix(sec, 0).UTC()
+}
+
+// firstValue returns the first non-empty value stored under keys
+func firstValue(metadata map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if value := strings.TrimSpace(metadata[key]); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// splitValue returns the values of the first non-empty key
+func splitValue(metadata map[string]string, keys []metadataKey) []string {
+	for _, k := range keys {
+		value := strings.TrimSpace(metadata[k.key])
+		if value == "" {
+			continue
+		}
+
+		var values []string
+		for _, v := range strings.FieldsFunc(value, func(r rune) bool {
+			return strings.ContainsRune(k.sep, r)
+		}) {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// String returns the metadata as lines of key: value, the empty fields
+// are left out and the raw metadata follows with keys prefixed with raw.
+func (m Metadata) String() string {
+	var sb strings.Builder
+	field := func(key, value string) {
+		if value = strings.Join(strings.Fields(value), " "); value != "" {
+			fmt.Fprintf(&sb, "%s: %s\n", key, value)
+		}
+	}
+	count := func(key string, n int) {
+		if n > 0 {
+			field(key, strconv.Itoa(n))
+		}
+	}
+	date := func(key string, t time.Time) {
+		if !t.IsZero() {
+			field(key, t.Format(time.RFC3339))
+		}
+	}
+
+	field("title", m.Title)
+	field("subject", m.Subject)
+	field("authors", strings.Join(m.Authors, "; "))
+	field("keywords", strings.Join(m.Keywords, ", "))
+	field("language", m.Language)
+	if m.Language != "" {
+		field("languageConfidence", strconv.FormatFloat(m.LanguageConfidence, 'f', 2, 64))
+	}
+	date("created", m.Created)
+	date("modified", m.Modified)
+	count("pageCount", m.PageCount)
+	count("wordCount", m.WordCount)
+	count("characterCount", m.CharacterCount)
+	field("producer", m.Producer)
+	field("charset", m.Charset)
+
+	for _, key := range slices.Sorted(maps.Keys(m.Raw)) {
+		field("raw."+key, m.Raw[key])
+	}
+
+	return sb.String()
+}
+
+// maxLanguageSample is the size of the start of the text
+// from which the language is detected
+const maxLanguageSample = 64 << 10
+
+// TextCounter counts the words and the characters of the text
+// written to it and keeps its start for the language detection
+type TextCounter struct {
+	// Words is the number of runs of characters which are not white space
+	Words int
+	// Characters is the number of characters, line breaks and other
+	// control characters excluded
+	Characters int
+
+	inWord bool
+	// rest is an incomplete UTF-8 sequence at the end of the last write
+	rest []byte
+	// sample is the start of the text
+	sample []byte
+}
+
+// Write counts the words and the characters of p
+func (c *TextCounter) Write(p []byte) (int, error) {
+	n := len(p)
+	if len(c.sample) < maxLanguageSample {
+		c.sample = append(c.sample, p[:min(len(p), maxLanguageSample-len(c.sample))]...)
+	}
+	if len(c.rest) > 0 {
+		p = append(c.rest, p...)
+		c.rest = nil
+	}
+
+	for len(p) > 0 {
+		if !utf8.FullRune(p) {
+			c.rest = append([]byte(nil), p...)
+			break
+		}
+		r, size := utf8.DecodeRune(p)
+		c.count(r)
+		p = p[size:]
+	}
+
+	return n, nil
+}
+
+// WriteString counts the words and the characters of s
+func (c *TextCounter) WriteString(s string) (int, error) {
+	return c.Write([]byte(s))
+}
+
+// count counts the character r
+func (c *TextCounter) count(r rune) {
+	if !unicode.IsControl(r) {
+		c.Characters++
+	}
+	if unicode.IsSpace(r) {
+		c.inWord = false
+		return
+	}
+	if !c.inWord {
+		c.Words++
+		c.inWord = true
+	}
+}
@@ -0,0 +1,136 @@
+package totext
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNewMetadata tests mapping the metadata of the converters into the schema
+func TestNewMetadata(t *testing.T) {
+	// Test data
+	testData := []struct {
+		name     string
+		raw      map[string]string
+		expected Metadata
+	}{
+		{
+			"pdf",
+			map[string]string{"Title": "Report", "Author": "Alice; Bob", "Producer": "LaTeX", "Creator": "Writer",
+				"Pages": "12", "CreatedDate": "1700000000"},
+			Metadata{Title: "Report", Authors: []string{"Alice", "Bob"}, Producer: "LaTeX", PageCount: 12,
+				Created: time.Unix(1700000000, 0).UTC()},
+		},
+		{
+			"docx",
+			map[string]string{"title": "Survey", "subject": "Study", "creator": "Jane Doe", "keywords": "a, b; c",
+				"language": "en-US", "ModifiedDate": "1641092645"},
+			Metadata{Title: "Survey", Subject: "Study", Authors: []string{"Jane Doe"}, Keywords: []string{"a", "b", "c"},
+				Language: "en-US", Modified: time.Unix(1641092645, 0).UTC()},
+		},
+		{
+			"odt",
+			map[string]string{"title": "Notes", "Author": "John Roe", "generator": "Writer", "Pages": "3", "word-count": "120"},
+			Metadata{Title: "Notes", Authors: []string{"John Roe"}, Producer: "Writer", PageCount: 3},
+		},
+		{
+			"pages",
+			map[string]string{"title": "Letter", "authors": "Jane Doe, John Roe"},
+			Metadata{Title: "Letter", Authors: []string{"Jane Doe", "John Roe"}},
+		},
+		{
+			"docx dates",
+			map[string]string{"created": "2022-01-02T03:04:05Z", "modified": "2022-01-03T05:04:05+02:00", "CreatedDate": "1"},
+			Metadata{Created: time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC), Modified: time.Date(2022, 1, 3, 3, 4, 5, 0, time.UTC)},
+		},
+		{
+			"pdf dates",
+			map[string]string{"CreationDate": "Sun Jan  2 03:04:05 2022 UTC", "ModDate": "Mon Jan  3 03:04:05 2022"},
+			Metadata{Created: time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC), Modified: time.Date(2022, 1, 3, 3, 4, 5, 0, time.UTC)},
+		},
+		{
+			"odt and doc dates",
+			map[string]string{"creation-date": "2022-01-02T03:04:05.123", "LastSaveTime": "2022-01-03 03:04:05 +0000 UTC"},
+			Metadata{Created: time.Date(2022, 1, 2, 3, 4, 5, 123e6, time.UTC), Modified: time.Date(2022, 1, 3, 3, 4, 5, 0, time.UTC)},
+		},
+		{
+			"html",
+			map[string]string{"title": "Home", "description": "Start page", "charset": "shift_jis"},
+			Metadata{Title: "Home", Subject: "Start page", Charset: "shift_jis"},
+		},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		m := NewMetadata(td.raw, nil)
+		if m.Title != td.expected.Title || m.Subject != td.expected.Subject || m.Language != td.expected.Language ||
+			m.Producer != td.expected.Producer || m.Charset != td.expected.Charset || m.PageCount != td.expected.PageCount ||
+			!slices.Equal(m.Authors, td.expected.Authors) || !slices.Equal(m.Keywords, td.expected.Keywords) ||
+			!m.Created.Equal(td.expected.Created) || !m.Modified.Equal(td.expected.Modified) {
+			t.Errorf("%s: expected %+v, got %+v", td.name, td.expected, m)
+		}
+		if (m.Language != "") != (m.LanguageConfidence == 1) {
+			t.Errorf("%s: expected the confidence of the declared language, got %v", td.name, m.LanguageConfidence)
+		}
+		if len(m.Raw) != len(td.raw) {
+			t.Errorf("%s: expected the raw metadata %v, got %v", td.name, td.raw, m.Raw)
+		}
+	}
+}
+
+// TestMetadataEncoding tests the text and the JSON encoding of the metadata
+func TestMetadataEncoding(t *testing.T) {
+	var counter TextCounter
+	_, _ = counter.WriteString("This is the text of the report\n")
+	m := NewMetadata(map[string]string{"Title": "Report", "Author": "Alice", "CreatedDate": "1700000000"}, &counter)
+
+	expected := "title: Report\nauthors: Alice\nlanguage: en\nlanguageConfidence: 0.24\ncreated: 2023-11-14T22:13:20Z\nwordCount: 7\ncharacterCount: 30\n" +
+		"raw.Author: Alice\nraw.CreatedDate: 1700000000\nraw.Title: Report\n"
+	if m.String() != expected {
+		t.Errorf("expected %q, got %q", expected, m.String())
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `{"title":"Report","authors":["Alice"],"language":"en","languageConfidence":0.24,"created":"2023-11-14T22:13:20Z","wordCount":7,"characterCount":30,` +
+		`"raw":{"Author":"Alice","CreatedDate":"1700000000","Title":"Report"}}`
+	if string(data) != expected {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+}
+
+// TestTextCounter tests counting the words and the characters of the text
+func TestTextCounter(t *testing.T) {
+	// Test data
+	testData := []struct {
+		text       string
+		words      int
+		characters int
+	}{
+		{"", 0, 0},
+		{"one two\tthree\n", 3, 12},
+		{"  café  naïve ", 2, 14},
+		{"日本語 テキスト", 2, 8},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		// Multi-byte characters are split between writes
+		var c TextCounter
+		for _, b := range []byte(td.text) {
+			_, _ = c.Write([]byte{b})
+		}
+		if c.Words != td.words || c.Characters != td.characters {
+			t.Errorf("%q: expected %d words and %d characters, got %d and %d",
+				td.text, td.words, td.characters, c.Words, c.Characters)
+		}
+	}
+
+	if m := NewMetadata(nil, nil); m.Raw == nil || strings.TrimSpace(m.String()) != "" {
+		t.Errorf("unexpected empty metadata %+v", m)
+	}
+}
@@ -0,0 +1,133 @@
+package totext
+
+import (
+	"io"
+	"mime"
+	"strings"
+)
+
+// mimeAliases are the MIME types accepted for each format,
+// the first one is the canonical MIME type
+var mimeAliases = []struct {
+	fileExt FileExtension
+	aliases []MIME
+}{
+	{DOC, []MIME{MimeDOC, "application/vnd.ms-word", "application/doc", "application/x-msword"}},
+	{DOCX, []MIME{MimeDOCX}},
+	{EPUB, []MIME{MimeEPUB}},
+	{FODT, []MIME{MimeFODT}},
+	{HTML, []MIME{MimeHTML, "application/xhtml+xml"}},
+	{JSON, []MIME{MimeJSON, "text/json", "application/x-json"}},
+	{MD, []MIME{MimeMD, "text/x-markdown", "text/x-web-markdown"}},
+	{ODP, []MIME{MimeODP}},
+	{ODS, []MIME{MimeODS}},
+	{ODT, []MIME{MimeODT}},
+	{OTT, []MIME{MimeOTT}},
+	{PAGES, []MIME{MimePAGES, "application/x-iwork-pages-sffpages"}},
+	{PDF, []MIME{MimePDF, "application/x-pdf", "application/acrobat", "applications/vnd.pdf", "text/pdf", "text/x-pdf"}},
+	{PPTX, []MIME{MimePPTX}},
+	{RTF, []MIME{MimeRTF, "text/rtf", "application/x-rtf", "text/richtext"}},
+	{TXT, []MIME{MimeTXT}},
+	{XLSX, []MIME{MimeXLSX}},
+}
+
+// plainTextFormats are the text formats which are often
+// sent as text/plain
+var plainTextFormats = map[FileExtension]bool{
+	JSON: true,
+	MD:   true,
+}
+
+// genericMIMETypes are the MIME types which tell nothing about the format
+var genericMIMETypes = map[MIME]bool{
+	"application/octet-stream":     true,
+	"binary/octet-stream":          true,
+	"application/unknown":          true,
+	"application/x-download":       true,
+	"application/force-download":   true,
+	"application/zip":              true,
+	"application/x-zip":            true,
+	"application/x-zip-compressed": true,
+	"application/x-ole-storage":    true,
+	"application/cdfv2":            true,
+}
+
+// mimeTypes are the canonical MIME types of the built-in formats
+var mimeTypes = make(map[FileExtension]MIME)
+
+// mimeFormats maps the known MIME types to their format
+var mimeFormats = make(map[MIME]FileExtension)
+
+func init() {
+	for _, entry := range mimeAliases {
+		mimeTypes[entry.fileExt] = entry.aliases[0]
+		for _, alias := range entry.aliases {
+			mimeFormats[alias] = entry.fileExt
+		}
+	}
+}
+
+// matchOptions holds the settings of IsMIMETypeMatched
+type matchOptions struct {
+	content io.ReadSeeker
+}
+
+// MatchOption configures IsMIMETypeMatched
+type MatchOption func(*matchOptions)
+
+// WithContentSniffing detects the format from the content
+// when the declared MIME type is generic,
+// e.g. application/octet-stream
+func WithContentSniffing(r io.ReadSeeker) MatchOption {
+	return func(o *matchOptions) {
+		o.content = r
+	}
+}
+
+// NormalizeMIME removes the parameters of the MIME type and
+// converts it to lower case, e.g. "Text/HTML; charset=utf-8"
+// becomes "text/html"
+func NormalizeMIME(m MIME) MIME {
+	mediaType, _, err := mime.ParseMediaType(string(m))
+	if err != nil {
+		// Keep the part before the parameters of malformed values
+		mediaType, _, _ = strings.Cut(string(m), ";")
+	}
+
+	return MIME(strings.ToLower(strings.TrimSpace(mediaType)))
+}
+
+// IsGenericMIME checks if the MIME type tells nothing about
+// the format, e.g. application/octet-stream
+func IsGenericMIME(m MIME) bool {
+	m = NormalizeMIME(m)
+	return m == "" || genericMIMETypes[m]
+}
+
+// FormatForMIME returns the format of the MIME type, or an empty
+// string if the MIME type is unknown or generic
+func FormatForMIME(m MIME) FileExtension {
+	m = NormalizeMIME(m)
+
+	if fileExt, ok := mimeFormats[m]; ok {
+		return fileExt
+	}
+
+	// Formats registered by the user
+	registry.RLock()
+	defer registry.RUnlock()
+
+	return registry.formats[m]
+}
+
+// mimeTypeOf returns the canonical MIME type of the format
+func mimeTypeOf(fileExt FileExtension) MIME {
+	registry.RLock()
+	m, ok := registry.mimeTypes[fileExt]
+	registry.RUnlock()
+	if ok && m != "" {
+		return m
+	}
+
+	return mimeTypes[fileExt]
+}
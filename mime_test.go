@@ -0,0 +1,84 @@
+package totext
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestIsMIMETypeMatchedAliases tests IsMIMETypeMatched function
+// with parameters and aliases
+func TestIsMIMETypeMatchedAliases(t *testing.T) {
+	// Test data
+	testData := []struct {
+		fileExt  FileExtension
+		mime     MIME
+		expected bool
+	}{
+		{HTML, "text/html; charset=utf-8", true},
+		{HTML, "Text/HTML", true},
+		{RTF, "text/rtf", true},
+		{PDF, "application/x-pdf", true},
+		{MD, "text/x-markdown", true},
+		{JSON, "application/json; charset=UTF-8", true},
+		{PDF, "application/octet-stream", false},
+		{TXT, "text/html", false},
+		{MD, "text/plain; charset=utf-8", true},
+		{JSON, "text/plain", true},
+		{TXT, "text/markdown", false},
+		{HTML, "text/plain", false},
+		{"", "text/plain", false},
+	}
+
+	// Iterate over test data
+	for _, data := range testData {
+		if matched := IsMIMETypeMatched(data.fileExt, data.mime); matched != data.expected {
+			t.Errorf("Expected %t for %s and %s, got %t", data.expected, data.fileExt, data.mime, matched)
+		}
+	}
+}
+
+// TestIsMIMETypeMatchedSniffing tests IsMIMETypeMatched function
+// with content sniffing
+func TestIsMIMETypeMatchedSniffing(t *testing.T) {
+	pdf := bytes.NewReader([]byte("%PDF-1.7\n"))
+
+	if !IsMIMETypeMatched(PDF, "application/octet-stream", WithContentSniffing(pdf)) {
+		t.Errorf("Expected PDF content to match")
+	}
+	if IsMIMETypeMatched(DOC, "application/octet-stream", WithContentSniffing(pdf)) {
+		t.Errorf("Expected PDF content not to match DOC")
+	}
+
+	// Declared types which are not generic are not sniffed
+	if IsMIMETypeMatched(PDF, MimeDOC, WithContentSniffing(pdf)) {
+		t.Errorf("Expected declared MIME type to win")
+	}
+}
+
+// TestFormatForMIME tests FormatForMIME function
+func TestFormatForMIME(t *testing.T) {
+	// Test data
+	testData := []struct {
+		mime     MIME
+		expected FileExtension
+	}{
+		{"application/pdf", PDF},
+		{"text/rtf", RTF},
+		{"text/html; charset=iso-8859-1", HTML},
+		{"text/plain", TXT},
+		{"application/epub+zip", EPUB},
+		{"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", XLSX},
+		{"application/vnd.oasis.opendocument.spreadsheet", ODS},
+		{"application/vnd.openxmlformats-officedocument.presentationml.presentation", PPTX},
+		{"application/vnd.oasis.opendocument.presentation", ODP},
+		{"application/octet-stream", ""},
+		{"image/png", ""},
+	}
+
+	// Iterate over test data
+	for _, data := range testData {
+		if fileExt := FormatForMIME(data.mime); fileExt != data.expected {
+			t.Errorf("Expected format %q for %s, got %q", data.expected, data.mime, fileExt)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package totext
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/pilinux/totext/internal/odf"
+	"github.com/pilinux/totext/internal/structure"
+)
+
+func init() {
+	RegisterConverter(ODP, MimeODP, ConverterFunc(ConvertODPReaderToTextContext))
+}
+
+// ConvertODPToText receives OpenDocument presentation odp filepath
+// as an argument and returns its text content and metadata
+//
+// The slides are laid out as with ConvertPPTXToText. The metadata holds
+// the meta.xml fields of the document and the number of its slides.
+func ConvertODPToText(filepath string, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertODPToTextContext(context.Background(), filepath, opts...)
+}
+
+// ConvertODPToTextContext is like ConvertODPToText but stops
+// the conversion when ctx is done
+func ConvertODPToTextContext(ctx context.Context, filepath string, opts ...Option) (content string, metadata map[string]string, err error) {
+	// Get the odp file
+	odpFile, err := os.Open(filepath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer func() {
+		_ = odpFile.Close()
+	}()
+
+	// Convert odp to text
+	return ConvertODPReaderToTextContext(ctx, odpFile, opts...)
+}
+
+// ConvertODPReaderToText receives OpenDocument presentation odp content as an
+// io.Reader and returns its text content and metadata
+//
+// The text content and the metadata are laid out as with ConvertODPToText.
+func ConvertODPReaderToText(r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertODPReaderToTextContext(context.Background(), r, opts...)
+}
+
+// ConvertODPReaderToTextContext is like ConvertODPReaderToText
+// but stops the conversion when ctx is done
+func ConvertODPReaderToTextContext(ctx context.Context, r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	slides, metadata, err := ConvertODPReaderToSlidesContext(ctx, r, opts...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return slidesText(slides, newOptions(opts...)), metadata, nil
+}
+
+// ConvertODPToSlides receives OpenDocument presentation odp filepath as an argument
+// and returns the text content of its slides in the order of the
+// presentation, and its metadata
+func ConvertODPToSlides(filepath string, opts ...Option) (slides []Slide, metadata map[string]string, err error) {
+	return ConvertODPToSlidesContext(context.Background(), filepath, opts...)
+}
+
+// ConvertODPToSlidesContext is like ConvertODPToSlides but stops
+// the conversion when ctx is done
+func ConvertODPToSlidesContext(ctx context.Context, filepath string, opts ...Option) (slides []Slide, metadata map[string]string, err error) {
+	// Get the odp file
+	odpFile, err := os.Open(filepath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_ = odpFile.Close()
+	}()
+
+	// Convert odp to slides
+	return ConvertODPReaderToSlidesContext(ctx, odpFile, opts...)
+}
+
+// ConvertODPReaderToSlides receives OpenDocument presentation odp content as an
+// io.Reader and returns the text content of its slides in the order of
+// the presentation, and its metadata
+func ConvertODPReaderToSlides(r io.Reader, opts ...Option) (slides []Slide, metadata map[string]string, err error) {
+	return ConvertODPReaderToSlidesContext(context.Background(), r, opts...)
+}
+
+// ConvertODPReaderToSlidesContext is like ConvertODPReaderToSlides
+// but stops the conversion when ctx is done
+func ConvertODPReaderToSlidesContext(ctx context.Context, r io.Reader, opts ...Option) (slides []Slide, metadata map[string]string, err error) {
+	if ctx.Err() != nil {
+		return nil, nil, contextError(ctx)
+	}
+
+	ra, err := sectionReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	slides, doc, err := presentationSlides(ctx, func(ctx context.Context) (*structure.Document, error) {
+		return odf.Read(ctx, ra, ra.Size(), false)
+	}, newOptions(opts...))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return slides, slideMetadata(odtMetadata(doc.Properties), doc), nil
+}
@@ -0,0 +1,70 @@
+package totext
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pilinux/totext/internal/fixture"
+)
+
+// odpEntries are the entries of an odp presentation with a slide
+// with speaker notes and a hidden slide
+var odpEntries = map[string]string{
+	"mimetype": string(MimeODP),
+	"content.xml": `<office:document-content ` + odtNS + `><office:automatic-styles>` +
+		`<style:style style:name="dp2" style:family="drawing-page"><style:drawing-page-properties presentation:visibility="hidden"/></style:style>` +
+		`</office:automatic-styles><office:body><office:presentation>` +
+		`<draw:page draw:name="page1" draw:style-name="dp1">` +
+		`<draw:frame presentation:class="title"><draw:text-box><text:p>Roadmap</text:p></draw:text-box></draw:frame>` +
+		`<draw:frame presentation:class="subtitle"><draw:text-box><text:p>Second quarter</text:p></draw:text-box></draw:frame>` +
+		`<presentation:notes><draw:frame presentation:class="notes"><draw:text-box>` +
+		`<text:p>Greet the audience</text:p></draw:text-box></draw:frame></presentation:notes></draw:page>` +
+		`<draw:page draw:name="page2" draw:style-name="dp2">` +
+		`<draw:frame presentation:class="title"><draw:text-box><text:p>Backup</text:p></draw:text-box></draw:frame>` +
+		`</draw:page></office:presentation></office:body></office:document-content>`,
+	"meta.xml": `<office:document-meta ` + odtNS + `><office:meta><dc:title>Roadmap</dc:title>` +
+		`<meta:initial-creator>Jane Doe</meta:initial-creator><meta:creation-date>2021-03-04T05:06:07</meta:creation-date>` +
+		`</office:meta></office:document-meta>`,
+}
+
+// TestConvertODPReaderToText tests the layouts of the slides of an odp presentation
+func TestConvertODPReaderToText(t *testing.T) {
+	data := fixture.Zip(t, odpEntries)
+
+	// Test data
+	testData := []struct {
+		opts     []Option
+		expected string
+	}{
+		{nil, "# Roadmap\nSecond quarter\n# Backup\n"},
+		{[]Option{WithSpeakerNotes(true), WithPageBreaks(true)}, "# Roadmap\nSecond quarter\n[Notes]\nGreet the audience\n\f# Backup\n"},
+		{[]Option{WithPages(PageRange{First: 2, Last: 5})}, "# Backup\n"},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		content, _, err := ConvertReader(bytes.NewReader(data), ODP, td.opts...)
+		if err != nil {
+			t.Fatalf("Error converting ODP: %s", err)
+		}
+		if content != td.expected {
+			t.Errorf("Expected content %q, got %q", td.expected, content)
+		}
+	}
+
+	// Compare metadata
+	slides, metadata, err := ConvertODPReaderToSlides(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Error converting ODP: %s", err)
+	}
+	if len(slides) != 2 || slides[1].Number != 2 || !slides[1].Hidden || slides[0].Notes != "" {
+		t.Errorf("Unexpected slides %+v", slides)
+	}
+	m := NewMetadata(metadata, nil)
+	if m.Title != "Roadmap" || metadata["Author"] != "Jane Doe" || m.PageCount != 2 || metadata["hidden-slides"] != "1" {
+		t.Errorf("Unexpected metadata %v", metadata)
+	}
+	if m.Created.Format("2006-01-02") != "2021-03-04" {
+		t.Errorf("Unexpected creation date %s", m.Created)
+	}
+}
@@ -0,0 +1,109 @@
+package totext
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/pilinux/totext/internal/sheet"
+)
+
+func init() {
+	RegisterConverter(ODS, MimeODS, ConverterFunc(ConvertODSReaderToTextContext))
+}
+
+// ConvertODSToText receives OpenDocument spreadsheet ods filepath
+// as an argument and returns its text content and metadata
+//
+// The sheets are laid out as with ConvertXLSXToText. The metadata holds
+// the meta.xml fields of the document and the number of its sheets.
+func ConvertODSToText(filepath string, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertODSToTextContext(context.Background(), filepath, opts...)
+}
+
+// ConvertODSToTextContext is like ConvertODSToText but stops
+// the conversion when ctx is done
+func ConvertODSToTextContext(ctx context.Context, filepath string, opts ...Option) (content string, metadata map[string]string, err error) {
+	// Get the ods file
+	odsFile, err := os.Open(filepath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer func() {
+		_ = odsFile.Close()
+	}()
+
+	// Convert ods to text
+	return ConvertODSReaderToTextContext(ctx, odsFile, opts...)
+}
+
+// ConvertODSReaderToText receives OpenDocument spreadsheet ods content
+// as an io.Reader and returns its text content and metadata
+//
+// The text content and the metadata are laid out as with ConvertODSToText.
+func ConvertODSReaderToText(r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertODSReaderToTextContext(context.Background(), r, opts...)
+}
+
+// ConvertODSReaderToTextContext is like ConvertODSReaderToText
+// but stops the conversion when ctx is done
+func ConvertODSReaderToTextContext(ctx context.Context, r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	sheets, metadata, err := ConvertODSReaderToSheetsContext(ctx, r, opts...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return sheetsText(sheets, newOptions(opts...)), metadata, nil
+}
+
+// ConvertODSToSheets receives OpenDocument spreadsheet ods filepath as
+// an argument and returns the text content of its sheets in the order
+// of the document, and its metadata
+func ConvertODSToSheets(filepath string, opts ...Option) (sheets []Sheet, metadata map[string]string, err error) {
+	return ConvertODSToSheetsContext(context.Background(), filepath, opts...)
+}
+
+// ConvertODSToSheetsContext is like ConvertODSToSheets but stops
+// the conversion when ctx is done
+func ConvertODSToSheetsContext(ctx context.Context, filepath string, opts ...Option) (sheets []Sheet, metadata map[string]string, err error) {
+	// Get the ods file
+	odsFile, err := os.Open(filepath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_ = odsFile.Close()
+	}()
+
+	// Convert ods to sheets
+	return ConvertODSReaderToSheetsContext(ctx, odsFile, opts...)
+}
+
+// ConvertODSReaderToSheets receives OpenDocument spreadsheet ods content
+// as an io.Reader and returns the text content of its sheets in the order
+// of the document, and its metadata
+func ConvertODSReaderToSheets(r io.Reader, opts ...Option) (sheets []Sheet, metadata map[string]string, err error) {
+	return ConvertODSReaderToSheetsContext(context.Background(), r, opts...)
+}
+
+// ConvertODSReaderToSheetsContext is like ConvertODSReaderToSheets
+// but stops the conversion when ctx is done
+func ConvertODSReaderToSheetsContext(ctx context.Context, r io.Reader, opts ...Option) (sheets []Sheet, metadata map[string]string, err error) {
+	if ctx.Err() != nil {
+		return nil, nil, contextError(ctx)
+	}
+
+	ra, err := sectionReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sheets, wb, err := workbookSheets(ctx, func(ctx context.Context) (*sheet.Workbook, error) {
+		return sheet.ReadODS(ctx, ra, ra.Size())
+	}, newOptions(opts...))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return sheets, sheetMetadata(odtMetadata(wb.Properties), wb), nil
+}
@@ -0,0 +1,68 @@
+package totext
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pilinux/totext/internal/fixture"
+)
+
+// odsEntries are the entries of an ods document with
+// a visible and a hidden sheet
+var odsEntries = map[string]string{
+	"mimetype": string(MimeODS),
+	"content.xml": `<office:document-content ` + odtNS + `><office:automatic-styles>` +
+		`<style:style style:name="ta2" style:family="table"><style:table-properties table:display="false"/></style:style>` +
+		`</office:automatic-styles><office:body><office:spreadsheet>` +
+		`<table:table table:name="Stock"><table:table-row>` +
+		`<table:table-cell office:value-type="string"><text:p>Item</text:p></table:table-cell>` +
+		`<table:table-cell office:value-type="string"><text:p>Count</text:p></table:table-cell></table:table-row>` +
+		`<table:table-row><table:table-cell office:value-type="string"><text:p>Nails</text:p></table:table-cell>` +
+		`<table:table-cell table:formula="of:=2*[.C2]" office:value-type="float" office:value="8"><text:p>8</text:p></table:table-cell>` +
+		`</table:table-row></table:table>` +
+		`<table:table table:name="Notes" table:style-name="ta2"><table:table-row>` +
+		`<table:table-cell office:value-type="string"><text:p>Draft</text:p></table:table-cell></table:table-row></table:table>` +
+		`</office:spreadsheet></office:body></office:document-content>`,
+	"meta.xml": `<office:document-meta ` + odtNS + `><office:meta><dc:title>Inventory</dc:title>` +
+		`<meta:initial-creator>Jane Doe</meta:initial-creator><meta:creation-date>2021-03-04T05:06:07</meta:creation-date>` +
+		`</office:meta></office:document-meta>`,
+}
+
+// TestConvertODSReaderToText tests the layouts of the sheets of an ods document
+func TestConvertODSReaderToText(t *testing.T) {
+	data := fixture.Zip(t, odsEntries)
+
+	// Test data
+	testData := []struct {
+		opts     []Option
+		expected string
+	}{
+		{nil, "# Stock\nItem | Count\nNails | 8\n"},
+		{[]Option{WithHiddenSheets(true)}, "# Stock\nItem | Count\nNails | 8\n# Notes\nDraft\n"},
+		{[]Option{WithSheetFormat(SheetFormatCSV), WithFormulas(true)}, "Item,Count\nNails,=2*[.C2]\n"},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		content, _, err := ConvertReader(bytes.NewReader(data), ODS, td.opts...)
+		if err != nil {
+			t.Fatalf("Error converting ODS: %s", err)
+		}
+		if content != td.expected {
+			t.Errorf("Expected content %q, got %q", td.expected, content)
+		}
+	}
+
+	// Compare metadata
+	_, metadata, err := ConvertODSReaderToText(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Error converting ODS: %s", err)
+	}
+	m := NewMetadata(metadata, nil)
+	if m.Title != "Inventory" || metadata["Author"] != "Jane Doe" || metadata["sheets"] != "2" {
+		t.Errorf("Unexpected metadata %v", metadata)
+	}
+	if m.Created.Format("2006-01-02") != "2021-03-04" {
+		t.Errorf("Unexpected creation date %s", m.Created)
+	}
+}
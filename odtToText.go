@@ -1,13 +1,38 @@
 package totext
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"time"
 
-	"code.sajari.com/docconv"
+	"github.com/pilinux/totext/internal/odf"
 )
 
+func init() {
+	RegisterConverter(ODT, MimeODT, ConverterFunc(ConvertOdtReaderToTextContext))
+	RegisterConverter(FODT, MimeFODT, ConverterFunc(ConvertOdtReaderToTextContext))
+	RegisterConverter(OTT, MimeOTT, ConverterFunc(ConvertOdtReaderToTextContext))
+}
+
+// odtDateLayouts are the layouts of the dates of the metadata
+var odtDateLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02"}
+
 // ConvertOdtToText receives odt filepath as an argument and returns its text content and metadata
+//
+// Flat fodt files and ott templates are read as well. The text content is
+// laid out as with ConvertDocxToText and the metadata holds every field of
+// meta.xml, keyed by the local name of its element, e.g. creator, or by the
+// name of the user-defined field.
 func ConvertOdtToText(filepath string) (content string, metadata map[string]string, err error) {
+	return ConvertOdtToTextContext(context.Background(), filepath)
+}
+
+// ConvertOdtToTextContext is like ConvertOdtToText but stops
+// the conversion when ctx is done
+func ConvertOdtToTextContext(ctx context.Context, filepath string, opts ...Option) (content string, metadata map[string]string, err error) {
 	// Get the odt file
 	odtFile, err := os.Open(filepath)
 	if err != nil {
@@ -18,13 +43,126 @@ func ConvertOdtToText(filepath string) (content string, metadata map[string]stri
 	}()
 
 	// Convert odt to text
-	content, metadata, err = docconv.ConvertODT(odtFile)
+	return ConvertOdtReaderToTextContext(ctx, odtFile, opts...)
+}
+
+// ConvertOdtReaderToText receives odt content as an io.Reader
+// and returns its text content and metadata
+//
+// The text content and the metadata are laid out as with ConvertOdtToText.
+func ConvertOdtReaderToText(r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertOdtReaderToTextContext(context.Background(), r, opts...)
+}
+
+// ConvertOdtReaderToTextContext is like ConvertOdtReaderToText
+// but stops the conversion when ctx is done
+func ConvertOdtReaderToTextContext(ctx context.Context, r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	markdown, err := newOptions(opts...).markdown()
+	if err != nil {
+		return "", nil, err
+	}
+
+	sections, metadata, err := ConvertOdtReaderToSectionsContext(ctx, r, opts...)
 	if err != nil {
 		return "", nil, err
 	}
 
-	// Filter out non-readable characters
-	content = FilterNonReadableCharacter(content)
+	return sectionsText(sections, markdown), metadata, nil
+}
+
+// ConvertOdtToSections receives odt filepath as an argument
+// and returns the text content of its body, headers, footers,
+// footnotes, endnotes and comments as separate sections, and its metadata
+func ConvertOdtToSections(filepath string, opts ...Option) (sections []Section, metadata map[string]string, err error) {
+	return ConvertOdtToSectionsContext(context.Background(), filepath, opts...)
+}
+
+// ConvertOdtToSectionsContext is like ConvertOdtToSections but stops
+// the conversion when ctx is done
+func ConvertOdtToSectionsContext(ctx context.Context, filepath string, opts ...Option) (sections []Section, metadata map[string]string, err error) {
+	// Get the odt file
+	odtFile, err := os.Open(filepath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_ = odtFile.Close()
+	}()
+
+	// Convert odt to sections
+	return ConvertOdtReaderToSectionsContext(ctx, odtFile, opts...)
+}
+
+// ConvertOdtReaderToSections receives odt content as an io.Reader
+// and returns the text content of its body, headers, footers,
+// footnotes, endnotes and comments as separate sections, and its metadata
+func ConvertOdtReaderToSections(r io.Reader, opts ...Option) (sections []Section, metadata map[string]string, err error) {
+	return ConvertOdtReaderToSectionsContext(context.Background(), r, opts...)
+}
+
+// ConvertOdtReaderToSectionsContext is like ConvertOdtReaderToSections
+// but stops the conversion when ctx is done
+func ConvertOdtReaderToSectionsContext(ctx context.Context, r io.Reader, opts ...Option) (sections []Section, metadata map[string]string, err error) {
+	if ctx.Err() != nil {
+		return nil, nil, contextError(ctx)
+	}
+
+	o := newOptions(opts...)
+	reject, err := o.rejectChanges()
+	if err != nil {
+		return nil, nil, err
+	}
+	markdown, err := o.markdown()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ra, err := sectionReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	doc, err := odf.Read(ctx, ra, ra.Size(), reject)
+	if ctx.Err() != nil {
+		return nil, nil, contextError(ctx)
+	}
+	if errors.Is(err, odf.ErrEncrypted) {
+		return nil, nil, fmt.Errorf("%w: %w", ErrEncrypted, err)
+	}
+	if err != nil {
+		return nil, nil, corruptError(err)
+	}
+
+	return documentSections(doc, markdown), odtMetadata(doc.Properties), nil
+}
+
+// odtMetadata returns the metadata of the meta.xml fields
+func odtMetadata(properties map[string]string) map[string]string {
+	metadata := make(map[string]string, len(properties)+5)
+	for key, value := range properties {
+		metadata[key] = value
+	}
+
+	// The creator is the author of the last revision
+	if author := metadata["creator"]; author != "" {
+		metadata["Author"] = author
+	} else if author := metadata["initial-creator"]; author != "" {
+		metadata["Author"] = author
+	}
+	if pages := metadata["page-count"]; pages != "" {
+		metadata["Pages"] = pages
+	}
+
+	// Convert dates to unix timestamps
+	for key, field := range map[string]string{
+		"CreatedDate":  "creation-date",
+		"ModifiedDate": "date",
+		"PrintedDate":  "print-date",
+	} {
+		if t, ok := parseTime(metadata[field], odtDateLayouts...); ok {
+			metadata[key] = fmt.Sprintf("%d", t.Unix())
+		}
+	}
 
-	return
+	return metadata
 }
@@ -0,0 +1,156 @@
+package totext
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pilinux/totext/internal/fixture"
+)
+
+// odtNS declares the namespaces of the OpenDocument test documents
+const odtNS = `xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" ` +
+	`xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" ` +
+	`xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" ` +
+	`xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" ` +
+	`xmlns:draw="urn:oasis:names:tc:opendocument:xmlns:drawing:1.0" ` +
+	`xmlns:presentation="urn:oasis:names:tc:opendocument:xmlns:presentation:1.0" ` +
+	`xmlns:meta="urn:oasis:names:tc:opendocument:xmlns:meta:1.0" ` +
+	`xmlns:dc="http://purl.org/dc/elements/1.1/"`
+
+// odtMeta is the metadata of the odt test documents
+const odtMeta = `<office:meta><dc:title>Survey</dc:title><meta:initial-creator>Jane Doe</meta:initial-creator>` +
+	`<meta:creation-date>2022-01-02T03:04:05</meta:creation-date>` +
+	`<meta:user-defined meta:name="Project">Apollo</meta:user-defined>` +
+	`<meta:document-statistic meta:page-count="2"/></office:meta>`
+
+// odtStyles are the list styles and the header of the odt test documents
+const odtStyles = `<office:automatic-styles><text:list-style style:name="L1">` +
+	`<text:list-level-style-number text:level="1" style:num-format="1" style:num-suffix="."/>` +
+	`<text:list-level-style-bullet text:level="2" text:bullet-char="-"/>` +
+	`</text:list-style></office:automatic-styles>` +
+	`<office:master-styles><style:master-page style:name="Standard">` +
+	`<style:header><text:p>Draft</text:p></style:header></style:master-page></office:master-styles>`
+
+// odtText is the text of the odt test documents with a heading, a list,
+// a table, a tracked change, a footnote and a comment
+const odtText = `<text:tracked-changes><text:changed-region text:id="c1"><text:deletion>` +
+	`<text:p>8</text:p></text:deletion></text:changed-region></text:tracked-changes>` +
+	`<text:h text:outline-level="2">Results</text:h>` +
+	`<text:p>See note<text:note text:note-class="footnote"><text:note-citation>1</text:note-citation>` +
+	`<text:note-body><text:p>Source: survey</text:p></text:note-body></text:note></text:p>` +
+	`<text:list text:style-name="L1"><text:list-item><text:p>First</text:p>` +
+	`<text:list><text:list-item><text:p>Nested</text:p></text:list-item></text:list></text:list-item></text:list>` +
+	`<table:table><table:table-row><table:table-cell><text:p>Name</text:p></table:table-cell>` +
+	`<table:table-cell><text:p>Score</text:p></table:table-cell></table:table-row>` +
+	`<table:table-row><table:table-cell><text:p>Ann</text:p></table:table-cell>` +
+	`<table:table-cell><text:p>9</text:p></table:table-cell></table:table-row></table:table>` +
+	`<text:p>Total <text:change text:change-id="c1"/>` +
+	`<office:annotation><dc:creator>Jane Doe</dc:creator><text:p>Verify</text:p></office:annotation></text:p>`
+
+// testOdt returns an odt package, or an ott template if mimetype is MimeOTT
+func testOdt(t *testing.T, mimetype MIME) []byte {
+	t.Helper()
+
+	return fixture.Zip(t, map[string]string{
+		"mimetype":   string(mimetype),
+		"meta.xml":   `<office:document-meta ` + odtNS + `>` + odtMeta + `</office:document-meta>`,
+		"styles.xml": `<office:document-styles ` + odtNS + `>` + odtStyles + `</office:document-styles>`,
+		"content.xml": `<office:document-content ` + odtNS + `>` + odtStyles +
+			`<office:body><office:text>` + odtText + `</office:text></office:body></office:document-content>`,
+	})
+}
+
+// testFodt returns a flat fodt document
+func testFodt() []byte {
+	return []byte(`<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+		`<office:document ` + odtNS + ` office:mimetype="application/vnd.oasis.opendocument.text">` +
+		odtMeta + odtStyles + `<office:body><office:text>` + odtText + `</office:text></office:body></office:document>`)
+}
+
+// TestConvertOdtReaderToText tests the structure of the odt text content
+func TestConvertOdtReaderToText(t *testing.T) {
+	const body = "## Results\nSee note[1]\n1. First\n  - Nested\nName | Score\nAnn | 9\n"
+	const notes = "[Header]\nDraft\n[Footnote 1]\nSource: survey\n[Comment 1 by Jane Doe]\nVerify\n"
+
+	// Test data
+	testData := []struct {
+		name     string
+		content  []byte
+		opts     []Option
+		expected string
+	}{
+		{"odt", testOdt(t, MimeODT), nil, body + "Total\n" + notes},
+		{"ott", testOdt(t, MimeOTT), nil, body + "Total\n" + notes},
+		{"fodt", testFodt(), nil, body + "Total\n" + notes},
+		{"rejected changes", testOdt(t, MimeODT), []Option{WithTrackedChanges(TrackedChangesReject)}, body + "Total 8\n" + notes},
+		{
+			"markdown",
+			testOdt(t, MimeODT),
+			[]Option{WithTextFormat(TextFormatMarkdown)},
+			"## Results\n\nSee note\\[1\\]\n\n1. First\n    - Nested\n\n| Name | Score |\n| --- | --- |\n| Ann | 9 |\n\nTotal\n\n" +
+				"\\[Header\\]\n\nDraft\n\n\\[Footnote 1\\]\n\nSource: survey\n\n\\[Comment 1 by Jane Doe\\]\n\nVerify\n",
+		},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		content, metadata, err := ConvertOdtReaderToText(bytes.NewReader(td.content), td.opts...)
+		if err != nil {
+			t.Fatalf("%s: %v", td.name, err)
+		}
+		if content != td.expected {
+			t.Errorf("%s: expected %q, got %q", td.name, td.expected, content)
+		}
+		if metadata["title"] != "Survey" || metadata["Author"] != "Jane Doe" || metadata["Project"] != "Apollo" ||
+			metadata["Pages"] != "2" || metadata["CreatedDate"] != "1641092645" {
+			t.Errorf("%s: unexpected metadata %v", td.name, metadata)
+		}
+	}
+}
+
+// TestConvertOdtReaderToSections tests the sections of an odt document
+func TestConvertOdtReaderToSections(t *testing.T) {
+	sections, _, err := ConvertOdtReaderToSections(bytes.NewReader(testOdt(t, MimeODT)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Section{
+		{Kind: SectionBody},
+		{Kind: SectionHeader, Text: "Draft\n"},
+		{Kind: SectionFootnote, ID: "1", Text: "Source: survey\n"},
+		{Kind: SectionComment, ID: "1", Author: "Jane Doe", Text: "Verify\n"},
+	}
+	if len(sections) != len(expected) {
+		t.Fatalf("expected %d sections, got %d", len(expected), len(sections))
+	}
+	for i, s := range sections {
+		if i == 0 {
+			if s.Kind != SectionBody || !strings.HasPrefix(s.Text, "## Results\n") {
+				t.Errorf("unexpected body %+v", s)
+			}
+			continue
+		}
+		if s != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], s)
+		}
+	}
+
+	_, _, err = ConvertOdtReaderToSections(strings.NewReader("not an odt"))
+	if !errors.Is(err, ErrCorrupt) {
+		t.Errorf("expected ErrCorrupt, got %v", err)
+	}
+}
+
+// TestConvertReaderFodt tests the conversion of a flat document by its format
+func TestConvertReaderFodt(t *testing.T) {
+	content, _, err := ConvertReader(bytes.NewReader(testFodt()), FODT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(content, "## Results\n") {
+		t.Errorf("unexpected content %q", content)
+	}
+}
@@ -0,0 +1,319 @@
+package totext
+
+import "fmt"
+
+// Options holds the settings used during a conversion
+type Options struct {
+	// Prettify runs prettier on HTML content before extracting its text
+	Prettify bool
+
+	// SkipPrettifyError ignores the errors returned by prettier
+	SkipPrettifyError bool
+
+	// Pages selects the pages of the documents which have pages, or
+	// the slides of presentations, all of them are converted if empty
+	Pages []PageRange
+
+	// PageBreaks separates the pages or the slides of the text
+	// content with form feeds
+	PageBreaks bool
+
+	// PDFBackend selects the PDF text extractor
+	PDFBackend PDFBackend
+
+	// RTFBackend selects the RTF text extractor
+	RTFBackend RTFBackend
+
+	// DocBackend selects the MS word doc text extractor
+	DocBackend DocBackend
+
+	// TrackedChanges selects how the tracked changes of documents
+	// are converted, they are accepted by default
+	TrackedChanges TrackedChanges
+
+	// TextFormat selects the format of the text content,
+	// plain text by default
+	TextFormat TextFormat
+
+	// Charset is the charset of HTML and text content,
+	// it is detected if empty
+	Charset string
+
+	// ContentType is the HTTP Content-Type header of HTML content,
+	// its charset is used if the content declares none
+	ContentType string
+
+	// JSONKeys selects the string values of JSON content by their
+	// key path, all the values are converted if empty
+	JSONKeys []string
+
+	// JSONKeyValues prefixes the string values of JSON content
+	// with their key path
+	JSONKeyValues bool
+
+	// SheetFormat selects the layout of the sheets of spreadsheets,
+	// text blocks by default
+	SheetFormat SheetFormat
+
+	// HiddenSheets includes the hidden sheets of spreadsheets
+	HiddenSheets bool
+
+	// Formulas renders the formulas of the cells of spreadsheets
+	// instead of their cached values
+	Formulas bool
+
+	// SpeakerNotes includes the speaker notes of the slides
+	// of presentations
+	SpeakerNotes bool
+
+	// warnings collects the non-fatal problems of the conversion
+	warnings *[]string
+}
+
+// TrackedChanges selects how the tracked changes of a document
+// are converted
+type TrackedChanges string
+
+const (
+	// TrackedChangesAccept converts the document as if all the
+	// changes were accepted, it is the default
+	TrackedChangesAccept TrackedChanges = "accept"
+	// TrackedChangesReject converts the document as if all the
+	// changes were rejected
+	TrackedChangesReject TrackedChanges = "reject"
+)
+
+// TextFormat is the format of the text content of a conversion
+type TextFormat string
+
+const (
+	// TextFormatPlain is plain text, it is the default
+	TextFormatPlain TextFormat = "txt"
+	// TextFormatMarkdown is Markdown with headings, lists, GFM tables,
+	// links, emphasis and code blocks. It is rendered from HTML, docx,
+	// odt, rtf, pages and epub documents, from the sheets of xlsx and ods
+	// spreadsheets and from the slides of pptx and odp presentations, the
+	// other formats are converted to plain text.
+	TextFormatMarkdown TextFormat = "md"
+)
+
+// SheetFormat is the layout of the sheets of spreadsheets
+type SheetFormat string
+
+const (
+	// SheetFormatText lays out each sheet as a text block with the
+	// sheet name and a table of its cells, it is the default
+	SheetFormatText SheetFormat = "text"
+	// SheetFormatTSV lays out each sheet as tab-separated values
+	SheetFormatTSV SheetFormat = "tsv"
+	// SheetFormatCSV lays out each sheet as comma-separated values
+	SheetFormatCSV SheetFormat = "csv"
+)
+
+// Option configures a conversion
+type Option func(*Options)
+
+// WithPrettify enables or disables prettifying HTML content
+// before extracting its text
+func WithPrettify(prettify bool) Option {
+	return func(o *Options) {
+		o.Prettify = prettify
+	}
+}
+
+// WithSkipPrettifyError ignores the errors returned by prettier
+func WithSkipPrettifyError(skip bool) Option {
+	return func(o *Options) {
+		o.SkipPrettifyError = skip
+	}
+}
+
+// WithPages converts only the given pages of the documents
+// which have pages, e.g. PDF, or the given slides of presentations
+func WithPages(ranges ...PageRange) Option {
+	return func(o *Options) {
+		o.Pages = ranges
+	}
+}
+
+// WithPageBreaks separates the pages or the slides of the
+// text content with form feeds
+func WithPageBreaks(pageBreaks bool) Option {
+	return func(o *Options) {
+		o.PageBreaks = pageBreaks
+	}
+}
+
+// WithPDFBackend selects the PDF text extractor, by default
+// poppler is used if it is installed
+func WithPDFBackend(backend PDFBackend) Option {
+	return func(o *Options) {
+		o.PDFBackend = backend
+	}
+}
+
+// WithRTFBackend selects the RTF text extractor, by default
+// the built-in parser is used
+func WithRTFBackend(backend RTFBackend) Option {
+	return func(o *Options) {
+		o.RTFBackend = backend
+	}
+}
+
+// WithDocBackend selects the MS word doc text extractor, by
+// default wv is used if it is installed
+func WithDocBackend(backend DocBackend) Option {
+	return func(o *Options) {
+		o.DocBackend = backend
+	}
+}
+
+// WithTrackedChanges selects how the tracked changes of documents,
+// e.g. docx, are converted, by default they are accepted
+func WithTrackedChanges(mode TrackedChanges) Option {
+	return func(o *Options) {
+		o.TrackedChanges = mode
+	}
+}
+
+// WithTextFormat selects the format of the text content,
+// by default it is plain text
+func WithTextFormat(format TextFormat) Option {
+	return func(o *Options) {
+		o.TextFormat = format
+	}
+}
+
+// WithCharset sets the charset of HTML and text content, e.g. shift_jis
+// or windows-1252, by default it is detected from the byte order mark,
+// the meta elements of HTML content, the Content-Type header given with
+// WithContentType and the bytes of the content
+func WithCharset(charset string) Option {
+	return func(o *Options) {
+		o.Charset = charset
+	}
+}
+
+// WithContentType sets the HTTP Content-Type header which HTML content
+// was served with, its charset is used if the content declares none
+func WithContentType(contentType string) Option {
+	return func(o *Options) {
+		o.ContentType = contentType
+	}
+}
+
+// WithJSONKeys converts only the string values of JSON content at the
+// key paths and below them, e.g. "title" or "items.*.name". The keys of
+// a path are separated with dots and * matches any key, the elements of
+// an array have the path of the array.
+func WithJSONKeys(paths ...string) Option {
+	return func(o *Options) {
+		o.JSONKeys = paths
+	}
+}
+
+// WithJSONKeyValues renders the string values of JSON content
+// as "key.path: value" lines
+func WithJSONKeyValues(keyValues bool) Option {
+	return func(o *Options) {
+		o.JSONKeyValues = keyValues
+	}
+}
+
+// WithSheetFormat selects the layout of the sheets of spreadsheets,
+// by default they are text blocks
+func WithSheetFormat(format SheetFormat) Option {
+	return func(o *Options) {
+		o.SheetFormat = format
+	}
+}
+
+// WithHiddenSheets includes the hidden sheets of spreadsheets,
+// by default they are left out
+func WithHiddenSheets(hidden bool) Option {
+	return func(o *Options) {
+		o.HiddenSheets = hidden
+	}
+}
+
+// WithFormulas renders the formulas of the cells of spreadsheets, e.g.
+// =SUM(A1:A3), instead of their cached values. The cells without
+// formula keep their value.
+func WithFormulas(formulas bool) Option {
+	return func(o *Options) {
+		o.Formulas = formulas
+	}
+}
+
+// WithSpeakerNotes includes the speaker notes of the slides of
+// presentations, by default they are left out
+func WithSpeakerNotes(notes bool) Option {
+	return func(o *Options) {
+		o.SpeakerNotes = notes
+	}
+}
+
+// WithWarnings collects the non-fatal problems of the conversion into w,
+// e.g. the fallback to another text extractor
+func WithWarnings(w *[]string) Option {
+	return func(o *Options) {
+		o.warnings = w
+	}
+}
+
+// warn records a non-fatal problem of the conversion
+func (o *Options) warn(format string, args ...any) {
+	if o.warnings != nil {
+		*o.warnings = append(*o.warnings, fmt.Sprintf(format, args...))
+	}
+}
+
+// rejectChanges reports whether the tracked changes are rejected
+func (o *Options) rejectChanges() (bool, error) {
+	switch o.TrackedChanges {
+	case TrackedChangesReject:
+		return true, nil
+	case "", TrackedChangesAccept:
+		return false, nil
+	}
+
+	return false, fmt.Errorf("unknown tracked changes mode %q", o.TrackedChanges)
+}
+
+// markdown reports whether the text content is rendered in Markdown
+func (o *Options) markdown() (bool, error) {
+	switch o.TextFormat {
+	case TextFormatMarkdown:
+		return true, nil
+	case "", TextFormatPlain:
+		return false, nil
+	}
+
+	return false, fmt.Errorf("unknown text format %q", o.TextFormat)
+}
+
+// sheetFormat returns the layout of the sheets of spreadsheets
+func (o *Options) sheetFormat() (SheetFormat, error) {
+	switch o.SheetFormat {
+	case "", SheetFormatText:
+		return SheetFormatText, nil
+	case SheetFormatTSV, SheetFormatCSV:
+		return o.SheetFormat, nil
+	}
+
+	return "", fmt.Errorf("unknown sheet format %q", o.SheetFormat)
+}
+
+// newOptions returns the default options overridden by opts
+func newOptions(opts ...Option) *Options {
+	o := &Options{
+		Prettify: true,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+
+	return o
+}
@@ -0,0 +1,173 @@
+package totext
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Page is the text content of a single page of a document
+type Page struct {
+	// Number is the page number, starting at 1
+	Number int
+	// Text is the text content of the page
+	Text string
+}
+
+// PageRange is an inclusive range of page numbers.
+// A Last of 0 means the last page of the document.
+type PageRange struct {
+	First int
+	Last  int
+}
+
+// String returns the range in the format accepted by ParsePageRanges
+func (pr PageRange) String() string {
+	switch {
+	case pr.Last == 0:
+		return fmt.Sprintf("%d-", pr.First)
+	case pr.First == pr.Last:
+		return strconv.Itoa(pr.First)
+	default:
+		return fmt.Sprintf("%d-%d", pr.First, pr.Last)
+	}
+}
+
+// ParsePageRanges parses a comma separated list of page numbers and
+// page ranges, e.g. "3-10,15" or "20-" for the page 20 to the end
+func ParsePageRanges(s string) ([]PageRange, error) {
+	var ranges []PageRange
+
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		first, last, isRange := strings.Cut(part, "-")
+		pr, err := parsePageRange(first, last, isRange)
+		if err != nil {
+			return nil, fmt.Errorf("invalid page range %q", part)
+		}
+		ranges = append(ranges, pr)
+	}
+
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("invalid page range %q", s)
+	}
+
+	return ranges, nil
+}
+
+// parsePageRange parses the bounds of a single page range
+func parsePageRange(first, last string, isRange bool) (PageRange, error) {
+	var pr PageRange
+	var err error
+
+	if pr.First, err = strconv.Atoi(strings.TrimSpace(first)); err != nil || pr.First < 1 {
+		return pr, fmt.Errorf("invalid first page")
+	}
+
+	switch {
+	case !isRange:
+		pr.Last = pr.First
+	case strings.TrimSpace(last) == "":
+		// Open ended range
+		pr.Last = 0
+	default:
+		if pr.Last, err = strconv.Atoi(strings.TrimSpace(last)); err != nil || pr.Last < pr.First {
+			return pr, fmt.Errorf("invalid last page")
+		}
+	}
+
+	return pr, nil
+}
+
+// normalizePageRanges clamps the ranges to the page count, sorts them
+// and merges the overlapping ones, so that every page is selected once.
+// No ranges select all the pages.
+func normalizePageRanges(ranges []PageRange, pageCount int) []PageRange {
+	if len(ranges) == 0 {
+		ranges = []PageRange{{First: 1}}
+	}
+
+	clamped := make([]PageRange, 0, len(ranges))
+	for _, pr := range ranges {
+		if pr.Last == 0 || pr.Last > pageCount {
+			pr.Last = pageCount
+		}
+		if pr.First < 1 || pr.First > pr.Last {
+			continue
+		}
+		clamped = append(clamped, pr)
+	}
+
+	sort.Slice(clamped, func(i, j int) bool {
+		return clamped[i].First < clamped[j].First
+	})
+
+	var merged []PageRange
+	for _, pr := range clamped {
+		if n := len(merged); n > 0 && pr.First <= merged[n-1].Last+1 {
+			merged[n-1].Last = max(merged[n-1].Last, pr.Last)
+			continue
+		}
+		merged = append(merged, pr)
+	}
+
+	return merged
+}
+
+// pageWriter splits the text written to it at form feeds
+// and calls fn with every filtered page
+type pageWriter struct {
+	number int
+	buf    bytes.Buffer
+	fn     func(Page) error
+	err    error
+}
+
+// Write buffers the text of the current page and
+// calls fn when the page is complete
+func (pw *pageWriter) Write(p []byte) (int, error) {
+	if pw.err != nil {
+		return 0, pw.err
+	}
+
+	n := len(p)
+	for {
+		i := bytes.IndexByte(p, '\f')
+		if i < 0 {
+			pw.buf.Write(p)
+			return n, nil
+		}
+		pw.buf.Write(p[:i])
+		if err := pw.flush(); err != nil {
+			return 0, err
+		}
+		p = p[i+1:]
+	}
+}
+
+// Close calls fn with the text left after the last form feed
+func (pw *pageWriter) Close() error {
+	if pw.err != nil || pw.buf.Len() == 0 {
+		return pw.err
+	}
+	return pw.flush()
+}
+
+// flush calls fn with the current page and starts the next one
+func (pw *pageWriter) flush() error {
+	page := Page{
+		Number: pw.number,
+		Text:   FilterNonReadableCharacter(pw.buf.String()),
+	}
+	pw.number++
+	pw.buf.Reset()
+
+	pw.err = pw.fn(page)
+	return pw.err
+}
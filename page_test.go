@@ -0,0 +1,81 @@
+package totext
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestParsePageRanges tests ParsePageRanges function
+func TestParsePageRanges(t *testing.T) {
+	// Test data
+	testData := []struct {
+		input    string
+		expected string
+		valid    bool
+	}{
+		{"3-10,15", "[3-10 15]", true},
+		{" 1 , 4 - 6 ", "[1 4-6]", true},
+		{"20-", "[20-]", true},
+		{"", "", false},
+		{"0", "", false},
+		{"5-3", "", false},
+		{"a-b", "", false},
+		{"1,,x", "", false},
+	}
+
+	// Iterate over test data
+	for _, data := range testData {
+		ranges, err := ParsePageRanges(data.input)
+		if (err == nil) != data.valid {
+			t.Errorf("%q: unexpected error %v", data.input, err)
+			continue
+		}
+		if data.valid && fmt.Sprint(ranges) != data.expected {
+			t.Errorf("%q: expected %s, got %v", data.input, data.expected, ranges)
+		}
+	}
+}
+
+// TestNormalizePageRanges tests normalizePageRanges function
+func TestNormalizePageRanges(t *testing.T) {
+	// Test data
+	testData := []struct {
+		ranges   []PageRange
+		expected string
+	}{
+		{nil, "[1-12]"},
+		{[]PageRange{{15, 15}, {3, 10}}, "[3-10]"},
+		{[]PageRange{{5, 8}, {1, 2}, {3, 6}, {11, 0}}, "[1-8 11-12]"},
+		{[]PageRange{{20, 0}}, "[]"},
+	}
+
+	// Iterate over test data
+	for _, data := range testData {
+		if ranges := normalizePageRanges(data.ranges, 12); fmt.Sprint(ranges) != data.expected {
+			t.Errorf("%v: expected %s, got %v", data.ranges, data.expected, ranges)
+		}
+	}
+}
+
+// TestPageWriter tests that pageWriter splits the text at form feeds
+func TestPageWriter(t *testing.T) {
+	var pages []Page
+	pw := &pageWriter{number: 3, fn: func(page Page) error {
+		pages = append(pages, page)
+		return nil
+	}}
+
+	for _, chunk := range []string{"first\n\n", "page\fsec", "ond page\f\f", "last"} {
+		if _, err := pw.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "[{3 first\npage} {4 second page} {5 } {6 last}]"
+	if fmt.Sprint(pages) != expected {
+		t.Errorf("Expected %s, got %v", expected, pages)
+	}
+}
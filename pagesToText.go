@@ -1,27 +1,136 @@
 package totext
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"strings"
 
-	"code.sajari.com/docconv"
+	"github.com/pilinux/totext/internal/pages"
 )
 
+func init() {
+	RegisterConverter(PAGES, MimePAGES, ConverterFunc(ConvertPagesReaderToTextContext))
+}
+
 // ConvertPagesToText receives pages filepath as an argument and returns its text content and metadata
+//
+// The text storages of the document are read by the built-in reader.
+// When they cannot be decoded, the text is read from the preview of
+// the document: QuickLook/Preview.pdf, converted like any PDF file, or
+// preview.jpg, which needs tesseract.
+//
+// Debian/Ubuntu: sudo apt install tesseract-ocr
+//
+// MacOS: brew install tesseract
 func ConvertPagesToText(filepath string) (content string, metadata map[string]string, err error) {
+	return ConvertPagesToTextContext(context.Background(), filepath)
+}
+
+// ConvertPagesToTextContext is like ConvertPagesToText but stops
+// the conversion when ctx is done
+func ConvertPagesToTextContext(ctx context.Context, filepath string, opts ...Option) (content string, metadata map[string]string, err error) {
 	// Get the pages file
 	pagesFile, err := os.Open(filepath)
 	if err != nil {
 		return "", nil, err
 	}
+	defer func() {
+		_ = pagesFile.Close()
+	}()
 
 	// Convert pages to text
-	content, metadata, err = docconv.ConvertPages(pagesFile)
+	return ConvertPagesReaderToTextContext(ctx, pagesFile, opts...)
+}
+
+// ConvertPagesReaderToText receives pages content as an io.Reader
+// and returns its text content and metadata
+//
+// The text is read as with ConvertPagesToText.
+func ConvertPagesReaderToText(r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertPagesReaderToTextContext(context.Background(), r, opts...)
+}
+
+// ConvertPagesReaderToTextContext is like ConvertPagesReaderToText
+// but stops the conversion and kills tesseract when ctx is done
+func ConvertPagesReaderToTextContext(ctx context.Context, r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	if ctx.Err() != nil {
+		return "", nil, contextError(ctx)
+	}
+
+	markdown, err := newOptions(opts...).markdown()
+	if err != nil {
+		return "", nil, err
+	}
+
+	ra, err := sectionReader(r)
 	if err != nil {
 		return "", nil, err
 	}
 
-	// Filter out non-readable characters
-	content = FilterNonReadableCharacter(content)
+	doc, err := pages.Read(ctx, ra, ra.Size())
+	if ctx.Err() != nil {
+		return "", nil, contextError(ctx)
+	}
+	switch {
+	case err == nil:
+		return sectionsText(documentSections(doc, markdown), markdown), doc.Properties, nil
+	case errors.Is(err, pages.ErrEncrypted):
+		return "", nil, fmt.Errorf("%w: %w", ErrEncrypted, err)
+	case errors.Is(err, pages.ErrNotPages):
+		return "", nil, corruptError(err)
+	}
+
+	// The properties of the document are kept when its text cannot be decoded
+	properties := make(map[string]string)
+	if doc != nil {
+		properties = doc.Properties
+	}
+
+	// Read the text from the preview instead
+	content, metadata, previewErr := pagesPreviewText(ctx, ra, opts...)
+	switch {
+	case previewErr == nil:
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+		newOptions(opts...).warn("%v, text read from the preview", err)
+		for key, value := range properties {
+			metadata[key] = value
+		}
+		return content, metadata, nil
+	case errors.Is(previewErr, pages.ErrNoPreview) && errors.Is(err, pages.ErrNoText):
+		// The document has no text
+		return "", properties, nil
+	case errors.Is(previewErr, pages.ErrNoPreview):
+		return "", nil, corruptError(err)
+	default:
+		return "", nil, previewErr
+	}
+}
+
+// pagesPreviewText returns the text content and the metadata of
+// the preview of the Pages document
+func pagesPreviewText(ctx context.Context, ra *io.SectionReader, opts ...Option) (content string, metadata map[string]string, err error) {
+	name, rc, err := pages.Preview(ra, ra.Size())
+	if err != nil {
+		return "", nil, err
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	if strings.HasSuffix(name, ".pdf") {
+		return ConvertPDFReaderToTextContext(ctx, rc, opts...)
+	}
+
+	// Read the text of the image with tesseract
+	output, err := runCommand(ctx, "tesseract", rc, "tesseract", "stdin", "stdout")
+	if err != nil {
+		return "", nil, err
+	}
 
-	return
+	return FilterNonReadableCharacter(string(output)), make(map[string]string), nil
 }
@@ -0,0 +1,128 @@
+package totext
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/pilinux/totext/internal/fixture"
+)
+
+// testPagesIWA returns the Index/Document.iwa file of a Pages document
+// with a body text storage, compressed as a single snappy literal
+func testPagesIWA(text string) []byte {
+	var storage []byte
+	storage = protowire.AppendTag(storage, 1, protowire.VarintType)
+	storage = protowire.AppendVarint(storage, 0)
+	storage = protowire.AppendTag(storage, 3, protowire.BytesType)
+	storage = protowire.AppendString(storage, text)
+
+	var messageInfo []byte
+	messageInfo = protowire.AppendTag(messageInfo, 1, protowire.VarintType)
+	messageInfo = protowire.AppendVarint(messageInfo, 2001)
+	messageInfo = protowire.AppendTag(messageInfo, 3, protowire.VarintType)
+	messageInfo = protowire.AppendVarint(messageInfo, uint64(len(storage)))
+
+	var archiveInfo []byte
+	archiveInfo = protowire.AppendTag(archiveInfo, 1, protowire.VarintType)
+	archiveInfo = protowire.AppendVarint(archiveInfo, 1)
+	archiveInfo = protowire.AppendTag(archiveInfo, 2, protowire.BytesType)
+	archiveInfo = protowire.AppendBytes(archiveInfo, messageInfo)
+
+	stream := protowire.AppendVarint(nil, uint64(len(archiveInfo)))
+	stream = append(stream, archiveInfo...)
+	stream = append(stream, storage...)
+
+	// A literal of up to 256 bytes has its length in the byte after the tag
+	block := protowire.AppendVarint(nil, uint64(len(stream)))
+	block = append(block, 60<<2, byte(len(stream)-1))
+	block = append(block, stream...)
+
+	return append([]byte{0, byte(len(block)), 0, 0}, block...)
+}
+
+// TestConvertPagesReaderToText tests the text storages of a Pages
+// document and the fallback to its preview
+func TestConvertPagesReaderToText(t *testing.T) {
+	// Test data
+	testData := []struct {
+		name     string
+		entries  map[string]string
+		expected string
+		warnings int
+	}{
+		{
+			"text storage",
+			map[string]string{
+				"Index/Document.iwa":    string(testPagesIWA("Title\nFirst paragraph with enough text to need a long literal")),
+				"QuickLook/Preview.pdf": testPDF,
+			},
+			"Title\nFirst paragraph with enough text to need a long literal\n",
+			0,
+		},
+		{
+			"preview",
+			map[string]string{
+				"Index/Document.iwa":    "\x00\x05\x00\x00broken",
+				"QuickLook/Preview.pdf": testPDF,
+			},
+			"First page\nSecond page\n",
+			1,
+		},
+		{
+			"empty",
+			map[string]string{"Index/Document.iwa": ""},
+			"",
+			0,
+		},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		var warnings []string
+		content, metadata, err := ConvertPagesReaderToText(bytes.NewReader(fixture.Zip(t, td.entries)),
+			WithPDFBackend(PDFBackendNative), WithWarnings(&warnings))
+		if err != nil {
+			t.Fatalf("%s: %v", td.name, err)
+		}
+		if content != td.expected || metadata == nil {
+			t.Errorf("%s: expected %q, got %q", td.name, td.expected, content)
+		}
+		if len(warnings) != td.warnings {
+			t.Errorf("%s: unexpected warnings %v", td.name, warnings)
+		}
+	}
+}
+
+// TestConvertPagesReaderToTextErrors tests the errors of invalid Pages documents
+func TestConvertPagesReaderToTextErrors(t *testing.T) {
+	// Test data
+	testData := []struct {
+		name     string
+		content  []byte
+		expected error
+	}{
+		{"not a zip", []byte("not a pages file"), ErrCorrupt},
+		{"broken without preview", fixture.Zip(t, map[string]string{"Index/Document.iwa": "broken"}), ErrCorrupt},
+		{"encrypted", fixture.Zip(t, map[string]string{".iwph": "hint", "Index/Document.iwa": ""}), ErrEncrypted},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		_, _, err := ConvertPagesReaderToText(bytes.NewReader(td.content))
+		if !errors.Is(err, td.expected) {
+			t.Errorf("%s: expected %v, got %v", td.name, td.expected, err)
+		}
+	}
+
+	// The image preview is read with tesseract
+	if _, ok := lookPath("tesseract"); !ok {
+		content := fixture.Zip(t, map[string]string{"preview.jpg": "not a jpeg"})
+		_, _, err := ConvertPagesReaderToText(bytes.NewReader(content))
+		if !errors.Is(err, ErrMissingDependency{Tool: "tesseract"}) {
+			t.Errorf("expected missing tesseract, got %v", err)
+		}
+	}
+}
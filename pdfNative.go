@@ -0,0 +1,92 @@
+package totext
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/pilinux/totext/internal/pdf"
+)
+
+// pdfNativeText writes the text of the selected pages of the PDF
+// content to w with the built-in extractor and returns the metadata
+func pdfNativeText(ctx context.Context, r io.Reader, w io.Writer, o *Options) (map[string]string, error) {
+	first := true
+	return pdfNativePages(ctx, r, o, func(page Page) error {
+		// Mark the boundaries of the pages
+		if o.PageBreaks && !first {
+			if _, err := io.WriteString(w, "\f"); err != nil {
+				return err
+			}
+		}
+		first = false
+		_, err := io.WriteString(w, page.Text)
+		return err
+	})
+}
+
+// pdfNativePages extracts the text of the selected pages of the PDF
+// content with the built-in extractor, calls fn with every page
+// and returns the metadata
+func pdfNativePages(ctx context.Context, r io.Reader, o *Options, fn func(Page) error) (map[string]string, error) {
+	ra, err := sectionReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := pdf.NewReader(ra, ra.Size())
+	if err != nil {
+		return nil, pdfNativeError(err)
+	}
+
+	for _, pr := range normalizePageRanges(o.Pages, doc.NumPage()) {
+		for n := pr.First; n <= pr.Last; n++ {
+			text, err := doc.PageText(ctx, n)
+			if ctx.Err() != nil {
+				return nil, contextError(ctx)
+			}
+			if err != nil {
+				// The other pages may still be readable
+				o.warn("page %d: %v", n, err)
+			}
+
+			page := Page{Number: n, Text: FilterNonReadableCharacter(text)}
+			if err = fn(page); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return pdfNativeInfo(doc), nil
+}
+
+// pdfNativeInfo returns the metadata of the PDF document with
+// the keys printed by pdfinfo
+func pdfNativeInfo(doc *pdf.Reader) map[string]string {
+	metadata := doc.Info()
+	metadata["Pages"] = strconv.Itoa(doc.NumPage())
+	metadata["PDF version"] = doc.Version()
+	metadata["Encrypted"] = "no"
+
+	// Convert dates to unix timestamps
+	if t, ok := pdf.ParseDate(metadata["ModDate"]); ok {
+		metadata["ModDate"] = t.UTC().Format(pdfTimeLayouts[1])
+		metadata["ModifiedDate"] = fmt.Sprintf("%d", t.Unix())
+	}
+	if t, ok := pdf.ParseDate(metadata["CreationDate"]); ok {
+		metadata["CreationDate"] = t.UTC().Format(pdfTimeLayouts[1])
+		metadata["CreatedDate"] = fmt.Sprintf("%d", t.Unix())
+	}
+
+	return metadata
+}
+
+// pdfNativeError classifies the error of the built-in extractor
+func pdfNativeError(err error) error {
+	if errors.Is(err, pdf.ErrEncrypted) {
+		return fmt.Errorf("%w: %w", ErrEncrypted, err)
+	}
+	return corruptError(err)
+}
@@ -0,0 +1,71 @@
+package totext
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testPDF is a PDF document with two pages and without
+// a cross-reference table
+const testPDF = `%PDF-1.4
+1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj
+2 0 obj << /Type /Pages /Kids [3 0 R 4 0 R] /Count 2
+/Resources << /Font << /F1 << /Type /Font /Subtype /Type1 /BaseFont /Helvetica >> >> >> >> endobj
+3 0 obj << /Type /Page /Parent 2 0 R /Contents 5 0 R >> endobj
+4 0 obj << /Type /Page /Parent 2 0 R /Contents 6 0 R >> endobj
+5 0 obj << /Length 44 >> stream
+BT /F1 12 Tf 72 720 Td (First page) Tj ET
+endstream endobj
+6 0 obj << /Length 45 >> stream
+BT /F1 12 Tf 72 720 Td (Second page) Tj ET
+endstream endobj
+7 0 obj << /Title (Native) /ModDate (D:20240102030405Z) >> endobj
+trailer << /Root 1 0 R /Info 7 0 R >>
+%%EOF
+`
+
+// TestPDFNativeBackend tests the built-in PDF extractor
+func TestPDFNativeBackend(t *testing.T) {
+	// Test data
+	testData := []struct {
+		opts     []Option
+		expected string
+	}{
+		{nil, "First page\nSecond page\n"},
+		{[]Option{WithPageBreaks(true)}, "First page\n\fSecond page\n"},
+		{[]Option{WithPages(PageRange{First: 2})}, "Second page\n"},
+	}
+
+	// Iterate over test data
+	for _, data := range testData {
+		opts := append([]Option{WithPDFBackend(PDFBackendNative)}, data.opts...)
+		content, metadata, err := ConvertPDFReaderToTextContext(context.Background(), strings.NewReader(testPDF), opts...)
+		if err != nil {
+			t.Errorf("unexpected error %v", err)
+			continue
+		}
+		if content != data.expected {
+			t.Errorf("expected %q, got %q", data.expected, content)
+		}
+		if metadata["Title"] != "Native" || metadata["Pages"] != "2" || metadata["ModifiedDate"] != "1704164645" {
+			t.Errorf("unexpected metadata %v", metadata)
+		}
+	}
+
+	pages, _, err := ConvertPDFReaderToPages(strings.NewReader(testPDF), WithPDFBackend(PDFBackendNative))
+	if err != nil || len(pages) != 2 || pages[1].Number != 2 || pages[1].Text != "Second page\n" {
+		t.Errorf("unexpected pages %v (%v)", pages, err)
+	}
+
+	_, _, err = ConvertPDFReaderToText(strings.NewReader("not a pdf"), WithPDFBackend(PDFBackendNative))
+	if !errors.Is(err, ErrCorrupt) {
+		t.Errorf("expected ErrCorrupt, got %v", err)
+	}
+
+	_, _, err = ConvertPDFReaderToText(strings.NewReader(testPDF), WithPDFBackend("other"))
+	if err == nil {
+		t.Error("expected an error for an unknown backend")
+	}
+}
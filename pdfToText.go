@@ -1,11 +1,37 @@
 package totext
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterConverter(PDF, MimePDF, StreamConverterFunc(ConvertPDFReaderToWriterContext))
+}
+
+// PDFBackend is a PDF text extractor
+type PDFBackend string
 
-	"code.sajari.com/docconv"
+const (
+	// PDFBackendAuto uses poppler if pdftotext and pdfinfo are
+	// installed, the built-in extractor otherwise
+	PDFBackendAuto PDFBackend = ""
+	// PDFBackendPoppler uses pdftotext and pdfinfo
+	PDFBackendPoppler PDFBackend = "poppler"
+	// PDFBackendNative uses the built-in extractor written in Go
+	PDFBackendNative PDFBackend = "native"
 )
 
+// pdfTimeLayouts are the date layouts printed by pdfinfo
+var pdfTimeLayouts = []string{time.ANSIC, "Mon Jan _2 15:04:05 2006 MST"}
+
 // ConvertPDFToText receives pdf filepath as an argument and returns its text content and metadata
 //
 // Dependencies:
@@ -13,7 +39,15 @@ import (
 // Debian/Ubuntu: sudo apt install poppler-utils
 //
 // MacOS: brew install poppler
+//
+// Without poppler the built-in extractor is used, see WithPDFBackend
 func ConvertPDFToText(filepath string) (content string, metadata map[string]string, err error) {
+	return ConvertPDFToTextContext(context.Background(), filepath)
+}
+
+// ConvertPDFToTextContext is like ConvertPDFToText but stops
+// the conversion when ctx is done
+func ConvertPDFToTextContext(ctx context.Context, filepath string, opts ...Option) (content string, metadata map[string]string, err error) {
 	// Get the PDF file
 	pdfFile, err := os.Open(filepath)
 	if err != nil {
@@ -24,13 +58,299 @@ func ConvertPDFToText(filepath string) (content string, metadata map[string]stri
 	}()
 
 	// Convert PDF to text
-	content, metadata, err = docconv.ConvertPDF(pdfFile)
+	return ConvertPDFReaderToTextContext(ctx, pdfFile, opts...)
+}
+
+// ConvertPDFReaderToText receives pdf content as an io.Reader
+// and returns its text content and metadata
+//
+// The external tool reads from a file, so r is copied to a
+// temporary file unless it is an *os.File. The built-in extractor
+// reads r directly if it implements io.ReaderAt and Size.
+//
+// Dependencies:
+//
+// Debian/Ubuntu: sudo apt install poppler-utils
+//
+// MacOS: brew install poppler
+//
+// Without poppler the built-in extractor is used, see WithPDFBackend
+func ConvertPDFReaderToText(r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertPDFReaderToTextContext(context.Background(), r, opts...)
+}
+
+// ConvertPDFReaderToTextContext is like ConvertPDFReaderToText but
+// kills pdftotext and pdfinfo when ctx is done
+func ConvertPDFReaderToTextContext(ctx context.Context, r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	return StreamConverterFunc(ConvertPDFReaderToWriterContext).ConvertReader(ctx, r, opts...)
+}
+
+// ConvertPDFReaderToWriter receives pdf content as an io.Reader,
+// writes its text content to w while pdftotext produces it
+// and returns its metadata
+//
+// w may have received part of the text when an error is returned
+//
+// Dependencies:
+//
+// Debian/Ubuntu: sudo apt install poppler-utils
+//
+// MacOS: brew install poppler
+//
+// Without poppler the built-in extractor is used, see WithPDFBackend
+func ConvertPDFReaderToWriter(r io.Reader, w io.Writer, opts ...Option) (metadata map[string]string, err error) {
+	return ConvertPDFReaderToWriterContext(context.Background(), r, w, opts...)
+}
+
+// ConvertPDFReaderToWriterContext is like ConvertPDFReaderToWriter but
+// kills pdftotext and pdfinfo when ctx is done
+func ConvertPDFReaderToWriterContext(ctx context.Context, r io.Reader, w io.Writer, opts ...Option) (metadata map[string]string, err error) {
+	if ctx.Err() != nil {
+		return nil, contextError(ctx)
+	}
+
+	o := newOptions(opts...)
+	native, err := o.pdfNative()
 	if err != nil {
-		return "", nil, err
+		return nil, err
+	}
+	if native {
+		return pdfNativeText(ctx, r, w, o)
+	}
+
+	// pdftotext and pdfinfo read from a file
+	path, done, err := localFile(r)
+	if err != nil {
+		return nil, err
 	}
+	defer done()
 
-	// Filter out non-readable characters
-	content = FilterNonReadableCharacter(content)
+	// Convert the selected pages and mark their boundaries
+	if len(o.Pages) > 0 || o.PageBreaks {
+		first := true
+		return pdfPages(ctx, path, o.Pages, func(page Page) error {
+			if o.PageBreaks && !first {
+				if _, err := io.WriteString(w, "\f"); err != nil {
+					return err
+				}
+			}
+			first = false
+			_, err := io.WriteString(w, page.Text)
+			return err
+		})
+	}
+
+	// Extract metadata and text concurrently
+	metaErr := make(chan error, 1)
+	go func() {
+		var e error
+		metadata, e = pdfInfo(ctx, path)
+		metaErr <- e
+	}()
+
+	// Convert PDF to text, filtering out non-readable characters
+	fw := NewFilterWriter(w)
+	err = streamCommand(ctx, "pdftotext", nil, fw, "pdftotext", "-q", "-nopgbrk", "-enc", "UTF-8", "-eol", "unix", path, "-")
+	if err == nil {
+		err = fw.Close()
+	}
+	// pdftotext runs quietly, pdfinfo reports why the file could not be opened
+	if e := <-metaErr; e != nil && (err == nil || errors.Is(e, ErrEncrypted)) {
+		err = e
+	}
+	if err != nil {
+		return nil, pdfError(err)
+	}
+
+	return metadata, nil
+}
+
+// ConvertPDFToPages receives pdf filepath as an argument and returns
+// the text content of its pages and its metadata.
+// The pages are selected with WithPages.
+//
+// Dependencies:
+//
+// Debian/Ubuntu: sudo apt install poppler-utils
+//
+// MacOS: brew install poppler
+//
+// Without poppler the built-in extractor is used, see WithPDFBackend
+func ConvertPDFToPages(filepath string, opts ...Option) (pages []Page, metadata map[string]string, err error) {
+	return ConvertPDFToPagesContext(context.Background(), filepath, opts...)
+}
+
+// ConvertPDFToPagesContext is like ConvertPDFToPages but stops
+// the conversion when ctx is done
+func ConvertPDFToPagesContext(ctx context.Context, filepath string, opts ...Option) (pages []Page, metadata map[string]string, err error) {
+	// Get the PDF file
+	pdfFile, err := os.Open(filepath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_ = pdfFile.Close()
+	}()
+
+	// Convert PDF to pages
+	return ConvertPDFReaderToPagesContext(ctx, pdfFile, opts...)
+}
+
+// ConvertPDFReaderToPages receives pdf content as an io.Reader and
+// returns the text content of its pages and its metadata.
+// The pages are selected with WithPages.
+//
+// Dependencies:
+//
+// Debian/Ubuntu: sudo apt install poppler-utils
+//
+// MacOS: brew install poppler
+//
+// Without poppler the built-in extractor is used, see WithPDFBackend
+func ConvertPDFReaderToPages(r io.Reader, opts ...Option) (pages []Page, metadata map[string]string, err error) {
+	return ConvertPDFReaderToPagesContext(context.Background(), r, opts...)
+}
+
+// ConvertPDFReaderToPagesContext is like ConvertPDFReaderToPages but
+// kills pdftotext and pdfinfo when ctx is done
+func ConvertPDFReaderToPagesContext(ctx context.Context, r io.Reader, opts ...Option) (pages []Page, metadata map[string]string, err error) {
+	metadata, err = ConvertPDFReaderToPagesFunc(ctx, r, func(page Page) error {
+		pages = append(pages, page)
+		return nil
+	}, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pages, metadata, nil
+}
+
+// ConvertPDFReaderToPagesFunc receives pdf content as an io.Reader,
+// calls fn with every page in order as soon as its text is extracted
+// and returns the metadata. The pages are selected with WithPages.
+// The conversion stops with the error returned by fn.
+func ConvertPDFReaderToPagesFunc(ctx context.Context, r io.Reader, fn func(Page) error, opts ...Option) (metadata map[string]string, err error) {
+	if ctx.Err() != nil {
+		return nil, contextError(ctx)
+	}
+
+	o := newOptions(opts...)
+	native, err := o.pdfNative()
+	if err != nil {
+		return nil, err
+	}
+	if native {
+		return pdfNativePages(ctx, r, o, fn)
+	}
+
+	// pdftotext and pdfinfo read from a file
+	path, done, err := localFile(r)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	return pdfPages(ctx, path, o.Pages, fn)
+}
+
+// pdfNative reports whether the built-in PDF extractor is used
+func (o *Options) pdfNative() (bool, error) {
+	switch o.PDFBackend {
+	case PDFBackendNative:
+		return true, nil
+	case PDFBackendPoppler:
+		return false, nil
+	case PDFBackendAuto:
+		_, pdftotext := lookPath("pdftotext")
+		_, pdfinfo := lookPath("pdfinfo")
+		return !pdftotext || !pdfinfo, nil
+	}
+
+	return false, fmt.Errorf("unknown PDF backend %q", o.PDFBackend)
+}
+
+// pdfPages extracts the text of the selected pages of the PDF file,
+// calls fn with every page and returns the metadata
+func pdfPages(ctx context.Context, path string, ranges []PageRange, fn func(Page) error) (map[string]string, error) {
+	// The page count is needed to resolve open ended ranges
+	metadata, err := pdfInfo(ctx, path)
+	if err != nil {
+		return nil, pdfError(err)
+	}
+	pageCount, err := strconv.Atoi(metadata["Pages"])
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid page count %q", ErrCorrupt, metadata["Pages"])
+	}
+
+	// pdftotext ends every page with a form feed
+	for _, pr := range normalizePageRanges(ranges, pageCount) {
+		pw := &pageWriter{number: pr.First, fn: fn}
+		err = streamCommand(ctx, "pdftotext", nil, pw, "pdftotext", "-q",
+			"-f", strconv.Itoa(pr.First), "-l", strconv.Itoa(pr.Last),
+			"-enc", "UTF-8", "-eol", "unix", path, "-")
+		if err == nil {
+			err = pw.Close()
+		}
+		if pw.err != nil {
+			// The error returned by fn
+			return nil, pw.err
+		}
+		if err != nil {
+			return nil, pdfError(err)
+		}
+	}
+
+	return metadata, nil
+}
+
+// pdfError classifies the error of pdftotext or pdfinfo
+func pdfError(err error) error {
+	// pdftotext exits with 3 when the permissions do not allow copying text
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 3 {
+		return fmt.Errorf("%w: %w", ErrEncrypted, err)
+	}
+	return corruptError(err)
+}
+
+// pdfInfo returns the metadata of the PDF file printed by pdfinfo
+func pdfInfo(ctx context.Context, path string) (map[string]string, error) {
+	info, err := runCommand(ctx, "pdfinfo", nil, "pdfinfo", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse pdfinfo output
+	metadata := make(map[string]string)
+	for _, line := range strings.Split(string(info), "\n") {
+		if parts := strings.SplitN(line, ":", 2); len(parts) > 1 {
+			metadata[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+
+	// Convert dates to unix timestamps
+	if t, ok := parseTime(metadata["ModDate"], pdfTimeLayouts...); ok {
+		metadata["ModifiedDate"] = fmt.Sprintf("%d", t.Unix())
+	}
+	if t, ok := parseTime(metadata["CreationDate"], pdfTimeLayouts...); ok {
+		metadata["CreatedDate"] = fmt.Sprintf("%d", t.Unix())
+	}
+
+	return metadata, nil
+}
+
+// parseTime parses value with the first matching layout
+func parseTime(value string, layouts ...string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
 
-	return
+	return time.Time{}, false
 }
@@ -0,0 +1,116 @@
+package totext
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/pilinux/totext/internal/pptx"
+	"github.com/pilinux/totext/internal/structure"
+)
+
+func init() {
+	RegisterConverter(PPTX, MimePPTX, ConverterFunc(ConvertPPTXReaderToTextContext))
+}
+
+// ConvertPPTXToText receives MS powerpoint pptx filepath as an argument
+// and returns its text content and metadata
+//
+// The slides are written in the order of the presentation, each one as
+// its title followed by the text of its shapes, the shapes of its groups
+// and its tables as with ConvertDocxToText. The slide numbers, dates,
+// headers and footers are left out. The speaker notes follow their slide
+// as a [Notes] section with WithSpeakerNotes. The slides are selected
+// with WithPages and separated with form feeds with WithPageBreaks. The
+// metadata holds the core properties of the presentation and the number
+// of its slides.
+func ConvertPPTXToText(filepath string, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertPPTXToTextContext(context.Background(), filepath, opts...)
+}
+
+// ConvertPPTXToTextContext is like ConvertPPTXToText but stops
+// the conversion when ctx is done
+func ConvertPPTXToTextContext(ctx context.Context, filepath string, opts ...Option) (content string, metadata map[string]string, err error) {
+	// Get the pptx file
+	pptxFile, err := os.Open(filepath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer func() {
+		_ = pptxFile.Close()
+	}()
+
+	// Convert pptx to text
+	return ConvertPPTXReaderToTextContext(ctx, pptxFile, opts...)
+}
+
+// ConvertPPTXReaderToText receives MS powerpoint pptx content as an
+// io.Reader and returns its text content and metadata
+//
+// The text content and the metadata are laid out as with ConvertPPTXToText.
+func ConvertPPTXReaderToText(r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertPPTXReaderToTextContext(context.Background(), r, opts...)
+}
+
+// ConvertPPTXReaderToTextContext is like ConvertPPTXReaderToText
+// but stops the conversion when ctx is done
+func ConvertPPTXReaderToTextContext(ctx context.Context, r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	slides, metadata, err := ConvertPPTXReaderToSlidesContext(ctx, r, opts...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return slidesText(slides, newOptions(opts...)), metadata, nil
+}
+
+// ConvertPPTXToSlides receives MS powerpoint pptx filepath as an argument
+// and returns the text content of its slides in the order of the
+// presentation, and its metadata
+func ConvertPPTXToSlides(filepath string, opts ...Option) (slides []Slide, metadata map[string]string, err error) {
+	return ConvertPPTXToSlidesContext(context.Background(), filepath, opts...)
+}
+
+// ConvertPPTXToSlidesContext is like ConvertPPTXToSlides but stops
+// the conversion when ctx is done
+func ConvertPPTXToSlidesContext(ctx context.Context, filepath string, opts ...Option) (slides []Slide, metadata map[string]string, err error) {
+	// Get the pptx file
+	pptxFile, err := os.Open(filepath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_ = pptxFile.Close()
+	}()
+
+	// Convert pptx to slides
+	return ConvertPPTXReaderToSlidesContext(ctx, pptxFile, opts...)
+}
+
+// ConvertPPTXReaderToSlides receives MS powerpoint pptx content as an
+// io.Reader and returns the text content of its slides in the order of
+// the presentation, and its metadata
+func ConvertPPTXReaderToSlides(r io.Reader, opts ...Option) (slides []Slide, metadata map[string]string, err error) {
+	return ConvertPPTXReaderToSlidesContext(context.Background(), r, opts...)
+}
+
+// ConvertPPTXReaderToSlidesContext is like ConvertPPTXReaderToSlides
+// but stops the conversion when ctx is done
+func ConvertPPTXReaderToSlidesContext(ctx context.Context, r io.Reader, opts ...Option) (slides []Slide, metadata map[string]string, err error) {
+	if ctx.Err() != nil {
+		return nil, nil, contextError(ctx)
+	}
+
+	ra, err := sectionReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	slides, doc, err := presentationSlides(ctx, func(ctx context.Context) (*structure.Document, error) {
+		return pptx.Read(ctx, ra, ra.Size())
+	}, newOptions(opts...))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return slides, slideMetadata(docxMetadata(doc.Properties), doc), nil
+}
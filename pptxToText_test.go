@@ -0,0 +1,115 @@
+package totext
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pilinux/totext/internal/fixture"
+)
+
+// pptxNS declares the namespaces of the pptx test presentations
+const pptxNS = `xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main" ` +
+	`xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" ` +
+	`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"`
+
+// pptxShape returns a shape with the text in the placeholder
+func pptxShape(placeholder, text string) string {
+	return `<p:sp><p:nvSpPr><p:cNvPr id="2" name="Shape"/><p:cNvSpPr/><p:nvPr><p:ph type="` + placeholder + `"/></p:nvPr></p:nvSpPr>` +
+		`<p:txBody><a:bodyPr/><a:p><a:r><a:t>` + text + `</a:t></a:r></a:p></p:txBody></p:sp>`
+}
+
+// pptxSlide returns a slide with the attributes and the shapes
+func pptxSlide(attrs, shapes string) string {
+	return `<p:sld ` + pptxNS + attrs + `><p:cSld><p:spTree>` + shapes + `</p:spTree></p:cSld></p:sld>`
+}
+
+// pptxEntries are the entries of a pptx presentation with three
+// slides, the first one has speaker notes and the second one is hidden
+var pptxEntries = map[string]string{
+	"[Content_Types].xml": `<Types><Override ContentType="application/vnd.openxmlformats-officedocument.presentationml.presentation.main+xml"/></Types>`,
+	"_rels/.rels": opcRels(opcRel("rId1", "officeDocument/2006/relationships/officeDocument", "ppt/presentation.xml"),
+		opcRel("rId2", "package/2006/relationships/metadata/core-properties", "docProps/core.xml")),
+	"docProps/core.xml": opcCore(`<dc:title>Roadmap</dc:title><dc:creator>Jane Doe</dc:creator>` +
+		`<dcterms:created>2021-03-04T05:06:07Z</dcterms:created>`),
+	"ppt/presentation.xml": `<p:presentation ` + pptxNS + `><p:sldIdLst><p:sldId id="256" r:id="rId1"/>` +
+		`<p:sldId id="257" r:id="rId2"/><p:sldId id="258" r:id="rId3"/></p:sldIdLst></p:presentation>`,
+	"ppt/_rels/presentation.xml.rels": opcRels(opcRel("rId1", "officeDocument/2006/relationships/slide", "slides/slide1.xml"),
+		opcRel("rId2", "officeDocument/2006/relationships/slide", "slides/slide2.xml"),
+		opcRel("rId3", "officeDocument/2006/relationships/slide", "slides/slide3.xml")),
+	"ppt/slides/slide1.xml":            pptxSlide(``, pptxShape("title", "Roadmap")+pptxShape("body", "Ship")),
+	"ppt/slides/_rels/slide1.xml.rels": opcRels(opcRel("rId1", "officeDocument/2006/relationships/notesSlide", "../notesSlides/notesSlide1.xml")),
+	"ppt/notesSlides/notesSlide1.xml": `<p:notes ` + pptxNS + `><p:cSld><p:spTree>` + pptxShape("sldImg", "") +
+		pptxShape("body", "Greet the audience") + `</p:spTree></p:cSld></p:notes>`,
+	"ppt/slides/slide2.xml": pptxSlide(` show="0"`, pptxShape("title", "Backup")),
+	"ppt/slides/slide3.xml": pptxSlide(``, pptxShape("title", "Goals")),
+}
+
+// TestConvertPPTXReaderToText tests the layouts of the slides of a presentation
+func TestConvertPPTXReaderToText(t *testing.T) {
+	data := fixture.Zip(t, pptxEntries)
+
+	// Test data
+	testData := []struct {
+		opts     []Option
+		expected string
+	}{
+		{nil, "# Roadmap\n• Ship\n# Backup\n# Goals\n"},
+		{[]Option{WithSpeakerNotes(true)}, "# Roadmap\n• Ship\n[Notes]\nGreet the audience\n# Backup\n# Goals\n"},
+		{[]Option{WithTextFormat(TextFormatMarkdown), WithSpeakerNotes(true)},
+			"# Roadmap\n\n- Ship\n\n\\[Notes\\]\n\nGreet the audience\n\n# Backup\n\n# Goals\n"},
+		{[]Option{WithPages(PageRange{First: 3}, PageRange{First: 1, Last: 1}), WithPageBreaks(true)},
+			"# Roadmap\n• Ship\n\f# Goals\n"},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		content, _, err := ConvertReader(bytes.NewReader(data), PPTX, td.opts...)
+		if err != nil {
+			t.Fatalf("Error converting PPTX: %s", err)
+		}
+		if content != td.expected {
+			t.Errorf("Expected content %q, got %q", td.expected, content)
+		}
+	}
+
+	// Compare metadata
+	_, metadata, err := ConvertPPTXReaderToText(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Error converting PPTX: %s", err)
+	}
+	m := NewMetadata(metadata, nil)
+	if m.Title != "Roadmap" || strings.Join(m.Authors, "|") != "Jane Doe" || m.PageCount != 3 ||
+		metadata["slides"] != "3" || metadata["hidden-slides"] != "1" {
+		t.Errorf("Unexpected metadata %v", metadata)
+	}
+	if m.Created.Format("2006-01-02") != "2021-03-04" {
+		t.Errorf("Unexpected creation date %s", m.Created)
+	}
+}
+
+// TestConvertPPTXReaderToSlides tests the slides of a presentation
+func TestConvertPPTXReaderToSlides(t *testing.T) {
+	slides, _, err := ConvertPPTXReaderToSlides(bytes.NewReader(fixture.Zip(t, pptxEntries)), WithSpeakerNotes(true))
+	if err != nil {
+		t.Fatalf("Error converting PPTX: %s", err)
+	}
+
+	// Test data
+	testData := []Slide{
+		{1, "Roadmap", false, "# Roadmap\n• Ship\n", "Greet the audience\n"},
+		{2, "Backup", true, "# Backup\n", ""},
+		{3, "Goals", false, "# Goals\n", ""},
+	}
+
+	if len(slides) != len(testData) {
+		t.Fatalf("Expected %d slides, got %d", len(testData), len(slides))
+	}
+
+	// Iterate over test data
+	for i, expected := range testData {
+		if slides[i] != expected {
+			t.Errorf("Expected slide %+v, got %+v", expected, slides[i])
+		}
+	}
+}
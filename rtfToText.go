@@ -1,19 +1,74 @@
 package totext
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"strings"
+	"time"
 
-	"code.sajari.com/docconv"
+	"github.com/pilinux/totext/internal/rtf"
+	"github.com/pilinux/totext/internal/structure"
 )
 
+func init() {
+	RegisterConverter(RTF, MimeRTF, StreamConverterFunc(ConvertRTFReaderToWriterContext))
+}
+
+// RTFBackend is an RTF text extractor
+type RTFBackend string
+
+const (
+	// RTFBackendAuto uses the built-in parser, and unrtf for the
+	// documents the parser rejects if unrtf is installed
+	RTFBackendAuto RTFBackend = ""
+	// RTFBackendUnrtf uses unrtf
+	RTFBackendUnrtf RTFBackend = "unrtf"
+	// RTFBackendNative uses the built-in parser written in Go
+	RTFBackendNative RTFBackend = "native"
+)
+
+// unrtfTimeLayout is the layout of the dates printed by unrtf
+const unrtfTimeLayout = "02 January 2006 15:04"
+
+// rtfInfoKeys are the metadata keys of the fields of the \info group
+var rtfInfoKeys = map[string]string{
+	"title":      "Title",
+	"subject":    "Subject",
+	"author":     "Author",
+	"manager":    "Manager",
+	"company":    "Company",
+	"operator":   "Operator",
+	"category":   "Category",
+	"keywords":   "Keywords",
+	"comment":    "Comment",
+	"doccomm":    "Comments",
+	"hlinkbase":  "HyperlinkBase",
+	"version":    "Version",
+	"edmins":     "EditingMinutes",
+	"nofpages":   "Pages",
+	"nofwords":   "Words",
+	"nofchars":   "Characters",
+	"nofcharsws": "CharactersWithSpaces",
+}
+
 // ConvertRTFToText receives rtf filepath as an argument and returns its text content and metadata
 //
-// Dependencies:
+// The built-in parser is used, unrtf is an optional fallback, see WithRTFBackend.
 //
 // Debian/Ubuntu: sudo apt install unrtf
 //
 // MacOS: brew install unrtf
 func ConvertRTFToText(filepath string) (content string, metadata map[string]string, err error) {
+	return ConvertRTFToTextContext(context.Background(), filepath)
+}
+
+// ConvertRTFToTextContext is like ConvertRTFToText but stops
+// the conversion when ctx is done
+func ConvertRTFToTextContext(ctx context.Context, filepath string, opts ...Option) (content string, metadata map[string]string, err error) {
 	// Get the rtf file
 	rtfFile, err := os.Open(filepath)
 	if err != nil {
@@ -24,13 +79,165 @@ func ConvertRTFToText(filepath string) (content string, metadata map[string]stri
 	}()
 
 	// Convert rtf to text
-	content, metadata, err = docconv.ConvertRTF(rtfFile)
+	return ConvertRTFReaderToTextContext(ctx, rtfFile, opts...)
+}
+
+// ConvertRTFReaderToText receives rtf content as an io.Reader
+// and returns its text content and metadata
+//
+// The built-in parser is used, unrtf is an optional fallback, see WithRTFBackend.
+//
+// Debian/Ubuntu: sudo apt install unrtf
+//
+// MacOS: brew install unrtf
+func ConvertRTFReaderToText(r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertRTFReaderToTextContext(context.Background(), r, opts...)
+}
+
+// ConvertRTFReaderToTextContext is like ConvertRTFReaderToText but
+// stops the conversion and kills unrtf when ctx is done
+func ConvertRTFReaderToTextContext(ctx context.Context, r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	return StreamConverterFunc(ConvertRTFReaderToWriterContext).ConvertReader(ctx, r, opts...)
+}
+
+// ConvertRTFReaderToWriter receives rtf content as an io.Reader,
+// writes its text content to w while it is parsed and returns its metadata
+//
+// w may have received part of the text when an error is returned
+func ConvertRTFReaderToWriter(r io.Reader, w io.Writer, opts ...Option) (metadata map[string]string, err error) {
+	return ConvertRTFReaderToWriterContext(context.Background(), r, w, opts...)
+}
+
+// ConvertRTFReaderToWriterContext is like ConvertRTFReaderToWriter but
+// stops the conversion and kills unrtf when ctx is done
+func ConvertRTFReaderToWriterContext(ctx context.Context, r io.Reader, w io.Writer, opts ...Option) (metadata map[string]string, err error) {
+	if ctx.Err() != nil {
+		return nil, contextError(ctx)
+	}
+
+	o := newOptions(opts...)
+	markdown, err := o.markdown()
 	if err != nil {
-		return "", nil, err
+		return nil, err
+	}
+	switch o.RTFBackend {
+	case RTFBackendUnrtf:
+		if markdown {
+			o.warn("unrtf does not keep the structure, plain text written")
+		}
+		return unrtfText(ctx, r, w)
+	case RTFBackendAuto, RTFBackendNative:
+	default:
+		return nil, fmt.Errorf("unknown RTF backend %q", o.RTFBackend)
+	}
+
+	// The parser does not read br when it rejects the content,
+	// so that unrtf can read it. Markdown is rendered from the
+	// structure of the whole document.
+	br := bufio.NewReader(r)
+	fw := NewFilterWriter(w)
+	var doc *structure.Document
+	var info *rtf.Info
+	if markdown {
+		doc, info, err = rtf.Read(ctx, br)
+	} else {
+		info, err = rtf.Convert(ctx, br, fw)
+	}
+	if errors.Is(err, rtf.ErrNotRTF) && o.RTFBackend == RTFBackendAuto {
+		if _, ok := lookPath("unrtf"); ok {
+			o.warn("%v, converted with unrtf", err)
+			return unrtfText(ctx, br, w)
+		}
+	}
+	if ctx.Err() != nil {
+		return nil, contextError(ctx)
+	}
+	if err != nil {
+		return nil, corruptError(err)
+	}
+	if markdown {
+		_, err = io.WriteString(w, sectionsText(documentSections(doc, true), true))
+	} else {
+		err = fw.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return rtfInfo(info), nil
+}
+
+// rtfInfo returns the metadata of the \info group
+func rtfInfo(info *rtf.Info) map[string]string {
+	metadata := make(map[string]string, len(info.Fields)+3)
+	for field, value := range info.Fields {
+		if key, ok := rtfInfoKeys[field]; ok {
+			metadata[key] = value
+		}
+	}
+
+	// Convert dates to unix timestamps
+	dates := []struct {
+		key string
+		t   time.Time
+	}{
+		{"CreatedDate", info.Created},
+		{"ModifiedDate", info.Revised},
+		{"PrintedDate", info.Printed},
+	}
+	for _, date := range dates {
+		if !date.t.IsZero() {
+			metadata[date.key] = fmt.Sprintf("%d", date.t.Unix())
+		}
+	}
+
+	return metadata
+}
+
+// unrtfText converts the rtf content to text with unrtf,
+// writes the text to w and returns the metadata
+func unrtfText(ctx context.Context, r io.Reader, w io.Writer) (metadata map[string]string, err error) {
+	// unrtf reads from a file
+	path, done, err := localFile(r)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	// Convert rtf to text
+	output, err := runCommand(ctx, "unrtf", nil, "unrtf", "--nopict", "--text", path)
+	if err != nil {
+		return nil, corruptError(err)
+	}
+
+	// Step through content looking for metadata and stripping out comments
+	var body strings.Builder
+	metadata = make(map[string]string)
+	for _, line := range strings.Split(string(output), "\n") {
+		if parts := strings.SplitN(line, ":", 2); len(parts) > 1 {
+			metadata[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+		if !strings.HasPrefix(line, "### ") {
+			body.WriteString(line)
+			body.WriteString("\n")
+		}
+	}
+
+	// Identify metadata
+	if author, ok := metadata["AUTHOR"]; ok {
+		metadata["Author"] = author
+	}
+	if t, ok := parseTime(metadata["### creation date"], unrtfTimeLayout); ok {
+		metadata["CreatedDate"] = fmt.Sprintf("%d", t.Unix())
+	}
+	if t, ok := parseTime(metadata["### revision date"], unrtfTimeLayout); ok {
+		metadata["ModifiedDate"] = fmt.Sprintf("%d", t.Unix())
 	}
 
 	// Filter out non-readable characters
-	content = FilterNonReadableCharacter(content)
+	if _, err = io.WriteString(w, FilterNonReadableCharacter(body.String())); err != nil {
+		return nil, err
+	}
 
-	return
+	return metadata, nil
 }
@@ -0,0 +1,56 @@
+package totext
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestConvertRTFReaderToText tests the built-in RTF parser
+func TestConvertRTFReaderToText(t *testing.T) {
+	doc := `{\rtf1\ansi{\info{\title Report}{\author Jane Doe}{\creatim\yr2019\mo6\dy5\hr10\min33}\nofpages2}` +
+		`First\par\par\par Second \'e9\u8364?\par}`
+
+	content, metadata, err := ConvertRTFReaderToText(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != "First\nSecond é€\n" {
+		t.Errorf("unexpected content %q", content)
+	}
+	if metadata["Title"] != "Report" || metadata["Author"] != "Jane Doe" ||
+		metadata["Pages"] != "2" || metadata["CreatedDate"] != "1559730780" {
+		t.Errorf("unexpected metadata %v", metadata)
+	}
+
+	// The table cells and the tabs are not merged by the filter
+	table := `{\rtf1\ansi Name\tab Value\par\trowd\cellx1000\cellx2000 col\cell b\cell\row}`
+	content, _, err = ConvertRTFReaderToText(strings.NewReader(table), WithRTFBackend(RTFBackendNative))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != "Name Value\ncol | b\n" {
+		t.Errorf("unexpected content %q", content)
+	}
+
+	md := `{\rtf1\ansi{\pard\outlinelevel0 Title\par}\pard Some {\b bold} text*\par` +
+		`{\listtext 1.\tab}\pard\ls1 One\par\pard\intbl A\cell B\cell\row}`
+	content, _, err = ConvertRTFReaderToText(strings.NewReader(md), WithTextFormat(TextFormatMarkdown))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "# Title\n\nSome **bold** text\\*\n\n1. One\n\n| A | B |\n| --- | --- |\n"
+	if content != expected {
+		t.Errorf("expected %q, got %q", expected, content)
+	}
+
+	_, _, err = ConvertRTFReaderToText(strings.NewReader("plain text"), WithRTFBackend(RTFBackendNative))
+	if !errors.Is(err, ErrCorrupt) {
+		t.Errorf("expected ErrCorrupt, got %v", err)
+	}
+
+	_, _, err = ConvertRTFReaderToText(strings.NewReader(doc), WithRTFBackend("other"))
+	if err == nil {
+		t.Error("expected an error for an unknown backend")
+	}
+}
@@ -0,0 +1,161 @@
+package totext
+
+import (
+	"strings"
+
+	"github.com/pilinux/totext/internal/structure"
+)
+
+// SectionKind is the kind of a section of a document
+type SectionKind string
+
+const (
+	// SectionBody is the main text of the document
+	SectionBody SectionKind = "body"
+	// SectionHeader is a page header
+	SectionHeader SectionKind = "header"
+	// SectionFooter is a page footer
+	SectionFooter SectionKind = "footer"
+	// SectionFootnote is a footnote
+	SectionFootnote SectionKind = "footnote"
+	// SectionEndnote is an endnote
+	SectionEndnote SectionKind = "endnote"
+	// SectionComment is a reviewer comment
+	SectionComment SectionKind = "comment"
+	// SectionNotes are the speaker notes of a slide
+	SectionNotes SectionKind = "notes"
+)
+
+// Section is the text content of a part of a document, e.g.
+// its body, a header or a footnote
+type Section struct {
+	Kind SectionKind
+	// ID is the number of a note or the id of a comment
+	ID string
+	// Author is the author of a comment
+	Author string
+	// Text is the text content of the section
+	Text string
+}
+
+// label returns the line which introduces the section in
+// the text content of the document, e.g. "[Footnote 1]"
+func (s Section) label() string {
+	label := string(s.Kind)
+	if label != "" {
+		label = strings.ToUpper(label[:1]) + label[1:]
+	}
+	if s.ID != "" {
+		label += " " + s.ID
+	}
+	if s.Author != "" {
+		label += " by " + s.Author
+	}
+
+	return "[" + label + "]"
+}
+
+// sectionsText returns the text content of the sections. The body comes
+// first and every other section is introduced by its label, which is a
+// paragraph of its own in Markdown.
+func sectionsText(sections []Section, markdown bool) string {
+	var text strings.Builder
+	for _, s := range sections {
+		switch {
+		case s.Kind == SectionBody:
+		case markdown:
+			if text.Len() > 0 {
+				text.WriteString("\n")
+			}
+			text.WriteString(escapeMarkdown(s.label(), true) + "\n\n")
+		default:
+			text.WriteString(s.label() + "\n")
+		}
+		text.WriteString(s.Text)
+	}
+
+	return text.String()
+}
+
+// documentSections returns the sections of the document in plain text
+// or in Markdown, the headers and footers repeated by the sections are
+// left out
+func documentSections(doc *structure.Document, markdown bool) []Section {
+	render, filter := blocksText, FilterNonReadableCharacter
+	if markdown {
+		render, filter = blocksMarkdown, filterMarkdown
+	}
+
+	// Filter out non-readable characters
+	sections := []Section{{Kind: SectionBody, Text: filter(render(doc.Body))}}
+
+	seen := make(map[Section]bool)
+	for _, parts := range []struct {
+		kind   SectionKind
+		blocks [][]structure.Block
+	}{
+		{SectionHeader, doc.Headers},
+		{SectionFooter, doc.Footers},
+	} {
+		for _, blocks := range parts.blocks {
+			s := Section{Kind: parts.kind, Text: filter(render(blocks))}
+			if strings.TrimSpace(s.Text) == "" || seen[s] {
+				continue
+			}
+			seen[s] = true
+			sections = append(sections, s)
+		}
+	}
+
+	for _, notes := range []struct {
+		kind  SectionKind
+		notes []structure.Note
+	}{
+		{SectionFootnote, doc.Footnotes},
+		{SectionEndnote, doc.Endnotes},
+		{SectionComment, doc.Comments},
+	} {
+		for _, note := range notes.notes {
+			sections = append(sections, Section{
+				Kind:   notes.kind,
+				ID:     note.ID,
+				Author: note.Author,
+				Text:   filter(strings.TrimLeft(render(note.Blocks), " ")),
+			})
+		}
+	}
+
+	return sections
+}
+
+// blocksText returns the text content of the blocks. Headings are
+// prefixed with # for every level, list items are indented and prefixed
+// with their label and the cells of the table rows are separated with " | ".
+func blocksText(blocks []structure.Block) string {
+	var text strings.Builder
+	for _, b := range blocks {
+		switch b.Kind {
+		case structure.Heading:
+			text.WriteString(strings.Repeat("#", b.Level) + " ")
+		case structure.ListItem:
+			text.WriteString(strings.Repeat("  ", b.Level))
+		case structure.Table:
+			for _, row := range b.Rows {
+				cells := make([]string, len(row))
+				for i, cell := range row {
+					cells[i] = strings.Join(strings.Fields(blocksText(cell)), " ")
+				}
+				text.WriteString(strings.Join(cells, " | ") + "\n")
+			}
+			continue
+		}
+
+		if b.Label != "" {
+			text.WriteString(b.Label + " ")
+		}
+		// Tabs are not kept by the filter of non-readable characters
+		text.WriteString(strings.ReplaceAll(b.Text, "\t", " ") + "\n")
+	}
+
+	return text.String()
+}
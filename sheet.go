@@ -0,0 +1,202 @@
+package totext
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pilinux/totext/internal/sheet"
+	"github.com/pilinux/totext/internal/structure"
+)
+
+// Sheet is the text content of a sheet of a spreadsheet
+type Sheet struct {
+	// Number is the number of the sheet in the workbook, starting at 1,
+	// the hidden sheets included
+	Number int
+	// Name is the name of the sheet
+	Name string
+	// Hidden is set for the sheets which are not displayed
+	Hidden bool
+	// Text is the text content of the sheet
+	Text string
+}
+
+// maxSheetColumns limits the columns of the text content of a sheet,
+// as many as LibreOffice had. A stray cell far to the right would
+// otherwise add thousands of empty cells to its row and to the
+// tables and the comma-separated values.
+const maxSheetColumns = 1024
+
+// tsvReplacer replaces the separators of tab-separated values in the cells
+var tsvReplacer = strings.NewReplacer("\r\n", " ", "\t", " ", "\n", " ", "\r", " ")
+
+// workbookSheets returns the text content of the sheets of the workbook
+// read by read, laid out as selected by the options
+func workbookSheets(ctx context.Context, read func(ctx context.Context) (*sheet.Workbook, error), o *Options) ([]Sheet, *sheet.Workbook, error) {
+	format, err := o.sheetFormat()
+	if err != nil {
+		return nil, nil, err
+	}
+	markdown, err := o.markdown()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wb, err := read(ctx)
+	if ctx.Err() != nil {
+		return nil, nil, contextError(ctx)
+	}
+	if errors.Is(err, sheet.ErrEncrypted) {
+		return nil, nil, fmt.Errorf("%w: %w", ErrEncrypted, err)
+	}
+	if err != nil {
+		return nil, nil, corruptError(err)
+	}
+
+	var sheets []Sheet
+	for i, s := range wb.Sheets {
+		if s.Hidden && !o.HiddenSheets {
+			continue
+		}
+		// The formulas replace the cached values of their cells,
+		// the cells after the last column are left out
+		rows := make([][]string, len(s.Rows))
+		columns := 0
+		for r, row := range s.Rows {
+			if len(row) > maxSheetColumns {
+				columns = max(columns, len(row))
+				row = row[:maxSheetColumns]
+			}
+			rows[r] = make([]string, len(row))
+			for c, cell := range row {
+				rows[r][c] = cell.Value
+				if o.Formulas && cell.Formula != "" {
+					rows[r][c] = cell.Formula
+				}
+			}
+		}
+		if columns > 0 {
+			o.warn("sheet %q: the cells after column %d of %d are left out", s.Name, maxSheetColumns, columns)
+			rows = trimRows(rows)
+		}
+
+		text, err := sheetText(s.Name, rows, format, markdown)
+		if err != nil {
+			return nil, nil, err
+		}
+		sheets = append(sheets, Sheet{
+			Number: i + 1,
+			Name:   s.Name,
+			Hidden: s.Hidden,
+			Text:   text,
+		})
+	}
+
+	return sheets, wb, nil
+}
+
+// trimRows returns the rows without the empty cells at
+// their end and without the empty rows at the end
+func trimRows(rows [][]string) [][]string {
+	for i, row := range rows {
+		for len(row) > 0 && row[len(row)-1] == "" {
+			row = row[:len(row)-1]
+		}
+		rows[i] = row
+	}
+	for len(rows) > 0 && len(rows[len(rows)-1]) == 0 {
+		rows = rows[:len(rows)-1]
+	}
+	return rows
+}
+
+// sheetText returns the text content of the rows of a sheet. The text
+// blocks start with the name of the sheet as a heading followed by a table
+// of the cells, the tab-separated and comma-separated values have no name.
+func sheetText(name string, rows [][]string, format SheetFormat, markdown bool) (string, error) {
+	switch format {
+	case SheetFormatTSV:
+		var text strings.Builder
+		for _, row := range rows {
+			cells := make([]string, len(row))
+			for i, cell := range row {
+				cells[i] = tsvReplacer.Replace(cell)
+			}
+			text.WriteString(strings.Join(cells, "\t") + "\n")
+		}
+		return text.String(), nil
+	case SheetFormatCSV:
+		var text strings.Builder
+		w := csv.NewWriter(&text)
+		// The rows of CSV content have the same number of fields
+		width := 0
+		for _, row := range rows {
+			width = max(width, len(row))
+		}
+		for _, row := range rows {
+			record := append(row, make([]string, width-len(row))...)
+			if err := w.Write(record); err != nil {
+				return "", err
+			}
+		}
+		w.Flush()
+		return text.String(), w.Error()
+	}
+
+	blocks := []structure.Block{{Kind: structure.Heading, Level: 1, Text: name}}
+	if len(rows) > 0 {
+		table := structure.Block{Kind: structure.Table, Rows: make([][]structure.Cell, len(rows))}
+		for r, row := range rows {
+			table.Rows[r] = make([]structure.Cell, len(row))
+			for c, value := range row {
+				if value != "" {
+					table.Rows[r][c] = structure.Cell{{Text: value}}
+				}
+			}
+		}
+		blocks = append(blocks, table)
+	}
+
+	if markdown {
+		return filterMarkdown(blocksMarkdown(blocks)), nil
+	}
+	return FilterNonReadableCharacter(blocksText(blocks)), nil
+}
+
+// sheetsText joins the text content of the sheets. The text blocks of
+// Markdown content are separated with a blank line, the tab-separated
+// and comma-separated values of the sheets with a form feed.
+func sheetsText(sheets []Sheet, o *Options) string {
+	texts := make([]string, len(sheets))
+	for i, s := range sheets {
+		texts[i] = s.Text
+	}
+
+	sep := ""
+	switch {
+	case o.SheetFormat == SheetFormatTSV || o.SheetFormat == SheetFormatCSV:
+		sep = "\f"
+	case o.TextFormat == TextFormatMarkdown:
+		sep = "\n"
+	}
+	return strings.Join(texts, sep)
+}
+
+// sheetMetadata adds the number of sheets of the workbook
+// and the number of its hidden sheets to the metadata
+func sheetMetadata(metadata map[string]string, wb *sheet.Workbook) map[string]string {
+	hidden := 0
+	for _, s := range wb.Sheets {
+		if s.Hidden {
+			hidden++
+		}
+	}
+	metadata["sheets"] = strconv.Itoa(len(wb.Sheets))
+	metadata["hidden-sheets"] = strconv.Itoa(hidden)
+
+	return metadata
+}
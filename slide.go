@@ -0,0 +1,119 @@
+package totext
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pilinux/totext/internal/odf"
+	"github.com/pilinux/totext/internal/pptx"
+	"github.com/pilinux/totext/internal/structure"
+)
+
+// Slide is the text content of a slide of a presentation
+type Slide struct {
+	// Number is the number of the slide in the presentation,
+	// starting at 1, the hidden slides included
+	Number int
+	// Title is the title of the slide
+	Title string
+	// Hidden is set for the slides which are not shown
+	Hidden bool
+	// Text is the text content of the slide, its title
+	// as a heading followed by the text of its shapes
+	Text string
+	// Notes is the text content of the speaker notes
+	// of the slide, it is set with WithSpeakerNotes
+	Notes string
+}
+
+// presentationSlides returns the text content of the slides of the
+// presentation read by read, selected with the pages of the options
+func presentationSlides(ctx context.Context, read func(ctx context.Context) (*structure.Document, error), o *Options) ([]Slide, *structure.Document, error) {
+	markdown, err := o.markdown()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	doc, err := read(ctx)
+	if ctx.Err() != nil {
+		return nil, nil, contextError(ctx)
+	}
+	if errors.Is(err, pptx.ErrEncrypted) || errors.Is(err, odf.ErrEncrypted) {
+		return nil, nil, fmt.Errorf("%w: %w", ErrEncrypted, err)
+	}
+	if err != nil {
+		return nil, nil, corruptError(err)
+	}
+
+	render, filter := blocksText, FilterNonReadableCharacter
+	if markdown {
+		render, filter = blocksMarkdown, filterMarkdown
+	}
+
+	var slides []Slide
+	for _, pr := range normalizePageRanges(o.Pages, len(doc.Slides)) {
+		for n := pr.First; n <= pr.Last; n++ {
+			s := doc.Slides[n-1]
+
+			var blocks []structure.Block
+			if s.Title != "" {
+				blocks = append(blocks, structure.Block{Kind: structure.Heading, Level: 1, Text: s.Title})
+			}
+			slide := Slide{
+				Number: n,
+				Title:  s.Title,
+				Hidden: s.Hidden,
+				Text:   filter(render(append(blocks, s.Body...))),
+			}
+			if o.SpeakerNotes {
+				slide.Notes = filter(strings.TrimLeft(render(s.Notes), " "))
+			}
+			slides = append(slides, slide)
+		}
+	}
+
+	return slides, doc, nil
+}
+
+// slidesText joins the text content of the slides, each one followed by
+// its speaker notes introduced by their label. The slides are separated
+// with a form feed with WithPageBreaks, or with a blank line in Markdown.
+func slidesText(slides []Slide, o *Options) string {
+	markdown := o.TextFormat == TextFormatMarkdown
+
+	texts := make([]string, len(slides))
+	for i, s := range slides {
+		sections := []Section{{Kind: SectionBody, Text: s.Text}}
+		if strings.TrimSpace(s.Notes) != "" {
+			sections = append(sections, Section{Kind: SectionNotes, Text: s.Notes})
+		}
+		texts[i] = sectionsText(sections, markdown)
+	}
+
+	sep := ""
+	switch {
+	case o.PageBreaks:
+		sep = "\f"
+	case markdown:
+		sep = "\n"
+	}
+	return strings.Join(texts, sep)
+}
+
+// slideMetadata adds the number of slides of the presentation
+// and the number of its hidden slides to the metadata
+func slideMetadata(metadata map[string]string, doc *structure.Document) map[string]string {
+	hidden := 0
+	for _, s := range doc.Slides {
+		if s.Hidden {
+			hidden++
+		}
+	}
+	metadata["slides"] = strconv.Itoa(len(doc.Slides))
+	metadata["hidden-slides"] = strconv.Itoa(hidden)
+
+	return metadata
+}
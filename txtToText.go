@@ -0,0 +1,103 @@
+package totext
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+func init() {
+	RegisterConverter(TXT, MimeTXT, ConverterFunc(ConvertTXTReaderToTextContext))
+}
+
+// ConvertTXTToText receives txt filepath as an argument and returns its text content and metadata
+//
+// The content is transcoded to UTF-8 from its charset, which is recorded
+// in the metadata, and normalized to NFC. The line endings are converted
+// to \n, the white space at the end of the lines and the control
+// characters other than tabs and form feeds are removed.
+func ConvertTXTToText(filepath string, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertTXTToTextContext(context.Background(), filepath, opts...)
+}
+
+// ConvertTXTToTextContext is like ConvertTXTToText but stops
+// the conversion when ctx is done
+func ConvertTXTToTextContext(ctx context.Context, filepath string, opts ...Option) (content string, metadata map[string]string, err error) {
+	// Get the txt file
+	txtFile, err := os.Open(filepath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer func() {
+		_ = txtFile.Close()
+	}()
+
+	// Convert txt to text
+	return ConvertTXTReaderToTextContext(ctx, txtFile, opts...)
+}
+
+// ConvertTXTReaderToText receives txt content as an io.Reader
+// and returns its text content and metadata
+//
+// The text content is cleaned up as with ConvertTXTToText.
+func ConvertTXTReaderToText(r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertTXTReaderToTextContext(context.Background(), r, opts...)
+}
+
+// ConvertTXTReaderToTextContext is like ConvertTXTReaderToText
+// but stops the conversion when ctx is done
+func ConvertTXTReaderToTextContext(ctx context.Context, r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	o := newOptions(opts...)
+	if _, err = o.markdown(); err != nil {
+		return "", nil, err
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", nil, err
+	}
+	if ctx.Err() != nil {
+		return "", nil, contextError(ctx)
+	}
+
+	data, charsetName, err := decodeCharset(data, false, o)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return cleanUpText(string(data)), map[string]string{"charset": charsetName}, nil
+}
+
+// cleanUpText normalizes the text to NFC, converts the line endings to
+// \n and removes the white space at the end of the lines and the control
+// characters other than tabs and form feeds. The text ends with a newline
+// unless it is empty.
+func cleanUpText(text string) string {
+	text = norm.NFC.String(strings.ToValidUTF8(text, "\uFFFD"))
+	text = strings.NewReplacer("\r\n", "\n", "\r", "\n", "\u2028", "\n", "\u2029", "\n").Replace(text)
+
+	var sb strings.Builder
+	sb.Grow(len(text))
+	for line := range strings.Lines(text) {
+		line = strings.TrimRightFunc(line, func(r rune) bool {
+			return unicode.IsSpace(r) && r != '\f'
+		})
+		for _, r := range line {
+			if !unicode.IsControl(r) || r == '\t' || r == '\f' {
+				sb.WriteRune(r)
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	// Blank lines at the end of the text
+	content := strings.TrimRight(sb.String(), "\n")
+	if content == "" {
+		return ""
+	}
+	return content + "\n"
+}
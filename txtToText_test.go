@@ -0,0 +1,41 @@
+package totext
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestConvertTXTReaderToText tests ConvertTXTReaderToText function
+func TestConvertTXTReaderToText(t *testing.T) {
+	// Test data
+	testData := []struct {
+		content  string
+		opts     []Option
+		expected string
+		charset  string
+	}{
+		{"line one  \r\nline two\rline three\t\n\n\n", nil, "line one\nline two\nline three\n", "utf-8"},
+		{"\uFEFFbom\x00 and \x1bcontrol\fpage\n", nil, "bom and control\fpage\n", "utf-8"},
+		{"cafe\u0301\u2028next\u2029last", nil, "café\nnext\nlast\n", "utf-8"},
+		{"\xff\xfeh\x00i\x00\r\x00\n\x00", nil, "hi\n", "utf-16le"},
+		{"caf\xe9 cr\xe8me", []Option{WithCharset("latin1")}, "café crème\n", "windows-1252"},
+		{" \n\t\n", nil, "", "utf-8"},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		content, metadata, err := ConvertTXTReaderToText(strings.NewReader(td.content), td.opts...)
+		if err != nil {
+			t.Errorf("Error converting %q: %s", td.content, err)
+			continue
+		}
+		if content != td.expected || metadata["charset"] != td.charset {
+			t.Errorf("Expected %q in %s, got %q in %s", td.expected, td.charset, content, metadata["charset"])
+		}
+	}
+
+	// Unknown charsets are errors
+	if _, _, err := ConvertTXTReaderToText(strings.NewReader("text"), WithCharset("unknown")); err == nil {
+		t.Errorf("Expected error for unknown charset")
+	}
+}
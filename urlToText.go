@@ -1,7 +1,9 @@
 package totext
 
 import (
+	"context"
 	"fmt"
+	"mime"
 	"net"
 	"net/http"
 	"net/url"
@@ -9,22 +11,31 @@ import (
 	"time"
 
 	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+
+	"github.com/pilinux/totext/internal/charset"
 )
 
 // ConvertURLToText fetches the HTML page at the URL given and returns its text content and metadata
 //
 // delayInSec: an additional delay in seconds which may be required for some web pages to load properly
-func ConvertURLToText(browser *rod.Browser, inputURL string, skipPrettifyError bool, delayInSec int) (htmlFilename, content string, metadata map[string]string, err error) {
+func ConvertURLToText(browser *rod.Browser, inputURL string, skipPrettifyError bool, delayInSec int, opts ...Option) (htmlFilename, content string, metadata map[string]string, err error) {
+	return ConvertURLToTextContext(context.Background(), browser, inputURL, skipPrettifyError, delayInSec, opts...)
+}
+
+// ConvertURLToTextContext is like ConvertURLToText but stops fetching
+// and converting the page when ctx is done
+func ConvertURLToTextContext(ctx context.Context, browser *rod.Browser, inputURL string, skipPrettifyError bool, delayInSec int, opts ...Option) (htmlFilename, content string, metadata map[string]string, err error) {
 	inputURL = strings.TrimSpace(inputURL)
 
 	// Parse the URL and validate it
-	u, err := ParseURLAndValidate(inputURL)
+	u, contentType, err := validateURL(ctx, inputURL)
 	if err != nil {
 		return
 	}
 
 	// Capture the HTML page
-	htmlContent, err := CaptureHTML(browser, inputURL, delayInSec)
+	htmlContent, err := CaptureHTMLContext(ctx, browser, inputURL, delayInSec)
 	if err != nil {
 		return
 	}
@@ -38,22 +49,35 @@ func ConvertURLToText(browser *rod.Browser, inputURL string, skipPrettifyError b
 		return
 	}
 
-	// Convert the HTML file to text
-	content, metadata, err = ConvertHTMLToText(htmlFilename, skipPrettifyError)
+	// Convert the HTML file to text, the browser serializes the page in UTF-8
+	content, metadata, err = ConvertHTMLToTextContext(ctx, htmlFilename, skipPrettifyError, append([]Option{WithCharset(charset.UTF8)}, opts...)...)
 	if err != nil {
 		return "", "", nil, err
 	}
 
-	// Filter out non-readable characters
-	content = FilterNonReadableCharacter(content)
+	// Report the charset the page was served with
+	if newOptions(opts...).Charset == "" {
+		metadata["charset"] = servedCharset(contentType, htmlContent)
+	}
+
+	// Filter out non-readable characters, Markdown is already filtered
+	if markdown, _ := newOptions(opts...).markdown(); !markdown {
+		content = FilterNonReadableCharacter(content)
+	}
 
 	return
 }
 
 // IsHostnameValid validates the hostname
 func IsHostnameValid(hostname string) bool {
+	return IsHostnameValidContext(context.Background(), hostname)
+}
+
+// IsHostnameValidContext is like IsHostnameValid but stops
+// the DNS lookup when ctx is done
+func IsHostnameValidContext(ctx context.Context, hostname string) bool {
 	// Perform a DNS lookup
-	_, err := net.LookupHost(hostname)
+	_, err := net.DefaultResolver.LookupHost(ctx, hostname)
 	return err == nil
 }
 
@@ -66,20 +90,36 @@ func IsContentTypeHTML(contentType string) bool {
 // ParseURLAndValidate parses the URL and validates
 // the scheme, hostname and content type
 func ParseURLAndValidate(inputURL string) (u *url.URL, err error) {
+	return ParseURLAndValidateContext(context.Background(), inputURL)
+}
+
+// ParseURLAndValidateContext is like ParseURLAndValidate but stops
+// the DNS lookup and the HTTP request when ctx is done
+func ParseURLAndValidateContext(ctx context.Context, inputURL string) (u *url.URL, err error) {
+	u, _, err = validateURL(ctx, inputURL)
+	return u, err
+}
+
+// validateURL parses the URL, validates it and returns the
+// Content-Type header of the page
+func validateURL(ctx context.Context, inputURL string) (u *url.URL, contentType string, err error) {
 	// Parse the URL
 	u, err = url.Parse(inputURL)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	// Check if the URL has a valid scheme (http or https)
 	if u.Scheme != "http" && u.Scheme != "https" {
-		return nil, fmt.Errorf("invalid scheme")
+		return nil, "", fmt.Errorf("%w: invalid scheme", ErrInvalidURL)
 	}
 
 	// Check if the URL has a valid hostname
-	if !IsHostnameValid(u.Hostname()) {
-		return nil, fmt.Errorf("invalid hostname")
+	if !IsHostnameValidContext(ctx, u.Hostname()) {
+		if ctx.Err() != nil {
+			return nil, "", contextError(ctx)
+		}
+		return nil, "", fmt.Errorf("%w: invalid hostname", ErrInvalidURL)
 	}
 
 	// Create an HTTP client with a timeout of 15 seconds
@@ -88,9 +128,16 @@ func ParseURLAndValidate(inputURL string) (u *url.URL, err error) {
 	}
 
 	// Make an HTTP HEAD request to check the content type
-	resp, err := client.Head(inputURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, inputURL, nil)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, "", contextError(ctx)
+		}
+		return nil, "", err
 	}
 	defer func() {
 		if e := resp.Body.Close(); e != nil && err == nil {
@@ -99,46 +146,93 @@ func ParseURLAndValidate(inputURL string) (u *url.URL, err error) {
 	}()
 
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("HTTP status code: %d", resp.StatusCode)
+		return nil, "", ErrHTTPStatus{Code: resp.StatusCode}
 	}
 
 	// Check if the content type is HTML
-	if !IsContentTypeHTML(resp.Header.Get("Content-Type")) {
-		return nil, fmt.Errorf("invalid content type")
+	contentType = resp.Header.Get("Content-Type")
+	if !IsContentTypeHTML(contentType) {
+		return nil, "", fmt.Errorf("%w: invalid content type", ErrUnsupportedFormat)
 	}
 
-	return u, nil
+	return u, contentType, nil
+}
+
+// servedCharset returns the charset the page was served with, which the
+// browser decoded it from. It is read from the Content-Type header and
+// detected from the page when the header declares none.
+func servedCharset(contentType, htmlContent string) string {
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		if name := charset.Name(params["charset"]); name != "" {
+			return name
+		}
+	}
+	return DetectCharset([]byte(htmlContent), true, "")
 }
 
 // CaptureHTML fetches the HTML page at the URL given and
 // returns the complete HTML content
 func CaptureHTML(browser *rod.Browser, inputURL string, delayInSec int) (content string, err error) {
-	// Create a new page and navigate to the URL
-	page := browser.MustPage(inputURL)
+	return CaptureHTMLContext(context.Background(), browser, inputURL, delayInSec)
+}
+
+// CaptureHTMLContext is like CaptureHTML but stops loading
+// the page when ctx is done. The page is always closed.
+func CaptureHTMLContext(ctx context.Context, browser *rod.Browser, inputURL string, delayInSec int) (content string, err error) {
+	// Create a new page, it is closed without ctx so that
+	// a cancelled context does not leave the page open
+	blankPage, err := browser.Page(proto.TargetCreateTarget{})
+	if err != nil {
+		return "", err
+	}
 	defer func() {
-		if e := page.Close(); e != nil && err == nil {
+		if e := blankPage.Close(); e != nil && err == nil {
 			err = e
 		}
 	}()
+	page := blankPage.Context(ctx)
+
+	// Navigate to the URL
+	if err = page.Navigate(inputURL); err != nil {
+		return "", captureError(ctx, err)
+	}
 
 	// Set a timeout of 30 seconds and wait for the page to load
-	page.Timeout(30 * time.Second).MustWaitLoad()
+	if err = page.Timeout(30 * time.Second).WaitLoad(); err != nil {
+		return "", captureError(ctx, err)
+	}
 
 	// Start to analyze request events
-	wait := page.MustWaitRequestIdle()
+	wait := page.WaitRequestIdle(300*time.Millisecond, nil, nil, nil)
 
 	// Wait until the page is idle
 	wait()
+	if ctx.Err() != nil {
+		return "", contextError(ctx)
+	}
 
 	// Add an additional delay in seconds which may be required for some web pages
-	time.Sleep(time.Duration(delayInSec) * time.Second)
+	if err = sleepContext(ctx, time.Duration(delayInSec)*time.Second); err != nil {
+		return "", err
+	}
 
 	// Get the HTML content
 	content, err = page.HTML()
+	if err != nil {
+		return "", captureError(ctx, err)
+	}
 
 	return
 }
 
+// captureError reports the context error if ctx is done
+func captureError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return contextError(ctx)
+	}
+	return err
+}
+
 // CreateHTMLFilename generates a filename for the HTML file from the URL
 func CreateHTMLFilename(u *url.URL) string {
 	// Get the hostname
@@ -26,3 +26,26 @@ func TestIsHostnameValid(t *testing.T) {
 		}
 	}
 }
+
+// TestServedCharset tests servedCharset function
+func TestServedCharset(t *testing.T) {
+	// Test data
+	testData := []struct {
+		contentType string
+		content     string
+		expected    string
+	}{
+		{"text/html; charset=Shift_JIS", `<meta charset="utf-8"><p>Hello</p>`, "shift_jis"},
+		{"text/html; charset=latin1", "<p>Hello</p>", "windows-1252"},
+		{"text/html", `<meta charset="euc-kr"><p>Hello</p>`, "euc-kr"},
+		{"text/html; charset=unknown", "<p>Hello</p>", "utf-8"},
+		{"", "<p>Hello</p>", "utf-8"},
+	}
+
+	// Iterate over test data
+	for _, data := range testData {
+		if name := servedCharset(data.contentType, data.content); name != data.expected {
+			t.Errorf("Expected charset %s for %q, got %s", data.expected, data.contentType, name)
+		}
+	}
+}
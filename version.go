@@ -1,5 +1,4 @@
-// Package totext uses https://github.com/sajari/docconv
-// to extract text from different file types.
+// Package totext extracts text from different file types.
 package totext
 
 import (
@@ -0,0 +1,114 @@
+package totext
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/pilinux/totext/internal/sheet"
+)
+
+func init() {
+	RegisterConverter(XLSX, MimeXLSX, ConverterFunc(ConvertXLSXReaderToTextContext))
+}
+
+// ConvertXLSXToText receives MS excel xlsx filepath as an argument
+// and returns its text content and metadata
+//
+// Each sheet is laid out as selected by WithSheetFormat, by default as
+// its name followed by a table of its cells as with ConvertDocxToText.
+// The cells hold their displayed value, e.g. the numbers and the dates
+// rendered with their number format, or their formula with WithFormulas.
+// The cells covered by a merged cell are empty. The hidden sheets are
+// left out unless WithHiddenSheets is given. The metadata holds the core
+// properties of the workbook and the number of its sheets.
+func ConvertXLSXToText(filepath string, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertXLSXToTextContext(context.Background(), filepath, opts...)
+}
+
+// ConvertXLSXToTextContext is like ConvertXLSXToText but stops
+// the conversion when ctx is done
+func ConvertXLSXToTextContext(ctx context.Context, filepath string, opts ...Option) (content string, metadata map[string]string, err error) {
+	// Get the xlsx file
+	xlsxFile, err := os.Open(filepath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer func() {
+		_ = xlsxFile.Close()
+	}()
+
+	// Convert xlsx to text
+	return ConvertXLSXReaderToTextContext(ctx, xlsxFile, opts...)
+}
+
+// ConvertXLSXReaderToText receives MS excel xlsx content as an io.Reader
+// and returns its text content and metadata
+//
+// The text content and the metadata are laid out as with ConvertXLSXToText.
+func ConvertXLSXReaderToText(r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	return ConvertXLSXReaderToTextContext(context.Background(), r, opts...)
+}
+
+// ConvertXLSXReaderToTextContext is like ConvertXLSXReaderToText
+// but stops the conversion when ctx is done
+func ConvertXLSXReaderToTextContext(ctx context.Context, r io.Reader, opts ...Option) (content string, metadata map[string]string, err error) {
+	sheets, metadata, err := ConvertXLSXReaderToSheetsContext(ctx, r, opts...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return sheetsText(sheets, newOptions(opts...)), metadata, nil
+}
+
+// ConvertXLSXToSheets receives MS excel xlsx filepath as an argument and
+// returns the text content of its sheets in the order of the workbook,
+// and its metadata
+func ConvertXLSXToSheets(filepath string, opts ...Option) (sheets []Sheet, metadata map[string]string, err error) {
+	return ConvertXLSXToSheetsContext(context.Background(), filepath, opts...)
+}
+
+// ConvertXLSXToSheetsContext is like ConvertXLSXToSheets but stops
+// the conversion when ctx is done
+func ConvertXLSXToSheetsContext(ctx context.Context, filepath string, opts ...Option) (sheets []Sheet, metadata map[string]string, err error) {
+	// Get the xlsx file
+	xlsxFile, err := os.Open(filepath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_ = xlsxFile.Close()
+	}()
+
+	// Convert xlsx to sheets
+	return ConvertXLSXReaderToSheetsContext(ctx, xlsxFile, opts...)
+}
+
+// ConvertXLSXReaderToSheets receives MS excel xlsx content as an io.Reader
+// and returns the text content of its sheets in the order of the workbook,
+// and its metadata
+func ConvertXLSXReaderToSheets(r io.Reader, opts ...Option) (sheets []Sheet, metadata map[string]string, err error) {
+	return ConvertXLSXReaderToSheetsContext(context.Background(), r, opts...)
+}
+
+// ConvertXLSXReaderToSheetsContext is like ConvertXLSXReaderToSheets
+// but stops the conversion when ctx is done
+func ConvertXLSXReaderToSheetsContext(ctx context.Context, r io.Reader, opts ...Option) (sheets []Sheet, metadata map[string]string, err error) {
+	if ctx.Err() != nil {
+		return nil, nil, contextError(ctx)
+	}
+
+	ra, err := sectionReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sheets, wb, err := workbookSheets(ctx, func(ctx context.Context) (*sheet.Workbook, error) {
+		return sheet.ReadXLSX(ctx, ra, ra.Size())
+	}, newOptions(opts...))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return sheets, sheetMetadata(docxMetadata(wb.Properties), wb), nil
+}
@@ -0,0 +1,145 @@
+package totext
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pilinux/totext/internal/fixture"
+)
+
+// xlsxWorksheet returns a worksheet with the rows
+func xlsxWorksheet(rows string) string {
+	return `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + rows + `</sheetData></worksheet>`
+}
+
+// xlsxEntries are the entries of an xlsx workbook with
+// a visible and a hidden sheet
+var xlsxEntries = map[string]string{
+	"[Content_Types].xml": `<Types><Override ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/></Types>`,
+	"_rels/.rels": opcRels(opcRel("rId1", "officeDocument/2006/relationships/officeDocument", "xl/workbook.xml"),
+		opcRel("rId2", "package/2006/relationships/metadata/core-properties", "docProps/core.xml")),
+	"docProps/core.xml": opcCore(`<dc:title>Budget</dc:title><dc:creator>Jane Doe</dc:creator>` +
+		`<dcterms:created>2021-03-04T05:06:07Z</dcterms:created>`),
+	"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>` +
+		`<sheet name="Plan" r:id="rId1"/><sheet name="Secret" state="hidden" r:id="rId2"/></sheets></workbook>`,
+	"xl/_rels/workbook.xml.rels": opcRels(opcRel("rId1", "officeDocument/2006/relationships/worksheet", "worksheets/sheet1.xml"),
+		opcRel("rId2", "officeDocument/2006/relationships/worksheet", "worksheets/sheet2.xml"),
+		opcRel("rId3", "officeDocument/2006/relationships/styles", "styles.xml")),
+	"xl/styles.xml": `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<cellXfs><xf numFmtId="0"/><xf numFmtId="4"/></cellXfs></styleSheet>`,
+	"xl/worksheets/sheet1.xml": xlsxWorksheet(`<row r="1"><c r="A1" t="inlineStr"><is><t>Item</t></is></c>` +
+		`<c r="B1" t="inlineStr"><is><t>Price, EUR</t></is></c></row>` +
+		`<row r="2"><c r="A2" t="inlineStr"><is><t>Rent</t></is></c><c r="B2" s="1"><v>1234.5</v></c></row>` +
+		`<row r="3"><c r="B3" s="1"><f>SUM(B2:B2)</f><v>1234.5</v></c></row>`),
+	"xl/worksheets/sheet2.xml": xlsxWorksheet(`<row r="1"><c r="A1" t="inlineStr"><is><t>Key</t></is></c><c r="B1"><v>42</v></c></row>`),
+}
+
+// TestConvertXLSXReaderToText tests the layouts of the sheets of a workbook
+func TestConvertXLSXReaderToText(t *testing.T) {
+	data := fixture.Zip(t, xlsxEntries)
+
+	// Test data
+	testData := []struct {
+		opts     []Option
+		expected string
+	}{
+		{nil, "# Plan\nItem | Price, EUR\nRent | 1,234.50\n | 1,234.50\n"},
+		{[]Option{WithTextFormat(TextFormatMarkdown)},
+			"# Plan\n\n| Item | Price, EUR |\n| --- | --- |\n| Rent | 1,234.50 |\n|  | 1,234.50 |\n"},
+		{[]Option{WithSheetFormat(SheetFormatTSV), WithFormulas(true)}, "Item\tPrice, EUR\nRent\t1,234.50\n\t=SUM(B2:B2)\n"},
+		{[]Option{WithSheetFormat(SheetFormatCSV), WithHiddenSheets(true)},
+			"Item,\"Price, EUR\"\nRent,\"1,234.50\"\n,\"1,234.50\"\n\fKey,42\n"},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		content, _, err := ConvertReader(bytes.NewReader(data), XLSX, td.opts...)
+		if err != nil {
+			t.Fatalf("Error converting XLSX: %s", err)
+		}
+		if content != td.expected {
+			t.Errorf("Expected content %q, got %q", td.expected, content)
+		}
+	}
+
+	// Compare metadata
+	_, metadata, err := ConvertXLSXReaderToText(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Error converting XLSX: %s", err)
+	}
+	m := NewMetadata(metadata, nil)
+	if m.Title != "Budget" || strings.Join(m.Authors, "|") != "Jane Doe" ||
+		metadata["sheets"] != "2" || metadata["hidden-sheets"] != "1" {
+		t.Errorf("Unexpected metadata %v", metadata)
+	}
+	if m.Created.Format("2006-01-02") != "2021-03-04" {
+		t.Errorf("Unexpected creation date %s", m.Created)
+	}
+
+	if _, _, err = ConvertXLSXReaderToText(bytes.NewReader(data), WithSheetFormat("xml")); err == nil {
+		t.Error("Expected an error for an unknown sheet format")
+	}
+}
+
+// TestConvertXLSXReaderToSheets tests the sheets of a workbook
+func TestConvertXLSXReaderToSheets(t *testing.T) {
+	sheets, _, err := ConvertXLSXReaderToSheets(bytes.NewReader(fixture.Zip(t, xlsxEntries)),
+		WithHiddenSheets(true), WithSheetFormat(SheetFormatTSV))
+	if err != nil {
+		t.Fatalf("Error converting XLSX: %s", err)
+	}
+
+	// Test data
+	testData := []Sheet{
+		{1, "Plan", false, "Item\tPrice, EUR\nRent\t1,234.50\n\t1,234.50\n"},
+		{2, "Secret", true, "Key\t42\n"},
+	}
+
+	if len(sheets) != len(testData) {
+		t.Fatalf("Expected %d sheets, got %d", len(testData), len(sheets))
+	}
+
+	// Iterate over test data
+	for i, expected := range testData {
+		if sheets[i] != expected {
+			t.Errorf("Expected sheet %+v, got %+v", expected, sheets[i])
+		}
+	}
+}
+
+// TestConvertXLSXFarColumn tests that the cells far to the right
+// are left out with a warning
+func TestConvertXLSXFarColumn(t *testing.T) {
+	data := fixture.Zip(t, xlsxEntries, map[string]string{
+		"xl/worksheets/sheet1.xml": xlsxWorksheet(`<row r="1"><c r="A1" t="inlineStr"><is><t>Item</t></is></c>` +
+			`<c r="B1" t="inlineStr"><is><t>Price</t></is></c></row>` +
+			`<row r="2"><c r="A2" t="inlineStr"><is><t>Rent</t></is></c><c r="AMJ2"><v>1</v></c></row>` +
+			`<row r="5"><c r="XFD5" t="inlineStr"><is><t>Stray</t></is></c></row>`),
+	})
+
+	// Test data
+	testData := []struct {
+		format   SheetFormat
+		expected string
+	}{
+		{SheetFormatText, "# Plan\nItem | Price\nRent" + strings.Repeat(" | ", 1022) + " | 1\n"},
+		{SheetFormatCSV, "Item,Price" + strings.Repeat(",", 1022) + "\nRent" + strings.Repeat(",", 1023) + "1\n"},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		var warnings []string
+		content, _, err := ConvertReader(bytes.NewReader(data), XLSX, WithSheetFormat(td.format), WithWarnings(&warnings))
+		if err != nil {
+			t.Fatalf("Error converting XLSX: %s", err)
+		}
+		if content != td.expected {
+			t.Errorf("Expected %s content of %d bytes, got %d bytes", td.format, len(td.expected), len(content))
+		}
+		if len(warnings) != 1 {
+			t.Errorf("Expected a warning, got %q", warnings)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package totext
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/pilinux/totext/internal/fixture"
+)
+
+// opcRel returns a relationship of an OPC package, typ is the part of
+// the type following http://schemas.openxmlformats.org/
+func opcRel(id, typ, target string) string {
+	return `<Relationship Id="` + id + `" Type="http://schemas.openxmlformats.org/` + typ + `" Target="` + target + `"/>`
+}
+
+// opcRels returns a relationships part with the relationships
+func opcRels(rels ...string) string {
+	return `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		strings.Join(rels, "") + `</Relationships>`
+}
+
+// opcCore returns a core properties part with the properties
+func opcCore(properties string) string {
+	return `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" ` +
+		`xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/">` +
+		properties + `</cp:coreProperties>`
+}
+
+// odfEncrypted is the manifest of an OpenDocument package
+// with an encrypted content.xml
+var odfEncrypted = map[string]string{
+	"META-INF/manifest.xml": `<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0">` +
+		`<manifest:file-entry manifest:full-path="content.xml"><manifest:encryption-data/></manifest:file-entry>` +
+		`</manifest:manifest>`,
+}
+
+// cfbContent is an empty compound file, the container of
+// encrypted OOXML packages
+var cfbContent = append([]byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}, make([]byte, 504)...)
+
+// TestConvertZipErrors tests the errors of the converters of the
+// ZIP based formats for encrypted and invalid content
+func TestConvertZipErrors(t *testing.T) {
+	// Test data
+	testData := []struct {
+		name     string
+		convert  func(io.Reader, ...Option) (string, map[string]string, error)
+		content  []byte
+		expected error
+	}{
+		{"epub encrypted", ConvertEPUBReaderToText, fixture.Zip(t, epubEntries, map[string]string{
+			"META-INF/encryption.xml": `<encryption xmlns="urn:oasis:names:tc:opendocument:xmlns:container" ` +
+				`xmlns:enc="http://www.w3.org/2001/04/xmlenc#"><enc:EncryptedData>` +
+				`<enc:EncryptionMethod Algorithm="http://www.w3.org/2001/04/xmlenc#aes128-cbc"/>` +
+				`<enc:CipherData><enc:CipherReference URI="OPS/a.xhtml"/></enc:CipherData></enc:EncryptedData></encryption>`,
+		}), ErrEncrypted},
+		{"epub not a zip", ConvertEPUBReaderToText, []byte("not a zip"), ErrCorrupt},
+		{"xlsx encrypted", ConvertXLSXReaderToText, cfbContent, ErrEncrypted},
+		{"xlsx not a zip", ConvertXLSXReaderToText, []byte("not a zip"), ErrCorrupt},
+		{"ods encrypted", ConvertODSReaderToText, fixture.Zip(t, odsEntries, odfEncrypted), ErrEncrypted},
+		{"ods malformed", ConvertODSReaderToText, fixture.Zip(t, odsEntries, map[string]string{
+			"content.xml": "<office:document-content><table:table>",
+		}), ErrCorrupt},
+		{"pptx encrypted", ConvertPPTXReaderToText, cfbContent, ErrEncrypted},
+		{"pptx not a zip", ConvertPPTXReaderToText, []byte("not a zip"), ErrCorrupt},
+		{"odp encrypted", ConvertODPReaderToText, fixture.Zip(t, odpEntries, odfEncrypted), ErrEncrypted},
+		{"odp malformed", ConvertODPReaderToText, fixture.Zip(t, odpEntries, map[string]string{
+			"content.xml": "<office:document-content><draw:page>",
+		}), ErrCorrupt},
+	}
+
+	// Iterate over test data
+	for _, td := range testData {
+		if _, _, err := td.convert(bytes.NewReader(td.content)); !errors.Is(err, td.expected) {
+			t.Errorf("%s: expected %v, got %v", td.name, td.expected, err)
+		}
+	}
+}